@@ -37,6 +37,8 @@ type InstanceTypeOptions struct {
 	OperatingSystems   sets.Set[string]
 	Resources          v1.ResourceList
 	InstanceTypeLabels map[string]string
+	LocalNVMe          resource.Quantity
+	NUMANodes          int
 }
 
 func MakeInstanceTypeLabels(cpu, memFactor int) map[string]string {
@@ -115,6 +117,8 @@ func newInstanceType(options InstanceTypeOptions) *cloudprovider.InstanceType {
 		scheduling.NewRequirement(InstanceFamilyLabelKey, v1.NodeSelectorOpIn, options.InstanceTypeLabels[InstanceFamilyLabelKey]),
 		scheduling.NewRequirement(InstanceCPULabelKey, v1.NodeSelectorOpIn, options.InstanceTypeLabels[InstanceCPULabelKey]),
 		scheduling.NewRequirement(InstanceMemoryLabelKey, v1.NodeSelectorOpIn, options.InstanceTypeLabels[InstanceMemoryLabelKey]),
+		cloudprovider.LocalNVMeRequirement(options.LocalNVMe),
+		cloudprovider.NUMANodesRequirement(options.NUMANodes),
 	)
 
 	return &cloudprovider.InstanceType{
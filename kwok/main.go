@@ -19,6 +19,7 @@ package main
 import (
 	kwok "sigs.k8s.io/karpenter/kwok/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/ratelimit"
 	"sigs.k8s.io/karpenter/pkg/controllers"
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/operator"
@@ -37,7 +38,7 @@ func init() {
 func main() {
 	ctx, op := operator.NewOperator()
 
-	cloudProvider := kwok.NewCloudProvider(ctx, op.GetClient(), kwok.ConstructInstanceTypes())
+	cloudProvider := ratelimit.Decorate(kwok.NewCloudProvider(ctx, op.GetClient(), kwok.ConstructInstanceTypes()), ratelimit.NewRateLimiter(ctx))
 	op.
 		WithControllers(ctx, controllers.NewControllers(
 			op.Clock,
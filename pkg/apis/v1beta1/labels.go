@@ -38,15 +38,56 @@ const (
 	NodeInitializedLabelKey = Group + "/initialized"
 	NodeRegisteredLabelKey  = Group + "/registered"
 	CapacityTypeLabelKey    = Group + "/capacity-type"
+	// ProvisionerNameLabelKey is the label that the nodes of the legacy Provisioner API were labeled with. It's only set
+	// on nodes, to the name of their NodePool, when the legacy provisioner name label is enabled during a migration.
+	ProvisionerNameLabelKey = Group + "/provisioner-name"
+	// InstanceLocalNVMeLabelKey is the total size, in GiB, of the local NVMe instance storage of an instance type.
+	// Instance types without local NVMe define it with the DoesNotExist operator, so that pods requiring local storage
+	// through this label are only scheduled to instance types that provide it.
+	InstanceLocalNVMeLabelKey = Group + "/instance-local-nvme"
+	// NUMANodesLabelKey is the number of NUMA nodes of an instance type. Pods can require a minimum number of NUMA
+	// nodes, or of sockets on providers where each socket is a NUMA node, with the Gt operator. Instance types whose
+	// NUMA topology is unknown define it with the DoesNotExist operator.
+	NUMANodesLabelKey = Group + "/numa-nodes"
 )
 
 // Karpenter specific annotations
 const (
-	DoNotDisruptAnnotationKey          = Group + "/do-not-disrupt"
+	DoNotDisruptAnnotationKey = Group + "/do-not-disrupt"
+	// DoNotConsolidateAnnotationKey blocks consolidation of a node, including emptiness, when set to "true" while still
+	// allowing drift and expiration to disrupt it. DoNotDisruptAnnotationKey takes precedence and blocks all voluntary
+	// disruption of the node regardless of this annotation.
+	DoNotConsolidateAnnotationKey      = Group + "/do-not-consolidate"
 	ProviderCompatabilityAnnotationKey = CompatabilityGroup + "/provider"
 	ManagedByAnnotationKey             = Group + "/managed-by"
 	NodePoolHashAnnotationKey          = Group + "/nodepool-hash"
 	NodePoolHashVersionAnnotationKey   = Group + "/nodepool-hash-version"
+	// DisruptionReasonAnnotationKey and DisruptionDecisionTimeAnnotationKey are set on a node when Karpenter begins
+	// disrupting it so that external tooling can attribute the disruption
+	DisruptionReasonAnnotationKey       = Group + "/disruption-reason"
+	DisruptionDecisionTimeAnnotationKey = Group + "/disruption-decision-time"
+	// DisruptionApprovedAnnotationKey is set to "true" on a node to approve its disruption when running in manual
+	// disruption mode, where only the approved nodes are disrupted. The approval is removed once it's been used.
+	DisruptionApprovedAnnotationKey = Group + "/disruption-approved"
+	// ArchitectureAnnotationKey is set on a pod to the comma separated kubernetes.io/arch values its images are built
+	// for, so that Karpenter doesn't launch or schedule it onto a node of another architecture when it doesn't select
+	// one itself. The kube-scheduler ignores the annotation, so it's a hint to Karpenter and not a placement guarantee.
+	ArchitectureAnnotationKey = Group + "/architecture"
+	// FeatureGatesAnnotationKey is set on a NodePool to a comma separated list of feature gates, e.g.
+	// "SpotToSpotConsolidation=true", that override the global feature gates for that NodePool. Only the Drift,
+	// SpotToSpotConsolidation and PodRebalancing gates can be overridden, as the others apply to the whole cluster.
+	FeatureGatesAnnotationKey = Group + "/feature-gates"
+	// ForceDriftCheckAnnotationKey is set on a NodePool or a NodeClaim to re-evaluate the drift of its NodeClaims right
+	// away, e.g. after updating a NodeClass. The annotation is removed once drift has been re-evaluated.
+	ForceDriftCheckAnnotationKey = Group + "/force-drift-check"
+	// ExpirationPausedAnnotationKey is set on a NodePool to temporarily stop its NodeClaims from expiring. Expiration
+	// resumes once the annotation is removed; consolidation and drift are unaffected.
+	ExpirationPausedAnnotationKey = Group + "/expiration-paused"
+	// DedicatedAnnotationKey is set to "true" on a pod to have it scheduled to a node that runs no other pods aside from
+	// DaemonSet pods. The pod must also tolerate the DedicatedNoScheduleTaint, which Karpenter adds to the NodeClaims
+	// that it launches for such pods so that kube-scheduler doesn't place other pods on them either. Pods that don't
+	// tolerate the taint are rejected, and DaemonSets must tolerate it to run on these nodes.
+	DedicatedAnnotationKey = Group + "/dedicated"
 )
 
 // Karpenter specific finalizers
@@ -81,6 +122,8 @@ var (
 		v1.LabelArchStable,
 		v1.LabelOSStable,
 		CapacityTypeLabelKey,
+		InstanceLocalNVMeLabelKey,
+		NUMANodesLabelKey,
 		v1.LabelWindowsBuild,
 	)
 
@@ -90,6 +133,13 @@ var (
 		v1.LabelHostname,
 	)
 
+	// NodeClaimOnlyAnnotations are annotations that Karpenter uses to track the state of a NodeClaim, so they aren't
+	// propagated to its Node along with the rest of its annotations.
+	NodeClaimOnlyAnnotations = sets.New(
+		NodePoolHashAnnotationKey,
+		NodePoolHashVersionAnnotationKey,
+	)
+
 	// NormalizedLabels translate aliased concepts into the controller's
 	// WellKnownLabels. Pod requirements are translated for compatibility.
 	NormalizedLabels = map[string]string{
@@ -53,6 +53,23 @@ type NodeClaimSpec struct {
 	// NodeClassRef is a reference to an object that defines provider specific configuration
 	// +required
 	NodeClassRef *NodeClassReference `json:"nodeClassRef"`
+	// AllowedNamespaces selects, by namespace labels, the namespaces whose pods may be scheduled to this capacity.
+	// Karpenter doesn't schedule the pods from namespaces that aren't selected to this capacity, or launch capacity for
+	// them, regardless of the tolerations they define. This isn't enforced by kube-scheduler, which can still bind pods
+	// from any namespace to the nodes, so taint the capacity to keep other pods off of it. Namespace labels are
+	// cached for up to a minute. If unset, pods from all namespaces are allowed.
+	// +optional
+	AllowedNamespaces *metav1.LabelSelector `json:"allowedNamespaces,omitempty" hash:"ignore"`
+	// MaxInstanceResources caps the capacity, e.g. cpu and memory, of the instance types that are launched for this
+	// capacity. Instance types with more of any of these resources are never launched, so pods that only fit on them
+	// are left pending instead of launching an oversized node. If unset, instance types of any size may be launched.
+	// +optional
+	MaxInstanceResources v1.ResourceList `json:"maxInstanceResources,omitempty" hash:"ignore"`
+	// TagLabels maps label keys to the keys of the cloud provider tags that their values are applied as when an instance
+	// is launched, e.g. to join instances to the workloads that run on them. Only labels that are known when the instance
+	// is launched are tagged. Cloud providers that don't support tagging instances ignore it.
+	// +optional
+	TagLabels map[string]string `json:"tagLabels,omitempty" hash:"ignore"`
 }
 
 // A node selector requirement with min values is a selector that contains values, a key, an operator that relates the key and values
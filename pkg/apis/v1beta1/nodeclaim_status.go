@@ -64,6 +64,19 @@ var (
 	Empty       apis.ConditionType = "Empty"
 	Drifted     apis.ConditionType = "Drifted"
 	Expired     apis.ConditionType = "Expired"
+	// MaintenanceScheduled is set when the cloud provider has scheduled maintenance for the NodeClaim's instance
+	MaintenanceScheduled apis.ConditionType = "MaintenanceScheduled"
+	// Unhealthy is set when the NodeClaim's node has been NotReady for longer than its NodePool's unhealthyAfter
+	Unhealthy apis.ConditionType = "Unhealthy"
+)
+
+// Reasons set on the Launched condition when a launch fails, derived from the type of error returned by the CloudProvider
+const (
+	LaunchFailedInsufficientCapacity = "InsufficientCapacity"
+	LaunchFailedRateLimited          = "RateLimited"
+	LaunchFailedNodeClassNotReady    = "NodeClassNotReady"
+	LaunchFailedUnauthorized         = "Unauthorized"
+	LaunchFailedUnknown              = "Unknown"
 )
 
 func (in *NodeClaim) GetConditions() apis.Conditions {
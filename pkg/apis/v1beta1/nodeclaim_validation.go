@@ -27,6 +27,7 @@ import (
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation"
 	"knative.dev/pkg/apis"
@@ -79,10 +80,45 @@ func (in *NodeClaimSpec) validate() (errs *apis.FieldError) {
 	return errs.Also(
 		in.validateTaints(),
 		in.validateRequirements(),
+		in.validateRequirementsSatisfiable(),
+		in.validateAllowedNamespaces(),
+		in.validateMaxInstanceResources(),
+		in.validateTagLabels(),
 		in.Kubelet.validate().ViaField("kubeletConfiguration"),
 	)
 }
 
+func (in *NodeClaimSpec) validateAllowedNamespaces() (errs *apis.FieldError) {
+	if in.AllowedNamespaces == nil {
+		return nil
+	}
+	if _, err := metav1.LabelSelectorAsSelector(in.AllowedNamespaces); err != nil {
+		return apis.ErrInvalidValue(in.AllowedNamespaces.String(), "allowedNamespaces", err.Error())
+	}
+	return nil
+}
+
+func (in *NodeClaimSpec) validateMaxInstanceResources() (errs *apis.FieldError) {
+	for name, quantity := range in.MaxInstanceResources {
+		if quantity.Sign() <= 0 {
+			errs = errs.Also(apis.ErrInvalidValue(quantity.String(), fmt.Sprintf("maxInstanceResources[%s]", name), "must be positive"))
+		}
+	}
+	return errs
+}
+
+func (in *NodeClaimSpec) validateTagLabels() (errs *apis.FieldError) {
+	for key, tag := range in.TagLabels {
+		for _, err := range validation.IsQualifiedName(key) {
+			errs = errs.Also(apis.ErrInvalidKeyName(key, "tagLabels", err))
+		}
+		if tag == "" {
+			errs = errs.Also(apis.ErrInvalidValue(tag, fmt.Sprintf("tagLabels[%s]", key), "must not be empty"))
+		}
+	}
+	return errs
+}
+
 type taintKeyEffect struct {
 	OwnerKey string
 	Effect   v1.TaintEffect
@@ -141,6 +177,54 @@ func (in *NodeClaimSpec) validateRequirements() (errs *apis.FieldError) {
 	return errs
 }
 
+// validateRequirementsSatisfiable rejects requirements on well known labels that can never be satisfied together, e.g.
+// requiring both In [arm64] and In [amd64] for the architecture, since they silently leave no compatible instance types.
+func (in *NodeClaimSpec) validateRequirementsSatisfiable() (errs *apis.FieldError) {
+	var keys []string
+	allowed := map[string]sets.Set[string]{}
+	disallowed := map[string]sets.Set[string]{}
+	exists := sets.New[string]()
+	doesNotExist := sets.New[string]()
+	for _, requirement := range in.Requirements {
+		key := requirement.Key
+		if normalized, ok := NormalizedLabels[key]; ok {
+			key = normalized
+		}
+		if !WellKnownLabels.Has(key) {
+			continue
+		}
+		if _, ok := disallowed[key]; !ok {
+			keys = append(keys, key)
+			disallowed[key] = sets.New[string]()
+		}
+		switch requirement.Operator {
+		case v1.NodeSelectorOpIn:
+			exists.Insert(key)
+			if values, ok := allowed[key]; ok {
+				allowed[key] = values.Intersection(sets.New(requirement.Values...))
+			} else {
+				allowed[key] = sets.New(requirement.Values...)
+			}
+		case v1.NodeSelectorOpNotIn:
+			disallowed[key].Insert(requirement.Values...)
+		case v1.NodeSelectorOpExists:
+			exists.Insert(key)
+		case v1.NodeSelectorOpDoesNotExist:
+			doesNotExist.Insert(key)
+		}
+	}
+	for _, key := range keys {
+		if exists.Has(key) && doesNotExist.Has(key) {
+			errs = errs.Also(apis.ErrGeneric(fmt.Sprintf("requirements for key %s can't be satisfied, the key is required to both exist and not exist", key), "requirements"))
+			continue
+		}
+		if values, ok := allowed[key]; ok && values.Difference(disallowed[key]).Len() == 0 {
+			errs = errs.Also(apis.ErrGeneric(fmt.Sprintf("requirements for key %s can't be satisfied, no value is allowed by all of its requirements", key), "requirements"))
+		}
+	}
+	return errs
+}
+
 func ValidateRequirement(requirement NodeSelectorRequirementWithMinValues) error { //nolint:gocyclo
 	var errs error
 	if normalized, ok := NormalizedLabels[requirement.Key]; ok {
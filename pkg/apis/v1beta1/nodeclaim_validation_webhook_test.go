@@ -29,6 +29,7 @@ import (
 	. "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
@@ -148,6 +149,53 @@ var _ = Describe("Validation", func() {
 			}
 			Expect(nodeClaim.Validate(ctx)).To(Succeed())
 		})
+		DescribeTable("should fail for contradictory requirements on well known labels",
+			func(key string, first, second []string) {
+				nodeClaim.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
+					{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: key, Operator: v1.NodeSelectorOpIn, Values: first}},
+					{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: key, Operator: v1.NodeSelectorOpIn, Values: second}},
+				}
+				Expect(nodeClaim.Validate(ctx)).ToNot(Succeed())
+			},
+			Entry("arch", v1.LabelArchStable, []string{ArchitectureArm64}, []string{ArchitectureAmd64}),
+			Entry("os", v1.LabelOSStable, []string{string(v1.Linux)}, []string{string(v1.Windows)}),
+			Entry("capacity-type", CapacityTypeLabelKey, []string{CapacityTypeSpot}, []string{CapacityTypeOnDemand}),
+		)
+		It("should fail when a NotIn requirement excludes every value of an In requirement", func() {
+			nodeClaim.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelArchStable, Operator: v1.NodeSelectorOpIn, Values: []string{ArchitectureArm64}}},
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelArchStable, Operator: v1.NodeSelectorOpNotIn, Values: []string{ArchitectureArm64}}},
+			}
+			Expect(nodeClaim.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail when a well known label is required to both exist and not exist", func() {
+			nodeClaim.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: CapacityTypeLabelKey, Operator: v1.NodeSelectorOpExists}},
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: CapacityTypeLabelKey, Operator: v1.NodeSelectorOpDoesNotExist}},
+			}
+			Expect(nodeClaim.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail for contradictory requirements that use a normalized label", func() {
+			nodeClaim.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelArchStable, Operator: v1.NodeSelectorOpIn, Values: []string{ArchitectureArm64}}},
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: "beta.kubernetes.io/arch", Operator: v1.NodeSelectorOpIn, Values: []string{ArchitectureAmd64}}},
+			}
+			Expect(nodeClaim.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should allow overlapping requirements on well known labels", func() {
+			nodeClaim.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelArchStable, Operator: v1.NodeSelectorOpIn, Values: []string{ArchitectureArm64, ArchitectureAmd64}}},
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelArchStable, Operator: v1.NodeSelectorOpIn, Values: []string{ArchitectureAmd64}}},
+			}
+			Expect(nodeClaim.Validate(ctx)).To(Succeed())
+		})
+		It("should allow contradictory requirements on custom labels", func() {
+			nodeClaim.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: "custom-label", Operator: v1.NodeSelectorOpIn, Values: []string{"a"}}},
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: "custom-label", Operator: v1.NodeSelectorOpIn, Values: []string{"b"}}},
+			}
+			Expect(nodeClaim.Validate(ctx)).To(Succeed())
+		})
 		It("should allow empty requirements", func() {
 			nodeClaim.Spec.Requirements = []NodeSelectorRequirementWithMinValues{}
 			Expect(nodeClaim.Validate(ctx)).To(Succeed())
@@ -174,6 +222,56 @@ var _ = Describe("Validation", func() {
 			Expect(nodeClaim.Validate(ctx)).ToNot(Succeed())
 		})
 	})
+	Context("AllowedNamespaces", func() {
+		It("should succeed for a valid namespace selector", func() {
+			nodeClaim.Spec.AllowedNamespaces = &metav1.LabelSelector{
+				MatchLabels: map[string]string{"kubernetes.io/metadata.name": "kube-system"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "team", Operator: metav1.LabelSelectorOpIn, Values: []string{"monitoring"}},
+				},
+			}
+			Expect(nodeClaim.Validate(ctx)).To(Succeed())
+		})
+		It("should fail for a namespace selector with an invalid operator", func() {
+			nodeClaim.Spec.AllowedNamespaces = &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "team", Operator: "Gt", Values: []string{"1"}},
+				},
+			}
+			Expect(nodeClaim.Validate(ctx)).ToNot(Succeed())
+		})
+	})
+	Context("MaxInstanceResources", func() {
+		It("should succeed for positive resource quantities", func() {
+			nodeClaim.Spec.MaxInstanceResources = v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("16"),
+				v1.ResourceMemory: resource.MustParse("64Gi"),
+			}
+			Expect(nodeClaim.Validate(ctx)).To(Succeed())
+		})
+		It("should fail for a zero resource quantity", func() {
+			nodeClaim.Spec.MaxInstanceResources = v1.ResourceList{v1.ResourceCPU: resource.MustParse("0")}
+			Expect(nodeClaim.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail for a negative resource quantity", func() {
+			nodeClaim.Spec.MaxInstanceResources = v1.ResourceList{v1.ResourceMemory: resource.MustParse("-1Gi")}
+			Expect(nodeClaim.Validate(ctx)).ToNot(Succeed())
+		})
+	})
+	Context("TagLabels", func() {
+		It("should succeed for valid label keys", func() {
+			nodeClaim.Spec.TagLabels = map[string]string{"team": "Team", v1.LabelTopologyZone: "zone"}
+			Expect(nodeClaim.Validate(ctx)).To(Succeed())
+		})
+		It("should fail for an invalid label key", func() {
+			nodeClaim.Spec.TagLabels = map[string]string{"not a label": "Team"}
+			Expect(nodeClaim.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail for an empty tag key", func() {
+			nodeClaim.Spec.TagLabels = map[string]string{"team": ""}
+			Expect(nodeClaim.Validate(ctx)).ToNot(Succeed())
+		})
+	})
 	Context("Kubelet", func() {
 		It("should fail on kubeReserved with invalid keys", func() {
 			nodeClaim.Spec.Kubelet = &KubeletConfiguration{
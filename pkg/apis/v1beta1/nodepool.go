@@ -22,6 +22,7 @@ import (
 	"math"
 	"sort"
 	"strconv"
+	"time"
 
 	"github.com/mitchellh/hashstructure/v2"
 	"github.com/robfig/cron/v3"
@@ -47,11 +48,39 @@ type NodePoolSpec struct {
 	// +kubebuilder:default={"consolidationPolicy": "WhenUnderutilized", "expireAfter": "720h"}
 	// +kubebuilder:validation:XValidation:message="consolidateAfter cannot be combined with consolidationPolicy=WhenUnderutilized",rule="has(self.consolidateAfter) ? self.consolidationPolicy != 'WhenUnderutilized' || self.consolidateAfter == 'Never' : true"
 	// +kubebuilder:validation:XValidation:message="consolidateAfter must be specified with consolidationPolicy=WhenEmpty",rule="self.consolidationPolicy == 'WhenEmpty' ? has(self.consolidateAfter) : true"
+	// +kubebuilder:validation:XValidation:message="underutilizationThreshold can only be combined with consolidationPolicy=WhenUnderutilized",rule="has(self.underutilizationThreshold) ? self.consolidationPolicy == 'WhenUnderutilized' : true"
 	// +optional
 	Disruption Disruption `json:"disruption"`
 	// Limits define a set of bounds for provisioning capacity.
+	// Any resource name can be limited, including extended resources, and is compared against the sum of
+	// the capacity of the nodes launched by this NodePool. The "nodes" resource limits the number of nodes
+	// launched by this NodePool, including nodes that are still launching.
 	// +optional
 	Limits Limits `json:"limits,omitempty"`
+	// MinNodes is the number of nodes that this NodePool keeps running as warm capacity, even when there are no pods
+	// pending for them. Nodes are launched to make up for any shortfall, and disruption won't delete nodes without
+	// replacing them if that takes the NodePool below MinNodes. Nodes launched for MinNodes are subject to Limits.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	MinNodes int32 `json:"minNodes,omitempty"`
+	// RequestRounding rounds pod resource requests up to a multiple of the given quantity, per resource, when
+	// simulating bin-packing onto NodeClaims launched by this NodePool. This reduces fragmentation caused by small,
+	// odd-sized requests. The requests on the pods themselves are not modified.
+	// +optional
+	RequestRounding v1.ResourceList `json:"requestRounding,omitempty"`
+	// PreferredArchitecture is the kubernetes.io/arch of the nodes launched by this NodePool when it allows several
+	// architectures and the pods on the node can run on any of them. Nodes are launched with other architectures when no
+	// instance type of the preferred architecture fits the pods.
+	// +optional
+	PreferredArchitecture string `json:"preferredArchitecture,omitempty"`
+	// SchedulingObjective describes what consolidation optimizes for with the nodes launched by this NodePool.
+	// Provisioning packs the pending pods onto as few nodes as the instance types allow, and launches the cheapest
+	// instance types that fit them, under either objective. "Cheapest" also replaces a node with a cheaper one once its
+	// pods fit onto it, while "FewestNodes" only replaces nodes when that reduces the number of nodes, so that nodes keep
+	// the room they have to spare for pods at a higher cost. This objective defaults to "Cheapest" if not specified
+	// +kubebuilder:validation:Enum:={Cheapest,FewestNodes}
+	// +optional
+	SchedulingObjective SchedulingObjective `json:"schedulingObjective,omitempty"`
 	// Weight is the priority given to the nodepool during scheduling. A higher
 	// numerical weight indicates that this nodepool will be ordered
 	// ahead of other nodepools with lower weights. A nodepool with no weight
@@ -77,6 +106,37 @@ type Disruption struct {
 	// +kubebuilder:validation:Enum:={WhenEmpty,WhenUnderutilized}
 	// +optional
 	ConsolidationPolicy ConsolidationPolicy `json:"consolidationPolicy,omitempty"`
+	// ConsolidationOrder describes the order in which consolidation evaluates the nodes of this NodePool. "LeastDisruptive"
+	// evaluates the nodes that are cheapest to disrupt first while "MostExpensive" evaluates the nodes with the highest
+	// offering price first, so that the biggest savings are realized first within the budgets. "HighestStatefulSetOrdinal"
+	// evaluates the nodes running the StatefulSet pods with the highest ordinals first, as these are typically the newest
+	// and least critical replicas. Ordinals are compared across all StatefulSets on the nodes, so a node running a
+	// small StatefulSet can be evaluated after a node running higher replicas of a bigger one. The order only applies to
+	// consolidation; drift, expiration and the other disruption methods disrupt nodes in the order that they were marked.
+	// This order defaults to "LeastDisruptive" if not specified
+	// +kubebuilder:validation:Enum:={LeastDisruptive,MostExpensive,HighestStatefulSetOrdinal}
+	// +optional
+	ConsolidationOrder ConsolidationOrder `json:"consolidationOrder,omitempty"`
+	// UnderutilizationThreshold is the percentage utilization below which a node is considered underutilized
+	// by consolidation. Utilization is the highest ratio of pod requests to allocatable across cpu and memory,
+	// and nodes at or above the threshold aren't consolidated. If unset, any node can be consolidated.
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=100
+	// +optional
+	UnderutilizationThreshold *int32 `json:"underutilizationThreshold,omitempty"`
+	// ConsolidateAcrossCapacityTypes allows consolidation to switch the capacity type of the nodes of this NodePool
+	// based on price and current availability. Spot nodes may be replaced with a cheaper on-demand node when no spot
+	// replacement is possible, and on-demand nodes are only replaced with spot when a cheaper spot offering is available.
+	// +optional
+	ConsolidateAcrossCapacityTypes bool `json:"consolidateAcrossCapacityTypes,omitempty"`
+	// ConsolidationValidationDelay is how long consolidation waits after deciding to disrupt the nodes of this NodePool
+	// before validating that the decision still holds and acting on it. A shorter delay reacts faster to changes in
+	// fast-moving NodePools, while a longer delay avoids acting on transient state. Commands that span several NodePools
+	// wait for the longest of their delays. This delay defaults to 15s if not specified
+	// +kubebuilder:validation:Pattern=`^([0-9]+(s|m|h))+$`
+	// +kubebuilder:validation:Type="string"
+	// +optional
+	ConsolidationValidationDelay *metav1.Duration `json:"consolidationValidationDelay,omitempty"`
 	// ExpireAfter is the duration the controller will wait
 	// before terminating a node, measured from when the node is created. This
 	// is useful to implement features like eventually consistent node upgrade,
@@ -87,6 +147,21 @@ type Disruption struct {
 	// +kubebuilder:validation:Schemaless
 	// +optional
 	ExpireAfter NillableDuration `json:"expireAfter"`
+	// ExpirationStrategy describes how Karpenter disrupts the expired nodes of this NodePool. "Delete" drains an expired
+	// node and only launches replacement capacity for the pods that don't fit onto existing nodes, while "Replace" always
+	// launches replacement capacity for the pods of an expired node and waits until it's initialized before draining the
+	// node. This strategy defaults to "Delete" if not specified
+	// +kubebuilder:validation:Enum:={Delete,Replace}
+	// +optional
+	ExpirationStrategy ExpirationStrategy `json:"expirationStrategy,omitempty"`
+	// UnhealthyAfter is how long a node must be NotReady, without interruption, before Karpenter replaces it. Nodes that
+	// become Ready again within this duration aren't disrupted, so brief control plane or network hiccups don't churn
+	// nodes. Unhealthy nodes are replaced within the disruption budgets, but NotReady nodes aren't counted against the
+	// budgets when replacing them. If unset, unhealthy nodes aren't replaced.
+	// +kubebuilder:validation:Pattern=`^([0-9]+(s|m|h))+$`
+	// +kubebuilder:validation:Type="string"
+	// +optional
+	UnhealthyAfter *metav1.Duration `json:"unhealthyAfter,omitempty"`
 	// Budgets is a list of Budgets.
 	// If there are multiple active budgets, Karpenter uses
 	// the most restrictive value. If left undefined,
@@ -130,6 +205,19 @@ type Budget struct {
 	Duration *metav1.Duration `json:"duration,omitempty" hash:"ignore"`
 }
 
+const (
+	// budgetOverlapHorizon is how far ahead budget schedules are walked when checking whether they overlap
+	budgetOverlapHorizon = 7 * 24 * time.Hour
+	// maxBudgetOverlapWindows bounds the number of schedule hits that are walked when checking whether budgets
+	// overlap, which is one hit per minute over the budgetOverlapHorizon
+	maxBudgetOverlapWindows = int(budgetOverlapHorizon / time.Minute)
+)
+
+// budgetOverlapReference is the start of the week that budget schedules are walked over when validating that they
+// don't overlap, so that validation doesn't depend on when it runs. It's a Monday, so that the week covers each day
+// of the week once.
+var budgetOverlapReference = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
 type ConsolidationPolicy string
 
 const (
@@ -137,6 +225,31 @@ const (
 	ConsolidationPolicyWhenUnderutilized ConsolidationPolicy = "WhenUnderutilized"
 )
 
+type ConsolidationOrder string
+
+const (
+	ConsolidationOrderLeastDisruptive           ConsolidationOrder = "LeastDisruptive"
+	ConsolidationOrderMostExpensive             ConsolidationOrder = "MostExpensive"
+	ConsolidationOrderHighestStatefulSetOrdinal ConsolidationOrder = "HighestStatefulSetOrdinal"
+)
+
+type SchedulingObjective string
+
+const (
+	SchedulingObjectiveCheapest    SchedulingObjective = "Cheapest"
+	SchedulingObjectiveFewestNodes SchedulingObjective = "FewestNodes"
+)
+
+type ExpirationStrategy string
+
+const (
+	ExpirationStrategyDelete  ExpirationStrategy = "Delete"
+	ExpirationStrategyReplace ExpirationStrategy = "Replace"
+)
+
+// ResourceNodes is the limit on the number of nodes that a NodePool may launch, regardless of their size
+const ResourceNodes v1.ResourceName = "nodes"
+
 type Limits v1.ResourceList
 
 func (l Limits) ExceededBy(resources v1.ResourceList) error {
@@ -243,10 +356,17 @@ func (in *NodePool) MustGetAllowedDisruptions(ctx context.Context, c clock.Clock
 // GetAllowedDisruptions returns the minimum allowed disruptions across all disruption budgets for a given node pool.
 // This will return an error if there is a configuration error with any budget's node or schedule values.
 func (in *NodePool) GetAllowedDisruptions(ctx context.Context, c clock.Clock, numNodes int) (int, error) {
+	return in.GetAllowedDisruptionsAt(c.Now(), numNodes)
+}
+
+// GetAllowedDisruptionsAt returns the effective allowed disruptions at the given time. When multiple budgets are
+// active at the same time, their allowances are combined by taking the most restrictive value. This returns MAXINT
+// if no budgets are active at the given time.
+func (in *NodePool) GetAllowedDisruptionsAt(t time.Time, numNodes int) (int, error) {
 	minVal := math.MaxInt32
 	var multiErr error
 	for i := range in.Spec.Disruption.Budgets {
-		val, err := in.Spec.Disruption.Budgets[i].GetAllowedDisruptions(c, numNodes)
+		val, err := in.Spec.Disruption.Budgets[i].GetAllowedDisruptionsAt(t, numNodes)
 		if err != nil {
 			multiErr = multierr.Append(multiErr, err)
 		}
@@ -259,7 +379,13 @@ func (in *NodePool) GetAllowedDisruptions(ctx context.Context, c clock.Clock, nu
 // for calculating if a disruption action is allowed. It returns an error if the
 // schedule is invalid. This returns MAXINT if the value is unbounded.
 func (in *Budget) GetAllowedDisruptions(c clock.Clock, numNodes int) (int, error) {
-	active, err := in.IsActive(c)
+	return in.GetAllowedDisruptionsAt(c.Now(), numNodes)
+}
+
+// GetAllowedDisruptionsAt returns the allowed disruptions for the budget at the given time. This returns MAXINT
+// if the budget isn't active at the given time.
+func (in *Budget) GetAllowedDisruptionsAt(t time.Time, numNodes int) (int, error) {
+	active, err := in.IsActiveAt(t)
 	// If the budget is misconfigured, fail closed.
 	if err != nil {
 		return 0, err
@@ -289,19 +415,68 @@ func (in *Budget) GetAllowedDisruptions(c clock.Clock, numNodes int) (int, error
 // schedule is active, as any more schedule hits in between would only extend this
 // window. This ensures that any previous schedule hits for a schedule are considered.
 func (in *Budget) IsActive(c clock.Clock) (bool, error) {
+	return in.IsActiveAt(c.Now())
+}
+
+// IsActiveAt returns if a budget is active at the given time. See IsActive for details.
+func (in *Budget) IsActiveAt(t time.Time) (bool, error) {
 	if in.Schedule == nil && in.Duration == nil {
 		return true, nil
 	}
+	schedule, err := in.schedule()
+	if err != nil {
+		return false, err
+	}
+	// Walk back in time for the duration associated with the schedule
+	checkPoint := t.UTC().Add(-lo.FromPtr(in.Duration).Duration)
+	nextHit := schedule.Next(checkPoint)
+	return !nextHit.After(t.UTC()), nil
+}
+
+// Overlaps returns true if the budget and the other budget are both active at any point in time within the week
+// following the given time. Budgets without a schedule are always active, so they overlap with every other budget.
+func (in *Budget) Overlaps(other *Budget, from time.Time) (bool, error) {
+	overlaps, _, err := in.overlaps(other, from, maxBudgetOverlapWindows)
+	return overlaps, err
+}
+
+// overlaps walks at most the given number of windows of the budget to check whether it overlaps with the other
+// budget, and returns the number of windows that it walked
+func (in *Budget) overlaps(other *Budget, from time.Time, windows int) (bool, int, error) {
+	if (in.Schedule == nil && in.Duration == nil) || (other.Schedule == nil && other.Duration == nil) {
+		return true, 0, nil
+	}
+	schedule, err := in.schedule()
+	if err != nil {
+		return false, 0, err
+	}
+	otherSchedule, err := other.schedule()
+	if err != nil {
+		return false, 0, err
+	}
+	duration, otherDuration := lo.FromPtr(in.Duration).Duration, lo.FromPtr(other.Duration).Duration
+	end := from.UTC().Add(budgetOverlapHorizon)
+	// Walk each window that the budget is active for, starting with any window that is already active, and check
+	// whether a window of the other budget starts while it is still active
+	hit := schedule.Next(from.UTC().Add(-duration))
+	walked := 0
+	for ; walked < windows && hit.Before(end); walked++ {
+		if otherSchedule.Next(hit.Add(-otherDuration)).Before(hit.Add(duration)) {
+			return true, walked + 1, nil
+		}
+		hit = schedule.Next(hit)
+	}
+	return false, walked, nil
+}
+
+func (in *Budget) schedule() (cron.Schedule, error) {
 	schedule, err := cron.ParseStandard(fmt.Sprintf("TZ=UTC %s", lo.FromPtr(in.Schedule)))
 	if err != nil {
 		// Should only occur if there's a discrepancy
 		// with the validation regex and the cron package.
-		return false, fmt.Errorf("invariant violated, invalid cron %s", schedule)
+		return nil, fmt.Errorf("invariant violated, invalid cron %s", lo.FromPtr(in.Schedule))
 	}
-	// Walk back in time for the duration associated with the schedule
-	checkPoint := c.Now().UTC().Add(-lo.FromPtr(in.Duration).Duration)
-	nextHit := schedule.Next(checkPoint)
-	return !nextHit.After(c.Now().UTC()), nil
+	return schedule, nil
 }
 
 func GetIntStrFromValue(str string) intstr.IntOrString {
@@ -127,6 +127,97 @@ var _ = Describe("Budgets", func() {
 			Expect(val).To(BeNumerically("==", 10))
 		})
 	})
+	Context("GetAllowedDisruptionsAt", func() {
+		BeforeEach(func() {
+			budgets = []Budget{
+				{
+					Nodes:    "10",
+					Schedule: lo.ToPtr("@daily"),
+					Duration: lo.ToPtr(metav1.Duration{Duration: lo.Must(time.ParseDuration("2h"))}),
+				},
+				{
+					Nodes:    "5%",
+					Schedule: lo.ToPtr("0 1 * * 1-5"),
+					Duration: lo.ToPtr(metav1.Duration{Duration: lo.Must(time.ParseDuration("2h"))}),
+				},
+			}
+			nodePool.Spec.Disruption.Budgets = budgets
+		})
+		It("should return the most restrictive allowance when daily and weekday schedules overlap", func() {
+			// Monday, January 8th 2024 at 01:30 UTC, both budgets are active
+			val, err := nodePool.GetAllowedDisruptionsAt(time.Date(2024, time.January, 8, 1, 30, 0, 0, time.UTC), 100)
+			Expect(err).To(Succeed())
+			Expect(val).To(BeNumerically("==", 5))
+		})
+		It("should return the daily allowance when only the daily schedule is active", func() {
+			// Sunday, January 7th 2024 at 01:30 UTC, the weekday budget is inactive
+			val, err := nodePool.GetAllowedDisruptionsAt(time.Date(2024, time.January, 7, 1, 30, 0, 0, time.UTC), 100)
+			Expect(err).To(Succeed())
+			Expect(val).To(BeNumerically("==", 10))
+		})
+		It("should return MaxInt32 when no schedules are active", func() {
+			val, err := nodePool.GetAllowedDisruptionsAt(time.Date(2024, time.January, 8, 12, 0, 0, 0, time.UTC), 100)
+			Expect(err).To(Succeed())
+			Expect(val).To(BeNumerically("==", math.MaxInt32))
+		})
+		It("should match GetAllowedDisruptions for the clock's time", func() {
+			fakeClock = clock.NewFakeClock(time.Date(2024, time.January, 8, 1, 30, 0, 0, time.UTC))
+			Expect(nodePool.MustGetAllowedDisruptions(ctx, fakeClock, 100)).To(BeNumerically("==", 5))
+		})
+	})
+	Context("Overlaps", func() {
+		var from time.Time
+		BeforeEach(func() {
+			// Set the date to the first sunday in 2024
+			from = time.Date(2024, time.January, 7, 12, 0, 0, 0, time.UTC)
+		})
+		It("should overlap when daily and weekday schedules are active at the same time", func() {
+			budgets[0].Schedule = lo.ToPtr("@daily")
+			budgets[0].Duration = lo.ToPtr(metav1.Duration{Duration: lo.Must(time.ParseDuration("2h"))})
+			budgets[1].Schedule = lo.ToPtr("0 1 * * 1-5")
+			budgets[1].Duration = lo.ToPtr(metav1.Duration{Duration: lo.Must(time.ParseDuration("2h"))})
+			overlaps, err := budgets[0].Overlaps(&budgets[1], from)
+			Expect(err).To(Succeed())
+			Expect(overlaps).To(BeTrue())
+			overlaps, err = budgets[1].Overlaps(&budgets[0], from)
+			Expect(err).To(Succeed())
+			Expect(overlaps).To(BeTrue())
+		})
+		It("should not overlap when daily and weekday schedules are active at different times", func() {
+			budgets[0].Schedule = lo.ToPtr("@daily")
+			budgets[0].Duration = lo.ToPtr(metav1.Duration{Duration: lo.Must(time.ParseDuration("2h"))})
+			budgets[1].Schedule = lo.ToPtr("0 9 * * 1-5")
+			budgets[1].Duration = lo.ToPtr(metav1.Duration{Duration: lo.Must(time.ParseDuration("8h"))})
+			overlaps, err := budgets[0].Overlaps(&budgets[1], from)
+			Expect(err).To(Succeed())
+			Expect(overlaps).To(BeFalse())
+			overlaps, err = budgets[1].Overlaps(&budgets[0], from)
+			Expect(err).To(Succeed())
+			Expect(overlaps).To(BeFalse())
+		})
+		It("should overlap when a duration spans into the other schedule", func() {
+			budgets[0].Schedule = lo.ToPtr("0 22 * * SUN")
+			budgets[0].Duration = lo.ToPtr(metav1.Duration{Duration: lo.Must(time.ParseDuration("4h"))})
+			budgets[1].Schedule = lo.ToPtr("0 1 * * MON")
+			budgets[1].Duration = lo.ToPtr(metav1.Duration{Duration: lo.Must(time.ParseDuration("1h"))})
+			overlaps, err := budgets[0].Overlaps(&budgets[1], from)
+			Expect(err).To(Succeed())
+			Expect(overlaps).To(BeTrue())
+		})
+		It("should always overlap when a budget has no schedule", func() {
+			budgets[0].Schedule = nil
+			budgets[0].Duration = nil
+			budgets[1].Schedule = lo.ToPtr("@yearly")
+			overlaps, err := budgets[0].Overlaps(&budgets[1], from)
+			Expect(err).To(Succeed())
+			Expect(overlaps).To(BeTrue())
+		})
+		It("should return an error if a schedule is invalid", func() {
+			budgets[1].Schedule = lo.ToPtr("@wrongly")
+			_, err := budgets[0].Overlaps(&budgets[1], from)
+			Expect(err).ToNot(Succeed())
+		})
+	})
 	Context("IsActive", func() {
 		It("should always consider a schedule and time in UTC", func() {
 			// Set the time to start of June 2000 in a time zone 1 hour ahead of UTC
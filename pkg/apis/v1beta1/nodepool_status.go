@@ -18,6 +18,7 @@ package v1beta1
 
 import (
 	v1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/apis"
 )
 
 // NodePoolStatus defines the observed state of NodePool
@@ -25,4 +26,42 @@ type NodePoolStatus struct {
 	// Resources is the list of resources that have been provisioned.
 	// +optional
 	Resources v1.ResourceList `json:"resources,omitempty"`
+	// InstanceTypes summarizes the instance types that the NodePool's requirements currently resolve to.
+	// +optional
+	InstanceTypes *InstanceTypesSummary `json:"instanceTypes,omitempty"`
+	// Conditions contains signals for the health of the NodePool
+	// +optional
+	Conditions apis.Conditions `json:"conditions,omitempty"`
+}
+
+// InstanceTypesSummary is a bounded summary of the instance types that a NodePool is able to launch, based on its
+// requirements and the cloud provider's catalog.
+type InstanceTypesSummary struct {
+	// Count is the number of instance types that are compatible with the NodePool's requirements and have an
+	// available offering.
+	Count int `json:"count"`
+	// Sample is a sorted subset of the names of the compatible instance types.
+	// +kubebuilder:validation:MaxItems:=10
+	// +optional
+	Sample []string `json:"sample,omitempty"`
+}
+
+var (
+	// LaunchesPaused is set when repeated launch failures for the NodePool have opened its launch circuit breaker
+	LaunchesPaused apis.ConditionType = "LaunchesPaused"
+	// SingleInstanceType is set when the NodePool's requirements resolve to a single instance type, which leaves the
+	// NodePool without any capacity diversity to fall back on. It's informational and doesn't block launches.
+	SingleInstanceType apis.ConditionType = "SingleInstanceType"
+)
+
+func (in *NodePool) StatusConditions() apis.ConditionManager {
+	return apis.NewLivingConditionSet().Manage(in)
+}
+
+func (in *NodePool) GetConditions() apis.Conditions {
+	return in.Status.Conditions
+}
+
+func (in *NodePool) SetConditions(conditions apis.Conditions) {
+	in.Status.Conditions = conditions
 }
@@ -55,9 +55,19 @@ func (in *NodePoolSpec) validate() (errs *apis.FieldError) {
 	return errs.Also(
 		in.Template.validate().ViaField("template"),
 		in.Disruption.validate().ViaField("deprovisioning"),
+		in.validateRequestRounding().ViaField("requestRounding"),
 	)
 }
 
+func (in *NodePoolSpec) validateRequestRounding() (errs *apis.FieldError) {
+	for name, quantity := range in.RequestRounding {
+		if quantity.Sign() <= 0 {
+			errs = errs.Also(apis.ErrInvalidValue(quantity.String(), string(name), "request rounding must be positive"))
+		}
+	}
+	return errs
+}
+
 func (in *NodeClaimTemplate) validate() (errs *apis.FieldError) {
 	if len(in.Spec.Resources.Requests) > 0 {
 		errs = errs.Also(apis.ErrDisallowedFields("resources.requests"))
@@ -104,12 +114,45 @@ func (in *Disruption) validate() (errs *apis.FieldError) {
 	if in.ConsolidateAfter == nil && in.ConsolidationPolicy == ConsolidationPolicyWhenEmpty {
 		return errs.Also(apis.ErrGeneric("consolidateAfter must be specified with consolidationPolicy=WhenEmpty"))
 	}
+	if in.UnderutilizationThreshold != nil && in.ConsolidationPolicy != ConsolidationPolicyWhenUnderutilized {
+		return errs.Also(apis.ErrGeneric("underutilizationThreshold can only be combined with consolidationPolicy=WhenUnderutilized"))
+	}
+	if in.UnderutilizationThreshold != nil && (*in.UnderutilizationThreshold < 1 || *in.UnderutilizationThreshold > 100) {
+		return errs.Also(apis.ErrOutOfBoundsValue(*in.UnderutilizationThreshold, 1, 100, "underutilizationThreshold"))
+	}
 	for i := range in.Budgets {
 		budget := in.Budgets[i]
 		if err := budget.validate(); err != nil {
 			errs = errs.Also(err.ViaIndex(i).ViaField("budget"))
 		}
 	}
+	if errs != nil {
+		return errs
+	}
+	return in.validateBudgetOverlap()
+}
+
+// validateBudgetOverlap warns when scheduled budgets are active at the same time. While both budgets are active,
+// only the most restrictive of them is used, which can produce surprising allowances. The schedules are walked over
+// a fixed week so that the warnings don't depend on when validation runs, and the number of windows walked is bounded
+// across all of the budgets, so that overlaps between budgets with frequent schedules may not be warned about.
+func (in *Disruption) validateBudgetOverlap() (errs *apis.FieldError) {
+	windows := maxBudgetOverlapWindows
+	for i := range in.Budgets {
+		if in.Budgets[i].Schedule == nil {
+			continue
+		}
+		for j := i + 1; j < len(in.Budgets) && windows > 0; j++ {
+			if in.Budgets[j].Schedule == nil {
+				continue
+			}
+			overlaps, walked, err := in.Budgets[i].overlaps(&in.Budgets[j], budgetOverlapReference, windows)
+			windows -= walked
+			if err == nil && overlaps {
+				errs = errs.Also(apis.ErrGeneric(fmt.Sprintf("budgets[%d] and budgets[%d] have overlapping schedules, only the most restrictive budget applies while both are active", i, j), "budget").At(apis.WarningLevel))
+			}
+		}
+	}
 	return errs
 }
 
@@ -33,6 +33,8 @@ import (
 	"knative.dev/pkg/ptr"
 
 	. "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/utils/functional"
 )
 
 var _ = Describe("CEL/Validation", func() {
@@ -64,6 +66,23 @@ var _ = Describe("CEL/Validation", func() {
 			},
 		}
 	})
+	DescribeTable("should succeed for the default test NodePool",
+		func(opts []functional.Option[NodePool]) {
+			Expect(env.Client.Create(ctx, test.DefaultNodePool(opts...))).To(Succeed())
+		},
+		Entry("without options", nil),
+		Entry("with options", []functional.Option[NodePool]{
+			test.WithNodePoolRequirements(NodeSelectorRequirementWithMinValues{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{CapacityTypeSpot}}}),
+			test.WithNodePoolLabels(map[string]string{"team": "a"}),
+			test.WithNodePoolTaints(v1.Taint{Key: "team", Value: "a", Effect: v1.TaintEffectNoSchedule}),
+			test.WithNodePoolStartupTaints(v1.Taint{Key: "startup", Effect: v1.TaintEffectNoSchedule}),
+			test.WithNodePoolConsolidationPolicy(ConsolidationPolicyWhenEmpty, time.Minute),
+			test.WithNodePoolExpireAfter(nil),
+			test.WithNodePoolBudgets(Budget{Nodes: "1"}),
+			test.WithNodePoolLimits(v1.ResourceList{v1.ResourceCPU: resource.MustParse("10")}),
+			test.WithNodePoolWeight(10),
+		}),
+	)
 	Context("Disruption", func() {
 		It("should fail on negative expireAfter", func() {
 			nodePool.Spec.Disruption.ExpireAfter.Duration = lo.ToPtr(lo.Must(time.ParseDuration("-1s")))
@@ -95,6 +114,38 @@ var _ = Describe("CEL/Validation", func() {
 			nodePool.Spec.Disruption.ConsolidationPolicy = ConsolidationPolicyWhenEmpty
 			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
 		})
+		It("should succeed when setting a valid consolidationOrder", func() {
+			nodePool.Spec.Disruption.ConsolidationOrder = ConsolidationOrderMostExpensive
+			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+		})
+		It("should fail when setting an invalid consolidationOrder", func() {
+			nodePool.Spec.Disruption.ConsolidationOrder = "Cheapest"
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		})
+		It("should succeed when setting a valid expirationStrategy", func() {
+			nodePool.Spec.Disruption.ExpirationStrategy = ExpirationStrategyReplace
+			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+		})
+		It("should fail when setting an invalid expirationStrategy", func() {
+			nodePool.Spec.Disruption.ExpirationStrategy = "Recycle"
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		})
+		It("should succeed when setting underutilizationThreshold with consolidationPolicy=WhenUnderutilized", func() {
+			nodePool.Spec.Disruption.UnderutilizationThreshold = lo.ToPtr[int32](50)
+			nodePool.Spec.Disruption.ConsolidationPolicy = ConsolidationPolicyWhenUnderutilized
+			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+		})
+		It("should fail when setting underutilizationThreshold with consolidationPolicy=WhenEmpty", func() {
+			nodePool.Spec.Disruption.UnderutilizationThreshold = lo.ToPtr[int32](50)
+			nodePool.Spec.Disruption.ConsolidateAfter = &NillableDuration{Duration: lo.ToPtr(lo.Must(time.ParseDuration("30s")))}
+			nodePool.Spec.Disruption.ConsolidationPolicy = ConsolidationPolicyWhenEmpty
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		})
+		It("should fail when setting underutilizationThreshold out of bounds", func() {
+			nodePool.Spec.Disruption.UnderutilizationThreshold = lo.ToPtr[int32](101)
+			nodePool.Spec.Disruption.ConsolidationPolicy = ConsolidationPolicyWhenUnderutilized
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		})
 		It("should fail when setting consolidateAfter with consolidationPolicy=WhenUnderutilized", func() {
 			nodePool.Spec.Disruption.ConsolidateAfter = &NillableDuration{Duration: lo.ToPtr(lo.Must(time.ParseDuration("30s")))}
 			nodePool.Spec.Disruption.ConsolidationPolicy = ConsolidationPolicyWhenUnderutilized
@@ -838,4 +889,24 @@ var _ = Describe("CEL/Validation", func() {
 			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
 		})
 	})
+	Context("MinNodes", func() {
+		It("should allow minNodes to be set", func() {
+			nodePool.Spec.MinNodes = 3
+			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+		})
+		It("should fail when minNodes is negative", func() {
+			nodePool.Spec.MinNodes = -1
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		})
+	})
+	Context("SchedulingObjective", func() {
+		It("should succeed when setting a valid schedulingObjective", func() {
+			nodePool.Spec.SchedulingObjective = SchedulingObjectiveFewestNodes
+			Expect(env.Client.Create(ctx, nodePool)).To(Succeed())
+		})
+		It("should fail when setting an invalid schedulingObjective", func() {
+			nodePool.Spec.SchedulingObjective = "MostExpensive"
+			Expect(env.Client.Create(ctx, nodePool)).ToNot(Succeed())
+		})
+	})
 })
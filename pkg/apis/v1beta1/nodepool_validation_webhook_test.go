@@ -29,9 +29,12 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/apis"
 	"knative.dev/pkg/ptr"
 
 	. "sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/utils/functional"
 )
 
 var _ = Describe("Webhook/Validation", func() {
@@ -52,6 +55,23 @@ var _ = Describe("Webhook/Validation", func() {
 			},
 		}
 	})
+	DescribeTable("should succeed for the default test NodePool",
+		func(opts []functional.Option[NodePool]) {
+			Expect(test.DefaultNodePool(opts...).Validate(ctx)).To(Succeed())
+		},
+		Entry("without options", nil),
+		Entry("with options", []functional.Option[NodePool]{
+			test.WithNodePoolRequirements(NodeSelectorRequirementWithMinValues{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{CapacityTypeSpot}}}),
+			test.WithNodePoolLabels(map[string]string{"team": "a"}),
+			test.WithNodePoolTaints(v1.Taint{Key: "team", Value: "a", Effect: v1.TaintEffectNoSchedule}),
+			test.WithNodePoolStartupTaints(v1.Taint{Key: "startup", Effect: v1.TaintEffectNoSchedule}),
+			test.WithNodePoolConsolidationPolicy(ConsolidationPolicyWhenEmpty, time.Minute),
+			test.WithNodePoolExpireAfter(nil),
+			test.WithNodePoolBudgets(Budget{Nodes: "1"}),
+			test.WithNodePoolLimits(v1.ResourceList{v1.ResourceCPU: resource.MustParse("10")}),
+			test.WithNodePoolWeight(10),
+		}),
+	)
 	Context("Disruption", func() {
 		It("should succeed on a disabled expireAfter", func() {
 			nodePool.Spec.Disruption.ExpireAfter.Duration = nil
@@ -74,6 +94,22 @@ var _ = Describe("Webhook/Validation", func() {
 			nodePool.Spec.Disruption.ConsolidationPolicy = ConsolidationPolicyWhenEmpty
 			Expect(nodePool.Validate(ctx)).To(Succeed())
 		})
+		It("should succeed when setting underutilizationThreshold with consolidationPolicy=WhenUnderutilized", func() {
+			nodePool.Spec.Disruption.UnderutilizationThreshold = lo.ToPtr[int32](50)
+			nodePool.Spec.Disruption.ConsolidationPolicy = ConsolidationPolicyWhenUnderutilized
+			Expect(nodePool.Validate(ctx)).To(Succeed())
+		})
+		It("should fail when setting underutilizationThreshold with consolidationPolicy=WhenEmpty", func() {
+			nodePool.Spec.Disruption.UnderutilizationThreshold = lo.ToPtr[int32](50)
+			nodePool.Spec.Disruption.ConsolidateAfter = &NillableDuration{Duration: lo.ToPtr(lo.Must(time.ParseDuration("30s")))}
+			nodePool.Spec.Disruption.ConsolidationPolicy = ConsolidationPolicyWhenEmpty
+			Expect(nodePool.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail when setting underutilizationThreshold out of bounds", func() {
+			nodePool.Spec.Disruption.UnderutilizationThreshold = lo.ToPtr[int32](101)
+			nodePool.Spec.Disruption.ConsolidationPolicy = ConsolidationPolicyWhenUnderutilized
+			Expect(nodePool.Validate(ctx)).ToNot(Succeed())
+		})
 		It("should fail when setting consolidateAfter with consolidationPolicy=WhenUnderutilized", func() {
 			nodePool.Spec.Disruption.ConsolidateAfter = &NillableDuration{Duration: lo.ToPtr(lo.Must(time.ParseDuration("30s")))}
 			nodePool.Spec.Disruption.ConsolidationPolicy = ConsolidationPolicyWhenUnderutilized
@@ -170,6 +206,51 @@ var _ = Describe("Webhook/Validation", func() {
 			}
 			Expect(nodePool.Validate(ctx)).ToNot(Succeed())
 		})
+		It("should warn when creating budgets with overlapping daily and weekday schedules", func() {
+			nodePool.Spec.Disruption.Budgets = []Budget{
+				{
+					Nodes:    "10",
+					Schedule: ptr.String("@daily"),
+					Duration: &metav1.Duration{Duration: lo.Must(time.ParseDuration("2h"))},
+				},
+				{
+					Nodes:    "0",
+					Schedule: ptr.String("0 1 * * 1-5"),
+					Duration: &metav1.Duration{Duration: lo.Must(time.ParseDuration("2h"))},
+				},
+			}
+			err := nodePool.Validate(ctx)
+			Expect(err.Filter(apis.ErrorLevel)).To(BeNil())
+			Expect(err.Filter(apis.WarningLevel)).ToNot(BeNil())
+			Expect(err.Filter(apis.WarningLevel).Error()).To(ContainSubstring("budgets[0] and budgets[1] have overlapping schedules"))
+		})
+		It("should not warn when creating budgets with schedules that don't overlap", func() {
+			nodePool.Spec.Disruption.Budgets = []Budget{
+				{
+					Nodes:    "10",
+					Schedule: ptr.String("@daily"),
+					Duration: &metav1.Duration{Duration: lo.Must(time.ParseDuration("2h"))},
+				},
+				{
+					Nodes:    "0",
+					Schedule: ptr.String("0 9 * * 1-5"),
+					Duration: &metav1.Duration{Duration: lo.Must(time.ParseDuration("8h"))},
+				},
+			}
+			Expect(nodePool.Validate(ctx)).To(Succeed())
+		})
+		It("should bound the schedule windows walked when validating budgets with frequent schedules", func() {
+			nodePool.Spec.Disruption.Budgets = lo.Times(50, func(i int) Budget {
+				return Budget{
+					Nodes:    "10",
+					Schedule: ptr.String(lo.Ternary(i%2 == 0, "*/2 * * * *", "1-59/2 * * * *")),
+					Duration: &metav1.Duration{Duration: lo.Must(time.ParseDuration("1m"))},
+				}
+			})
+			start := time.Now()
+			Expect(nodePool.Validate(ctx).Filter(apis.ErrorLevel)).To(BeNil())
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+		})
 	})
 	Context("Limits", func() {
 		It("should allow undefined limits", func() {
@@ -181,6 +262,23 @@ var _ = Describe("Webhook/Validation", func() {
 			Expect(nodePool.Validate(ctx)).To(Succeed())
 		})
 	})
+	Context("RequestRounding", func() {
+		It("should allow positive request rounding", func() {
+			nodePool.Spec.RequestRounding = v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("100m"),
+				v1.ResourceMemory: resource.MustParse("64Mi"),
+			}
+			Expect(nodePool.Validate(ctx)).To(Succeed())
+		})
+		It("should fail on zero request rounding", func() {
+			nodePool.Spec.RequestRounding = v1.ResourceList{v1.ResourceCPU: resource.MustParse("0")}
+			Expect(nodePool.Validate(ctx)).ToNot(Succeed())
+		})
+		It("should fail on negative request rounding", func() {
+			nodePool.Spec.RequestRounding = v1.ResourceList{v1.ResourceMemory: resource.MustParse("-64Mi")}
+			Expect(nodePool.Validate(ctx)).ToNot(Succeed())
+		})
+	})
 	Context("Template", func() {
 		It("should fail if resource requests are set", func() {
 			nodePool.Spec.Template.Spec.Resources.Requests = v1.ResourceList{
@@ -316,6 +414,13 @@ var _ = Describe("Webhook/Validation", func() {
 				}
 				Expect(nodePool.Validate(ctx)).To(Succeed())
 			})
+			It("should fail for contradictory requirements on well known labels", func() {
+				nodePool.Spec.Template.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
+					{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelArchStable, Operator: v1.NodeSelectorOpIn, Values: []string{ArchitectureArm64}}},
+					{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelArchStable, Operator: v1.NodeSelectorOpIn, Values: []string{ArchitectureAmd64}}},
+				}
+				Expect(nodePool.Validate(ctx)).ToNot(Succeed())
+			})
 			It("should fail for unsupported ops", func() {
 				for _, op := range []v1.NodeSelectorOperator{"unknown"} {
 					nodePool.Spec.Template.Spec.Requirements = []NodeSelectorRequirementWithMinValues{
@@ -22,6 +22,7 @@ import v1 "k8s.io/api/core/v1"
 const (
 	DisruptionTaintKey             = Group + "/disruption"
 	DisruptingNoScheduleTaintValue = "disrupting"
+	DedicatedTaintKey              = Group + "/dedicated"
 )
 
 var (
@@ -32,6 +33,14 @@ var (
 		Effect: v1.TaintEffectNoSchedule,
 		Value:  DisruptingNoScheduleTaintValue,
 	}
+	// DedicatedNoScheduleTaint is added to the NodeClaims that are launched for pods with the karpenter.sh/dedicated
+	// annotation, so that no other pods are scheduled to them. Dedicated pods must tolerate it, and only the DaemonSets
+	// that tolerate it run on these nodes.
+	DedicatedNoScheduleTaint = v1.Taint{
+		Key:    DedicatedTaintKey,
+		Effect: v1.TaintEffectNoSchedule,
+		Value:  "true",
+	}
 )
 
 func IsDisruptingTaint(taint v1.Taint) bool {
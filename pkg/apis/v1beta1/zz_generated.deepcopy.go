@@ -61,7 +61,22 @@ func (in *Disruption) DeepCopyInto(out *Disruption) {
 		*out = new(NillableDuration)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.UnderutilizationThreshold != nil {
+		in, out := &in.UnderutilizationThreshold, &out.UnderutilizationThreshold
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ConsolidationValidationDelay != nil {
+		in, out := &in.ConsolidationValidationDelay, &out.ConsolidationValidationDelay
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 	in.ExpireAfter.DeepCopyInto(&out.ExpireAfter)
+	if in.UnhealthyAfter != nil {
+		in, out := &in.UnhealthyAfter, &out.UnhealthyAfter
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 	if in.Budgets != nil {
 		in, out := &in.Budgets, &out.Budgets
 		*out = make([]Budget, len(*in))
@@ -81,6 +96,26 @@ func (in *Disruption) DeepCopy() *Disruption {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InstanceTypesSummary) DeepCopyInto(out *InstanceTypesSummary) {
+	*out = *in
+	if in.Sample != nil {
+		in, out := &in.Sample, &out.Sample
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InstanceTypesSummary.
+func (in *InstanceTypesSummary) DeepCopy() *InstanceTypesSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(InstanceTypesSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KubeletConfiguration) DeepCopyInto(out *KubeletConfiguration) {
 	*out = *in
@@ -301,6 +336,25 @@ func (in *NodeClaimSpec) DeepCopyInto(out *NodeClaimSpec) {
 		*out = new(NodeClassReference)
 		**out = **in
 	}
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaxInstanceResources != nil {
+		in, out := &in.MaxInstanceResources, &out.MaxInstanceResources
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.TagLabels != nil {
+		in, out := &in.TagLabels, &out.TagLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeClaimSpec.
@@ -452,6 +506,13 @@ func (in *NodePoolSpec) DeepCopyInto(out *NodePoolSpec) {
 			(*out)[key] = val.DeepCopy()
 		}
 	}
+	if in.RequestRounding != nil {
+		in, out := &in.RequestRounding, &out.RequestRounding
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
 	if in.Weight != nil {
 		in, out := &in.Weight, &out.Weight
 		*out = new(int32)
@@ -479,6 +540,18 @@ func (in *NodePoolStatus) DeepCopyInto(out *NodePoolStatus) {
 			(*out)[key] = val.DeepCopy()
 		}
 	}
+	if in.InstanceTypes != nil {
+		in, out := &in.InstanceTypes, &out.InstanceTypes
+		*out = new(InstanceTypesSummary)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make(apis.Conditions, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolStatus.
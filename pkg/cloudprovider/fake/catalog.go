@@ -0,0 +1,112 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+)
+
+// Catalog is a frozen snapshot of a cloud provider's instance types, offerings and prices. It's read from JSON or
+// YAML files so that tests and offline tools can schedule against a realistic catalog without a live cloud provider.
+type Catalog struct {
+	InstanceTypes []CatalogInstanceType `json:"instanceTypes"`
+}
+
+// CatalogInstanceType is the serializable form of InstanceTypeOptions. Fields that are left out are defaulted the
+// same way as they are by NewInstanceType.
+type CatalogInstanceType struct {
+	Name             string            `json:"name"`
+	Architecture     string            `json:"architecture,omitempty"`
+	OperatingSystems []string          `json:"operatingSystems,omitempty"`
+	Resources        v1.ResourceList   `json:"resources,omitempty"`
+	Offerings        []CatalogOffering `json:"offerings,omitempty"`
+}
+
+type CatalogOffering struct {
+	CapacityType string  `json:"capacityType"`
+	Zone         string  `json:"zone"`
+	Price        float64 `json:"price"`
+	// Available defaults to true
+	Available *bool `json:"available,omitempty"`
+}
+
+// NewCloudProviderFromFile returns a CloudProvider that offers the instance types of the catalog file at path. The
+// instance types are cleared by Reset, so tests that reset the CloudProvider should load them with InstanceTypesFromFile.
+func NewCloudProviderFromFile(path string) (*CloudProvider, error) {
+	instanceTypes, err := InstanceTypesFromFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := NewCloudProvider()
+	c.InstanceTypes = instanceTypes
+	return c, nil
+}
+
+// InstanceTypesFromFile reads the instance types of the JSON or YAML catalog file at path
+func InstanceTypesFromFile(path string) ([]*cloudprovider.InstanceType, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading catalog, %w", err)
+	}
+	instanceTypes, err := ParseInstanceTypes(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing catalog %s, %w", path, err)
+	}
+	return instanceTypes, nil
+}
+
+// ParseInstanceTypes returns the instance types of a JSON or YAML catalog
+func ParseInstanceTypes(data []byte) ([]*cloudprovider.InstanceType, error) {
+	catalog := Catalog{}
+	if err := yaml.UnmarshalStrict(data, &catalog); err != nil {
+		return nil, err
+	}
+	names := sets.New[string]()
+	var instanceTypes []*cloudprovider.InstanceType
+	for i, it := range catalog.InstanceTypes {
+		if it.Name == "" {
+			return nil, fmt.Errorf("instance type %d has no name", i)
+		}
+		if names.Has(it.Name) {
+			return nil, fmt.Errorf("instance type %s is defined more than once", it.Name)
+		}
+		names.Insert(it.Name)
+		instanceTypes = append(instanceTypes, NewInstanceType(InstanceTypeOptions{
+			Name:             it.Name,
+			Architecture:     it.Architecture,
+			OperatingSystems: sets.New(it.OperatingSystems...),
+			Resources:        it.Resources,
+			Offerings: lo.Map(it.Offerings, func(o CatalogOffering, _ int) cloudprovider.Offering {
+				return cloudprovider.Offering{
+					CapacityType: o.CapacityType,
+					Zone:         o.Zone,
+					Price:        o.Price,
+					Available:    lo.FromPtrOr(o.Available, true),
+				}
+			}),
+		}))
+	}
+	return instanceTypes, nil
+}
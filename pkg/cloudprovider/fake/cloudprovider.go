@@ -51,10 +51,18 @@ type CloudProvider struct {
 	AllowedCreateCalls int
 	NextCreateErr      error
 	NextDeleteErr      error
+	NextGetErr         error
 	DeleteCalls        []*v1beta1.NodeClaim
 
-	CreatedNodeClaims         map[string]*v1beta1.NodeClaim
-	Drifted                   cloudprovider.DriftReason
+	CreatedNodeClaims map[string]*v1beta1.NodeClaim
+	// CreatedTags are the instance tags that the created NodeClaims were launched with, keyed by provider id
+	CreatedTags map[string]map[string]string
+	Drifted     cloudprovider.DriftReason
+	// NodeMatcher is used by MatchNode to associate Nodes with NodeClaims when their providerIDs don't match
+	NodeMatcher func(*v1beta1.NodeClaim, *v1.Node) bool
+	// ScheduledMaintenance are the maintenance events returned by MaintenanceEvents, keyed by provider id
+	ScheduledMaintenance      map[string][]cloudprovider.MaintenanceEvent
+	NextMaintenanceEventsErr  error
 	NodeClassGroupVersionKind []schema.GroupVersionKind
 }
 
@@ -62,8 +70,10 @@ func NewCloudProvider() *CloudProvider {
 	return &CloudProvider{
 		AllowedCreateCalls:       math.MaxInt,
 		CreatedNodeClaims:        map[string]*v1beta1.NodeClaim{},
+		CreatedTags:              map[string]map[string]string{},
 		InstanceTypesForNodePool: map[string][]*cloudprovider.InstanceType{},
 		ErrorsForNodePool:        map[string]error{},
+		ScheduledMaintenance:     map[string][]cloudprovider.MaintenanceEvent{},
 	}
 }
 
@@ -73,14 +83,19 @@ func (c *CloudProvider) Reset() {
 	defer c.mu.Unlock()
 	c.CreateCalls = nil
 	c.CreatedNodeClaims = map[string]*v1beta1.NodeClaim{}
+	c.CreatedTags = map[string]map[string]string{}
 	c.InstanceTypes = nil
 	c.InstanceTypesForNodePool = map[string][]*cloudprovider.InstanceType{}
 	c.ErrorsForNodePool = map[string]error{}
 	c.AllowedCreateCalls = math.MaxInt
 	c.NextCreateErr = nil
 	c.NextDeleteErr = nil
+	c.NextGetErr = nil
 	c.DeleteCalls = []*v1beta1.NodeClaim{}
 	c.Drifted = "drifted"
+	c.NodeMatcher = nil
+	c.ScheduledMaintenance = map[string][]cloudprovider.MaintenanceEvent{}
+	c.NextMaintenanceEventsErr = nil
 	c.NodeClassGroupVersionKind = []schema.GroupVersionKind{
 		{
 			Group:   "",
@@ -109,7 +124,7 @@ func (c *CloudProvider) Create(ctx context.Context, nodeClaim *v1beta1.NodeClaim
 	instanceTypes := lo.Filter(lo.Must(c.GetInstanceTypes(ctx, np)), func(i *cloudprovider.InstanceType, _ int) bool {
 		return reqs.Compatible(i.Requirements, scheduling.AllowUndefinedWellKnownLabels) == nil &&
 			len(i.Offerings.Compatible(reqs).Available()) > 0 &&
-			resources.Fits(nodeClaim.Spec.Resources.Requests, i.Allocatable())
+			resources.Fits(nodeClaim.Spec.Resources.Requests, i.AllocatableFor(reqs))
 	})
 	// Order instance types so that we get the cheapest instance types of the available offerings
 	sort.Slice(instanceTypes, func(i, j int) bool {
@@ -130,9 +145,14 @@ func (c *CloudProvider) Create(ctx context.Context, nodeClaim *v1beta1.NodeClaim
 		if reqs.Compatible(scheduling.NewRequirements(
 			scheduling.NewRequirement(v1.LabelTopologyZone, v1.NodeSelectorOpIn, o.Zone),
 			scheduling.NewRequirement(v1beta1.CapacityTypeLabelKey, v1.NodeSelectorOpIn, o.CapacityType),
-		), scheduling.AllowUndefinedWellKnownLabels) == nil {
+		), scheduling.AllowUndefinedWellKnownLabels) == nil && o.Requirements.Intersects(reqs) == nil {
 			labels[v1.LabelTopologyZone] = o.Zone
 			labels[v1beta1.CapacityTypeLabelKey] = o.CapacityType
+			for key, requirement := range o.Requirements {
+				if requirement = requirement.Intersection(reqs.Get(key)); requirement.Operator() == v1.NodeSelectorOpIn {
+					labels[key] = requirement.Values()[0]
+				}
+			}
 			break
 		}
 	}
@@ -146,17 +166,23 @@ func (c *CloudProvider) Create(ctx context.Context, nodeClaim *v1beta1.NodeClaim
 		Status: v1beta1.NodeClaimStatus{
 			ProviderID:  test.RandomProviderID(),
 			Capacity:    functional.FilterMap(instanceType.Capacity, func(_ v1.ResourceName, v resource.Quantity) bool { return !resources.IsZero(v) }),
-			Allocatable: functional.FilterMap(instanceType.Allocatable(), func(_ v1.ResourceName, v resource.Quantity) bool { return !resources.IsZero(v) }),
+			Allocatable: functional.FilterMap(instanceType.AllocatableFor(reqs), func(_ v1.ResourceName, v resource.Quantity) bool { return !resources.IsZero(v) }),
 		},
 	}
 	c.CreatedNodeClaims[created.Status.ProviderID] = created
+	c.CreatedTags[created.Status.ProviderID] = cloudprovider.InstanceTags(created)
 	return created, nil
 }
 
 func (c *CloudProvider) Get(_ context.Context, id string) (*v1beta1.NodeClaim, error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
+	if c.NextGetErr != nil {
+		tempErr := c.NextGetErr
+		c.NextGetErr = nil
+		return nil, tempErr
+	}
 	if nodeClaim, ok := c.CreatedNodeClaims[id]; ok {
 		return nodeClaim.DeepCopy(), nil
 	}
@@ -250,6 +276,28 @@ func (c *CloudProvider) IsDrifted(context.Context, *v1beta1.NodeClaim) (cloudpro
 	return c.Drifted, nil
 }
 
+func (c *CloudProvider) MatchNode(nodeClaim *v1beta1.NodeClaim, node *v1.Node) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.NodeMatcher == nil {
+		return false
+	}
+	return c.NodeMatcher(nodeClaim, node)
+}
+
+func (c *CloudProvider) MaintenanceEvents(_ context.Context, nodeClaim *v1beta1.NodeClaim) ([]cloudprovider.MaintenanceEvent, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.NextMaintenanceEventsErr != nil {
+		tempError := c.NextMaintenanceEventsErr
+		c.NextMaintenanceEventsErr = nil
+		return nil, tempError
+	}
+	return c.ScheduledMaintenance[nodeClaim.Status.ProviderID], nil
+}
+
 // Name returns the CloudProvider implementation name.
 func (c *CloudProvider) Name() string {
 	return "fake"
@@ -35,6 +35,8 @@ const (
 	LabelInstanceSize                       = "size"
 	ExoticInstanceLabelKey                  = "special"
 	IntegerInstanceLabelKey                 = "integer"
+	HypervisorLabelKey                      = "hypervisor"
+	OSVersionLabelKey                       = "os-version"
 	ResourceGPUVendorA      v1.ResourceName = "fake.com/vendor-a"
 	ResourceGPUVendorB      v1.ResourceName = "fake.com/vendor-b"
 )
@@ -44,6 +46,8 @@ func init() {
 		LabelInstanceSize,
 		ExoticInstanceLabelKey,
 		IntegerInstanceLabelKey,
+		HypervisorLabelKey,
+		OSVersionLabelKey,
 	)
 }
 
@@ -88,6 +92,10 @@ func NewInstanceTypeWithCustomRequirement(options InstanceTypeOptions, customReq
 		scheduling.NewRequirement(LabelInstanceSize, v1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(ExoticInstanceLabelKey, v1.NodeSelectorOpDoesNotExist),
 		scheduling.NewRequirement(IntegerInstanceLabelKey, v1.NodeSelectorOpIn, fmt.Sprint(options.Resources.Cpu().Value())),
+		offeringRequirement(HypervisorLabelKey, options.Offerings),
+		offeringRequirement(OSVersionLabelKey, options.Offerings),
+		cloudprovider.LocalNVMeRequirement(options.LocalNVMe),
+		cloudprovider.NUMANodesRequirement(options.NUMANodes),
 	)
 	if customReq != nil {
 		requirements.Add(customReq)
@@ -114,6 +122,21 @@ func NewInstanceTypeWithCustomRequirement(options InstanceTypeOptions, customReq
 	}
 }
 
+// offeringRequirement returns the requirement on a label that's only known to the cloudprovider for the union of its
+// values across the available offerings
+func offeringRequirement(key string, offerings cloudprovider.Offerings) *scheduling.Requirement {
+	values := sets.New[string]()
+	for _, o := range offerings.Available() {
+		if o.Requirements.Has(key) {
+			values.Insert(o.Requirements.Get(key).Values()...)
+		}
+	}
+	if values.Len() == 0 {
+		return scheduling.NewRequirement(key, v1.NodeSelectorOpDoesNotExist)
+	}
+	return scheduling.NewRequirement(key, v1.NodeSelectorOpIn, sets.List(values)...)
+}
+
 // InstanceTypesAssorted create many unique instance types with varying CPU/memory/architecture/OS/zone/capacity type.
 func InstanceTypesAssorted() []*cloudprovider.InstanceType {
 	var instanceTypes []*cloudprovider.InstanceType
@@ -178,6 +201,10 @@ type InstanceTypeOptions struct {
 	Architecture     string
 	OperatingSystems sets.Set[string]
 	Resources        v1.ResourceList
+	// LocalNVMe is the total size of the instance type's local NVMe instance storage
+	LocalNVMe resource.Quantity
+	// NUMANodes is the number of NUMA nodes of the instance type, zero if its NUMA topology is unknown
+	NUMANodes int
 }
 
 func PriceFromResources(resources v1.ResourceList) float64 {
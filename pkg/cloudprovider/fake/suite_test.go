@@ -0,0 +1,107 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	. "knative.dev/pkg/logging/testing"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/test"
+)
+
+var ctx context.Context
+
+func TestFake(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CloudProvider/Fake")
+}
+
+var _ = Describe("Catalog", func() {
+	It("should load instance types, offerings and prices from a catalog file", func() {
+		instanceTypes, err := fake.InstanceTypesFromFile("testdata/catalog.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lo.Map(instanceTypes, func(it *cloudprovider.InstanceType, _ int) string { return it.Name })).To(Equal([]string{"small-amd64", "large-arm64", "defaulted"}))
+
+		small := instanceTypes[0]
+		Expect(small.Capacity.Cpu().Equal(resource.MustParse("2"))).To(BeTrue())
+		Expect(small.Capacity.Memory().Equal(resource.MustParse("4Gi"))).To(BeTrue())
+		Expect(small.Capacity.Pods().Equal(resource.MustParse("20"))).To(BeTrue())
+		Expect(small.Requirements.Get(v1.LabelArchStable).Values()).To(ConsistOf(v1beta1.ArchitectureAmd64))
+		Expect(small.Requirements.Get(v1.LabelOSStable).Values()).To(ConsistOf(string(v1.Linux)))
+		Expect(small.Offerings).To(HaveLen(3))
+		Expect(small.Offerings.Available()).To(HaveLen(2))
+		Expect(small.Offerings.Available().Cheapest().Price).To(BeNumerically("~", 0.03))
+		Expect(small.Requirements.Get(v1.LabelTopologyZone).Values()).To(ConsistOf("test-zone-1"))
+		Expect(small.Requirements.Get(v1beta1.CapacityTypeLabelKey).Values()).To(ConsistOf(v1beta1.CapacityTypeSpot, v1beta1.CapacityTypeOnDemand))
+
+		large := instanceTypes[1]
+		Expect(large.Requirements.Get(v1.LabelArchStable).Values()).To(ConsistOf(v1beta1.ArchitectureArm64))
+		Expect(large.Offerings).To(HaveLen(1))
+		Expect(large.Offerings[0].Price).To(BeNumerically("~", 0.8))
+	})
+	It("should default the fields that are left out", func() {
+		instanceTypes, err := fake.InstanceTypesFromFile("testdata/catalog.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		defaulted := instanceTypes[2]
+		expected := fake.NewInstanceType(fake.InstanceTypeOptions{Name: "defaulted"})
+		Expect(defaulted.Capacity).To(Equal(expected.Capacity))
+		Expect(defaulted.Offerings).To(Equal(expected.Offerings))
+		Expect(defaulted.Requirements.Get(v1.LabelArchStable).Values()).To(ConsistOf(v1beta1.ArchitectureAmd64))
+	})
+	It("should parse JSON catalogs", func() {
+		instanceTypes, err := fake.ParseInstanceTypes([]byte(`{"instanceTypes": [{"name": "json", "resources": {"cpu": "8"}, "offerings": [{"capacityType": "spot", "zone": "test-zone-3", "price": 0.2}]}]}`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).To(HaveLen(1))
+		Expect(instanceTypes[0].Name).To(Equal("json"))
+		Expect(instanceTypes[0].Capacity.Cpu().Equal(resource.MustParse("8"))).To(BeTrue())
+		Expect(instanceTypes[0].Offerings).To(Equal(cloudprovider.Offerings{{CapacityType: v1beta1.CapacityTypeSpot, Zone: "test-zone-3", Price: 0.2, Available: true}}))
+	})
+	It("should fail for instance types without a name", func() {
+		_, err := fake.ParseInstanceTypes([]byte(`instanceTypes: [{architecture: amd64}]`))
+		Expect(err).To(HaveOccurred())
+	})
+	It("should fail for instance types that are defined more than once", func() {
+		_, err := fake.ParseInstanceTypes([]byte(`instanceTypes: [{name: duplicate}, {name: duplicate}]`))
+		Expect(err).To(HaveOccurred())
+	})
+	It("should fail for unknown fields", func() {
+		_, err := fake.ParseInstanceTypes([]byte(`instanceTypes: [{name: typo, offering: []}]`))
+		Expect(err).To(HaveOccurred())
+	})
+	It("should fail for a missing catalog file", func() {
+		_, err := fake.InstanceTypesFromFile("testdata/missing.yaml")
+		Expect(err).To(HaveOccurred())
+	})
+	It("should offer the catalog's instance types from the cloud provider", func() {
+		cloudProvider, err := fake.NewCloudProviderFromFile("testdata/catalog.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		instanceTypes, err := cloudProvider.GetInstanceTypes(ctx, test.NodePool())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instanceTypes).To(HaveLen(3))
+	})
+})
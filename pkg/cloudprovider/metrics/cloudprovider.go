@@ -20,6 +20,7 @@ import (
 	"context"
 
 	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
 	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
@@ -155,6 +156,32 @@ func (d *decorator) IsDrifted(ctx context.Context, nodeClaim *v1beta1.NodeClaim)
 	return isDrifted, err
 }
 
+func (d *decorator) MaintenanceEvents(ctx context.Context, nodeClaim *v1beta1.NodeClaim) ([]cloudprovider.MaintenanceEvent, error) {
+	provider, ok := d.CloudProvider.(cloudprovider.MaintenanceEventsProvider)
+	if !ok {
+		return nil, nil
+	}
+	method := "MaintenanceEvents"
+	defer metrics.Measure(methodDurationHistogramVec.With(getLabelsMapForDuration(ctx, d, method)))()
+	maintenanceEvents, err := provider.MaintenanceEvents(ctx, nodeClaim)
+	if err != nil {
+		errorsTotalCounter.With(getLabelsMapForError(ctx, d, method, err)).Inc()
+	}
+	return maintenanceEvents, err
+}
+
+func (d *decorator) MatchNode(nodeClaim *v1beta1.NodeClaim, node *v1.Node) bool {
+	matcher, ok := d.CloudProvider.(cloudprovider.NodeMatcher)
+	if !ok {
+		return false
+	}
+	return matcher.MatchNode(nodeClaim, node)
+}
+
+func (d *decorator) Unwrap() cloudprovider.CloudProvider {
+	return d.CloudProvider
+}
+
 // getLabelsMapForDuration is a convenience func that constructs a map[string]string
 // for a prometheus Label map used to compose a duration metric spec
 func getLabelsMapForDuration(ctx context.Context, d *decorator, method string) map[string]string {
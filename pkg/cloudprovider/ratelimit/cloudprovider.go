@@ -0,0 +1,112 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/flowcontrol"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+)
+
+// decorator implements CloudProvider
+var _ cloudprovider.CloudProvider = (*decorator)(nil)
+
+type decorator struct {
+	cloudprovider.CloudProvider
+	rateLimiter flowcontrol.RateLimiter
+}
+
+// NewRateLimiter returns the rate limiter for calls to the cloud provider API, configured by the cloud provider qps and
+// burst options. It returns nil if the cloud provider qps isn't set, in which case calls aren't rate limited.
+func NewRateLimiter(ctx context.Context) flowcontrol.RateLimiter {
+	if options.FromContext(ctx).CloudProviderQPS == 0 {
+		return nil
+	}
+	return flowcontrol.NewTokenBucketRateLimiter(float32(options.FromContext(ctx).CloudProviderQPS), options.FromContext(ctx).CloudProviderBurst)
+}
+
+// Decorate returns a new `CloudProvider` instance that will delegate all method calls to the argument,
+// `cloudProvider`, after waiting on the rate limiter for the methods that call the cloud provider API (Create, Delete,
+// Get and List). The other methods are expected to be served from the cloud provider's caches so they aren't rate
+// limited. If the rate limiter is nil, `cloudProvider` is returned as is.
+func Decorate(cloudProvider cloudprovider.CloudProvider, rateLimiter flowcontrol.RateLimiter) cloudprovider.CloudProvider {
+	if rateLimiter == nil {
+		return cloudProvider
+	}
+	return &decorator{CloudProvider: cloudProvider, rateLimiter: rateLimiter}
+}
+
+func (d *decorator) Create(ctx context.Context, nodeClaim *v1beta1.NodeClaim) (*v1beta1.NodeClaim, error) {
+	if err := d.wait(ctx); err != nil {
+		return nil, err
+	}
+	return d.CloudProvider.Create(ctx, nodeClaim)
+}
+
+func (d *decorator) Delete(ctx context.Context, nodeClaim *v1beta1.NodeClaim) error {
+	if err := d.wait(ctx); err != nil {
+		return err
+	}
+	return d.CloudProvider.Delete(ctx, nodeClaim)
+}
+
+func (d *decorator) Get(ctx context.Context, id string) (*v1beta1.NodeClaim, error) {
+	if err := d.wait(ctx); err != nil {
+		return nil, err
+	}
+	return d.CloudProvider.Get(ctx, id)
+}
+
+func (d *decorator) List(ctx context.Context) ([]*v1beta1.NodeClaim, error) {
+	if err := d.wait(ctx); err != nil {
+		return nil, err
+	}
+	return d.CloudProvider.List(ctx)
+}
+
+func (d *decorator) MaintenanceEvents(ctx context.Context, nodeClaim *v1beta1.NodeClaim) ([]cloudprovider.MaintenanceEvent, error) {
+	provider, ok := d.CloudProvider.(cloudprovider.MaintenanceEventsProvider)
+	if !ok {
+		return nil, nil
+	}
+	return provider.MaintenanceEvents(ctx, nodeClaim)
+}
+
+func (d *decorator) MatchNode(nodeClaim *v1beta1.NodeClaim, node *v1.Node) bool {
+	matcher, ok := d.CloudProvider.(cloudprovider.NodeMatcher)
+	if !ok {
+		return false
+	}
+	return matcher.MatchNode(nodeClaim, node)
+}
+
+func (d *decorator) Unwrap() cloudprovider.CloudProvider {
+	return d.CloudProvider
+}
+
+func (d *decorator) wait(ctx context.Context) error {
+	if err := d.rateLimiter.Wait(ctx); err != nil {
+		return fmt.Errorf("waiting on cloud provider rate limiter, %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimit_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	. "knative.dev/pkg/logging/testing"
+
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/ratelimit"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+)
+
+var ctx context.Context
+
+func TestRateLimit(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "RateLimit")
+}
+
+// rateLimiter counts the calls that wait on it and fails them with err if it's set
+type rateLimiter struct {
+	waits int
+	err   error
+}
+
+func (r *rateLimiter) TryAccept() bool { return true }
+func (r *rateLimiter) Accept()         {}
+func (r *rateLimiter) Stop()           {}
+func (r *rateLimiter) QPS() float32    { return 1 }
+func (r *rateLimiter) Wait(context.Context) error {
+	r.waits++
+	return r.err
+}
+
+var _ = Describe("RateLimit", func() {
+	Context("NewRateLimiter", func() {
+		It("should not rate limit the cloud provider when the qps isn't set", func() {
+			ctx = options.ToContext(ctx, test.Options())
+			Expect(ratelimit.NewRateLimiter(ctx)).To(BeNil())
+		})
+		It("should rate limit the cloud provider with the configured qps", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{CloudProviderQPS: lo.ToPtr(20), CloudProviderBurst: lo.ToPtr(40)}))
+			rl := ratelimit.NewRateLimiter(ctx)
+			Expect(rl).ToNot(BeNil())
+			Expect(rl.QPS()).To(BeNumerically("==", 20))
+		})
+		It("should allow up to the configured burst of calls without waiting", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{CloudProviderQPS: lo.ToPtr(1), CloudProviderBurst: lo.ToPtr(3)}))
+			rl := ratelimit.NewRateLimiter(ctx)
+			for i := 0; i < 3; i++ {
+				Expect(rl.TryAccept()).To(BeTrue())
+			}
+			Expect(rl.TryAccept()).To(BeFalse())
+		})
+	})
+	Context("Decorate", func() {
+		var cloudProvider *fake.CloudProvider
+		var rl *rateLimiter
+		BeforeEach(func() {
+			cloudProvider = fake.NewCloudProvider()
+			rl = &rateLimiter{}
+		})
+		It("should not decorate the cloud provider without a rate limiter", func() {
+			Expect(ratelimit.Decorate(cloudProvider, nil)).To(BeIdenticalTo(cloudProvider))
+		})
+		It("should wait on the rate limiter for the calls to the cloud provider API", func() {
+			decorated := ratelimit.Decorate(cloudProvider, rl)
+			nodeClaim, err := decorated.Create(ctx, test.NodeClaim())
+			Expect(err).ToNot(HaveOccurred())
+			_, err = decorated.Get(ctx, nodeClaim.Status.ProviderID)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = decorated.List(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decorated.Delete(ctx, nodeClaim)).To(Succeed())
+			Expect(rl.waits).To(Equal(4))
+			Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+			Expect(cloudProvider.DeleteCalls).To(HaveLen(1))
+		})
+		It("should not wait on the rate limiter for the calls that are served from the cloud provider's caches", func() {
+			decorated := ratelimit.Decorate(cloudProvider, rl)
+			_, err := decorated.GetInstanceTypes(ctx, test.NodePool())
+			Expect(err).ToNot(HaveOccurred())
+			_, err = decorated.IsDrifted(ctx, test.NodeClaim())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rl.waits).To(Equal(0))
+		})
+		It("should not call the cloud provider when waiting on the rate limiter fails", func() {
+			rl.err = fmt.Errorf("context canceled")
+			decorated := ratelimit.Decorate(cloudProvider, rl)
+			_, err := decorated.Create(ctx, test.NodeClaim())
+			Expect(err).To(HaveOccurred())
+			Expect(cloudProvider.CreateCalls).To(BeEmpty())
+		})
+	})
+})
@@ -23,9 +23,11 @@ import (
 	"math"
 	"sort"
 	"sync"
+	"time"
 
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
@@ -60,6 +62,61 @@ type CloudProvider interface {
 	GetSupportedNodeClasses() []schema.GroupVersionKind
 }
 
+// NodeMatcher is an optional interface that is implemented by CloudProviders that report providerIDs in a nonstandard
+// format, or that set them late. Nodes are only matched to NodeClaims by their providerID for CloudProviders that
+// don't implement it.
+type NodeMatcher interface {
+	// MatchNode returns whether a Node belongs to a NodeClaim when the Node's providerID doesn't match the NodeClaim's.
+	MatchNode(*v1beta1.NodeClaim, *v1.Node) bool
+}
+
+// MaintenanceEventsProvider is an optional interface that is implemented by CloudProviders that signal the maintenance
+// they have scheduled for instances. CloudProviders that don't implement it never have their NodeClaims replaced for
+// maintenance.
+type MaintenanceEventsProvider interface {
+	// MaintenanceEvents returns the maintenance events that the cloud provider has scheduled for the NodeClaim's
+	// instance. NodeClaims with scheduled maintenance are replaced ahead of other disruptions so that their pods are
+	// moved before the instance is stopped.
+	MaintenanceEvents(context.Context, *v1beta1.NodeClaim) ([]MaintenanceEvent, error)
+}
+
+// Decorator is implemented by CloudProviders that wrap another CloudProvider, e.g. to measure or rate limit its calls.
+// Decorators implement the optional interfaces by delegating to the CloudProvider they wrap, so Unwrap is used to tell
+// whether that CloudProvider implements them.
+type Decorator interface {
+	Unwrap() CloudProvider
+}
+
+// As returns the CloudProvider as the optional interface T if it implements it. Decorators only count as implementing
+// T if the CloudProvider that they decorate does.
+func As[T any](cloudProvider CloudProvider) (T, bool) {
+	var zero T
+	inner := cloudProvider
+	for {
+		d, ok := inner.(Decorator)
+		if !ok {
+			break
+		}
+		inner = d.Unwrap()
+	}
+	if _, ok := inner.(T); !ok {
+		return zero, false
+	}
+	t, ok := cloudProvider.(T)
+	return t, ok
+}
+
+// MaintenanceEvent is maintenance that the cloud provider has scheduled for an instance, which stops or terminates
+// the instance regardless of the pods running on it
+type MaintenanceEvent struct {
+	// ID identifies the event with the cloud provider
+	ID string
+	// Reason describes the maintenance, e.g. "system-reboot" or "instance-retirement"
+	Reason string
+	// NotBefore is the earliest time at which the maintenance may begin
+	NotBefore time.Time
+}
+
 // InstanceType describes the properties of a potential node (either concrete attributes of an instance of this type
 // or supported options in the case of arrays)
 type InstanceType struct {
@@ -75,17 +132,80 @@ type InstanceType struct {
 	// Overhead is the amount of resource overhead expected to be used by kubelet and any other system daemons outside
 	// of Kubernetes.
 	Overhead *InstanceTypeOverhead
-
-	once        sync.Once
-	allocatable v1.ResourceList
+	// OSOverhead overrides the Overhead for nodes running a specific operating system, keyed by the kubernetes.io/os
+	// value. This allows modeling operating systems like Windows that reserve substantially more resources for system
+	// daemons. Operating systems without an entry use the Overhead.
+	OSOverhead map[string]*InstanceTypeOverhead
+	// OSCapacity overrides entries of the Capacity for nodes running a specific operating system, keyed by the
+	// kubernetes.io/os value. This allows modeling operating systems like Windows that support fewer pods per node.
+	OSCapacity map[string]v1.ResourceList
+	// Deprecated instance types are being retired by the CloudProvider. No new nodes are launched with them, and the
+	// nodes that are running them are drifted so that they're replaced before the instance type becomes unavailable.
+	Deprecated bool
+
+	once          sync.Once
+	allocatable   v1.ResourceList
+	osAllocatable map[string]v1.ResourceList
 }
 
 type InstanceTypes []*InstanceType
 
+// LocalNVMeRequirement returns the requirement on the v1beta1.InstanceLocalNVMeLabelKey label for an instance type with
+// the given total local NVMe instance storage, rounded up to the nearest GiB. Instance types without local NVMe get
+// a DoesNotExist requirement so that they aren't selected for pods that require local storage.
+func LocalNVMeRequirement(size resource.Quantity) *scheduling.Requirement {
+	if size.Sign() <= 0 {
+		return scheduling.NewRequirement(v1beta1.InstanceLocalNVMeLabelKey, v1.NodeSelectorOpDoesNotExist)
+	}
+	gib := int64(math.Ceil(size.AsApproximateFloat64() / (1 << 30)))
+	return scheduling.NewRequirement(v1beta1.InstanceLocalNVMeLabelKey, v1.NodeSelectorOpIn, fmt.Sprint(gib))
+}
+
+// NUMANodesRequirement returns the requirement on the v1beta1.NUMANodesLabelKey label for an instance type with the
+// given number of NUMA nodes. Instance types whose NUMA topology is unknown get a DoesNotExist requirement so that they
+// aren't selected for pods that require a minimum number of NUMA nodes.
+func NUMANodesRequirement(numaNodes int) *scheduling.Requirement {
+	if numaNodes <= 0 {
+		return scheduling.NewRequirement(v1beta1.NUMANodesLabelKey, v1.NodeSelectorOpDoesNotExist)
+	}
+	return scheduling.NewRequirement(v1beta1.NUMANodesLabelKey, v1.NodeSelectorOpIn, fmt.Sprint(numaNodes))
+}
+
+// InstanceTags returns the tags that the instance launched for a NodeClaim should be tagged with, keyed by the tag
+// keys of its TagLabels. A label's value is taken from the NodeClaim's labels or, if it isn't set there, from a
+// requirement that restricts it to a single value. Labels whose value isn't known yet aren't tagged.
+func InstanceTags(nodeClaim *v1beta1.NodeClaim) map[string]string {
+	if len(nodeClaim.Spec.TagLabels) == 0 {
+		return nil
+	}
+	reqs := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	tags := map[string]string{}
+	for key, tag := range nodeClaim.Spec.TagLabels {
+		if value, ok := nodeClaim.Labels[key]; ok {
+			tags[tag] = value
+			continue
+		}
+		if reqs.Has(key) {
+			if requirement := reqs.Get(key); requirement.Operator() == v1.NodeSelectorOpIn && requirement.Len() == 1 {
+				tags[tag] = requirement.Values()[0]
+			}
+		}
+	}
+	return tags
+}
+
 // precompute is used to ensure we only compute the allocatable resources onces as its called many times
 // and the operation is fairly expensive.
 func (i *InstanceType) precompute() {
 	i.allocatable = resources.Subtract(i.Capacity, i.Overhead.Total())
+	i.osAllocatable = map[string]v1.ResourceList{}
+	for _, os := range lo.Union(lo.Keys(i.OSOverhead), lo.Keys(i.OSCapacity)) {
+		overhead, ok := i.OSOverhead[os]
+		if !ok {
+			overhead = i.Overhead
+		}
+		i.osAllocatable[os] = resources.Subtract(lo.Assign(i.Capacity, i.OSCapacity[os]), overhead.Total())
+	}
 }
 
 func (i *InstanceType) Allocatable() v1.ResourceList {
@@ -93,6 +213,29 @@ func (i *InstanceType) Allocatable() v1.ResourceList {
 	return i.allocatable.DeepCopy()
 }
 
+// AllocatableFor returns the allocatable resources of a node of this instance type that is launched with the given
+// requirements, taking operating system specific overhead and capacity into account. If the requirements allow
+// operating systems with differing allocatable resources, the minimum of each resource is returned so that pods
+// that fit will fit regardless of which operating system the node runs.
+func (i *InstanceType) AllocatableFor(reqs scheduling.Requirements) v1.ResourceList {
+	i.once.Do(i.precompute)
+	if len(i.osAllocatable) == 0 {
+		return i.allocatable.DeepCopy()
+	}
+	operatingSystems := i.Requirements.Get(v1.LabelOSStable).Intersection(reqs.Get(v1.LabelOSStable))
+	var candidates []v1.ResourceList
+	for os, allocatable := range i.osAllocatable {
+		if operatingSystems.Has(os) {
+			candidates = append(candidates, allocatable)
+		}
+	}
+	// any other operating system that the requirements allow uses the default allocatable
+	if len(candidates) == 0 || operatingSystems.Len() > len(candidates) {
+		candidates = append(candidates, i.allocatable)
+	}
+	return resources.MinResources(candidates...)
+}
+
 func (its InstanceTypes) OrderByPrice(reqs scheduling.Requirements) InstanceTypes {
 	// Order instance types so that we get the cheapest instance types of the available offerings
 	sort.Slice(its, func(i, j int) bool {
@@ -145,6 +288,17 @@ type Offering struct {
 	// Available is added so that Offerings can return all offerings that have ever existed for an instance type,
 	// so we can get historical pricing data for calculating savings in consolidation
 	Available bool
+	// ReservationExpiry is the time at which the reserved rate backing this offering ends, after which capacity
+	// launched from it is billed at the on-demand rate. This is nil if the offering isn't backed by a reservation.
+	ReservationExpiry *time.Time
+	// Requirements describe the labels, only known to the CloudProvider, that nodes launched from this offering will
+	// have, e.g. the generation of the hypervisor that backs them. Pods that select on these labels are only scheduled
+	// to compatible offerings. The label keys must be registered as v1beta1.WellKnownLabels, and the InstanceType's
+	// Requirements must include the values of all of its offerings. Labels with integer values, e.g. the version of
+	// the image that nodes are launched with, can be selected on with the Gt and Lt operators. Like kube-scheduler,
+	// these operators only compare integers and never match other values, so dotted versions like "5.10" must be
+	// encoded as integers that sort the same way, e.g. "5010" for major*1000+minor.
+	Requirements scheduling.Requirements
 }
 
 type Offerings []Offering
@@ -168,7 +322,8 @@ func (ofs Offerings) Available() Offerings {
 func (ofs Offerings) Compatible(reqs scheduling.Requirements) Offerings {
 	return lo.Filter(ofs, func(offering Offering, _ int) bool {
 		return (!reqs.Has(v1.LabelTopologyZone) || reqs.Get(v1.LabelTopologyZone).Has(offering.Zone)) &&
-			(!reqs.Has(v1beta1.CapacityTypeLabelKey) || reqs.Get(v1beta1.CapacityTypeLabelKey).Has(offering.CapacityType))
+			(!reqs.Has(v1beta1.CapacityTypeLabelKey) || reqs.Get(v1beta1.CapacityTypeLabelKey).Has(offering.CapacityType)) &&
+			offering.Requirements.Intersects(reqs) == nil
 	})
 }
 
@@ -298,3 +453,63 @@ func IgnoreRetryableError(err error) error {
 	}
 	return err
 }
+
+// RateLimitedError is an error type returned by CloudProviders when a launch fails due to the cloud provider API throttling requests
+type RateLimitedError struct {
+	error
+}
+
+func NewRateLimitedError(err error) *RateLimitedError {
+	return &RateLimitedError{
+		error: err,
+	}
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("rate limited, %s", e.error)
+}
+
+func IsRateLimitedError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var rlErr *RateLimitedError
+	return errors.As(err, &rlErr)
+}
+
+func IgnoreRateLimitedError(err error) error {
+	if IsRateLimitedError(err) {
+		return nil
+	}
+	return err
+}
+
+// UnauthorizedError is an error type returned by CloudProviders when a launch fails because the credentials in use aren't permitted to perform it
+type UnauthorizedError struct {
+	error
+}
+
+func NewUnauthorizedError(err error) *UnauthorizedError {
+	return &UnauthorizedError{
+		error: err,
+	}
+}
+
+func (e *UnauthorizedError) Error() string {
+	return fmt.Sprintf("unauthorized, %s", e.error)
+}
+
+func IsUnauthorizedError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var uErr *UnauthorizedError
+	return errors.As(err, &uErr)
+}
+
+func IgnoreUnauthorizedError(err error) error {
+	if IsUnauthorizedError(err) {
+		return nil
+	}
+	return err
+}
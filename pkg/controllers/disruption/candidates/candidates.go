@@ -0,0 +1,123 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package candidates
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+)
+
+// Path is the path on the metrics server that the pending disruption commands are served from
+const Path = "/debug/disruption-candidates"
+
+// Pending holds the disruption commands that are waiting to be approved while running in manual disruption mode. Like
+// the metrics registry, it's shared across the operator so that it can be served from the metrics server, which must
+// be configured before the controllers are constructed.
+var Pending = New()
+
+// Report is the set of commands that the disruption methods computed in a disruption loop but didn't execute because
+// their candidates weren't approved
+type Report struct {
+	Time     time.Time `json:"time"`
+	Commands []Command `json:"commands"`
+}
+
+// Command is a disruption decision along with its estimated impact
+type Command struct {
+	// Method is the disruption method that computed the command, e.g. drift or consolidation, and is the reason that
+	// its candidates are disrupted
+	Method            string      `json:"method"`
+	ConsolidationType string      `json:"consolidationType,omitempty"`
+	Action            string      `json:"action"`
+	Candidates        []Candidate `json:"candidates"`
+	// Replacements is the number of NodeClaims that are launched before the candidates are disrupted
+	Replacements int `json:"replacements,omitempty"`
+	// EstimatedSavings is the estimated reduction in the hourly price of the cluster once the command is executed
+	EstimatedSavings float64 `json:"estimatedSavings,omitempty"`
+}
+
+// Candidate is a node that a command disrupts
+type Candidate struct {
+	NodeName      string `json:"nodeName"`
+	NodeClaimName string `json:"nodeClaimName"`
+	NodePool      string `json:"nodePool"`
+	InstanceType  string `json:"instanceType,omitempty"`
+	CapacityType  string `json:"capacityType,omitempty"`
+	Zone          string `json:"zone,omitempty"`
+	// ReschedulablePods is the number of pods that are evicted from the node and rescheduled
+	ReschedulablePods int     `json:"reschedulablePods"`
+	DisruptionCost    float64 `json:"disruptionCost"`
+	// Approved is true if the node has been approved for disruption
+	Approved bool `json:"approved"`
+}
+
+// Recorder keeps the most recent report
+type Recorder struct {
+	mu     sync.RWMutex
+	report *Report
+}
+
+func New() *Recorder {
+	return &Recorder{}
+}
+
+// Enabled returns true if disruption commands are only executed once they are approved
+func Enabled(ctx context.Context) bool {
+	return options.FromContext(ctx).ManualDisruption
+}
+
+// Record replaces the most recent report with the commands that are pending approval
+func (r *Recorder) Record(ctx context.Context, now time.Time, commands []Command) {
+	if !Enabled(ctx) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report = &Report{Time: now, Commands: append([]Command{}, commands...)}
+}
+
+// Get returns the most recent report, or nil if nothing has been recorded
+func (r *Recorder) Get() *Report {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.report
+}
+
+// Reset clears the most recent report
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.report = nil
+}
+
+// ServeHTTP serves the most recent report as JSON
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	report := r.Get()
+	if report == nil {
+		http.Error(w, "no disruption loop has completed yet", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
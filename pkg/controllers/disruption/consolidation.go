@@ -25,10 +25,10 @@ import (
 
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
-	"sigs.k8s.io/karpenter/pkg/apis/v1alpha5"
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	disruptionevents "sigs.k8s.io/karpenter/pkg/controllers/disruption/events"
@@ -37,13 +37,40 @@ import (
 	pscheduling "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/events"
-	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
+	podutil "sigs.k8s.io/karpenter/pkg/utils/pod"
+	"sigs.k8s.io/karpenter/pkg/utils/resources"
 )
 
 // consolidationTTL is the TTL between creating a consolidation command and validating that it still works.
 const consolidationTTL = 15 * time.Second
 
+type skipValidationDelayKey struct{}
+
+// withoutValidationDelay returns a context in which consolidation commands are validated as soon as they're computed.
+// Commands that are only reported for approval aren't executed, so there's no reason to wait before validating them.
+func withoutValidationDelay(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipValidationDelayKey{}, true)
+}
+
+// validationDelay returns the TTL between creating a consolidation command for the candidates and validating that it
+// still works, which is the longest consolidation validation delay of their nodepools
+func validationDelay(ctx context.Context, defaultDelay time.Duration, candidates []*Candidate) time.Duration {
+	if skip, _ := ctx.Value(skipValidationDelayKey{}).(bool); skip {
+		return 0
+	}
+	if len(candidates) == 0 {
+		return defaultDelay
+	}
+	return lo.Max(lo.Map(candidates, func(c *Candidate, _ int) time.Duration {
+		if d := c.nodePool.Spec.Disruption.ConsolidationValidationDelay; d != nil {
+			return d.Duration
+		}
+		return defaultDelay
+	}))
+}
+
 // MinInstanceTypesForSpotToSpotConsolidation is the minimum number of instanceTypes in a NodeClaim needed to trigger spot-to-spot single-node consolidation
 const MinInstanceTypesForSpotToSpotConsolidation = 15
 
@@ -86,9 +113,8 @@ func (c *consolidation) markConsolidated() {
 
 // ShouldDisrupt is a predicate used to filter candidates
 func (c *consolidation) ShouldDisrupt(_ context.Context, cn *Candidate) bool {
-	// TODO: Remove the check for do-not-consolidate at v1
-	if cn.Annotations()[v1alpha5.DoNotConsolidateNodeAnnotationKey] == "true" {
-		c.recorder.Publish(disruptionevents.Unconsolidatable(cn.Node, cn.NodeClaim, fmt.Sprintf("%s annotation exists", v1alpha5.DoNotConsolidateNodeAnnotationKey))...)
+	if cn.Annotations()[v1beta1.DoNotConsolidateAnnotationKey] == "true" {
+		c.recorder.Publish(disruptionevents.Unconsolidatable(cn.Node, cn.NodeClaim, fmt.Sprintf("%s annotation exists", v1beta1.DoNotConsolidateAnnotationKey))...)
 		return false
 	}
 	// If we don't have the "WhenUnderutilized" policy set, we should not do any of the consolidation methods, but
@@ -101,24 +127,74 @@ func (c *consolidation) ShouldDisrupt(_ context.Context, cn *Candidate) bool {
 		c.recorder.Publish(disruptionevents.Unconsolidatable(cn.Node, cn.NodeClaim, fmt.Sprintf("NodePool %q has consolidation disabled", cn.nodePool.Name))...)
 		return false
 	}
+	// Candidates whose instance type was retired by the cloud provider can't be priced, so they're left to drift
+	if len(cn.instanceType.Offerings) == 0 {
+		c.recorder.Publish(disruptionevents.Unconsolidatable(cn.Node, cn.NodeClaim, fmt.Sprintf("Instance type %q has no offerings", cn.instanceType.Name))...)
+		return false
+	}
+	if threshold := cn.nodePool.Spec.Disruption.UnderutilizationThreshold; threshold != nil && utilization(cn) >= float64(*threshold)/100 {
+		c.recorder.Publish(disruptionevents.Unconsolidatable(cn.Node, cn.NodeClaim, fmt.Sprintf("Node utilization is at or above NodePool %q underutilization threshold of %d%%", cn.nodePool.Name, *threshold))...)
+		return false
+	}
 	return true
 }
 
-// sortCandidates sorts candidates by disruption cost (where the lowest disruption cost is first) and returns the result
+// sortCandidates sorts candidates by disruption cost (where the lowest disruption cost is first) and returns the result.
+// Candidates of NodePools that consolidate the most expensive nodes first are then reordered by price (where the highest
+// price is first), and candidates of NodePools that consolidate the highest StatefulSet ordinals first are reordered by
+// the highest ordinal of their StatefulSet pods, with candidates without StatefulSet pods last. Candidates are reordered
+// within the positions they hold, so they don't jump ahead of the candidates of other NodePools.
 func (c *consolidation) sortCandidates(candidates []*Candidate) []*Candidate {
 	sort.Slice(candidates, func(i int, j int) bool {
 		return candidates[i].disruptionCost < candidates[j].disruptionCost
 	})
+	reorderCandidates(candidates, v1beta1.ConsolidationOrderMostExpensive, func(a, b *Candidate) bool {
+		return candidatePrice(a) > candidatePrice(b)
+	})
+	reorderCandidates(candidates, v1beta1.ConsolidationOrderHighestStatefulSetOrdinal, func(a, b *Candidate) bool {
+		return highestStatefulSetOrdinal(a) > highestStatefulSetOrdinal(b)
+	})
 	return candidates
 }
 
+// reorderCandidates stably sorts the candidates of NodePools with the given consolidation order within the positions
+// that they hold
+func reorderCandidates(candidates []*Candidate, order v1beta1.ConsolidationOrder, less func(a, b *Candidate) bool) {
+	var positions []int
+	var ordered []*Candidate
+	for i, cn := range candidates {
+		if cn.nodePool.Spec.Disruption.ConsolidationOrder == order {
+			positions = append(positions, i)
+			ordered = append(ordered, cn)
+		}
+	}
+	sort.SliceStable(ordered, func(i int, j int) bool {
+		return less(ordered[i], ordered[j])
+	})
+	for i, cn := range ordered {
+		candidates[positions[i]] = cn
+	}
+}
+
+// highestStatefulSetOrdinal returns the highest ordinal of the StatefulSet pods on the candidate, or -1 if it doesn't
+// have any StatefulSet pods. The ordinals of different StatefulSets aren't told apart.
+func highestStatefulSetOrdinal(c *Candidate) int {
+	highest := -1
+	for _, p := range c.reschedulablePods {
+		if ordinal, ok := podutil.StatefulSetOrdinal(p); ok && ordinal > highest {
+			highest = ordinal
+		}
+	}
+	return highest
+}
+
 // computeConsolidation computes a consolidation action to take
 //
 // nolint:gocyclo
 func (c *consolidation) computeConsolidation(ctx context.Context, candidates ...*Candidate) (Command, pscheduling.Results, error) {
 	var err error
 	// Run scheduling simulation to compute consolidation option
-	results, err := SimulateScheduling(ctx, c.kubeClient, c.cluster, c.provisioner, candidates...)
+	results, err := SimulateConsolidation(ctx, c.kubeClient, c.cluster, c.provisioner, candidates...)
 	if err != nil {
 		// if a candidate node is now deleting, just retry
 		if errors.Is(err, errCandidateDeleting) {
@@ -151,6 +227,13 @@ func (c *consolidation) computeConsolidation(ctx context.Context, candidates ...
 		return Command{}, pscheduling.Results{}, nil
 	}
 
+	// NodePools with the FewestNodes scheduling objective trade cost for fewer nodes, so a single node isn't replaced
+	// with a cheaper one. The room that it has to spare would only be launched again once more pods are pending.
+	if len(candidates) == 1 && candidates[0].nodePool.Spec.SchedulingObjective == v1beta1.SchedulingObjectiveFewestNodes {
+		c.recorder.Publish(disruptionevents.Unconsolidatable(candidates[0].Node, candidates[0].NodeClaim, "Can't replace a node of a NodePool with the FewestNodes scheduling objective")...)
+		return Command{}, pscheduling.Results{}, nil
+	}
+
 	// get the current node price based on the offering
 	// fallback if we can't find the specific zonal pricing data
 	candidatePrice, err := getCandidatePrices(candidates)
@@ -171,7 +254,21 @@ func (c *consolidation) computeConsolidation(ctx context.Context, candidates ...
 
 	if allExistingAreSpot &&
 		results.NewNodeClaims[0].Requirements.Get(v1beta1.CapacityTypeLabelKey).Has(v1beta1.CapacityTypeSpot) {
-		return c.computeSpotToSpotConsolidation(ctx, candidates, results, candidatePrice)
+		if !consolidateAcrossCapacityTypes(candidates) ||
+			!results.NewNodeClaims[0].Requirements.Get(v1beta1.CapacityTypeLabelKey).Has(v1beta1.CapacityTypeOnDemand) {
+			return c.computeSpotToSpotConsolidation(ctx, candidates, results, candidatePrice)
+		}
+		// spot-to-spot consolidation narrows the requirements and instance types of the replacement, so we keep a copy
+		// of them to fall back to an on-demand replacement if no spot replacement is possible
+		requirements := scheduling.NewRequirements(results.NewNodeClaims[0].Requirements.Values()...)
+		instanceTypeOptions := results.NewNodeClaims[0].NodeClaimTemplate.InstanceTypeOptions
+		cmd, spotResults, err := c.computeSpotToSpotConsolidation(ctx, candidates, results, candidatePrice)
+		if err != nil || len(cmd.candidates) > 0 {
+			return cmd, spotResults, err
+		}
+		results.NewNodeClaims[0].Requirements = requirements
+		results.NewNodeClaims[0].NodeClaimTemplate.InstanceTypeOptions = instanceTypeOptions
+		return c.computeSpotToOnDemandConsolidation(candidates, results, candidatePrice)
 	}
 
 	var incompatibleMinReqKey string
@@ -197,9 +294,15 @@ func (c *consolidation) computeConsolidation(ctx context.Context, candidates ...
 	// assumption, that the spot variant will launch. We also need to add a requirement to the node to ensure that if
 	// spot capacity is insufficient we don't replace the node with a more expensive on-demand node.  Instead the launch
 	// should fail and we'll just leave the node alone.
+	// If the NodePool allows consolidating across capacity types and none of the cheaper instance types currently has
+	// spot capacity available, we replace the node with a cheaper on-demand node instead of a spot launch that can't succeed.
 	ctReq := results.NewNodeClaims[0].Requirements.Get(v1beta1.CapacityTypeLabelKey)
 	if ctReq.Has(v1beta1.CapacityTypeSpot) && ctReq.Has(v1beta1.CapacityTypeOnDemand) {
-		results.NewNodeClaims[0].Requirements.Add(scheduling.NewRequirement(v1beta1.CapacityTypeLabelKey, v1.NodeSelectorOpIn, v1beta1.CapacityTypeSpot))
+		capacityType := v1beta1.CapacityTypeSpot
+		if consolidateAcrossCapacityTypes(candidates) && !hasAvailableOffering(results.NewNodeClaims[0].InstanceTypeOptions, results.NewNodeClaims[0].Requirements, v1beta1.CapacityTypeSpot) {
+			capacityType = v1beta1.CapacityTypeOnDemand
+		}
+		results.NewNodeClaims[0].Requirements.Add(scheduling.NewRequirement(v1beta1.CapacityTypeLabelKey, v1.NodeSelectorOpIn, capacityType))
 	}
 
 	return Command{
@@ -209,7 +312,7 @@ func (c *consolidation) computeConsolidation(ctx context.Context, candidates ...
 }
 
 // Compute command to execute spot-to-spot consolidation if:
-//  1. The SpotToSpotConsolidation feature flag is set to true.
+//  1. The SpotToSpotConsolidation feature flag is set to true for the NodePools of all the candidates.
 //  2. For single-node consolidation:
 //     a. There are at least 15 cheapest instance type replacement options to consolidate.
 //     b. The current candidate is NOT part of the first 15 cheapest instance types inorder to avoid repeated consolidation.
@@ -217,7 +320,9 @@ func (c *consolidation) computeSpotToSpotConsolidation(ctx context.Context, cand
 	candidatePrice float64) (Command, pscheduling.Results, error) {
 
 	// Spot consolidation is turned off.
-	if !options.FromContext(ctx).FeatureGates.SpotToSpotConsolidation {
+	if !lo.EveryBy(candidates, func(candidate *Candidate) bool {
+		return nodePoolFeatureGates(ctx, c.recorder, candidate.nodePool).SpotToSpotConsolidation
+	}) {
 		if len(candidates) == 1 {
 			c.recorder.Publish(disruptionevents.Unconsolidatable(candidates[0].Node, candidates[0].NodeClaim, "SpotToSpotConsolidation is disabled, can't replace a spot node with a spot node")...)
 		}
@@ -290,6 +395,51 @@ func (c *consolidation) computeSpotToSpotConsolidation(ctx context.Context, cand
 	}, results, nil
 }
 
+// computeSpotToOnDemandConsolidation computes a command that replaces spot candidates with a cheaper on-demand node. This
+// is only considered for NodePools that consolidate across capacity types, once no spot replacement is possible.
+func (c *consolidation) computeSpotToOnDemandConsolidation(candidates []*Candidate, results pscheduling.Results,
+	candidatePrice float64) (Command, pscheduling.Results, error) {
+	results.NewNodeClaims[0].Requirements.Add(scheduling.NewRequirement(v1beta1.CapacityTypeLabelKey, v1.NodeSelectorOpIn, v1beta1.CapacityTypeOnDemand))
+	instanceTypeOptionsWithOnDemandOfferings :=
+		results.NewNodeClaims[0].NodeClaimTemplate.InstanceTypeOptions.Compatible(results.NewNodeClaims[0].Requirements)
+
+	var incompatibleMinReqKey string
+	results.NewNodeClaims[0].NodeClaimTemplate.InstanceTypeOptions, incompatibleMinReqKey, _ =
+		filterByPriceWithMinValues(instanceTypeOptionsWithOnDemandOfferings, results.NewNodeClaims[0].Requirements, candidatePrice)
+
+	if len(results.NewNodeClaims[0].NodeClaimTemplate.InstanceTypeOptions) == 0 {
+		if len(candidates) == 1 {
+			if len(incompatibleMinReqKey) > 0 {
+				c.recorder.Publish(disruptionevents.Unconsolidatable(candidates[0].Node, candidates[0].NodeClaim, fmt.Sprintf("minValues requirement is not met for %s", incompatibleMinReqKey))...)
+			} else {
+				c.recorder.Publish(disruptionevents.Unconsolidatable(candidates[0].Node, candidates[0].NodeClaim, "Can't replace spot node with a cheaper on-demand node")...)
+			}
+		}
+		return Command{}, pscheduling.Results{}, nil
+	}
+	return Command{
+		candidates:   candidates,
+		replacements: results.NewNodeClaims,
+	}, results, nil
+}
+
+// consolidateAcrossCapacityTypes returns true if the NodePools of all the candidates allow consolidation to switch capacity types
+func consolidateAcrossCapacityTypes(candidates []*Candidate) bool {
+	return lo.EveryBy(candidates, func(c *Candidate) bool {
+		return c.nodePool.Spec.Disruption.ConsolidateAcrossCapacityTypes
+	})
+}
+
+// hasAvailableOffering returns true if any of the instance types has an available offering of the given capacity type
+// that is compatible with the requirements
+func hasAvailableOffering(instanceTypes []*cloudprovider.InstanceType, reqs scheduling.Requirements, capacityType string) bool {
+	return lo.ContainsBy(instanceTypes, func(it *cloudprovider.InstanceType) bool {
+		return lo.ContainsBy(it.Offerings.Available(), func(of cloudprovider.Offering) bool {
+			return of.CapacityType == capacityType && reqs.Get(v1.LabelTopologyZone).Has(of.Zone)
+		})
+	})
+}
+
 // getCandidatePrices returns the sum of the prices of the given candidates
 func getCandidatePrices(candidates []*Candidate) (float64, error) {
 	var price float64
@@ -302,3 +452,68 @@ func getCandidatePrices(candidates []*Candidate) (float64, error) {
 	}
 	return price, nil
 }
+
+// candidatePrice returns the price of the candidate's offering, or zero if it can't be determined
+func candidatePrice(c *Candidate) float64 {
+	offering, ok := c.instanceType.Offerings.Get(c.capacityType, c.zone)
+	if !ok {
+		return 0
+	}
+	return offering.Price
+}
+
+// consolidationSavings estimates the hourly cost saved by the command, by NodePool. The savings are the price of the
+// candidates' offerings minus the worst-case launch price of the replacements, which are the prices that consolidation
+// compares when it computes the command. They're split between the candidates' NodePools in proportion to the
+// candidates' prices.
+func consolidationSavings(cmd Command) map[string]float64 {
+	candidatesPrice := lo.SumBy(cmd.candidates, candidatePrice)
+	replacementsPrice := lo.SumBy(cmd.replacements, replacementPrice)
+	savings := candidatesPrice - replacementsPrice
+	if candidatesPrice <= 0 || savings <= 0 {
+		return nil
+	}
+	savingsByNodePool := map[string]float64{}
+	for _, c := range cmd.candidates {
+		savingsByNodePool[c.nodePool.Name] += savings * candidatePrice(c) / candidatesPrice
+	}
+	return savingsByNodePool
+}
+
+// replacementPrice returns the highest price that the replacement may be launched at across its instance type options
+func replacementPrice(replacement *pscheduling.NodeClaim) float64 {
+	return lo.Max(lo.Map(replacement.InstanceTypeOptions, func(it *cloudprovider.InstanceType, _ int) float64 {
+		return worstLaunchPrice(it.Offerings.Available(), replacement.Requirements)
+	}))
+}
+
+// utilization returns the highest ratio of the candidate's reschedulable pod requests to its allocatable across cpu
+// and memory
+func utilization(c *Candidate) float64 {
+	return requestsShare(c.reschedulablePods, c.Allocatable())
+}
+
+// requestsShare returns the highest ratio of the pods' requests to the allocatable across cpu and memory
+func requestsShare(pods []*v1.Pod, allocatable v1.ResourceList) float64 {
+	requests := resources.RequestsForPods(pods...)
+	return lo.Max(lo.FilterMap([]v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory}, func(name v1.ResourceName, _ int) (float64, bool) {
+		total, ok := allocatable[name]
+		if !ok || total.IsZero() {
+			return 0, false
+		}
+		request := requests[name]
+		return request.AsApproximateFloat64() / total.AsApproximateFloat64(), true
+	}))
+}
+
+// recordUtilization observes the utilization of the consolidation candidates that haven't been sampled yet in this
+// disruption loop, so that each candidate is sampled once even though every consolidation method considers it
+func recordUtilization(candidates []*Candidate, sampled sets.Set[string]) {
+	for _, c := range candidates {
+		if sampled.Has(c.ProviderID()) {
+			continue
+		}
+		sampled.Insert(c.ProviderID())
+		NodeUtilizationHistogram.With(map[string]string{metrics.NodePoolLabel: c.nodePool.Name}).Observe(utilization(c))
+	}
+}
@@ -31,6 +31,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -134,6 +135,190 @@ var _ = Describe("Consolidation", func() {
 			Expect(recorder.Calls("Unconsolidatable")).To(Equal(6))
 		})
 	})
+	Context("Savings Estimate", func() {
+		BeforeEach(func() {
+			disruption.ConsolidationSavingsEstimateCounter.Reset()
+		})
+		It("should record the price of a deleted node as the savings", func() {
+			ExpectApplied(ctx, env.Client, node, nodeClaim, nodePool)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{node}, []*v1beta1.NodeClaim{nodeClaim})
+			fakeClock.Step(10 * time.Minute)
+
+			var wg sync.WaitGroup
+			ExpectTriggerVerifyAction(&wg)
+			ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+			wg.Wait()
+
+			metric, found := FindMetricWithLabelValues("karpenter_disruption_consolidation_savings_estimate", map[string]string{
+				"nodepool": nodePool.Name,
+			})
+			Expect(found).To(BeTrue())
+			Expect(metric.GetCounter().GetValue()).To(BeNumerically("~", mostExpensiveOffering.Price, 1e-9))
+		})
+		It("should record the price of a replaced node minus the price of its replacement as the savings", func() {
+			pod := test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}})
+			ExpectApplied(ctx, env.Client, pod, node, nodeClaim, nodePool)
+			ExpectManualBinding(ctx, env.Client, pod, node)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{node}, []*v1beta1.NodeClaim{nodeClaim})
+			fakeClock.Step(10 * time.Minute)
+
+			var wg sync.WaitGroup
+			ExpectTriggerVerifyAction(&wg)
+			ExpectMakeNewNodeClaimsReady(ctx, env.Client, &wg, cluster, cloudProvider, 1)
+			ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+			wg.Wait()
+
+			nodeClaims := lo.Filter(ExpectNodeClaims(ctx, env.Client), func(nc *v1beta1.NodeClaim, _ int) bool { return nc.Name != nodeClaim.Name })
+			Expect(nodeClaims).To(HaveLen(1))
+			// The savings are estimated from the most expensive instance type that the replacement may launch as
+			replacementInstanceTypes := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaims[0].Spec.Requirements...).Get(v1.LabelInstanceTypeStable)
+			replacementPrice := lo.Max(lo.FilterMap(cloudProvider.InstanceTypes, func(it *cloudprovider.InstanceType, _ int) (float64, bool) {
+				if !replacementInstanceTypes.Has(it.Name) {
+					return 0, false
+				}
+				return lo.Max(lo.FilterMap(it.Offerings.Available(), func(o cloudprovider.Offering, _ int) (float64, bool) {
+					return o.Price, o.CapacityType == v1beta1.CapacityTypeOnDemand
+				})), true
+			}))
+			metric, found := FindMetricWithLabelValues("karpenter_disruption_consolidation_savings_estimate", map[string]string{
+				"nodepool": nodePool.Name,
+			})
+			Expect(found).To(BeTrue())
+			Expect(metric.GetCounter().GetValue()).To(BeNumerically("~", mostExpensiveOffering.Price-replacementPrice, 1e-9))
+			Expect(metric.GetCounter().GetValue()).To(BeNumerically(">", 0))
+		})
+		It("should not record savings for drift", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{Drift: lo.ToPtr(true)}}))
+			nodeClaim.StatusConditions().MarkTrue(v1beta1.Drifted)
+			ExpectApplied(ctx, env.Client, node, nodeClaim, nodePool)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{node}, []*v1beta1.NodeClaim{nodeClaim})
+
+			var wg sync.WaitGroup
+			ExpectTriggerVerifyAction(&wg)
+			ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+			wg.Wait()
+
+			// Process the item so that the nodes can be deleted.
+			ExpectReconcileSucceeded(ctx, queue, types.NamespacedName{})
+			// Cascade any deletion of the nodeClaim to the node
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim)
+			ExpectNotFound(ctx, env.Client, nodeClaim, node)
+			_, found := FindMetricWithLabelValues("karpenter_disruption_consolidation_savings_estimate", map[string]string{
+				"nodepool": nodePool.Name,
+			})
+			Expect(found).To(BeFalse())
+		})
+	})
+	Context("Utilization Metric", func() {
+		BeforeEach(func() {
+			disruption.NodeUtilizationHistogram.Reset()
+		})
+		It("should observe the utilization of consolidation candidates once per disruption loop", func() {
+			// pin the pod to its node so that consolidation evaluates the node without acting on it
+			pod := test.Pod(test.PodOptions{
+				ObjectMeta:           metav1.ObjectMeta{Labels: labels},
+				NodeSelector:         map[string]string{v1.LabelHostname: node.Name},
+				ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("8")}},
+			})
+			node.Labels[v1.LabelHostname] = node.Name
+			ExpectApplied(ctx, env.Client, pod, node, nodeClaim, nodePool)
+			ExpectManualBinding(ctx, env.Client, pod, node)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{node}, []*v1beta1.NodeClaim{nodeClaim})
+			ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+
+			metric, found := FindMetricWithLabelValues("karpenter_nodes_utilization_ratio", map[string]string{
+				"nodepool": nodePool.Name,
+			})
+			Expect(found).To(BeTrue())
+			// every consolidation method considers the candidate, but it's only sampled once
+			Expect(metric.GetHistogram().GetSampleCount()).To(BeNumerically("==", 1))
+			// the pod requests 8 of the node's 32 cpus
+			Expect(metric.GetHistogram().GetSampleSum()).To(BeNumerically("~", 0.25, 1e-9))
+
+			// the candidate is sampled again in the next disruption loop
+			ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+			metric, found = FindMetricWithLabelValues("karpenter_nodes_utilization_ratio", map[string]string{
+				"nodepool": nodePool.Name,
+			})
+			Expect(found).To(BeTrue())
+			Expect(metric.GetHistogram().GetSampleCount()).To(BeNumerically("==", 2))
+			Expect(metric.GetHistogram().GetSampleSum()).To(BeNumerically("~", 0.5, 1e-9))
+		})
+		It("should observe the utilization of each candidate by nodepool", func() {
+			nodePool2 := test.NodePool(v1beta1.NodePool{
+				Spec: v1beta1.NodePoolSpec{
+					Disruption: v1beta1.Disruption{
+						ConsolidationPolicy: v1beta1.ConsolidationPolicyWhenUnderutilized,
+						Budgets:             []v1beta1.Budget{{Nodes: "100%"}},
+					},
+				},
+			})
+			nodeClaim2, node2 := test.NodeClaimAndNode(v1beta1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1beta1.NodePoolLabelKey:     nodePool2.Name,
+						v1.LabelInstanceTypeStable:   mostExpensiveInstance.Name,
+						v1beta1.CapacityTypeLabelKey: mostExpensiveOffering.CapacityType,
+						v1.LabelTopologyZone:         mostExpensiveOffering.Zone,
+					},
+				},
+				Status: v1beta1.NodeClaimStatus{
+					Allocatable: map[v1.ResourceName]resource.Quantity{
+						v1.ResourceCPU:    resource.MustParse("32"),
+						v1.ResourceMemory: resource.MustParse("64Gi"),
+					},
+				},
+			})
+			node.Labels[v1.LabelHostname] = node.Name
+			node2.Labels[v1.LabelHostname] = node2.Name
+			pods := []*v1.Pod{
+				test.Pod(test.PodOptions{
+					ObjectMeta:           metav1.ObjectMeta{Labels: labels},
+					NodeSelector:         map[string]string{v1.LabelHostname: node.Name},
+					ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("16")}},
+				}),
+				// the highest ratio of cpu and memory is observed
+				test.Pod(test.PodOptions{
+					ObjectMeta:   metav1.ObjectMeta{Labels: labels},
+					NodeSelector: map[string]string{v1.LabelHostname: node2.Name},
+					ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse("8"),
+						v1.ResourceMemory: resource.MustParse("48Gi"),
+					}},
+				}),
+			}
+			ExpectApplied(ctx, env.Client, pods[0], pods[1], node, nodeClaim, node2, nodeClaim2, nodePool, nodePool2)
+			ExpectManualBinding(ctx, env.Client, pods[0], node)
+			ExpectManualBinding(ctx, env.Client, pods[1], node2)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{node, node2}, []*v1beta1.NodeClaim{nodeClaim, nodeClaim2})
+			ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+
+			metric, found := FindMetricWithLabelValues("karpenter_nodes_utilization_ratio", map[string]string{
+				"nodepool": nodePool.Name,
+			})
+			Expect(found).To(BeTrue())
+			Expect(metric.GetHistogram().GetSampleCount()).To(BeNumerically("==", 1))
+			Expect(metric.GetHistogram().GetSampleSum()).To(BeNumerically("~", 0.5, 1e-9))
+
+			metric, found = FindMetricWithLabelValues("karpenter_nodes_utilization_ratio", map[string]string{
+				"nodepool": nodePool2.Name,
+			})
+			Expect(found).To(BeTrue())
+			Expect(metric.GetHistogram().GetSampleCount()).To(BeNumerically("==", 1))
+			Expect(metric.GetHistogram().GetSampleSum()).To(BeNumerically("~", 0.75, 1e-9))
+		})
+		It("should not observe the utilization of nodes that aren't consolidation candidates", func() {
+			node.Annotations = lo.Assign(node.Annotations, map[string]string{v1beta1.DoNotDisruptAnnotationKey: "true"})
+			ExpectApplied(ctx, env.Client, node, nodeClaim, nodePool)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{node}, []*v1beta1.NodeClaim{nodeClaim})
+			ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+
+			_, found := FindMetricWithLabelValues("karpenter_nodes_utilization_ratio", map[string]string{
+				"nodepool": nodePool.Name,
+			})
+			Expect(found).To(BeFalse())
+		})
+	})
 	Context("Budgets", func() {
 		var numNodes = 10
 		var nodeClaims []*v1beta1.NodeClaim
@@ -1171,6 +1356,41 @@ var _ = Describe("Consolidation", func() {
 			Entry("if the candidate is on-demand node", false),
 			Entry("if the candidate is spot node", true),
 		)
+		It("should not replace a node of a NodePool with the FewestNodes scheduling objective", func() {
+			nodePool.Spec.SchedulingObjective = v1beta1.SchedulingObjectiveFewestNodes
+			rs := test.ReplicaSet()
+			ExpectApplied(ctx, env.Client, rs)
+			Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(rs), rs)).To(Succeed())
+
+			pod := test.Pod(test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         ptr.Bool(true),
+							BlockOwnerDeletion: ptr.Bool(true),
+						},
+					}}})
+			ExpectApplied(ctx, env.Client, rs, pod, node, nodeClaim, nodePool)
+
+			// bind pods to node
+			ExpectManualBinding(ctx, env.Client, pod, node)
+
+			// inform cluster state about nodes and nodeClaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{node}, []*v1beta1.NodeClaim{nodeClaim})
+
+			fakeClock.Step(10 * time.Minute)
+			ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+
+			// the node keeps its room to spare even though there is a cheaper one that can hold the pod
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(1))
+			ExpectExists(ctx, env.Client, nodeClaim)
+			ExpectExists(ctx, env.Client, node)
+		})
 		It("cannot replace spot with spot if less than minimum InstanceTypes flexibility", func() {
 			// Forcefully shrink the possible instanceTypes to be lower than 15 to replace a nodeclaim
 			cloudProvider.InstanceTypes = lo.Slice(fake.InstanceTypesAssorted(), 0, 5)
@@ -1294,41 +1514,71 @@ var _ = Describe("Consolidation", func() {
 			})
 			Expect(ok).To(BeTrue())
 		})
-		It("cannot replace spot with spot if it is part of the 15 cheapest instance types.", func() {
-			cloudProvider.InstanceTypes = lo.Slice(fake.InstanceTypesAssorted(), 0, 20)
-			// Forcefully assign lowest possible instancePrice to make sure we have atleast one instance
-			// that is lower than the current node.
-			cloudProvider.InstanceTypes[0].Offerings[0].Price = 0.001
-			cloudProvider.InstanceTypes[0].Offerings[0].CapacityType = v1beta1.CapacityTypeSpot
-			// Also sort the cloud provider instances by pricing from low to high
-			sort.Slice(cloudProvider.InstanceTypes, func(i, j int) bool {
-				return cloudProvider.InstanceTypes[i].Offerings.Cheapest().Price < cloudProvider.InstanceTypes[j].Offerings.Cheapest().Price
-			})
-			spotInstances = lo.Filter(cloudProvider.InstanceTypes, func(i *cloudprovider.InstanceType, _ int) bool {
-				for _, o := range i.Offerings {
-					if o.CapacityType == v1beta1.CapacityTypeSpot {
-						return true
-					}
+		DescribeTable("should use the NodePool's SpotToSpotConsolidation feature gate",
+			func(global bool, annotation string, replaced bool, malformed bool) {
+				ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{SpotToSpotConsolidation: lo.ToPtr(global)}}))
+				nodePool.Annotations = lo.Assign(nodePool.Annotations, map[string]string{v1beta1.FeatureGatesAnnotationKey: annotation})
+				// create our RS so we can link a pod to it
+				rs := test.ReplicaSet()
+				ExpectApplied(ctx, env.Client, rs)
+				Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(rs), rs)).To(Succeed())
+
+				pod := test.Pod(test.PodOptions{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels,
+						OwnerReferences: []metav1.OwnerReference{
+							{
+								APIVersion:         "apps/v1",
+								Kind:               "ReplicaSet",
+								Name:               rs.Name,
+								UID:                rs.UID,
+								Controller:         ptr.Bool(true),
+								BlockOwnerDeletion: ptr.Bool(true),
+							},
+						}}})
+				ExpectApplied(ctx, env.Client, rs, pod, spotNode, spotNodeClaim, nodePool)
+
+				// bind pods to node
+				ExpectManualBinding(ctx, env.Client, pod, spotNode)
+
+				// inform cluster state about nodes and nodeClaims
+				ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{spotNode}, []*v1beta1.NodeClaim{spotNodeClaim})
+
+				fakeClock.Step(10 * time.Minute)
+
+				// consolidation won't delete the old nodeclaim until the new nodeclaim is ready
+				var wg sync.WaitGroup
+				ExpectTriggerVerifyAction(&wg)
+				if replaced {
+					ExpectMakeNewNodeClaimsReady(ctx, env.Client, &wg, cluster, cloudProvider, 1)
 				}
-				return false
-			})
+				ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+				wg.Wait()
 
-			spotInstance := spotInstances[1]
-			spotOffering := spotInstance.Offerings[0]
-			spotNodeClaim.Labels = lo.Assign(spotNodeClaim.Labels, map[string]string{
-				v1beta1.NodePoolLabelKey:     nodePool.Name,
-				v1.LabelInstanceTypeStable:   spotInstance.Name,
-				v1beta1.CapacityTypeLabelKey: spotOffering.CapacityType,
-				v1.LabelTopologyZone:         spotOffering.Zone,
-			})
+				// Process the item so that the nodes can be deleted.
+				ExpectReconcileSucceeded(ctx, queue, types.NamespacedName{})
 
-			spotNode.Labels = lo.Assign(spotNode.Labels, map[string]string{
-				v1beta1.NodePoolLabelKey:     nodePool.Name,
-				v1.LabelInstanceTypeStable:   spotInstance.Name,
-				v1beta1.CapacityTypeLabelKey: spotOffering.CapacityType,
-				v1.LabelTopologyZone:         spotOffering.Zone,
-			})
+				// Cascade any deletion of the nodeclaim to the node
+				ExpectNodeClaimsCascadeDeletion(ctx, env.Client, spotNodeClaim)
 
+				Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+				if replaced {
+					ExpectNotFound(ctx, env.Client, spotNodeClaim, spotNode)
+				} else {
+					ExpectExists(ctx, env.Client, spotNodeClaim)
+					ExpectExists(ctx, env.Client, spotNode)
+				}
+				_, ok := lo.Find(recorder.Events(), func(e events.Event) bool { return e.Reason == "InvalidFeatureGates" })
+				Expect(ok).To(Equal(malformed))
+			},
+			Entry("if enabled for the NodePool and disabled globally", false, "SpotToSpotConsolidation=true", true, false),
+			Entry("if disabled for the NodePool and enabled globally", true, "SpotToSpotConsolidation=false", false, false),
+			Entry("falling back to the global gate if the NodePool doesn't set it", true, "", true, false),
+			Entry("falling back to the global gate if the NodePool's annotation is malformed", false, "SpotToSpotConsolidation", false, true),
+		)
+		It("can replace spot with on-demand if the nodePool consolidates across capacity types", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{SpotToSpotConsolidation: lo.ToPtr(false)}}))
+			nodePool.Spec.Disruption.ConsolidateAcrossCapacityTypes = true
+			// create our RS so we can link a pod to it
 			rs := test.ReplicaSet()
 			ExpectApplied(ctx, env.Client, rs)
 			Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(rs), rs)).To(Succeed())
@@ -1350,7 +1600,7 @@ var _ = Describe("Consolidation", func() {
 			// bind pods to node
 			ExpectManualBinding(ctx, env.Client, pod, spotNode)
 
-			// inform cluster state about nodes and nodeclaims
+			// inform cluster state about nodes and nodeClaims
 			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{spotNode}, []*v1beta1.NodeClaim{spotNodeClaim})
 
 			fakeClock.Step(10 * time.Minute)
@@ -1358,46 +1608,193 @@ var _ = Describe("Consolidation", func() {
 			// consolidation won't delete the old nodeclaim until the new nodeclaim is ready
 			var wg sync.WaitGroup
 			ExpectTriggerVerifyAction(&wg)
+			ExpectMakeNewNodeClaimsReady(ctx, env.Client, &wg, cluster, cloudProvider, 1)
 			ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
 			wg.Wait()
 
-			// we didn't create a new nodeclaim or delete the old one
-			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			// Process the item so that the nodes can be deleted.
+			ExpectReconcileSucceeded(ctx, queue, types.NamespacedName{})
+
+			// Cascade any deletion of the nodeclaim to the node
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, spotNodeClaim)
+
+			// should create a new on-demand nodeclaim as there is a cheaper one that can hold the pod
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
 			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(1))
-			ExpectExists(ctx, env.Client, spotNodeClaim)
-			ExpectExists(ctx, env.Client, spotNode)
+			ctReq := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaims[0].Spec.Requirements...).Get(v1beta1.CapacityTypeLabelKey)
+			Expect(ctReq.Has(v1beta1.CapacityTypeOnDemand)).To(BeTrue())
+			Expect(ctReq.Has(v1beta1.CapacityTypeSpot)).To(BeFalse())
+
+			// and delete the old one
+			ExpectNotFound(ctx, env.Client, spotNodeClaim, spotNode)
 		})
-		It("spot to spot consolidation should order the instance types by price before enforcing minimum flexibility.", func() {
-			// Fetch 18 spot instances
-			spotInstances = lo.Slice(lo.Filter(cloudProvider.InstanceTypes, func(i *cloudprovider.InstanceType, _ int) bool {
-				for _, o := range i.Offerings {
-					if o.CapacityType == v1beta1.CapacityTypeSpot {
-						return true
+		DescribeTable("can replace on-demand based on spot availability",
+			func(consolidateAcrossCapacityTypes, spotAvailable bool, expectedCapacityType string) {
+				nodePool.Spec.Disruption.ConsolidateAcrossCapacityTypes = consolidateAcrossCapacityTypes
+				for _, it := range cloudProvider.InstanceTypes {
+					for i := range it.Offerings {
+						if it.Offerings[i].CapacityType == v1beta1.CapacityTypeSpot {
+							it.Offerings[i].Available = spotAvailable
+						}
 					}
 				}
-				return false
-			}), 0, 18)
-			// Assign the prices for 18 spot instance in ascending order incrementally
-			for i, inst := range spotInstances {
-				inst.Offerings[0].Price = 1.00 + float64(i)*0.1
-			}
-			// Force an instancetype that is outside the bound of 15 instances to have the cheapest price among the lot.
-			spotInstances[16].Offerings[0].Price = 0.001
-
-			// We now have these spot instance in the list as lowest priced and highest priced instanceTypes
-			cheapestSpotInstanceType := spotInstances[16]
-			mostExpensiveInstanceType := spotInstances[17]
-
-			// Add these spot instance with this special condition to cloud provider instancetypes
-			cloudProvider.InstanceTypes = spotInstances
+				// create our RS so we can link a pod to it
+				rs := test.ReplicaSet()
+				ExpectApplied(ctx, env.Client, rs)
+				Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(rs), rs)).To(Succeed())
 
-			expectedInstanceTypesForConsolidation := make([]*cloudprovider.InstanceType, len(spotInstances))
-			copy(expectedInstanceTypesForConsolidation, spotInstances)
-			// Sort the spot instances by pricing from low to high
-			sort.Slice(expectedInstanceTypesForConsolidation, func(i, j int) bool {
-				return expectedInstanceTypesForConsolidation[i].Offerings[0].Price < expectedInstanceTypesForConsolidation[j].Offerings[0].Price
-			})
-			// These 15 cheapest instance types should eventually be considered for consolidation.
+				pod := test.Pod(test.PodOptions{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels,
+						OwnerReferences: []metav1.OwnerReference{
+							{
+								APIVersion:         "apps/v1",
+								Kind:               "ReplicaSet",
+								Name:               rs.Name,
+								UID:                rs.UID,
+								Controller:         ptr.Bool(true),
+								BlockOwnerDeletion: ptr.Bool(true),
+							},
+						}}})
+				ExpectApplied(ctx, env.Client, rs, pod, node, nodeClaim, nodePool)
+
+				// bind pods to node
+				ExpectManualBinding(ctx, env.Client, pod, node)
+
+				// inform cluster state about nodes and nodeClaims
+				ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{node}, []*v1beta1.NodeClaim{nodeClaim})
+
+				fakeClock.Step(10 * time.Minute)
+
+				// consolidation won't delete the old nodeclaim until the new nodeclaim is ready
+				var wg sync.WaitGroup
+				ExpectTriggerVerifyAction(&wg)
+				ExpectMakeNewNodeClaimsReady(ctx, env.Client, &wg, cluster, cloudProvider, 1)
+				ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+				wg.Wait()
+
+				// Process the item so that the nodes can be deleted.
+				ExpectReconcileSucceeded(ctx, queue, types.NamespacedName{})
+
+				// Cascade any deletion of the nodeclaim to the node
+				ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim)
+
+				nodeClaims := ExpectNodeClaims(ctx, env.Client)
+				Expect(nodeClaims).To(HaveLen(1))
+				ctReq := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaims[0].Spec.Requirements...).Get(v1beta1.CapacityTypeLabelKey)
+				Expect(ctReq.Values()).To(ConsistOf(expectedCapacityType))
+
+				// and delete the old one
+				ExpectNotFound(ctx, env.Client, nodeClaim, node)
+			},
+			Entry("with spot if spot is available", false, true, v1beta1.CapacityTypeSpot),
+			Entry("with spot if spot is available and the nodePool consolidates across capacity types", true, true, v1beta1.CapacityTypeSpot),
+			Entry("with on-demand if spot is unavailable and the nodePool consolidates across capacity types", true, false, v1beta1.CapacityTypeOnDemand),
+		)
+		It("cannot replace spot with spot if it is part of the 15 cheapest instance types.", func() {
+			cloudProvider.InstanceTypes = lo.Slice(fake.InstanceTypesAssorted(), 0, 20)
+			// Forcefully assign lowest possible instancePrice to make sure we have atleast one instance
+			// that is lower than the current node.
+			cloudProvider.InstanceTypes[0].Offerings[0].Price = 0.001
+			cloudProvider.InstanceTypes[0].Offerings[0].CapacityType = v1beta1.CapacityTypeSpot
+			// Also sort the cloud provider instances by pricing from low to high
+			sort.Slice(cloudProvider.InstanceTypes, func(i, j int) bool {
+				return cloudProvider.InstanceTypes[i].Offerings.Cheapest().Price < cloudProvider.InstanceTypes[j].Offerings.Cheapest().Price
+			})
+			spotInstances = lo.Filter(cloudProvider.InstanceTypes, func(i *cloudprovider.InstanceType, _ int) bool {
+				for _, o := range i.Offerings {
+					if o.CapacityType == v1beta1.CapacityTypeSpot {
+						return true
+					}
+				}
+				return false
+			})
+
+			spotInstance := spotInstances[1]
+			spotOffering := spotInstance.Offerings[0]
+			spotNodeClaim.Labels = lo.Assign(spotNodeClaim.Labels, map[string]string{
+				v1beta1.NodePoolLabelKey:     nodePool.Name,
+				v1.LabelInstanceTypeStable:   spotInstance.Name,
+				v1beta1.CapacityTypeLabelKey: spotOffering.CapacityType,
+				v1.LabelTopologyZone:         spotOffering.Zone,
+			})
+
+			spotNode.Labels = lo.Assign(spotNode.Labels, map[string]string{
+				v1beta1.NodePoolLabelKey:     nodePool.Name,
+				v1.LabelInstanceTypeStable:   spotInstance.Name,
+				v1beta1.CapacityTypeLabelKey: spotOffering.CapacityType,
+				v1.LabelTopologyZone:         spotOffering.Zone,
+			})
+
+			rs := test.ReplicaSet()
+			ExpectApplied(ctx, env.Client, rs)
+			Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(rs), rs)).To(Succeed())
+
+			pod := test.Pod(test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         ptr.Bool(true),
+							BlockOwnerDeletion: ptr.Bool(true),
+						},
+					}}})
+			ExpectApplied(ctx, env.Client, rs, pod, spotNode, spotNodeClaim, nodePool)
+
+			// bind pods to node
+			ExpectManualBinding(ctx, env.Client, pod, spotNode)
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{spotNode}, []*v1beta1.NodeClaim{spotNodeClaim})
+
+			fakeClock.Step(10 * time.Minute)
+
+			// consolidation won't delete the old nodeclaim until the new nodeclaim is ready
+			var wg sync.WaitGroup
+			ExpectTriggerVerifyAction(&wg)
+			ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+			wg.Wait()
+
+			// we didn't create a new nodeclaim or delete the old one
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(1))
+			ExpectExists(ctx, env.Client, spotNodeClaim)
+			ExpectExists(ctx, env.Client, spotNode)
+		})
+		It("spot to spot consolidation should order the instance types by price before enforcing minimum flexibility.", func() {
+			// Fetch 18 spot instances
+			spotInstances = lo.Slice(lo.Filter(cloudProvider.InstanceTypes, func(i *cloudprovider.InstanceType, _ int) bool {
+				for _, o := range i.Offerings {
+					if o.CapacityType == v1beta1.CapacityTypeSpot {
+						return true
+					}
+				}
+				return false
+			}), 0, 18)
+			// Assign the prices for 18 spot instance in ascending order incrementally
+			for i, inst := range spotInstances {
+				inst.Offerings[0].Price = 1.00 + float64(i)*0.1
+			}
+			// Force an instancetype that is outside the bound of 15 instances to have the cheapest price among the lot.
+			spotInstances[16].Offerings[0].Price = 0.001
+
+			// We now have these spot instance in the list as lowest priced and highest priced instanceTypes
+			cheapestSpotInstanceType := spotInstances[16]
+			mostExpensiveInstanceType := spotInstances[17]
+
+			// Add these spot instance with this special condition to cloud provider instancetypes
+			cloudProvider.InstanceTypes = spotInstances
+
+			expectedInstanceTypesForConsolidation := make([]*cloudprovider.InstanceType, len(spotInstances))
+			copy(expectedInstanceTypesForConsolidation, spotInstances)
+			// Sort the spot instances by pricing from low to high
+			sort.Slice(expectedInstanceTypesForConsolidation, func(i, j int) bool {
+				return expectedInstanceTypesForConsolidation[i].Offerings[0].Price < expectedInstanceTypesForConsolidation[j].Offerings[0].Price
+			})
+			// These 15 cheapest instance types should eventually be considered for consolidation.
 			var expectedInstanceTypesNames []string
 			for i := 0; i < 15; i++ {
 				expectedInstanceTypesNames = append(expectedInstanceTypesNames, expectedInstanceTypesForConsolidation[i].Name)
@@ -2750,6 +3147,59 @@ var _ = Describe("Consolidation", func() {
 			// and delete the old one
 			ExpectNotFound(ctx, env.Client, nodeClaims[1], nodes[1])
 		})
+		It("can delete nodes when a pod is orphaned on a node that doesn't exist", func() {
+			// create our RS so we can link a pod to it
+			rs := test.ReplicaSet()
+			ExpectApplied(ctx, env.Client, rs)
+			pods := test.Pods(3, test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         ptr.Bool(true),
+							BlockOwnerDeletion: ptr.Bool(true),
+						},
+					}}})
+			orphaned := test.Pod(test.PodOptions{
+				NodeName: "deleted-node",
+				Phase:    v1.PodPending,
+				Conditions: []v1.PodCondition{{
+					Type:               v1.PodScheduled,
+					Status:             v1.ConditionTrue,
+					LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Hour)),
+				}},
+			})
+			ExpectApplied(ctx, env.Client, rs, pods[0], pods[1], pods[2], orphaned, nodeClaims[0], nodes[0], nodeClaims[1], nodes[1], nodePool)
+
+			// bind pods to node
+			ExpectManualBinding(ctx, env.Client, pods[0], nodes[0])
+			ExpectManualBinding(ctx, env.Client, pods[1], nodes[0])
+			ExpectManualBinding(ctx, env.Client, pods[2], nodes[1])
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{nodes[0], nodes[1]}, []*v1beta1.NodeClaim{nodeClaims[0], nodeClaims[1]})
+
+			fakeClock.Step(10 * time.Minute)
+
+			var wg sync.WaitGroup
+			ExpectTriggerVerifyAction(&wg)
+			ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+			wg.Wait()
+
+			// Process the item so that the nodes can be deleted.
+			ExpectReconcileSucceeded(ctx, queue, types.NamespacedName{})
+
+			// Cascade any deletion of the nodeclaim to the node
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaims[1])
+
+			// the orphaned pod can never be scheduled, so it shouldn't block consolidation
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(1))
+			ExpectNotFound(ctx, env.Client, nodeClaims[1], nodes[1])
+		})
 		It("can delete nodes if another nodePool has no node template", func() {
 			// create our RS so we can link a pod to it
 			rs := test.ReplicaSet()
@@ -3109,6 +3559,98 @@ var _ = Describe("Consolidation", func() {
 			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(1))
 			ExpectNotFound(ctx, env.Client, nodeClaims[0], nodes[0])
 		})
+		DescribeTable("can delete nodes, doesn't reschedule pods that are about to exceed their active deadline",
+			func(activeDeadline time.Duration, blocked bool) {
+				// create our RS so we can link a pod to it
+				rs := test.ReplicaSet()
+				ExpectApplied(ctx, env.Client, rs)
+				Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(rs), rs)).To(Succeed())
+
+				pods := test.Pods(2, test.PodOptions{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels,
+						OwnerReferences: []metav1.OwnerReference{
+							{
+								APIVersion:         "apps/v1",
+								Kind:               "ReplicaSet",
+								Name:               rs.Name,
+								UID:                rs.UID,
+								Controller:         ptr.Bool(true),
+								BlockOwnerDeletion: ptr.Bool(true),
+							},
+						}},
+					ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")}},
+				})
+				// This job pod doesn't fit on the other node, so it keeps its node from being deleted unless it's about to
+				// be failed for exceeding its deadline
+				jobPod := test.Pod(test.PodOptions{
+					ObjectMeta:           metav1.ObjectMeta{Labels: labels},
+					ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("31")}},
+				})
+				jobPod.Spec.ActiveDeadlineSeconds = lo.ToPtr(int64((10*time.Minute + activeDeadline).Seconds()))
+				jobPod.Status.StartTime = &metav1.Time{Time: fakeClock.Now()}
+
+				ExpectApplied(ctx, env.Client, rs, pods[0], pods[1], jobPod, nodePool)
+				ExpectApplied(ctx, env.Client, nodeClaims[0], nodes[0], nodeClaims[1], nodes[1])
+
+				// bind pods to node
+				ExpectManualBinding(ctx, env.Client, pods[0], nodes[0])
+				ExpectManualBinding(ctx, env.Client, pods[1], nodes[0])
+				ExpectManualBinding(ctx, env.Client, jobPod, nodes[1])
+
+				// inform cluster state about nodes and nodeClaims
+				ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{nodes[0], nodes[1]}, []*v1beta1.NodeClaim{nodeClaims[0], nodeClaims[1]})
+
+				fakeClock.Step(10 * time.Minute)
+
+				var wg sync.WaitGroup
+				ExpectTriggerVerifyAction(&wg)
+				ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+				wg.Wait()
+
+				ExpectReconcileSucceeded(ctx, queue, types.NamespacedName{})
+
+				if blocked {
+					Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+					ExpectExists(ctx, env.Client, nodeClaims[0])
+					ExpectExists(ctx, env.Client, nodeClaims[1])
+					return
+				}
+				ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaims[1])
+				Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+				Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(1))
+				ExpectNotFound(ctx, env.Client, nodeClaims[1], nodes[1])
+				ExpectExists(ctx, env.Client, nodeClaims[0])
+			},
+			Entry("if the deadline is imminent", 30*time.Second, false),
+			Entry("if the deadline is reached", time.Duration(0), false),
+			Entry("if the deadline isn't imminent", 10*time.Minute, true),
+		)
+		It("can't delete nodes with a karpenter.sh/do-not-disrupt pod that is about to exceed its active deadline", func() {
+			jobPod := test.Pod(test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      labels,
+					Annotations: map[string]string{v1beta1.DoNotDisruptAnnotationKey: "true"},
+				},
+			})
+			jobPod.Spec.ActiveDeadlineSeconds = lo.ToPtr(int64((10*time.Minute + 30*time.Second).Seconds()))
+			jobPod.Status.StartTime = &metav1.Time{Time: fakeClock.Now()}
+			ExpectApplied(ctx, env.Client, jobPod, nodePool, nodeClaims[0], nodes[0])
+			ExpectManualBinding(ctx, env.Client, jobPod, nodes[0])
+
+			// inform cluster state about nodes and nodeClaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{nodes[0]}, []*v1beta1.NodeClaim{nodeClaims[0]})
+
+			fakeClock.Step(10 * time.Minute)
+
+			var wg sync.WaitGroup
+			ExpectTriggerVerifyAction(&wg)
+			ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+			wg.Wait()
+
+			ExpectReconcileSucceeded(ctx, queue, types.NamespacedName{})
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			ExpectExists(ctx, env.Client, nodeClaims[0])
+		})
 		It("can delete nodes, evicts pods without an ownerRef", func() {
 			// create our RS so we can link a pod to it
 			rs := test.ReplicaSet()
@@ -4094,13 +4636,142 @@ var _ = Describe("Consolidation", func() {
 			ExpectExists(ctx, env.Client, nodes[0])
 			ExpectExists(ctx, env.Client, nodes[1])
 		})
-	})
+		It("should wait for the nodepool's consolidation validation delay for empty nodes before consolidating", func() {
+			nodePool.Spec.Disruption.ConsolidationValidationDelay = &metav1.Duration{Duration: time.Minute}
+			ExpectApplied(ctx, env.Client, nodeClaims[0], nodes[0], nodePool)
 
-	Context("Timeout", func() {
-		It("should return the last valid command when multi-nodeclaim consolidation times out", func() {
-			numNodes := 20
-			nodeClaims, nodes := test.NodeClaimsAndNodes(numNodes, v1beta1.NodeClaim{
-				ObjectMeta: metav1.ObjectMeta{
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{nodes[0]}, []*v1beta1.NodeClaim{nodeClaims[0]})
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			finished := atomic.Bool{}
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				defer finished.Store(true)
+				ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+			}()
+
+			// wait for the controller to block on the validation timeout
+			Eventually(fakeClock.HasWaiters, time.Second*10).Should(BeTrue())
+
+			// advance the clock past the default delay, the controller should still be blocking
+			fakeClock.Step(31 * time.Second)
+			Consistently(finished.Load, time.Second).Should(BeFalse())
+			ExpectExists(ctx, env.Client, nodeClaims[0])
+
+			// advance the clock so that the nodepool's delay expires
+			fakeClock.Step(30 * time.Second)
+			Eventually(finished.Load, 10*time.Second).Should(BeTrue())
+			wg.Wait()
+
+			// Process the item so that the nodes can be deleted.
+			ExpectReconcileSucceeded(ctx, queue, types.NamespacedName{})
+
+			// Cascade any deletion of the nodeclaim to the node
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaims[0])
+
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(0))
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(0))
+			ExpectNotFound(ctx, env.Client, nodeClaims[0], nodes[0])
+		})
+		It("should consolidate empty nodes after a consolidation validation delay shorter than the default", func() {
+			nodePool.Spec.Disruption.ConsolidationValidationDelay = &metav1.Duration{Duration: 5 * time.Second}
+			ExpectApplied(ctx, env.Client, nodeClaims[0], nodes[0], nodePool)
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{nodes[0]}, []*v1beta1.NodeClaim{nodeClaims[0]})
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			finished := atomic.Bool{}
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				defer finished.Store(true)
+				ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+			}()
+
+			// wait for the controller to block on the validation timeout
+			Eventually(fakeClock.HasWaiters, time.Second*10).Should(BeTrue())
+
+			// advance the clock past the nodepool's delay, but not the default one
+			fakeClock.Step(6 * time.Second)
+			Eventually(finished.Load, 10*time.Second).Should(BeTrue())
+			wg.Wait()
+
+			// Process the item so that the nodes can be deleted.
+			ExpectReconcileSucceeded(ctx, queue, types.NamespacedName{})
+
+			// Cascade any deletion of the nodeclaim to the node
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaims[0])
+
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(0))
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(0))
+			ExpectNotFound(ctx, env.Client, nodeClaims[0], nodes[0])
+		})
+		It("should wait for the longest consolidation validation delay when consolidating nodes from several nodepools", func() {
+			nodePool.Spec.Disruption.ConsolidationValidationDelay = &metav1.Duration{Duration: 5 * time.Second}
+			nodePool2 := test.NodePool(v1beta1.NodePool{
+				Spec: v1beta1.NodePoolSpec{
+					Disruption: v1beta1.Disruption{
+						ConsolidationPolicy:          v1beta1.ConsolidationPolicyWhenUnderutilized,
+						ConsolidationValidationDelay: &metav1.Duration{Duration: time.Minute},
+						Budgets: []v1beta1.Budget{{
+							Nodes: "100%",
+						}},
+					},
+				},
+			})
+			nodeClaims[1].Labels[v1beta1.NodePoolLabelKey] = nodePool2.Name
+			nodes[1].Labels[v1beta1.NodePoolLabelKey] = nodePool2.Name
+			ExpectApplied(ctx, env.Client, nodeClaims[0], nodes[0], nodeClaims[1], nodes[1], nodePool, nodePool2)
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			finished := atomic.Bool{}
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				defer finished.Store(true)
+				ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+			}()
+
+			// wait for the controller to block on the validation timeout
+			Eventually(fakeClock.HasWaiters, time.Second*10).Should(BeTrue())
+
+			// advance the clock past the shortest delay, the controller should still be blocking
+			fakeClock.Step(6 * time.Second)
+			Consistently(finished.Load, time.Second).Should(BeFalse())
+			ExpectExists(ctx, env.Client, nodeClaims[0])
+			ExpectExists(ctx, env.Client, nodeClaims[1])
+
+			// advance the clock so that the longest delay expires
+			fakeClock.Step(time.Minute)
+			Eventually(finished.Load, 10*time.Second).Should(BeTrue())
+			wg.Wait()
+
+			// Process the item so that the nodes can be deleted.
+			ExpectReconcileSucceeded(ctx, queue, types.NamespacedName{})
+
+			// Cascade any deletion of the nodeclaim to the node
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaims...)
+
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(0))
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(0))
+			ExpectNotFound(ctx, env.Client, nodeClaims[0], nodes[0], nodeClaims[1], nodes[1])
+		})
+	})
+
+	Context("Timeout", func() {
+		It("should return the last valid command when multi-nodeclaim consolidation times out", func() {
+			numNodes := 20
+			nodeClaims, nodes := test.NodeClaimsAndNodes(numNodes, v1beta1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
 					Labels: map[string]string{
 						v1beta1.NodePoolLabelKey:     nodePool.Name,
 						v1.LabelInstanceTypeStable:   leastExpensiveInstance.Name,
@@ -4827,71 +5498,35 @@ var _ = Describe("Consolidation", func() {
 			ExpectNotFound(ctx, env.Client, nodeClaims[0], nodes[0])
 		})
 	})
-	Context("Topology Consideration", func() {
+	Context("Drain Time Consideration", func() {
 		var nodeClaims []*v1beta1.NodeClaim
 		var nodes []*v1.Node
-		var oldNodeClaimNames sets.Set[string]
 
 		BeforeEach(func() {
-			testZone1Instance := leastExpensiveInstanceWithZone("test-zone-1")
-			testZone2Instance := mostExpensiveInstanceWithZone("test-zone-2")
-			testZone3Instance := leastExpensiveInstanceWithZone("test-zone-3")
-
-			nodeClaims, nodes = test.NodeClaimsAndNodes(3, v1beta1.NodeClaim{
+			nodeClaims, nodes = test.NodeClaimsAndNodes(2, v1beta1.NodeClaim{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: map[string]string{
 						v1beta1.NodePoolLabelKey:     nodePool.Name,
-						v1.LabelTopologyZone:         "test-zone-1",
-						v1.LabelInstanceTypeStable:   testZone1Instance.Name,
-						v1beta1.CapacityTypeLabelKey: testZone1Instance.Offerings[0].CapacityType,
+						v1.LabelInstanceTypeStable:   leastExpensiveInstance.Name,
+						v1beta1.CapacityTypeLabelKey: leastExpensiveOffering.CapacityType,
+						v1.LabelTopologyZone:         leastExpensiveOffering.Zone,
 					},
 				},
 				Status: v1beta1.NodeClaimStatus{
-					Allocatable: map[v1.ResourceName]resource.Quantity{v1.ResourceCPU: resource.MustParse("1")},
+					Allocatable: map[v1.ResourceName]resource.Quantity{
+						v1.ResourceCPU:  resource.MustParse("32"),
+						v1.ResourcePods: resource.MustParse("100"),
+					},
 				},
 			})
-			nodeClaims[1].Labels = lo.Assign(nodeClaims[1].Labels, map[string]string{
-				v1.LabelTopologyZone:         "test-zone-2",
-				v1.LabelInstanceTypeStable:   testZone2Instance.Name,
-				v1beta1.CapacityTypeLabelKey: testZone2Instance.Offerings[0].CapacityType,
-			})
-			nodes[1].Labels = lo.Assign(nodes[1].Labels, map[string]string{
-				v1.LabelTopologyZone:         "test-zone-2",
-				v1.LabelInstanceTypeStable:   testZone2Instance.Name,
-				v1beta1.CapacityTypeLabelKey: testZone2Instance.Offerings[0].CapacityType,
-			})
-
-			nodeClaims[2].Labels = lo.Assign(nodeClaims[2].Labels, map[string]string{
-				v1.LabelTopologyZone:         "test-zone-3",
-				v1.LabelInstanceTypeStable:   testZone3Instance.Name,
-				v1beta1.CapacityTypeLabelKey: testZone1Instance.Offerings[0].CapacityType,
-			})
-			nodes[2].Labels = lo.Assign(nodes[2].Labels, map[string]string{
-				v1.LabelTopologyZone:         "test-zone-3",
-				v1.LabelInstanceTypeStable:   testZone3Instance.Name,
-				v1beta1.CapacityTypeLabelKey: testZone1Instance.Offerings[0].CapacityType,
-			})
-			oldNodeClaimNames = sets.New(nodeClaims[0].Name, nodeClaims[1].Name, nodeClaims[2].Name)
 		})
-		It("can replace node maintaining zonal topology spread", func() {
-			labels = map[string]string{
-				"app": "test-zonal-spread",
-			}
+		It("should consider pod termination grace periods when calculating disruption cost", func() {
 			// create our RS so we can link a pod to it
 			rs := test.ReplicaSet()
 			ExpectApplied(ctx, env.Client, rs)
 
-			tsc := v1.TopologySpreadConstraint{
-				MaxSkew:           1,
-				TopologyKey:       v1.LabelTopologyZone,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-			}
-			pods := test.Pods(4, test.PodOptions{
-				ResourceRequirements:      v1.ResourceRequirements{Requests: map[v1.ResourceName]resource.Quantity{v1.ResourceCPU: resource.MustParse("1")}},
-				TopologySpreadConstraints: []v1.TopologySpreadConstraint{tsc},
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
+			podOptions := test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels,
 					OwnerReferences: []metav1.OwnerReference{
 						{
 							APIVersion:         "apps/v1",
@@ -4901,68 +5536,68 @@ var _ = Describe("Consolidation", func() {
 							Controller:         ptr.Bool(true),
 							BlockOwnerDeletion: ptr.Bool(true),
 						},
-					}}})
+					}}}
+			slowPod := test.Pod(podOptions)
+			slowPod.Spec.TerminationGracePeriodSeconds = lo.ToPtr[int64](3600)
+			fastPod := test.Pod(podOptions)
 
-			ExpectApplied(ctx, env.Client, rs, pods[0], pods[1], pods[2], nodeClaims[0], nodes[0], nodeClaims[1], nodes[1], nodeClaims[2], nodes[2], nodePool)
+			ExpectApplied(ctx, env.Client, rs, slowPod, fastPod, nodePool, nodeClaims[0], nodes[0], nodeClaims[1], nodes[1])
 
-			// bind pods to nodes
-			ExpectManualBinding(ctx, env.Client, pods[0], nodes[0])
-			ExpectManualBinding(ctx, env.Client, pods[1], nodes[1])
-			ExpectManualBinding(ctx, env.Client, pods[2], nodes[2])
+			// one pod on each node, but the pod on node 1 takes much longer to terminate
+			ExpectManualBinding(ctx, env.Client, slowPod, nodes[0])
+			ExpectManualBinding(ctx, env.Client, fastPod, nodes[1])
 
 			// inform cluster state about nodes and nodeclaims
-			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{nodes[0], nodes[1], nodes[2]}, []*v1beta1.NodeClaim{nodeClaims[0], nodeClaims[1], nodeClaims[2]})
-
-			ExpectSkew(ctx, env.Client, "default", &tsc).To(ConsistOf(1, 1, 1))
-
-			fakeClock.Step(10 * time.Minute)
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{nodes[0], nodes[1]}, []*v1beta1.NodeClaim{nodeClaims[0], nodeClaims[1]})
 
-			// consolidation won't delete the old node until the new node is ready
 			var wg sync.WaitGroup
 			ExpectTriggerVerifyAction(&wg)
-			ExpectMakeNewNodeClaimsReady(ctx, env.Client, &wg, cluster, cloudProvider, 1)
 			ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
 			wg.Wait()
 
 			// Process the item so that the nodes can be deleted.
 			ExpectReconcileSucceeded(ctx, queue, types.NamespacedName{})
+
 			// Cascade any deletion of the nodeclaim to the node
 			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaims[1])
 
-			// should create a new node as there is a cheaper one that can hold the pod
-			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(3))
-			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(3))
+			// both nodes are equally cheap to consolidate, but the second node drains faster, so it should be deleted
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(1))
 			ExpectNotFound(ctx, env.Client, nodeClaims[1], nodes[1])
-
-			// Find the new node associated with the nodeclaim
-			newNodeClaim, ok := lo.Find(ExpectNodeClaims(ctx, env.Client), func(m *v1beta1.NodeClaim) bool {
-				return !oldNodeClaimNames.Has(m.Name)
-			})
-			Expect(ok).To(BeTrue())
-			newNode, ok := lo.Find(ExpectNodes(ctx, env.Client), func(n *v1.Node) bool {
-				return newNodeClaim.Status.ProviderID == n.Spec.ProviderID
-			})
-			Expect(ok).To(BeTrue())
-
-			// we need to emulate the replicaset controller and bind a new pod to the newly created node
-			ExpectApplied(ctx, env.Client, pods[3])
-			ExpectManualBinding(ctx, env.Client, pods[3], newNode)
-
-			// we should maintain our skew, the new node must be in the same zone as the old node it replaced
-			ExpectSkew(ctx, env.Client, "default", &tsc).To(ConsistOf(1, 1, 1))
+			ExpectExists(ctx, env.Client, nodes[0])
 		})
-		It("won't delete node if it would violate pod anti-affinity", func() {
-			// create our RS so we can link a pod to it
-			rs := test.ReplicaSet()
-			ExpectApplied(ctx, env.Client, rs)
-			pods := test.Pods(3, test.PodOptions{
-				ResourceRequirements: v1.ResourceRequirements{Requests: map[v1.ResourceName]resource.Quantity{v1.ResourceCPU: resource.MustParse("1")}},
-				PodAntiRequirements: []v1.PodAffinityTerm{
-					{
-						LabelSelector: &metav1.LabelSelector{MatchLabels: labels},
-						TopologyKey:   v1.LabelHostname,
+	})
+	Context("Underutilization Threshold", func() {
+		var nodeClaims []*v1beta1.NodeClaim
+		var nodes []*v1.Node
+		var rs *appsv1.ReplicaSet
+
+		BeforeEach(func() {
+			nodeClaims, nodes = test.NodeClaimsAndNodes(2, v1beta1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1beta1.NodePoolLabelKey:     nodePool.Name,
+						v1.LabelInstanceTypeStable:   leastExpensiveInstance.Name,
+						v1beta1.CapacityTypeLabelKey: leastExpensiveOffering.CapacityType,
+						v1.LabelTopologyZone:         leastExpensiveOffering.Zone,
+					},
+				},
+				Status: v1beta1.NodeClaimStatus{
+					Allocatable: map[v1.ResourceName]resource.Quantity{
+						v1.ResourceCPU:  resource.MustParse("32"),
+						v1.ResourcePods: resource.MustParse("100"),
 					},
 				},
+			})
+			rs = test.ReplicaSet()
+			ExpectApplied(ctx, env.Client, rs)
+		})
+		// expectConsolidation binds a pod with the cpu request to each node and returns the number of nodes that remain
+		// after consolidation
+		expectConsolidation := func(cpu string) int {
+			GinkgoHelper()
+			pods := test.Pods(2, test.PodOptions{
 				ObjectMeta: metav1.ObjectMeta{Labels: labels,
 					OwnerReferences: []metav1.OwnerReference{
 						{
@@ -4973,32 +5608,615 @@ var _ = Describe("Consolidation", func() {
 							Controller:         ptr.Bool(true),
 							BlockOwnerDeletion: ptr.Bool(true),
 						},
-					},
-				},
+					}},
+				ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu)}},
 			})
+			ExpectApplied(ctx, env.Client, pods[0], pods[1], nodeClaims[0], nodes[0], nodeClaims[1], nodes[1], nodePool)
+			ExpectManualBinding(ctx, env.Client, pods[0], nodes[0])
+			ExpectManualBinding(ctx, env.Client, pods[1], nodes[1])
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{nodes[0], nodes[1]}, []*v1beta1.NodeClaim{nodeClaims[0], nodeClaims[1]})
 
-			// Make the Zone 2 instance also the least expensive instance
-			zone2Instance := leastExpensiveInstanceWithZone("test-zone-2")
-			nodes[1].Labels = lo.Assign(nodes[1].Labels, map[string]string{
-				v1beta1.NodePoolLabelKey:     nodePool.Name,
-				v1.LabelTopologyZone:         "test-zone-2",
-				v1.LabelInstanceTypeStable:   zone2Instance.Name,
-				v1beta1.CapacityTypeLabelKey: zone2Instance.Offerings[0].CapacityType,
-			})
-			nodeClaims[1].Labels = lo.Assign(nodeClaims[1].Labels, map[string]string{
-				v1beta1.NodePoolLabelKey:     nodePool.Name,
-				v1.LabelTopologyZone:         "test-zone-2",
-				v1.LabelInstanceTypeStable:   zone2Instance.Name,
-				v1beta1.CapacityTypeLabelKey: zone2Instance.Offerings[0].CapacityType,
+			fakeClock.Step(10 * time.Minute)
+
+			var wg sync.WaitGroup
+			ExpectTriggerVerifyAction(&wg)
+			ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+			wg.Wait()
+			ExpectReconcileSucceeded(ctx, queue, types.NamespacedName{})
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaims...)
+			return len(ExpectNodeClaims(ctx, env.Client))
+		}
+		It("should consolidate nodes at any utilization when the threshold isn't set", func() {
+			Expect(expectConsolidation("16")).To(Equal(1))
+		})
+		It("should consolidate nodes below the threshold", func() {
+			nodePool.Spec.Disruption.UnderutilizationThreshold = lo.ToPtr[int32](50)
+			Expect(expectConsolidation("15")).To(Equal(1))
+		})
+		It("should not consolidate nodes at the threshold", func() {
+			nodePool.Spec.Disruption.UnderutilizationThreshold = lo.ToPtr[int32](50)
+			Expect(expectConsolidation("16")).To(Equal(2))
+			ExpectEvent(recorder, nodes[0], "Unconsolidatable")
+		})
+		It("should not consolidate nodes above the threshold", func() {
+			nodePool.Spec.Disruption.UnderutilizationThreshold = lo.ToPtr[int32](40)
+			Expect(expectConsolidation("15")).To(Equal(2))
+		})
+	})
+	Context("Consolidation Order", func() {
+		var nodeClaims []*v1beta1.NodeClaim
+		var nodes []*v1.Node
+
+		BeforeEach(func() {
+			// the nodes are ordered from the most to the least expensive, but are applied in reverse
+			instanceTypes := []*cloudprovider.InstanceType{mostExpensiveInstance, onDemandInstances[len(onDemandInstances)/2], leastExpensiveInstance}
+			nodeClaims, nodes = test.NodeClaimsAndNodes(len(instanceTypes), v1beta1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1beta1.NodePoolLabelKey: nodePool.Name,
+					},
+				},
+				Status: v1beta1.NodeClaimStatus{
+					Allocatable: map[v1.ResourceName]resource.Quantity{
+						v1.ResourceCPU:  resource.MustParse("32"),
+						v1.ResourcePods: resource.MustParse("100"),
+					},
+				},
+			})
+			for i, it := range instanceTypes {
+				for _, obj := range []client.Object{nodeClaims[i], nodes[i]} {
+					obj.SetLabels(lo.Assign(obj.GetLabels(), map[string]string{
+						v1.LabelInstanceTypeStable:   it.Name,
+						v1beta1.CapacityTypeLabelKey: it.Offerings[0].CapacityType,
+						v1.LabelTopologyZone:         it.Offerings[0].Zone,
+					}))
+				}
+			}
+		})
+		// expectConsolidation consolidates the empty nodes and returns the names of the remaining nodeclaims
+		expectConsolidation := func() []string {
+			GinkgoHelper()
+			ExpectApplied(ctx, env.Client, nodePool)
+			for i := len(nodeClaims) - 1; i >= 0; i-- {
+				ExpectApplied(ctx, env.Client, nodeClaims[i], nodes[i])
+			}
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+
+			var wg sync.WaitGroup
+			ExpectTriggerVerifyAction(&wg)
+			ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+			wg.Wait()
+			ExpectReconcileSucceeded(ctx, queue, types.NamespacedName{})
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaims...)
+			return lo.Map(ExpectNodeClaims(ctx, env.Client), func(nc *v1beta1.NodeClaim, _ int) string { return nc.Name })
+		}
+		It("should consolidate the most expensive node first", func() {
+			nodePool.Spec.Disruption.ConsolidationOrder = v1beta1.ConsolidationOrderMostExpensive
+			nodePool.Spec.Disruption.Budgets = []v1beta1.Budget{{Nodes: "1"}}
+			Expect(expectConsolidation()).To(ConsistOf(nodeClaims[1].Name, nodeClaims[2].Name))
+			ExpectNotFound(ctx, env.Client, nodeClaims[0], nodes[0])
+		})
+		It("should consolidate the nodes in descending order of price within the budget", func() {
+			nodePool.Spec.Disruption.ConsolidationOrder = v1beta1.ConsolidationOrderMostExpensive
+			nodePool.Spec.Disruption.Budgets = []v1beta1.Budget{{Nodes: "2"}}
+			Expect(expectConsolidation()).To(ConsistOf(nodeClaims[2].Name))
+			ExpectNotFound(ctx, env.Client, nodeClaims[0], nodes[0], nodeClaims[1], nodes[1])
+		})
+	})
+	Context("StatefulSet Ordinal Order", func() {
+		var nodeClaims []*v1beta1.NodeClaim
+		var nodes []*v1.Node
+		var ss *appsv1.StatefulSet
+		var rs *appsv1.ReplicaSet
+
+		BeforeEach(func() {
+			nodeClaims, nodes = test.NodeClaimsAndNodes(3, v1beta1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1beta1.NodePoolLabelKey:     nodePool.Name,
+						v1.LabelInstanceTypeStable:   leastExpensiveInstance.Name,
+						v1beta1.CapacityTypeLabelKey: leastExpensiveOffering.CapacityType,
+						v1.LabelTopologyZone:         leastExpensiveOffering.Zone,
+					},
+				},
+				Status: v1beta1.NodeClaimStatus{
+					Allocatable: map[v1.ResourceName]resource.Quantity{
+						v1.ResourceCPU:  resource.MustParse("32"),
+						v1.ResourcePods: resource.MustParse("100"),
+					},
+				},
+			})
+			ss = test.StatefulSet()
+			rs = test.ReplicaSet()
+			ExpectApplied(ctx, env.Client, ss, rs)
+			nodePool.Spec.Disruption.Budgets = []v1beta1.Budget{{Nodes: "1"}}
+		})
+		statefulSetPod := func(ordinal int) *v1.Pod {
+			return test.Pod(test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{appsv1.PodIndexLabel: fmt.Sprint(ordinal)},
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "StatefulSet",
+							Name:               ss.Name,
+							UID:                ss.UID,
+							Controller:         ptr.Bool(true),
+							BlockOwnerDeletion: ptr.Bool(true),
+						},
+					},
+				},
+			})
+		}
+		replicaSetPod := func() *v1.Pod {
+			return test.Pod(test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         ptr.Bool(true),
+							BlockOwnerDeletion: ptr.Bool(true),
+						},
+					},
+				},
+			})
+		}
+		// expectConsolidation binds the pods to the nodes with the same index, consolidates a single node and returns
+		// the names of the remaining nodeclaims
+		expectConsolidation := func(pods ...[]*v1.Pod) []string {
+			GinkgoHelper()
+			ExpectApplied(ctx, env.Client, nodePool)
+			for i := range nodeClaims {
+				ExpectApplied(ctx, env.Client, nodeClaims[i], nodes[i])
+				for _, p := range pods[i] {
+					ExpectApplied(ctx, env.Client, p)
+					ExpectManualBinding(ctx, env.Client, p, nodes[i])
+				}
+			}
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+
+			fakeClock.Step(10 * time.Minute)
+
+			var wg sync.WaitGroup
+			ExpectTriggerVerifyAction(&wg)
+			ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+			wg.Wait()
+			ExpectReconcileSucceeded(ctx, queue, types.NamespacedName{})
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaims...)
+			return lo.Map(ExpectNodeClaims(ctx, env.Client), func(nc *v1beta1.NodeClaim, _ int) string { return nc.Name })
+		}
+		It("should consolidate the least disruptive node by default", func() {
+			// the node with the highest ordinal is the most disruptive, as it has an additional pod
+			Expect(expectConsolidation(
+				[]*v1.Pod{statefulSetPod(0)},
+				[]*v1.Pod{statefulSetPod(1)},
+				[]*v1.Pod{statefulSetPod(2), replicaSetPod()},
+			)).To(HaveLen(2))
+			ExpectExists(ctx, env.Client, nodeClaims[2])
+		})
+		It("should consolidate the node with the highest StatefulSet ordinal first", func() {
+			nodePool.Spec.Disruption.ConsolidationOrder = v1beta1.ConsolidationOrderHighestStatefulSetOrdinal
+			Expect(expectConsolidation(
+				[]*v1.Pod{statefulSetPod(0)},
+				[]*v1.Pod{statefulSetPod(1)},
+				[]*v1.Pod{statefulSetPod(2), replicaSetPod()},
+			)).To(ConsistOf(nodeClaims[0].Name, nodeClaims[1].Name))
+			ExpectNotFound(ctx, env.Client, nodeClaims[2], nodes[2])
+		})
+		It("should consolidate nodes with StatefulSet pods before nodes without them", func() {
+			nodePool.Spec.Disruption.ConsolidationOrder = v1beta1.ConsolidationOrderHighestStatefulSetOrdinal
+			Expect(expectConsolidation(
+				[]*v1.Pod{replicaSetPod()},
+				[]*v1.Pod{statefulSetPod(0), replicaSetPod()},
+				[]*v1.Pod{replicaSetPod(), replicaSetPod()},
+			)).To(ConsistOf(nodeClaims[0].Name, nodeClaims[2].Name))
+			ExpectNotFound(ctx, env.Client, nodeClaims[1], nodes[1])
+		})
+	})
+	Context("Reservation Expiry Consideration", func() {
+		var nodeClaims []*v1beta1.NodeClaim
+		var nodes []*v1.Node
+		var reservedInstance, otherInstance *cloudprovider.InstanceType
+
+		BeforeEach(func() {
+			reservedInstance = leastExpensiveInstance
+			// find an instance type with the same price that isn't backed by a reservation
+			var ok bool
+			otherInstance, ok = lo.Find(onDemandInstances, func(it *cloudprovider.InstanceType) bool {
+				return it.Name != reservedInstance.Name && it.Offerings.Cheapest().Price == leastExpensiveOffering.Price
+			})
+			Expect(ok).To(BeTrue())
+			nodeClaims, nodes = test.NodeClaimsAndNodes(2, v1beta1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1beta1.NodePoolLabelKey:     nodePool.Name,
+						v1.LabelInstanceTypeStable:   reservedInstance.Name,
+						v1beta1.CapacityTypeLabelKey: reservedInstance.Offerings[0].CapacityType,
+						v1.LabelTopologyZone:         reservedInstance.Offerings[0].Zone,
+					},
+				},
+				Status: v1beta1.NodeClaimStatus{
+					Allocatable: map[v1.ResourceName]resource.Quantity{
+						v1.ResourceCPU:  resource.MustParse("32"),
+						v1.ResourcePods: resource.MustParse("100"),
+					},
+				},
+			})
+			for _, obj := range []client.Object{nodeClaims[1], nodes[1]} {
+				obj.SetLabels(lo.Assign(obj.GetLabels(), map[string]string{
+					v1.LabelInstanceTypeStable:   otherInstance.Name,
+					v1beta1.CapacityTypeLabelKey: otherInstance.Offerings[0].CapacityType,
+					v1.LabelTopologyZone:         otherInstance.Offerings[0].Zone,
+				}))
+			}
+		})
+		It("should consider reservation expiry when calculating disruption cost", func() {
+			// the reserved rate of the first node's offering is ending soon
+			reservedInstance.Offerings[0].ReservationExpiry = lo.ToPtr(fakeClock.Now().Add(time.Hour))
+
+			// create our RS so we can link a pod to it
+			rs := test.ReplicaSet()
+			ExpectApplied(ctx, env.Client, rs)
+
+			pods := test.Pods(3, test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         ptr.Bool(true),
+							BlockOwnerDeletion: ptr.Bool(true),
+						},
+					}}})
+
+			ExpectApplied(ctx, env.Client, rs, pods[0], pods[1], pods[2], nodePool, nodeClaims[0], nodes[0], nodeClaims[1], nodes[1])
+
+			// two pods on node 1, one on node 2
+			ExpectManualBinding(ctx, env.Client, pods[0], nodes[0])
+			ExpectManualBinding(ctx, env.Client, pods[1], nodes[0])
+			ExpectManualBinding(ctx, env.Client, pods[2], nodes[1])
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{nodes[0], nodes[1]}, []*v1beta1.NodeClaim{nodeClaims[0], nodeClaims[1]})
+
+			var wg sync.WaitGroup
+			ExpectTriggerVerifyAction(&wg)
+			ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+			wg.Wait()
+
+			// Process the item so that the nodes can be deleted.
+			ExpectReconcileSucceeded(ctx, queue, types.NamespacedName{})
+
+			// Cascade any deletion of the nodeclaim to the node
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaims[0])
+
+			// the first node has more pods, so it would normally not be picked for consolidation, except its reservation
+			// is about to expire, so it should be deleted
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(1))
+			ExpectNotFound(ctx, env.Client, nodeClaims[0], nodes[0])
+		})
+	})
+	Context("Topology Consideration", func() {
+		var nodeClaims []*v1beta1.NodeClaim
+		var nodes []*v1.Node
+		var oldNodeClaimNames sets.Set[string]
+
+		BeforeEach(func() {
+			testZone1Instance := leastExpensiveInstanceWithZone("test-zone-1")
+			testZone2Instance := mostExpensiveInstanceWithZone("test-zone-2")
+			testZone3Instance := leastExpensiveInstanceWithZone("test-zone-3")
+
+			nodeClaims, nodes = test.NodeClaimsAndNodes(3, v1beta1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1beta1.NodePoolLabelKey:     nodePool.Name,
+						v1.LabelTopologyZone:         "test-zone-1",
+						v1.LabelInstanceTypeStable:   testZone1Instance.Name,
+						v1beta1.CapacityTypeLabelKey: testZone1Instance.Offerings[0].CapacityType,
+					},
+				},
+				Status: v1beta1.NodeClaimStatus{
+					Allocatable: map[v1.ResourceName]resource.Quantity{v1.ResourceCPU: resource.MustParse("1")},
+				},
+			})
+			nodeClaims[1].Labels = lo.Assign(nodeClaims[1].Labels, map[string]string{
+				v1.LabelTopologyZone:         "test-zone-2",
+				v1.LabelInstanceTypeStable:   testZone2Instance.Name,
+				v1beta1.CapacityTypeLabelKey: testZone2Instance.Offerings[0].CapacityType,
+			})
+			nodes[1].Labels = lo.Assign(nodes[1].Labels, map[string]string{
+				v1.LabelTopologyZone:         "test-zone-2",
+				v1.LabelInstanceTypeStable:   testZone2Instance.Name,
+				v1beta1.CapacityTypeLabelKey: testZone2Instance.Offerings[0].CapacityType,
+			})
+
+			nodeClaims[2].Labels = lo.Assign(nodeClaims[2].Labels, map[string]string{
+				v1.LabelTopologyZone:         "test-zone-3",
+				v1.LabelInstanceTypeStable:   testZone3Instance.Name,
+				v1beta1.CapacityTypeLabelKey: testZone1Instance.Offerings[0].CapacityType,
+			})
+			nodes[2].Labels = lo.Assign(nodes[2].Labels, map[string]string{
+				v1.LabelTopologyZone:         "test-zone-3",
+				v1.LabelInstanceTypeStable:   testZone3Instance.Name,
+				v1beta1.CapacityTypeLabelKey: testZone1Instance.Offerings[0].CapacityType,
+			})
+			oldNodeClaimNames = sets.New(nodeClaims[0].Name, nodeClaims[1].Name, nodeClaims[2].Name)
+		})
+		It("can replace node maintaining zonal topology spread", func() {
+			labels = map[string]string{
+				"app": "test-zonal-spread",
+			}
+			// create our RS so we can link a pod to it
+			rs := test.ReplicaSet()
+			ExpectApplied(ctx, env.Client, rs)
+
+			tsc := v1.TopologySpreadConstraint{
+				MaxSkew:           1,
+				TopologyKey:       v1.LabelTopologyZone,
+				WhenUnsatisfiable: v1.DoNotSchedule,
+				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
+			}
+			pods := test.Pods(4, test.PodOptions{
+				ResourceRequirements:      v1.ResourceRequirements{Requests: map[v1.ResourceName]resource.Quantity{v1.ResourceCPU: resource.MustParse("1")}},
+				TopologySpreadConstraints: []v1.TopologySpreadConstraint{tsc},
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         ptr.Bool(true),
+							BlockOwnerDeletion: ptr.Bool(true),
+						},
+					}}})
+
+			ExpectApplied(ctx, env.Client, rs, pods[0], pods[1], pods[2], nodeClaims[0], nodes[0], nodeClaims[1], nodes[1], nodeClaims[2], nodes[2], nodePool)
+
+			// bind pods to nodes
+			ExpectManualBinding(ctx, env.Client, pods[0], nodes[0])
+			ExpectManualBinding(ctx, env.Client, pods[1], nodes[1])
+			ExpectManualBinding(ctx, env.Client, pods[2], nodes[2])
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{nodes[0], nodes[1], nodes[2]}, []*v1beta1.NodeClaim{nodeClaims[0], nodeClaims[1], nodeClaims[2]})
+
+			ExpectSkew(ctx, env.Client, "default", &tsc).To(ConsistOf(1, 1, 1))
+
+			fakeClock.Step(10 * time.Minute)
+
+			// consolidation won't delete the old node until the new node is ready
+			var wg sync.WaitGroup
+			ExpectTriggerVerifyAction(&wg)
+			ExpectMakeNewNodeClaimsReady(ctx, env.Client, &wg, cluster, cloudProvider, 1)
+			ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+			wg.Wait()
+
+			// Process the item so that the nodes can be deleted.
+			ExpectReconcileSucceeded(ctx, queue, types.NamespacedName{})
+			// Cascade any deletion of the nodeclaim to the node
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaims[1])
+
+			// should create a new node as there is a cheaper one that can hold the pod
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(3))
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(3))
+			ExpectNotFound(ctx, env.Client, nodeClaims[1], nodes[1])
+
+			// Find the new node associated with the nodeclaim
+			newNodeClaim, ok := lo.Find(ExpectNodeClaims(ctx, env.Client), func(m *v1beta1.NodeClaim) bool {
+				return !oldNodeClaimNames.Has(m.Name)
+			})
+			Expect(ok).To(BeTrue())
+			newNode, ok := lo.Find(ExpectNodes(ctx, env.Client), func(n *v1.Node) bool {
+				return newNodeClaim.Status.ProviderID == n.Spec.ProviderID
+			})
+			Expect(ok).To(BeTrue())
+
+			// we need to emulate the replicaset controller and bind a new pod to the newly created node
+			ExpectApplied(ctx, env.Client, pods[3])
+			ExpectManualBinding(ctx, env.Client, pods[3], newNode)
+
+			// we should maintain our skew, the new node must be in the same zone as the old node it replaced
+			ExpectSkew(ctx, env.Client, "default", &tsc).To(ConsistOf(1, 1, 1))
+		})
+		It("won't delete node if it would violate pod anti-affinity", func() {
+			// create our RS so we can link a pod to it
+			rs := test.ReplicaSet()
+			ExpectApplied(ctx, env.Client, rs)
+			pods := test.Pods(3, test.PodOptions{
+				ResourceRequirements: v1.ResourceRequirements{Requests: map[v1.ResourceName]resource.Quantity{v1.ResourceCPU: resource.MustParse("1")}},
+				PodAntiRequirements: []v1.PodAffinityTerm{
+					{
+						LabelSelector: &metav1.LabelSelector{MatchLabels: labels},
+						TopologyKey:   v1.LabelHostname,
+					},
+				},
+				ObjectMeta: metav1.ObjectMeta{Labels: labels,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         ptr.Bool(true),
+							BlockOwnerDeletion: ptr.Bool(true),
+						},
+					},
+				},
+			})
+
+			// Make the Zone 2 instance also the least expensive instance
+			zone2Instance := leastExpensiveInstanceWithZone("test-zone-2")
+			nodes[1].Labels = lo.Assign(nodes[1].Labels, map[string]string{
+				v1beta1.NodePoolLabelKey:     nodePool.Name,
+				v1.LabelTopologyZone:         "test-zone-2",
+				v1.LabelInstanceTypeStable:   zone2Instance.Name,
+				v1beta1.CapacityTypeLabelKey: zone2Instance.Offerings[0].CapacityType,
+			})
+			nodeClaims[1].Labels = lo.Assign(nodeClaims[1].Labels, map[string]string{
+				v1beta1.NodePoolLabelKey:     nodePool.Name,
+				v1.LabelTopologyZone:         "test-zone-2",
+				v1.LabelInstanceTypeStable:   zone2Instance.Name,
+				v1beta1.CapacityTypeLabelKey: zone2Instance.Offerings[0].CapacityType,
+			})
+			ExpectApplied(ctx, env.Client, rs, pods[0], pods[1], pods[2], nodeClaims[0], nodes[0], nodeClaims[1], nodes[1], nodeClaims[2], nodes[2], nodePool)
+
+			// bind pods to nodes
+			ExpectManualBinding(ctx, env.Client, pods[0], nodes[0])
+			ExpectManualBinding(ctx, env.Client, pods[1], nodes[1])
+			ExpectManualBinding(ctx, env.Client, pods[2], nodes[2])
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{nodes[0], nodes[1], nodes[2]}, []*v1beta1.NodeClaim{nodeClaims[0], nodeClaims[1], nodeClaims[2]})
+
+			fakeClock.Step(10 * time.Minute)
+
+			var wg sync.WaitGroup
+			ExpectTriggerVerifyAction(&wg)
+			ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+			wg.Wait()
+
+			// our nodes are already the cheapest available, so we can't replace them.  If we delete, it would
+			// violate the anti-affinity rule, so we can't do anything.
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(3))
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(3))
+			ExpectExists(ctx, env.Client, nodeClaims[0])
+			ExpectExists(ctx, env.Client, nodeClaims[1])
+			ExpectExists(ctx, env.Client, nodeClaims[2])
+		})
+		It("can co-locate pods that prefer pod anti-affinity when no node keeps them apart", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{KeepPreferredAntiAffinity: lo.ToPtr(true)}}))
+			rs := test.ReplicaSet()
+			ExpectApplied(ctx, env.Client, rs)
+			pods := test.Pods(3, test.PodOptions{
+				ResourceRequirements: v1.ResourceRequirements{Requests: map[v1.ResourceName]resource.Quantity{v1.ResourceCPU: resource.MustParse("100m")}},
+				PodAntiPreferences: []v1.WeightedPodAffinityTerm{
+					{
+						Weight: 100,
+						PodAffinityTerm: v1.PodAffinityTerm{
+							LabelSelector: &metav1.LabelSelector{MatchLabels: labels},
+							TopologyKey:   v1.LabelHostname,
+						},
+					},
+				},
+				ObjectMeta: metav1.ObjectMeta{Labels: labels,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         ptr.Bool(true),
+							BlockOwnerDeletion: ptr.Bool(true),
+						},
+					},
+				},
+			})
+
+			// Make the Zone 2 instance also the least expensive instance, so that none of the nodes can be replaced
+			// by a cheaper one
+			zone2Instance := leastExpensiveInstanceWithZone("test-zone-2")
+			for _, obj := range []client.Object{nodes[1], nodeClaims[1]} {
+				obj.SetLabels(lo.Assign(obj.GetLabels(), map[string]string{
+					v1.LabelInstanceTypeStable:   zone2Instance.Name,
+					v1beta1.CapacityTypeLabelKey: zone2Instance.Offerings[0].CapacityType,
+				}))
+			}
+			// The pods would fit onto a single node if their anti-affinity was relaxed
+			for i := range nodes {
+				nodes[i].Status.Allocatable = v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourcePods: resource.MustParse("10")}
+				nodeClaims[i].Status.Allocatable = v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourcePods: resource.MustParse("10")}
+			}
+			ExpectApplied(ctx, env.Client, rs, pods[0], pods[1], pods[2], nodeClaims[0], nodes[0], nodeClaims[1], nodes[1], nodeClaims[2], nodes[2], nodePool)
+
+			ExpectManualBinding(ctx, env.Client, pods[0], nodes[0])
+			ExpectManualBinding(ctx, env.Client, pods[1], nodes[1])
+			ExpectManualBinding(ctx, env.Client, pods[2], nodes[2])
+
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{nodes[0], nodes[1], nodes[2]}, []*v1beta1.NodeClaim{nodeClaims[0], nodeClaims[1], nodeClaims[2]})
+
+			fakeClock.Step(10 * time.Minute)
+
+			var wg sync.WaitGroup
+			ExpectTriggerVerifyAction(&wg)
+			ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+			wg.Wait()
+
+			// Preferred pod anti-affinity only orders the nodes that the pods are moved to, so consolidation still
+			// co-locates the replicas when every remaining node already has one
+			ExpectReconcileSucceeded(ctx, queue, types.NamespacedName{})
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaims...)
+			Expect(len(ExpectNodeClaims(ctx, env.Client))).To(BeNumerically("<", 3))
+		})
+		It("can delete a node whose pods keep their preferred pod anti-affinity on other nodes", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{KeepPreferredAntiAffinity: lo.ToPtr(true)}}))
+			rs := test.ReplicaSet()
+			ExpectApplied(ctx, env.Client, rs)
+			antiAffine := test.Pods(2, test.PodOptions{
+				ResourceRequirements: v1.ResourceRequirements{Requests: map[v1.ResourceName]resource.Quantity{v1.ResourceCPU: resource.MustParse("100m")}},
+				PodAntiPreferences: []v1.WeightedPodAffinityTerm{
+					{
+						Weight: 100,
+						PodAffinityTerm: v1.PodAffinityTerm{
+							LabelSelector: &metav1.LabelSelector{MatchLabels: labels},
+							TopologyKey:   v1.LabelHostname,
+						},
+					},
+				},
+				ObjectMeta: metav1.ObjectMeta{Labels: labels,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         ptr.Bool(true),
+							BlockOwnerDeletion: ptr.Bool(true),
+						},
+					},
+				},
+			})
+			// the pod on the third node isn't selected by the anti-affinity of the others
+			other := test.Pod(test.PodOptions{
+				ResourceRequirements: v1.ResourceRequirements{Requests: map[v1.ResourceName]resource.Quantity{v1.ResourceCPU: resource.MustParse("100m")}},
+				ObjectMeta: metav1.ObjectMeta{
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         ptr.Bool(true),
+							BlockOwnerDeletion: ptr.Bool(true),
+						},
+					},
+				},
 			})
-			ExpectApplied(ctx, env.Client, rs, pods[0], pods[1], pods[2], nodeClaims[0], nodes[0], nodeClaims[1], nodes[1], nodeClaims[2], nodes[2], nodePool)
 
-			// bind pods to nodes
-			ExpectManualBinding(ctx, env.Client, pods[0], nodes[0])
-			ExpectManualBinding(ctx, env.Client, pods[1], nodes[1])
-			ExpectManualBinding(ctx, env.Client, pods[2], nodes[2])
+			zone2Instance := leastExpensiveInstanceWithZone("test-zone-2")
+			for _, obj := range []client.Object{nodes[1], nodeClaims[1]} {
+				obj.SetLabels(lo.Assign(obj.GetLabels(), map[string]string{
+					v1.LabelInstanceTypeStable:   zone2Instance.Name,
+					v1beta1.CapacityTypeLabelKey: zone2Instance.Offerings[0].CapacityType,
+				}))
+			}
+			for i := range nodes {
+				nodes[i].Status.Allocatable = v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourcePods: resource.MustParse("10")}
+				nodeClaims[i].Status.Allocatable = v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourcePods: resource.MustParse("10")}
+			}
+			ExpectApplied(ctx, env.Client, rs, antiAffine[0], antiAffine[1], other, nodeClaims[0], nodes[0], nodeClaims[1], nodes[1], nodeClaims[2], nodes[2], nodePool)
+
+			ExpectManualBinding(ctx, env.Client, antiAffine[0], nodes[0])
+			ExpectManualBinding(ctx, env.Client, antiAffine[1], nodes[1])
+			ExpectManualBinding(ctx, env.Client, other, nodes[2])
 
-			// inform cluster state about nodes and nodeclaims
 			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{nodes[0], nodes[1], nodes[2]}, []*v1beta1.NodeClaim{nodeClaims[0], nodeClaims[1], nodeClaims[2]})
 
 			fakeClock.Step(10 * time.Minute)
@@ -5008,13 +6226,375 @@ var _ = Describe("Consolidation", func() {
 			ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
 			wg.Wait()
 
-			// our nodes are already the cheapest available, so we can't replace them.  If we delete, it would
-			// violate the anti-affinity rule, so we can't do anything.
+			// The replicas can still be kept apart with one node fewer
+			ExpectReconcileSucceeded(ctx, queue, types.NamespacedName{})
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaims...)
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+		})
+	})
+	Context("Volume Topology Consideration", func() {
+		var zonalNodeClaim *v1beta1.NodeClaim
+		var zonalNode *v1.Node
+		var pod *v1.Pod
+		var pv *v1.PersistentVolume
+		var pvc *v1.PersistentVolumeClaim
+		var currentInstance *cloudprovider.InstanceType
+
+		BeforeEach(func() {
+			currentInstance = fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name: "current-on-demand",
+				Offerings: []cloudprovider.Offering{
+					{CapacityType: v1beta1.CapacityTypeOnDemand, Zone: "test-zone-2", Price: 1.5, Available: true},
+				},
+			})
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+				currentInstance,
+				fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: "cheaper-zone-1",
+					Offerings: []cloudprovider.Offering{
+						{CapacityType: v1beta1.CapacityTypeOnDemand, Zone: "test-zone-1", Price: 0.5, Available: true},
+					},
+				}),
+			}
+			zonalNodeClaim, zonalNode = test.NodeClaimAndNode(v1beta1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1beta1.NodePoolLabelKey:     nodePool.Name,
+						v1.LabelInstanceTypeStable:   currentInstance.Name,
+						v1beta1.CapacityTypeLabelKey: v1beta1.CapacityTypeOnDemand,
+						v1.LabelTopologyZone:         "test-zone-2",
+					},
+				},
+				Status: v1beta1.NodeClaimStatus{
+					Allocatable: map[v1.ResourceName]resource.Quantity{v1.ResourceCPU: resource.MustParse("32")},
+				},
+			})
+			// the pod's volume was provisioned in test-zone-2 and can only be attached to nodes in that zone
+			pv = test.PersistentVolume(test.PersistentVolumeOptions{Zones: []string{"test-zone-2"}})
+			pvc = test.PersistentVolumeClaim(test.PersistentVolumeClaimOptions{VolumeName: pv.Name})
+			rs := test.ReplicaSet()
+			ExpectApplied(ctx, env.Client, rs)
+			pod = test.Pod(test.PodOptions{
+				PersistentVolumeClaims: []string{pvc.Name},
+				ObjectMeta: metav1.ObjectMeta{Labels: labels,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         ptr.Bool(true),
+							BlockOwnerDeletion: ptr.Bool(true),
+						},
+					}}})
+		})
+		It("won't replace a node with a cheaper node in a zone the pod's volume can't attach to", func() {
+			ExpectApplied(ctx, env.Client, pv, pvc, pod, zonalNode, zonalNodeClaim, nodePool)
+			ExpectManualBinding(ctx, env.Client, pod, zonalNode)
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{zonalNode}, []*v1beta1.NodeClaim{zonalNodeClaim})
+
+			fakeClock.Step(10 * time.Minute)
+
+			var wg sync.WaitGroup
+			ExpectTriggerVerifyAction(&wg)
+			ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+			wg.Wait()
+
+			// the only cheaper instance type is in test-zone-1, so the node can't be replaced
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(1))
+			ExpectExists(ctx, env.Client, zonalNodeClaim)
+			ExpectExists(ctx, env.Client, zonalNode)
+		})
+		It("can replace a node with a cheaper node in the same zone as the pod's volume", func() {
+			cloudProvider.InstanceTypes = append(cloudProvider.InstanceTypes, fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name: "cheaper-zone-2",
+				Offerings: []cloudprovider.Offering{
+					{CapacityType: v1beta1.CapacityTypeOnDemand, Zone: "test-zone-2", Price: 1.0, Available: true},
+				},
+			}))
+			ExpectApplied(ctx, env.Client, pv, pvc, pod, zonalNode, zonalNodeClaim, nodePool)
+			ExpectManualBinding(ctx, env.Client, pod, zonalNode)
+
+			// inform cluster state about nodes and nodeclaims
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{zonalNode}, []*v1beta1.NodeClaim{zonalNodeClaim})
+
+			fakeClock.Step(10 * time.Minute)
+
+			// consolidation won't delete the old nodeclaim until the new nodeclaim is ready
+			var wg sync.WaitGroup
+			ExpectTriggerVerifyAction(&wg)
+			ExpectMakeNewNodeClaimsReady(ctx, env.Client, &wg, cluster, cloudProvider, 1)
+			ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+			wg.Wait()
+
+			// Process the item so that the nodes can be deleted.
+			ExpectReconcileSucceeded(ctx, queue, types.NamespacedName{})
+			// Cascade any deletion of the nodeclaim to the node
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, zonalNodeClaim)
+
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
+			ExpectNotFound(ctx, env.Client, zonalNodeClaim, zonalNode)
+
+			// the replacement must stay in the zone of the volume, even though test-zone-1 is cheaper
+			requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaims[0].Spec.Requirements...)
+			Expect(requirements.Get(v1.LabelTopologyZone).Values()).To(ConsistOf("test-zone-2"))
+			Expect(requirements.Get(v1.LabelInstanceTypeStable).Values()).To(ConsistOf("cheaper-zone-2"))
+		})
+		Context("WaitForFirstConsumer", func() {
+			var sc *storagev1.StorageClass
+
+			BeforeEach(func() {
+				// the pod's claim hasn't been bound yet, but its storage class only allows volumes in test-zone-2
+				sc = test.StorageClass(test.StorageClassOptions{
+					Zones:             []string{"test-zone-2"},
+					VolumeBindingMode: lo.ToPtr(storagev1.VolumeBindingWaitForFirstConsumer),
+				})
+				pvc = test.PersistentVolumeClaim(test.PersistentVolumeClaimOptions{StorageClassName: lo.ToPtr(sc.Name)})
+				pod.Spec.Volumes = []v1.Volume{{
+					Name:         "test-volume",
+					VolumeSource: v1.VolumeSource{PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: pvc.Name}},
+				}}
+			})
+			It("won't replace a node with a cheaper node in a zone the pod's pending claim can't bind in", func() {
+				ExpectApplied(ctx, env.Client, sc, pvc, pod, zonalNode, zonalNodeClaim, nodePool)
+				ExpectManualBinding(ctx, env.Client, pod, zonalNode)
+
+				// inform cluster state about nodes and nodeclaims
+				ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{zonalNode}, []*v1beta1.NodeClaim{zonalNodeClaim})
+
+				fakeClock.Step(10 * time.Minute)
+
+				var wg sync.WaitGroup
+				ExpectTriggerVerifyAction(&wg)
+				ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+				wg.Wait()
+
+				// the only cheaper instance type is in test-zone-1, so the node can't be replaced
+				Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+				Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(1))
+				ExpectExists(ctx, env.Client, zonalNodeClaim)
+				ExpectExists(ctx, env.Client, zonalNode)
+			})
+			It("won't move a pod onto an existing node in a zone the pod's pending claim can't bind in", func() {
+				zone1NodeClaim, zone1Node := test.NodeClaimAndNode(v1beta1.NodeClaim{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{
+							v1beta1.NodePoolLabelKey:     nodePool.Name,
+							v1.LabelInstanceTypeStable:   "cheaper-zone-1",
+							v1beta1.CapacityTypeLabelKey: v1beta1.CapacityTypeOnDemand,
+							v1.LabelTopologyZone:         "test-zone-1",
+						},
+					},
+					Status: v1beta1.NodeClaimStatus{
+						Allocatable: map[v1.ResourceName]resource.Quantity{v1.ResourceCPU: resource.MustParse("32")},
+					},
+				})
+				// keep the test-zone-1 node busy so that it isn't a consolidation candidate itself
+				zone1Pod := test.Pod(test.PodOptions{
+					ObjectMeta: metav1.ObjectMeta{
+						Annotations: map[string]string{v1beta1.DoNotDisruptAnnotationKey: "true"},
+					},
+				})
+				ExpectApplied(ctx, env.Client, sc, pvc, pod, zone1Pod, zonalNode, zonalNodeClaim, zone1Node, zone1NodeClaim, nodePool)
+				ExpectManualBinding(ctx, env.Client, pod, zonalNode)
+				ExpectManualBinding(ctx, env.Client, zone1Pod, zone1Node)
+
+				// inform cluster state about nodes and nodeclaims
+				ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{zonalNode, zone1Node}, []*v1beta1.NodeClaim{zonalNodeClaim, zone1NodeClaim})
+
+				fakeClock.Step(10 * time.Minute)
+
+				var wg sync.WaitGroup
+				ExpectTriggerVerifyAction(&wg)
+				ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+				wg.Wait()
+
+				// the test-zone-1 node has room for the pod, but its claim can only bind in test-zone-2
+				Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+				Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(2))
+				ExpectExists(ctx, env.Client, zonalNodeClaim)
+				ExpectExists(ctx, env.Client, zonalNode)
+			})
+			It("can replace a node with a cheaper node in a zone the pod's pending claim can bind in", func() {
+				cloudProvider.InstanceTypes = append(cloudProvider.InstanceTypes, fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: "cheaper-zone-2",
+					Offerings: []cloudprovider.Offering{
+						{CapacityType: v1beta1.CapacityTypeOnDemand, Zone: "test-zone-2", Price: 1.0, Available: true},
+					},
+				}))
+				ExpectApplied(ctx, env.Client, sc, pvc, pod, zonalNode, zonalNodeClaim, nodePool)
+				ExpectManualBinding(ctx, env.Client, pod, zonalNode)
+
+				// inform cluster state about nodes and nodeclaims
+				ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{zonalNode}, []*v1beta1.NodeClaim{zonalNodeClaim})
+
+				fakeClock.Step(10 * time.Minute)
+
+				// consolidation won't delete the old nodeclaim until the new nodeclaim is ready
+				var wg sync.WaitGroup
+				ExpectTriggerVerifyAction(&wg)
+				ExpectMakeNewNodeClaimsReady(ctx, env.Client, &wg, cluster, cloudProvider, 1)
+				ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+				wg.Wait()
+
+				// Process the item so that the nodes can be deleted.
+				ExpectReconcileSucceeded(ctx, queue, types.NamespacedName{})
+				// Cascade any deletion of the nodeclaim to the node
+				ExpectNodeClaimsCascadeDeletion(ctx, env.Client, zonalNodeClaim)
+
+				nodeClaims := ExpectNodeClaims(ctx, env.Client)
+				Expect(nodeClaims).To(HaveLen(1))
+				ExpectNotFound(ctx, env.Client, zonalNodeClaim, zonalNode)
+
+				// the replacement must stay in a zone allowed by the storage class, even though test-zone-1 is cheaper
+				requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaims[0].Spec.Requirements...)
+				Expect(requirements.Get(v1.LabelTopologyZone).Values()).To(ConsistOf("test-zone-2"))
+				Expect(requirements.Get(v1.LabelInstanceTypeStable).Values()).To(ConsistOf("cheaper-zone-2"))
+			})
+		})
+	})
+	Context("Batching", func() {
+		var rs *appsv1.ReplicaSet
+		var nodeClaims []*v1beta1.NodeClaim
+		var nodes []*v1.Node
+		var singleConsolidation *disruption.SingleNodeConsolidation
+		BeforeEach(func() {
+			rs = test.ReplicaSet()
+			ExpectApplied(ctx, env.Client, rs)
+			Expect(env.Client.Get(ctx, client.ObjectKeyFromObject(rs), rs)).To(Succeed())
+			nodeClaims, nodes = test.NodeClaimsAndNodes(3, v1beta1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1beta1.NodePoolLabelKey:     nodePool.Name,
+						v1.LabelInstanceTypeStable:   mostExpensiveInstance.Name,
+						v1beta1.CapacityTypeLabelKey: mostExpensiveOffering.CapacityType,
+						v1.LabelTopologyZone:         mostExpensiveOffering.Zone,
+					},
+				},
+				Status: v1beta1.NodeClaimStatus{
+					Allocatable: map[v1.ResourceName]resource.Quantity{
+						v1.ResourceCPU:  resource.MustParse("32"),
+						v1.ResourcePods: resource.MustParse("100"),
+					},
+				},
+			})
+			singleConsolidation = disruption.NewSingleNodeConsolidation(disruption.MakeConsolidation(fakeClock, cluster, env.Client, prov, cloudProvider, recorder, queue))
+		})
+		// applyPods binds one pod to each node. Pods with anti-affinity can't move onto the other nodes, so each node can
+		// only be consolidated by replacing it, independently of the other nodes.
+		applyPods := func(antiAffinity bool) {
+			GinkgoHelper()
+			podOptions := test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels,
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "apps/v1",
+							Kind:               "ReplicaSet",
+							Name:               rs.Name,
+							UID:                rs.UID,
+							Controller:         ptr.Bool(true),
+							BlockOwnerDeletion: ptr.Bool(true),
+						},
+					}},
+				ResourceRequirements: v1.ResourceRequirements{Requests: map[v1.ResourceName]resource.Quantity{v1.ResourceCPU: resource.MustParse("1")}},
+			}
+			if antiAffinity {
+				podOptions.PodAntiRequirements = []v1.PodAffinityTerm{{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: labels},
+					TopologyKey:   v1.LabelHostname,
+				}}
+			}
+			pods := test.Pods(len(nodes), podOptions)
+			ExpectApplied(ctx, env.Client, nodePool)
+			for i := range nodes {
+				ExpectApplied(ctx, env.Client, pods[i], nodeClaims[i], nodes[i])
+				ExpectManualBinding(ctx, env.Client, pods[i], nodes[i])
+			}
+			ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+		}
+		computeCommands := func() []disruption.Command {
+			GinkgoHelper()
+			budgets, err := disruption.BuildDisruptionBudgets(ctx, cluster, fakeClock, env.Client, recorder)
+			Expect(err).ToNot(HaveOccurred())
+			candidates, err := disruption.GetCandidates(ctx, cluster, env.Client, recorder, fakeClock, cloudProvider, singleConsolidation.ShouldDisrupt, queue)
+			Expect(err).ToNot(HaveOccurred())
+
+			var wg sync.WaitGroup
+			ExpectTriggerVerifyAction(&wg)
+			cmds, results, err := singleConsolidation.ComputeCommands(ctx, budgets, candidates...)
+			wg.Wait()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(results).To(HaveLen(len(cmds)))
+			return cmds
+		}
+		// expectDisjoint expects each node to be disrupted by at most one of the commands
+		expectDisjoint := func(cmds []disruption.Command) {
+			GinkgoHelper()
+			for _, n := range nodes {
+				Expect(lo.CountBy(cmds, func(cmd disruption.Command) bool { return strings.Contains(cmd.String(), n.Name) })).To(BeNumerically("<=", 1))
+			}
+		}
+
+		It("should only compute a single command by default", func() {
+			applyPods(true)
+			cmds := computeCommands()
+			Expect(cmds).To(HaveLen(1))
+			Expect(cmds[0].Action()).To(Equal(disruption.ReplaceAction))
+		})
+		It("should compute multiple independent commands up to the batch size", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ConsolidationBatchSize: lo.ToPtr(2)}))
+			applyPods(true)
+			cmds := computeCommands()
+			Expect(cmds).To(HaveLen(2))
+			Expect(lo.Map(cmds, func(cmd disruption.Command, _ int) disruption.Action { return cmd.Action() })).To(ConsistOf(disruption.ReplaceAction, disruption.ReplaceAction))
+			expectDisjoint(cmds)
+		})
+		It("should compute a command for every candidate if the batch size allows it", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ConsolidationBatchSize: lo.ToPtr(10)}))
+			applyPods(true)
+			cmds := computeCommands()
+			Expect(cmds).To(HaveLen(3))
+			expectDisjoint(cmds)
+		})
+		It("should respect budgets across the batched commands", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ConsolidationBatchSize: lo.ToPtr(10)}))
+			nodePool.Spec.Disruption.Budgets = []v1beta1.Budget{{Nodes: "2"}}
+			applyPods(true)
+			cmds := computeCommands()
+			Expect(cmds).To(HaveLen(2))
+			expectDisjoint(cmds)
+		})
+		It("should not batch commands that move pods onto a node that another command disrupts", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ConsolidationBatchSize: lo.ToPtr(10)}))
+			// without anti-affinity, each node's pod is moved onto one of the other nodes
+			applyPods(false)
+			cmds := computeCommands()
+			Expect(cmds).To(HaveLen(1))
+			Expect(cmds[0].Action()).To(Equal(disruption.DeleteAction))
+		})
+		It("should execute all of the batched commands in a single disruption loop", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ConsolidationBatchSize: lo.ToPtr(10)}))
+			applyPods(true)
+
+			var wg sync.WaitGroup
+			ExpectTriggerVerifyAction(&wg)
+			ExpectMakeNewNodeClaimsReady(ctx, env.Client, &wg, cluster, cloudProvider, 3)
+			ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+			wg.Wait()
+
+			// every node was replaced by its own command
+			Expect(cloudProvider.CreateCalls).To(HaveLen(3))
+			// the queue processes one command per reconcile
+			for range nodeClaims {
+				ExpectReconcileSucceeded(ctx, queue, types.NamespacedName{})
+			}
+			ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaims...)
+			ExpectNotFound(ctx, env.Client, lo.Map(nodeClaims, func(nc *v1beta1.NodeClaim, _ int) client.Object { return nc })...)
 			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(3))
-			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(3))
-			ExpectExists(ctx, env.Client, nodeClaims[0])
-			ExpectExists(ctx, env.Client, nodeClaims[1])
-			ExpectExists(ctx, env.Client, nodeClaims[2])
 		})
 	})
 	Context("Parallelization", func() {
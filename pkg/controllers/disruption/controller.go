@@ -24,7 +24,11 @@ import (
 	"time"
 
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/multierr"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/utils/clock"
 	"knative.dev/pkg/logging"
@@ -36,13 +40,18 @@ import (
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/disruption/candidates"
 	"sigs.k8s.io/karpenter/pkg/controllers/disruption/orchestration"
+	"sigs.k8s.io/karpenter/pkg/controllers/node/termination/terminator"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/operator/controller"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/tracing"
+	podutil "sigs.k8s.io/karpenter/pkg/utils/pod"
 )
 
 type Controller struct {
@@ -53,9 +62,14 @@ type Controller struct {
 	recorder      events.Recorder
 	clock         clock.Clock
 	cloudProvider cloudprovider.CloudProvider
+	evictionQueue *terminator.Queue
 	methods       []Method
 	mu            sync.Mutex
 	lastRun       map[string]time.Time
+	// rebalances maps the provider IDs of the nodes that are tainted while their pods are rebalanced to their rebalance
+	rebalances map[string]rebalance
+	// pending are the commands of the current disruption loop that are waiting to be approved in manual disruption mode
+	pending []candidates.Command
 }
 
 // pollingPeriod that we inspect cluster to look for opportunities to disrupt
@@ -64,7 +78,7 @@ const pollingPeriod = 10 * time.Second
 var errCandidateDeleting = fmt.Errorf("candidate is deleting")
 
 func NewController(clk clock.Clock, kubeClient client.Client, provisioner *provisioning.Provisioner,
-	cp cloudprovider.CloudProvider, recorder events.Recorder, cluster *state.Cluster, queue *orchestration.Queue,
+	cp cloudprovider.CloudProvider, recorder events.Recorder, cluster *state.Cluster, queue *orchestration.Queue, evictionQueue *terminator.Queue,
 ) *Controller {
 	c := MakeConsolidation(clk, cluster, kubeClient, provisioner, cp, recorder, queue)
 	return &Controller{
@@ -75,20 +89,28 @@ func NewController(clk clock.Clock, kubeClient client.Client, provisioner *provi
 		provisioner:   provisioner,
 		recorder:      recorder,
 		cloudProvider: cp,
+		evictionQueue: evictionQueue,
 		lastRun:       map[string]time.Time{},
+		rebalances:    map[string]rebalance{},
 		methods: []Method{
+			// Replace any NodeClaims with maintenance scheduled by the cloud provider before their instances are stopped
+			NewMaintenance(kubeClient, cluster, provisioner, recorder),
+			// Replace any NodeClaims whose nodes have been NotReady for longer than their NodePool allows
+			NewUnhealthy(clk, kubeClient, cluster, provisioner, recorder),
 			// Expire any NodeClaims that must be deleted, allowing their pods to potentially land on currently
 			NewExpiration(clk, kubeClient, cluster, provisioner, recorder),
 			// Terminate any NodeClaims that have drifted from provisioning specifications, allowing the pods to reschedule.
 			NewDrift(kubeClient, cluster, provisioner, recorder),
 			// Delete any remaining empty NodeClaims as there is zero cost in terms of disruption.  Emptiness and
 			// emptyNodeConsolidation are mutually exclusive, only one of these will operate
-			NewEmptiness(clk, recorder),
+			NewEmptiness(clk, cluster, recorder),
 			NewEmptyNodeConsolidation(c),
 			// Attempt to identify multiple NodeClaims that we can consolidate simultaneously to reduce pod churn
 			NewMultiNodeConsolidation(c),
 			// And finally fall back our single NodeClaim consolidation to further reduce cluster cost.
 			NewSingleNodeConsolidation(c),
+			// If no node can be removed, move the pods off of nodes that are pinned by pods that can't be evicted.
+			NewPodRebalancing(c),
 		},
 	}
 }
@@ -101,7 +123,10 @@ func (c *Controller) Builder(_ context.Context, m manager.Manager) controller.Bu
 	return controller.NewSingletonManagedBy(m)
 }
 
-func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (_ reconcile.Result, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "disruption.Reconcile")
+	defer func() { tracing.End(span, err) }()
+
 	// this won't catch if the reconcile loop hangs forever, but it will catch other issues
 	c.logAbnormalRuns(ctx)
 	defer c.logAbnormalRuns(ctx)
@@ -120,17 +145,34 @@ func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconc
 
 	// Karpenter taints nodes with a karpenter.sh/disruption taint as part of the disruption process
 	// while it progresses in memory. If Karpenter restarts during a disruption action, some nodes can be left tainted.
-	// Idempotently remove this taint from candidates that are not in the orchestration queue before continuing.
+	// Idempotently remove this taint from candidates that are not in the orchestration queue or having their pods
+	// rebalanced before continuing.
+	rebalancing := c.rebalancingNodes(ctx)
 	if err := state.RequireNoScheduleTaint(ctx, c.kubeClient, false, lo.Filter(c.cluster.Nodes(), func(s *state.StateNode, _ int) bool {
-		return !c.queue.HasAny(s.ProviderID())
+		return !c.queue.HasAny(s.ProviderID()) && !rebalancing.Has(s.ProviderID())
 	})...); err != nil {
 		return reconcile.Result{}, fmt.Errorf("removing taint from nodes, %w", err)
 	}
 
+	// Consolidation is only allowed within the global consolidation window, if one is configured
+	consolidationAllowed, err := ConsolidationWindowActive(ctx, c.clock)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("evaluating consolidation schedule, %w", err)
+	}
+	ConsolidationWindowActiveGauge.Set(lo.Ternary(consolidationAllowed, 1.0, 0.0))
+
+	// Report the commands that weren't executed because they are waiting to be approved once the loop completes
+	c.pending = nil
+	defer func() { candidates.Pending.Record(ctx, c.clock.Now(), c.pending) }()
+
 	// Attempt different disruption methods. We'll only let one method perform an action
+	sampled := sets.New[string]()
 	for _, m := range c.methods {
+		if IsConsolidation(m) && !consolidationAllowed {
+			continue
+		}
 		c.recordRun(fmt.Sprintf("%T", m))
-		success, err := c.disrupt(ctx, m)
+		success, err := c.disrupt(ctx, m, sampled)
 		if err != nil {
 			return reconcile.Result{}, fmt.Errorf("disrupting via %q, %w", m.Type(), err)
 		}
@@ -143,15 +185,30 @@ func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconc
 	return reconcile.Result{RequeueAfter: pollingPeriod}, nil
 }
 
-func (c *Controller) disrupt(ctx context.Context, disruption Method) (bool, error) {
+// disrupt computes and executes the commands of a disruption method. The utilization of consolidation candidates is
+// sampled once per disruption loop, so the candidates that have already been sampled are tracked across methods.
+func (c *Controller) disrupt(ctx context.Context, disruption Method, sampled sets.Set[string]) (_ bool, err error) {
 	defer metrics.Measure(EvaluationDurationHistogram.With(map[string]string{
 		methodLabel:            disruption.Type(),
 		consolidationTypeLabel: disruption.ConsolidationType(),
 	}))()
-	candidates, err := GetCandidates(ctx, c.cluster, c.kubeClient, c.recorder, c.clock, c.cloudProvider, disruption.ShouldDisrupt, c.queue)
+	ctx, span := tracing.Tracer().Start(ctx, "disruption.Disrupt", trace.WithAttributes(tracing.MethodKey.String(fmt.Sprintf("%s/%s", disruption.Type(), disruption.ConsolidationType()))))
+	defer func() { tracing.End(span, err) }()
+
+	getCandidates := GetCandidates
+	if _, ok := disruption.(*PodRebalancing); ok {
+		getCandidates = GetRebalanceCandidates
+	}
+	candidatesCtx, candidatesSpan := tracing.Tracer().Start(ctx, "disruption.GetCandidates")
+	candidates, err := getCandidates(candidatesCtx, c.cluster, c.kubeClient, c.recorder, c.clock, c.cloudProvider, disruption.ShouldDisrupt, c.queue)
+	candidatesSpan.SetAttributes(tracing.CandidatesKey.Int(len(candidates)))
+	tracing.End(candidatesSpan, err)
 	if err != nil {
 		return false, fmt.Errorf("determining candidates, %w", err)
 	}
+	if IsConsolidation(disruption) {
+		recordUtilization(candidates, sampled)
+	}
 	// If there are no candidates, move to the next disruption
 	if len(candidates) == 0 {
 		return false, nil
@@ -161,36 +218,85 @@ func (c *Controller) disrupt(ctx context.Context, disruption Method) (bool, erro
 		return false, fmt.Errorf("building disruption budgets, %w", err)
 	}
 
+	// In manual disruption mode, only the approved candidates are disrupted and the others are reported for approval
+	manual := options.FromContext(ctx).ManualDisruption
+	if manual {
+		unapproved := lo.Reject(candidates, func(c *Candidate, _ int) bool { return approved(c) })
+		if err = c.reportPending(ctx, disruption, disruptionBudgetMapping, unapproved); err != nil {
+			return false, err
+		}
+		if candidates = lo.Filter(candidates, func(c *Candidate, _ int) bool { return approved(c) }); len(candidates) == 0 {
+			return false, nil
+		}
+	}
+
 	// Determine the disruption action
-	cmd, schedulingResults, err := disruption.ComputeCommand(ctx, disruptionBudgetMapping, candidates...)
+	computeCtx, computeSpan := tracing.Tracer().Start(ctx, "disruption.ComputeCommand", trace.WithAttributes(tracing.CandidatesKey.Int(len(candidates))))
+	cmds, schedulingResults, err := computeCommands(computeCtx, disruption, disruptionBudgetMapping, candidates...)
+	decision := NoOpAction
+	if len(cmds) > 0 {
+		decision = cmds[0].Action()
+	}
+	computeSpan.SetAttributes(tracing.DecisionKey.String(string(decision)))
+	tracing.End(computeSpan, err)
 	if err != nil {
 		return false, fmt.Errorf("computing disruption decision, %w", err)
 	}
-	if cmd.Action() == NoOpAction {
+	span.SetAttributes(tracing.DecisionKey.String(string(decision)))
+	if len(cmds) == 0 {
 		return false, nil
 	}
-
-	// Attempt to disrupt
-	if err := c.executeCommand(ctx, disruption, cmd, schedulingResults); err != nil {
-		return false, fmt.Errorf("disrupting candidates, %w", err)
+	// Attempt to disrupt. The commands don't conflict with each other, so the commands that were executed before one
+	// fails are still valid on their own.
+	for i, cmd := range cmds {
+		executeCtx, executeSpan := tracing.Tracer().Start(ctx, "disruption.ExecuteCommand", trace.WithAttributes(
+			tracing.CandidatesKey.Int(len(cmd.candidates)), tracing.NodeClaimsKey.Int(len(cmd.replacements))))
+		err = c.executeCommand(executeCtx, disruption, cmd, schedulingResults[i])
+		tracing.End(executeSpan, err)
+		if err != nil {
+			return false, fmt.Errorf("disrupting candidates, %w", err)
+		}
+		if manual {
+			c.clearApproval(ctx, cmd)
+		}
 	}
-
 	return true, nil
 }
 
+// computeCommands computes the commands of a method, which are all executed in the same disruption loop. Only a
+// BatchMethod computes more than one command.
+func computeCommands(ctx context.Context, m Method, disruptionBudgetMapping map[string]int, candidates ...*Candidate) ([]Command, []scheduling.Results, error) {
+	if b, ok := m.(BatchMethod); ok {
+		return b.ComputeCommands(ctx, disruptionBudgetMapping, candidates...)
+	}
+	cmd, results, err := m.ComputeCommand(ctx, disruptionBudgetMapping, candidates...)
+	if err != nil || cmd.Action() == NoOpAction {
+		return nil, nil, err
+	}
+	return []Command{cmd}, []scheduling.Results{results}, nil
+}
+
 // executeCommand will do the following, untainting if the step fails.
-// 1. Taint candidate nodes
+// 1. Taint candidate nodes and annotate them with the disruption reason
 // 2. Spin up replacement nodes
 // 3. Add Command to orchestration.Queue to wait to delete the candiates.
 func (c *Controller) executeCommand(ctx context.Context, m Method, cmd Command, schedulingResults scheduling.Results) error {
 	commandID := uuid.NewUUID()
 	logging.FromContext(ctx).With("command-id", commandID).Infof("disrupting via %s %s", m.Type(), cmd)
 
+	// Rebalancing only evicts pods, so the candidates aren't handed to the orchestration queue
+	if cmd.Action() == RebalanceAction {
+		if err := c.rebalance(ctx, m, cmd, schedulingResults); err != nil {
+			return fmt.Errorf("rebalancing pods (command-id: %s), %w", commandID, err)
+		}
+		return nil
+	}
+
 	stateNodes := lo.Map(cmd.candidates, func(c *Candidate, _ int) *state.StateNode {
 		return c.StateNode
 	})
 	// Cordon the old nodes before we launch the replacements to prevent new pods from scheduling to the old nodes
-	if err := state.RequireNoScheduleTaint(ctx, c.kubeClient, true, stateNodes...); err != nil {
+	if err := state.RequireDisruptionTaint(ctx, c.kubeClient, m.Type(), c.clock.Now(), stateNodes...); err != nil {
 		return multierr.Append(fmt.Errorf("tainting nodes (command-id: %s), %w", commandID, err), state.RequireNoScheduleTaint(ctx, c.kubeClient, false, stateNodes...))
 	}
 
@@ -245,9 +351,70 @@ func (c *Controller) executeCommand(ctx context.Context, m Method, cmd Command,
 			consolidationTypeLabel: m.ConsolidationType(),
 		}).Add(float64(len(cd.reschedulablePods)))
 	}
+	if m.ConsolidationType() != "" {
+		for nodePoolName, savings := range consolidationSavings(cmd) {
+			ConsolidationSavingsEstimateCounter.With(map[string]string{
+				metrics.NodePoolLabel: nodePoolName,
+			}).Add(savings)
+		}
+	}
+	return nil
+}
+
+// rebalance taints the candidates so that the evicted pods don't schedule back onto them, nominates the nodes that the
+// evicted pods are expected to schedule to and hands the pods to the eviction queue, which respects the PDBs of the pods
+func (c *Controller) rebalance(ctx context.Context, m Method, cmd Command, schedulingResults scheduling.Results) error {
+	stateNodes := lo.Map(cmd.candidates, func(c *Candidate, _ int) *state.StateNode {
+		return c.StateNode
+	})
+	if err := state.RequireNoScheduleTaint(ctx, c.kubeClient, true, stateNodes...); err != nil {
+		return multierr.Append(fmt.Errorf("tainting nodes, %w", err), state.RequireNoScheduleTaint(ctx, c.kubeClient, false, stateNodes...))
+	}
+	for _, cd := range cmd.candidates {
+		c.rebalances[cd.ProviderID()] = rebalance{pods: cmd.evictions, startedAt: c.clock.Now()}
+	}
+	schedulingResults.Record(logging.WithLogger(ctx, operatorlogging.NopLogger), c.recorder, c.cluster)
+	c.evictionQueue.Add(cmd.evictions...)
+
+	ActionsPerformedCounter.With(map[string]string{
+		actionLabel:            string(cmd.Action()),
+		methodLabel:            m.Type(),
+		consolidationTypeLabel: m.ConsolidationType(),
+	}).Inc()
+	for _, cd := range cmd.candidates {
+		PodsDisruptedCounter.With(map[string]string{
+			metrics.NodePoolLabel:  cd.nodePool.Name,
+			actionLabel:            string(cmd.Action()),
+			methodLabel:            m.Type(),
+			consolidationTypeLabel: m.ConsolidationType(),
+		}).Add(float64(len(cmd.evictions)))
+	}
 	return nil
 }
 
+// rebalancingNodes returns the provider IDs of the nodes that stay tainted while their pods are rebalanced. A rebalance
+// is done once every evicted pod is gone from its node, or once the pod rebalancing cooldown has passed.
+func (c *Controller) rebalancingNodes(ctx context.Context) sets.Set[string] {
+	rebalancing := sets.New[string]()
+	for providerID, r := range c.rebalances {
+		if c.clock.Since(r.startedAt) >= PodRebalancingCooldown || lo.EveryBy(r.pods, func(p *v1.Pod) bool { return c.moved(ctx, p) }) {
+			delete(c.rebalances, providerID)
+			continue
+		}
+		rebalancing.Insert(providerID)
+	}
+	return rebalancing
+}
+
+// moved returns true if the evicted pod is gone from the node it was evicted from
+func (c *Controller) moved(ctx context.Context, pod *v1.Pod) bool {
+	current := &v1.Pod{}
+	if err := c.kubeClient.Get(ctx, client.ObjectKeyFromObject(pod), current); err != nil {
+		return apierrors.IsNotFound(err)
+	}
+	return current.UID != pod.UID || current.Spec.NodeName != pod.Spec.NodeName || !podutil.IsActive(current)
+}
+
 // createReplacementNodeClaims creates replacement NodeClaims
 func (c *Controller) createReplacementNodeClaims(ctx context.Context, m Method, cmd Command) ([]string, error) {
 	reason := fmt.Sprintf("%s/%s", m.Type(), cmd.Action())
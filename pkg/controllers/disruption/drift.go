@@ -18,19 +18,15 @@ package disruption
 
 import (
 	"context"
-	"errors"
-	"sort"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
-	disruptionevents "sigs.k8s.io/karpenter/pkg/controllers/disruption/events"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/metrics"
-	"sigs.k8s.io/karpenter/pkg/operator/options"
 )
 
 // Drift is a subreconciler that deletes drifted candidates.
@@ -52,71 +48,16 @@ func NewDrift(kubeClient client.Client, cluster *state.Cluster, provisioner *pro
 
 // ShouldDisrupt is a predicate used to filter candidates
 func (d *Drift) ShouldDisrupt(ctx context.Context, c *Candidate) bool {
-	return options.FromContext(ctx).FeatureGates.Drift &&
+	return nodePoolFeatureGates(ctx, d.recorder, c.nodePool).Drift &&
 		c.NodeClaim.StatusConditions().GetCondition(v1beta1.Drifted).IsTrue()
 }
 
 // ComputeCommand generates a disruption command given candidates
 func (d *Drift) ComputeCommand(ctx context.Context, disruptionBudgetMapping map[string]int, candidates ...*Candidate) (Command, scheduling.Results, error) {
-	sort.Slice(candidates, func(i int, j int) bool {
-		return candidates[i].NodeClaim.StatusConditions().GetCondition(v1beta1.Drifted).LastTransitionTime.Inner.Time.Before(
-			candidates[j].NodeClaim.StatusConditions().GetCondition(v1beta1.Drifted).LastTransitionTime.Inner.Time)
-	})
-	EligibleNodesGauge.With(map[string]string{
-		methodLabel:            d.Type(),
-		consolidationTypeLabel: d.ConsolidationType(),
-	}).Set(float64(len(candidates)))
-
-	// Do a quick check through the candidates to see if they're empty.
-	// For each candidate that is empty with a nodePool allowing its disruption
-	// add it to the existing command.
-	empty := make([]*Candidate, 0, len(candidates))
-	for _, candidate := range candidates {
-		if len(candidate.reschedulablePods) > 0 {
-			continue
-		}
-		// If there's disruptions allowed for the candidate's nodepool,
-		// add it to the list of candidates, and decrement the budget.
-		if disruptionBudgetMapping[candidate.nodePool.Name] > 0 {
-			empty = append(empty, candidate)
-			disruptionBudgetMapping[candidate.nodePool.Name]--
-		}
-	}
-	// Disrupt all empty drifted candidates, as they require no scheduling simulations.
-	if len(empty) > 0 {
-		return Command{
-			candidates: empty,
-		}, scheduling.Results{}, nil
-	}
-
-	for _, candidate := range candidates {
-		// If the disruption budget doesn't allow this candidate to be disrupted,
-		// continue to the next candidate. We don't need to decrement any budget
-		// counter since drift commands can only have one candidate.
-		if disruptionBudgetMapping[candidate.nodePool.Name] == 0 {
-			continue
-		}
-		// Check if we need to create any NodeClaims.
-		results, err := SimulateScheduling(ctx, d.kubeClient, d.cluster, d.provisioner, candidate)
-		if err != nil {
-			// if a candidate is now deleting, just retry
-			if errors.Is(err, errCandidateDeleting) {
-				continue
-			}
-			return Command{}, scheduling.Results{}, err
-		}
-		// Emit an event that we couldn't reschedule the pods on the node.
-		if !results.AllNonPendingPodsScheduled() {
-			d.recorder.Publish(disruptionevents.Blocked(candidate.Node, candidate.NodeClaim, "Scheduling simulation failed to schedule all pods")...)
-			continue
-		}
-
-		return Command{
-			candidates:   []*Candidate{candidate},
-			replacements: results.NewNodeClaims,
-		}, results, nil
-	}
-	return Command{}, scheduling.Results{}, nil
+	return computeConditionCommand(ctx, d, v1beta1.Drifted, d.cluster, d.recorder, disruptionBudgetMapping, candidates,
+		func(ctx context.Context, candidate *Candidate) (scheduling.Results, error) {
+			return SimulateScheduling(ctx, d.kubeClient, d.cluster, d.provisioner, candidate)
+		})
 }
 
 func (d *Drift) Type() string {
@@ -25,6 +25,7 @@ import (
 
 	disruptionevents "sigs.k8s.io/karpenter/pkg/controllers/disruption/events"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/events"
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
@@ -35,12 +36,14 @@ import (
 // Emptiness will respect TTLSecondsAfterEmpty
 type Emptiness struct {
 	clock    clock.Clock
+	cluster  *state.Cluster
 	recorder events.Recorder
 }
 
-func NewEmptiness(clk clock.Clock, recorder events.Recorder) *Emptiness {
+func NewEmptiness(clk clock.Clock, cluster *state.Cluster, recorder events.Recorder) *Emptiness {
 	return &Emptiness{
 		clock:    clk,
+		cluster:  cluster,
 		recorder: recorder,
 	}
 }
@@ -53,6 +56,10 @@ func (e *Emptiness) ShouldDisrupt(_ context.Context, c *Candidate) bool {
 	if c.nodePool.Spec.Disruption.ConsolidationPolicy != v1beta1.ConsolidationPolicyWhenEmpty {
 		return false
 	}
+	if c.Annotations()[v1beta1.DoNotConsolidateAnnotationKey] == "true" {
+		e.recorder.Publish(disruptionevents.Unconsolidatable(c.Node, c.NodeClaim, fmt.Sprintf("%s annotation exists", v1beta1.DoNotConsolidateAnnotationKey))...)
+		return false
+	}
 	if c.nodePool.Spec.Disruption.ConsolidateAfter != nil && c.nodePool.Spec.Disruption.ConsolidateAfter.Duration == nil {
 		e.recorder.Publish(disruptionevents.Unconsolidatable(c.Node, c.NodeClaim, fmt.Sprintf("NodePool %q has consolidation disabled", c.nodePool.Name))...)
 		return false
@@ -74,13 +81,14 @@ func (e *Emptiness) ComputeCommand(_ context.Context, disruptionBudgetMapping ma
 	}).Set(float64(len(candidates)))
 
 	empty := make([]*Candidate, 0, len(emptyCandidates))
+	minNodes := newMinNodesAllowance(e.cluster, emptyCandidates)
 	for _, candidate := range emptyCandidates {
 		if len(candidate.reschedulablePods) > 0 {
 			continue
 		}
-		// If there's disruptions allowed for the candidate's nodepool,
-		// add it to the list of candidates, and decrement the budget.
-		if disruptionBudgetMapping[candidate.nodePool.Name] > 0 {
+		// If there's disruptions allowed for the candidate's nodepool and deleting it doesn't take the nodepool below
+		// its minNodes, add it to the list of candidates, and decrement the budget.
+		if disruptionBudgetMapping[candidate.nodePool.Name] > 0 && minNodes.take(candidate) {
 			empty = append(empty, candidate)
 			disruptionBudgetMapping[candidate.nodePool.Name]--
 		}
@@ -51,6 +51,7 @@ func (c *EmptyNodeConsolidation) ComputeCommand(ctx context.Context, disruptionB
 
 	empty := make([]*Candidate, 0, len(candidates))
 	constrainedByBudgets := false
+	minNodes := newMinNodesAllowance(c.cluster, candidates)
 	for _, candidate := range candidates {
 		if len(candidate.reschedulablePods) > 0 {
 			continue
@@ -60,6 +61,10 @@ func (c *EmptyNodeConsolidation) ComputeCommand(ctx context.Context, disruptionB
 			constrainedByBudgets = true
 			continue
 		}
+		// Empty nodes that keep the nodepool at its minNodes aren't consolidated
+		if !minNodes.take(candidate) {
+			continue
+		}
 		// If there's disruptions allowed for the candidate's nodepool,
 		// add it to the list of candidates, and decrement the budget.
 		empty = append(empty, candidate)
@@ -83,10 +88,12 @@ func (c *EmptyNodeConsolidation) ComputeCommand(ctx context.Context, disruptionB
 	// Empty Node Consolidation doesn't use Validation as we get to take advantage of cluster.IsNodeNominated.  This
 	// lets us avoid a scheduling simulation (which is performed periodically while pending pods exist and drives
 	// cluster.IsNodeNominated already).
-	select {
-	case <-ctx.Done():
-		return Command{}, scheduling.Results{}, errors.New("interrupted")
-	case <-c.clock.After(consolidationTTL):
+	if delay := validationDelay(ctx, consolidationTTL, cmd.candidates); delay > 0 {
+		select {
+		case <-ctx.Done():
+			return Command{}, scheduling.Results{}, errors.New("interrupted")
+		case <-c.clock.After(delay):
+		}
 	}
 	validationCandidates, err := GetCandidates(ctx, c.cluster, c.kubeClient, c.recorder, c.clock, c.cloudProvider, c.ShouldDisrupt, c.queue)
 	if err != nil {
@@ -106,8 +113,10 @@ func (c *EmptyNodeConsolidation) ComputeCommand(ctx context.Context, disruptionB
 	// 1. All the candidatesToDelete are still empty
 	// 2. The node isn't a target of a recent scheduling simulation
 	// 3. the number of candidates for a given nodepool can no longer be disrupted as it would violate the budget
+	// 4. deleting the candidates doesn't take their nodepool below its minNodes
+	postValidationMinNodes := newMinNodesAllowance(c.cluster, candidatesToDelete)
 	for _, n := range candidatesToDelete {
-		if len(n.reschedulablePods) != 0 || c.cluster.IsNodeNominated(n.ProviderID()) || postValidationMapping[n.nodePool.Name] == 0 {
+		if len(n.reschedulablePods) != 0 || c.cluster.IsNodeNominated(n.ProviderID()) || postValidationMapping[n.nodePool.Name] == 0 || !postValidationMinNodes.take(n) {
 			logging.FromContext(ctx).Debugf("abandoning empty node consolidation attempt due to pod churn, command is no longer valid, %s", cmd)
 			return Command{}, scheduling.Results{}, nil
 		}
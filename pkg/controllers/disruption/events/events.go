@@ -23,6 +23,7 @@ import (
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/flowcontrol"
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/events"
@@ -132,3 +133,21 @@ func NodePoolBlocked(nodePool *v1beta1.NodePool) events.Event {
 		DedupeTimeout: 1 * time.Minute,
 	}
 }
+
+// InvalidFeatureGatesRateLimiter is a pointer so it rate-limits across events, since the feature gates of a NodePool
+// are resolved every time one of its candidates is evaluated
+var InvalidFeatureGatesRateLimiter = flowcontrol.NewTokenBucketRateLimiter(0.1, 5)
+
+// InvalidFeatureGates is an event that informs the user that the feature gates annotation of a NodePool can't be
+// parsed, so the global feature gates are used for it
+func InvalidFeatureGates(nodePool *v1beta1.NodePool, err error) events.Event {
+	return events.Event{
+		InvolvedObject: nodePool,
+		Type:           v1.EventTypeWarning,
+		Reason:         "InvalidFeatureGates",
+		Message:        fmt.Sprintf("Ignoring the %s annotation, %s", v1beta1.FeatureGatesAnnotationKey, err),
+		DedupeValues:   []string{string(nodePool.UID)},
+		DedupeTimeout:  time.Minute * 15,
+		RateLimiter:    InvalidFeatureGatesRateLimiter,
+	}
+}
@@ -18,8 +18,6 @@ package disruption
 
 import (
 	"context"
-	"errors"
-	"sort"
 
 	"k8s.io/utils/clock"
 
@@ -27,7 +25,6 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
-	disruptionevents "sigs.k8s.io/karpenter/pkg/controllers/disruption/events"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
@@ -57,72 +54,28 @@ func NewExpiration(clk clock.Clock, kubeClient client.Client, cluster *state.Clu
 
 // ShouldDisrupt is a predicate used to filter candidates
 func (e *Expiration) ShouldDisrupt(_ context.Context, c *Candidate) bool {
-	return c.nodePool.Spec.Disruption.ExpireAfter.Duration != nil &&
+	_, paused := c.nodePool.Annotations[v1beta1.ExpirationPausedAnnotationKey]
+	return c.nodePool.Spec.Disruption.ExpireAfter.Duration != nil && !paused &&
 		c.NodeClaim.StatusConditions().GetCondition(v1beta1.Expired).IsTrue()
 }
 
 // ComputeCommand generates a disruption command given candidates
 func (e *Expiration) ComputeCommand(ctx context.Context, disruptionBudgetMapping map[string]int, candidates ...*Candidate) (Command, scheduling.Results, error) {
-	sort.Slice(candidates, func(i int, j int) bool {
-		return candidates[i].NodeClaim.StatusConditions().GetCondition(v1beta1.Expired).LastTransitionTime.Inner.Time.Before(
-			candidates[j].NodeClaim.StatusConditions().GetCondition(v1beta1.Expired).LastTransitionTime.Inner.Time)
-	})
-	EligibleNodesGauge.With(map[string]string{
-		methodLabel:            e.Type(),
-		consolidationTypeLabel: e.ConsolidationType(),
-	}).Set(float64(len(candidates)))
-
-	// Do a quick check through the candidates to see if they're empty.
-	// For each candidate that is empty with a nodePool allowing its disruption
-	// add it to the existing command.
-	empty := make([]*Candidate, 0, len(candidates))
-	for _, candidate := range candidates {
-		if len(candidate.reschedulablePods) > 0 {
-			continue
-		}
-		// If there's disruptions allowed for the candidate's nodepool,
-		// add it to the list of candidates, and decrement the budget.
-		if disruptionBudgetMapping[candidate.nodePool.Name] > 0 {
-			empty = append(empty, candidate)
-			disruptionBudgetMapping[candidate.nodePool.Name]--
-		}
-	}
-	// Disrupt all empty expired candidates, as they require no scheduling simulations.
-	// Return empty scheduling results since no empty nodes should be rescheduling any pods.
-	if len(empty) > 0 {
-		return Command{
-			candidates: empty,
-		}, scheduling.Results{}, nil
+	cmd, results, err := computeConditionCommand(ctx, e, v1beta1.Expired, e.cluster, e.recorder, disruptionBudgetMapping, candidates, e.simulate)
+	if err == nil && len(cmd.candidates) > 0 {
+		logging.FromContext(ctx).With("ttl", cmd.candidates[0].nodePool.Spec.Disruption.ExpireAfter.String()).Infof("triggering termination for expired node after TTL")
 	}
+	return cmd, results, err
+}
 
-	for _, candidate := range candidates {
-		// If the disruption budget doesn't allow this candidate to be disrupted,
-		// continue to the next candidate. We don't need to decrement any budget
-		// counter since expiration commands can only have one candidate.
-		if disruptionBudgetMapping[candidate.nodePool.Name] == 0 {
-			continue
-		}
-		// Check if we need to create any NodeClaims.
-		results, err := SimulateScheduling(ctx, e.kubeClient, e.cluster, e.provisioner, candidate)
-		if err != nil {
-			// if a candidate node is now deleting, just retry
-			if errors.Is(err, errCandidateDeleting) {
-				continue
-			}
-			return Command{}, scheduling.Results{}, err
-		}
-		// Emit an event that we couldn't reschedule the pods on the node.
-		if !results.AllNonPendingPodsScheduled() {
-			e.recorder.Publish(disruptionevents.Blocked(candidate.Node, candidate.NodeClaim, "Scheduling simulation failed to schedule all pods")...)
-			continue
-		}
-		logging.FromContext(ctx).With("ttl", candidates[0].nodePool.Spec.Disruption.ExpireAfter.String()).Infof("triggering termination for expired node after TTL")
-		return Command{
-			candidates:   []*Candidate{candidate},
-			replacements: results.NewNodeClaims,
-		}, results, nil
+// simulate checks if we need to create any NodeClaims for the candidate's pods. NodePools with the Replace expiration
+// strategy always launch replacements for the candidate's pods, and the candidate isn't drained until the replacements
+// are initialized.
+func (e *Expiration) simulate(ctx context.Context, candidate *Candidate) (scheduling.Results, error) {
+	if candidate.nodePool.Spec.Disruption.ExpirationStrategy == v1beta1.ExpirationStrategyReplace {
+		return SimulateReplacement(ctx, e.kubeClient, e.cluster, e.provisioner, candidate)
 	}
-	return Command{}, scheduling.Results{}, nil
+	return SimulateScheduling(ctx, e.kubeClient, e.cluster, e.provisioner, candidate)
 }
 
 func (e *Expiration) Type() string {
@@ -18,8 +18,10 @@ package disruption
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
 
 	"github.com/samber/lo"
@@ -28,8 +30,10 @@ import (
 	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
 
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/utils/clock"
+	"knative.dev/pkg/apis"
 	"knative.dev/pkg/logging"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -42,12 +46,34 @@ import (
 	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/metrics"
 	operatorlogging "sigs.k8s.io/karpenter/pkg/operator/logging"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
+	"sigs.k8s.io/karpenter/pkg/utils/functional"
+	podutil "sigs.k8s.io/karpenter/pkg/utils/pod"
 )
 
-//nolint:gocyclo
 func SimulateScheduling(ctx context.Context, kubeClient client.Client, cluster *state.Cluster, provisioner *provisioning.Provisioner,
 	candidates ...*Candidate,
+) (pscheduling.Results, error) {
+	return simulateScheduling(ctx, kubeClient, cluster, provisioner, nil, candidates...)
+}
+
+// SimulateConsolidation simulates scheduling like SimulateScheduling, except that the pods are moved to the nodes that
+// violate the fewest of their preferred pod anti-affinity terms if the KeepPreferredAntiAffinity feature gate is
+// enabled. This keeps replicas that prefer to be spread for fault tolerance apart whenever the remaining nodes allow
+// it, without keeping consolidation from packing them onto fewer nodes when they don't.
+func SimulateConsolidation(ctx context.Context, kubeClient client.Client, cluster *state.Cluster, provisioner *provisioning.Provisioner,
+	candidates ...*Candidate,
+) (pscheduling.Results, error) {
+	if !options.FromContext(ctx).FeatureGates.KeepPreferredAntiAffinity {
+		return SimulateScheduling(ctx, kubeClient, cluster, provisioner, candidates...)
+	}
+	return simulateScheduling(ctx, kubeClient, cluster, provisioner, []functional.Option[pscheduling.SchedulerOptions]{pscheduling.PreferSatisfiedPodAntiAffinities}, candidates...)
+}
+
+//nolint:gocyclo
+func simulateScheduling(ctx context.Context, kubeClient client.Client, cluster *state.Cluster, provisioner *provisioning.Provisioner,
+	opts []functional.Option[pscheduling.SchedulerOptions], candidates ...*Candidate,
 ) (pscheduling.Results, error) {
 	candidateNames := sets.NewString(lo.Map(candidates, func(t *Candidate, i int) string { return t.Name() })...)
 	nodes := cluster.Nodes()
@@ -75,11 +101,22 @@ func SimulateScheduling(ctx context.Context, kubeClient client.Client, cluster *
 	if err != nil {
 		return pscheduling.Results{}, fmt.Errorf("determining pending pods, %w", err)
 	}
+	// Pods that are orphaned on a node that doesn't exist are provisioned for, but they can never be scheduled and are
+	// garbage collected. We leave them out of the simulation so that they don't look like pods that we failed to
+	// reschedule and block every disruption.
+	pods = lo.Filter(pods, func(p *v1.Pod, _ int) bool { return podutil.IsProvisionable(p) })
 	for _, n := range candidates {
 		pods = append(pods, n.reschedulablePods...)
 	}
 	pods = append(pods, deletingNodePods...)
-	scheduler, err := provisioner.NewScheduler(logging.WithLogger(ctx, operatorlogging.NopLogger), pods, stateNodes)
+	// The scheduler injects the volume topology requirements of the pods we are simulating, so pods with zonal
+	// volumes can only be moved to existing or replacement nodes in a zone their volumes can attach to. Displaced pods
+	// are moved to the nodes that satisfy the most of their preferred pod affinities, so that consolidation keeps
+	// them co-located with the pods they prefer even when their preferences have to be relaxed.
+	// NodePools that would drop below their minNodes without the candidates get replacements for them, even if the
+	// candidates' pods fit elsewhere.
+	scheduler, err := provisioner.NewScheduler(logging.WithLogger(ctx, operatorlogging.NopLogger), pods, stateNodes,
+		append([]functional.Option[pscheduling.SchedulerOptions]{pscheduling.PreferSatisfiedPodAffinities, pscheduling.MaintainMinNodes}, opts...)...)
 	if err != nil {
 		return pscheduling.Results{}, fmt.Errorf("creating scheduler, %w", err)
 	}
@@ -113,6 +150,119 @@ func SimulateScheduling(ctx context.Context, kubeClient client.Client, cluster *
 	return results, nil
 }
 
+// SimulateReplacement simulates scheduling the candidate's pods onto new capacity only, so that replacements are
+// launched for the candidate even if its pods would fit onto existing nodes
+func SimulateReplacement(ctx context.Context, kubeClient client.Client, cluster *state.Cluster, provisioner *provisioning.Provisioner,
+	candidate *Candidate,
+) (pscheduling.Results, error) {
+	if _, ok := lo.Find(cluster.Nodes().Deleting(), func(n *state.StateNode) bool {
+		return n.Name() == candidate.Name()
+	}); ok {
+		return pscheduling.Results{}, errCandidateDeleting
+	}
+	scheduler, err := provisioner.NewScheduler(logging.WithLogger(ctx, operatorlogging.NopLogger), candidate.reschedulablePods, nil, pscheduling.PreferSatisfiedPodAffinities)
+	if err != nil {
+		return pscheduling.Results{}, fmt.Errorf("creating scheduler, %w", err)
+	}
+	return scheduler.Solve(logging.WithLogger(ctx, operatorlogging.NopLogger), candidate.reschedulablePods).TruncateInstanceTypes(pscheduling.MaxInstanceTypes), nil
+}
+
+// computeConditionCommand generates a command for a method that disrupts the candidates marked with a status condition,
+// in the order that they were marked. Empty candidates are disrupted together since they require no scheduling
+// simulations, while the others are disrupted one at a time once simulate shows that their pods can be rescheduled.
+func computeConditionCommand(ctx context.Context, m Method, conditionType apis.ConditionType, cluster *state.Cluster, recorder events.Recorder,
+	disruptionBudgetMapping map[string]int, candidates []*Candidate, simulate func(context.Context, *Candidate) (pscheduling.Results, error),
+) (Command, pscheduling.Results, error) {
+	sort.Slice(candidates, func(i int, j int) bool {
+		return candidates[i].NodeClaim.StatusConditions().GetCondition(conditionType).LastTransitionTime.Inner.Time.Before(
+			candidates[j].NodeClaim.StatusConditions().GetCondition(conditionType).LastTransitionTime.Inner.Time)
+	})
+	EligibleNodesGauge.With(map[string]string{
+		methodLabel:            m.Type(),
+		consolidationTypeLabel: m.ConsolidationType(),
+	}).Set(float64(len(candidates)))
+
+	// Do a quick check through the candidates to see if they're empty.
+	// For each candidate that is empty with a nodePool allowing its disruption
+	// add it to the existing command.
+	// Empty candidates that would take their nodepool below its minNodes are left to be replaced below.
+	empty := make([]*Candidate, 0, len(candidates))
+	minNodes := newMinNodesAllowance(cluster, candidates)
+	for _, candidate := range candidates {
+		if len(candidate.reschedulablePods) > 0 {
+			continue
+		}
+		// If there's disruptions allowed for the candidate's nodepool,
+		// add it to the list of candidates, and decrement the budget.
+		if disruptionBudgetMapping[candidate.nodePool.Name] > 0 && minNodes.take(candidate) {
+			empty = append(empty, candidate)
+			disruptionBudgetMapping[candidate.nodePool.Name]--
+		}
+	}
+	// Disrupt all empty candidates, as they require no scheduling simulations.
+	// Return empty scheduling results since no empty nodes should be rescheduling any pods.
+	if len(empty) > 0 {
+		return Command{
+			candidates: empty,
+		}, pscheduling.Results{}, nil
+	}
+
+	for _, candidate := range candidates {
+		// If the disruption budget doesn't allow this candidate to be disrupted, continue to the next candidate. The
+		// budget isn't decremented since the command only ever has this one candidate.
+		if disruptionBudgetMapping[candidate.nodePool.Name] == 0 {
+			continue
+		}
+		results, err := simulate(ctx, candidate)
+		if err != nil {
+			// if a candidate is now deleting, just retry
+			if errors.Is(err, errCandidateDeleting) {
+				continue
+			}
+			return Command{}, pscheduling.Results{}, err
+		}
+		// Emit an event that we couldn't reschedule the pods on the node.
+		if !results.AllNonPendingPodsScheduled() {
+			recorder.Publish(disruptionevents.Blocked(candidate.Node, candidate.NodeClaim, "Scheduling simulation failed to schedule all pods")...)
+			continue
+		}
+		return Command{
+			candidates:   []*Candidate{candidate},
+			replacements: results.NewNodeClaims,
+		}, results, nil
+	}
+	return Command{}, pscheduling.Results{}, nil
+}
+
+// minNodesAllowance is the number of nodes of each NodePool with minNodes that can be deleted without being replaced
+// before the NodePool drops below its minNodes. NodePools without minNodes aren't tracked.
+type minNodesAllowance map[string]int
+
+func newMinNodesAllowance(cluster *state.Cluster, candidates []*Candidate) minNodesAllowance {
+	counts := cluster.Nodes().Active().CountByNodePool()
+	allowance := minNodesAllowance{}
+	for _, c := range candidates {
+		if c.nodePool.Spec.MinNodes > 0 {
+			allowance[c.nodePool.Name] = lo.Max([]int{counts[c.nodePool.Name] - int(c.nodePool.Spec.MinNodes), 0})
+		}
+	}
+	return allowance
+}
+
+// take returns true if the candidate can be deleted without being replaced, counting it against its NodePool's
+// allowance
+func (m minNodesAllowance) take(c *Candidate) bool {
+	allowance, ok := m[c.nodePool.Name]
+	if !ok {
+		return true
+	}
+	if allowance == 0 {
+		return false
+	}
+	m[c.nodePool.Name]--
+	return true
+}
+
 // instanceTypesAreSubset returns true if the lhs slice of instance types are a subset of the rhs.
 func instanceTypesAreSubset(lhs []*cloudprovider.InstanceType, rhs []*cloudprovider.InstanceType) bool {
 	rhsNames := sets.NewString(lo.Map(rhs, func(t *cloudprovider.InstanceType, i int) string { return t.Name })...)
@@ -196,22 +346,56 @@ func GetCandidates(ctx context.Context, cluster *state.Cluster, kubeClient clien
 	return lo.Filter(candidates, func(c *Candidate, _ int) bool { return shouldDeprovision(ctx, c) }), nil
 }
 
+// GetRebalanceCandidates returns nodes that appear to be currently deprovisionable based off of their nodePool, including
+// nodes with pods that can't be evicted. These nodes can't be terminated, but their other pods can be rebalanced.
+func GetRebalanceCandidates(ctx context.Context, cluster *state.Cluster, kubeClient client.Client, recorder events.Recorder, clk clock.Clock,
+	cloudProvider cloudprovider.CloudProvider, shouldDeprovision CandidateFilter, queue *orchestration.Queue,
+) ([]*Candidate, error) {
+	nodePoolMap, nodePoolToInstanceTypesMap, err := BuildNodePoolMap(ctx, kubeClient, cloudProvider)
+	if err != nil {
+		return nil, err
+	}
+	candidates := lo.FilterMap(cluster.Nodes(), func(n *state.StateNode, _ int) (*Candidate, bool) {
+		cn, e := newCandidate(ctx, kubeClient, recorder, clk, n, nil, nodePoolMap, nodePoolToInstanceTypesMap, queue, true)
+		return cn, e == nil
+	})
+	return lo.Filter(candidates, func(c *Candidate, _ int) bool { return shouldDeprovision(ctx, c) }), nil
+}
+
 // BuildDisruptionBudgets will return a map for nodePoolName -> numAllowedDisruptions and an error
 func BuildDisruptionBudgets(ctx context.Context, cluster *state.Cluster, clk clock.Clock, kubeClient client.Client, recorder events.Recorder) (map[string]int, error) {
 	nodePoolList := &v1beta1.NodePoolList{}
 	if err := kubeClient.List(ctx, nodePoolList); err != nil {
 		return nil, fmt.Errorf("listing node pools, %w", err)
 	}
+	nodePools := lo.ToSlicePtr(nodePoolList.Items)
+	// NotReady nodes are subtracted from the allowed disruptions along with the deleting nodes
+	disruptionBudgetMapping := allowedDisruptions(ctx, cluster, clk, nodePools, func(node *state.StateNode) bool {
+		return nodeutils.GetCondition(node.Node, v1.NodeReady).Status != v1.ConditionTrue || node.MarkedForDeletion()
+	})
+	for _, nodePool := range nodePools {
+		// If the nodepool is fully blocked, emit an event
+		if disruptionBudgetMapping[nodePool.Name] == 0 {
+			recorder.Publish(disruptionevents.NodePoolBlocked(nodePool))
+		}
+		BudgetsAllowedDisruptionsGauge.With(map[string]string{
+			metrics.NodePoolLabel: nodePool.Name,
+		}).Set(float64(disruptionBudgetMapping[nodePool.Name]))
+	}
+	return disruptionBudgetMapping, nil
+}
+
+// allowedDisruptions returns the number of nodes that can be disrupted for each NodePool: the disruptions allowed by its
+// budgets, less its nodes that are already unavailable.
+func allowedDisruptions(ctx context.Context, cluster *state.Cluster, clk clock.Clock, nodePools []*v1beta1.NodePool, unavailable func(*state.StateNode) bool) map[string]int {
 	numNodes := map[string]int{}
-	deleting := map[string]int{}
-	disruptionBudgetMapping := map[string]int{}
+	numUnavailable := map[string]int{}
 	// We need to get all the nodes in the cluster
 	// Get each current active number of nodes per nodePool
 	// Get the max disruptions for each nodePool
-	// Get the number of deleting nodes for each of those nodePools
+	// Get the number of unavailable nodes for each of those nodePools
 	// Find the difference to know how much left we can disrupt
-	nodes := cluster.Nodes()
-	for _, node := range nodes {
+	for _, node := range cluster.Nodes() {
 		// We only consider nodes that we own and are initialized towards the total.
 		// If a node is launched/registered, but not initialized, pods aren't scheduled
 		// to the node, and these are treated as unhealthy until they're cleaned up.
@@ -223,33 +407,41 @@ func BuildDisruptionBudgets(ctx context.Context, cluster *state.Cluster, clk clo
 			continue
 		}
 		nodePool := node.Labels()[v1beta1.NodePoolLabelKey]
-		// If the node satisfies one of the following, we subtract it from the allowed disruptions.
-		// 1. Has a NotReady conditiion
-		// 2. Is marked as deleting
-		if cond := nodeutils.GetCondition(node.Node, v1.NodeReady); cond.Status != v1.ConditionTrue || node.MarkedForDeletion() {
-			deleting[nodePool]++
+		if unavailable(node) {
+			numUnavailable[nodePool]++
 		}
 		numNodes[nodePool]++
 	}
-
-	for i := range nodePoolList.Items {
-		nodePool := nodePoolList.Items[i]
+	disruptionBudgetMapping := map[string]int{}
+	for _, nodePool := range nodePools {
 		disruptions := nodePool.MustGetAllowedDisruptions(ctx, clk, numNodes[nodePool.Name])
-		// Subtract the allowed number of disruptions from the number of already deleting nodes.
-		// Floor the value since the number of deleting nodes can exceed the number of allowed disruptions.
+		// Subtract the allowed number of disruptions from the number of already unavailable nodes.
+		// Floor the value since the number of unavailable nodes can exceed the number of allowed disruptions.
 		// Allowing this value to be negative breaks assumptions in the code used to calculate how
 		// many nodes can be disrupted.
-		allowedDisruptions := lo.Clamp(disruptions-deleting[nodePool.Name], 0, math.MaxInt32)
-		disruptionBudgetMapping[nodePool.Name] = allowedDisruptions
-		// If the nodepool is fully blocked, emit an event
-		if allowedDisruptions == 0 {
-			recorder.Publish(disruptionevents.NodePoolBlocked(lo.ToPtr(nodePool)))
-		}
-		BudgetsAllowedDisruptionsGauge.With(map[string]string{
-			metrics.NodePoolLabel: nodePool.Name,
-		}).Set(float64(allowedDisruptions))
+		disruptionBudgetMapping[nodePool.Name] = lo.Clamp(disruptions-numUnavailable[nodePool.Name], 0, math.MaxInt32)
 	}
-	return disruptionBudgetMapping, nil
+	return disruptionBudgetMapping
+}
+
+// ConsolidationWindowActive returns whether consolidation is currently allowed by the global consolidation schedule.
+// If no schedule is configured, consolidation is always allowed.
+func ConsolidationWindowActive(ctx context.Context, clk clock.Clock) (bool, error) {
+	opts := options.FromContext(ctx)
+	if opts.ConsolidationSchedule == "" {
+		return true, nil
+	}
+	// The global window shares the semantics of a NodePool disruption budget schedule
+	window := &v1beta1.Budget{
+		Schedule: lo.ToPtr(opts.ConsolidationSchedule),
+		Duration: &metav1.Duration{Duration: opts.ConsolidationScheduleDuration},
+	}
+	return window.IsActive(clk)
+}
+
+// IsConsolidation returns whether the method is a form of consolidation, as opposed to drift or expiration
+func IsConsolidation(m Method) bool {
+	return m.Type() == metrics.ConsolidationReason || m.Type() == metrics.EmptinessReason
 }
 
 // BuildNodePoolMap builds a provName -> nodePool map and a provName -> instanceName -> instance type map
@@ -327,3 +519,13 @@ func clamp(min, val, max float64) float64 {
 	}
 	return val
 }
+
+// nodePoolFeatureGates returns the feature gates for the NodePool. A malformed feature gates annotation is reported
+// with an event on the NodePool and the global feature gates are used.
+func nodePoolFeatureGates(ctx context.Context, recorder events.Recorder, nodePool *v1beta1.NodePool) options.FeatureGates {
+	gates, err := options.NodePoolFeatureGates(ctx, nodePool)
+	if err != nil {
+		recorder.Publish(disruptionevents.InvalidFeatureGates(nodePool, err))
+	}
+	return gates
+}
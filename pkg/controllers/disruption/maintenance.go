@@ -0,0 +1,71 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruption
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+// Maintenance is a subreconciler that replaces candidates with maintenance scheduled by the cloud provider, so that
+// their pods are moved before the cloud provider stops the instances.
+type Maintenance struct {
+	kubeClient  client.Client
+	cluster     *state.Cluster
+	provisioner *provisioning.Provisioner
+	recorder    events.Recorder
+}
+
+func NewMaintenance(kubeClient client.Client, cluster *state.Cluster, provisioner *provisioning.Provisioner, recorder events.Recorder) *Maintenance {
+	return &Maintenance{
+		kubeClient:  kubeClient,
+		cluster:     cluster,
+		provisioner: provisioner,
+		recorder:    recorder,
+	}
+}
+
+// ShouldDisrupt is a predicate used to filter candidates
+func (m *Maintenance) ShouldDisrupt(_ context.Context, c *Candidate) bool {
+	return c.NodeClaim.StatusConditions().GetCondition(v1beta1.MaintenanceScheduled).IsTrue()
+}
+
+// ComputeCommand generates a disruption command given candidates. Replacements are always launched for the candidate's
+// pods, so that they have somewhere to go before the maintenance begins. The candidate isn't drained until the
+// replacements are initialized.
+func (m *Maintenance) ComputeCommand(ctx context.Context, disruptionBudgetMapping map[string]int, candidates ...*Candidate) (Command, scheduling.Results, error) {
+	return computeConditionCommand(ctx, m, v1beta1.MaintenanceScheduled, m.cluster, m.recorder, disruptionBudgetMapping, candidates,
+		func(ctx context.Context, candidate *Candidate) (scheduling.Results, error) {
+			return SimulateReplacement(ctx, m.kubeClient, m.cluster, m.provisioner, candidate)
+		})
+}
+
+func (m *Maintenance) Type() string {
+	return metrics.MaintenanceReason
+}
+
+func (m *Maintenance) ConsolidationType() string {
+	return ""
+}
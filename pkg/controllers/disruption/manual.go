@@ -0,0 +1,92 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruption
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/controllers/disruption/candidates"
+)
+
+// reportPending computes the commands that would disrupt the candidates that haven't been approved yet, and adds them
+// to the commands that are reported at the end of the disruption loop. The commands aren't executed, so they're
+// validated without waiting for the consolidation validation delay.
+func (c *Controller) reportPending(ctx context.Context, m Method, disruptionBudgetMapping map[string]int, candidates []*Candidate) error {
+	if len(candidates) == 0 {
+		return nil
+	}
+	// Computing the commands may consume the budgets, which have to be left for the approved candidates
+	cmds, _, err := computeCommands(withoutValidationDelay(ctx), m, lo.Assign(disruptionBudgetMapping), candidates...)
+	if err != nil {
+		return fmt.Errorf("computing pending disruption decision, %w", err)
+	}
+	for _, cmd := range cmds {
+		c.pending = append(c.pending, newPendingCommand(m, cmd))
+	}
+	return nil
+}
+
+// clearApproval removes the approval of the candidates of a command once it has been executed, so that an approval
+// is only ever used for a single disruption decision
+func (c *Controller) clearApproval(ctx context.Context, cmd Command) {
+	for _, candidate := range cmd.candidates {
+		stored := candidate.Node.DeepCopy()
+		node := candidate.Node.DeepCopy()
+		delete(node.Annotations, v1beta1.DisruptionApprovedAnnotationKey)
+		if err := c.kubeClient.Patch(ctx, node, client.MergeFrom(stored)); client.IgnoreNotFound(err) != nil {
+			logging.FromContext(ctx).With("node", node.Name).Errorf("clearing disruption approval, %s", err)
+		}
+	}
+}
+
+// approved returns true if the candidate's node has been annotated to approve its disruption
+func approved(c *Candidate) bool {
+	return c.Annotations()[v1beta1.DisruptionApprovedAnnotationKey] == "true"
+}
+
+func newPendingCommand(m Method, cmd Command) candidates.Command {
+	pending := candidates.Command{
+		Method:            m.Type(),
+		ConsolidationType: m.ConsolidationType(),
+		Action:            string(cmd.Action()),
+		Replacements:      len(cmd.replacements),
+		Candidates: lo.Map(cmd.candidates, func(c *Candidate, _ int) candidates.Candidate {
+			return candidates.Candidate{
+				NodeName:          c.Node.Name,
+				NodeClaimName:     c.NodeClaim.Name,
+				NodePool:          c.nodePool.Name,
+				InstanceType:      c.Labels()[v1.LabelInstanceTypeStable],
+				CapacityType:      c.capacityType,
+				Zone:              c.zone,
+				ReschedulablePods: len(c.reschedulablePods),
+				DisruptionCost:    c.disruptionCost,
+				Approved:          approved(c),
+			}
+		}),
+	}
+	if m.ConsolidationType() != "" {
+		pending.EstimatedSavings = lo.Sum(lo.Values(consolidationSavings(cmd)))
+	}
+	return pending
+}
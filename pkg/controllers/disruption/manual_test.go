@@ -0,0 +1,213 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruption_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/controllers/disruption/candidates"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("Manual Disruption", func() {
+	var nodePool *v1beta1.NodePool
+	var nodeClaim *v1beta1.NodeClaim
+	var node *v1.Node
+
+	BeforeEach(func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			ManualDisruption: lo.ToPtr(true),
+			FeatureGates:     test.FeatureGates{Drift: lo.ToPtr(true)},
+		}))
+		nodePool = test.NodePool(v1beta1.NodePool{
+			Spec: v1beta1.NodePoolSpec{
+				Disruption: v1beta1.Disruption{
+					// Only drift disrupts the node unless consolidation is enabled by the test
+					ConsolidateAfter: &v1beta1.NillableDuration{Duration: nil},
+					ExpireAfter:      v1beta1.NillableDuration{Duration: nil},
+					Budgets:          []v1beta1.Budget{{Nodes: "100%"}},
+				},
+			},
+		})
+		nodeClaim, node = test.NodeClaimAndNode(v1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1beta1.NodePoolLabelKey:     nodePool.Name,
+					v1.LabelInstanceTypeStable:   mostExpensiveInstance.Name,
+					v1beta1.CapacityTypeLabelKey: mostExpensiveOffering.CapacityType,
+					v1.LabelTopologyZone:         mostExpensiveOffering.Zone,
+				},
+			},
+			Status: v1beta1.NodeClaimStatus{
+				ProviderID: test.RandomProviderID(),
+				Allocatable: map[v1.ResourceName]resource.Quantity{
+					v1.ResourceCPU:  resource.MustParse("32"),
+					v1.ResourcePods: resource.MustParse("100"),
+				},
+			},
+		})
+	})
+	AfterEach(func() {
+		candidates.Pending.Reset()
+	})
+	reconcile := func() {
+		GinkgoHelper()
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{node}, []*v1beta1.NodeClaim{nodeClaim})
+		fakeClock.Step(10 * time.Minute)
+
+		var wg sync.WaitGroup
+		ExpectTriggerVerifyAction(&wg)
+		ExpectReconcileSucceeded(ctx, disruptionController, types.NamespacedName{})
+		wg.Wait()
+		ExpectReconcileSucceeded(ctx, queue, types.NamespacedName{})
+	}
+	It("should report drifted nodes without disrupting them until they are approved", func() {
+		nodeClaim.StatusConditions().MarkTrue(v1beta1.Drifted)
+		ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
+		reconcile()
+
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+		ExpectTaintedNodeCount(ctx, env.Client, 0)
+
+		report := candidates.Pending.Get()
+		Expect(report).ToNot(BeNil())
+		Expect(report.Commands).To(HaveLen(1))
+		Expect(report.Commands[0].Method).To(Equal("drift"))
+		Expect(report.Commands[0].Action).To(Equal("delete"))
+		Expect(report.Commands[0].Candidates).To(ConsistOf(candidates.Candidate{
+			NodeName:      node.Name,
+			NodeClaimName: nodeClaim.Name,
+			NodePool:      nodePool.Name,
+			InstanceType:  mostExpensiveInstance.Name,
+			CapacityType:  mostExpensiveOffering.CapacityType,
+			Zone:          mostExpensiveOffering.Zone,
+			Approved:      false,
+		}))
+	})
+	It("should disrupt nodes once they are approved", func() {
+		nodeClaim.StatusConditions().MarkTrue(v1beta1.Drifted)
+		node.Annotations = lo.Assign(node.Annotations, map[string]string{v1beta1.DisruptionApprovedAnnotationKey: "true"})
+		ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
+		reconcile()
+
+		ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim)
+		ExpectNotFound(ctx, env.Client, nodeClaim, node)
+		Expect(candidates.Pending.Get().Commands).To(BeEmpty())
+	})
+	It("should clear the approval once the node is disrupted", func() {
+		nodeClaim.StatusConditions().MarkTrue(v1beta1.Drifted)
+		node.Annotations = lo.Assign(node.Annotations, map[string]string{v1beta1.DisruptionApprovedAnnotationKey: "true"})
+		ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{node}, []*v1beta1.NodeClaim{nodeClaim})
+		fakeClock.Step(10 * time.Minute)
+
+		var wg sync.WaitGroup
+		ExpectTriggerVerifyAction(&wg)
+		ExpectReconcileSucceeded(ctx, disruptionController, types.NamespacedName{})
+		wg.Wait()
+
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Annotations).ToNot(HaveKey(v1beta1.DisruptionApprovedAnnotationKey))
+		ExpectTaintedNodeCount(ctx, env.Client, 1)
+	})
+	It("should disrupt approved nodes when other nodes haven't been approved", func() {
+		unapprovedNodeClaim, unapprovedNode := test.NodeClaimAndNode(v1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{Labels: nodeClaim.Labels},
+			Status: v1beta1.NodeClaimStatus{
+				ProviderID:  test.RandomProviderID(),
+				Allocatable: nodeClaim.Status.Allocatable,
+			},
+		})
+		nodeClaim.StatusConditions().MarkTrue(v1beta1.Drifted)
+		unapprovedNodeClaim.StatusConditions().MarkTrue(v1beta1.Drifted)
+		node.Annotations = lo.Assign(node.Annotations, map[string]string{v1beta1.DisruptionApprovedAnnotationKey: "true"})
+		ExpectApplied(ctx, env.Client, nodeClaim, node, unapprovedNodeClaim, unapprovedNode, nodePool)
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{node, unapprovedNode}, []*v1beta1.NodeClaim{nodeClaim, unapprovedNodeClaim})
+		fakeClock.Step(10 * time.Minute)
+
+		var wg sync.WaitGroup
+		ExpectTriggerVerifyAction(&wg)
+		ExpectReconcileSucceeded(ctx, disruptionController, types.NamespacedName{})
+		wg.Wait()
+
+		Expect(ExpectExists(ctx, env.Client, node).Spec.Taints).To(ContainElement(v1beta1.DisruptionNoScheduleTaint))
+		Expect(ExpectExists(ctx, env.Client, unapprovedNode).Spec.Taints).ToNot(ContainElement(v1beta1.DisruptionNoScheduleTaint))
+		report := candidates.Pending.Get()
+		Expect(report.Commands).To(HaveLen(1))
+		Expect(report.Commands[0].Candidates).To(HaveLen(1))
+		Expect(report.Commands[0].Candidates[0].NodeName).To(Equal(unapprovedNode.Name))
+	})
+	It("should not disrupt nodes whose approval annotation isn't true", func() {
+		nodeClaim.StatusConditions().MarkTrue(v1beta1.Drifted)
+		node.Annotations = lo.Assign(node.Annotations, map[string]string{v1beta1.DisruptionApprovedAnnotationKey: "false"})
+		ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
+		reconcile()
+
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+		Expect(candidates.Pending.Get().Commands).To(HaveLen(1))
+	})
+	It("should report the estimated savings of consolidation", func() {
+		nodePool.Spec.Disruption.ConsolidateAfter = nil
+		ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
+		reconcile()
+
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+		report := candidates.Pending.Get()
+		Expect(report.Commands).To(HaveLen(1))
+		Expect(report.Commands[0].Method).To(Equal("consolidation"))
+		Expect(report.Commands[0].ConsolidationType).To(Equal("empty"))
+		Expect(report.Commands[0].EstimatedSavings).To(BeNumerically("~", mostExpensiveOffering.Price, 0.0001))
+	})
+	It("should serve the pending commands as JSON", func() {
+		nodeClaim.StatusConditions().MarkTrue(v1beta1.Drifted)
+		ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
+		reconcile()
+
+		recorder := httptest.NewRecorder()
+		candidates.Pending.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, candidates.Path, nil))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		report := &candidates.Report{}
+		Expect(json.Unmarshal(recorder.Body.Bytes(), report)).To(Succeed())
+		Expect(report.Commands).To(HaveLen(1))
+		Expect(report.Commands[0].Candidates[0].NodeName).To(Equal(node.Name))
+	})
+	It("should not report commands when disruption isn't manual", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{Drift: lo.ToPtr(true)}}))
+		nodeClaim.StatusConditions().MarkTrue(v1beta1.Drifted)
+		ExpectApplied(ctx, env.Client, nodeClaim, node, nodePool)
+		reconcile()
+
+		ExpectNodeClaimsCascadeDeletion(ctx, env.Client, nodeClaim)
+		ExpectNotFound(ctx, env.Client, nodeClaim, node)
+		Expect(candidates.Pending.Get()).To(BeNil())
+	})
+})
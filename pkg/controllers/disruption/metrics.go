@@ -32,6 +32,9 @@ func init() {
 		EligibleNodesGauge,
 		ConsolidationTimeoutTotalCounter,
 		BudgetsAllowedDisruptionsGauge,
+		ConsolidationWindowActiveGauge,
+		ConsolidationSavingsEstimateCounter,
+		NodeUtilizationHistogram,
 	)
 }
 
@@ -107,4 +110,31 @@ var (
 		},
 		[]string{metrics.NodePoolLabel},
 	)
+	ConsolidationWindowActiveGauge = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: disruptionSubsystem,
+			Name:      "consolidation_window_active",
+			Help:      "Whether consolidation is currently within the window allowed by the global consolidation schedule. 1 if consolidation is allowed, 0 otherwise.",
+		},
+	)
+	ConsolidationSavingsEstimateCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: disruptionSubsystem,
+			Name:      "consolidation_savings_estimate",
+			Help:      "Estimated hourly cost saved by executed consolidation commands, i.e. the price of the consolidated nodes' offerings minus the worst-case launch price of their replacements. This is an estimate based on the list or effective offering prices that the cloud provider reports, not on billing data. Labeled by the NodePool of the consolidated nodes.",
+		},
+		[]string{metrics.NodePoolLabel},
+	)
+	NodeUtilizationHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metrics.NodeSubsystem,
+			Name:      "utilization_ratio",
+			Help:      "Ratio of the requests of the reschedulable pods on a node to its allocatable, taking the highest of cpu and memory. Sampled once for each consolidation candidate in every disruption loop. Labeled by NodePool.",
+			Buckets:   prometheus.LinearBuckets(0.1, 0.1, 10),
+		},
+		[]string{metrics.NodePoolLabel},
+	)
 )
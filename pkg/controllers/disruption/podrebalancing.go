@@ -0,0 +1,199 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruption
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/logging"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	disruptionevents "sigs.k8s.io/karpenter/pkg/controllers/disruption/events"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	podutil "sigs.k8s.io/karpenter/pkg/utils/pod"
+)
+
+// PodRebalancingCooldown is how long a node that had its pods rebalanced is skipped for, so that pods which the
+// kube-scheduler places back onto the node aren't evicted over and over again. The node is tainted while its pods
+// move, for at most this long.
+const PodRebalancingCooldown = 10 * time.Minute
+
+// rebalance tracks the pods that are evicted from a node that's tainted while they move
+type rebalance struct {
+	pods      []*v1.Pod
+	startedAt time.Time
+}
+
+// PodRebalancing is the consolidation method that evicts pods from nodes without terminating them. It only considers
+// nodes that can't be disrupted because some of their pods can't be evicted, either due to the
+// karpenter.sh/do-not-disrupt annotation or a PDB. The other pods are moved onto the spare capacity of existing nodes,
+// so that the node is left with only the pods that block its disruption and can be consolidated as soon as they are
+// gone. Rebalancing never launches new capacity, and only moves pods when they're cheaper to run where they land.
+type PodRebalancing struct {
+	consolidation
+	lastRebalanced map[string]time.Time
+}
+
+func NewPodRebalancing(consolidation consolidation) *PodRebalancing {
+	return &PodRebalancing{consolidation: consolidation, lastRebalanced: map[string]time.Time{}}
+}
+
+// ShouldDisrupt is a predicate used to filter candidates
+func (p *PodRebalancing) ShouldDisrupt(ctx context.Context, cn *Candidate) bool {
+	return nodePoolFeatureGates(ctx, p.recorder, cn.nodePool).PodRebalancing && p.consolidation.ShouldDisrupt(ctx, cn)
+}
+
+// ComputeCommand generates a disruption command given candidates
+func (p *PodRebalancing) ComputeCommand(ctx context.Context, disruptionBudgetMapping map[string]int, candidates ...*Candidate) (Command, scheduling.Results, error) {
+	if p.IsConsolidated() {
+		return Command{}, scheduling.Results{}, nil
+	}
+	candidates = p.sortCandidates(candidates)
+	EligibleNodesGauge.With(map[string]string{
+		methodLabel:            p.Type(),
+		consolidationTypeLabel: p.ConsolidationType(),
+	}).Set(float64(len(candidates)))
+
+	// Forget the nodes whose cooldown has passed, so that the nodes that are gone aren't tracked forever
+	for name, t := range p.lastRebalanced {
+		if p.clock.Since(t) >= PodRebalancingCooldown {
+			delete(p.lastRebalanced, name)
+		}
+	}
+	pdbs, err := NewPDBLimits(ctx, p.clock, p.kubeClient)
+	if err != nil {
+		return Command{}, scheduling.Results{}, fmt.Errorf("tracking PodDisruptionBudgets, %w", err)
+	}
+	_, instanceTypes, err := BuildNodePoolMap(ctx, p.kubeClient, p.cloudProvider)
+	if err != nil {
+		return Command{}, scheduling.Results{}, fmt.Errorf("building nodepool map, %w", err)
+	}
+	constrainedByBudgets := false
+	for _, candidate := range candidates {
+		// Rebalancing doesn't terminate the candidate, but it still disrupts its pods, so we respect the budgets
+		if disruptionBudgetMapping[candidate.nodePool.Name] == 0 {
+			constrainedByBudgets = true
+			continue
+		}
+		if _, ok := p.lastRebalanced[candidate.Name()]; ok {
+			continue
+		}
+		cmd, results, err := p.computeRebalance(ctx, pdbs, instanceTypes, candidate)
+		if err != nil {
+			logging.FromContext(ctx).Errorf("computing pod rebalancing %s", err)
+			continue
+		}
+		if cmd.Action() == NoOpAction {
+			continue
+		}
+		p.lastRebalanced[candidate.Name()] = p.clock.Now()
+		return cmd, results, nil
+	}
+	if !constrainedByBudgets {
+		p.markConsolidated()
+	}
+	return Command{}, scheduling.Results{}, nil
+}
+
+// computeRebalance computes the pods that can be evicted from a candidate that can't be disrupted as a whole
+func (p *PodRebalancing) computeRebalance(ctx context.Context, pdbs *PDBLimits, instanceTypes map[string]map[string]*cloudprovider.InstanceType,
+	candidate *Candidate) (Command, scheduling.Results, error) {
+	pods, err := candidate.Pods(ctx, p.kubeClient)
+	if err != nil {
+		return Command{}, scheduling.Results{}, fmt.Errorf("getting pods from candidate, %w", err)
+	}
+	// Candidates that can be disrupted as a whole are left to the other consolidation methods
+	if _, ok := pdbs.CanEvictPods(pods); ok && lo.EveryBy(pods, podutil.IsDisruptable) {
+		return Command{}, scheduling.Results{}, nil
+	}
+	evictions := lo.Filter(candidate.reschedulablePods, func(po *v1.Pod, _ int) bool {
+		_, ok := pdbs.CanEvictPods([]*v1.Pod{po})
+		return ok && podutil.IsDisruptable(po) && podutil.IsEvictable(po)
+	})
+	if len(evictions) == 0 {
+		return Command{}, scheduling.Results{}, nil
+	}
+	// Only the evicted pods are rescheduled, the pods that block the candidate's disruption stay where they are
+	rebalanced := *candidate
+	rebalanced.reschedulablePods = evictions
+	results, err := SimulateConsolidation(ctx, p.kubeClient, p.cluster, p.provisioner, &rebalanced)
+	if err != nil {
+		// if the candidate is now deleting, just retry
+		if errors.Is(err, errCandidateDeleting) {
+			return Command{}, scheduling.Results{}, nil
+		}
+		return Command{}, scheduling.Results{}, err
+	}
+	if !results.AllNonPendingPodsScheduled() {
+		p.recorder.Publish(disruptionevents.Unconsolidatable(candidate.Node, candidate.NodeClaim, results.NonPendingPodSchedulingErrors())...)
+		return Command{}, scheduling.Results{}, nil
+	}
+	// Moving the pods is only worth it if they fit onto capacity that we're already paying for
+	if len(results.NewNodeClaims) != 0 {
+		p.recorder.Publish(disruptionevents.Unconsolidatable(candidate.Node, candidate.NodeClaim, fmt.Sprintf("Can't rebalance pods without creating %d nodes", len(results.NewNodeClaims)))...)
+		return Command{}, scheduling.Results{}, nil
+	}
+	if rebalanceSavings(candidate, evictions, results, instanceTypes) <= 0 {
+		p.recorder.Publish(disruptionevents.Unconsolidatable(candidate.Node, candidate.NodeClaim, "Can't rebalance pods onto cheaper capacity")...)
+		return Command{}, scheduling.Results{}, nil
+	}
+	return Command{
+		candidates: []*Candidate{candidate},
+		evictions:  evictions,
+	}, results, nil
+}
+
+// rebalanceSavings estimates the hourly cost saved by moving the evicted pods of the candidate onto the nodes that
+// they're expected to schedule to. A pod's cost on a node is the node's price in proportion to the share of the node's
+// allocatable that the pod requests. The savings are 0 if the price of a node that the pods move to isn't known.
+func rebalanceSavings(candidate *Candidate, evictions []*v1.Pod, results scheduling.Results, instanceTypes map[string]map[string]*cloudprovider.InstanceType) float64 {
+	evicted := sets.New(lo.Map(evictions, func(p *v1.Pod, _ int) types.UID { return p.UID })...)
+	savings := 0.0
+	for _, n := range results.ExistingNodes {
+		pods := lo.Filter(n.Pods, func(p *v1.Pod, _ int) bool { return evicted.Has(p.UID) })
+		if len(pods) == 0 {
+			continue
+		}
+		it, ok := instanceTypes[n.Labels()[v1beta1.NodePoolLabelKey]][n.Labels()[v1.LabelInstanceTypeStable]]
+		if !ok {
+			return 0
+		}
+		offering, ok := it.Offerings.Get(n.Labels()[v1beta1.CapacityTypeLabelKey], n.Labels()[v1.LabelTopologyZone])
+		if !ok {
+			return 0
+		}
+		savings += candidatePrice(candidate)*requestsShare(pods, candidate.Allocatable()) - offering.Price*requestsShare(pods, n.Allocatable())
+	}
+	return savings
+}
+
+func (p *PodRebalancing) Type() string {
+	return metrics.ConsolidationReason
+}
+
+func (p *PodRebalancing) ConsolidationType() string {
+	return "rebalance"
+}
@@ -0,0 +1,289 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruption_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/disruption"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("Pod Rebalancing", func() {
+	var nodePool *v1beta1.NodePool
+	var nodeClaims []*v1beta1.NodeClaim
+	var nodes []*v1.Node
+	var labels = map[string]string{
+		"app": "test",
+	}
+	var requests = v1.ResourceRequirements{
+		Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+	}
+	BeforeEach(func() {
+		nodePool = test.NodePool(v1beta1.NodePool{
+			Spec: v1beta1.NodePoolSpec{
+				Disruption: v1beta1.Disruption{
+					ConsolidationPolicy: v1beta1.ConsolidationPolicyWhenUnderutilized,
+					Budgets: []v1beta1.Budget{{
+						Nodes: "100%",
+					}},
+				},
+			},
+		})
+		// the pods move from the first node onto the spare capacity of the second node, which is cheaper
+		nodeClaims, nodes = make([]*v1beta1.NodeClaim, 2), make([]*v1.Node, 2)
+		for i, it := range []struct {
+			instanceType *cloudprovider.InstanceType
+			offering     cloudprovider.Offering
+		}{{mostExpensiveInstance, mostExpensiveOffering}, {leastExpensiveInstance, leastExpensiveOffering}} {
+			nodeClaims[i], nodes[i] = test.NodeClaimAndNode(v1beta1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1beta1.NodePoolLabelKey:     nodePool.Name,
+						v1.LabelInstanceTypeStable:   it.instanceType.Name,
+						v1beta1.CapacityTypeLabelKey: it.offering.CapacityType,
+						v1.LabelTopologyZone:         it.offering.Zone,
+					},
+				},
+				Status: v1beta1.NodeClaimStatus{
+					Allocatable: map[v1.ResourceName]resource.Quantity{v1.ResourceCPU: resource.MustParse("32")},
+				},
+			})
+		}
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{PodRebalancing: lo.ToPtr(true)}}))
+	})
+	// blockedPods creates a pod that can't be evicted on each node, so that neither node can be disrupted as a whole,
+	// and returns a pod on the first node that can be evicted
+	blockedPods := func() (*v1.Pod, []*v1.Pod) {
+		blocking := test.Pods(2, test.PodOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{v1beta1.DoNotDisruptAnnotationKey: "true"},
+			},
+		})
+		pod := test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, ResourceRequirements: requests})
+		ExpectApplied(ctx, env.Client, nodePool, pod, blocking[0], blocking[1])
+		ExpectApplied(ctx, env.Client, nodeClaims[0], nodes[0], nodeClaims[1], nodes[1])
+		ExpectManualBinding(ctx, env.Client, pod, nodes[0])
+		ExpectManualBinding(ctx, env.Client, blocking[0], nodes[0])
+		ExpectManualBinding(ctx, env.Client, blocking[1], nodes[1])
+		return pod, blocking
+	}
+	It("should evict the pods that can be moved off of a node that's blocked by a karpenter.sh/do-not-disrupt pod", func() {
+		pod, blocking := blockedPods()
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+
+		ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+
+		Expect(evictionQueue.Has(pod)).To(BeTrue())
+		Expect(evictionQueue.Has(blocking[0])).To(BeFalse())
+		Expect(evictionQueue.Has(blocking[1])).To(BeFalse())
+
+		// the node is tainted while its pods move, but it isn't terminated
+		Expect(queue.HasAny(nodes[0].Spec.ProviderID)).To(BeFalse())
+		Expect(ExpectExists(ctx, env.Client, nodes[0]).Spec.Taints).To(ContainElement(v1beta1.DisruptionNoScheduleTaint))
+		ExpectExists(ctx, env.Client, nodeClaims[0])
+		ExpectMetricCounterValue("karpenter_disruption_pods_disrupted_total", 1, map[string]string{
+			"nodepool":           nodePool.Name,
+			"action":             "rebalance",
+			"method":             "consolidation",
+			"consolidation_type": "rebalance",
+		})
+	})
+	It("should untaint the node once its evicted pods are gone", func() {
+		pod, _ := blockedPods()
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+
+		ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+		Expect(evictionQueue.Has(pod)).To(BeTrue())
+		Expect(ExpectExists(ctx, env.Client, nodes[0]).Spec.Taints).To(ContainElement(v1beta1.DisruptionNoScheduleTaint))
+
+		// the node stays tainted while the evicted pod is still on it
+		ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+		Expect(ExpectExists(ctx, env.Client, nodes[0]).Spec.Taints).To(ContainElement(v1beta1.DisruptionNoScheduleTaint))
+
+		ExpectDeleted(ctx, env.Client, pod)
+		ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+		Expect(ExpectExists(ctx, env.Client, nodes[0]).Spec.Taints).ToNot(ContainElement(v1beta1.DisruptionNoScheduleTaint))
+	})
+	It("should untaint the node once the cooldown has passed even if its evicted pods are still on it", func() {
+		pod, _ := blockedPods()
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+
+		ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+		Expect(evictionQueue.Has(pod)).To(BeTrue())
+		Expect(ExpectExists(ctx, env.Client, nodes[0]).Spec.Taints).To(ContainElement(v1beta1.DisruptionNoScheduleTaint))
+
+		fakeClock.Step(disruption.PodRebalancingCooldown)
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{PodRebalancing: lo.ToPtr(false)}}))
+		ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+		Expect(ExpectExists(ctx, env.Client, nodes[0]).Spec.Taints).ToNot(ContainElement(v1beta1.DisruptionNoScheduleTaint))
+	})
+	It("should not evict pods that wouldn't be cheaper to run on the node they move to", func() {
+		nodeClaims[1].Labels = lo.Assign(nodeClaims[1].Labels, map[string]string{
+			v1.LabelInstanceTypeStable:   mostExpensiveInstance.Name,
+			v1beta1.CapacityTypeLabelKey: mostExpensiveOffering.CapacityType,
+			v1.LabelTopologyZone:         mostExpensiveOffering.Zone,
+		})
+		nodes[1].Labels = lo.Assign(nodes[1].Labels, nodeClaims[1].Labels)
+		pod, _ := blockedPods()
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+
+		ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+
+		Expect(evictionQueue.Has(pod)).To(BeFalse())
+		Expect(ExpectExists(ctx, env.Client, nodes[0]).Spec.Taints).ToNot(ContainElement(v1beta1.DisruptionNoScheduleTaint))
+	})
+	It("should not evict pods when the PodRebalancing feature gate is disabled", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{PodRebalancing: lo.ToPtr(false)}}))
+		pod, _ := blockedPods()
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+
+		ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+
+		Expect(evictionQueue.Has(pod)).To(BeFalse())
+	})
+	It("should not evict pods that are protected by a PDB", func() {
+		pod, _ := blockedPods()
+		protected := test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "protected"}}})
+		pdb := test.PodDisruptionBudget(test.PDBOptions{
+			Labels:         map[string]string{"app": "protected"},
+			MaxUnavailable: fromInt(0),
+			Status: &policyv1.PodDisruptionBudgetStatus{
+				ObservedGeneration: 1,
+				DisruptionsAllowed: 0,
+				CurrentHealthy:     1,
+				DesiredHealthy:     1,
+				ExpectedPods:       1,
+			},
+		})
+		ExpectApplied(ctx, env.Client, protected, pdb)
+		ExpectManualBinding(ctx, env.Client, protected, nodes[0])
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+
+		ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+
+		Expect(evictionQueue.Has(pod)).To(BeTrue())
+		Expect(evictionQueue.Has(protected)).To(BeFalse())
+	})
+	It("should evict the pods of a node that's blocked by a PDB", func() {
+		pod := test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, ResourceRequirements: requests})
+		protected := test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "protected"}}})
+		blocking := test.Pod(test.PodOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{v1beta1.DoNotDisruptAnnotationKey: "true"},
+			},
+		})
+		pdb := test.PodDisruptionBudget(test.PDBOptions{
+			Labels:         map[string]string{"app": "protected"},
+			MaxUnavailable: fromInt(0),
+			Status: &policyv1.PodDisruptionBudgetStatus{
+				ObservedGeneration: 1,
+				DisruptionsAllowed: 0,
+				CurrentHealthy:     1,
+				DesiredHealthy:     1,
+				ExpectedPods:       1,
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, pod, protected, blocking, pdb)
+		ExpectApplied(ctx, env.Client, nodeClaims[0], nodes[0], nodeClaims[1], nodes[1])
+		ExpectManualBinding(ctx, env.Client, pod, nodes[0])
+		ExpectManualBinding(ctx, env.Client, protected, nodes[0])
+		ExpectManualBinding(ctx, env.Client, blocking, nodes[1])
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+
+		ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+
+		Expect(evictionQueue.Has(pod)).To(BeTrue())
+		Expect(evictionQueue.Has(protected)).To(BeFalse())
+		ExpectExists(ctx, env.Client, nodeClaims[0])
+	})
+	It("should not evict pods from a node with the karpenter.sh/do-not-disrupt annotation", func() {
+		nodes[0].Annotations = lo.Assign(nodes[0].Annotations, map[string]string{v1beta1.DoNotDisruptAnnotationKey: "true"})
+		pod, _ := blockedPods()
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+
+		ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+
+		Expect(evictionQueue.Has(pod)).To(BeFalse())
+	})
+	It("should not evict pods that would need new capacity", func() {
+		// the pod requests more than the spare capacity of the other node
+		pod := test.Pod(test.PodOptions{
+			ObjectMeta: metav1.ObjectMeta{Labels: labels},
+			ResourceRequirements: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("20")},
+			},
+		})
+		blocking := test.Pods(2, test.PodOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{v1beta1.DoNotDisruptAnnotationKey: "true"},
+			},
+			ResourceRequirements: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("20")},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, pod, blocking[0], blocking[1])
+		ExpectApplied(ctx, env.Client, nodeClaims[0], nodes[0], nodeClaims[1], nodes[1])
+		ExpectManualBinding(ctx, env.Client, pod, nodes[0])
+		ExpectManualBinding(ctx, env.Client, blocking[0], nodes[0])
+		ExpectManualBinding(ctx, env.Client, blocking[1], nodes[1])
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+
+		ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+
+		Expect(evictionQueue.Has(pod)).To(BeFalse())
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+	})
+	It("should not evict pods from nodes that are blocked by a disruption budget", func() {
+		nodePool.Spec.Disruption.Budgets = []v1beta1.Budget{{Nodes: "0%"}}
+		pod, _ := blockedPods()
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+
+		ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+
+		Expect(evictionQueue.Has(pod)).To(BeFalse())
+	})
+	It("should not rebalance the same node again until the cooldown has passed", func() {
+		pod, _ := blockedPods()
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+
+		ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+		Expect(evictionQueue.Has(pod)).To(BeTrue())
+
+		// the pod was placed back onto the same node
+		evictionQueue.Reset()
+		cluster.MarkUnconsolidated()
+		ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+		Expect(evictionQueue.Has(pod)).To(BeFalse())
+
+		fakeClock.Step(disruption.PodRebalancingCooldown)
+		cluster.MarkUnconsolidated()
+		ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+		Expect(evictionQueue.Has(pod)).To(BeTrue())
+	})
+})
@@ -21,10 +21,13 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"knative.dev/pkg/logging"
 
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
 	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 )
 
 const SingleNodeConsolidationTimeoutDuration = 3 * time.Minute
@@ -39,10 +42,27 @@ func NewSingleNodeConsolidation(consolidation consolidation) *SingleNodeConsolid
 }
 
 // ComputeCommand generates a disruption command given candidates
-// nolint:gocyclo
 func (s *SingleNodeConsolidation) ComputeCommand(ctx context.Context, disruptionBudgetMapping map[string]int, candidates ...*Candidate) (Command, scheduling.Results, error) {
+	cmds, results, err := s.computeCommands(ctx, disruptionBudgetMapping, 1, candidates...)
+	if err != nil || len(cmds) == 0 {
+		return Command{}, scheduling.Results{}, err
+	}
+	return cmds[0], results[0], nil
+}
+
+// ComputeCommands generates up to the consolidation batch size of disruption commands given candidates
+func (s *SingleNodeConsolidation) ComputeCommands(ctx context.Context, disruptionBudgetMapping map[string]int, candidates ...*Candidate) ([]Command, []scheduling.Results, error) {
+	return s.computeCommands(ctx, disruptionBudgetMapping, options.FromContext(ctx).ConsolidationBatchSize, candidates...)
+}
+
+// computeCommands generates up to batchSize commands that don't conflict with each other. Commands conflict if they
+// share a candidate, or if one of them schedules pods to a node that another one disrupts or schedules pods to, since
+// each command's scheduling simulation assumed that it was the only one using the spare capacity of its target nodes.
+//
+// nolint:gocyclo
+func (s *SingleNodeConsolidation) computeCommands(ctx context.Context, disruptionBudgetMapping map[string]int, batchSize int, candidates ...*Candidate) ([]Command, []scheduling.Results, error) {
 	if s.IsConsolidated() {
-		return Command{}, scheduling.Results{}, nil
+		return nil, nil, nil
 	}
 	candidates = s.sortCandidates(candidates)
 	EligibleNodesGauge.With(map[string]string{
@@ -55,22 +75,29 @@ func (s *SingleNodeConsolidation) ComputeCommand(ctx context.Context, disruption
 	// Set a timeout
 	timeout := s.clock.Now().Add(SingleNodeConsolidationTimeoutDuration)
 	constrainedByBudgets := false
-	// binary search to find the maximum number of NodeClaims we can terminate
+	// the budgets are decremented for each command in the batch
+	budgets := lo.Assign(disruptionBudgetMapping)
+	// the provider IDs of the nodes that are disrupted or scheduled to by the batched commands
+	used := sets.New[string]()
+	var cmds []Command
+	var results []scheduling.Results
 	for i, candidate := range candidates {
 		// If the disruption budget doesn't allow this candidate to be disrupted,
-		// continue to the next candidate. We don't need to decrement any budget
-		// counter since single node consolidation commands can only have one candidate.
-		if disruptionBudgetMapping[candidate.nodePool.Name] == 0 {
+		// continue to the next candidate.
+		if budgets[candidate.nodePool.Name] == 0 {
 			constrainedByBudgets = true
 			continue
 		}
 		if s.clock.Now().After(timeout) {
 			ConsolidationTimeoutTotalCounter.WithLabelValues(s.ConsolidationType()).Inc()
 			logging.FromContext(ctx).Debugf("abandoning single-node consolidation due to timeout after evaluating %d candidates", i)
-			return Command{}, scheduling.Results{}, nil
+			return cmds, results, nil
+		}
+		if used.Has(candidate.ProviderID()) {
+			continue
 		}
 		// compute a possible consolidation option
-		cmd, results, err := s.computeConsolidation(ctx, candidate)
+		cmd, result, err := s.computeConsolidation(ctx, candidate)
 		if err != nil {
 			logging.FromContext(ctx).Errorf("computing consolidation %s", err)
 			continue
@@ -78,23 +105,36 @@ func (s *SingleNodeConsolidation) ComputeCommand(ctx context.Context, disruption
 		if cmd.Action() == NoOpAction {
 			continue
 		}
+		targets := lo.FilterMap(result.ExistingNodes, func(n *scheduling.ExistingNode, _ int) (string, bool) {
+			return n.ProviderID(), len(n.Pods) > 0
+		})
+		if used.HasAny(targets...) {
+			continue
+		}
 		isValid, err := v.IsValid(ctx, cmd)
 		if err != nil {
-			return Command{}, scheduling.Results{}, fmt.Errorf("validating consolidation, %w", err)
+			return nil, nil, fmt.Errorf("validating consolidation, %w", err)
 		}
 		if !isValid {
 			logging.FromContext(ctx).Debugf("abandoning single-node consolidation attempt due to pod churn, command is no longer valid, %s", cmd)
-			return Command{}, scheduling.Results{}, nil
+			return cmds, results, nil
+		}
+		cmds = append(cmds, cmd)
+		results = append(results, result)
+		if len(cmds) == batchSize {
+			return cmds, results, nil
 		}
-		return cmd, results, nil
+		budgets[candidate.nodePool.Name]--
+		used.Insert(candidate.ProviderID())
+		used.Insert(targets...)
 	}
-	if !constrainedByBudgets {
+	if len(cmds) == 0 && !constrainedByBudgets {
 		// if there are no candidates because of a budget, don't mark
 		// as consolidated, as it's possible it should be consolidatable
 		// the next time we try to disrupt.
 		s.markConsolidated()
 	}
-	return Command{}, scheduling.Results{}, nil
+	return cmds, results, nil
 }
 
 func (s *SingleNodeConsolidation) Type() string {
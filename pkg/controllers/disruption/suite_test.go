@@ -27,6 +27,10 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -44,12 +48,16 @@ import (
 	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
 	"sigs.k8s.io/karpenter/pkg/controllers/disruption"
 	"sigs.k8s.io/karpenter/pkg/controllers/disruption/orchestration"
+	"sigs.k8s.io/karpenter/pkg/controllers/node/termination/terminator"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	pscheduling "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/controllers/state/informer"
+	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/operator/controller"
 	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	"sigs.k8s.io/karpenter/pkg/operator/tracing"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 	"sigs.k8s.io/karpenter/pkg/test"
 	. "sigs.k8s.io/karpenter/pkg/test/expectations"
@@ -66,6 +74,7 @@ var nodeClaimStateController controller.Controller
 var fakeClock *clock.FakeClock
 var recorder *test.EventRecorder
 var queue *orchestration.Queue
+var evictionQueue *terminator.Queue
 
 var onDemandInstances []*cloudprovider.InstanceType
 var spotInstances []*cloudprovider.InstanceType
@@ -91,7 +100,8 @@ var _ = BeforeSuite(func() {
 	recorder = test.NewEventRecorder()
 	prov = provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster)
 	queue = orchestration.NewTestingQueue(env.Client, recorder, cluster, fakeClock, prov)
-	disruptionController = disruption.NewController(fakeClock, env.Client, prov, cloudProvider, recorder, cluster, queue)
+	evictionQueue = terminator.NewQueue(env.Client, recorder)
+	disruptionController = disruption.NewController(fakeClock, env.Client, prov, cloudProvider, recorder, cluster, queue, evictionQueue)
 })
 
 var _ = AfterSuite(func() {
@@ -111,6 +121,7 @@ var _ = BeforeEach(func() {
 	fakeClock.SetTime(time.Now())
 	cluster.Reset()
 	queue.Reset()
+	evictionQueue.Reset()
 	cluster.MarkUnconsolidated()
 
 	// Reset Feature Flags to test defaults
@@ -455,6 +466,157 @@ var _ = Describe("Simulate Scheduling", func() {
 		Expect(nodeclaims[0].Name).ToNot(Equal(nodeClaim.Name))
 		Expect(nodes[0].Name).ToNot(Equal(node.Name))
 	})
+	It("should prefer moving pods to the nodes that satisfy the most of their preferred pod affinities", func() {
+		numNodes := 6
+		nodeClaims, nodes := test.NodeClaimsAndNodes(numNodes, v1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1beta1.NodePoolLabelKey:     nodePool.Name,
+					v1.LabelInstanceTypeStable:   mostExpensiveInstance.Name,
+					v1beta1.CapacityTypeLabelKey: mostExpensiveOffering.CapacityType,
+					v1.LabelTopologyZone:         mostExpensiveOffering.Zone,
+				},
+			},
+			Status: v1beta1.NodeClaimStatus{
+				Allocatable: map[v1.ResourceName]resource.Quantity{
+					v1.ResourceCPU:  resource.MustParse("3"),
+					v1.ResourcePods: resource.MustParse("100"),
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool)
+		for i := 0; i < numNodes; i++ {
+			ExpectApplied(ctx, env.Client, nodeClaims[i], nodes[i])
+		}
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+
+		// The pod prefers being co-located with both a database and a cache pod, but no node has both, so its
+		// preferences are relaxed until it can be moved to any node
+		pod := test.Pod(test.PodOptions{
+			ResourceRequirements: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+			},
+			PodPreferences: []v1.WeightedPodAffinityTerm{
+				{
+					Weight: 100,
+					PodAffinityTerm: v1.PodAffinityTerm{
+						LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+						TopologyKey:   v1.LabelHostname,
+					},
+				},
+				{
+					Weight: 10,
+					PodAffinityTerm: v1.PodAffinityTerm{
+						LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "cache"}},
+						TopologyKey:   v1.LabelHostname,
+					},
+				},
+			},
+		})
+		dbPod := test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "db"}}})
+		ExpectApplied(ctx, env.Client, pod, dbPod)
+		ExpectManualBinding(ctx, env.Client, pod, nodes[0])
+		ExpectManualBinding(ctx, env.Client, dbPod, nodes[numNodes-1])
+		for _, n := range nodes {
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(n))
+		}
+
+		nodePoolMap, nodePoolToInstanceTypesMap, err := disruption.BuildNodePoolMap(ctx, env.Client, cloudProvider)
+		Expect(err).To(Succeed())
+		pdbs, err := disruption.NewPDBLimits(ctx, fakeClock, env.Client)
+		Expect(err).To(Succeed())
+		candidate, err := disruption.NewCandidate(ctx, env.Client, recorder, fakeClock, ExpectStateNodeExists(cluster, nodes[0]), pdbs, nodePoolMap, nodePoolToInstanceTypesMap, queue)
+		Expect(err).To(Succeed())
+
+		results, err := disruption.SimulateScheduling(ctx, env.Client, cluster, prov, candidate)
+		Expect(err).To(Succeed())
+		Expect(results.PodErrors).To(BeEmpty())
+		Expect(results.NewNodeClaims).To(BeEmpty())
+		existingNode, ok := lo.Find(results.ExistingNodes, func(n *pscheduling.ExistingNode) bool { return len(n.Pods) > 0 })
+		Expect(ok).To(BeTrue())
+		Expect(existingNode.Name()).To(Equal(nodes[numNodes-1].Name))
+		Expect(existingNode.Pods).To(ConsistOf(pod))
+	})
+	It("should prefer moving pods to the nodes that violate the fewest of their preferred pod anti-affinities", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{KeepPreferredAntiAffinity: lo.ToPtr(true)}}))
+		numNodes := 6
+		nodeClaims, nodes := test.NodeClaimsAndNodes(numNodes, v1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1beta1.NodePoolLabelKey:     nodePool.Name,
+					v1.LabelInstanceTypeStable:   mostExpensiveInstance.Name,
+					v1beta1.CapacityTypeLabelKey: mostExpensiveOffering.CapacityType,
+					v1.LabelTopologyZone:         mostExpensiveOffering.Zone,
+				},
+			},
+			Status: v1beta1.NodeClaimStatus{
+				Allocatable: map[v1.ResourceName]resource.Quantity{
+					v1.ResourceCPU:  resource.MustParse("3"),
+					v1.ResourcePods: resource.MustParse("100"),
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool)
+		for i := 0; i < numNodes; i++ {
+			ExpectApplied(ctx, env.Client, nodeClaims[i], nodes[i])
+		}
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, nodes, nodeClaims)
+
+		// The pod prefers being apart from both web and cache pods, but every other node has a cache pod, so its
+		// preferences are relaxed until it can be moved to any node
+		pod := test.Pod(test.PodOptions{
+			ResourceRequirements: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+			},
+			PodAntiPreferences: []v1.WeightedPodAffinityTerm{
+				{
+					Weight: 100,
+					PodAffinityTerm: v1.PodAffinityTerm{
+						LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+						TopologyKey:   v1.LabelHostname,
+					},
+				},
+				{
+					Weight: 10,
+					PodAffinityTerm: v1.PodAffinityTerm{
+						LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "cache"}},
+						TopologyKey:   v1.LabelHostname,
+					},
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, pod)
+		ExpectManualBinding(ctx, env.Client, pod, nodes[0])
+		for i := 1; i < numNodes; i++ {
+			cachePod := test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "cache"}}})
+			ExpectApplied(ctx, env.Client, cachePod)
+			ExpectManualBinding(ctx, env.Client, cachePod, nodes[i])
+			// Only the last node doesn't have a web pod
+			if i < numNodes-1 {
+				webPod := test.Pod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}}})
+				ExpectApplied(ctx, env.Client, webPod)
+				ExpectManualBinding(ctx, env.Client, webPod, nodes[i])
+			}
+		}
+		for _, n := range nodes {
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(n))
+		}
+
+		nodePoolMap, nodePoolToInstanceTypesMap, err := disruption.BuildNodePoolMap(ctx, env.Client, cloudProvider)
+		Expect(err).To(Succeed())
+		pdbs, err := disruption.NewPDBLimits(ctx, fakeClock, env.Client)
+		Expect(err).To(Succeed())
+		candidate, err := disruption.NewCandidate(ctx, env.Client, recorder, fakeClock, ExpectStateNodeExists(cluster, nodes[0]), pdbs, nodePoolMap, nodePoolToInstanceTypesMap, queue)
+		Expect(err).To(Succeed())
+
+		results, err := disruption.SimulateConsolidation(ctx, env.Client, cluster, prov, candidate)
+		Expect(err).To(Succeed())
+		Expect(results.PodErrors).To(BeEmpty())
+		Expect(results.NewNodeClaims).To(BeEmpty())
+		existingNode, ok := lo.Find(results.ExistingNodes, func(n *pscheduling.ExistingNode) bool { return lo.Contains(n.Pods, pod) })
+		Expect(ok).To(BeTrue())
+		Expect(existingNode.Name()).To(Equal(nodes[numNodes-1].Name))
+	})
 })
 
 var _ = Describe("Disruption Taints", func() {
@@ -530,6 +692,10 @@ var _ = Describe("Disruption Taints", func() {
 		})
 		nodePool.Spec.Disruption.ConsolidateAfter = &v1beta1.NillableDuration{Duration: nil}
 		node.Spec.Taints = append(node.Spec.Taints, v1beta1.DisruptionNoScheduleTaint)
+		node.Annotations = lo.Assign(node.Annotations, map[string]string{
+			v1beta1.DisruptionReasonAnnotationKey:       metrics.ConsolidationReason,
+			v1beta1.DisruptionDecisionTimeAnnotationKey: fakeClock.Now().UTC().Format(time.RFC3339),
+		})
 		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node, pod)
 		ExpectManualBinding(ctx, env.Client, pod, node)
 
@@ -547,6 +713,8 @@ var _ = Describe("Disruption Taints", func() {
 		wg.Wait()
 		node = ExpectNodeExists(ctx, env.Client, node.Name)
 		Expect(node.Spec.Taints).ToNot(ContainElement(v1beta1.DisruptionNoScheduleTaint))
+		Expect(node.Annotations).ToNot(HaveKey(v1beta1.DisruptionReasonAnnotationKey))
+		Expect(node.Annotations).ToNot(HaveKey(v1beta1.DisruptionDecisionTimeAnnotationKey))
 	})
 	It("should add and remove taints from NodeClaims that fail to disrupt", func() {
 		nodePool.Spec.Disruption.ConsolidationPolicy = v1beta1.ConsolidationPolicyWhenUnderutilized
@@ -583,6 +751,8 @@ var _ = Describe("Disruption Taints", func() {
 		}
 		node = ExpectNodeExists(ctx, env.Client, node.Name)
 		Expect(node.Spec.Taints).To(ContainElement(v1beta1.DisruptionNoScheduleTaint))
+		Expect(node.Annotations).To(HaveKeyWithValue(v1beta1.DisruptionReasonAnnotationKey, metrics.ConsolidationReason))
+		Expect(node.Annotations).To(HaveKeyWithValue(v1beta1.DisruptionDecisionTimeAnnotationKey, fakeClock.Now().UTC().Format(time.RFC3339)))
 
 		createdNodeClaim := lo.Reject(ExpectNodeClaims(ctx, env.Client), func(nc *v1beta1.NodeClaim, _ int) bool {
 			return nc.Name == nodeClaim.Name
@@ -599,6 +769,8 @@ var _ = Describe("Disruption Taints", func() {
 
 		node = ExpectNodeExists(ctx, env.Client, node.Name)
 		Expect(node.Spec.Taints).ToNot(ContainElement(v1beta1.DisruptionNoScheduleTaint))
+		Expect(node.Annotations).ToNot(HaveKey(v1beta1.DisruptionReasonAnnotationKey))
+		Expect(node.Annotations).ToNot(HaveKey(v1beta1.DisruptionDecisionTimeAnnotationKey))
 	})
 })
 
@@ -1993,3 +2165,61 @@ func ExpectMakeNewNodeClaimsReady(ctx context.Context, c client.Client, wg *sync
 		}
 	}()
 }
+
+var _ = Describe("Tracing", func() {
+	var spanRecorder *tracetest.SpanRecorder
+	BeforeEach(func() {
+		spanRecorder = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	})
+	AfterEach(func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+	})
+	It("should create spans for candidate selection, simulation and execution", func() {
+		nodePool := test.NodePool(v1beta1.NodePool{
+			Spec: v1beta1.NodePoolSpec{
+				Disruption: v1beta1.Disruption{
+					ConsolidateAfter:    &v1beta1.NillableDuration{Duration: lo.ToPtr(time.Second * 0)},
+					ConsolidationPolicy: v1beta1.ConsolidationPolicyWhenEmpty,
+					ExpireAfter:         v1beta1.NillableDuration{Duration: nil},
+				},
+			},
+		})
+		nodeClaim, node := test.NodeClaimAndNode(v1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1beta1.NodePoolLabelKey:     nodePool.Name,
+					v1.LabelInstanceTypeStable:   mostExpensiveInstance.Name,
+					v1beta1.CapacityTypeLabelKey: mostExpensiveOffering.CapacityType,
+					v1.LabelTopologyZone:         mostExpensiveOffering.Zone,
+				},
+			},
+			Status: v1beta1.NodeClaimStatus{
+				Allocatable: map[v1.ResourceName]resource.Quantity{
+					v1.ResourceCPU:  resource.MustParse("32"),
+					v1.ResourcePods: resource.MustParse("100"),
+				},
+			},
+		})
+		nodeClaim.StatusConditions().MarkTrue(v1beta1.Empty)
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+
+		// inform cluster state about nodes and nodeclaims
+		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeStateController, nodeClaimStateController, []*v1.Node{node}, []*v1beta1.NodeClaim{nodeClaim})
+		fakeClock.Step(10 * time.Minute)
+
+		var wg sync.WaitGroup
+		ExpectTriggerVerifyAction(&wg)
+		ExpectReconcileSucceeded(ctx, disruptionController, client.ObjectKey{})
+		wg.Wait()
+
+		spans := lo.SliceToMap(spanRecorder.Ended(), func(s sdktrace.ReadOnlySpan) (string, sdktrace.ReadOnlySpan) { return s.Name(), s })
+		Expect(spans).To(HaveKey("disruption.Reconcile"))
+		Expect(spans).To(HaveKey("disruption.Disrupt"))
+		Expect(spans).To(HaveKey("disruption.GetCandidates"))
+		Expect(spans).To(HaveKey("disruption.ComputeCommand"))
+		Expect(spans).To(HaveKey("disruption.ExecuteCommand"))
+		Expect(spans["disruption.ExecuteCommand"].Attributes()).To(ContainElement(tracing.CandidatesKey.Int(1)))
+		Expect(spans["disruption.Disrupt"].Attributes()).To(ContainElement(tracing.DecisionKey.String(string(disruption.DeleteAction))))
+	})
+})
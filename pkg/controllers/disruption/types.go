@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
@@ -45,6 +46,13 @@ type Method interface {
 	ConsolidationType() string
 }
 
+// BatchMethod is a Method that can compute several commands that don't conflict with each other, which are all executed
+// in the same disruption loop. The scheduling results are returned in the same order as the commands.
+type BatchMethod interface {
+	Method
+	ComputeCommands(context.Context, map[string]int, ...*Candidate) ([]Command, []scheduling.Results, error)
+}
+
 type CandidateFilter func(context.Context, *Candidate) bool
 
 // Candidate is a state.StateNode that we are considering for disruption along with extra information to be used in
@@ -59,9 +67,19 @@ type Candidate struct {
 	reschedulablePods []*v1.Pod
 }
 
-//nolint:gocyclo
 func NewCandidate(ctx context.Context, kubeClient client.Client, recorder events.Recorder, clk clock.Clock, node *state.StateNode, pdbs *PDBLimits,
 	nodePoolMap map[string]*v1beta1.NodePool, nodePoolToInstanceTypesMap map[string]map[string]*cloudprovider.InstanceType, queue *orchestration.Queue) (*Candidate, error) {
+	return newCandidate(ctx, kubeClient, recorder, clk, node, pdbs, nodePoolMap, nodePoolToInstanceTypesMap, queue, false)
+}
+
+// newCandidate builds a Candidate from a state node. Pods that can't be evicted, either due to the
+// karpenter.sh/do-not-disrupt annotation or a PDB, block the candidate unless allowBlockingPods is set, which is only
+// the case for methods that don't terminate the candidate.
+//
+//nolint:gocyclo
+func newCandidate(ctx context.Context, kubeClient client.Client, recorder events.Recorder, clk clock.Clock, node *state.StateNode, pdbs *PDBLimits,
+	nodePoolMap map[string]*v1beta1.NodePool, nodePoolToInstanceTypesMap map[string]map[string]*cloudprovider.InstanceType, queue *orchestration.Queue,
+	allowBlockingPods bool) (*Candidate, error) {
 
 	if node.Node == nil || node.NodeClaim == nil {
 		return nil, fmt.Errorf("state node doesn't contain both a node and a nodeclaim")
@@ -104,6 +122,11 @@ func NewCandidate(ctx context.Context, kubeClient client.Client, recorder events
 		return nil, fmt.Errorf("nodepool %q can't be resolved for state node", nodePoolName)
 	}
 	instanceType := instanceTypeMap[node.Labels()[v1.LabelInstanceTypeStable]]
+	// The instance types of drifted candidates may have been retired by the cloud provider, so they're replaced without
+	// knowing anything but the name of their instance type
+	if instanceType == nil && node.NodeClaim.StatusConditions().GetCondition(v1beta1.Drifted).IsTrue() {
+		instanceType = &cloudprovider.InstanceType{Name: node.Labels()[v1.LabelInstanceTypeStable]}
+	}
 	// skip any candidates that we can't determine the instance of
 	if instanceType == nil {
 		recorder.Publish(disruptionevents.Blocked(node.Node, node.NodeClaim, fmt.Sprintf("Instance type %q not found", node.Labels()[v1.LabelInstanceTypeStable]))...)
@@ -119,30 +142,72 @@ func NewCandidate(ctx context.Context, kubeClient client.Client, recorder events
 		logging.FromContext(ctx).Errorf("determining node pods, %s", err)
 		return nil, fmt.Errorf("getting pods from state node, %w", err)
 	}
-	for _, po := range pods {
-		// We only consider pods that are actively running for "karpenter.sh/do-not-disrupt"
-		// This means that we will allow Mirror Pods and DaemonSets to block disruption using this annotation
-		if !pod.IsDisruptable(po) {
-			recorder.Publish(disruptionevents.Blocked(node.Node, node.NodeClaim, fmt.Sprintf(`Pod %q has "karpenter.sh/do-not-disrupt" annotation`, client.ObjectKeyFromObject(po)))...)
-			return nil, fmt.Errorf(`pod %q has "karpenter.sh/do-not-disrupt" annotation`, client.ObjectKeyFromObject(po))
+	if !allowBlockingPods {
+		for _, po := range pods {
+			// We only consider pods that are actively running for "karpenter.sh/do-not-disrupt"
+			// This means that we will allow Mirror Pods and DaemonSets to block disruption using this annotation
+			if !pod.IsDisruptable(po) {
+				recorder.Publish(disruptionevents.Blocked(node.Node, node.NodeClaim, fmt.Sprintf(`Pod %q has "karpenter.sh/do-not-disrupt" annotation`, client.ObjectKeyFromObject(po)))...)
+				return nil, fmt.Errorf(`pod %q has "karpenter.sh/do-not-disrupt" annotation`, client.ObjectKeyFromObject(po))
+			}
+		}
+		if pdbKey, ok := pdbs.CanEvictPods(pods); !ok {
+			recorder.Publish(disruptionevents.Blocked(node.Node, node.NodeClaim, fmt.Sprintf("PDB %q prevents pod evictions", pdbKey))...)
+			return nil, fmt.Errorf("pdb %q prevents pod evictions", pdbKey)
 		}
 	}
-	if pdbKey, ok := pdbs.CanEvictPods(pods); !ok {
-		recorder.Publish(disruptionevents.Blocked(node.Node, node.NodeClaim, fmt.Sprintf("PDB %q prevents pod evictions", pdbKey))...)
-		return nil, fmt.Errorf("pdb %q prevents pod evictions", pdbKey)
-	}
+	// Pods that are about to be failed for exceeding their active deadline go away on their own, so they don't need room
+	// elsewhere and don't keep the candidate from being disrupted
+	reschedulablePods := lo.Filter(pods, func(p *v1.Pod, _ int) bool {
+		return pod.IsReschedulable(p) && !pod.IsNearActiveDeadline(p, clk, ActiveDeadlineThreshold)
+	})
 	return &Candidate{
 		StateNode:         node.DeepCopy(),
 		instanceType:      instanceType,
 		nodePool:          nodePool,
 		capacityType:      node.Labels()[v1beta1.CapacityTypeLabelKey],
 		zone:              node.Labels()[v1.LabelTopologyZone],
-		reschedulablePods: lo.Filter(pods, func(p *v1.Pod, _ int) bool { return pod.IsReschedulable(p) }),
+		reschedulablePods: reschedulablePods,
 		// We get the disruption cost from all pods in the candidate, not just the reschedulable pods
-		disruptionCost: disruptionCost(ctx, pods) * lifetimeRemaining(clk, nodePool, node.Node),
+		disruptionCost: disruptionCost(ctx, pods) * lifetimeRemaining(clk, nodePool, node.Node) *
+			reservationRemaining(clk, instanceType, node.Labels()[v1beta1.CapacityTypeLabelKey], node.Labels()[v1.LabelTopologyZone]) *
+			drainTime(pods),
 	}, nil
 }
 
+// ActiveDeadlineThreshold is how close to its spec.activeDeadlineSeconds a pod must be to no longer be rescheduled when
+// its node is disrupted. The kubelet fails the pod once the deadline is exceeded, so evicting it shortly before doesn't
+// change its outcome. The pod is still evicted respecting karpenter.sh/do-not-disrupt and PDBs.
+const ActiveDeadlineThreshold = time.Minute
+
+// reservationExpiryWindow is how long before a reservation expires that we begin to scale down the disruption cost
+// of candidates that are launched from the reserved offering
+const reservationExpiryWindow = 24 * time.Hour
+
+// reservationRemaining calculates the fraction of the reservation expiry window remaining in the range [0.0, 1.0]. If the
+// candidate's offering has a reservation that ends within the window, we use it to scale down the disruption cost of the
+// candidate so that consolidation prefers moving its pods elsewhere before it starts being billed at on-demand rates.
+func reservationRemaining(clk clock.Clock, instanceType *cloudprovider.InstanceType, capacityType, zone string) float64 {
+	offering, ok := instanceType.Offerings.Get(capacityType, zone)
+	if !ok || offering.ReservationExpiry == nil {
+		return 1.0
+	}
+	return clamp(0.0, offering.ReservationExpiry.Sub(clk.Now()).Seconds()/reservationExpiryWindow.Seconds(), 1.0)
+}
+
+// drainTimeScale is the pod termination grace period at which the disruption cost of a candidate is doubled
+const drainTimeScale = time.Hour
+
+// drainTime calculates a factor in the range [1.0, 2.0] from the longest terminationGracePeriodSeconds of the candidate's
+// pods. Draining a candidate can take as long as its slowest pod takes to terminate, so we use it to scale up the
+// disruption cost of the candidate so that consolidation prefers candidates that finish draining sooner.
+func drainTime(pods []*v1.Pod) float64 {
+	longest := lo.Max(lo.Map(pods, func(p *v1.Pod, _ int) int64 {
+		return lo.FromPtrOr(p.Spec.TerminationGracePeriodSeconds, v1.DefaultTerminationGracePeriodSeconds)
+	}))
+	return 1.0 + clamp(0.0, float64(longest)/drainTimeScale.Seconds(), 1.0)
+}
+
 // lifetimeRemaining calculates the fraction of node lifetime remaining in the range [0.0, 1.0].  If the TTLSecondsUntilExpired
 // is non-zero, we use it to scale down the disruption costs of candidates that are going to expire.  Just after creation, the
 // disruption cost is highest, and it approaches zero as the node ages towards its expiration time.
@@ -160,18 +225,23 @@ func lifetimeRemaining(clock clock.Clock, nodePool *v1beta1.NodePool, node *v1.N
 type Command struct {
 	candidates   []*Candidate
 	replacements []*scheduling.NodeClaim
+	// evictions are the pods that are evicted from the candidates without terminating them
+	evictions []*v1.Pod
 }
 
 type Action string
 
 var (
-	NoOpAction    Action = "no-op"
-	ReplaceAction Action = "replace"
-	DeleteAction  Action = "delete"
+	NoOpAction      Action = "no-op"
+	ReplaceAction   Action = "replace"
+	DeleteAction    Action = "delete"
+	RebalanceAction Action = "rebalance"
 )
 
 func (c Command) Action() Action {
 	switch {
+	case len(c.candidates) > 0 && len(c.evictions) > 0:
+		return RebalanceAction
 	case len(c.candidates) > 0 && len(c.replacements) > 0:
 		return ReplaceAction
 	case len(c.candidates) > 0 && len(c.replacements) == 0:
@@ -183,6 +253,16 @@ func (c Command) Action() Action {
 
 func (c Command) String() string {
 	var buf bytes.Buffer
+	if c.Action() == RebalanceAction {
+		fmt.Fprintf(&buf, "%s, evicting %d pods from ", c.Action(), len(c.evictions))
+		for i, old := range c.candidates {
+			if i != 0 {
+				fmt.Fprint(&buf, ", ")
+			}
+			fmt.Fprintf(&buf, "%s/%s/%s", old.Name(), old.instanceType.Name, old.capacityType)
+		}
+		return buf.String()
+	}
 	podCount := lo.Reduce(c.candidates, func(_ int, cd *Candidate, _ int) int { return len(cd.reschedulablePods) }, 0)
 	fmt.Fprintf(&buf, "%s, terminating %d nodes (%d pods) ", c.Action(), len(c.candidates), podCount)
 	for i, old := range c.candidates {
@@ -0,0 +1,78 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruption
+
+import (
+	"context"
+
+	"github.com/samber/lo"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+)
+
+// Unhealthy is a subreconciler that replaces candidates whose nodes have been NotReady for longer than their
+// NodePool's unhealthyAfter. Unhealthy candidates are disrupted within the disruption budgets of their NodePool, but
+// NotReady nodes aren't counted against them, otherwise the unhealthy candidates would block their own replacement.
+type Unhealthy struct {
+	clock       clock.Clock
+	kubeClient  client.Client
+	cluster     *state.Cluster
+	provisioner *provisioning.Provisioner
+	recorder    events.Recorder
+}
+
+func NewUnhealthy(clk clock.Clock, kubeClient client.Client, cluster *state.Cluster, provisioner *provisioning.Provisioner, recorder events.Recorder) *Unhealthy {
+	return &Unhealthy{
+		clock:       clk,
+		kubeClient:  kubeClient,
+		cluster:     cluster,
+		provisioner: provisioner,
+		recorder:    recorder,
+	}
+}
+
+// ShouldDisrupt is a predicate used to filter candidates
+func (u *Unhealthy) ShouldDisrupt(_ context.Context, c *Candidate) bool {
+	return c.NodeClaim.StatusConditions().GetCondition(v1beta1.Unhealthy).IsTrue()
+}
+
+// ComputeCommand generates a disruption command given candidates. Replacements are always launched for the candidate's
+// pods, so that they have somewhere to go before the candidate is drained.
+func (u *Unhealthy) ComputeCommand(ctx context.Context, _ map[string]int, candidates ...*Candidate) (Command, scheduling.Results, error) {
+	// Only the deleting nodes are subtracted from the allowed disruptions, not the NotReady nodes
+	nodePools := lo.Uniq(lo.Map(candidates, func(c *Candidate, _ int) *v1beta1.NodePool { return c.nodePool }))
+	disruptionBudgetMapping := allowedDisruptions(ctx, u.cluster, u.clock, nodePools, (*state.StateNode).MarkedForDeletion)
+	return computeConditionCommand(ctx, u, v1beta1.Unhealthy, u.cluster, u.recorder, disruptionBudgetMapping, candidates,
+		func(ctx context.Context, candidate *Candidate) (scheduling.Results, error) {
+			return SimulateReplacement(ctx, u.kubeClient, u.cluster, u.provisioner, candidate)
+		})
+}
+
+func (u *Unhealthy) Type() string {
+	return metrics.UnhealthyReason
+}
+
+func (u *Unhealthy) ConsolidationType() string {
+	return ""
+}
@@ -69,10 +69,21 @@ var (
 			nodePoolNameLabel,
 		},
 	)
+	expirationPausedGaugeVec = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: nodePoolSubsystem,
+			Name:      "expiration_paused",
+			Help:      "Whether expiration is paused on the nodepool through the karpenter.sh/expiration-paused annotation, 1 if paused and 0 otherwise. Labeled by nodepool name.",
+		},
+		[]string{
+			nodePoolNameLabel,
+		},
+	)
 )
 
 func init() {
-	crmetrics.Registry.MustRegister(limitGaugeVec, usageGaugeVec)
+	crmetrics.Registry.MustRegister(limitGaugeVec, usageGaugeVec, expirationPausedGaugeVec)
 }
 
 type Controller struct {
@@ -120,6 +131,12 @@ func buildMetrics(nodePool *v1beta1.NodePool) (res []*metrics.StoreMetric) {
 			})
 		}
 	}
+	_, paused := nodePool.Annotations[v1beta1.ExpirationPausedAnnotationKey]
+	res = append(res, &metrics.StoreMetric{
+		GaugeVec: expirationPausedGaugeVec,
+		Labels:   prometheus.Labels{nodePoolNameLabel: nodePool.Name},
+		Value:    lo.Ternary(paused, 1.0, 0.0),
+	})
 	return res
 }
 
@@ -110,8 +110,28 @@ var _ = Describe("Metrics", func() {
 			Expect(m.GetGauge().GetValue()).To(BeNumerically("~", v.AsApproximateFloat64()))
 		}
 	})
+	It("should update the nodepool expiration paused metric", func() {
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+
+		m, found := FindMetricWithLabelValues("karpenter_nodepool_expiration_paused", map[string]string{
+			"nodepool": nodePool.GetName(),
+		})
+		Expect(found).To(BeTrue())
+		Expect(m.GetGauge().GetValue()).To(BeNumerically("==", 0))
+
+		nodePool.Annotations = map[string]string{v1beta1.ExpirationPausedAnnotationKey: "true"}
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+
+		m, found = FindMetricWithLabelValues("karpenter_nodepool_expiration_paused", map[string]string{
+			"nodepool": nodePool.GetName(),
+		})
+		Expect(found).To(BeTrue())
+		Expect(m.GetGauge().GetValue()).To(BeNumerically("==", 1))
+	})
 	It("should delete the nodepool state metrics on nodepool delete", func() {
-		expectedMetrics := []string{"karpenter_nodepool_limit", "karpenter_nodepool_usage"}
+		expectedMetrics := []string{"karpenter_nodepool_limit", "karpenter_nodepool_usage", "karpenter_nodepool_expiration_paused"}
 		nodePool.Spec.Limits = v1beta1.Limits{
 			v1.ResourceCPU:              resource.MustParse("100"),
 			v1.ResourceMemory:           resource.MustParse("100Mi"),
@@ -55,7 +55,12 @@ const (
 )
 
 var (
-	podGaugeVec = prometheus.NewGaugeVec(
+	podGaugeVec           = newPodGaugeVec()
+	podStartupTimeSummary = newPodStartupTimeSummary()
+)
+
+func newPodGaugeVec() *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Namespace: "karpenter",
 			Subsystem: "pods",
@@ -64,7 +69,10 @@ var (
 		},
 		labelNames(),
 	)
-	podStartupTimeSummary = prometheus.NewSummary(
+}
+
+func newPodStartupTimeSummary() prometheus.Summary {
+	return prometheus.NewSummary(
 		prometheus.SummaryOpts{
 			Namespace:  "karpenter",
 			Subsystem:  "pods",
@@ -73,14 +81,16 @@ var (
 			Objectives: metrics.SummaryObjectives(),
 		},
 	)
-)
+}
 
 // Controller for the resource
 type Controller struct {
 	kubeClient  client.Client
 	metricStore *metrics.Store
 
-	pendingPods sets.Set[string]
+	podGaugeVec           *prometheus.GaugeVec
+	podStartupTimeSummary prometheus.Summary
+	pendingPods           sets.Set[string]
 }
 
 func init() {
@@ -105,10 +115,24 @@ func labelNames() []string {
 
 // NewController constructs a podController instance
 func NewController(kubeClient client.Client) controller.Controller {
+	return newController(kubeClient, podGaugeVec, podStartupTimeSummary)
+}
+
+// NewControllerWithRegistry constructs a podController instance that emits its metrics to the passed registry rather
+// than the global metrics registry
+func NewControllerWithRegistry(kubeClient client.Client, registry prometheus.Registerer) controller.Controller {
+	gaugeVec, startupTimeSummary := newPodGaugeVec(), newPodStartupTimeSummary()
+	registry.MustRegister(gaugeVec, startupTimeSummary)
+	return newController(kubeClient, gaugeVec, startupTimeSummary)
+}
+
+func newController(kubeClient client.Client, gaugeVec *prometheus.GaugeVec, startupTimeSummary prometheus.Summary) *Controller {
 	return &Controller{
-		kubeClient:  kubeClient,
-		metricStore: metrics.NewStore(),
-		pendingPods: sets.New[string](),
+		kubeClient:            kubeClient,
+		metricStore:           metrics.NewStore(),
+		podGaugeVec:           gaugeVec,
+		podStartupTimeSummary: startupTimeSummary,
+		pendingPods:           sets.New[string](),
 	}
 }
 
@@ -133,7 +157,7 @@ func (c *Controller) Reconcile(ctx context.Context, req reconcile.Request) (reco
 	}
 	c.metricStore.Update(client.ObjectKeyFromObject(pod).String(), []*metrics.StoreMetric{
 		{
-			GaugeVec: podGaugeVec,
+			GaugeVec: c.podGaugeVec,
 			Value:    1,
 			Labels:   labels,
 		},
@@ -152,7 +176,7 @@ func (c *Controller) recordPodStartupMetric(pod *v1.Pod) {
 		return c.Type == v1.PodReady
 	})
 	if c.pendingPods.Has(key) && ok {
-		podStartupTimeSummary.Observe(cond.LastTransitionTime.Sub(pod.CreationTimestamp.Time).Seconds())
+		c.podStartupTimeSummary.Observe(cond.LastTransitionTime.Sub(pod.CreationTimestamp.Time).Seconds())
 		c.pendingPods.Delete(key)
 	}
 }
@@ -23,6 +23,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	. "knative.dev/pkg/logging/testing"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -100,4 +101,54 @@ var _ = Describe("Pod Metrics", func() {
 		})
 		Expect(found).To(BeFalse())
 	})
+	Context("Registry", func() {
+		var registry *test.MetricsRegistry
+		var registryController controller.Controller
+
+		BeforeEach(func() {
+			registry = test.NewMetricsRegistry()
+			registryController = pod.NewControllerWithRegistry(env.Client, registry)
+		})
+		It("should emit the pod state metrics to the injected registry", func() {
+			p := test.Pod()
+			ExpectApplied(ctx, env.Client, p)
+			ExpectReconcileSucceeded(ctx, registryController, client.ObjectKeyFromObject(p))
+
+			registry.ExpectMetricGaugeValue("karpenter_pods_state", map[string]string{
+				"name":      p.GetName(),
+				"namespace": p.GetNamespace(),
+			}, 1)
+			_, found := FindMetricWithLabelValues("karpenter_pods_state", map[string]string{
+				"name":      p.GetName(),
+				"namespace": p.GetNamespace(),
+			})
+			Expect(found).To(BeFalse())
+		})
+		It("should emit the pod startup time metric to the injected registry", func() {
+			p := test.Pod()
+			p.Status.Phase = v1.PodPending
+			ExpectApplied(ctx, env.Client, p)
+			ExpectReconcileSucceeded(ctx, registryController, client.ObjectKeyFromObject(p))
+			registry.ExpectMetricSummaryCount("karpenter_pods_startup_time_seconds", nil, 0)
+
+			p.Status.Phase = v1.PodRunning
+			p.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue, LastTransitionTime: metav1.Now()}}
+			ExpectApplied(ctx, env.Client, p)
+			ExpectReconcileSucceeded(ctx, registryController, client.ObjectKeyFromObject(p))
+			registry.ExpectMetricSummaryCount("karpenter_pods_startup_time_seconds", nil, 1)
+		})
+		It("should not share metrics between registries", func() {
+			p := test.Pod()
+			ExpectApplied(ctx, env.Client, p)
+			ExpectReconcileSucceeded(ctx, registryController, client.ObjectKeyFromObject(p))
+
+			otherRegistry := test.NewMetricsRegistry()
+			pod.NewControllerWithRegistry(env.Client, otherRegistry)
+			_, found := otherRegistry.FindMetricWithLabelValues("karpenter_pods_state", map[string]string{
+				"name":      p.GetName(),
+				"namespace": p.GetNamespace(),
+			})
+			Expect(found).To(BeFalse())
+		})
+	})
 })
@@ -26,21 +26,26 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/clock"
 	"knative.dev/pkg/logging"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/node/termination/history"
 	"sigs.k8s.io/karpenter/pkg/controllers/node/termination/terminator"
 	terminatorevents "sigs.k8s.io/karpenter/pkg/controllers/node/termination/terminator/events"
 	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/metrics"
 	operatorcontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
 	nodeclaimutil "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
 )
@@ -49,19 +54,25 @@ var _ operatorcontroller.FinalizingTypedController[*v1.Node] = (*Controller)(nil
 
 // Controller for the resource
 type Controller struct {
+	clock         clock.Clock
 	kubeClient    client.Client
 	cloudProvider cloudprovider.CloudProvider
 	terminator    *terminator.Terminator
 	recorder      events.Recorder
+	reschedulings *reschedulings
+	drains        *drains
 }
 
 // NewController constructs a controller instance
-func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, terminator *terminator.Terminator, recorder events.Recorder) operatorcontroller.Controller {
+func NewController(clk clock.Clock, kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, terminator *terminator.Terminator, recorder events.Recorder) operatorcontroller.Controller {
 	return operatorcontroller.Typed[*v1.Node](kubeClient, &Controller{
+		clock:         clk,
 		kubeClient:    kubeClient,
 		cloudProvider: cloudProvider,
 		terminator:    terminator,
 		recorder:      recorder,
+		reschedulings: newReschedulings(),
+		drains:        newDrains(),
 	})
 }
 
@@ -84,7 +95,28 @@ func (c *Controller) Finalize(ctx context.Context, node *v1.Node) (reconcile.Res
 	if err := c.terminator.Taint(ctx, node); err != nil {
 		return reconcile.Result{}, fmt.Errorf("tainting node, %w", err)
 	}
-	if err := c.terminator.Drain(ctx, node); err != nil {
+	// Tainted nodes wait for a slot before they start draining, so that large rollouts don't evict from every node at once.
+	// Nodes that aren't Ready don't wait and release the slot they hold, as their pods can't be drained gracefully anyway
+	// and a node whose instance is gone shouldn't keep the others from draining.
+	if nodeutils.GetCondition(node, v1.NodeReady).Status != v1.ConditionTrue {
+		c.drains.release(node)
+	} else if !c.drains.admit(node, options.FromContext(ctx).MaxConcurrentNodeDrains) {
+		return reconcile.Result{RequeueAfter: 1 * time.Second}, nil
+	}
+	if history.Enabled(ctx) && !history.Terminations.Started(node) {
+		pods, err := nodeutils.GetPods(ctx, c.kubeClient, node)
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("listing pods on node, %w", err)
+		}
+		history.Terminations.Start(ctx, node, len(pods))
+	}
+	// Consolidation only moves pods off of a node when there is capacity for them elsewhere, so the pods of a
+	// consolidated node are evicted one at a time per owner, once the pods evicted before them are Ready elsewhere
+	hold, err := c.reschedulingHold(ctx, node)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if err := c.terminator.Drain(ctx, node, hold); err != nil {
 		if !terminator.IsNodeDrainError(err) {
 			return reconcile.Result{}, fmt.Errorf("draining node, %w", err)
 		}
@@ -104,6 +136,7 @@ func (c *Controller) Finalize(ctx context.Context, node *v1.Node) (reconcile.Res
 		}
 		return reconcile.Result{RequeueAfter: 1 * time.Second}, nil
 	}
+	c.drains.release(node)
 	// Be careful when removing this delete call in the Node termination flow
 	// This delete call is needed so that we ensure that we don't remove the node from the cluster
 	// until the full instance shutdown has taken place
@@ -141,6 +174,7 @@ func (c *Controller) deleteAllNodeClaims(ctx context.Context, node *v1.Node) err
 func (c *Controller) removeFinalizer(ctx context.Context, n *v1.Node) error {
 	stored := n.DeepCopy()
 	controllerutil.RemoveFinalizer(n, v1beta1.TerminationFinalizer)
+	c.forget(n)
 	if !equality.Semantic.DeepEqual(stored, n) {
 		if err := c.kubeClient.Patch(ctx, n, client.StrategicMergeFrom(stored)); err != nil {
 			return client.IgnoreNotFound(fmt.Errorf("patching node, %w", err))
@@ -148,19 +182,44 @@ func (c *Controller) removeFinalizer(ctx context.Context, n *v1.Node) error {
 		metrics.NodesTerminatedCounter.With(prometheus.Labels{
 			metrics.NodePoolLabel: n.Labels[v1beta1.NodePoolLabelKey],
 		}).Inc()
+		// The disruption reason is only set when the node was disrupted by Karpenter and is empty otherwise
+		metrics.NodePoolNodesDeletedCounter.With(prometheus.Labels{
+			metrics.NodePoolLabel:     n.Labels[v1beta1.NodePoolLabelKey],
+			metrics.CapacityTypeLabel: n.Labels[v1beta1.CapacityTypeLabelKey],
+			metrics.ReasonLabel:       n.Annotations[v1beta1.DisruptionReasonAnnotationKey],
+		}).Inc()
 		// We use stored.DeletionTimestamp since the api-server may give back a node after the patch without a deletionTimestamp
 		TerminationSummary.With(prometheus.Labels{
 			metrics.NodePoolLabel: n.Labels[v1beta1.NodePoolLabelKey],
 		}).Observe(time.Since(stored.DeletionTimestamp.Time).Seconds())
+		history.Terminations.Finish(ctx, stored)
 		logging.FromContext(ctx).Infof("deleted node")
 	}
 	return nil
 }
 
+// forget stops tracking the drain of the node, once it's terminated or deleted
+func (c *Controller) forget(n *v1.Node) {
+	c.reschedulings.forget(n)
+	c.drains.forget(n)
+}
+
 func (c *Controller) Builder(_ context.Context, m manager.Manager) operatorcontroller.Builder {
 	return operatorcontroller.Adapt(controllerruntime.
 		NewControllerManagedBy(m).
 		For(&v1.Node{}).
+		// Nodes whose finalizer is removed out of band are deleted without being finalized here, so we stop tracking
+		// their drain when they're deleted to release their slot
+		Watches(
+			&v1.Node{},
+			handler.Funcs{
+				DeleteFunc: func(_ context.Context, e event.DeleteEvent, _ workqueue.RateLimitingInterface) {
+					if n, ok := e.Object.(*v1.Node); ok {
+						c.forget(n)
+					}
+				},
+			},
+		).
 		WithOptions(
 			controller.Options{
 				RateLimiter: workqueue.NewMaxOfRateLimiter(
@@ -0,0 +1,77 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package termination
+
+import (
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// drains bounds the number of nodes that are actively draining at once. Nodes are admitted in the order in which they
+// are first reconciled and hold their slot until they're fully drained, stop being Ready, or are deleted. It's kept in
+// memory, so the nodes that were draining before a restart compete for the slots again.
+type drains struct {
+	mu sync.Mutex
+	// nodes maps the UIDs of the admitted nodes to whether they released their slot
+	nodes map[types.UID]bool
+}
+
+func newDrains() *drains {
+	return &drains{nodes: map[types.UID]bool{}}
+}
+
+// admit returns true if the node may drain, admitting it if there are fewer than limit nodes actively draining. A
+// limit of 0 admits every node.
+func (d *drains) admit(node *v1.Node, limit int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.nodes[node.UID]; ok {
+		return true
+	}
+	if limit > 0 && d.draining() >= limit {
+		return false
+	}
+	d.nodes[node.UID] = false
+	return true
+}
+
+// release frees the node's slot, while still admitting it until it's forgotten
+func (d *drains) release(node *v1.Node) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.nodes[node.UID] = true
+}
+
+// forget stops tracking the node once it's terminated or deleted
+func (d *drains) forget(node *v1.Node) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.nodes, node.UID)
+}
+
+// draining returns the number of nodes that are actively draining. It must be called with the lock held.
+func (d *drains) draining() int {
+	count := 0
+	for _, drained := range d.nodes {
+		if !drained {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,160 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+)
+
+const (
+	// Path is the path on the metrics server that the termination history is served from
+	Path = "/debug/terminations"
+	// StartedTTL is how long the start of a termination is kept for. Nodes that are removed without their termination
+	// finishing, e.g. when their finalizer is removed by hand, are never recorded, so their starts are evicted instead
+	// of being kept forever.
+	StartedTTL = 24 * time.Hour
+)
+
+// Terminations is the history of the nodes that Karpenter has terminated. Like the metrics registry, it's shared
+// across the operator so that it can be served from the metrics server, which must be configured before the
+// controllers are constructed.
+var Terminations = New(StartedTTL)
+
+// Record is a summary of a terminated node and its NodeClaim that's kept for post-mortem debugging
+type Record struct {
+	NodeName     string `json:"nodeName"`
+	ProviderID   string `json:"providerID"`
+	NodePool     string `json:"nodePool,omitempty"`
+	InstanceType string `json:"instanceType,omitempty"`
+	CapacityType string `json:"capacityType,omitempty"`
+	// Reason is the disruption reason if the node was disrupted by Karpenter and is empty otherwise
+	Reason            string        `json:"reason,omitempty"`
+	CreationTimestamp time.Time     `json:"creationTimestamp"`
+	DeletionTimestamp time.Time     `json:"deletionTimestamp"`
+	Lifetime          time.Duration `json:"lifetime"`
+	// Pods is the number of pods that were scheduled to the node when its termination started
+	Pods int `json:"pods"`
+}
+
+// History keeps the records of the most recently terminated nodes. The number of records that are retained is
+// bounded by the termination history size option and recording is disabled when it is zero.
+type History struct {
+	mu      sync.RWMutex
+	records []Record
+	// pods tracks the number of pods that were on each terminating node when its termination started
+	pods *cache.Cache
+}
+
+// New constructs a History that evicts the starts of terminations that haven't finished within the startedTTL
+func New(startedTTL time.Duration) *History {
+	return &History{pods: cache.New(startedTTL, startedTTL/2)}
+}
+
+// Enabled returns true if terminated nodes should be recorded
+func Enabled(ctx context.Context) bool {
+	return options.FromContext(ctx).TerminationHistorySize > 0
+}
+
+// Started returns true if the start of the node's termination has already been recorded
+func (h *History) Started(node *v1.Node) bool {
+	_, ok := h.pods.Get(string(node.UID))
+	return ok
+}
+
+// Start records the number of pods that are on the node when its termination starts
+func (h *History) Start(ctx context.Context, node *v1.Node, pods int) {
+	if !Enabled(ctx) {
+		return
+	}
+	// Add only stores the pod count if the start of the termination hasn't been recorded yet
+	_ = h.pods.Add(string(node.UID), pods, cache.DefaultExpiration)
+}
+
+// Finish adds a record for the terminated node, evicting the oldest record once the history is full
+func (h *History) Finish(ctx context.Context, node *v1.Node) {
+	if !Enabled(ctx) {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	record := Record{
+		NodeName:          node.Name,
+		ProviderID:        node.Spec.ProviderID,
+		NodePool:          node.Labels[v1beta1.NodePoolLabelKey],
+		InstanceType:      node.Labels[v1.LabelInstanceTypeStable],
+		CapacityType:      node.Labels[v1beta1.CapacityTypeLabelKey],
+		Reason:            node.Annotations[v1beta1.DisruptionReasonAnnotationKey],
+		CreationTimestamp: node.CreationTimestamp.Time,
+		DeletionTimestamp: lo.FromPtr(node.DeletionTimestamp).Time,
+		Pods:              h.startedPods(node),
+	}
+	if !record.DeletionTimestamp.IsZero() {
+		record.Lifetime = record.DeletionTimestamp.Sub(record.CreationTimestamp)
+	}
+	h.pods.Delete(string(node.UID))
+
+	size := options.FromContext(ctx).TerminationHistorySize
+	if len(h.records) >= size {
+		// Shift the retained records in place so that the backing array never grows past the history size
+		n := copy(h.records, h.records[len(h.records)-size+1:])
+		h.records = h.records[:n]
+	}
+	h.records = append(h.records, record)
+}
+
+// List returns the retained records, from the most to the least recently terminated node
+func (h *History) List() []Record {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return lo.Reverse(append([]Record{}, h.records...))
+}
+
+// Reset clears the history
+func (h *History) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = nil
+	h.pods.Flush()
+}
+
+// startedPods returns the number of pods that were on the node when its termination started, or 0 if it wasn't recorded
+func (h *History) startedPods(node *v1.Node) int {
+	pods, ok := h.pods.Get(string(node.UID))
+	if !ok {
+		return 0
+	}
+	return pods.(int)
+}
+
+// ServeHTTP serves the retained records as JSON
+func (h *History) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(h.List()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
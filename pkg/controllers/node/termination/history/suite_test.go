@@ -0,0 +1,128 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package history_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	. "knative.dev/pkg/logging/testing"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/controllers/node/termination/history"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+)
+
+var ctx context.Context
+
+func TestHistory(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Termination/History")
+}
+
+var _ = Describe("History", func() {
+	var h *history.History
+	var node *v1.Node
+
+	BeforeEach(func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{TerminationHistorySize: lo.ToPtr(3)}))
+		h = history.New(history.StartedTTL)
+		node = test.Node(test.NodeOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1beta1.NodePoolLabelKey:     "default",
+					v1.LabelInstanceTypeStable:   "default-instance-type",
+					v1beta1.CapacityTypeLabelKey: v1beta1.CapacityTypeSpot,
+				},
+				Annotations: map[string]string{
+					v1beta1.DisruptionReasonAnnotationKey: "drift",
+				},
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+				DeletionTimestamp: lo.ToPtr(metav1.Now()),
+			},
+			ProviderID: test.RandomProviderID(),
+		})
+	})
+
+	It("should record the terminated node", func() {
+		h.Start(ctx, node, 5)
+		Expect(h.Started(node)).To(BeTrue())
+		h.Finish(ctx, node)
+		Expect(h.Started(node)).To(BeFalse())
+
+		records := h.List()
+		Expect(records).To(HaveLen(1))
+		Expect(records[0].NodeName).To(Equal(node.Name))
+		Expect(records[0].ProviderID).To(Equal(node.Spec.ProviderID))
+		Expect(records[0].NodePool).To(Equal("default"))
+		Expect(records[0].InstanceType).To(Equal("default-instance-type"))
+		Expect(records[0].CapacityType).To(Equal(v1beta1.CapacityTypeSpot))
+		Expect(records[0].Reason).To(Equal("drift"))
+		Expect(records[0].Pods).To(Equal(5))
+		Expect(records[0].Lifetime).To(BeNumerically("~", time.Hour, time.Second))
+	})
+	It("should keep the pod count from the start of the termination", func() {
+		h.Start(ctx, node, 5)
+		h.Start(ctx, node, 0)
+		h.Finish(ctx, node)
+		Expect(h.List()[0].Pods).To(Equal(5))
+	})
+	It("should evict the start of a termination that doesn't finish", func() {
+		h = history.New(10 * time.Millisecond)
+		h.Start(ctx, node, 5)
+		Expect(h.Started(node)).To(BeTrue())
+		Eventually(func() bool { return h.Started(node) }).Should(BeFalse())
+	})
+	It("should only retain the most recently terminated nodes", func() {
+		for i := 0; i < 5; i++ {
+			n := node.DeepCopy()
+			n.Name = fmt.Sprintf("node-%d", i)
+			h.Finish(ctx, n)
+		}
+		Expect(lo.Map(h.List(), func(r history.Record, _ int) string { return r.NodeName })).To(Equal([]string{"node-4", "node-3", "node-2"}))
+	})
+	It("should not record terminated nodes when disabled", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{TerminationHistorySize: lo.ToPtr(0)}))
+		h.Start(ctx, node, 5)
+		Expect(h.Started(node)).To(BeFalse())
+		h.Finish(ctx, node)
+		Expect(h.List()).To(BeEmpty())
+	})
+	It("should serve the records as JSON", func() {
+		h.Finish(ctx, node)
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, history.Path, nil))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+
+		var records []history.Record
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &records)).To(Succeed())
+		Expect(records).To(HaveLen(1))
+		Expect(records[0].NodeName).To(Equal(node.Name))
+	})
+})
@@ -0,0 +1,204 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package termination
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
+	podutil "sigs.k8s.io/karpenter/pkg/utils/pod"
+)
+
+// reschedulings tracks the pods that are evicted from consolidated nodes, so that a pod is only evicted once the pods
+// of the same owner that were evicted before it are replaced by Ready pods elsewhere. It's kept in memory, so the
+// nodes that were draining before a restart start tracking their remaining pods from scratch.
+type reschedulings struct {
+	mu    sync.Mutex
+	nodes map[types.UID]*rescheduling
+}
+
+type rescheduling struct {
+	// owners maps the UIDs of the controllers of the pods on the node to the pods they had when they were first seen,
+	// so that the pods they create afterwards are known to be replacements
+	owners map[types.UID]*owner
+	// pods maps the UIDs of the pods that were seen on the node to the UIDs of their controllers
+	pods map[types.UID]types.UID
+	// evicted maps the UIDs of the pods that were evicted from the node to when they were first seen evicted
+	evicted map[types.UID]time.Time
+}
+
+type owner struct {
+	namespace string
+	existing  sets.Set[types.UID]
+}
+
+func newReschedulings() *reschedulings {
+	return &reschedulings{nodes: map[types.UID]*rescheduling{}}
+}
+
+// awaitsRescheduling returns true if the pods of the node shouldn't be evicted until the pods that were evicted before
+// them are Ready elsewhere
+func awaitsRescheduling(ctx context.Context, node *v1.Node) bool {
+	return options.FromContext(ctx).ConsolidationPodReadyTimeout > 0 &&
+		node.Annotations[v1beta1.DisruptionReasonAnnotationKey] == metrics.ConsolidationReason
+}
+
+func (r *reschedulings) get(node *v1.Node) *rescheduling {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rs, ok := r.nodes[node.UID]
+	if !ok {
+		rs = &rescheduling{owners: map[types.UID]*owner{}, pods: map[types.UID]types.UID{}, evicted: map[types.UID]time.Time{}}
+		r.nodes[node.UID] = rs
+	}
+	return rs
+}
+
+func (r *reschedulings) forget(node *v1.Node) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.nodes, node.UID)
+}
+
+// reschedulingHold returns the pods of a consolidated node that shouldn't be evicted yet. Pods are evicted one at a
+// time per owner: a pod is held while a pod of the same owner was evicted from the node and isn't replaced by a Ready
+// pod elsewhere, unless the consolidation pod ready timeout elapsed since the last eviction of the owner. Pods that
+// are owned by the node or by a DaemonSet aren't rescheduled elsewhere, so they're never held.
+func (c *Controller) reschedulingHold(ctx context.Context, node *v1.Node) (func(*v1.Pod) bool, error) {
+	if !awaitsRescheduling(ctx, node) {
+		return nil, nil
+	}
+	pods, err := nodeutils.GetPods(ctx, c.kubeClient, node)
+	if err != nil {
+		return nil, fmt.Errorf("listing pods on node, %w", err)
+	}
+	rs := c.reschedulings.get(node)
+	now := c.clock.Now()
+	// remaining maps the UIDs of the owners to their pods that are still on the node and haven't been evicted
+	remaining := map[types.UID][]*v1.Pod{}
+	remainingUIDs := sets.New[types.UID]()
+	for _, pod := range pods {
+		ref := metav1.GetControllerOf(pod)
+		if ref == nil || podutil.IsOwnedByDaemonSet(pod) || podutil.IsMirror(pod) {
+			continue
+		}
+		if _, ok := rs.owners[ref.UID]; !ok {
+			rs.owners[ref.UID] = &owner{namespace: pod.Namespace}
+		}
+		rs.pods[pod.UID] = ref.UID
+		if podutil.IsTerminating(pod) || podutil.IsTerminal(pod) {
+			continue
+		}
+		remaining[ref.UID] = append(remaining[ref.UID], pod)
+		remainingUIDs.Insert(pod.UID)
+	}
+	evicted := map[types.UID][]time.Time{}
+	for uid, ownerUID := range rs.pods {
+		if remainingUIDs.Has(uid) {
+			continue
+		}
+		if _, ok := rs.evicted[uid]; !ok {
+			rs.evicted[uid] = now
+		}
+		evicted[ownerUID] = append(evicted[ownerUID], rs.evicted[uid])
+	}
+	replacements, err := c.readyReplacements(ctx, node, rs)
+	if err != nil {
+		return nil, err
+	}
+	timeout := options.FromContext(ctx).ConsolidationPodReadyTimeout
+	held := sets.New[types.UID]()
+	for ownerUID, ownerPods := range remaining {
+		awaiting := len(evicted[ownerUID]) > replacements[ownerUID] &&
+			now.Sub(lo.MaxBy(evicted[ownerUID], func(a, b time.Time) bool { return a.After(b) })) < timeout
+		sort.Slice(ownerPods, func(i, j int) bool { return ownerPods[i].Name < ownerPods[j].Name })
+		for i, pod := range ownerPods {
+			if awaiting || i > 0 {
+				held.Insert(pod.UID)
+			}
+		}
+	}
+	if held.Len() > 0 {
+		logging.FromContext(ctx).With("pods", held.Len()).Debugf("waiting on evicted pods to be Ready elsewhere")
+	}
+	return func(p *v1.Pod) bool { return held.Has(p.UID) }, nil
+}
+
+// readyReplacements counts, for each of the tracked owners, the pods they created since they were first seen on the
+// node that are scheduled and Ready on another node. The pods that the owners had when they were first seen are
+// recorded here as well, so that they're never mistaken for replacements.
+func (c *Controller) readyReplacements(ctx context.Context, node *v1.Node, rs *rescheduling) (map[types.UID]int, error) {
+	replacements := map[types.UID]int{}
+	// the owners that weren't listed yet have all of their current pods recorded, as none of them can be a replacement
+	fresh := sets.New[types.UID]()
+	for uid, o := range rs.owners {
+		if o.existing == nil {
+			o.existing = sets.New[types.UID]()
+			fresh.Insert(uid)
+		}
+	}
+	namespaces := sets.New(lo.MapToSlice(rs.owners, func(_ types.UID, o *owner) string { return o.namespace })...)
+	for _, namespace := range namespaces.UnsortedList() {
+		podList := &v1.PodList{}
+		if err := c.kubeClient.List(ctx, podList, client.InNamespace(namespace)); err != nil {
+			return nil, fmt.Errorf("listing pods, %w", err)
+		}
+		for i := range podList.Items {
+			pod := &podList.Items[i]
+			ref := metav1.GetControllerOf(pod)
+			if ref == nil {
+				continue
+			}
+			o, ok := rs.owners[ref.UID]
+			if !ok {
+				continue
+			}
+			if _, tracked := rs.pods[pod.UID]; tracked || fresh.Has(ref.UID) || o.existing.Has(pod.UID) {
+				o.existing.Insert(pod.UID)
+				continue
+			}
+			if pod.Spec.NodeName == node.Name || podutil.IsTerminal(pod) || podutil.IsTerminating(pod) {
+				continue
+			}
+			if podutil.IsScheduled(pod) && isReady(pod) {
+				replacements[ref.UID]++
+			}
+		}
+	}
+	return replacements, nil
+}
+
+func isReady(pod *v1.Pod) bool {
+	return lo.ContainsBy(pod.Status.Conditions, func(c v1.PodCondition) bool {
+		return c.Type == v1.PodReady && c.Status == v1.ConditionTrue
+	})
+}
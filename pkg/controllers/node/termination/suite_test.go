@@ -36,9 +36,11 @@ import (
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
 	"sigs.k8s.io/karpenter/pkg/controllers/node/termination"
+	"sigs.k8s.io/karpenter/pkg/controllers/node/termination/history"
 	"sigs.k8s.io/karpenter/pkg/controllers/node/termination/terminator"
 	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/operator/controller"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/operator/scheme"
 	"sigs.k8s.io/karpenter/pkg/test"
 
@@ -74,7 +76,7 @@ var _ = BeforeSuite(func() {
 	cloudProvider = fake.NewCloudProvider()
 	recorder = test.NewEventRecorder()
 	queue = terminator.NewQueue(env.Client, recorder)
-	terminationController = termination.NewController(env.Client, cloudProvider, terminator.NewTerminator(fakeClock, env.Client, queue), recorder)
+	terminationController = termination.NewController(fakeClock, env.Client, cloudProvider, terminator.NewTerminator(fakeClock, env.Client, queue), recorder)
 })
 
 var _ = AfterSuite(func() {
@@ -86,6 +88,7 @@ var _ = Describe("Termination", func() {
 	var nodeClaim *v1beta1.NodeClaim
 
 	BeforeEach(func() {
+		ctx = options.ToContext(ctx, test.Options())
 		nodeClaim, node = test.NodeClaimAndNode(v1beta1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Finalizers: []string{v1beta1.TerminationFinalizer}}})
 		node.Labels[v1beta1.NodePoolLabelKey] = test.NodePool().Name
 		cloudProvider.CreatedNodeClaims[node.Spec.ProviderID] = nodeClaim
@@ -99,8 +102,10 @@ var _ = Describe("Termination", func() {
 
 		// Reset the metrics collectors
 		metrics.NodesTerminatedCounter.Reset()
+		metrics.NodePoolNodesDeletedCounter.Reset()
 		termination.TerminationSummary.Reset()
 		terminator.EvictionQueueDepth.Set(0)
+		history.Terminations.Reset()
 	})
 
 	Context("Reconciliation", func() {
@@ -224,6 +229,97 @@ var _ = Describe("Termination", func() {
 			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
 			ExpectNotFound(ctx, env.Client, node)
 		})
+		It("should not evict pods that tolerate the NoExecute karpenter disruption taint until their tolerationSeconds elapse", func() {
+			podEvict := test.Pod(test.PodOptions{NodeName: node.Name, ObjectMeta: metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs}})
+			podTolerate := test.Pod(test.PodOptions{
+				NodeName:    node.Name,
+				Tolerations: []v1.Toleration{{Key: v1beta1.DisruptionTaintKey, Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoExecute, TolerationSeconds: lo.ToPtr[int64](300)}},
+				ObjectMeta:  metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs},
+			})
+			ExpectApplied(ctx, env.Client, node, podEvict, podTolerate)
+
+			// Trigger Termination Controller
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+			Expect(queue.Has(podTolerate)).To(BeFalse())
+			ExpectReconcileSucceeded(ctx, queue, client.ObjectKey{})
+
+			// Expect podEvict to be evicting, and delete it
+			EventuallyExpectTerminating(ctx, env.Client, podEvict)
+			ExpectDeleted(ctx, env.Client, podEvict)
+
+			// The node waits on podTolerate while it still tolerates the disruption
+			fakeClock.Step(time.Minute)
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+			Expect(queue.Has(podTolerate)).To(BeFalse())
+			ExpectNodeWithNodeClaimDraining(env.Client, node.Name)
+
+			// Once the tolerationSeconds elapse, podTolerate is evicted
+			fakeClock.Step(5 * time.Minute)
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+			Expect(queue.Has(podTolerate)).To(BeTrue())
+			ExpectReconcileSucceeded(ctx, queue, client.ObjectKey{})
+			EventuallyExpectTerminating(ctx, env.Client, podTolerate)
+			ExpectDeleted(ctx, env.Client, podTolerate)
+
+			// Reconcile to delete node
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+			ExpectNotFound(ctx, env.Client, node)
+		})
+		It("should evict pods that tolerate the karpenter disruption taint once the disruption toleration timeout elapses", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{DisruptionTolerationTimeout: lo.ToPtr(5 * time.Minute)}))
+			podTolerate := test.Pod(test.PodOptions{
+				NodeName:    node.Name,
+				Tolerations: []v1.Toleration{{Key: v1beta1.DisruptionTaintKey, Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoExecute, TolerationSeconds: lo.ToPtr[int64](3600)}},
+				ObjectMeta:  metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs},
+			})
+			ExpectApplied(ctx, env.Client, node, podTolerate)
+
+			// Trigger Termination Controller
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+			Expect(queue.Has(podTolerate)).To(BeFalse())
+
+			// The pod still tolerates the disruption, but the node doesn't wait on it past the timeout
+			fakeClock.Step(6 * time.Minute)
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+			Expect(queue.Has(podTolerate)).To(BeTrue())
+			ExpectReconcileSucceeded(ctx, queue, client.ObjectKey{})
+			EventuallyExpectTerminating(ctx, env.Client, podTolerate)
+		})
+		It("should not hold back pods whose tolerationSeconds are for a different karpenter disruption taint value", func() {
+			podEvict := test.Pod(test.PodOptions{
+				NodeName:    node.Name,
+				Tolerations: []v1.Toleration{{Key: v1beta1.DisruptionTaintKey, Operator: v1.TolerationOpEqual, Value: "other", Effect: v1.TaintEffectNoExecute, TolerationSeconds: lo.ToPtr[int64](300)}},
+				ObjectMeta:  metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs},
+			})
+			ExpectApplied(ctx, env.Client, node, podEvict)
+
+			// Trigger Termination Controller
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+			Expect(queue.Has(podEvict)).To(BeTrue())
+		})
+		It("should evict pods that tolerate the NoExecute karpenter disruption taint without tolerationSeconds", func() {
+			podEvict := test.Pod(test.PodOptions{
+				NodeName:    node.Name,
+				Tolerations: []v1.Toleration{{Key: v1beta1.DisruptionTaintKey, Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoExecute}},
+				ObjectMeta:  metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs},
+			})
+			ExpectApplied(ctx, env.Client, node, podEvict)
+
+			// Trigger Termination Controller
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+			Expect(queue.Has(podEvict)).To(BeTrue())
+			ExpectReconcileSucceeded(ctx, queue, client.ObjectKey{})
+			EventuallyExpectTerminating(ctx, env.Client, podEvict)
+		})
 		It("should evict pods that tolerate the node.kubernetes.io/unschedulable taint", func() {
 			podEvict := test.Pod(test.PodOptions{
 				NodeName:    node.Name,
@@ -621,6 +717,32 @@ var _ = Describe("Termination", func() {
 			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
 			ExpectNotFound(ctx, env.Client, node)
 		})
+		It("should skip draining nodes whose remaining pods are all past their termination grace period", func() {
+			pods := test.Pods(2, test.PodOptions{NodeName: node.Name, ObjectMeta: metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs}})
+			fakeClock.SetTime(time.Now()) // make our fake clock match the pod creation time
+			ExpectApplied(ctx, env.Client, node, pods[0], pods[1])
+
+			// Delete the pods before the node so that they are mid-termination when the node starts draining
+			Expect(env.Client.Delete(ctx, pods[0])).To(Succeed())
+			Expect(env.Client.Delete(ctx, pods[1])).To(Succeed())
+			EventuallyExpectTerminating(ctx, env.Client, pods[0], pods[1])
+
+			// Within the grace period, the node should wait for the pods to terminate
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+			ExpectNodeExists(ctx, env.Client, node.Name)
+			Expect(queue.Has(pods[0])).To(BeFalse())
+			Expect(queue.Has(pods[1])).To(BeFalse())
+
+			// Past the grace period but before the pods are considered stuck, the node should delete without waiting
+			// on or evicting the remaining pods. The deletion timestamps are from etcd which we can't control, so we
+			// reset the time to current time + 40 seconds rather than advancing the clock.
+			fakeClock.SetTime(time.Now().Add(40 * time.Second))
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+			ExpectNotFound(ctx, env.Client, node)
+			Expect(queue.Has(pods[0])).To(BeFalse())
+			Expect(queue.Has(pods[1])).To(BeFalse())
+		})
 		It("should not evict a new pod with the same name using the old pod's eviction queue key", func() {
 			pod := test.Pod(test.PodOptions{
 				NodeName: node.Name,
@@ -675,6 +797,249 @@ var _ = Describe("Termination", func() {
 			}, ReconcilerPropagationTime, RequestInterval).Should(Succeed())
 		})
 	})
+	Context("Concurrent Drains", func() {
+		var drainController controller.Controller
+		var nodes []*v1.Node
+		var pods []*v1.Pod
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{MaxConcurrentNodeDrains: lo.ToPtr(2)}))
+			// the drain slots are tracked in memory, so we use a controller that doesn't share them with other tests
+			drainController = termination.NewController(fakeClock, env.Client, cloudProvider, terminator.NewTerminator(fakeClock, env.Client, queue), recorder)
+			nodes = []*v1.Node{node}
+			for i := 0; i < 2; i++ {
+				nc, n := test.NodeClaimAndNode(v1beta1.NodeClaim{ObjectMeta: metav1.ObjectMeta{Finalizers: []string{v1beta1.TerminationFinalizer}}})
+				n.Labels[v1beta1.NodePoolLabelKey] = node.Labels[v1beta1.NodePoolLabelKey]
+				cloudProvider.CreatedNodeClaims[n.Spec.ProviderID] = nc
+				nodes = append(nodes, n)
+			}
+			pods = lo.Map(nodes, func(n *v1.Node, _ int) *v1.Pod {
+				return test.Pod(test.PodOptions{NodeName: n.Name, ObjectMeta: metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs}})
+			})
+			for i := range nodes {
+				ExpectApplied(ctx, env.Client, nodes[i], pods[i])
+			}
+		})
+		It("should not drain more nodes at once than the max concurrent node drains", func() {
+			for i := range nodes {
+				Expect(env.Client.Delete(ctx, nodes[i])).To(Succeed())
+				nodes[i] = ExpectNodeExists(ctx, env.Client, nodes[i].Name)
+				ExpectReconcileSucceeded(ctx, drainController, client.ObjectKeyFromObject(nodes[i]))
+			}
+			// Every node is tainted, but only the first two are draining
+			for i := range nodes {
+				ExpectNodeWithNodeClaimDraining(env.Client, nodes[i].Name)
+			}
+			Expect(queue.Has(pods[0])).To(BeTrue())
+			Expect(queue.Has(pods[1])).To(BeTrue())
+			Expect(queue.Has(pods[2])).To(BeFalse())
+
+			// The third node keeps waiting while the others are draining
+			nodes[2] = ExpectNodeExists(ctx, env.Client, nodes[2].Name)
+			ExpectReconcileSucceeded(ctx, drainController, client.ObjectKeyFromObject(nodes[2]))
+			Expect(queue.Has(pods[2])).To(BeFalse())
+
+			// Finish draining the first node, which frees up a slot
+			ExpectReconcileSucceeded(ctx, queue, client.ObjectKey{})
+			EventuallyExpectTerminating(ctx, env.Client, pods[0])
+			ExpectDeleted(ctx, env.Client, pods[0])
+			nodes[0] = ExpectNodeExists(ctx, env.Client, nodes[0].Name)
+			ExpectReconcileSucceeded(ctx, drainController, client.ObjectKeyFromObject(nodes[0]))
+			ExpectNotFound(ctx, env.Client, nodes[0])
+
+			nodes[2] = ExpectNodeExists(ctx, env.Client, nodes[2].Name)
+			ExpectReconcileSucceeded(ctx, drainController, client.ObjectKeyFromObject(nodes[2]))
+			Expect(queue.Has(pods[2])).To(BeTrue())
+		})
+		It("should drain nodes that aren't Ready without waiting for a slot", func() {
+			nodes[2].Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionUnknown}}
+			ExpectApplied(ctx, env.Client, nodes[2])
+			for i := range nodes {
+				Expect(env.Client.Delete(ctx, nodes[i])).To(Succeed())
+				nodes[i] = ExpectNodeExists(ctx, env.Client, nodes[i].Name)
+				ExpectReconcileSucceeded(ctx, drainController, client.ObjectKeyFromObject(nodes[i]))
+			}
+			for i := range pods {
+				Expect(queue.Has(pods[i])).To(BeTrue())
+			}
+		})
+		It("should release the slot of a draining node once it stops being Ready", func() {
+			for i := range nodes {
+				Expect(env.Client.Delete(ctx, nodes[i])).To(Succeed())
+				nodes[i] = ExpectNodeExists(ctx, env.Client, nodes[i].Name)
+				ExpectReconcileSucceeded(ctx, drainController, client.ObjectKeyFromObject(nodes[i]))
+			}
+			Expect(queue.Has(pods[2])).To(BeFalse())
+
+			// The first node's kubelet stops reporting while its pod is still waiting to be evicted
+			nodes[0].Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionUnknown}}
+			ExpectApplied(ctx, env.Client, nodes[0])
+			ExpectReconcileSucceeded(ctx, drainController, client.ObjectKeyFromObject(nodes[0]))
+
+			nodes[2] = ExpectNodeExists(ctx, env.Client, nodes[2].Name)
+			ExpectReconcileSucceeded(ctx, drainController, client.ObjectKeyFromObject(nodes[2]))
+			Expect(queue.Has(pods[2])).To(BeTrue())
+		})
+		It("should drain every node at once when the max concurrent node drains isn't set", func() {
+			ctx = options.ToContext(ctx, test.Options())
+			for i := range nodes {
+				Expect(env.Client.Delete(ctx, nodes[i])).To(Succeed())
+				nodes[i] = ExpectNodeExists(ctx, env.Client, nodes[i].Name)
+				ExpectReconcileSucceeded(ctx, drainController, client.ObjectKeyFromObject(nodes[i]))
+			}
+			for i := range pods {
+				Expect(queue.Has(pods[i])).To(BeTrue())
+			}
+		})
+	})
+	Context("Consolidation Pod Readiness", func() {
+		var ownerRefs []metav1.OwnerReference
+		var pods []*v1.Pod
+		var rescheduled *v1.Pod
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ConsolidationPodReadyTimeout: lo.ToPtr(5 * time.Minute)}))
+			node.Annotations = lo.Assign(node.Annotations, map[string]string{v1beta1.DisruptionReasonAnnotationKey: metrics.ConsolidationReason})
+			ownerRefs = []metav1.OwnerReference{{Kind: "ReplicaSet", APIVersion: "appsv1", Name: "rs", UID: "1234567890", Controller: lo.ToPtr(true)}}
+			pods = []*v1.Pod{
+				test.Pod(test.PodOptions{NodeName: node.Name, ObjectMeta: metav1.ObjectMeta{Name: "pod-a", OwnerReferences: ownerRefs}}),
+				test.Pod(test.PodOptions{NodeName: node.Name, ObjectMeta: metav1.ObjectMeta{Name: "pod-b", OwnerReferences: ownerRefs}}),
+			}
+			// the pod that replaces the first evicted pod on another node is slow to start
+			rescheduled = test.Pod(test.PodOptions{
+				NodeName:   "other-node",
+				ObjectMeta: metav1.ObjectMeta{OwnerReferences: ownerRefs},
+				Phase:      v1.PodRunning,
+				Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionFalse}},
+			})
+		})
+		// evictFirst starts terminating the node and evicts the first of its pods
+		evictFirst := func() {
+			GinkgoHelper()
+			ExpectApplied(ctx, env.Client, node, pods[0], pods[1])
+
+			// Trigger Termination Controller
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+			Expect(queue.Has(pods[0])).To(BeTrue())
+			Expect(queue.Has(pods[1])).To(BeFalse())
+			ExpectReconcileSucceeded(ctx, queue, client.ObjectKey{})
+			EventuallyExpectTerminating(ctx, env.Client, pods[0])
+			ExpectDeleted(ctx, env.Client, pods[0])
+		}
+		It("should not evict another pod of the same owner until the evicted pod is Ready elsewhere", func() {
+			evictFirst()
+			ExpectApplied(ctx, env.Client, rescheduled)
+
+			// The first pod is evicted, but the pod that replaces it isn't Ready yet
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+			Expect(queue.Has(pods[1])).To(BeFalse())
+
+			rescheduled.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}
+			ExpectApplied(ctx, env.Client, rescheduled)
+
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+			Expect(queue.Has(pods[1])).To(BeTrue())
+			ExpectReconcileSucceeded(ctx, queue, client.ObjectKey{})
+			EventuallyExpectTerminating(ctx, env.Client, pods[1])
+			ExpectDeleted(ctx, env.Client, pods[1])
+
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+			ExpectNotFound(ctx, env.Client, node)
+		})
+		It("should evict another pod of the same owner once the timeout elapses even if the evicted pod isn't Ready elsewhere", func() {
+			evictFirst()
+			ExpectApplied(ctx, env.Client, rescheduled)
+
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+			Expect(queue.Has(pods[1])).To(BeFalse())
+
+			fakeClock.Step(5 * time.Minute)
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+			Expect(queue.Has(pods[1])).To(BeTrue())
+		})
+		It("should not count the pods that the owner had before the eviction as replacements", func() {
+			// the owner already has a Ready pod elsewhere before the node is drained
+			rescheduled.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}
+			ExpectApplied(ctx, env.Client, rescheduled)
+			evictFirst()
+
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+			Expect(queue.Has(pods[1])).To(BeFalse())
+		})
+		It("should not hold pods of other owners", func() {
+			pods[1].OwnerReferences = []metav1.OwnerReference{{Kind: "ReplicaSet", APIVersion: "appsv1", Name: "other-rs", UID: "0987654321", Controller: lo.ToPtr(true)}}
+			ExpectApplied(ctx, env.Client, node, pods[0], pods[1])
+
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+			Expect(queue.Has(pods[0])).To(BeTrue())
+			Expect(queue.Has(pods[1])).To(BeTrue())
+		})
+		It("should not hold pods for nodes that weren't consolidated", func() {
+			node.Annotations[v1beta1.DisruptionReasonAnnotationKey] = metrics.DriftReason
+			ExpectApplied(ctx, env.Client, node, pods[0], pods[1])
+
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+			Expect(queue.Has(pods[0])).To(BeTrue())
+			Expect(queue.Has(pods[1])).To(BeTrue())
+		})
+		It("should not hold pods when the timeout is disabled", func() {
+			ctx = options.ToContext(ctx, test.Options())
+			ExpectApplied(ctx, env.Client, node, pods[0], pods[1])
+
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+			Expect(queue.Has(pods[0])).To(BeTrue())
+			Expect(queue.Has(pods[1])).To(BeTrue())
+		})
+	})
+	Context("History", func() {
+		It("should record terminated nodes when the termination history is enabled", func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{TerminationHistorySize: lo.ToPtr(10)}))
+			node.Annotations = lo.Assign(node.Annotations, map[string]string{v1beta1.DisruptionReasonAnnotationKey: metrics.DriftReason})
+			pods := test.Pods(2, test.PodOptions{NodeName: node.Name, ObjectMeta: metav1.ObjectMeta{OwnerReferences: defaultOwnerRefs}})
+			ExpectApplied(ctx, env.Client, node, pods[0], pods[1])
+
+			// Trigger Termination Controller
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+			ExpectReconcileSucceeded(ctx, queue, client.ObjectKey{})
+			ExpectReconcileSucceeded(ctx, queue, client.ObjectKey{})
+			EventuallyExpectTerminating(ctx, env.Client, pods[0], pods[1])
+			ExpectDeleted(ctx, env.Client, pods[0], pods[1])
+
+			// Reconcile to delete node
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+			ExpectNotFound(ctx, env.Client, node)
+
+			records := history.Terminations.List()
+			Expect(records).To(HaveLen(1))
+			Expect(records[0].NodeName).To(Equal(node.Name))
+			Expect(records[0].NodePool).To(Equal(node.Labels[v1beta1.NodePoolLabelKey]))
+			Expect(records[0].Reason).To(Equal(metrics.DriftReason))
+			Expect(records[0].Pods).To(Equal(2))
+		})
+		It("should not record terminated nodes when the termination history is disabled", func() {
+			ExpectApplied(ctx, env.Client, node)
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+			ExpectNotFound(ctx, env.Client, node)
+			Expect(history.Terminations.List()).To(BeEmpty())
+		})
+	})
 	Context("Metrics", func() {
 		It("should fire the terminationSummary metric when deleting nodes", func() {
 			ExpectApplied(ctx, env.Client, node)
@@ -696,6 +1061,35 @@ var _ = Describe("Termination", func() {
 			Expect(ok).To(BeTrue())
 			Expect(lo.FromPtr(m.GetCounter().Value)).To(BeNumerically("==", 1))
 		})
+		It("should fire the nodepool nodes deleted counter metric with the disruption reason when deleting nodes", func() {
+			node.Labels = lo.Assign(node.Labels, map[string]string{v1beta1.CapacityTypeLabelKey: v1beta1.CapacityTypeOnDemand})
+			node.Annotations = lo.Assign(node.Annotations, map[string]string{v1beta1.DisruptionReasonAnnotationKey: metrics.DriftReason})
+			ExpectApplied(ctx, env.Client, node)
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+
+			m, ok := FindMetricWithLabelValues("karpenter_nodepool_nodes_deleted_total", map[string]string{
+				"nodepool":      node.Labels[v1beta1.NodePoolLabelKey],
+				"capacity_type": v1beta1.CapacityTypeOnDemand,
+				"reason":        metrics.DriftReason,
+			})
+			Expect(ok).To(BeTrue())
+			Expect(lo.FromPtr(m.GetCounter().Value)).To(BeNumerically("==", 1))
+		})
+		It("should fire the nodepool nodes deleted counter metric without a reason when deleting nodes that weren't disrupted", func() {
+			ExpectApplied(ctx, env.Client, node)
+			Expect(env.Client.Delete(ctx, node)).To(Succeed())
+			node = ExpectNodeExists(ctx, env.Client, node.Name)
+			ExpectReconcileSucceeded(ctx, terminationController, client.ObjectKeyFromObject(node))
+
+			m, ok := FindMetricWithLabelValues("karpenter_nodepool_nodes_deleted_total", map[string]string{
+				"nodepool": node.Labels[v1beta1.NodePoolLabelKey],
+				"reason":   "",
+			})
+			Expect(ok).To(BeTrue())
+			Expect(lo.FromPtr(m.GetCounter().Value)).To(BeNumerically("==", 1))
+		})
 		It("should update the eviction queueDepth metric when reconciling pods", func() {
 			minAvailable := intstr.FromInt32(0)
 			labelSelector := map[string]string{test.RandomName(): test.RandomName()}
@@ -26,10 +26,13 @@ import (
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/util/workqueue"
 	"knative.dev/pkg/logging"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -81,8 +84,9 @@ type Queue struct {
 	mu  sync.Mutex
 	set sets.Set[QueueKey]
 
-	kubeClient client.Client
-	recorder   events.Recorder
+	kubeClient           client.Client
+	recorder             events.Recorder
+	evictionGroupVersion schema.GroupVersion
 }
 
 func NewQueue(kubeClient client.Client, recorder events.Recorder) *Queue {
@@ -91,6 +95,7 @@ func NewQueue(kubeClient client.Client, recorder events.Recorder) *Queue {
 		set:                   sets.New[QueueKey](),
 		kubeClient:            kubeClient,
 		recorder:              recorder,
+		evictionGroupVersion:  policyv1.SchemeGroupVersion,
 	}
 	return queue
 }
@@ -99,10 +104,55 @@ func (q *Queue) Name() string {
 	return "eviction-queue"
 }
 
-func (q *Queue) Builder(_ context.Context, m manager.Manager) controller.Builder {
+func (q *Queue) Builder(ctx context.Context, m manager.Manager) controller.Builder {
+	// Resolve the Eviction API version that the apiserver serves once at startup so that drain works against
+	// clusters that still only serve policy/v1beta1
+	if discoveryClient, err := discovery.NewDiscoveryClientForConfig(m.GetConfig()); err != nil {
+		logging.FromContext(ctx).Errorf("creating discovery client, falling back to %s eviction, %s", q.evictionGroupVersion, err)
+	} else {
+		q.SetEvictionGroupVersion(DiscoverEvictionGroupVersion(ctx, discoveryClient))
+	}
 	return controller.NewSingletonManagedBy(m)
 }
 
+// SetEvictionGroupVersion overrides the API group version that the Queue uses when creating evictions
+func (q *Queue) SetEvictionGroupVersion(gv schema.GroupVersion) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.evictionGroupVersion = gv
+}
+
+// EvictionGroupVersion returns the API group version that the Queue uses when creating evictions
+func (q *Queue) EvictionGroupVersion() schema.GroupVersion {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.evictionGroupVersion
+}
+
+// DiscoverEvictionGroupVersion returns the API group version of the pods/eviction subresource advertised by the
+// apiserver, mirroring the detection used by "kubectl drain". If discovery fails or the apiserver doesn't advertise a
+// supported version, policy/v1 is returned.
+func DiscoverEvictionGroupVersion(ctx context.Context, discoveryClient discovery.ServerResourcesInterface) schema.GroupVersion {
+	resourceList, err := discoveryClient.ServerResourcesForGroupVersion(v1.SchemeGroupVersion.String())
+	if err != nil {
+		logging.FromContext(ctx).Errorf("discovering eviction api version, falling back to %s, %s", policyv1.SchemeGroupVersion, err)
+		return policyv1.SchemeGroupVersion
+	}
+	gvs := sets.New[schema.GroupVersion]()
+	for _, resource := range resourceList.APIResources {
+		if resource.Name == "pods/eviction" && resource.Kind == "Eviction" && resource.Group != "" && resource.Version != "" {
+			gvs.Insert(schema.GroupVersion{Group: resource.Group, Version: resource.Version})
+		}
+	}
+	// Prefer policy/v1 when it is served, only falling back to policy/v1beta1 when it is the only version available
+	if !gvs.Has(policyv1.SchemeGroupVersion) && gvs.Has(policyv1beta1.SchemeGroupVersion) {
+		return policyv1beta1.SchemeGroupVersion
+	}
+	return policyv1.SchemeGroupVersion
+}
+
 // Add adds pods to the Queue
 func (q *Queue) Add(pods ...*v1.Pod) {
 	q.mu.Lock()
@@ -157,13 +207,7 @@ func (q *Queue) Evict(ctx context.Context, key QueueKey) bool {
 	ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("pod", key.NamespacedName))
 	if err := q.kubeClient.SubResource("eviction").Create(ctx,
 		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name}},
-		&policyv1.Eviction{
-			DeleteOptions: &metav1.DeleteOptions{
-				Preconditions: &metav1.Preconditions{
-					UID: lo.ToPtr(key.UID),
-				},
-			},
-		}); err != nil {
+		q.newEviction(key)); err != nil {
 		// status codes for the eviction API are defined here:
 		// https://kubernetes.io/docs/concepts/scheduling-eviction/api-eviction/#how-api-initiated-eviction-works
 		if apierrors.IsNotFound(err) || apierrors.IsConflict(err) {
@@ -187,10 +231,24 @@ func (q *Queue) Evict(ctx context.Context, key QueueKey) bool {
 	return true
 }
 
+// newEviction constructs an eviction for the pod using the Eviction API version that the Queue is configured with
+func (q *Queue) newEviction(key QueueKey) client.Object {
+	deleteOptions := &metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{
+			UID: lo.ToPtr(key.UID),
+		},
+	}
+	if q.EvictionGroupVersion() == policyv1beta1.SchemeGroupVersion {
+		return &policyv1beta1.Eviction{DeleteOptions: deleteOptions}
+	}
+	return &policyv1.Eviction{DeleteOptions: deleteOptions}
+}
+
 func (q *Queue) Reset() {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
 	q.RateLimitingInterface = workqueue.NewRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(evictionQueueBaseDelay, evictionQueueMaxDelay))
 	q.set = sets.New[QueueKey]()
+	q.evictionGroupVersion = policyv1.SchemeGroupVersion
 }
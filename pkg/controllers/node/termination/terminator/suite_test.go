@@ -26,9 +26,13 @@ import (
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/uuid"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
 	. "knative.dev/pkg/logging/testing"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -154,4 +158,49 @@ var _ = Describe("Eviction/Queue", func() {
 			}
 		})
 	})
+	Context("Eviction API Version", func() {
+		var discoveryClient *fakediscovery.FakeDiscovery
+		evictionResource := func(gv schema.GroupVersion) metav1.APIResource {
+			return metav1.APIResource{Name: "pods/eviction", Kind: "Eviction", Group: gv.Group, Version: gv.Version}
+		}
+		BeforeEach(func() {
+			discoveryClient = &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+		})
+		It("should use policy/v1beta1 when it is the only eviction version advertised", func() {
+			discoveryClient.Resources = []*metav1.APIResourceList{
+				{
+					GroupVersion: v1.SchemeGroupVersion.String(),
+					APIResources: []metav1.APIResource{{Name: "pods", Kind: "Pod"}, evictionResource(policyv1beta1.SchemeGroupVersion)},
+				},
+			}
+			Expect(terminator.DiscoverEvictionGroupVersion(ctx, discoveryClient)).To(Equal(policyv1beta1.SchemeGroupVersion))
+		})
+		It("should prefer policy/v1 when both eviction versions are advertised", func() {
+			discoveryClient.Resources = []*metav1.APIResourceList{
+				{
+					GroupVersion: v1.SchemeGroupVersion.String(),
+					APIResources: []metav1.APIResource{evictionResource(policyv1beta1.SchemeGroupVersion), evictionResource(policyv1.SchemeGroupVersion)},
+				},
+			}
+			Expect(terminator.DiscoverEvictionGroupVersion(ctx, discoveryClient)).To(Equal(policyv1.SchemeGroupVersion))
+		})
+		It("should fallback to policy/v1 when the eviction subresource isn't advertised", func() {
+			discoveryClient.Resources = []*metav1.APIResourceList{
+				{
+					GroupVersion: v1.SchemeGroupVersion.String(),
+					APIResources: []metav1.APIResource{{Name: "pods", Kind: "Pod"}},
+				},
+			}
+			Expect(terminator.DiscoverEvictionGroupVersion(ctx, discoveryClient)).To(Equal(policyv1.SchemeGroupVersion))
+		})
+		It("should fallback to policy/v1 when discovery fails", func() {
+			Expect(terminator.DiscoverEvictionGroupVersion(ctx, discoveryClient)).To(Equal(policyv1.SchemeGroupVersion))
+		})
+		It("should evict pods using the policy/v1beta1 eviction API", func() {
+			queue.SetEvictionGroupVersion(policyv1beta1.SchemeGroupVersion)
+			ExpectApplied(ctx, env.Client, pod)
+			Expect(queue.Evict(ctx, terminator.NewQueueKey(pod))).To(BeTrue())
+			Expect(recorder.Calls("Evicted")).To(Equal(1))
+		})
+	})
 })
@@ -19,6 +19,7 @@ package terminator
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
@@ -30,6 +31,7 @@ import (
 	nodeutil "sigs.k8s.io/karpenter/pkg/utils/node"
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	podutil "sigs.k8s.io/karpenter/pkg/utils/pod"
 )
 
@@ -77,27 +79,34 @@ func (t *Terminator) Taint(ctx context.Context, node *v1.Node) error {
 	return nil
 }
 
-// Drain evicts pods from the node and returns true when all pods are evicted
+// Drain evicts pods from the node and returns true when all pods are evicted. Pods for which hold returns true aren't
+// evicted yet, and keep the node from being drained.
 // https://kubernetes.io/docs/concepts/architecture/nodes/#graceful-node-shutdown
-func (t *Terminator) Drain(ctx context.Context, node *v1.Node) error {
+func (t *Terminator) Drain(ctx context.Context, node *v1.Node, hold func(*v1.Pod) bool) error {
 	pods, err := nodeutil.GetPods(ctx, t.kubeClient, node)
 	if err != nil {
 		return fmt.Errorf("listing pods on node, %w", err)
 	}
 	// evictablePods are pods that aren't yet terminating are eligible to have the eviction API called against them
 	evictablePods := lo.Filter(pods, func(p *v1.Pod, _ int) bool { return podutil.IsEvictable(p) })
-	t.Evict(evictablePods)
+	t.Evict(ctx, node, evictablePods, hold)
 
 	// podsWaitingEvictionCount are  the number of pods that either haven't had eviction called against them yet
 	// or are still actively terminated and haven't exceeded their termination grace period yet
-	podsWaitingEvictionCount := lo.CountBy(pods, func(p *v1.Pod) bool { return podutil.IsWaitingEviction(p, t.clock) })
-	if podsWaitingEvictionCount > 0 {
-		return NewNodeDrainError(fmt.Errorf("%d pods are waiting to be evicted", len(pods)))
+	podsWaitingEviction := lo.Filter(pods, func(p *v1.Pod, _ int) bool { return podutil.IsWaitingEviction(p, t.clock) })
+	if len(podsWaitingEviction) == 0 {
+		return nil
 	}
-	return nil
+	// If every remaining pod is already terminating and has exceeded its termination grace period, there's nothing
+	// left for the drain to do, so we move on to deleting the instance rather than waiting out the stuck buffer
+	if lo.EveryBy(podsWaitingEviction, func(p *v1.Pod) bool { return podutil.IsPastTerminationGracePeriod(p, t.clock) }) {
+		logging.FromContext(ctx).With("pods", len(podsWaitingEviction)).Debugf("skipping drain, all remaining pods are past their termination grace period")
+		return nil
+	}
+	return NewNodeDrainError(fmt.Errorf("%d pods are waiting to be evicted", len(podsWaitingEviction)))
 }
 
-func (t *Terminator) Evict(pods []*v1.Pod) {
+func (t *Terminator) Evict(ctx context.Context, node *v1.Node, pods []*v1.Pod, hold func(*v1.Pod) bool) {
 	// 1. Prioritize noncritical pods, non-daemon pods https://kubernetes.io/docs/concepts/architecture/nodes/#graceful-node-shutdown
 	var criticalNonDaemon, criticalDaemon, nonCriticalNonDaemon, nonCriticalDaemon []*v1.Pod
 	for _, pod := range pods {
@@ -120,13 +129,38 @@ func (t *Terminator) Evict(pods []*v1.Pod) {
 	// b. non-critical daemonsets
 	// c. critical non-daemonsets
 	// d. critical daemonsets
+	// Pods that still tolerate the disruption of the node or that are held are held back, but keep their group from being skipped
 	if len(nonCriticalNonDaemon) != 0 {
-		t.evictionQueue.Add(nonCriticalNonDaemon...)
+		t.evictionQueue.Add(t.releasable(ctx, node, nonCriticalNonDaemon, hold)...)
 	} else if len(nonCriticalDaemon) != 0 {
-		t.evictionQueue.Add(nonCriticalDaemon...)
+		t.evictionQueue.Add(t.releasable(ctx, node, nonCriticalDaemon, hold)...)
 	} else if len(criticalNonDaemon) != 0 {
-		t.evictionQueue.Add(criticalNonDaemon...)
+		t.evictionQueue.Add(t.releasable(ctx, node, criticalNonDaemon, hold)...)
 	} else if len(criticalDaemon) != 0 {
-		t.evictionQueue.Add(criticalDaemon...)
+		t.evictionQueue.Add(t.releasable(ctx, node, criticalDaemon, hold)...)
+	}
+}
+
+// releasable filters out the pods that are held or that still tolerate the disruption of the node
+func (t *Terminator) releasable(ctx context.Context, node *v1.Node, pods []*v1.Pod, hold func(*v1.Pod) bool) []*v1.Pod {
+	pods = t.pastToleration(ctx, node, pods)
+	if hold == nil {
+		return pods
 	}
+	return lo.Reject(pods, func(p *v1.Pod, _ int) bool { return hold(p) })
+}
+
+// pastToleration filters out the pods that tolerate the disruption taint for a tolerationSeconds that hasn't elapsed
+// yet. Like the NoExecute taint manager, the toleration is counted from when the node started terminating, and pods
+// are only held back for up to the disruption toleration timeout.
+func (t *Terminator) pastToleration(ctx context.Context, node *v1.Node, pods []*v1.Pod) []*v1.Pod {
+	if node.DeletionTimestamp.IsZero() {
+		return pods
+	}
+	timeout := options.FromContext(ctx).DisruptionTolerationTimeout
+	return lo.Reject(pods, func(p *v1.Pod, _ int) bool {
+		seconds, ok := podutil.DisruptionTolerationSeconds(p)
+		toleration := lo.Min([]time.Duration{time.Duration(seconds) * time.Second, timeout})
+		return ok && t.clock.Now().Before(node.DeletionTimestamp.Add(toleration))
+	})
 }
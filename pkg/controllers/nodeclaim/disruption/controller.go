@@ -19,6 +19,7 @@ package disruption
 import (
 	"context"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/multierr"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
@@ -26,6 +27,8 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/clock"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/logging"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -35,6 +38,7 @@ import (
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/metrics"
 	operatorcontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
 	nodeclaimutil "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
 	"sigs.k8s.io/karpenter/pkg/utils/result"
@@ -52,9 +56,11 @@ type Controller struct {
 	kubeClient    client.Client
 	cloudProvider cloudprovider.CloudProvider
 
-	drift      *Drift
-	expiration *Expiration
-	emptiness  *Emptiness
+	drift       *Drift
+	expiration  *Expiration
+	emptiness   *Emptiness
+	maintenance *Maintenance
+	unhealthy   *Unhealthy
 }
 
 // NewController constructs a nodeclaim disruption controller
@@ -64,7 +70,9 @@ func NewController(clk clock.Clock, kubeClient client.Client, cluster *state.Clu
 		cloudProvider: cloudProvider,
 		drift:         &Drift{cloudProvider: cloudProvider},
 		expiration:    &Expiration{kubeClient: kubeClient, clock: clk},
-		emptiness:     &Emptiness{kubeClient: kubeClient, cluster: cluster, clock: clk},
+		emptiness:     &Emptiness{kubeClient: kubeClient, cluster: cluster, cloudProvider: cloudProvider, clock: clk},
+		maintenance:   &Maintenance{cloudProvider: cloudProvider, clock: clk},
+		unhealthy:     &Unhealthy{kubeClient: kubeClient, cloudProvider: cloudProvider, clock: clk},
 	})
 }
 
@@ -89,6 +97,8 @@ func (c *Controller) Reconcile(ctx context.Context, nodeClaim *v1beta1.NodeClaim
 		c.expiration,
 		c.drift,
 		c.emptiness,
+		c.maintenance,
+		c.unhealthy,
 	}
 	for _, reconciler := range reconcilers {
 		res, err := reconciler.Reconcile(ctx, nodePool, nodeClaim)
@@ -109,6 +119,14 @@ func (c *Controller) Reconcile(ctx context.Context, nodeClaim *v1beta1.NodeClaim
 	if errs != nil {
 		return reconcile.Result{}, errs
 	}
+	// Drift was re-evaluated above, so a forced drift check is complete
+	if _, ok := nodeClaim.Annotations[v1beta1.ForceDriftCheckAnnotationKey]; ok {
+		stored = nodeClaim.DeepCopy()
+		delete(nodeClaim.Annotations, v1beta1.ForceDriftCheckAnnotationKey)
+		if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); err != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(err)
+		}
+	}
 	return result.Min(results...), nil
 }
 
@@ -128,6 +146,10 @@ func (c *Controller) Builder(_ context.Context, m manager.Manager) operatorcontr
 		Watches(
 			&v1.Pod{},
 			nodeclaimutil.PodEventHandler(c.kubeClient),
+		).
+		Watches(
+			&v1.Node{},
+			nodeclaimutil.NodeEventHandler(c.kubeClient),
 		)
 	for _, ncGVK := range c.cloudProvider.GetSupportedNodeClasses() {
 		nodeclass := &unstructured.Unstructured{}
@@ -140,3 +162,27 @@ func (c *Controller) Builder(_ context.Context, m manager.Manager) operatorcontr
 
 	return operatorcontroller.Adapt(builder)
 }
+
+// clearDisruptionCondition removes a disruption status condition from the NodeClaim, logging why if the NodeClaim had it
+func clearDisruptionCondition(ctx context.Context, nodeClaim *v1beta1.NodeClaim, conditionType apis.ConditionType, why string) {
+	if nodeClaim.StatusConditions().GetCondition(conditionType) == nil {
+		return
+	}
+	_ = nodeClaim.StatusConditions().ClearCondition(conditionType)
+	logging.FromContext(ctx).Debugf("removing %s status condition, %s", conditionType, why)
+}
+
+// markDisruptionCondition sets a disruption status condition on the NodeClaim. The NodeClaim is counted as disrupted for
+// the reason when it didn't already have the condition.
+func markDisruptionCondition(ctx context.Context, nodeClaim *v1beta1.NodeClaim, condition apis.Condition, reason string) {
+	hasCondition := nodeClaim.StatusConditions().GetCondition(condition.Type) != nil
+	nodeClaim.StatusConditions().SetCondition(condition)
+	if hasCondition {
+		return
+	}
+	logging.FromContext(ctx).Debugf("marking %s", condition.Type)
+	metrics.NodeClaimsDisruptedCounter.With(prometheus.Labels{
+		metrics.TypeLabel:     reason,
+		metrics.NodePoolLabel: nodeClaim.Labels[v1beta1.NodePoolLabelKey],
+	}).Inc()
+}
@@ -39,6 +39,7 @@ import (
 const (
 	NodePoolDrifted     cloudprovider.DriftReason = "NodePoolDrifted"
 	RequirementsDrifted cloudprovider.DriftReason = "RequirementsDrifted"
+	InstanceTypeRetired cloudprovider.DriftReason = "InstanceTypeRetired"
 )
 
 // Drift is a nodeclaim sub-controller that adds or removes status conditions on drifted nodeclaims
@@ -51,7 +52,9 @@ func (d *Drift) Reconcile(ctx context.Context, nodePool *v1beta1.NodePool, nodeC
 
 	// From here there are three scenarios to handle:
 	// 1. If drift is not enabled but the NodeClaim is drifted, remove the status condition
-	if !options.FromContext(ctx).FeatureGates.Drift {
+	// A malformed feature gates annotation is reported by the disruption controller, so the global gates are used here
+	gates, _ := options.NodePoolFeatureGates(ctx, nodePool)
+	if !gates.Drift {
 		_ = nodeClaim.StatusConditions().ClearCondition(v1beta1.Drifted)
 		if hasDriftedCondition {
 			logging.FromContext(ctx).Debugf("removing drift status condition, drift has been disabled")
@@ -108,6 +111,9 @@ func (d *Drift) isDrifted(ctx context.Context, nodePool *v1beta1.NodePool, nodeC
 	}); reason != "" {
 		return reason, nil
 	}
+	if reason, err := d.isInstanceTypeRetired(ctx, nodePool, nodeClaim); err != nil || reason != "" {
+		return reason, err
+	}
 	driftedReason, err := d.cloudProvider.IsDrifted(ctx, nodeClaim)
 	if err != nil {
 		return "", err
@@ -133,6 +139,25 @@ func areStaticFieldsDrifted(nodePool *v1beta1.NodePool, nodeClaim *v1beta1.NodeC
 	return lo.Ternary(nodePoolHash != nodeClaimHash, NodePoolDrifted, "")
 }
 
+// isInstanceTypeRetired checks if the CloudProvider no longer offers the NodeClaim's instance type to the NodePool, or
+// has deprecated it. If no instance types are resolved at all, we can't tell retirement apart from a misconfiguration
+// so the NodeClaim isn't considered drifted.
+func (d *Drift) isInstanceTypeRetired(ctx context.Context, nodePool *v1beta1.NodePool, nodeClaim *v1beta1.NodeClaim) (cloudprovider.DriftReason, error) {
+	instanceTypeName, ok := nodeClaim.Labels[v1.LabelInstanceTypeStable]
+	if !ok {
+		return "", nil
+	}
+	instanceTypes, err := d.cloudProvider.GetInstanceTypes(ctx, nodePool)
+	if err != nil {
+		return "", fmt.Errorf("getting instance types, %w", err)
+	}
+	if len(instanceTypes) == 0 {
+		return "", nil
+	}
+	instanceType, ok := lo.Find(instanceTypes, func(it *cloudprovider.InstanceType) bool { return it.Name == instanceTypeName })
+	return lo.Ternary(!ok || instanceType.Deprecated, InstanceTypeRetired, ""), nil
+}
+
 func areRequirementsDrifted(nodePool *v1beta1.NodePool, nodeClaim *v1beta1.NodeClaim) cloudprovider.DriftReason {
 	nodepoolReq := scheduling.NewNodeSelectorRequirementsWithMinValues(nodePool.Spec.Template.Spec.Requirements...)
 	nodeClaimReq := scheduling.NewLabelRequirements(nodeClaim.Labels)
@@ -30,6 +30,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/utils/node"
@@ -38,9 +39,10 @@ import (
 
 // Emptiness is a nodeclaim sub-controller that adds or removes status conditions on empty nodeclaims based on TTLSecondsAfterEmpty
 type Emptiness struct {
-	kubeClient client.Client
-	cluster    *state.Cluster
-	clock      clock.Clock
+	kubeClient    client.Client
+	cluster       *state.Cluster
+	cloudProvider cloudprovider.CloudProvider
+	clock         clock.Clock
 }
 
 //nolint:gocyclo
@@ -67,7 +69,7 @@ func (e *Emptiness) Reconcile(ctx context.Context, nodePool *v1beta1.NodePool, n
 		return reconcile.Result{}, nil
 	}
 	// Get the node to check for pods scheduled to it
-	n, err := nodeclaimutil.NodeForNodeClaim(ctx, e.kubeClient, nodeClaim)
+	n, err := nodeclaimutil.NodeForNodeClaim(ctx, e.kubeClient, e.cloudProvider, nodeClaim)
 	if err != nil {
 		// 3. If Node mapping doesn't exist, remove the emptiness status condition
 		if nodeclaimutil.IsDuplicateNodeError(err) || nodeclaimutil.IsNodeNotFoundError(err) {
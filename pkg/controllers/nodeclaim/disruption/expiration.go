@@ -19,11 +19,9 @@ package disruption
 import (
 	"context"
 
-	"github.com/prometheus/client_golang/prometheus"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/utils/clock"
 	"knative.dev/pkg/apis"
-	"knative.dev/pkg/logging"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
@@ -38,40 +36,31 @@ type Expiration struct {
 }
 
 func (e *Expiration) Reconcile(ctx context.Context, nodePool *v1beta1.NodePool, nodeClaim *v1beta1.NodeClaim) (reconcile.Result, error) {
-	hasExpiredCondition := nodeClaim.StatusConditions().GetCondition(v1beta1.Expired) != nil
-
-	// From here there are three scenarios to handle:
+	// From here there are four scenarios to handle:
 	// 1. If ExpireAfter is not configured, remove the expired status condition
 	if nodePool.Spec.Disruption.ExpireAfter.Duration == nil {
-		_ = nodeClaim.StatusConditions().ClearCondition(v1beta1.Expired)
-		if hasExpiredCondition {
-			logging.FromContext(ctx).Debugf("removing expiration status condition, expiration has been disabled")
-		}
+		clearDisruptionCondition(ctx, nodeClaim, v1beta1.Expired, "expiration has been disabled")
+		return reconcile.Result{}, nil
+	}
+	// 2. If expiration is paused on the NodePool, remove the expired status condition. The NodeClaim is reconciled
+	// again when the annotation is removed from the NodePool.
+	if _, ok := nodePool.Annotations[v1beta1.ExpirationPausedAnnotationKey]; ok {
+		clearDisruptionCondition(ctx, nodeClaim, v1beta1.Expired, "expiration is paused")
 		return reconcile.Result{}, nil
 	}
 	expirationTime := nodeClaim.CreationTimestamp.Add(*nodePool.Spec.Disruption.ExpireAfter.Duration)
-	// 2. If the NodeClaim isn't expired, remove the status condition.
+	// 3. If the NodeClaim isn't expired, remove the status condition.
 	if e.clock.Now().Before(expirationTime) {
-		_ = nodeClaim.StatusConditions().ClearCondition(v1beta1.Expired)
-		if hasExpiredCondition {
-			logging.FromContext(ctx).Debugf("removing expired status condition, not expired")
-		}
+		clearDisruptionCondition(ctx, nodeClaim, v1beta1.Expired, "not expired")
 		// If the NodeClaim isn't expired and doesn't have the status condition, return.
 		// Use t.Sub(clock.Now()) instead of time.Until() to ensure we're using the injected clock.
 		return reconcile.Result{RequeueAfter: expirationTime.Sub(e.clock.Now())}, nil
 	}
-	// 3. Otherwise, if the NodeClaim is expired, but doesn't have the status condition, add it.
-	nodeClaim.StatusConditions().SetCondition(apis.Condition{
+	// 4. Otherwise, if the NodeClaim is expired, but doesn't have the status condition, add it.
+	markDisruptionCondition(ctx, nodeClaim, apis.Condition{
 		Type:     v1beta1.Expired,
 		Status:   v1.ConditionTrue,
 		Severity: apis.ConditionSeverityWarning,
-	})
-	if !hasExpiredCondition {
-		logging.FromContext(ctx).Debugf("marking expired")
-		metrics.NodeClaimsDisruptedCounter.With(prometheus.Labels{
-			metrics.TypeLabel:     metrics.ExpirationReason,
-			metrics.NodePoolLabel: nodeClaim.Labels[v1beta1.NodePoolLabelKey],
-		}).Inc()
-	}
+	}, metrics.ExpirationReason)
 	return reconcile.Result{}, nil
 }
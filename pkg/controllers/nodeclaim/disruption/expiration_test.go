@@ -75,6 +75,35 @@ var _ = Describe("Expiration", func() {
 		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
 		Expect(nodeClaim.StatusConditions().GetCondition(v1beta1.Expired)).To(BeNil())
 	})
+	It("should remove the status condition from the NodeClaims when expiration is paused", func() {
+		nodePool.Spec.Disruption.ExpireAfter.Duration = lo.ToPtr(time.Second * 30)
+		nodePool.Annotations = map[string]string{v1beta1.ExpirationPausedAnnotationKey: "true"}
+		nodeClaim.StatusConditions().MarkTrue(v1beta1.Expired)
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+
+		fakeClock.Step(60 * time.Second)
+		ExpectReconcileSucceeded(ctx, nodeClaimDisruptionController, client.ObjectKeyFromObject(nodeClaim))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().GetCondition(v1beta1.Expired)).To(BeNil())
+	})
+	It("should mark NodeClaims as expired once expiration is resumed", func() {
+		nodePool.Spec.Disruption.ExpireAfter.Duration = lo.ToPtr(time.Second * 30)
+		nodePool.Annotations = map[string]string{v1beta1.ExpirationPausedAnnotationKey: "true"}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+
+		fakeClock.Step(60 * time.Second)
+		ExpectReconcileSucceeded(ctx, nodeClaimDisruptionController, client.ObjectKeyFromObject(nodeClaim))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().GetCondition(v1beta1.Expired)).To(BeNil())
+
+		delete(nodePool.Annotations, v1beta1.ExpirationPausedAnnotationKey)
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectReconcileSucceeded(ctx, nodeClaimDisruptionController, client.ObjectKeyFromObject(nodeClaim))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().GetCondition(v1beta1.Expired).IsTrue()).To(BeTrue())
+	})
 	It("should mark NodeClaims as expired", func() {
 		nodePool.Spec.Disruption.ExpireAfter.Duration = lo.ToPtr(time.Second * 30)
 		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
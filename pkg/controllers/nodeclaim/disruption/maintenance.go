@@ -0,0 +1,84 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruption
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+)
+
+// Maintenance is a nodeclaim sub-controller that adds or removes status conditions on nodeclaims based on the
+// maintenance events that the cloud provider has scheduled for their instances. Only the maintenance events that begin
+// within the maintenance lead time are considered, so that nodes aren't replaced long before their maintenance.
+type Maintenance struct {
+	cloudProvider cloudprovider.CloudProvider
+	clock         clock.Clock
+}
+
+func (m *Maintenance) Reconcile(ctx context.Context, _ *v1beta1.NodePool, nodeClaim *v1beta1.NodeClaim) (reconcile.Result, error) {
+	// From here there are five scenarios to handle:
+	// 1. If the CloudProvider doesn't signal maintenance, remove the maintenance status condition
+	provider, ok := cloudprovider.As[cloudprovider.MaintenanceEventsProvider](m.cloudProvider)
+	if !ok {
+		clearDisruptionCondition(ctx, nodeClaim, v1beta1.MaintenanceScheduled, "cloud provider doesn't signal maintenance")
+		return reconcile.Result{}, nil
+	}
+	// 2. If NodeClaim is not launched, remove the maintenance status condition
+	if launchCond := nodeClaim.StatusConditions().GetCondition(v1beta1.Launched); launchCond == nil || launchCond.IsFalse() {
+		clearDisruptionCondition(ctx, nodeClaim, v1beta1.MaintenanceScheduled, "isn't launched")
+		return reconcile.Result{}, nil
+	}
+	maintenanceEvents, err := provider.MaintenanceEvents(ctx, nodeClaim)
+	if err != nil {
+		return reconcile.Result{}, cloudprovider.IgnoreNodeClaimNotFoundError(fmt.Errorf("getting maintenance events, %w", err))
+	}
+	// 3. If the NodeClaim doesn't have any scheduled maintenance, but has the status condition, remove it.
+	if len(maintenanceEvents) == 0 {
+		clearDisruptionCondition(ctx, nodeClaim, v1beta1.MaintenanceScheduled, "no maintenance is scheduled")
+		return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+	}
+	event := lo.MinBy(maintenanceEvents, func(a, b cloudprovider.MaintenanceEvent) bool { return a.NotBefore.Before(b.NotBefore) })
+	// 4. If the earliest maintenance event begins after the lead time, remove the status condition until it's within it
+	// Use t.Sub(clock.Now()) instead of time.Until() to ensure we're using the injected clock.
+	if untilLeadTime := event.NotBefore.Sub(m.clock.Now()) - options.FromContext(ctx).MaintenanceLeadTime; untilLeadTime > 0 {
+		clearDisruptionCondition(ctx, nodeClaim, v1beta1.MaintenanceScheduled, "maintenance doesn't begin within the lead time")
+		return reconcile.Result{RequeueAfter: lo.Min([]time.Duration{untilLeadTime, 5 * time.Minute})}, nil
+	}
+	// 5. Otherwise, add the status condition for the earliest maintenance event.
+	markDisruptionCondition(logging.WithLogger(ctx, logging.FromContext(ctx).With("maintenance-event", event.ID)), nodeClaim, apis.Condition{
+		Type:     v1beta1.MaintenanceScheduled,
+		Status:   v1.ConditionTrue,
+		Severity: apis.ConditionSeverityWarning,
+		Reason:   "MaintenanceScheduled",
+		Message:  fmt.Sprintf("Maintenance %q (%s) is scheduled to begin at %s", event.ID, event.Reason, event.NotBefore.UTC().Format(time.RFC3339)),
+	}, metrics.MaintenanceReason)
+	// Requeue after 5 minutes in case the maintenance is cancelled or rescheduled
+	return reconcile.Result{RequeueAfter: 5 * time.Minute}, nil
+}
@@ -0,0 +1,146 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruption_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Maintenance", func() {
+	var nodePool *v1beta1.NodePool
+	var nodeClaim *v1beta1.NodeClaim
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+		nodeClaim, _ = test.NodeClaimAndNode(v1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1beta1.NodePoolLabelKey:   nodePool.Name,
+					v1.LabelInstanceTypeStable: test.RandomName(),
+				},
+			},
+		})
+		// NodeClaims are required to be launched before they can be evaluated for maintenance
+		nodeClaim.StatusConditions().MarkTrue(v1beta1.Launched)
+	})
+	It("should mark the NodeClaim when the cloud provider has scheduled maintenance for it", func() {
+		cp.ScheduledMaintenance[nodeClaim.Status.ProviderID] = []cloudprovider.MaintenanceEvent{
+			{ID: "event-2", Reason: "instance-retirement", NotBefore: fakeClock.Now().Add(48 * time.Hour)},
+			{ID: "event-1", Reason: "system-reboot", NotBefore: fakeClock.Now().Add(24 * time.Hour)},
+		}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		result := ExpectReconcileSucceeded(ctx, nodeClaimDisruptionController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		condition := ExpectStatusConditionExists(nodeClaim, v1beta1.MaintenanceScheduled)
+		Expect(condition.Status).To(Equal(v1.ConditionTrue))
+		// the condition describes the earliest maintenance event
+		Expect(condition.Message).To(ContainSubstring("event-1"))
+		Expect(condition.Message).To(ContainSubstring("system-reboot"))
+	})
+	It("should not mark the NodeClaim until its maintenance begins within the lead time", func() {
+		cp.ScheduledMaintenance[nodeClaim.Status.ProviderID] = []cloudprovider.MaintenanceEvent{
+			{ID: "event-1", Reason: "system-reboot", NotBefore: fakeClock.Now().Add(72 * time.Hour)},
+		}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		result := ExpectReconcileSucceeded(ctx, nodeClaimDisruptionController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(result.RequeueAfter).To(Equal(5 * time.Minute))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().GetCondition(v1beta1.MaintenanceScheduled)).To(BeNil())
+
+		// the maintenance now begins within the default 24h lead time
+		fakeClock.Step(48 * time.Hour)
+		ExpectReconcileSucceeded(ctx, nodeClaimDisruptionController, client.ObjectKeyFromObject(nodeClaim))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().GetCondition(v1beta1.MaintenanceScheduled).IsTrue()).To(BeTrue())
+	})
+	It("should requeue the NodeClaim when its maintenance enters the lead time", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{MaintenanceLeadTime: lo.ToPtr(time.Hour)}))
+		cp.ScheduledMaintenance[nodeClaim.Status.ProviderID] = []cloudprovider.MaintenanceEvent{
+			{ID: "event-1", Reason: "system-reboot", NotBefore: fakeClock.Now().Add(time.Hour + time.Minute)},
+		}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		result := ExpectReconcileSucceeded(ctx, nodeClaimDisruptionController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(result.RequeueAfter).To(Equal(time.Minute))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().GetCondition(v1beta1.MaintenanceScheduled)).To(BeNil())
+	})
+	It("should remove the status condition once the maintenance is rescheduled past the lead time", func() {
+		nodeClaim.StatusConditions().MarkTrue(v1beta1.MaintenanceScheduled)
+		cp.ScheduledMaintenance[nodeClaim.Status.ProviderID] = []cloudprovider.MaintenanceEvent{
+			{ID: "event-1", Reason: "system-reboot", NotBefore: fakeClock.Now().Add(72 * time.Hour)},
+		}
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectReconcileSucceeded(ctx, nodeClaimDisruptionController, client.ObjectKeyFromObject(nodeClaim))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().GetCondition(v1beta1.MaintenanceScheduled)).To(BeNil())
+	})
+	It("should not mark the NodeClaim when the cloud provider hasn't scheduled maintenance for it", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectReconcileSucceeded(ctx, nodeClaimDisruptionController, client.ObjectKeyFromObject(nodeClaim))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().GetCondition(v1beta1.MaintenanceScheduled)).To(BeNil())
+	})
+	It("should not mark the NodeClaim if it isn't launched", func() {
+		cp.ScheduledMaintenance[nodeClaim.Status.ProviderID] = []cloudprovider.MaintenanceEvent{
+			{ID: "event-1", Reason: "system-reboot", NotBefore: fakeClock.Now().Add(24 * time.Hour)},
+		}
+		nodeClaim.StatusConditions().MarkFalse(v1beta1.Launched, "", "")
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectReconcileSucceeded(ctx, nodeClaimDisruptionController, client.ObjectKeyFromObject(nodeClaim))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().GetCondition(v1beta1.MaintenanceScheduled)).To(BeNil())
+	})
+	It("should remove the status condition once the maintenance is no longer scheduled", func() {
+		nodeClaim.StatusConditions().MarkTrue(v1beta1.MaintenanceScheduled)
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectReconcileSucceeded(ctx, nodeClaimDisruptionController, client.ObjectKeyFromObject(nodeClaim))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().GetCondition(v1beta1.MaintenanceScheduled)).To(BeNil())
+	})
+	It("should keep the status condition if the maintenance events can't be retrieved", func() {
+		nodeClaim.StatusConditions().MarkTrue(v1beta1.MaintenanceScheduled)
+		cp.NextMaintenanceEventsErr = fmt.Errorf("failed to get maintenance events")
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectReconcileFailed(ctx, nodeClaimDisruptionController, client.ObjectKeyFromObject(nodeClaim))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().GetCondition(v1beta1.MaintenanceScheduled).IsTrue()).To(BeTrue())
+	})
+})
@@ -0,0 +1,117 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruption
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/apis"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	nodeutils "sigs.k8s.io/karpenter/pkg/utils/node"
+	nodeclaimutil "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
+)
+
+// Unhealthy is a nodeclaim sub-controller that adds or removes status conditions on nodeclaims whose nodes have been
+// NotReady for longer than their NodePool's UnhealthyAfter
+type Unhealthy struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+	clock         clock.Clock
+}
+
+func (u *Unhealthy) Reconcile(ctx context.Context, nodePool *v1beta1.NodePool, nodeClaim *v1beta1.NodeClaim) (reconcile.Result, error) {
+	hasUnhealthyCondition := nodeClaim.StatusConditions().GetCondition(v1beta1.Unhealthy) != nil
+
+	// From here there are a few scenarios to handle:
+	// 1. If UnhealthyAfter is not configured, remove the unhealthy status condition
+	if nodePool.Spec.Disruption.UnhealthyAfter == nil {
+		_ = nodeClaim.StatusConditions().ClearCondition(v1beta1.Unhealthy)
+		if hasUnhealthyCondition {
+			logging.FromContext(ctx).Debugf("removing unhealthy status condition, unhealthy replacement is disabled")
+		}
+		return reconcile.Result{}, nil
+	}
+	// 2. If NodeClaim is not initialized, remove the unhealthy status condition. Nodes that never become Ready are
+	// cleaned up by the liveness checks instead.
+	if initCond := nodeClaim.StatusConditions().GetCondition(v1beta1.Initialized); initCond == nil || initCond.IsFalse() {
+		_ = nodeClaim.StatusConditions().ClearCondition(v1beta1.Unhealthy)
+		if hasUnhealthyCondition {
+			logging.FromContext(ctx).Debugf("removing unhealthy status condition, isn't initialized")
+		}
+		return reconcile.Result{}, nil
+	}
+	n, err := nodeclaimutil.NodeForNodeClaim(ctx, u.kubeClient, u.cloudProvider, nodeClaim)
+	if err != nil {
+		// 3. If Node mapping doesn't exist, remove the unhealthy status condition
+		if nodeclaimutil.IsDuplicateNodeError(err) || nodeclaimutil.IsNodeNotFoundError(err) {
+			_ = nodeClaim.StatusConditions().ClearCondition(v1beta1.Unhealthy)
+			if hasUnhealthyCondition {
+				logging.FromContext(ctx).Debugf("removing unhealthy status condition, doesn't have a single node mapping")
+			}
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	// 4. If the Node is Ready, or doesn't report a Ready condition, remove the unhealthy status condition. The Node is
+	// watched, so the NodeClaim is reconciled again when it becomes NotReady.
+	ready := nodeutils.GetCondition(n, v1.NodeReady)
+	if ready.Status == v1.ConditionTrue || ready.Type == "" {
+		_ = nodeClaim.StatusConditions().ClearCondition(v1beta1.Unhealthy)
+		if hasUnhealthyCondition {
+			logging.FromContext(ctx).Debugf("removing unhealthy status condition, node is ready")
+		}
+		return reconcile.Result{}, nil
+	}
+	// 5. If the Node hasn't been NotReady for UnhealthyAfter, remove the unhealthy status condition. The transition
+	// time of the Ready condition is reset whenever the Node flaps, so only a sustained NotReady marks it unhealthy.
+	unhealthyTime := ready.LastTransitionTime.Add(nodePool.Spec.Disruption.UnhealthyAfter.Duration)
+	if u.clock.Now().Before(unhealthyTime) {
+		_ = nodeClaim.StatusConditions().ClearCondition(v1beta1.Unhealthy)
+		if hasUnhealthyCondition {
+			logging.FromContext(ctx).Debugf("removing unhealthy status condition, hasn't been not ready for long enough")
+		}
+		// Use t.Sub(clock.Now()) instead of time.Until() to ensure we're using the injected clock.
+		return reconcile.Result{RequeueAfter: unhealthyTime.Sub(u.clock.Now())}, nil
+	}
+	// 6. Otherwise, if the Node has been NotReady for UnhealthyAfter, add the status condition.
+	nodeClaim.StatusConditions().SetCondition(apis.Condition{
+		Type:     v1beta1.Unhealthy,
+		Status:   v1.ConditionTrue,
+		Severity: apis.ConditionSeverityWarning,
+		Reason:   "NodeNotReady",
+		Message:  fmt.Sprintf("Node has been NotReady since %s", ready.LastTransitionTime.UTC().Format(time.RFC3339)),
+	})
+	if !hasUnhealthyCondition {
+		logging.FromContext(ctx).Debugf("marking unhealthy")
+		metrics.NodeClaimsDisruptedCounter.With(prometheus.Labels{
+			metrics.TypeLabel:     metrics.UnhealthyReason,
+			metrics.NodePoolLabel: nodeClaim.Labels[v1beta1.NodePoolLabelKey],
+		}).Inc()
+	}
+	return reconcile.Result{}, nil
+}
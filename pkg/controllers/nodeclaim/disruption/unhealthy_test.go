@@ -0,0 +1,187 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package disruption_test
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("Unhealthy", func() {
+	var nodePool *v1beta1.NodePool
+	var nodeClaim *v1beta1.NodeClaim
+	var node *v1.Node
+	// setReady sets the status of the node's Ready condition, as the kubelet and node lifecycle controller would
+	setReady := func(status v1.ConditionStatus) {
+		node = ExpectExists(ctx, env.Client, node)
+		node.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: status, LastTransitionTime: metav1.NewTime(fakeClock.Now())}}
+		ExpectApplied(ctx, env.Client, node)
+	}
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+		nodePool.Spec.Disruption.UnhealthyAfter = &metav1.Duration{Duration: 5 * time.Minute}
+		nodeClaim, node = test.NodeClaimAndNode(v1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1beta1.NodePoolLabelKey:   nodePool.Name,
+					v1.LabelInstanceTypeStable: "default-instance-type", // need the instance type for the cluster state update
+				},
+			},
+		})
+	})
+	Context("Metrics", func() {
+		It("should fire a karpenter_nodeclaims_disrupted metric when unhealthy", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+			ExpectMakeNodeClaimsInitialized(ctx, env.Client, nodeClaim)
+			setReady(v1.ConditionFalse)
+			fakeClock.Step(10 * time.Minute)
+
+			ExpectReconcileSucceeded(ctx, nodeClaimDisruptionController, client.ObjectKeyFromObject(nodeClaim))
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.StatusConditions().GetCondition(v1beta1.Unhealthy).IsTrue()).To(BeTrue())
+
+			metric, found := FindMetricWithLabelValues("karpenter_nodeclaims_disrupted", map[string]string{
+				"type":     "unhealthy",
+				"nodepool": nodePool.Name,
+			})
+			Expect(found).To(BeTrue())
+			Expect(metric.GetCounter().GetValue()).To(BeNumerically("==", 1))
+		})
+	})
+	It("should mark NodeClaims as unhealthy when their node has been NotReady for longer than unhealthyAfter", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodeClaimsInitialized(ctx, env.Client, nodeClaim)
+		setReady(v1.ConditionFalse)
+		fakeClock.Step(10 * time.Minute)
+
+		ExpectReconcileSucceeded(ctx, nodeClaimDisruptionController, client.ObjectKeyFromObject(nodeClaim))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().GetCondition(v1beta1.Unhealthy).IsTrue()).To(BeTrue())
+	})
+	It("should mark NodeClaims as unhealthy when their node's readiness has been unknown for longer than unhealthyAfter", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodeClaimsInitialized(ctx, env.Client, nodeClaim)
+		setReady(v1.ConditionUnknown)
+		fakeClock.Step(10 * time.Minute)
+
+		ExpectReconcileSucceeded(ctx, nodeClaimDisruptionController, client.ObjectKeyFromObject(nodeClaim))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().GetCondition(v1beta1.Unhealthy).IsTrue()).To(BeTrue())
+	})
+	It("should not mark NodeClaims as unhealthy when their node has been NotReady for less than unhealthyAfter", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodeClaimsInitialized(ctx, env.Client, nodeClaim)
+		setReady(v1.ConditionFalse)
+		fakeClock.Step(3 * time.Minute)
+
+		result := ExpectReconcileSucceeded(ctx, nodeClaimDisruptionController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(result.RequeueAfter).To(BeNumerically("~", 2*time.Minute, time.Second))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().GetCondition(v1beta1.Unhealthy)).To(BeNil())
+	})
+	It("should not mark NodeClaims as unhealthy when their node flaps between NotReady and Ready within unhealthyAfter", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodeClaimsInitialized(ctx, env.Client, nodeClaim)
+
+		// The node is NotReady for a total of 9 minutes, but never for 5 minutes in a row
+		for i := 0; i < 3; i++ {
+			setReady(v1.ConditionFalse)
+			fakeClock.Step(3 * time.Minute)
+			ExpectReconcileSucceeded(ctx, nodeClaimDisruptionController, client.ObjectKeyFromObject(nodeClaim))
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.StatusConditions().GetCondition(v1beta1.Unhealthy)).To(BeNil())
+
+			setReady(v1.ConditionTrue)
+			fakeClock.Step(10 * time.Second)
+			ExpectReconcileSucceeded(ctx, nodeClaimDisruptionController, client.ObjectKeyFromObject(nodeClaim))
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(nodeClaim.StatusConditions().GetCondition(v1beta1.Unhealthy)).To(BeNil())
+		}
+
+		// Once the node stays NotReady, it's marked as unhealthy
+		setReady(v1.ConditionFalse)
+		fakeClock.Step(5 * time.Minute)
+		ExpectReconcileSucceeded(ctx, nodeClaimDisruptionController, client.ObjectKeyFromObject(nodeClaim))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().GetCondition(v1beta1.Unhealthy).IsTrue()).To(BeTrue())
+	})
+	It("should remove the status condition from NodeClaims when their node becomes Ready", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodeClaimsInitialized(ctx, env.Client, nodeClaim)
+		setReady(v1.ConditionFalse)
+		fakeClock.Step(10 * time.Minute)
+
+		ExpectReconcileSucceeded(ctx, nodeClaimDisruptionController, client.ObjectKeyFromObject(nodeClaim))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().GetCondition(v1beta1.Unhealthy).IsTrue()).To(BeTrue())
+
+		setReady(v1.ConditionTrue)
+		ExpectReconcileSucceeded(ctx, nodeClaimDisruptionController, client.ObjectKeyFromObject(nodeClaim))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().GetCondition(v1beta1.Unhealthy)).To(BeNil())
+	})
+	It("should not mark NodeClaims as unhealthy when their node doesn't report a Ready condition", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodeClaimsInitialized(ctx, env.Client, nodeClaim)
+		node = ExpectExists(ctx, env.Client, node)
+		node.Status.Conditions = nil
+		ExpectApplied(ctx, env.Client, node)
+		fakeClock.Step(time.Hour)
+
+		ExpectReconcileSucceeded(ctx, nodeClaimDisruptionController, client.ObjectKeyFromObject(nodeClaim))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().GetCondition(v1beta1.Unhealthy)).To(BeNil())
+	})
+	It("should not mark NodeClaims as unhealthy when unhealthyAfter is unset", func() {
+		nodePool.Spec.Disruption.UnhealthyAfter = nil
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		ExpectMakeNodeClaimsInitialized(ctx, env.Client, nodeClaim)
+		setReady(v1.ConditionFalse)
+		fakeClock.Step(time.Hour)
+
+		ExpectReconcileSucceeded(ctx, nodeClaimDisruptionController, client.ObjectKeyFromObject(nodeClaim))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().GetCondition(v1beta1.Unhealthy)).To(BeNil())
+	})
+	It("should not mark NodeClaims as unhealthy when they aren't initialized", func() {
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim, node)
+		setReady(v1.ConditionFalse)
+		fakeClock.Step(10 * time.Minute)
+
+		ExpectReconcileSucceeded(ctx, nodeClaimDisruptionController, client.ObjectKeyFromObject(nodeClaim))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.StatusConditions().GetCondition(v1beta1.Unhealthy)).To(BeNil())
+	})
+})
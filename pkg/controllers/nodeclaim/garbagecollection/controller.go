@@ -84,7 +84,7 @@ func (c *Controller) Reconcile(ctx context.Context, _ reconcile.Request) (reconc
 
 	errs := make([]error, len(nodeClaims))
 	workqueue.ParallelizeUntil(ctx, 20, len(nodeClaims), func(i int) {
-		node, err := nodeclaimutil.NodeForNodeClaim(ctx, c.kubeClient, nodeClaims[i])
+		node, err := nodeclaimutil.NodeForNodeClaim(ctx, c.kubeClient, c.cloudProvider, nodeClaims[i])
 		// Ignore these errors since a registered NodeClaim should only have a NotFound node when
 		// the Node was deleted out from under us and a Duplicate Node is an invalid state
 		if nodeclaimutil.IgnoreDuplicateNodeError(nodeclaimutil.IgnoreNodeNotFoundError(err)) != nil {
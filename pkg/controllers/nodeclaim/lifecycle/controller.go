@@ -21,15 +21,19 @@ import (
 	"time"
 
 	"github.com/patrickmn/go-cache"
+	"github.com/samber/lo"
 	"go.uber.org/multierr"
 	"golang.org/x/time/rate"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/utils/clock"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	controllerruntime "sigs.k8s.io/controller-runtime"
@@ -40,6 +44,7 @@ import (
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/events"
 	operatorcontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
 	nodeclaimutil "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
@@ -57,7 +62,8 @@ type nodeClaimReconciler interface {
 // the cluster as nodes and that they are properly initialized, ensuring that nodeclaims that do not have matching nodes
 // after some liveness TTL are removed
 type Controller struct {
-	kubeClient client.Client
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
 
 	launch         *Launch
 	registration   *Registration
@@ -65,13 +71,14 @@ type Controller struct {
 	liveness       *Liveness
 }
 
-func NewController(clk clock.Clock, kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, recorder events.Recorder) operatorcontroller.Controller {
+func NewController(clk clock.Clock, kubeClient client.Client, cloudProvider cloudprovider.CloudProvider, cluster *state.Cluster, recorder events.Recorder) operatorcontroller.Controller {
 	return operatorcontroller.Typed[*v1beta1.NodeClaim](kubeClient, &Controller{
-		kubeClient: kubeClient,
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
 
-		launch:         &Launch{kubeClient: kubeClient, cloudProvider: cloudProvider, cache: cache.New(time.Minute, time.Second*10), recorder: recorder},
-		registration:   &Registration{kubeClient: kubeClient},
-		initialization: &Initialization{kubeClient: kubeClient},
+		launch:         &Launch{kubeClient: kubeClient, cloudProvider: cloudProvider, cluster: cluster, cache: cache.New(time.Minute, time.Second*10), recorder: recorder},
+		registration:   &Registration{kubeClient: kubeClient, cloudProvider: cloudProvider, getFailures: cache.New(time.Hour, time.Minute)},
+		initialization: &Initialization{kubeClient: kubeClient, cloudProvider: cloudProvider},
 		liveness:       &Liveness{clock: clk, kubeClient: kubeClient},
 	})
 }
@@ -129,7 +136,7 @@ func (*Controller) Name() string {
 }
 
 func (c *Controller) Builder(_ context.Context, m manager.Manager) operatorcontroller.Builder {
-	return operatorcontroller.Adapt(controllerruntime.
+	b := controllerruntime.
 		NewControllerManagedBy(m).
 		For(&v1beta1.NodeClaim{}, builder.WithPredicates(
 			predicate.Funcs{
@@ -141,7 +148,15 @@ func (c *Controller) Builder(_ context.Context, m manager.Manager) operatorcontr
 		Watches(
 			&v1.Node{},
 			nodeclaimutil.NodeEventHandler(c.kubeClient),
-		).
+		)
+	// Nodes can only be matched to NodeClaims other than by their providerID if the CloudProvider implements MatchNode
+	if _, ok := cloudprovider.As[cloudprovider.NodeMatcher](c.cloudProvider); ok {
+		b = b.Watches(
+			&v1.Node{},
+			handler.EnqueueRequestsFromMapFunc(c.unmatchedNodeEventHandler),
+		)
+	}
+	return operatorcontroller.Adapt(b.
 		WithOptions(controller.Options{
 			RateLimiter: workqueue.NewMaxOfRateLimiter(
 				workqueue.NewItemExponentialFailureRateLimiter(time.Second, time.Minute),
@@ -151,3 +166,29 @@ func (c *Controller) Builder(_ context.Context, m manager.Manager) operatorcontr
 			MaxConcurrentReconciles: 1000, // higher concurrency limit since we want fast reaction to node syncing and launch
 		}))
 }
+
+// unmatchedNodeEventHandler enqueues the NodeClaims that could be matched to a Node by the CloudProvider's MatchNode.
+// These are the owners of the Node once it's registered and, before that, every launched NodeClaim that isn't
+// registered if no NodeClaim matches the Node by its providerID.
+func (c *Controller) unmatchedNodeEventHandler(ctx context.Context, o client.Object) []reconcile.Request {
+	node := o.(*v1.Node)
+	if node.Labels[v1beta1.NodeRegisteredLabelKey] == "true" {
+		return lo.FilterMap(node.OwnerReferences, func(o metav1.OwnerReference, _ int) (reconcile.Request, bool) {
+			return reconcile.Request{NamespacedName: types.NamespacedName{Name: o.Name}}, o.Kind == "NodeClaim"
+		})
+	}
+	nodeClaimList := &v1beta1.NodeClaimList{}
+	if node.Spec.ProviderID != "" {
+		if err := c.kubeClient.List(ctx, nodeClaimList, client.MatchingFields{"status.providerID": node.Spec.ProviderID}); err != nil || len(nodeClaimList.Items) > 0 {
+			return nil
+		}
+	}
+	// NodeClaims are only given a nodeName once they're registered
+	if err := c.kubeClient.List(ctx, nodeClaimList, client.MatchingFields{"status.nodeName": ""}); err != nil {
+		return nil
+	}
+	return lo.FilterMap(nodeClaimList.Items, func(nc v1beta1.NodeClaim, _ int) (reconcile.Request, bool) {
+		return reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&nc)},
+			nc.StatusConditions().GetCondition(v1beta1.Launched).IsTrue() && !nc.StatusConditions().GetCondition(v1beta1.Registered).IsTrue()
+	})
+}
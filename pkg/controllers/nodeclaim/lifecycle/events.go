@@ -20,11 +20,29 @@ import (
 	"fmt"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/flowcontrol"
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/events"
 )
 
+// PodLaunchRateLimiter is a pointer so it rate-limits across events, which bounds the events of large launches
+var PodLaunchRateLimiter = flowcontrol.NewTokenBucketRateLimiter(5, 10)
+
+// TriggeredLaunchPodEvent is an event on a pod that triggered the creation of a NodeClaim, naming the instance type
+// that was launched for it
+func TriggeredLaunchPodEvent(pod *v1.Pod, nodeClaim *v1beta1.NodeClaim) events.Event {
+	return events.Event{
+		InvolvedObject: pod,
+		Type:           v1.EventTypeNormal,
+		Reason:         "TriggeredLaunch",
+		Message: fmt.Sprintf("Pod triggered the launch of nodeclaim/%s from nodepool %s, instance type: %s",
+			nodeClaim.Name, nodeClaim.Labels[v1beta1.NodePoolLabelKey], nodeClaim.Labels[v1.LabelInstanceTypeStable]),
+		DedupeValues: []string{string(pod.UID)},
+		RateLimiter:  PodLaunchRateLimiter,
+	}
+}
+
 func InsufficientCapacityErrorEvent(nodeClaim *v1beta1.NodeClaim, err error) events.Event {
 	return events.Event{
 		InvolvedObject: nodeClaim,
@@ -30,6 +30,7 @@ import (
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
@@ -38,6 +39,7 @@ import (
 type Launch struct {
 	kubeClient    client.Client
 	cloudProvider cloudprovider.CloudProvider
+	cluster       *state.Cluster
 	cache         *cache.Cache // exists due to eventual consistency on the cache
 	recorder      events.Recorder
 }
@@ -58,6 +60,10 @@ func (l *Launch) Reconcile(ctx context.Context, nodeClaim *v1beta1.NodeClaim) (r
 	if ret, ok := l.cache.Get(string(nodeClaim.UID)); ok {
 		created = ret.(*v1beta1.NodeClaim)
 	} else {
+		// Hold off on launching while repeated launch failures for the NodePool have opened its circuit breaker
+		if d := l.cluster.AcquireLaunch(nodeClaim.Labels[v1beta1.NodePoolLabelKey]); d > 0 {
+			return reconcile.Result{RequeueAfter: d}, nil
+		}
 		created, err = l.launchNodeClaim(ctx, nodeClaim)
 	}
 	// Either the Node launch failed or the Node was deleted due to InsufficientCapacity/NotFound
@@ -70,6 +76,9 @@ func (l *Launch) Reconcile(ctx context.Context, nodeClaim *v1beta1.NodeClaim) (r
 	l.cache.SetDefault(string(nodeClaim.UID), created)
 	nodeClaim = PopulateNodeClaimDetails(nodeClaim, created)
 	nodeClaim.StatusConditions().MarkTrue(v1beta1.Launched)
+	for _, pod := range l.cluster.TakeLaunchPods(nodeClaim.Name) {
+		l.recorder.Publish(TriggeredLaunchPodEvent(pod, nodeClaim))
+	}
 	metrics.NodeClaimsLaunchedCounter.With(prometheus.Labels{
 		metrics.NodePoolLabel: nodeClaim.Labels[v1beta1.NodePoolLabelKey],
 	}).Inc()
@@ -84,6 +93,7 @@ func (l *Launch) launchNodeClaim(ctx context.Context, nodeClaim *v1beta1.NodeCla
 		case cloudprovider.IsInsufficientCapacityError(err):
 			l.recorder.Publish(InsufficientCapacityErrorEvent(nodeClaim, err))
 			logging.FromContext(ctx).Error(err)
+			nodeClaim.StatusConditions().MarkFalse(v1beta1.Launched, launchFailureReason(err), truncateMessage(err.Error()))
 			if err = l.kubeClient.Delete(ctx, nodeClaim); err != nil {
 				return nil, client.IgnoreNotFound(err)
 			}
@@ -95,13 +105,15 @@ func (l *Launch) launchNodeClaim(ctx context.Context, nodeClaim *v1beta1.NodeCla
 			return nil, nil
 		case cloudprovider.IsNodeClassNotReadyError(err):
 			l.recorder.Publish(NodeClassNotReadyEvent(nodeClaim, err))
-			nodeClaim.StatusConditions().MarkFalse(v1beta1.Launched, "LaunchFailed", truncateMessage(err.Error()))
+			nodeClaim.StatusConditions().MarkFalse(v1beta1.Launched, launchFailureReason(err), truncateMessage(err.Error()))
 			return nil, fmt.Errorf("launching nodeclaim, %w", err)
 		default:
-			nodeClaim.StatusConditions().MarkFalse(v1beta1.Launched, "LaunchFailed", truncateMessage(err.Error()))
+			l.cluster.RecordLaunchFailure(nodeClaim.Labels[v1beta1.NodePoolLabelKey])
+			nodeClaim.StatusConditions().MarkFalse(v1beta1.Launched, launchFailureReason(err), truncateMessage(err.Error()))
 			return nil, fmt.Errorf("launching nodeclaim, %w", err)
 		}
 	}
+	l.cluster.RecordLaunchSuccess(nodeClaim.Labels[v1beta1.NodePoolLabelKey])
 	logging.FromContext(ctx).With(
 		"provider-id", created.Status.ProviderID,
 		"instance-type", created.Labels[v1.LabelInstanceTypeStable],
@@ -111,6 +123,22 @@ func (l *Launch) launchNodeClaim(ctx context.Context, nodeClaim *v1beta1.NodeCla
 	return created, nil
 }
 
+// launchFailureReason maps the typed error returned by the CloudProvider to the reason set on the Launched condition
+func launchFailureReason(err error) string {
+	switch {
+	case cloudprovider.IsInsufficientCapacityError(err):
+		return v1beta1.LaunchFailedInsufficientCapacity
+	case cloudprovider.IsRateLimitedError(err):
+		return v1beta1.LaunchFailedRateLimited
+	case cloudprovider.IsNodeClassNotReadyError(err):
+		return v1beta1.LaunchFailedNodeClassNotReady
+	case cloudprovider.IsUnauthorizedError(err):
+		return v1beta1.LaunchFailedUnauthorized
+	default:
+		return v1beta1.LaunchFailedUnknown
+	}
+}
+
 func PopulateNodeClaimDetails(nodeClaim, retrieved *v1beta1.NodeClaim) *v1beta1.NodeClaim {
 	// These are ordered in priority order so that user-defined nodeClaim labels and requirements trump retrieved labels
 	// or the static nodeClaim labels
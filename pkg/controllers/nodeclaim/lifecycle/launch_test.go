@@ -22,9 +22,11 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/test"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -69,12 +71,50 @@ var _ = Describe("Launch", func() {
 
 		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
 		Expect(ExpectStatusConditionExists(nodeClaim, v1beta1.Launched).Status).To(Equal(v1.ConditionTrue))
+		ExpectNoEvent(recorder, nodeClaim, "InsufficientCapacityError")
+	})
+	It("should emit an event on the pods that triggered the NodeClaim once it's launched", func() {
+		nodeClaim := test.NodeClaim(v1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1beta1.NodePoolLabelKey: nodePool.Name,
+				},
+			},
+		})
+		pods := test.Pods(2, test.PodOptions{})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		cluster.RecordLaunchPods(nodeClaim.Name, pods)
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		// the event is only emitted once
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.Labels).To(HaveKey(v1.LabelInstanceTypeStable))
+		for _, pod := range pods {
+			evts := recorder.EventsFor(pod, "TriggeredLaunch")
+			Expect(evts).To(HaveLen(1))
+			Expect(evts[0].Type).To(Equal(v1.EventTypeNormal))
+			Expect(evts[0].Message).To(Equal(fmt.Sprintf("Pod triggered the launch of nodeclaim/%s from nodepool %s, instance type: %s",
+				nodeClaim.Name, nodePool.Name, nodeClaim.Labels[v1.LabelInstanceTypeStable])))
+			Expect(evts[0].RateLimiter).ToNot(BeNil())
+		}
+		Expect(cluster.TakeLaunchPods(nodeClaim.Name)).To(BeEmpty())
+	})
+	It("should not emit an event on the pods that triggered the NodeClaim if the launch fails", func() {
+		cloudProvider.NextCreateErr = cloudprovider.NewInsufficientCapacityError(fmt.Errorf("all instance types were unavailable"))
+		nodeClaim := test.NodeClaim()
+		pod := test.Pod()
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		cluster.RecordLaunchPods(nodeClaim.Name, []*v1.Pod{pod})
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(recorder.EventsFor(pod, "TriggeredLaunch")).To(BeEmpty())
 	})
 	It("should delete the nodeclaim if InsufficientCapacity is returned from the cloudprovider", func() {
 		cloudProvider.NextCreateErr = cloudprovider.NewInsufficientCapacityError(fmt.Errorf("all instance types were unavailable"))
 		nodeClaim := test.NodeClaim()
 		ExpectApplied(ctx, env.Client, nodeClaim)
 		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		ExpectEvent(recorder, nodeClaim, "InsufficientCapacityError")
 		ExpectFinalizersRemoved(ctx, env.Client, nodeClaim)
 		ExpectNotFound(ctx, env.Client, nodeClaim)
 	})
@@ -84,8 +124,122 @@ var _ = Describe("Launch", func() {
 		ExpectApplied(ctx, env.Client, nodeClaim)
 		res := ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
 		Expect(res.Requeue).To(BeTrue())
+		ExpectEvent(recorder, nodeClaim, "NodeClassNotReady")
 
 		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
 		Expect(ExpectStatusConditionExists(nodeClaim, v1beta1.Launched).Status).To(Equal(v1.ConditionFalse))
 	})
+	It("should set the InsufficientCapacity reason on the Launched condition before deleting the nodeclaim", func() {
+		cloudProvider.NextCreateErr = cloudprovider.NewInsufficientCapacityError(fmt.Errorf("all instance types were unavailable"))
+		nodeClaim := test.NodeClaim()
+		ExpectApplied(ctx, env.Client, nodeClaim)
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeFalse())
+		condition := ExpectStatusConditionExists(nodeClaim, v1beta1.Launched)
+		Expect(condition.Status).To(Equal(v1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(v1beta1.LaunchFailedInsufficientCapacity))
+		ExpectFinalizersRemoved(ctx, env.Client, nodeClaim)
+		ExpectNotFound(ctx, env.Client, nodeClaim)
+	})
+	DescribeTable("should set the Launched condition reason from the type of the cloudprovider error",
+		func(err error, reason string) {
+			cloudProvider.NextCreateErr = err
+			nodeClaim := test.NodeClaim()
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			_, _ = nodeClaimController.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(nodeClaim)})
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			condition := ExpectStatusConditionExists(nodeClaim, v1beta1.Launched)
+			Expect(condition.Status).To(Equal(v1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(reason))
+		},
+		Entry("RateLimited", cloudprovider.NewRateLimitedError(fmt.Errorf("request limit exceeded")), v1beta1.LaunchFailedRateLimited),
+		Entry("NodeClassNotReady", cloudprovider.NewNodeClassNotReadyError(fmt.Errorf("nodeClass isn't ready")), v1beta1.LaunchFailedNodeClassNotReady),
+		Entry("Unauthorized", cloudprovider.NewUnauthorizedError(fmt.Errorf("not authorized to launch instances")), v1beta1.LaunchFailedUnauthorized),
+		Entry("wrapped Unauthorized", fmt.Errorf("creating instance, %w", cloudprovider.NewUnauthorizedError(fmt.Errorf("not authorized to launch instances"))), v1beta1.LaunchFailedUnauthorized),
+		Entry("untyped errors as Unknown", fmt.Errorf("failed to launch"), v1beta1.LaunchFailedUnknown),
+	)
+	It("should record a launch failure for the NodePool if the cloudprovider fails to launch", func() {
+		nodeClaim := test.NodeClaim(v1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1beta1.NodePoolLabelKey: nodePool.Name,
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		for i := 0; i < state.LaunchFailureThreshold; i++ {
+			cloudProvider.NextCreateErr = fmt.Errorf("failed to launch")
+			ExpectReconcileFailed(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		}
+		Expect(cluster.LaunchesPausedFor(nodePool.Name)).To(Equal(state.LaunchBackoff))
+	})
+	It("should not launch while the NodePool's launch circuit breaker is open", func() {
+		for i := 0; i < state.LaunchFailureThreshold; i++ {
+			cluster.RecordLaunchFailure(nodePool.Name)
+		}
+		nodeClaim := test.NodeClaim(v1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1beta1.NodePoolLabelKey: nodePool.Name,
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		res := ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(res.RequeueAfter).To(Equal(state.LaunchBackoff))
+		Expect(cloudProvider.CreateCalls).To(HaveLen(0))
+
+		// Once the backoff has elapsed, a trial launch is let through and closes the breaker when it succeeds
+		fakeClock.Step(state.LaunchBackoff)
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+		Expect(cluster.LaunchesPausedFor(nodePool.Name)).To(BeZero())
+	})
+	It("should only launch a single trial NodeClaim once the NodePool's launch backoff has elapsed", func() {
+		for i := 0; i < state.LaunchFailureThreshold; i++ {
+			cluster.RecordLaunchFailure(nodePool.Name)
+		}
+		ExpectApplied(ctx, env.Client, nodePool)
+		var nodeClaims []*v1beta1.NodeClaim
+		for i := 0; i < 3; i++ {
+			nodeClaim := test.NodeClaim(v1beta1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1beta1.NodePoolLabelKey: nodePool.Name,
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodeClaim)
+			nodeClaims = append(nodeClaims, nodeClaim)
+		}
+		fakeClock.Step(state.LaunchBackoff)
+
+		// The trial launch fails, so the breaker re-opens and the other NodeClaims aren't launched
+		cloudProvider.NextCreateErr = fmt.Errorf("failed to launch")
+		ExpectReconcileFailed(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaims[0]))
+		for _, nodeClaim := range nodeClaims[1:] {
+			res := ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+			Expect(res.RequeueAfter).To(Equal(state.LaunchBackoff * 2))
+		}
+		Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+	})
+	It("should not record a launch failure if InsufficientCapacity is returned from the cloudprovider", func() {
+		nodeClaim := test.NodeClaim(v1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1beta1.NodePoolLabelKey: nodePool.Name,
+				},
+			},
+		})
+		for i := 0; i < state.LaunchFailureThreshold-1; i++ {
+			cluster.RecordLaunchFailure(nodePool.Name)
+		}
+		cloudProvider.NextCreateErr = cloudprovider.NewInsufficientCapacityError(fmt.Errorf("all instance types were unavailable"))
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(cluster.LaunchesPausedFor(nodePool.Name)).To(BeZero())
+	})
 })
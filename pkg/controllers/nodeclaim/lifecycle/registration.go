@@ -19,7 +19,10 @@ package lifecycle
 import (
 	"context"
 	"fmt"
+	"math"
+	"time"
 
+	"github.com/patrickmn/go-cache"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
@@ -30,18 +33,22 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 	nodeclaimutil "sigs.k8s.io/karpenter/pkg/utils/nodeclaim"
 )
 
 type Registration struct {
-	kubeClient client.Client
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+	getFailures   *cache.Cache // consecutive transient errors getting the instance of each NodeClaim
 }
 
 func (r *Registration) Reconcile(ctx context.Context, nodeClaim *v1beta1.NodeClaim) (reconcile.Result, error) {
 	if nodeClaim.StatusConditions().GetCondition(v1beta1.Registered).IsTrue() {
-		return reconcile.Result{}, nil
+		return reconcile.Result{}, r.syncProvisionerNameLabel(ctx, nodeClaim)
 	}
 	if !nodeClaim.StatusConditions().GetCondition(v1beta1.Launched).IsTrue() {
 		nodeClaim.StatusConditions().MarkFalse(v1beta1.Registered, "NotLaunched", "Node not launched")
@@ -49,11 +56,11 @@ func (r *Registration) Reconcile(ctx context.Context, nodeClaim *v1beta1.NodeCla
 	}
 
 	ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("provider-id", nodeClaim.Status.ProviderID))
-	node, err := nodeclaimutil.NodeForNodeClaim(ctx, r.kubeClient, nodeClaim)
+	node, err := nodeclaimutil.NodeForNodeClaim(ctx, r.kubeClient, r.cloudProvider, nodeClaim)
 	if err != nil {
 		if nodeclaimutil.IsNodeNotFoundError(err) {
 			nodeClaim.StatusConditions().MarkFalse(v1beta1.Registered, "NodeNotFound", "Node not registered with cluster")
-			return reconcile.Result{}, nil
+			return r.checkInstance(ctx, nodeClaim)
 		}
 		if nodeclaimutil.IsDuplicateNodeError(err) {
 			nodeClaim.StatusConditions().MarkFalse(v1beta1.Registered, "MultipleNodesFound", "Invariant violated, matched multiple nodes")
@@ -75,6 +82,10 @@ func (r *Registration) Reconcile(ctx context.Context, nodeClaim *v1beta1.NodeCla
 	metrics.NodesCreatedCounter.With(prometheus.Labels{
 		metrics.NodePoolLabel: nodeClaim.Labels[v1beta1.NodePoolLabelKey],
 	}).Inc()
+	metrics.NodePoolNodesCreatedCounter.With(prometheus.Labels{
+		metrics.NodePoolLabel:     nodeClaim.Labels[v1beta1.NodePoolLabelKey],
+		metrics.CapacityTypeLabel: nodeClaim.Labels[v1beta1.CapacityTypeLabelKey],
+	}).Inc()
 	return reconcile.Result{}, nil
 }
 
@@ -84,13 +95,15 @@ func (r *Registration) syncNode(ctx context.Context, nodeClaim *v1beta1.NodeClai
 
 	node = nodeclaimutil.UpdateNodeOwnerReferences(nodeClaim, node)
 	node.Labels = lo.Assign(node.Labels, nodeClaim.Labels)
-	node.Annotations = lo.Assign(node.Annotations, nodeClaim.Annotations)
+	// Annotations that are already set on the Node, e.g. by the CloudProvider, aren't overwritten
+	node.Annotations = lo.Assign(lo.OmitByKeys(nodeClaim.Annotations, v1beta1.NodeClaimOnlyAnnotations.UnsortedList()), node.Annotations)
 	// Sync all taints inside NodeClaim into the Node taints
 	node.Spec.Taints = scheduling.Taints(node.Spec.Taints).Merge(nodeClaim.Spec.Taints)
 	node.Spec.Taints = scheduling.Taints(node.Spec.Taints).Merge(nodeClaim.Spec.StartupTaints)
 	node.Labels = lo.Assign(node.Labels, nodeClaim.Labels, map[string]string{
 		v1beta1.NodeRegisteredLabelKey: "true",
 	})
+	provisionerNameLabel(ctx, nodeClaim, node)
 	if !equality.Semantic.DeepEqual(stored, node) {
 		if err := r.kubeClient.Patch(ctx, node, client.StrategicMergeFrom(stored)); err != nil {
 			return fmt.Errorf("syncing node labels, %w", err)
@@ -98,3 +111,73 @@ func (r *Registration) syncNode(ctx context.Context, nodeClaim *v1beta1.NodeClai
 	}
 	return nil
 }
+
+// checkInstance gets the instance of a NodeClaim whose Node hasn't registered yet from the CloudProvider. Transient
+// errors, which the CloudProvider returns as retryable or rate limited, are retried with an exponential backoff so
+// that registration recovers from them quickly. NodeClaims whose instance no longer exists are marked so that it's
+// clear why their Node never registered. They, along with the NodeClaims whose instance can't be gotten, are left to
+// liveness and garbage collection.
+func (r *Registration) checkInstance(ctx context.Context, nodeClaim *v1beta1.NodeClaim) (reconcile.Result, error) {
+	_, err := r.cloudProvider.Get(ctx, nodeClaim.Status.ProviderID)
+	if cloudprovider.IsRetryableError(err) || cloudprovider.IsRateLimitedError(err) {
+		delay := r.backoff(ctx, nodeClaim)
+		logging.FromContext(ctx).With("error", err).Debugf("getting instance from cloudprovider, retrying in %s", delay)
+		return reconcile.Result{RequeueAfter: delay}, nil
+	}
+	r.getFailures.Delete(string(nodeClaim.UID))
+	if cloudprovider.IsNodeClaimNotFoundError(err) {
+		nodeClaim.StatusConditions().MarkFalse(v1beta1.Registered, "InstanceNotFound", "Instance not found in cloudprovider")
+		return reconcile.Result{}, nil
+	}
+	if err != nil {
+		logging.FromContext(ctx).Errorf("getting instance from cloudprovider, %s", err)
+	}
+	return reconcile.Result{}, nil
+}
+
+// backoff records a transient error getting the instance of the NodeClaim and returns the delay before it's retried,
+// which doubles with each consecutive transient error
+func (r *Registration) backoff(ctx context.Context, nodeClaim *v1beta1.NodeClaim) time.Duration {
+	failures := 0
+	if v, ok := r.getFailures.Get(string(nodeClaim.UID)); ok {
+		failures = v.(int)
+	}
+	r.getFailures.SetDefault(string(nodeClaim.UID), failures+1)
+	maxDelay := options.FromContext(ctx).RegistrationBackoffMaxDelay
+	delay := float64(options.FromContext(ctx).RegistrationBackoffBaseDelay) * math.Pow(2, float64(failures))
+	if delay > float64(maxDelay) {
+		return maxDelay
+	}
+	return time.Duration(delay)
+}
+
+// syncProvisionerNameLabel adds or removes the legacy provisioner name label on the Node of a registered NodeClaim, so
+// that the Nodes follow the option when it's turned on or off after they registered
+func (r *Registration) syncProvisionerNameLabel(ctx context.Context, nodeClaim *v1beta1.NodeClaim) error {
+	node, err := nodeclaimutil.NodeForNodeClaim(ctx, r.kubeClient, r.cloudProvider, nodeClaim)
+	if err != nil {
+		if nodeclaimutil.IsNodeNotFoundError(err) || nodeclaimutil.IsDuplicateNodeError(err) {
+			return nil
+		}
+		return fmt.Errorf("getting node for nodeclaim, %w", err)
+	}
+	stored := node.DeepCopy()
+	provisionerNameLabel(ctx, nodeClaim, node)
+	if !equality.Semantic.DeepEqual(stored, node) {
+		if err := r.kubeClient.Patch(ctx, node, client.StrategicMergeFrom(stored)); err != nil {
+			return client.IgnoreNotFound(fmt.Errorf("syncing provisioner name label, %w", err))
+		}
+	}
+	return nil
+}
+
+// provisionerNameLabel sets the legacy provisioner name label on the Node to the name of its NodePool when the option
+// is enabled, and removes the label that it set otherwise
+func provisionerNameLabel(ctx context.Context, nodeClaim *v1beta1.NodeClaim, node *v1.Node) {
+	nodePoolName := nodeClaim.Labels[v1beta1.NodePoolLabelKey]
+	if options.FromContext(ctx).EnableProvisionerNameLabel {
+		node.Labels = lo.Assign(node.Labels, map[string]string{v1beta1.ProvisionerNameLabelKey: nodePoolName})
+	} else if value, ok := node.Labels[v1beta1.ProvisionerNameLabelKey]; ok && value == nodePoolName {
+		delete(node.Labels, v1beta1.ProvisionerNameLabelKey)
+	}
+}
@@ -17,11 +17,17 @@ limitations under the License.
 package lifecycle_test
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/test"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -146,6 +152,172 @@ var _ = Describe("Registration", func() {
 			Expect(node.Annotations).To(HaveKeyWithValue(k, v))
 		}
 	})
+	It("should sync the annotations to the Node without the Karpenter-internal annotations", func() {
+		nodeClaim := test.NodeClaim(v1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1beta1.NodePoolLabelKey: nodePool.Name,
+				},
+				Annotations: map[string]string{
+					"cost-center":                            "1234",
+					"owner":                                  "team-a",
+					v1beta1.NodePoolHashAnnotationKey:        nodePool.Hash(),
+					v1beta1.NodePoolHashVersionAnnotationKey: v1beta1.NodePoolHashVersion,
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+
+		node := test.Node(test.NodeOptions{ProviderID: nodeClaim.Status.ProviderID})
+		ExpectApplied(ctx, env.Client, node)
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		node = ExpectExists(ctx, env.Client, node)
+
+		Expect(node.Annotations).To(HaveKeyWithValue("cost-center", "1234"))
+		Expect(node.Annotations).To(HaveKeyWithValue("owner", "team-a"))
+		// Karpenter-internal annotations stay on the NodeClaim
+		Expect(node.Annotations).ToNot(HaveKey(v1beta1.NodePoolHashAnnotationKey))
+		Expect(node.Annotations).ToNot(HaveKey(v1beta1.NodePoolHashVersionAnnotationKey))
+	})
+	It("should not overwrite annotations that are already set on the Node", func() {
+		nodeClaim := test.NodeClaim(v1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1beta1.NodePoolLabelKey: nodePool.Name,
+				},
+				Annotations: map[string]string{
+					"owner":       "team-a",
+					"cost-center": "1234",
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+
+		node := test.Node(test.NodeOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					"owner": "cloudprovider",
+				},
+			},
+			ProviderID: nodeClaim.Status.ProviderID,
+		})
+		ExpectApplied(ctx, env.Client, node)
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		node = ExpectExists(ctx, env.Client, node)
+
+		Expect(node.Annotations).To(HaveKeyWithValue("owner", "cloudprovider"))
+		Expect(node.Annotations).To(HaveKeyWithValue("cost-center", "1234"))
+	})
+	It("should not label the Node with the legacy provisioner name by default", func() {
+		nodeClaim := test.NodeClaim(v1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1beta1.NodePoolLabelKey: nodePool.Name,
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+
+		node := test.Node(test.NodeOptions{ProviderID: nodeClaim.Status.ProviderID})
+		ExpectApplied(ctx, env.Client, node)
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Labels).ToNot(HaveKey(v1beta1.ProvisionerNameLabelKey))
+	})
+	It("should label the Node with the legacy provisioner name when enabled", func() {
+		ctx := options.ToContext(ctx, test.Options(test.OptionsFields{EnableProvisionerNameLabel: lo.ToPtr(true)}))
+		nodeClaim := test.NodeClaim(v1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1beta1.NodePoolLabelKey: nodePool.Name,
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+
+		node := test.Node(test.NodeOptions{ProviderID: nodeClaim.Status.ProviderID})
+		ExpectApplied(ctx, env.Client, node)
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		node = ExpectExists(ctx, env.Client, node)
+		Expect(node.Labels).To(HaveKeyWithValue(v1beta1.ProvisionerNameLabelKey, nodePool.Name))
+		// The legacy label is only an alias on the Node
+		Expect(ExpectExists(ctx, env.Client, nodeClaim).Labels).ToNot(HaveKey(v1beta1.ProvisionerNameLabelKey))
+	})
+	It("should remove the legacy provisioner name label from registered Nodes when disabled", func() {
+		enabled := options.ToContext(ctx, test.Options(test.OptionsFields{EnableProvisionerNameLabel: lo.ToPtr(true)}))
+		nodeClaim := test.NodeClaim(v1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1beta1.NodePoolLabelKey: nodePool.Name,
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectReconcileSucceeded(enabled, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+
+		node := test.Node(test.NodeOptions{ProviderID: nodeClaim.Status.ProviderID})
+		ExpectApplied(ctx, env.Client, node)
+		ExpectReconcileSucceeded(enabled, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(ExpectExists(ctx, env.Client, node).Labels).To(HaveKeyWithValue(v1beta1.ProvisionerNameLabelKey, nodePool.Name))
+		Expect(ExpectExists(ctx, env.Client, nodeClaim).StatusConditions().GetCondition(v1beta1.Registered).IsTrue()).To(BeTrue())
+
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(ExpectExists(ctx, env.Client, node).Labels).ToNot(HaveKey(v1beta1.ProvisionerNameLabelKey))
+	})
+	It("should label registered Nodes with the legacy provisioner name when enabled", func() {
+		nodeClaim := test.NodeClaim(v1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1beta1.NodePoolLabelKey: nodePool.Name,
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+
+		node := test.Node(test.NodeOptions{ProviderID: nodeClaim.Status.ProviderID})
+		ExpectApplied(ctx, env.Client, node)
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(ExpectExists(ctx, env.Client, node).Labels).ToNot(HaveKey(v1beta1.ProvisionerNameLabelKey))
+		Expect(ExpectExists(ctx, env.Client, nodeClaim).StatusConditions().GetCondition(v1beta1.Registered).IsTrue()).To(BeTrue())
+
+		enabled := options.ToContext(ctx, test.Options(test.OptionsFields{EnableProvisionerNameLabel: lo.ToPtr(true)}))
+		ExpectReconcileSucceeded(enabled, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(ExpectExists(ctx, env.Client, node).Labels).To(HaveKeyWithValue(v1beta1.ProvisionerNameLabelKey, nodePool.Name))
+	})
+	It("should keep a legacy provisioner name label that doesn't match the NodePool when disabled", func() {
+		nodeClaim := test.NodeClaim(v1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1beta1.NodePoolLabelKey: nodePool.Name,
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+
+		node := test.Node(test.NodeOptions{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{v1beta1.ProvisionerNameLabelKey: "default"},
+			},
+			ProviderID: nodeClaim.Status.ProviderID,
+		})
+		ExpectApplied(ctx, env.Client, node)
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		Expect(ExpectExists(ctx, env.Client, node).Labels).To(HaveKeyWithValue(v1beta1.ProvisionerNameLabelKey, "default"))
+	})
 	It("should sync the taints to the Node when the Node comes online", func() {
 		nodeClaim := test.NodeClaim(v1beta1.NodeClaim{
 			ObjectMeta: metav1.ObjectMeta{
@@ -330,4 +502,170 @@ var _ = Describe("Registration", func() {
 		node = ExpectExists(ctx, env.Client, node)
 		Expect(node.Spec.Taints).To(HaveLen(0))
 	})
+	It("should count the node as created for the nodepool exactly once when the Node comes online", func() {
+		nodeClaim := test.NodeClaim(v1beta1.NodeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{
+					v1beta1.NodePoolLabelKey: nodePool.Name,
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+
+		node := test.Node(test.NodeOptions{ProviderID: nodeClaim.Status.ProviderID})
+		ExpectApplied(ctx, env.Client, node)
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+
+		nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+		ExpectMetricCounterValue("karpenter_nodepool_nodes_created_total", 1, map[string]string{
+			"nodepool":      nodePool.Name,
+			"capacity_type": nodeClaim.Labels[v1beta1.CapacityTypeLabelKey],
+		})
+	})
+	Context("Custom Node Matching", func() {
+		var nodeClaim *v1beta1.NodeClaim
+		BeforeEach(func() {
+			nodeClaim = test.NodeClaim(v1beta1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1beta1.NodePoolLabelKey: nodePool.Name,
+					},
+				},
+			})
+			// Match Nodes whose providerID is the NodeClaim's providerID in a nonstandard format
+			cloudProvider.NodeMatcher = func(nc *v1beta1.NodeClaim, n *v1.Node) bool {
+				return n.Spec.ProviderID == "custom:///"+nc.Status.ProviderID
+			}
+		})
+		It("should match the nodeClaim to the Node when the cloudprovider matches the Node", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+
+			node := test.Node(test.NodeOptions{ProviderID: "custom:///" + nodeClaim.Status.ProviderID})
+			ExpectApplied(ctx, env.Client, node)
+			ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(ExpectStatusConditionExists(nodeClaim, v1beta1.Registered).Status).To(Equal(v1.ConditionTrue))
+			Expect(nodeClaim.Status.NodeName).To(Equal(node.Name))
+			node = ExpectExists(ctx, env.Client, node)
+			ExpectOwnerReferenceExists(node, nodeClaim)
+			Expect(node.Labels).To(HaveKeyWithValue(v1beta1.NodeRegisteredLabelKey, "true"))
+		})
+		It("should prefer the Node that matches the providerID over the cloudprovider match", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+
+			node := test.Node(test.NodeOptions{ProviderID: nodeClaim.Status.ProviderID})
+			customNode := test.Node(test.NodeOptions{ProviderID: "custom:///" + nodeClaim.Status.ProviderID})
+			ExpectApplied(ctx, env.Client, node, customNode)
+			ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(ExpectStatusConditionExists(nodeClaim, v1beta1.Registered).Status).To(Equal(v1.ConditionTrue))
+			Expect(nodeClaim.Status.NodeName).To(Equal(node.Name))
+		})
+		It("should not match the nodeClaim to a Node that is registered to another nodeClaim", func() {
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+
+			node := test.Node(test.NodeOptions{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1beta1.NodeRegisteredLabelKey: "true",
+					},
+				},
+				ProviderID: "custom:///" + nodeClaim.Status.ProviderID,
+			})
+			ExpectApplied(ctx, env.Client, node)
+			ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(ExpectStatusConditionExists(nodeClaim, v1beta1.Registered).Status).To(Equal(v1.ConditionFalse))
+			Expect(ExpectStatusConditionExists(nodeClaim, v1beta1.Registered).Reason).To(Equal("NodeNotFound"))
+		})
+		It("should not match the nodeClaim when the cloudprovider matches multiple Nodes", func() {
+			cloudProvider.NodeMatcher = func(*v1beta1.NodeClaim, *v1.Node) bool { return true }
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+
+			ExpectApplied(ctx, env.Client, test.Node(), test.Node())
+			ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(ExpectStatusConditionExists(nodeClaim, v1beta1.Registered).Status).To(Equal(v1.ConditionFalse))
+			Expect(ExpectStatusConditionExists(nodeClaim, v1beta1.Registered).Reason).To(Equal("MultipleNodesFound"))
+		})
+		It("should not match the nodeClaim to a Node with a different providerID by default", func() {
+			cloudProvider.NodeMatcher = nil
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+			ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+
+			ExpectApplied(ctx, env.Client, test.Node(test.NodeOptions{ProviderID: "custom:///" + nodeClaim.Status.ProviderID}))
+			ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+
+			nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+			Expect(ExpectStatusConditionExists(nodeClaim, v1beta1.Registered).Status).To(Equal(v1.ConditionFalse))
+			Expect(ExpectStatusConditionExists(nodeClaim, v1beta1.Registered).Reason).To(Equal("NodeNotFound"))
+		})
+	})
+	Context("Instance Backoff", func() {
+		var nodeClaim *v1beta1.NodeClaim
+		BeforeEach(func() {
+			nodeClaim = test.NodeClaim(v1beta1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1beta1.NodePoolLabelKey: nodePool.Name,
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, nodeClaim)
+		})
+		It("should retry transient errors getting the instance with an exponential backoff", func() {
+			cloudProvider.NextGetErr = cloudprovider.NewRetryableError(fmt.Errorf("internal error"))
+			Expect(ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim)).RequeueAfter).To(Equal(time.Second))
+			cloudProvider.NextGetErr = cloudprovider.NewRateLimitedError(fmt.Errorf("throttled"))
+			Expect(ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim)).RequeueAfter).To(Equal(2 * time.Second))
+			cloudProvider.NextGetErr = cloudprovider.NewRetryableError(fmt.Errorf("internal error"))
+			Expect(ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim)).RequeueAfter).To(Equal(4 * time.Second))
+			Expect(ExpectStatusConditionExists(ExpectExists(ctx, env.Client, nodeClaim), v1beta1.Registered).Reason).To(Equal("NodeNotFound"))
+		})
+		It("should cap the backoff of transient errors at the max delay", func() {
+			ctx := options.ToContext(ctx, test.Options(test.OptionsFields{
+				RegistrationBackoffBaseDelay: lo.ToPtr(40 * time.Second),
+				RegistrationBackoffMaxDelay:  lo.ToPtr(time.Minute),
+			}))
+			cloudProvider.NextGetErr = cloudprovider.NewRetryableError(fmt.Errorf("internal error"))
+			Expect(ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim)).RequeueAfter).To(Equal(40 * time.Second))
+			cloudProvider.NextGetErr = cloudprovider.NewRetryableError(fmt.Errorf("internal error"))
+			Expect(ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim)).RequeueAfter).To(Equal(time.Minute))
+		})
+		It("should reset the backoff once the instance is found", func() {
+			cloudProvider.NextGetErr = cloudprovider.NewRetryableError(fmt.Errorf("internal error"))
+			Expect(ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim)).RequeueAfter).To(Equal(time.Second))
+			Expect(ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim)).RequeueAfter).To(BeNumerically(">", time.Second))
+			cloudProvider.NextGetErr = cloudprovider.NewRetryableError(fmt.Errorf("internal error"))
+			Expect(ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim)).RequeueAfter).To(Equal(time.Second))
+		})
+		It("should leave terminal errors getting the instance to liveness", func() {
+			ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+			cloudProvider.NextGetErr = fmt.Errorf("unauthorized")
+			Expect(ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim)).RequeueAfter).To(BeNumerically(">", time.Second))
+			Expect(ExpectStatusConditionExists(ExpectExists(ctx, env.Client, nodeClaim), v1beta1.Registered).Reason).To(Equal("NodeNotFound"))
+		})
+		It("should mark the nodeClaim and not retry when the instance doesn't exist", func() {
+			ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+			cloudProvider.NextGetErr = cloudprovider.NewNodeClaimNotFoundError(fmt.Errorf("not found"))
+			Expect(ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim)).RequeueAfter).To(BeNumerically(">", time.Second))
+			Expect(ExpectStatusConditionExists(ExpectExists(ctx, env.Client, nodeClaim), v1beta1.Registered).Reason).To(Equal("InstanceNotFound"))
+		})
+	})
 })
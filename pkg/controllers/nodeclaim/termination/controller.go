@@ -71,7 +71,7 @@ func (c *Controller) Finalize(ctx context.Context, nodeClaim *v1beta1.NodeClaim)
 	if !controllerutil.ContainsFinalizer(nodeClaim, v1beta1.TerminationFinalizer) {
 		return reconcile.Result{}, nil
 	}
-	nodes, err := nodeclaimutil.AllNodesForNodeClaim(ctx, c.kubeClient, nodeClaim)
+	nodes, err := nodeclaimutil.AllNodesForNodeClaim(ctx, c.kubeClient, c.cloudProvider, nodeClaim)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
@@ -184,6 +184,23 @@ var _ = Describe("Counter", func() {
 		Expect(nodePool.Status.Resources).To(BeEquivalentTo(nodeClaim2.Status.Capacity))
 		Expect(nodePool.Status.Resources).To(BeEquivalentTo(node2.Status.Capacity))
 	})
+	It("should count extended resources from the nodeClaim capacity", func() {
+		nodeClaim.Status.Capacity[fake.ResourceGPUVendorA] = resource.MustParse("2")
+		nodeClaim2.Status.Capacity[fake.ResourceGPUVendorA] = resource.MustParse("4")
+		ExpectApplied(ctx, env.Client, node, nodeClaim, node2, nodeClaim2)
+		// Don't initialize the nodes, as the device plugin may not have reported the extended resources yet
+		ExpectMakeNodeClaimsInitialized(ctx, env.Client, nodeClaim, nodeClaim2)
+		ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+		ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node2))
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim2))
+
+		ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+
+		gpus := nodePool.Status.Resources[fake.ResourceGPUVendorA]
+		Expect(gpus.Value()).To(BeNumerically("==", 6))
+	})
 	It("should nil out the counter when all nodes are deleted", func() {
 		ExpectApplied(ctx, env.Client, node, nodeClaim)
 		ExpectMakeNodesAndNodeClaimsInitializedAndStateUpdated(ctx, env.Client, nodeController, nodeClaimController, []*v1.Node{node}, []*v1beta1.NodeClaim{nodeClaim})
@@ -0,0 +1,99 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driftcheck
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"knative.dev/pkg/logging"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	operatorcontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+)
+
+var _ operatorcontroller.TypedController[*v1beta1.NodePool] = (*Controller)(nil)
+
+// Controller propagates the force drift check annotation from a NodePool to its NodeClaims, where the NodeClaim
+// disruption controller re-evaluates their drift and removes it
+type Controller struct {
+	kubeClient client.Client
+}
+
+// NewController constructs a controller instance
+func NewController(kubeClient client.Client) operatorcontroller.Controller {
+	return operatorcontroller.Typed[*v1beta1.NodePool](kubeClient, &Controller{
+		kubeClient: kubeClient,
+	})
+}
+
+// Reconcile the resource
+func (c *Controller) Reconcile(ctx context.Context, nodePool *v1beta1.NodePool) (reconcile.Result, error) {
+	value, ok := nodePool.Annotations[v1beta1.ForceDriftCheckAnnotationKey]
+	if !ok {
+		return reconcile.Result{}, nil
+	}
+	nodeClaimList := &v1beta1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaimList, client.MatchingLabels{v1beta1.NodePoolLabelKey: nodePool.Name}); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	var errs error
+	for i := range nodeClaimList.Items {
+		nodeClaim := &nodeClaimList.Items[i]
+		if !nodeClaim.DeletionTimestamp.IsZero() {
+			continue
+		}
+		stored := nodeClaim.DeepCopy()
+		nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{v1beta1.ForceDriftCheckAnnotationKey: value})
+		if !equality.Semantic.DeepEqual(stored, nodeClaim) {
+			if err := c.kubeClient.Patch(ctx, nodeClaim, client.MergeFrom(stored)); client.IgnoreNotFound(err) != nil {
+				errs = multierr.Append(errs, fmt.Errorf("annotating nodeclaim, %w", err))
+			}
+		}
+	}
+	// We keep the annotation on the NodePool to retry the NodeClaims that we failed to annotate
+	if errs != nil {
+		return reconcile.Result{}, errs
+	}
+	stored := nodePool.DeepCopy()
+	delete(nodePool.Annotations, v1beta1.ForceDriftCheckAnnotationKey)
+	if err := c.kubeClient.Patch(ctx, nodePool, client.MergeFrom(stored)); err != nil {
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	logging.FromContext(ctx).With("nodeclaims", len(nodeClaimList.Items)).Infof("forcing drift check")
+	return reconcile.Result{}, nil
+}
+
+func (c *Controller) Name() string {
+	return "nodepool.driftcheck"
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) operatorcontroller.Builder {
+	return operatorcontroller.Adapt(controllerruntime.
+		NewControllerManagedBy(m).
+		For(&v1beta1.NodePool{}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 10}),
+	)
+}
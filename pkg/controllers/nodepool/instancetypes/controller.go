@@ -0,0 +1,124 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancetypes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"knative.dev/pkg/apis"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	operatorcontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+)
+
+const (
+	// MaxSampleSize bounds the number of instance type names published in the NodePool status
+	MaxSampleSize = 10
+	// RefreshInterval is how often the instance types are resolved again, since the cloud provider's catalog and
+	// offering availability change without the NodePool changing
+	RefreshInterval = 5 * time.Minute
+)
+
+var _ operatorcontroller.TypedController[*v1beta1.NodePool] = (*Controller)(nil)
+
+// Controller resolves the instance types that each NodePool's requirements are compatible with and publishes a
+// bounded summary in the NodePool status, so that users can confirm their requirements aren't over-restrictive. A
+// NodePool that resolves to a single instance type is flagged with the SingleInstanceType condition.
+type Controller struct {
+	kubeClient    client.Client
+	cloudProvider cloudprovider.CloudProvider
+}
+
+// NewController is a constructor
+func NewController(kubeClient client.Client, cloudProvider cloudprovider.CloudProvider) operatorcontroller.Controller {
+	return operatorcontroller.Typed[*v1beta1.NodePool](kubeClient, &Controller{
+		kubeClient:    kubeClient,
+		cloudProvider: cloudProvider,
+	})
+}
+
+// Reconcile a control loop for the resource
+func (c *Controller) Reconcile(ctx context.Context, nodePool *v1beta1.NodePool) (reconcile.Result, error) {
+	if !nodePool.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+	instanceTypes, err := c.cloudProvider.GetInstanceTypes(ctx, nodePool)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("getting instance types, %w", err)
+	}
+	stored := nodePool.DeepCopy()
+	nodePool.Status.InstanceTypes = summarize(nodePool, instanceTypes)
+	if nodePool.Status.InstanceTypes.Count == 1 {
+		// The condition is set directly, rather than marked, so that it doesn't affect the readiness of the NodePool
+		nodePool.StatusConditions().SetCondition(apis.Condition{
+			Type:     v1beta1.SingleInstanceType,
+			Status:   v1.ConditionTrue,
+			Severity: apis.ConditionSeverityWarning,
+			Reason:   "NoInstanceTypeDiversity",
+			Message:  fmt.Sprintf("Requirements only resolve to instance type %q, consider allowing more instance types so that launches can fall back when it lacks capacity", nodePool.Status.InstanceTypes.Sample[0]),
+		})
+	} else {
+		_ = nodePool.StatusConditions().ClearCondition(v1beta1.SingleInstanceType)
+	}
+	if !equality.Semantic.DeepEqual(stored, nodePool) {
+		if err := c.kubeClient.Status().Patch(ctx, nodePool, client.MergeFrom(stored)); err != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(err)
+		}
+	}
+	return reconcile.Result{RequeueAfter: RefreshInterval}, nil
+}
+
+// summarize returns the count and a sorted sample of the instance types that are compatible with the NodePool's
+// requirements and have an available offering. The sample is sorted by name so that the status doesn't churn when
+// the cloud provider returns the same instance types in a different order.
+func summarize(nodePool *v1beta1.NodePool, instanceTypes []*cloudprovider.InstanceType) *v1beta1.InstanceTypesSummary {
+	requirements := scheduling.NewNodeClaimTemplate(nodePool).Requirements
+	names := lo.FilterMap(instanceTypes, func(it *cloudprovider.InstanceType, _ int) (string, bool) {
+		return it.Name, it.Requirements.Intersects(requirements) == nil && len(it.Offerings.Available().Compatible(requirements)) > 0
+	})
+	sort.Strings(names)
+	summary := &v1beta1.InstanceTypesSummary{Count: len(names)}
+	if len(names) > 0 {
+		summary.Sample = names[:lo.Min([]int{len(names), MaxSampleSize})]
+	}
+	return summary
+}
+
+func (c *Controller) Name() string {
+	return "nodepool.instancetypes"
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) operatorcontroller.Builder {
+	return operatorcontroller.Adapt(controllerruntime.
+		NewControllerManagedBy(m).
+		For(&v1beta1.NodePool{}).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 10}),
+	)
+}
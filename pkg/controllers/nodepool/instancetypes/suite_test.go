@@ -0,0 +1,191 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package instancetypes_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	knativeapis "knative.dev/pkg/apis"
+	. "knative.dev/pkg/logging/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/nodepool/instancetypes"
+	"sigs.k8s.io/karpenter/pkg/operator/controller"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var nodePoolController controller.Controller
+var ctx context.Context
+var env *test.Environment
+var cloudProvider *fake.CloudProvider
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "InstanceTypes")
+}
+
+var _ = BeforeSuite(func() {
+	cloudProvider = fake.NewCloudProvider()
+	env = test.NewEnvironment(scheme.Scheme, test.WithCRDs(apis.CRDs...))
+	nodePoolController = instancetypes.NewController(env.Client, cloudProvider)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("InstanceTypes", func() {
+	var nodePool *v1beta1.NodePool
+	BeforeEach(func() {
+		cloudProvider.Reset()
+		nodePool = test.NodePool()
+	})
+	It("should publish the count and a sorted sample of the compatible instance types", func() {
+		cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+			fake.NewInstanceType(fake.InstanceTypeOptions{Name: "instance-type-c"}),
+			fake.NewInstanceType(fake.InstanceTypeOptions{Name: "instance-type-a"}),
+			fake.NewInstanceType(fake.InstanceTypeOptions{Name: "instance-type-b"}),
+		}
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+
+		Expect(nodePool.Status.InstanceTypes).ToNot(BeNil())
+		Expect(nodePool.Status.InstanceTypes.Count).To(Equal(3))
+		Expect(nodePool.Status.InstanceTypes.Sample).To(Equal([]string{"instance-type-a", "instance-type-b", "instance-type-c"}))
+	})
+	It("should bound the sample while counting all compatible instance types", func() {
+		cloudProvider.InstanceTypes = fake.InstanceTypes(instancetypes.MaxSampleSize + 5)
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+
+		Expect(nodePool.Status.InstanceTypes.Count).To(Equal(instancetypes.MaxSampleSize + 5))
+		Expect(nodePool.Status.InstanceTypes.Sample).To(HaveLen(instancetypes.MaxSampleSize))
+	})
+	It("should only include instance types that are compatible with the nodepool requirements", func() {
+		cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+			fake.NewInstanceType(fake.InstanceTypeOptions{Name: "amd64-instance-type", Architecture: v1beta1.ArchitectureAmd64}),
+			fake.NewInstanceType(fake.InstanceTypeOptions{Name: "arm64-instance-type", Architecture: v1beta1.ArchitectureArm64}),
+		}
+		nodePool.Spec.Template.Spec.Requirements = []v1beta1.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelArchStable, Operator: v1.NodeSelectorOpIn, Values: []string{v1beta1.ArchitectureArm64}}},
+		}
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+
+		Expect(nodePool.Status.InstanceTypes.Count).To(Equal(1))
+		Expect(nodePool.Status.InstanceTypes.Sample).To(Equal([]string{"arm64-instance-type"}))
+	})
+	It("should not include instance types without an available offering", func() {
+		cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+			fake.NewInstanceType(fake.InstanceTypeOptions{Name: "available-instance-type"}),
+			fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name: "unavailable-instance-type",
+				Offerings: []cloudprovider.Offering{
+					{CapacityType: v1beta1.CapacityTypeOnDemand, Zone: "test-zone-1", Price: 1, Available: false},
+				},
+			}),
+		}
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+
+		Expect(nodePool.Status.InstanceTypes.Count).To(Equal(1))
+		Expect(nodePool.Status.InstanceTypes.Sample).To(Equal([]string{"available-instance-type"}))
+	})
+	It("should report no instance types when the requirements are over-restrictive", func() {
+		cloudProvider.InstanceTypes = fake.InstanceTypes(3)
+		nodePool.Spec.Template.Spec.Requirements = []v1beta1.NodeSelectorRequirementWithMinValues{
+			{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpIn, Values: []string{"does-not-exist"}}},
+		}
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+
+		Expect(nodePool.Status.InstanceTypes.Count).To(Equal(0))
+		Expect(nodePool.Status.InstanceTypes.Sample).To(BeEmpty())
+	})
+	Context("SingleInstanceType", func() {
+		It("should set the SingleInstanceType condition when the requirements resolve to a single instance type", func() {
+			cloudProvider.InstanceTypes = fake.InstanceTypes(3)
+			nodePool.Spec.Template.Spec.Requirements = []v1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpIn, Values: []string{cloudProvider.InstanceTypes[0].Name}}},
+			}
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+
+			condition := ExpectStatusConditionExists(nodePool, v1beta1.SingleInstanceType)
+			Expect(condition.Status).To(Equal(v1.ConditionTrue))
+			Expect(condition.Severity).To(Equal(knativeapis.ConditionSeverityWarning))
+			Expect(condition.Message).To(ContainSubstring(cloudProvider.InstanceTypes[0].Name))
+		})
+		It("should not set the SingleInstanceType condition when the requirements resolve to multiple instance types", func() {
+			cloudProvider.InstanceTypes = fake.InstanceTypes(3)
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+
+			Expect(nodePool.StatusConditions().GetCondition(v1beta1.SingleInstanceType)).To(BeNil())
+		})
+		It("should not set the SingleInstanceType condition when the requirements resolve to no instance types", func() {
+			cloudProvider.InstanceTypes = fake.InstanceTypes(3)
+			nodePool.Spec.Template.Spec.Requirements = []v1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpIn, Values: []string{"does-not-exist"}}},
+			}
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+
+			Expect(nodePool.StatusConditions().GetCondition(v1beta1.SingleInstanceType)).To(BeNil())
+		})
+		It("should clear the SingleInstanceType condition once the requirements resolve to multiple instance types", func() {
+			cloudProvider.InstanceTypes = fake.InstanceTypes(3)
+			nodePool.Spec.Template.Spec.Requirements = []v1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpIn, Values: []string{cloudProvider.InstanceTypes[0].Name}}},
+			}
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			Expect(ExpectStatusConditionExists(nodePool, v1beta1.SingleInstanceType).Status).To(Equal(v1.ConditionTrue))
+
+			nodePool.Spec.Template.Spec.Requirements = nil
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+
+			Expect(nodePool.StatusConditions().GetCondition(v1beta1.SingleInstanceType)).To(BeNil())
+		})
+	})
+})
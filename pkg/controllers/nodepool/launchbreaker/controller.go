@@ -0,0 +1,107 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package launchbreaker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/apis"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/metrics"
+	operatorcontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+)
+
+var _ operatorcontroller.TypedController[*v1beta1.NodePool] = (*Controller)(nil)
+
+// Controller surfaces the state of each NodePool's launch circuit breaker through the LaunchesPaused status condition
+// and the launches paused metric. The breaker itself is tracked in cluster state by the NodeClaim lifecycle controller.
+type Controller struct {
+	kubeClient client.Client
+	cluster    *state.Cluster
+}
+
+// NewController is a constructor
+func NewController(kubeClient client.Client, cluster *state.Cluster) operatorcontroller.Controller {
+	return operatorcontroller.Typed[*v1beta1.NodePool](kubeClient, &Controller{
+		kubeClient: kubeClient,
+		cluster:    cluster,
+	})
+}
+
+// Reconcile a control loop for the resource
+func (c *Controller) Reconcile(ctx context.Context, nodePool *v1beta1.NodePool) (reconcile.Result, error) {
+	if !nodePool.DeletionTimestamp.IsZero() {
+		launchesPausedGauge.Delete(prometheus.Labels{metrics.NodePoolLabel: nodePool.Name})
+		return reconcile.Result{}, nil
+	}
+	stored := nodePool.DeepCopy()
+	pausedFor := c.cluster.LaunchesPausedFor(nodePool.Name)
+	if pausedFor > 0 {
+		// The condition is set directly, rather than marked, so that the NodePool doesn't gain a happy condition
+		nodePool.StatusConditions().SetCondition(apis.Condition{
+			Type:     v1beta1.LaunchesPaused,
+			Status:   v1.ConditionTrue,
+			Severity: apis.ConditionSeverityWarning,
+			Reason:   "RepeatedLaunchFailures",
+			Message:  fmt.Sprintf("Launches are paused after %d consecutive launch failures", c.cluster.LaunchFailures(nodePool.Name)),
+		})
+	} else {
+		_ = nodePool.StatusConditions().ClearCondition(v1beta1.LaunchesPaused)
+	}
+	launchesPausedGauge.With(prometheus.Labels{metrics.NodePoolLabel: nodePool.Name}).Set(lo.Ternary[float64](pausedFor > 0, 1, 0))
+	if !equality.Semantic.DeepEqual(stored, nodePool) {
+		if err := c.kubeClient.Status().Patch(ctx, nodePool, client.MergeFrom(stored)); err != nil {
+			return reconcile.Result{}, client.IgnoreNotFound(err)
+		}
+	}
+	// Requeue for when the backoff elapses so that the condition is cleared once launches are let through again
+	return reconcile.Result{RequeueAfter: pausedFor}, nil
+}
+
+func (c *Controller) Name() string {
+	return "nodepool.launchbreaker"
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) operatorcontroller.Builder {
+	return operatorcontroller.Adapt(controllerruntime.
+		NewControllerManagedBy(m).
+		For(&v1beta1.NodePool{}).
+		Watches(
+			&v1beta1.NodeClaim{},
+			handler.EnqueueRequestsFromMapFunc(func(_ context.Context, o client.Object) []reconcile.Request {
+				if name, ok := o.GetLabels()[v1beta1.NodePoolLabelKey]; ok {
+					return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name}}}
+				}
+				return nil
+			}),
+		).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 10}))
+}
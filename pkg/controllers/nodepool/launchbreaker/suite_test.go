@@ -0,0 +1,112 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package launchbreaker_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	clock "k8s.io/utils/clock/testing"
+	. "knative.dev/pkg/logging/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/nodepool/launchbreaker"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/operator/controller"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var nodePoolController controller.Controller
+var ctx context.Context
+var env *test.Environment
+var fakeClock *clock.FakeClock
+var cluster *state.Cluster
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "LaunchBreaker")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(scheme.Scheme, test.WithCRDs(apis.CRDs...))
+	fakeClock = clock.NewFakeClock(time.Now())
+	cluster = state.NewCluster(fakeClock, env.Client, fake.NewCloudProvider())
+	nodePoolController = launchbreaker.NewController(env.Client, cluster)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+	cluster.Reset()
+})
+
+var _ = Describe("LaunchBreaker", func() {
+	var nodePool *v1beta1.NodePool
+	BeforeEach(func() {
+		fakeClock.SetTime(time.Now())
+		nodePool = test.NodePool()
+	})
+	It("should not set the LaunchesPaused condition when launches are allowed", func() {
+		ExpectApplied(ctx, env.Client, nodePool)
+		res := ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+		Expect(res.RequeueAfter).To(BeZero())
+
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().GetCondition(v1beta1.LaunchesPaused)).To(BeNil())
+	})
+	It("should set the LaunchesPaused condition and requeue for the backoff when the circuit breaker is open", func() {
+		for i := 0; i < state.LaunchFailureThreshold; i++ {
+			cluster.RecordLaunchFailure(nodePool.Name)
+		}
+		ExpectApplied(ctx, env.Client, nodePool)
+		res := ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+		Expect(res.RequeueAfter).To(Equal(state.LaunchBackoff))
+
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		condition := ExpectStatusConditionExists(nodePool, v1beta1.LaunchesPaused)
+		Expect(condition.Status).To(Equal(v1.ConditionTrue))
+		Expect(condition.Message).To(ContainSubstring(fmt.Sprintf("after %d consecutive launch failures", state.LaunchFailureThreshold)))
+	})
+	It("should clear the LaunchesPaused condition once the backoff has elapsed", func() {
+		for i := 0; i < state.LaunchFailureThreshold; i++ {
+			cluster.RecordLaunchFailure(nodePool.Name)
+		}
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(ExpectStatusConditionExists(nodePool, v1beta1.LaunchesPaused).Status).To(Equal(v1.ConditionTrue))
+
+		fakeClock.Step(state.LaunchBackoff)
+		ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.StatusConditions().GetCondition(v1beta1.LaunchesPaused)).To(BeNil())
+	})
+})
@@ -0,0 +1,187 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package termination
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/pkg/logging"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	operatorcontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+)
+
+var _ operatorcontroller.FinalizingTypedController[*v1beta1.NodePool] = (*Controller)(nil)
+
+// Controller is a NodePool Termination controller that holds a deleted NodePool until its NodeClaims have either
+// been gracefully terminated or orphaned, depending on the operator's configuration. Only NodePools with NodeClaims
+// have the termination finalizer, since there's nothing to terminate or orphan when NodePools without any are deleted.
+type Controller struct {
+	kubeClient client.Client
+}
+
+// NewController is a constructor for the NodePool Termination Controller
+func NewController(kubeClient client.Client) operatorcontroller.Controller {
+	return operatorcontroller.Typed[*v1beta1.NodePool](kubeClient, &Controller{
+		kubeClient: kubeClient,
+	})
+}
+
+func (c *Controller) Reconcile(ctx context.Context, nodePool *v1beta1.NodePool) (reconcile.Result, error) {
+	stored := nodePool.DeepCopy()
+	nodeClaimList := &v1beta1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaimList, client.MatchingLabels{v1beta1.NodePoolLabelKey: nodePool.Name}); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	// NodeClaim events re-enqueue the NodePool, so the finalizer is added once its first NodeClaim is created and
+	// removed once its last NodeClaim is gone
+	if len(nodeClaimList.Items) > 0 {
+		controllerutil.AddFinalizer(nodePool, v1beta1.TerminationFinalizer)
+		if !equality.Semantic.DeepEqual(nodePool, stored) {
+			if err := c.kubeClient.Patch(ctx, nodePool, client.MergeFrom(stored)); err != nil {
+				return reconcile.Result{}, client.IgnoreNotFound(err)
+			}
+		}
+		return reconcile.Result{}, nil
+	}
+	controllerutil.RemoveFinalizer(nodePool, v1beta1.TerminationFinalizer)
+	if !equality.Semantic.DeepEqual(nodePool, stored) {
+		// We call Update() here rather than Patch() since the finalizers are a list, see Finalize()
+		if err := c.kubeClient.Update(ctx, nodePool); err != nil {
+			if errors.IsConflict(err) {
+				return reconcile.Result{Requeue: true}, nil
+			}
+			return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("removing termination finalizer, %w", err))
+		}
+	}
+	return reconcile.Result{}, nil
+}
+
+func (c *Controller) Finalize(ctx context.Context, nodePool *v1beta1.NodePool) (reconcile.Result, error) {
+	stored := nodePool.DeepCopy()
+	if !controllerutil.ContainsFinalizer(nodePool, v1beta1.TerminationFinalizer) {
+		return reconcile.Result{}, nil
+	}
+	nodeClaimList := &v1beta1.NodeClaimList{}
+	if err := c.kubeClient.List(ctx, nodeClaimList, client.MatchingLabels{v1beta1.NodePoolLabelKey: nodePool.Name}); err != nil {
+		return reconcile.Result{}, fmt.Errorf("listing nodeclaims, %w", err)
+	}
+	if options.FromContext(ctx).OrphanNodeClaimsOnNodePoolDeletion {
+		if err := c.orphan(ctx, nodePool, nodeClaimList.Items); err != nil {
+			if errors.IsConflict(err) {
+				return reconcile.Result{Requeue: true}, nil
+			}
+			return reconcile.Result{}, err
+		}
+	} else {
+		if err := c.terminate(ctx, nodeClaimList.Items); err != nil {
+			return reconcile.Result{}, err
+		}
+		// We wait until all the NodeClaims of the NodePool have completed their deletion before removing the finalizer.
+		// NodeClaim deletion events re-enqueue the NodePool.
+		if len(nodeClaimList.Items) > 0 {
+			return reconcile.Result{}, nil
+		}
+	}
+	controllerutil.RemoveFinalizer(nodePool, v1beta1.TerminationFinalizer)
+	if !equality.Semantic.DeepEqual(stored, nodePool) {
+		// We call Update() here rather than Patch() because patching a list with a JSON merge patch
+		// can cause races due to the fact that it fully replaces the list on a change
+		// https://github.com/kubernetes/kubernetes/issues/111643#issuecomment-2016489732
+		if err := c.kubeClient.Update(ctx, nodePool); err != nil {
+			if errors.IsConflict(err) {
+				return reconcile.Result{Requeue: true}, nil
+			}
+			return reconcile.Result{}, client.IgnoreNotFound(fmt.Errorf("removing termination finalizer, %w", err))
+		}
+		logging.FromContext(ctx).Infof("deleted nodepool")
+	}
+	return reconcile.Result{}, nil
+}
+
+// terminate deletes the NodeClaims, which gracefully terminates them through the NodeClaim and Node termination
+// flows, draining their nodes while respecting PodDisruptionBudgets
+func (c *Controller) terminate(ctx context.Context, nodeClaims []v1beta1.NodeClaim) error {
+	var errs error
+	for i := range nodeClaims {
+		// If the NodeClaim is already terminating, we don't need to call Delete again
+		if !nodeClaims[i].DeletionTimestamp.IsZero() {
+			continue
+		}
+		if err := c.kubeClient.Delete(ctx, &nodeClaims[i]); client.IgnoreNotFound(err) != nil {
+			errs = multierr.Append(errs, fmt.Errorf("deleting nodeclaim, %w", err))
+			continue
+		}
+		logging.FromContext(ctx).With("nodeclaim", nodeClaims[i].Name).Infof("terminating nodeclaim of deleted nodepool")
+	}
+	return errs
+}
+
+// orphan removes the NodePool owner reference from the NodeClaims so that they aren't garbage collected along with
+// the NodePool and their nodes keep running
+func (c *Controller) orphan(ctx context.Context, nodePool *v1beta1.NodePool, nodeClaims []v1beta1.NodeClaim) error {
+	for i := range nodeClaims {
+		ownerReferences := lo.Reject(nodeClaims[i].OwnerReferences, func(o metav1.OwnerReference, _ int) bool {
+			return o.UID == nodePool.UID
+		})
+		if len(ownerReferences) == len(nodeClaims[i].OwnerReferences) {
+			continue
+		}
+		nodeClaims[i].OwnerReferences = ownerReferences
+		// We call Update() here rather than Patch() since the owner references are a list, see above
+		if err := c.kubeClient.Update(ctx, &nodeClaims[i]); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("removing nodepool owner reference, %w", err)
+		}
+		logging.FromContext(ctx).With("nodeclaim", nodeClaims[i].Name).Infof("orphaned nodeclaim of deleted nodepool")
+	}
+	return nil
+}
+
+func (*Controller) Name() string {
+	return "nodepool.termination"
+}
+
+func (c *Controller) Builder(_ context.Context, m manager.Manager) operatorcontroller.Builder {
+	return operatorcontroller.Adapt(controllerruntime.
+		NewControllerManagedBy(m).
+		For(&v1beta1.NodePool{}).
+		Watches(
+			&v1beta1.NodeClaim{},
+			handler.EnqueueRequestsFromMapFunc(func(_ context.Context, o client.Object) []reconcile.Request {
+				if name, ok := o.GetLabels()[v1beta1.NodePoolLabelKey]; ok {
+					return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name}}}
+				}
+				return nil
+			}),
+		).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 10}))
+}
@@ -0,0 +1,186 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package termination_test
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	. "knative.dev/pkg/logging/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/apis"
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/controllers/nodepool/termination"
+	"sigs.k8s.io/karpenter/pkg/operator/controller"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var ctx context.Context
+var env *test.Environment
+var nodePoolController controller.Controller
+
+func TestAPIs(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "NodePoolTermination")
+}
+
+var _ = BeforeSuite(func() {
+	env = test.NewEnvironment(scheme.Scheme, test.WithCRDs(apis.CRDs...))
+	nodePoolController = termination.NewController(env.Client)
+})
+
+var _ = AfterSuite(func() {
+	Expect(env.Stop()).To(Succeed(), "Failed to stop environment")
+})
+
+var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options())
+})
+
+var _ = AfterEach(func() {
+	ExpectCleanedUp(ctx, env.Client)
+})
+
+var _ = Describe("Termination", func() {
+	var nodePool *v1beta1.NodePool
+	var nodeClaims []*v1beta1.NodeClaim
+	BeforeEach(func() {
+		nodePool = test.NodePool()
+		ExpectApplied(ctx, env.Client, nodePool)
+
+		nodeClaims = nil
+		for i := 0; i < 3; i++ {
+			nodeClaims = append(nodeClaims, test.NodeClaim(v1beta1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:     map[string]string{v1beta1.NodePoolLabelKey: nodePool.Name},
+					Finalizers: []string{v1beta1.TerminationFinalizer},
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         v1beta1.SchemeGroupVersion.String(),
+							Kind:               "NodePool",
+							Name:               nodePool.Name,
+							UID:                nodePool.UID,
+							BlockOwnerDeletion: lo.ToPtr(true),
+						},
+					},
+				},
+			}))
+		}
+		ExpectApplied(ctx, env.Client, lo.Map(nodeClaims, func(n *v1beta1.NodeClaim, _ int) client.Object { return n })...)
+		ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+	})
+	It("should add the termination finalizer to a NodePool with NodeClaims", func() {
+		Expect(nodePool.Finalizers).To(ContainElement(v1beta1.TerminationFinalizer))
+	})
+	It("should not add the termination finalizer to a NodePool without NodeClaims", func() {
+		otherNodePool := test.NodePool()
+		ExpectApplied(ctx, env.Client, otherNodePool)
+		ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(otherNodePool))
+
+		otherNodePool = ExpectExists(ctx, env.Client, otherNodePool)
+		Expect(otherNodePool.Finalizers).ToNot(ContainElement(v1beta1.TerminationFinalizer))
+	})
+	It("should remove the termination finalizer once the NodePool has no NodeClaims", func() {
+		for _, nodeClaim := range nodeClaims {
+			ExpectFinalizersRemoved(ctx, env.Client, nodeClaim)
+			ExpectDeleted(ctx, env.Client, nodeClaim)
+		}
+		ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+
+		nodePool = ExpectExists(ctx, env.Client, nodePool)
+		Expect(nodePool.Finalizers).ToNot(ContainElement(v1beta1.TerminationFinalizer))
+	})
+	Context("Terminate", func() {
+		It("should gracefully terminate the NodeClaims of a deleted NodePool", func() {
+			Expect(env.Client.Delete(ctx, nodePool)).To(Succeed())
+			ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+
+			for _, nodeClaim := range nodeClaims {
+				nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+				Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeFalse())
+			}
+			// The NodePool is held until its NodeClaims are terminated
+			nodePool = ExpectExists(ctx, env.Client, nodePool)
+			Expect(nodePool.Finalizers).To(ContainElement(v1beta1.TerminationFinalizer))
+		})
+		It("should remove the NodePool once its NodeClaims are terminated", func() {
+			Expect(env.Client.Delete(ctx, nodePool)).To(Succeed())
+			ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+
+			for _, nodeClaim := range nodeClaims {
+				ExpectFinalizersRemoved(ctx, env.Client, nodeClaim)
+				ExpectNotFound(ctx, env.Client, nodeClaim)
+			}
+			ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+			ExpectNotFound(ctx, env.Client, nodePool)
+		})
+		It("should not terminate the NodeClaims of other NodePools", func() {
+			otherNodeClaim := test.NodeClaim(v1beta1.NodeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{v1beta1.NodePoolLabelKey: "other-nodepool"},
+				},
+			})
+			ExpectApplied(ctx, env.Client, otherNodeClaim)
+			Expect(env.Client.Delete(ctx, nodePool)).To(Succeed())
+			ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+
+			otherNodeClaim = ExpectExists(ctx, env.Client, otherNodeClaim)
+			Expect(otherNodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+		})
+	})
+	Context("Orphan", func() {
+		BeforeEach(func() {
+			ctx = options.ToContext(ctx, test.Options(test.OptionsFields{OrphanNodeClaimsOnNodePoolDeletion: lo.ToPtr(true)}))
+		})
+		It("should orphan the NodeClaims of a deleted NodePool", func() {
+			Expect(env.Client.Delete(ctx, nodePool)).To(Succeed())
+			ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+
+			for _, nodeClaim := range nodeClaims {
+				nodeClaim = ExpectExists(ctx, env.Client, nodeClaim)
+				Expect(nodeClaim.DeletionTimestamp.IsZero()).To(BeTrue())
+				Expect(nodeClaim.OwnerReferences).To(BeEmpty())
+			}
+			ExpectNotFound(ctx, env.Client, nodePool)
+		})
+		It("should keep the other owner references of the NodeClaims", func() {
+			ownerReference := metav1.OwnerReference{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+				Name:       "owner",
+				UID:        "owner-uid",
+			}
+			nodeClaims[0].OwnerReferences = append(nodeClaims[0].OwnerReferences, ownerReference)
+			ExpectApplied(ctx, env.Client, nodeClaims[0])
+			Expect(env.Client.Delete(ctx, nodePool)).To(Succeed())
+			ExpectReconcileSucceeded(ctx, nodePoolController, client.ObjectKeyFromObject(nodePool))
+
+			nodeClaim := ExpectExists(ctx, env.Client, nodeClaims[0])
+			Expect(nodeClaim.OwnerReferences).To(ConsistOf(ownerReference))
+		})
+	})
+})
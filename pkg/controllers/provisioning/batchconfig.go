@@ -0,0 +1,121 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioning
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/system"
+	controllerruntime "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	operatorcontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+)
+
+const (
+	// BatchConfigMapName is the name of the ConfigMap in Karpenter's namespace that overrides the batching window
+	// durations at runtime
+	BatchConfigMapName = "karpenter-batch-config"
+
+	BatchMaxDurationKey  = "batchMaxDuration"
+	BatchIdleDurationKey = "batchIdleDuration"
+)
+
+// BatchConfigController watches the batch ConfigMap and reloads the provisioner's batching window durations so that
+// they can be tuned without restarting the operator
+type BatchConfigController struct {
+	kubeClient  client.Client
+	provisioner *Provisioner
+}
+
+// NewBatchConfigController constructs a controller instance
+func NewBatchConfigController(kubeClient client.Client, provisioner *Provisioner) operatorcontroller.Controller {
+	return &BatchConfigController{
+		kubeClient:  kubeClient,
+		provisioner: provisioner,
+	}
+}
+
+func (*BatchConfigController) Name() string {
+	return "provisioner.batchconfig"
+}
+
+// Reconcile the resource
+func (c *BatchConfigController) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	if req.Name != BatchConfigMapName {
+		return reconcile.Result{}, nil
+	}
+	cm := &v1.ConfigMap{}
+	if err := c.kubeClient.Get(ctx, req.NamespacedName, cm); err != nil {
+		if errors.IsNotFound(err) {
+			// fall back to the durations configured through options
+			c.provisioner.batcher.ResetDurations()
+		}
+		return reconcile.Result{}, client.IgnoreNotFound(err)
+	}
+	maxDuration, idleDuration, err := parseBatchDurations(ctx, cm)
+	if err == nil {
+		err = c.provisioner.batcher.SetDurations(maxDuration, idleDuration)
+	}
+	if err != nil {
+		// Retrying won't fix a bad config, so we keep the previous durations until the ConfigMap is updated
+		logging.FromContext(ctx).Errorf("rejecting batch config, keeping previous durations, %s", err)
+		return reconcile.Result{}, nil
+	}
+	logging.FromContext(ctx).With("batch-max-duration", maxDuration, "batch-idle-duration", idleDuration).Infof("reloaded batch config")
+	return reconcile.Result{}, nil
+}
+
+func (c *BatchConfigController) Builder(_ context.Context, m manager.Manager) operatorcontroller.Builder {
+	return operatorcontroller.Adapt(controllerruntime.
+		NewControllerManagedBy(m).
+		For(&v1.ConfigMap{}).
+		WithEventFilter(predicate.NewPredicateFuncs(func(o client.Object) bool {
+			return o.GetNamespace() == system.Namespace() && o.GetName() == BatchConfigMapName
+		})),
+	)
+}
+
+// parseBatchDurations reads the batching window durations from the ConfigMap, falling back to the durations
+// configured through options for any key that isn't set
+func parseBatchDurations(ctx context.Context, cm *v1.ConfigMap) (time.Duration, time.Duration, error) {
+	maxDuration, idleDuration := options.FromContext(ctx).BatchMaxDuration, options.FromContext(ctx).BatchIdleDuration
+	if val, ok := cm.Data[BatchMaxDurationKey]; ok {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return 0, 0, fmt.Errorf("parsing %s, %w", BatchMaxDurationKey, err)
+		}
+		maxDuration = d
+	}
+	if val, ok := cm.Data[BatchIdleDurationKey]; ok {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return 0, 0, fmt.Errorf("parsing %s, %w", BatchIdleDurationKey, err)
+		}
+		idleDuration = d
+	}
+	return maxDuration, idleDuration, nil
+}
@@ -18,6 +18,8 @@ package provisioning
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"time"
 
 	"sigs.k8s.io/karpenter/pkg/operator/options"
@@ -28,6 +30,10 @@ import (
 // maximum batch duration.
 type Batcher struct {
 	trigger chan struct{}
+
+	mu           sync.RWMutex
+	maxDuration  *time.Duration
+	idleDuration *time.Duration
 }
 
 // NewBatcher is a constructor for the Batcher
@@ -47,6 +53,50 @@ func (b *Batcher) Trigger() {
 	}
 }
 
+// SetDurations overrides the batching window durations configured through options. The new durations are used
+// starting with the next batching window. Invalid durations are rejected and the previous durations are kept.
+func (b *Batcher) SetDurations(maxDuration, idleDuration time.Duration) error {
+	if err := validateBatchDurations(maxDuration, idleDuration); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.maxDuration, b.idleDuration = &maxDuration, &idleDuration
+	return nil
+}
+
+// validateBatchDurations ensures that the batching window durations are non-negative and that the max duration is at
+// least as long as the idle duration
+func validateBatchDurations(maxDuration, idleDuration time.Duration) error {
+	if maxDuration < 0 {
+		return fmt.Errorf("batch max duration %s must be non-negative", maxDuration)
+	}
+	if idleDuration < 0 {
+		return fmt.Errorf("batch idle duration %s must be non-negative", idleDuration)
+	}
+	if maxDuration < idleDuration {
+		return fmt.Errorf("batch max duration %s must be greater than or equal to batch idle duration %s", maxDuration, idleDuration)
+	}
+	return nil
+}
+
+// ResetDurations removes any overrides so that the batching window durations configured through options are used
+func (b *Batcher) ResetDurations() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.maxDuration, b.idleDuration = nil, nil
+}
+
+// durations returns the batching window durations, preferring overrides over the configured options
+func (b *Batcher) durations(ctx context.Context) (time.Duration, time.Duration) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.maxDuration != nil && b.idleDuration != nil {
+		return *b.maxDuration, *b.idleDuration
+	}
+	return options.FromContext(ctx).BatchMaxDuration, options.FromContext(ctx).BatchIdleDuration
+}
+
 // Wait starts a batching window and continues waiting as long as it continues receiving triggers within
 // the idleDuration, up to the maxDuration
 func (b *Batcher) Wait(ctx context.Context) bool {
@@ -57,8 +107,9 @@ func (b *Batcher) Wait(ctx context.Context) bool {
 		// If no pods, bail to the outer controller framework to refresh the context
 		return false
 	}
-	timeout := time.NewTimer(options.FromContext(ctx).BatchMaxDuration)
-	idle := time.NewTimer(options.FromContext(ctx).BatchIdleDuration)
+	maxDuration, idleDuration := b.durations(ctx)
+	timeout := time.NewTimer(maxDuration)
+	idle := time.NewTimer(idleDuration)
 	for {
 		select {
 		case <-b.trigger:
@@ -66,7 +117,7 @@ func (b *Batcher) Wait(ctx context.Context) bool {
 			if !idle.Stop() {
 				<-idle.C
 			}
-			idle.Reset(options.FromContext(ctx).BatchIdleDuration)
+			idle.Reset(idleDuration)
 		case <-timeout.C:
 			return true
 		case <-idle.C:
@@ -22,15 +22,20 @@ import (
 
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/events"
 	operatorcontroller "sigs.k8s.io/karpenter/pkg/operator/controller"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	nodeutil "sigs.k8s.io/karpenter/pkg/utils/node"
 	"sigs.k8s.io/karpenter/pkg/utils/pod"
 )
 
@@ -57,10 +62,17 @@ func (*PodController) Name() string {
 }
 
 // Reconcile the resource
-func (c *PodController) Reconcile(_ context.Context, p *v1.Pod) (reconcile.Result, error) {
-	if !pod.IsProvisionable(p) {
+func (c *PodController) Reconcile(ctx context.Context, p *v1.Pod) (reconcile.Result, error) {
+	// Pods of other schedulers are placed by their scheduler, so they never need capacity from us
+	if !options.FromContext(ctx).IsManagedScheduler(p.Spec.SchedulerName) {
 		return reconcile.Result{}, nil
 	}
+	if !pod.IsProvisionable(p) {
+		orphaned, recheckAfter, err := nodeutil.IsOrphaned(ctx, c.kubeClient, p)
+		if err != nil || !orphaned {
+			return reconcile.Result{RequeueAfter: recheckAfter}, err
+		}
+	}
 	c.provisioner.Trigger()
 	// Continue to requeue until the pod is no longer provisionable. Pods may
 	// not be scheduled as expected if new pods are created while nodes are
@@ -122,3 +134,57 @@ func (*NodeController) Builder(_ context.Context, m manager.Manager) operatorcon
 		WithOptions(controller.Options{MaxConcurrentReconciles: 10}),
 	)
 }
+
+var _ operatorcontroller.TypedController[*v1beta1.NodePool] = (*NodePoolController)(nil)
+
+// NodePoolController for the resource
+type NodePoolController struct {
+	kubeClient  client.Client
+	provisioner *Provisioner
+	cluster     *state.Cluster
+}
+
+// NewNodePoolController constructs a controller instance
+func NewNodePoolController(kubeClient client.Client, provisioner *Provisioner, cluster *state.Cluster) operatorcontroller.Controller {
+	return operatorcontroller.Typed[*v1beta1.NodePool](kubeClient, &NodePoolController{
+		kubeClient:  kubeClient,
+		provisioner: provisioner,
+		cluster:     cluster,
+	})
+}
+
+func (*NodePoolController) Name() string {
+	return "provisioner.trigger.nodepool"
+}
+
+// Reconcile the resource
+func (c *NodePoolController) Reconcile(_ context.Context, np *v1beta1.NodePool) (reconcile.Result, error) {
+	if np.Spec.MinNodes == 0 || !np.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+	// Nodes that are marked for deletion aren't counted, since they're about to be removed
+	if nodeCounts(c.cluster)[np.Name] >= int(np.Spec.MinNodes) {
+		return reconcile.Result{}, nil
+	}
+	c.provisioner.Trigger()
+	// Continue to requeue until the nodepool has its min nodes, since launches for it may fail or be refused by its
+	// limits without any of the nodeclaims that we watch changing.
+	return reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+}
+
+func (*NodePoolController) Builder(_ context.Context, m manager.Manager) operatorcontroller.Builder {
+	return operatorcontroller.Adapt(controllerruntime.
+		NewControllerManagedBy(m).
+		For(&v1beta1.NodePool{}).
+		Watches(
+			&v1beta1.NodeClaim{},
+			handler.EnqueueRequestsFromMapFunc(func(_ context.Context, o client.Object) []reconcile.Request {
+				if name, ok := o.GetLabels()[v1beta1.NodePoolLabelKey]; ok {
+					return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name}}}
+				}
+				return nil
+			}),
+		).
+		WithOptions(controller.Options{MaxConcurrentReconciles: 10}),
+	)
+}
@@ -0,0 +1,45 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioning
+
+import (
+	"context"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+)
+
+// LaunchPolicy is consulted by the provisioner with every NodeClaim that it plans to launch. This allows launches to be
+// vetoed by an external policy decision point, e.g. one that disallows certain instance types in certain zones.
+type LaunchPolicy interface {
+	// Admit returns nil if the NodeClaim may be launched, or an error describing why the launch is vetoed
+	Admit(context.Context, *v1beta1.NodeClaim) error
+}
+
+// PermissiveLaunchPolicy is the default LaunchPolicy, which admits every NodeClaim
+type PermissiveLaunchPolicy struct{}
+
+func (PermissiveLaunchPolicy) Admit(context.Context, *v1beta1.NodeClaim) error {
+	return nil
+}
+
+// WithLaunchPolicy causes the provisioner to consult the LaunchPolicy before launching each NodeClaim
+func WithLaunchPolicy(launchPolicy LaunchPolicy) func(ProvisionerOptions) ProvisionerOptions {
+	return func(o ProvisionerOptions) ProvisionerOptions {
+		o.LaunchPolicy = launchPolicy
+		return o
+	}
+}
@@ -0,0 +1,55 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioning
+
+import (
+	"context"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	scheduler "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+)
+
+// LimitProvider resolves the resource limits that the provisioner enforces for a NodePool. This allows the limits of
+// a NodePool to be driven by an external quota source rather than by editing the NodePool itself.
+type LimitProvider interface {
+	// Limits returns the effective limits of the NodePool. Nil limits mean that the NodePool is unlimited.
+	Limits(context.Context, *v1beta1.NodePool) (v1beta1.Limits, error)
+}
+
+// StaticLimitProvider is the default LimitProvider, which enforces the spec.limits of the NodePool
+type StaticLimitProvider struct{}
+
+func (StaticLimitProvider) Limits(_ context.Context, nodePool *v1beta1.NodePool) (v1beta1.Limits, error) {
+	return nodePool.Spec.Limits, nil
+}
+
+// ProvisionerOptions are the set of options that can be used to configure the provisioner
+type ProvisionerOptions struct {
+	LimitProvider      LimitProvider
+	LaunchPolicy       LaunchPolicy
+	NodeClaimDecorator NodeClaimDecorator
+	SchedulingFilter   scheduler.SchedulingFilter
+}
+
+// WithLimitProvider causes the provisioner to enforce the limits resolved by the LimitProvider instead of the
+// spec.limits of each NodePool.
+func WithLimitProvider(limitProvider LimitProvider) func(ProvisionerOptions) ProvisionerOptions {
+	return func(o ProvisionerOptions) ProvisionerOptions {
+		o.LimitProvider = limitProvider
+		return o
+	}
+}
@@ -24,9 +24,11 @@ import (
 )
 
 func init() {
-	crmetrics.Registry.MustRegister(schedulingDuration)
+	crmetrics.Registry.MustRegister(schedulingDuration, wouldLaunchCounter, nodePoolSelectedCounter)
 }
 
+const instanceTypeLabel = "instance_type"
+
 var schedulingDuration = prometheus.NewHistogram(
 	prometheus.HistogramOpts{
 		Namespace: metrics.Namespace,
@@ -36,3 +38,23 @@ var schedulingDuration = prometheus.NewHistogram(
 		Buckets:   metrics.DurationBuckets(),
 	},
 )
+
+var wouldLaunchCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "provisioner",
+		Name:      "would_launch_total",
+		Help:      "Number of nodeclaims that would have been launched while running in dry-run mode. Labeled by nodepool and the cheapest compatible instance type.",
+	},
+	[]string{metrics.NodePoolLabel, instanceTypeLabel},
+)
+
+var nodePoolSelectedCounter = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "provisioner",
+		Name:      "nodepool_selected_total",
+		Help:      "Number of nodeclaims launched for a nodepool selected by the scheduler. Labeled by nodepool.",
+	},
+	[]string{metrics.NodePoolLabel},
+)
@@ -0,0 +1,84 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioning
+
+import (
+	"context"
+	"strings"
+
+	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+)
+
+// NodeClaimDecorator is invoked by the provisioner with every NodeClaim before it's created. This allows platform teams
+// to enforce their naming and tagging conventions, e.g. by adding labels, annotations and finalizers or by changing the
+// prefix that NodeClaim names are generated from.
+type NodeClaimDecorator interface {
+	// Decorate mutates the NodeClaim in place, or returns an error to fail the launch
+	Decorate(context.Context, *v1beta1.NodeClaim) error
+}
+
+// NopNodeClaimDecorator is the default NodeClaimDecorator, which leaves every NodeClaim unchanged
+type NopNodeClaimDecorator struct{}
+
+func (NopNodeClaimDecorator) Decorate(context.Context, *v1beta1.NodeClaim) error {
+	return nil
+}
+
+// WithNodeClaimDecorator causes the provisioner to invoke the NodeClaimDecorator before creating each NodeClaim
+func WithNodeClaimDecorator(nodeClaimDecorator NodeClaimDecorator) func(ProvisionerOptions) ProvisionerOptions {
+	return func(o ProvisionerOptions) ProvisionerOptions {
+		o.NodeClaimDecorator = nodeClaimDecorator
+		return o
+	}
+}
+
+// decorate invokes the decorator with a copy of the NodeClaim and merges the result back into the NodeClaim. Only
+// additions are merged: labels and annotations that are already set, labels in restricted domains, annotations in
+// Karpenter's domains and the rest of the NodeClaim's spec are left as they were.
+func decorate(ctx context.Context, decorator NodeClaimDecorator, nodeClaim *v1beta1.NodeClaim) error {
+	decorated := nodeClaim.DeepCopy()
+	if err := decorator.Decorate(ctx, decorated); err != nil {
+		return err
+	}
+	for k, v := range decorated.Labels {
+		if _, ok := nodeClaim.Labels[k]; ok || v1beta1.IsRestrictedNodeLabel(k) {
+			continue
+		}
+		nodeClaim.Labels = lo.Assign(nodeClaim.Labels, map[string]string{k: v})
+	}
+	for k, v := range decorated.Annotations {
+		if _, ok := nodeClaim.Annotations[k]; ok || isKarpenterAnnotation(k) {
+			continue
+		}
+		nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{k: v})
+	}
+	for _, finalizer := range decorated.Finalizers {
+		controllerutil.AddFinalizer(nodeClaim, finalizer)
+	}
+	if decorated.GenerateName != "" {
+		nodeClaim.GenerateName = decorated.GenerateName
+	}
+	return nil
+}
+
+func isKarpenterAnnotation(key string) bool {
+	domain := v1beta1.GetLabelDomain(key)
+	return strings.HasSuffix(domain, v1beta1.Group) || strings.HasSuffix(domain, v1beta1.CompatabilityGroup)
+}
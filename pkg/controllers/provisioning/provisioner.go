@@ -25,9 +25,11 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/multierr"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/util/workqueue"
@@ -40,12 +42,16 @@ import (
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/operator/controller"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/tracing"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 	"sigs.k8s.io/karpenter/pkg/utils/functional"
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	scheduler "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/snapshot"
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/metrics"
@@ -73,26 +79,39 @@ func WithReason(reason string) func(LaunchOptions) LaunchOptions {
 
 // Provisioner waits for enqueued pods, batches them, creates capacity and binds the pods to the capacity.
 type Provisioner struct {
-	cloudProvider  cloudprovider.CloudProvider
-	kubeClient     client.Client
-	batcher        *Batcher
-	volumeTopology *scheduler.VolumeTopology
-	cluster        *state.Cluster
-	recorder       events.Recorder
-	cm             *pretty.ChangeMonitor
+	cloudProvider         cloudprovider.CloudProvider
+	kubeClient            client.Client
+	batcher               *Batcher
+	volumeTopology        *scheduler.VolumeTopology
+	resourceClaimTopology *scheduler.ResourceClaimTopology
+	namespaceCache        *scheduler.NamespaceCache
+	cluster               *state.Cluster
+	recorder              events.Recorder
+	limitProvider         LimitProvider
+	launchPolicy          LaunchPolicy
+	nodeClaimDecorator    NodeClaimDecorator
+	schedulingFilter      scheduler.SchedulingFilter
+	cm                    *pretty.ChangeMonitor
 }
 
 func NewProvisioner(kubeClient client.Client, recorder events.Recorder,
-	cloudProvider cloudprovider.CloudProvider, cluster *state.Cluster,
+	cloudProvider cloudprovider.CloudProvider, cluster *state.Cluster, opts ...functional.Option[ProvisionerOptions],
 ) *Provisioner {
+	o := functional.ResolveOptions(opts...)
 	p := &Provisioner{
-		batcher:        NewBatcher(),
-		cloudProvider:  cloudProvider,
-		kubeClient:     kubeClient,
-		volumeTopology: scheduler.NewVolumeTopology(kubeClient),
-		cluster:        cluster,
-		recorder:       recorder,
-		cm:             pretty.NewChangeMonitor(),
+		batcher:               NewBatcher(),
+		cloudProvider:         cloudProvider,
+		kubeClient:            kubeClient,
+		volumeTopology:        scheduler.NewVolumeTopology(kubeClient),
+		resourceClaimTopology: scheduler.NewResourceClaimTopology(kubeClient),
+		namespaceCache:        scheduler.NewNamespaceCache(kubeClient),
+		cluster:               cluster,
+		recorder:              recorder,
+		limitProvider:         lo.Ternary[LimitProvider](o.LimitProvider != nil, o.LimitProvider, StaticLimitProvider{}),
+		launchPolicy:          lo.Ternary[LaunchPolicy](o.LaunchPolicy != nil, o.LaunchPolicy, PermissiveLaunchPolicy{}),
+		nodeClaimDecorator:    lo.Ternary[NodeClaimDecorator](o.NodeClaimDecorator != nil, o.NodeClaimDecorator, NopNodeClaimDecorator{}),
+		schedulingFilter:      lo.Ternary[scheduler.SchedulingFilter](o.SchedulingFilter != nil, o.SchedulingFilter, scheduler.NopSchedulingFilter{}),
+		cm:                    pretty.NewChangeMonitor(),
 	}
 	return p
 }
@@ -114,6 +133,10 @@ func (p *Provisioner) Reconcile(ctx context.Context, _ reconcile.Request) (resul
 	if triggered := p.batcher.Wait(ctx); !triggered {
 		return reconcile.Result{}, nil
 	}
+	// The span is only started once the batch triggers, so that idle loops don't emit any traces
+	ctx, span := tracing.Tracer().Start(ctx, "provisioning.Reconcile")
+	defer func() { tracing.End(span, err) }()
+
 	// We need to ensure that our internal cluster state mechanism is synced before we proceed
 	// with making any scheduling decision off of our state nodes. Otherwise, we have the potential to make
 	// a scheduling decision based on a smaller subset of nodes in our cluster state than actually exist.
@@ -130,6 +153,10 @@ func (p *Provisioner) Reconcile(ctx context.Context, _ reconcile.Request) (resul
 	if len(results.NewNodeClaims) == 0 {
 		return reconcile.Result{}, nil
 	}
+	if options.FromContext(ctx).DryRun {
+		p.reportWouldLaunch(ctx, results.NewNodeClaims)
+		return reconcile.Result{}, nil
+	}
 	_, err = p.CreateNodeClaims(ctx, results.NewNodeClaims, WithReason(metrics.ProvisioningReason), RecordPodNomination)
 	return reconcile.Result{}, err
 }
@@ -191,20 +218,41 @@ func (p *Provisioner) consolidationWarnings(ctx context.Context, po *v1.Pod) {
 
 var ErrNodePoolsNotFound = errors.New("no nodepools found")
 
-//nolint:gocyclo
-func (p *Provisioner) NewScheduler(ctx context.Context, pods []*v1.Pod, stateNodes []*state.StateNode) (*scheduler.Scheduler, error) {
+func (p *Provisioner) NewScheduler(ctx context.Context, pods []*v1.Pod, stateNodes []*state.StateNode, opts ...functional.Option[scheduler.SchedulerOptions]) (*scheduler.Scheduler, error) {
 	nodePoolList := &v1beta1.NodePoolList{}
-	err := p.kubeClient.List(ctx, nodePoolList)
-	if err != nil {
+	if err := p.kubeClient.List(ctx, nodePoolList); err != nil {
 		return nil, fmt.Errorf("listing node pools, %w", err)
 	}
+	return p.newScheduler(ctx, nodePoolList, pods, stateNodes, opts...)
+}
+
+// newScheduler creates a scheduler for the pods against the state nodes and the given NodePools
+//
+//nolint:gocyclo
+func (p *Provisioner) newScheduler(ctx context.Context, nodePoolList *v1beta1.NodePoolList, pods []*v1.Pod, stateNodes []*state.StateNode, opts ...functional.Option[scheduler.SchedulerOptions]) (*scheduler.Scheduler, error) {
 	nodePoolList.Items = lo.Filter(nodePoolList.Items, func(n v1beta1.NodePool, _ int) bool {
 		if err := n.RuntimeValidate(); err != nil {
 			logging.FromContext(ctx).With("nodepool", n.Name).Errorf("nodepool failed validation, %s", err)
 			return false
 		}
+		// Pods shouldn't be scheduled against NodePools that are failing to launch, as the resulting NodeClaims would
+		// not be launched until the NodePool's launch circuit breaker closes
+		if d := p.cluster.LaunchesPausedFor(n.Name); d > 0 {
+			logging.FromContext(ctx).With("nodepool", n.Name).Debugf("skipping, launches are paused for %s after repeated launch failures", d)
+			return false
+		}
 		return n.DeletionTimestamp.IsZero()
 	})
+	// The scheduler enforces the limits of the NodePools it is given, so we substitute in their effective limits
+	nodePoolList.Items = lo.FilterMap(nodePoolList.Items, func(n v1beta1.NodePool, _ int) (v1beta1.NodePool, bool) {
+		limits, err := p.limitProvider.Limits(ctx, &n)
+		if err != nil {
+			logging.FromContext(ctx).With("nodepool", n.Name).Errorf("skipping, unable to resolve limits, %s", err)
+			return n, false
+		}
+		n.Spec.Limits = limits
+		return n, true
+	})
 	if len(nodePoolList.Items) == 0 {
 		return nil, ErrNodePoolsNotFound
 	}
@@ -225,6 +273,10 @@ func (p *Provisioner) NewScheduler(ctx context.Context, pods []*v1.Pod, stateNod
 			logging.FromContext(ctx).With("nodepool", nodePool.Name).Errorf("skipping, unable to resolve instance types, %s", err)
 			continue
 		}
+		// Excluded and deprecated instance types are filtered out of every nodepool on top of the nodepool's own requirements
+		instanceTypeOptions = lo.Reject(instanceTypeOptions, func(it *cloudprovider.InstanceType, _ int) bool {
+			return options.FromContext(ctx).IsExcludedInstanceType(it.Name) || it.Deprecated
+		})
 		if len(instanceTypeOptions) == 0 {
 			logging.FromContext(ctx).With("nodepool", nodePool.Name).Info("skipping, no resolved instance types found")
 			continue
@@ -268,6 +320,7 @@ func (p *Provisioner) NewScheduler(ctx context.Context, pods []*v1.Pod, stateNod
 
 	// inject topology constraints
 	pods = p.injectVolumeTopologyRequirements(ctx, pods)
+	pods = p.injectResourceClaimRequirements(ctx, pods)
 
 	// Calculate cluster topology
 	topology, err := scheduler.NewTopology(ctx, p.kubeClient, p.cluster, domains, pods)
@@ -278,13 +331,61 @@ func (p *Provisioner) NewScheduler(ctx context.Context, pods []*v1.Pod, stateNod
 	if err != nil {
 		return nil, fmt.Errorf("getting daemon pods, %w", err)
 	}
-	return scheduler.NewScheduler(ctx, p.kubeClient, lo.ToSlicePtr(nodePoolList.Items), p.cluster, stateNodes, topology, instanceTypes, daemonSetPods, p.recorder), nil
+	opts = append(opts, lo.Ternary(options.FromContext(ctx).FeatureGates.PreferExistingNodes, scheduler.PreferExistingNodes, nil), scheduler.WithSchedulingFilter(p.schedulingFilter), scheduler.WithNamespaceCache(p.namespaceCache))
+	return scheduler.NewScheduler(ctx, p.kubeClient, lo.ToSlicePtr(nodePoolList.Items), p.cluster, stateNodes, topology, instanceTypes, daemonSetPods, p.recorder, opts...), nil
+}
+
+// SimulateWithNodePools simulates scheduling the pending pods against the cluster's nodes and NodePools, with the
+// given hypothetical NodePools layered on top of the NodePools in the cluster. A hypothetical NodePool replaces the
+// NodePool of the same name in the cluster. The hypothetical NodePools don't need to exist, and nothing is launched or
+// recorded for the pods, which allows evaluating where pods would schedule before a NodePool is applied.
+func (p *Provisioner) SimulateWithNodePools(ctx context.Context, nodePools ...*v1beta1.NodePool) (scheduler.Results, error) {
+	nodes := p.cluster.Nodes()
+	pendingPods, err := p.GetPendingPods(ctx)
+	if err != nil {
+		return scheduler.Results{}, err
+	}
+	deletingNodePods, err := nodes.Deleting().ReschedulablePods(ctx, p.kubeClient)
+	if err != nil {
+		return scheduler.Results{}, err
+	}
+	pods := append(pendingPods, deletingNodePods...)
+
+	nodePoolList := &v1beta1.NodePoolList{}
+	if err = p.kubeClient.List(ctx, nodePoolList); err != nil {
+		return scheduler.Results{}, fmt.Errorf("listing node pools, %w", err)
+	}
+	hypothetical := sets.New(lo.Map(nodePools, func(n *v1beta1.NodePool, _ int) string { return n.Name })...)
+	nodePoolList.Items = append(lo.Reject(nodePoolList.Items, func(n v1beta1.NodePool, _ int) bool {
+		return hypothetical.Has(n.Name)
+	}), lo.Map(nodePools, func(n *v1beta1.NodePool, _ int) v1beta1.NodePool { return *n.DeepCopy() })...)
+
+	s, err := p.newScheduler(ctx, nodePoolList, pods, nodes.Active())
+	if err != nil {
+		return scheduler.Results{}, fmt.Errorf("creating scheduler, %w", err)
+	}
+	return s.Solve(ctx, pods).TruncateInstanceTypes(scheduler.MaxInstanceTypes), nil
 }
 
 func (p *Provisioner) Schedule(ctx context.Context) (scheduler.Results, error) {
 	defer metrics.Measure(schedulingDuration)()
+	ctx, span := tracing.Tracer().Start(ctx, "provisioning.Schedule")
+	defer span.End()
 	start := time.Now()
 
+	// NodePools that are below their minNodes need NodeClaims even when there are no pods to schedule
+	belowMinNodes, err := p.belowMinNodes(ctx)
+	if err != nil {
+		return scheduler.Results{}, err
+	}
+	// Exit before snapshotting the cluster state if there's nothing to schedule, since the snapshot is expensive on
+	// large clusters and idle clusters would otherwise pay for it on every reconcile
+	if idle, err := p.idle(ctx); err != nil {
+		return scheduler.Results{}, err
+	} else if idle && !belowMinNodes {
+		return scheduler.Results{}, nil
+	}
+
 	// We collect the nodes with their used capacities before we get the list of pending pods. This ensures that
 	// the node capacities we schedule against are always >= what the actual capacity is at any given instance. This
 	// prevents over-provisioning at the cost of potentially under-provisioning which will self-heal during the next
@@ -310,11 +411,12 @@ func (p *Provisioner) Schedule(ctx context.Context) (scheduler.Results, error) {
 		return scheduler.Results{}, err
 	}
 	pods := append(pendingPods, deletingNodePods...)
+	span.SetAttributes(tracing.PodsKey.Int(len(pods)), tracing.NodesKey.Int(len(nodes)))
 	// nothing to schedule, so just return success
-	if len(pods) == 0 {
+	if len(pods) == 0 && !belowMinNodes {
 		return scheduler.Results{}, nil
 	}
-	s, err := p.NewScheduler(ctx, pods, nodes.Active())
+	s, err := p.NewScheduler(ctx, pods, nodes.Active(), scheduler.MaintainMinNodes)
 	if err != nil {
 		if errors.Is(err, ErrNodePoolsNotFound) {
 			logging.FromContext(ctx).Info(ErrNodePoolsNotFound)
@@ -322,25 +424,123 @@ func (p *Provisioner) Schedule(ctx context.Context) (scheduler.Results, error) {
 		}
 		return scheduler.Results{}, fmt.Errorf("creating scheduler, %w", err)
 	}
-	results := s.Solve(ctx, pods).TruncateInstanceTypes(scheduler.MaxInstanceTypes)
-	logging.FromContext(ctx).With("pods", pretty.Slice(lo.Map(pods, func(p *v1.Pod, _ int) string { return client.ObjectKeyFromObject(p).String() }), 5)).
-		With("duration", time.Since(start)).
-		Infof("found provisionable pod(s)")
+	if snapshot.Enabled(ctx) {
+		p.recordSnapshot(ctx, s, pods, nodes)
+	}
+	solveCtx, solveSpan := tracing.Tracer().Start(ctx, "provisioning.Solve")
+	results := s.Solve(solveCtx, pods).TruncateInstanceTypes(scheduler.MaxInstanceTypes)
+	solveSpan.SetAttributes(tracing.NodeClaimsKey.Int(len(results.NewNodeClaims)))
+	solveSpan.End()
+	span.SetAttributes(tracing.NodeClaimsKey.Int(len(results.NewNodeClaims)))
+	if len(pods) > 0 {
+		logging.FromContext(ctx).With("pods", pretty.Slice(lo.Map(pods, func(p *v1.Pod, _ int) string { return client.ObjectKeyFromObject(p).String() }), 5)).
+			With("duration", time.Since(start)).
+			Infof("found provisionable pod(s)")
+	}
 	results.Record(ctx, p.recorder, p.cluster)
 	return results, nil
 }
 
-func (p *Provisioner) Create(ctx context.Context, n *scheduler.NodeClaim, opts ...functional.Option[LaunchOptions]) (string, error) {
+// recordSnapshot records the cluster state that pods are being scheduled against, so that it can be served for
+// debugging and the scheduling decision can be reproduced offline. It reuses the instance types that the scheduler
+// resolved, and copies the pods since scheduling them mutates them while the snapshot may be served.
+func (p *Provisioner) recordSnapshot(ctx context.Context, s *scheduler.Scheduler, pods []*v1.Pod, nodes state.StateNodes) {
+	nodePoolList := &v1beta1.NodePoolList{}
+	if err := p.kubeClient.List(ctx, nodePoolList); err != nil {
+		logging.FromContext(ctx).Errorf("snapshotting cluster state, listing node pools, %s", err)
+		return
+	}
+	snapshot.Snapshots.Record(ctx, &snapshot.Snapshot{
+		Time:      time.Now(),
+		NodePools: nodePoolList.Items,
+		InstanceTypes: lo.MapValues(s.InstanceTypes(), func(instanceTypes []*cloudprovider.InstanceType, _ string) []snapshot.InstanceType {
+			return lo.Map(instanceTypes, func(it *cloudprovider.InstanceType, _ int) snapshot.InstanceType { return snapshot.NewInstanceType(it) })
+		}),
+		Nodes: snapshot.NewNodes(ctx, p.kubeClient, nodes),
+		Pods:  lo.Map(pods, func(p *v1.Pod, _ int) *v1.Pod { return p.DeepCopy() }),
+	})
+}
+
+// idle returns true when there are no provisionable pods and no nodes are being deleted, so there are no pods to
+// schedule. This check is cheap since it doesn't copy the cluster state nodes or validate the pods, which is left to
+// Schedule when there may be pods to schedule.
+func (p *Provisioner) idle(ctx context.Context) (bool, error) {
+	deleting := false
+	p.cluster.ForEachNode(func(n *state.StateNode) bool {
+		deleting = n.MarkedForDeletion()
+		return !deleting
+	})
+	if deleting {
+		return false, nil
+	}
+	pods, err := nodeutil.GetProvisionablePods(ctx, p.kubeClient)
+	if err != nil {
+		return false, fmt.Errorf("listing pods, %w", err)
+	}
+	return len(pods) == 0, nil
+}
+
+// belowMinNodes returns true if any NodePool has fewer nodes than its minNodes
+func (p *Provisioner) belowMinNodes(ctx context.Context) (bool, error) {
+	nodePoolList := &v1beta1.NodePoolList{}
+	if err := p.kubeClient.List(ctx, nodePoolList); err != nil {
+		return false, fmt.Errorf("listing node pools, %w", err)
+	}
+	if !lo.ContainsBy(nodePoolList.Items, func(np v1beta1.NodePool) bool { return np.Spec.MinNodes > 0 }) {
+		return false, nil
+	}
+	counts := nodeCounts(p.cluster)
+	return lo.ContainsBy(nodePoolList.Items, func(np v1beta1.NodePool) bool {
+		return np.DeletionTimestamp.IsZero() && counts[np.Name] < int(np.Spec.MinNodes)
+	}), nil
+}
+
+// nodeCounts returns the number of nodes of each NodePool that count towards its minNodes. This walks the cluster state
+// rather than copying its nodes, since it's checked on every provisioning loop.
+func nodeCounts(cluster *state.Cluster) map[string]int {
+	counts := map[string]int{}
+	cluster.ForEachNode(func(n *state.StateNode) bool {
+		if nodePoolName, ok := n.Labels()[v1beta1.NodePoolLabelKey]; ok && !n.MarkedForDeletion() {
+			counts[nodePoolName]++
+		}
+		return true
+	})
+	return counts
+}
+
+func (p *Provisioner) Create(ctx context.Context, n *scheduler.NodeClaim, opts ...functional.Option[LaunchOptions]) (_ string, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "provisioning.Launch", trace.WithAttributes(tracing.NodePoolKey.String(n.NodePoolName), tracing.PodsKey.Int(len(n.Pods))))
+	defer func() { tracing.End(span, err) }()
 	ctx = logging.WithLogger(ctx, logging.FromContext(ctx).With("nodepool", n.NodePoolName))
 	options := functional.ResolveOptions(opts...)
 	latest := &v1beta1.NodePool{}
 	if err := p.kubeClient.Get(ctx, types.NamespacedName{Name: n.NodePoolName}, latest); err != nil {
 		return "", fmt.Errorf("getting current resource usage, %w", err)
 	}
-	if err := latest.Spec.Limits.ExceededBy(latest.Status.Resources); err != nil {
+	limits, err := p.limitProvider.Limits(ctx, latest)
+	if err != nil {
+		return "", fmt.Errorf("resolving limits, %w", err)
+	}
+	if err := limits.ExceededBy(latest.Status.Resources); err != nil {
 		return "", err
 	}
+	// The node count isn't part of the nodepool's status, so we count its nodes in cluster state, including the ones
+	// that are still launching
+	if limit, ok := limits[v1beta1.ResourceNodes]; ok {
+		if count := nodeCounts(p.cluster)[n.NodePoolName]; int64(count) >= limit.Value() {
+			return "", fmt.Errorf("%s resource usage of %d has reached limit of %v", v1beta1.ResourceNodes, count, limit.AsDec())
+		}
+	}
 	nodeClaim := n.ToNodeClaim(latest)
+	if err := decorate(ctx, p.nodeClaimDecorator, nodeClaim); err != nil {
+		return "", fmt.Errorf("decorating nodeclaim, %w", err)
+	}
+	if err := p.launchPolicy.Admit(ctx, nodeClaim); err != nil {
+		for _, pod := range n.Pods {
+			p.recorder.Publish(scheduler.LaunchVetoedEvent(pod, n.NodePoolName, err))
+		}
+		return "", fmt.Errorf("launch vetoed by policy, %w", err)
+	}
 
 	if err := p.kubeClient.Create(ctx, nodeClaim); err != nil {
 		return "", err
@@ -354,12 +554,16 @@ func (p *Provisioner) Create(ctx context.Context, n *scheduler.NodeClaim, opts .
 		metrics.NodePoolLabel:     nodeClaim.Labels[v1beta1.NodePoolLabelKey],
 		metrics.CapacityTypeLabel: nodeClaim.Labels[v1beta1.CapacityTypeLabelKey],
 	}).Inc()
+	// Scheduling simulations select nodepools too, so we only count the selections that result in a launch
+	nodePoolSelectedCounter.With(prometheus.Labels{metrics.NodePoolLabel: n.NodePoolName}).Inc()
 	// Update the nodeclaim manually in state to avoid evenutal consistency delay races with our watcher.
 	// This is essential to avoiding races where disruption can create a replacement node, then immediately
 	// requeue. This can race with controller-runtime's internal cache as it watches events on the cluster
 	// to then trigger cluster state updates. Triggering it manually ensures that Karpenter waits for the
 	// internal cache to sync before moving onto another disruption loop.
 	p.cluster.UpdateNodeClaim(nodeClaim)
+	// The pods are told which instance type was launched for them once the NodeClaim is launched
+	p.cluster.RecordLaunchPods(nodeClaim.Name, n.Pods)
 	if functional.ResolveOptions(opts...).RecordPodNomination {
 		for _, pod := range n.Pods {
 			p.recorder.Publish(scheduler.NominatePodEvent(pod, nil, nodeClaim))
@@ -368,6 +572,23 @@ func (p *Provisioner) Create(ctx context.Context, n *scheduler.NodeClaim, opts .
 	return nodeClaim.Name, nil
 }
 
+// reportWouldLaunch logs, records events and metrics for the NodeClaims that would be launched without creating them
+func (p *Provisioner) reportWouldLaunch(ctx context.Context, nodeClaims []*scheduler.NodeClaim) {
+	for _, n := range nodeClaims {
+		instanceTypes := lo.Map(n.InstanceTypeOptions.OrderByPrice(n.Requirements), func(it *cloudprovider.InstanceType, _ int) string { return it.Name })
+		logging.FromContext(ctx).With("nodepool", n.NodePoolName, "pods", len(n.Pods), "requests", n.Spec.Resources.Requests, "instance-types", instanceTypeList(instanceTypes)).Infof("would create nodeclaim (dry-run)")
+		// Scheduled nodeclaims always have at least one instance type option. The cheapest is the one most likely to be
+		// launched, so we label with it to keep cardinality bounded
+		wouldLaunchCounter.With(prometheus.Labels{
+			metrics.NodePoolLabel: n.NodePoolName,
+			instanceTypeLabel:     instanceTypes[0],
+		}).Inc()
+		for _, pod := range n.Pods {
+			p.recorder.Publish(scheduler.WouldLaunchPodEvent(pod, n.NodePoolName, instanceTypes))
+		}
+	}
+}
+
 func instanceTypeList(names []string) string {
 	var itSb strings.Builder
 	for i, name := range names {
@@ -392,7 +613,9 @@ func (p *Provisioner) getDaemonSetPods(ctx context.Context) ([]*v1.Pod, error) {
 	return lo.Map(daemonSetList.Items, func(d appsv1.DaemonSet, _ int) *v1.Pod {
 		pod := p.cluster.GetDaemonSetPod(&d)
 		if pod == nil {
-			pod = &v1.Pod{Spec: d.Spec.Template.Spec}
+			pod = &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: d.Namespace}, Spec: *d.Spec.Template.Spec.DeepCopy()}
+			// Unlike the DaemonSet's pods, its pod template isn't defaulted when it's created
+			p.injectLimitRangeDefaults(ctx, pod)
 		}
 		// Replacing retrieved pod affinity with daemonset pod template required node affinity since this is overridden
 		// by the daemonset controller during pod creation
@@ -412,13 +635,25 @@ func (p *Provisioner) getDaemonSetPods(ctx context.Context) ([]*v1.Pod, error) {
 
 func (p *Provisioner) Validate(ctx context.Context, pod *v1.Pod) error {
 	return multierr.Combine(
+		validateSchedulerName(ctx, pod),
 		validateKarpenterManagedLabelCanExist(pod),
+		validateDedicated(pod),
 		validateNodeSelector(pod),
 		validateAffinity(pod),
 		p.volumeTopology.ValidatePersistentVolumeClaims(ctx, pod),
+		p.resourceClaimTopology.ValidateResourceClaims(ctx, pod),
 	)
 }
 
+// validateSchedulerName ensures that the pod is placed by a scheduler that Karpenter provisions for, since other
+// schedulers own the placement of their pods
+func validateSchedulerName(ctx context.Context, p *v1.Pod) error {
+	if !options.FromContext(ctx).IsManagedScheduler(p.Spec.SchedulerName) {
+		return fmt.Errorf("scheduled by %q which isn't a managed scheduler", p.Spec.SchedulerName)
+	}
+	return nil
+}
+
 // validateKarpenterManagedLabelCanExist provides a more clear error message in the event of scheduling a pod that specifically doesn't
 // want to run on a Karpenter node (e.g. a Karpenter controller replica).
 func validateKarpenterManagedLabelCanExist(p *v1.Pod) error {
@@ -431,6 +666,26 @@ func validateKarpenterManagedLabelCanExist(p *v1.Pod) error {
 	return nil
 }
 
+// validateDedicated provides a more clear error message in the event of scheduling a pod with the karpenter.sh/dedicated
+// annotation that doesn't tolerate the taint of the nodes that are launched for it, as it could never schedule to them.
+func validateDedicated(p *v1.Pod) error {
+	if podutils.IsDedicated(p) && !podutils.ToleratesDedicatedNoScheduleTaint(p) {
+		return fmt.Errorf("annotated with %s but doesn't tolerate the %s taint", v1beta1.DedicatedAnnotationKey, v1beta1.DedicatedTaintKey)
+	}
+	return nil
+}
+
+// injectLimitRangeDefaults applies the defaults of the LimitRanges of the pod's namespace to the containers of a pod
+// that wasn't created yet, so that the pods that the apiserver will default aren't under-sized
+func (p *Provisioner) injectLimitRangeDefaults(ctx context.Context, pod *v1.Pod) {
+	limitRangeList := &v1.LimitRangeList{}
+	if err := p.kubeClient.List(ctx, limitRangeList, client.InNamespace(pod.Namespace)); err != nil {
+		logging.FromContext(ctx).Errorf("listing limit ranges, %s", err)
+		return
+	}
+	scheduler.InjectLimitRangeDefaults(pod, limitRangeList.Items)
+}
+
 func (p *Provisioner) injectVolumeTopologyRequirements(ctx context.Context, pods []*v1.Pod) []*v1.Pod {
 	var schedulablePods []*v1.Pod
 	for _, pod := range pods {
@@ -443,6 +698,18 @@ func (p *Provisioner) injectVolumeTopologyRequirements(ctx context.Context, pods
 	return schedulablePods
 }
 
+func (p *Provisioner) injectResourceClaimRequirements(ctx context.Context, pods []*v1.Pod) []*v1.Pod {
+	var schedulablePods []*v1.Pod
+	for _, pod := range pods {
+		if err := p.resourceClaimTopology.Inject(ctx, pod); err != nil {
+			logging.FromContext(ctx).With("pod", client.ObjectKeyFromObject(pod)).Errorf("getting resource claim requirements, %s", err)
+		} else {
+			schedulablePods = append(schedulablePods, pod)
+		}
+	}
+	return schedulablePods
+}
+
 func validateNodeSelector(p *v1.Pod) (errs error) {
 	terms := lo.MapToSlice(p.Spec.NodeSelector, func(k string, v string) v1.NodeSelectorTerm {
 		return v1.NodeSelectorTerm{
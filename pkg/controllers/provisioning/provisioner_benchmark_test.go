@@ -0,0 +1,109 @@
+//go:build test_performance
+
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioning_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakecr "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/events"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+)
+
+// To run the benchmarks use:
+// `go test -tags=test_performance -run=XXX -bench=Idle`
+//
+// BenchmarkIdleSchedule measures a provisioning loop on an idle cluster, which exits before snapshotting the cluster
+// state. BenchmarkIdleSnapshot measures the snapshot that an idle provisioning loop used to take before finding out
+// that there was nothing to schedule, so comparing the two shows the cost that's saved on every idle loop.
+
+func BenchmarkIdleSchedule100(b *testing.B)  { benchmarkIdleSchedule(b, 100) }
+func BenchmarkIdleSchedule1000(b *testing.B) { benchmarkIdleSchedule(b, 1000) }
+func BenchmarkIdleSchedule5000(b *testing.B) { benchmarkIdleSchedule(b, 5000) }
+
+func BenchmarkIdleSnapshot100(b *testing.B)  { benchmarkIdleSnapshot(b, 100) }
+func BenchmarkIdleSnapshot1000(b *testing.B) { benchmarkIdleSnapshot(b, 1000) }
+func BenchmarkIdleSnapshot5000(b *testing.B) { benchmarkIdleSnapshot(b, 5000) }
+
+func benchmarkIdleSchedule(b *testing.B, nodeCount int) {
+	ctx, kubeClient, idleCluster := idleClusterWithNodes(b, nodeCount)
+	p := provisioning.NewProvisioner(kubeClient, events.NewRecorder(&record.FakeRecorder{}), fake.NewCloudProvider(), idleCluster)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		results, err := p.Schedule(ctx)
+		if err != nil {
+			b.Fatalf("scheduling, %s", err)
+		}
+		if len(results.NewNodeClaims) != 0 {
+			b.Fatalf("expected no new nodeclaims, got %d", len(results.NewNodeClaims))
+		}
+	}
+}
+
+func benchmarkIdleSnapshot(b *testing.B, nodeCount int) {
+	ctx, kubeClient, idleCluster := idleClusterWithNodes(b, nodeCount)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := idleCluster.Nodes().Deleting().ReschedulablePods(ctx, kubeClient); err != nil {
+			b.Fatalf("getting reschedulable pods, %s", err)
+		}
+	}
+}
+
+// idleClusterWithNodes returns a cluster state tracking nodeCount nodes that each run a pod, with no pending pods.
+// The pods are only tracked by the cluster state since listing pods through an index with the fake client copies every
+// pod, while the informer cache only returns the pods that match.
+func idleClusterWithNodes(b *testing.B, nodeCount int) (context.Context, client.Client, *state.Cluster) {
+	// disable logging
+	ctx := logging.WithLogger(context.Background(), zap.NewNop().Sugar())
+	ctx = options.ToContext(ctx, test.Options())
+	kubeClient := fakecr.NewClientBuilder().
+		WithIndex(&v1.Pod{}, "spec.nodeName", func(o client.Object) []string {
+			return []string{o.(*v1.Pod).Spec.NodeName}
+		}).
+		Build()
+	idleCluster := state.NewCluster(&clock.RealClock{}, kubeClient, fake.NewCloudProvider())
+	for i := 0; i < nodeCount; i++ {
+		node := test.Node(test.NodeOptions{ProviderID: test.RandomProviderID()})
+		pod := test.Pod(test.PodOptions{NodeName: node.Name})
+		lo.Must0(kubeClient.Create(ctx, node))
+		if err := idleCluster.UpdateNode(ctx, node); err != nil {
+			b.Fatalf("updating node, %s", err)
+		}
+		if err := idleCluster.UpdatePod(ctx, pod); err != nil {
+			b.Fatalf("updating pod, %s", err)
+		}
+	}
+	return ctx, kubeClient, idleCluster
+}
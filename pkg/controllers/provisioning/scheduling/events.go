@@ -21,6 +21,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/util/flowcontrol"
 
@@ -49,6 +50,28 @@ func NominatePodEvent(pod *v1.Pod, node *v1.Node, nodeClaim *v1beta1.NodeClaim)
 	}
 }
 
+func WouldLaunchPodEvent(pod *v1.Pod, nodePoolName string, instanceTypes []string) events.Event {
+	return events.Event{
+		InvolvedObject: pod,
+		Type:           v1.EventTypeNormal,
+		Reason:         "WouldLaunch",
+		Message:        fmt.Sprintf("Pod would schedule on a new nodeclaim from nodepool %s (dry-run), instance types: %s", nodePoolName, strings.Join(lo.Slice(instanceTypes, 0, 5), ", ")),
+		DedupeValues:   []string{string(pod.UID)},
+		RateLimiter:    PodNominationRateLimiter,
+	}
+}
+
+func LaunchVetoedEvent(pod *v1.Pod, nodePoolName string, err error) events.Event {
+	return events.Event{
+		InvolvedObject: pod,
+		Type:           v1.EventTypeWarning,
+		Reason:         "LaunchVetoed",
+		Message:        fmt.Sprintf("Launch of a nodeclaim from nodepool %s was vetoed by policy, %s", nodePoolName, err),
+		DedupeValues:   []string{string(pod.UID), nodePoolName},
+		DedupeTimeout:  5 * time.Minute,
+	}
+}
+
 func PodFailedToScheduleEvent(pod *v1.Pod, err error) events.Event {
 	return events.Event{
 		InvolvedObject: pod,
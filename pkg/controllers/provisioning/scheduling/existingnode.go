@@ -20,21 +20,25 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
 	"sigs.k8s.io/karpenter/pkg/utils/resources"
 )
 
 type ExistingNode struct {
 	*state.StateNode
 
-	Pods         []*v1.Pod
-	topology     *Topology
-	requests     v1.ResourceList
-	requirements scheduling.Requirements
+	Pods              []*v1.Pod
+	topology          *Topology
+	requests          v1.ResourceList
+	requirements      scheduling.Requirements
+	allowedNamespaces sets.Set[string]
 }
 
 func NewExistingNode(n *state.StateNode, topology *Topology, daemonResources v1.ResourceList) *ExistingNode {
@@ -62,10 +66,18 @@ func NewExistingNode(n *state.StateNode, topology *Topology, daemonResources v1.
 }
 
 func (n *ExistingNode) Add(ctx context.Context, kubeClient client.Client, pod *v1.Pod) error {
+	// Check Namespace
+	if err := allowsNamespace(n.allowedNamespaces, pod); err != nil {
+		return err
+	}
 	// Check Taints
 	if err := scheduling.Taints(n.Taints()).Tolerates(pod); err != nil {
 		return err
 	}
+	// Check Dedication
+	if err := checkDedicated(pod, n.Dedicated() || lo.ContainsBy(n.Pods, podutils.IsDedicated), n.NonDaemonSetPodCount()+len(n.Pods)); err != nil {
+		return err
+	}
 	// determine the volumes that will be mounted if the pod schedules
 	volumes, err := scheduling.GetVolumes(ctx, kubeClient, pod)
 	if err != nil {
@@ -90,6 +102,7 @@ func (n *ExistingNode) Add(ctx context.Context, kubeClient client.Client, pod *v
 
 	nodeRequirements := scheduling.NewRequirements(n.requirements.Values()...)
 	podRequirements := scheduling.NewPodRequirements(pod)
+	podRequirements.Add(architectureRequirements(pod).Values()...)
 	// Check NodeClaim Affinity Requirements
 	if err = nodeRequirements.Compatible(podRequirements); err != nil {
 		return err
@@ -26,7 +26,9 @@ import (
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
@@ -643,6 +645,329 @@ var _ = Describe("Instance Type Selection", func() {
 		node := ExpectScheduled(ctx, env.Client, pod)
 		Expect(node.Labels[v1.LabelInstanceTypeStable]).To(Equal("test-instance1"))
 	})
+	Context("Architecture", func() {
+		It("should only launch instance types of the architecture in the pod's architecture annotation", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{v1beta1.ArchitectureAnnotationKey: v1beta1.ArchitectureArm64},
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[v1.LabelArchStable]).To(Equal(v1beta1.ArchitectureArm64))
+			ExpectInstancesWithLabel(supportedInstanceTypes(cloudProvider.CreateCalls[0]), v1.LabelArchStable, v1beta1.ArchitectureArm64)
+		})
+		It("should allow any of the architectures in the pod's architecture annotation", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{v1beta1.ArchitectureAnnotationKey: "amd64, arm64"},
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(nodePrice(node)).To(Equal(minPrice))
+		})
+		It("should not schedule a pod whose architecture annotation conflicts with its node selector", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{
+				ObjectMeta:   metav1.ObjectMeta{Annotations: map[string]string{v1beta1.ArchitectureAnnotationKey: v1beta1.ArchitectureArm64}},
+				NodeSelector: map[string]string{v1.LabelArchStable: v1beta1.ArchitectureAmd64},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should not schedule a pod onto an existing node of another architecture than its architecture annotation", func() {
+			node := test.Node(test.NodeOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.LabelArchStable: v1beta1.ArchitectureAmd64}},
+				Allocatable: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("10"),
+					v1.ResourceMemory: resource.MustParse("10Gi"),
+					v1.ResourcePods:   resource.MustParse("110"),
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, node)
+			ExpectMakeNodesInitialized(ctx, env.Client, node)
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+
+			pod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{v1beta1.ArchitectureAnnotationKey: v1beta1.ArchitectureArm64},
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			scheduled := ExpectScheduled(ctx, env.Client, pod)
+			Expect(scheduled.Name).ToNot(Equal(node.Name))
+			Expect(scheduled.Labels[v1.LabelArchStable]).To(Equal(v1beta1.ArchitectureArm64))
+		})
+		It("should schedule a pod onto an existing node of the architecture in its architecture annotation", func() {
+			node := test.Node(test.NodeOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.LabelArchStable: v1beta1.ArchitectureArm64}},
+				Allocatable: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("10"),
+					v1.ResourceMemory: resource.MustParse("10Gi"),
+					v1.ResourcePods:   resource.MustParse("110"),
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, node)
+			ExpectMakeNodesInitialized(ctx, env.Client, node)
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+
+			pod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{v1beta1.ArchitectureAnnotationKey: v1beta1.ArchitectureArm64},
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			Expect(ExpectScheduled(ctx, env.Client, pod).Name).To(Equal(node.Name))
+		})
+		It("should launch instance types of the nodepool's preferred architecture", func() {
+			nodePool.Spec.PreferredArchitecture = v1beta1.ArchitectureArm64
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[v1.LabelArchStable]).To(Equal(v1beta1.ArchitectureArm64))
+			ExpectInstancesWithLabel(supportedInstanceTypes(cloudProvider.CreateCalls[0]), v1.LabelArchStable, v1beta1.ArchitectureArm64)
+		})
+		It("should launch instance types of another architecture when the pod requires it", func() {
+			nodePool.Spec.PreferredArchitecture = v1beta1.ArchitectureArm64
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{v1beta1.ArchitectureAnnotationKey: v1beta1.ArchitectureAmd64},
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[v1.LabelArchStable]).To(Equal(v1beta1.ArchitectureAmd64))
+		})
+		It("should launch instance types of another architecture when none of the preferred architecture fit", func() {
+			cloudProvider.InstanceTypes = lo.Reject(cloudProvider.InstanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+				return it.Requirements.Get(v1.LabelArchStable).Has(v1beta1.ArchitectureArm64)
+			})
+			nodePool.Spec.PreferredArchitecture = v1beta1.ArchitectureArm64
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[v1.LabelArchStable]).To(Equal(v1beta1.ArchitectureAmd64))
+		})
+	})
+	Context("Local NVMe", func() {
+		BeforeEach(func() {
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+				fake.NewInstanceType(fake.InstanceTypeOptions{Name: "no-local-nvme"}),
+				fake.NewInstanceType(fake.InstanceTypeOptions{Name: "small-local-nvme", LocalNVMe: resource.MustParse("475Gi")}),
+				fake.NewInstanceType(fake.InstanceTypeOptions{Name: "large-local-nvme", LocalNVMe: resource.MustParse("1900Gi")}),
+			}
+		})
+		It("should only select instance types with local NVMe for pods that require it", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{NodeRequirements: []v1.NodeSelectorRequirement{
+				{Key: v1beta1.InstanceLocalNVMeLabelKey, Operator: v1.NodeSelectorOpExists},
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+			Expect(lo.Map(supportedInstanceTypes(cloudProvider.CreateCalls[0]), func(it *cloudprovider.InstanceType, _ int) string { return it.Name })).
+				To(ConsistOf("small-local-nvme", "large-local-nvme"))
+		})
+		It("should only select instance types with enough local NVMe for pods that require a minimum size", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{NodeRequirements: []v1.NodeSelectorRequirement{
+				{Key: v1beta1.InstanceLocalNVMeLabelKey, Operator: v1.NodeSelectorOpGt, Values: []string{"1000"}},
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[v1.LabelInstanceTypeStable]).To(Equal("large-local-nvme"))
+			Expect(node.Labels[v1beta1.InstanceLocalNVMeLabelKey]).To(Equal("1900"))
+		})
+		It("should only select instance types without local NVMe for pods that require its absence", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{NodeRequirements: []v1.NodeSelectorRequirement{
+				{Key: v1beta1.InstanceLocalNVMeLabelKey, Operator: v1.NodeSelectorOpDoesNotExist},
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[v1.LabelInstanceTypeStable]).To(Equal("no-local-nvme"))
+		})
+		It("should not schedule pods requiring more local NVMe than any instance type provides", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{NodeRequirements: []v1.NodeSelectorRequirement{
+				{Key: v1beta1.InstanceLocalNVMeLabelKey, Operator: v1.NodeSelectorOpGt, Values: []string{"2000"}},
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+	})
+	Context("NUMA Nodes", func() {
+		BeforeEach(func() {
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+				fake.NewInstanceType(fake.InstanceTypeOptions{Name: "unknown-topology"}),
+				fake.NewInstanceType(fake.InstanceTypeOptions{Name: "single-socket", NUMANodes: 1}),
+				fake.NewInstanceType(fake.InstanceTypeOptions{Name: "dual-socket", NUMANodes: 2}),
+				fake.NewInstanceType(fake.InstanceTypeOptions{Name: "quad-socket", NUMANodes: 4}),
+			}
+		})
+		It("should only select multi-socket instance types for pods that require a minimum number of NUMA nodes", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{NodeRequirements: []v1.NodeSelectorRequirement{
+				{Key: v1beta1.NUMANodesLabelKey, Operator: v1.NodeSelectorOpGt, Values: []string{"1"}},
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+			Expect(lo.Map(supportedInstanceTypes(cloudProvider.CreateCalls[0]), func(it *cloudprovider.InstanceType, _ int) string { return it.Name })).
+				To(ConsistOf("dual-socket", "quad-socket"))
+		})
+		It("should select the instance type with the exact number of NUMA nodes that the pod requires", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{NodeRequirements: []v1.NodeSelectorRequirement{
+				{Key: v1beta1.NUMANodesLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{"4"}},
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[v1.LabelInstanceTypeStable]).To(Equal("quad-socket"))
+			Expect(node.Labels[v1beta1.NUMANodesLabelKey]).To(Equal("4"))
+		})
+		It("should not select instance types with an unknown NUMA topology for pods that require NUMA nodes", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{NodeRequirements: []v1.NodeSelectorRequirement{
+				{Key: v1beta1.NUMANodesLabelKey, Operator: v1.NodeSelectorOpExists},
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectScheduled(ctx, env.Client, pod)
+			Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+			Expect(lo.Map(supportedInstanceTypes(cloudProvider.CreateCalls[0]), func(it *cloudprovider.InstanceType, _ int) string { return it.Name })).
+				To(ConsistOf("single-socket", "dual-socket", "quad-socket"))
+		})
+		It("should select multi-socket instance types for NodePools that require a minimum number of NUMA nodes", func() {
+			nodePool.Spec.Template.Spec.Requirements = append(nodePool.Spec.Template.Spec.Requirements, v1beta1.NodeSelectorRequirementWithMinValues{
+				NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1beta1.NUMANodesLabelKey, Operator: v1.NodeSelectorOpGt, Values: []string{"2"}},
+			})
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[v1.LabelInstanceTypeStable]).To(Equal("quad-socket"))
+		})
+		It("should not schedule pods requiring more NUMA nodes than any instance type provides", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{NodeRequirements: []v1.NodeSelectorRequirement{
+				{Key: v1beta1.NUMANodesLabelKey, Operator: v1.NodeSelectorOpGt, Values: []string{"4"}},
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+	})
+	Context("Operating System Overhead", func() {
+		var windowsOverhead *cloudprovider.InstanceTypeOverhead
+		BeforeEach(func() {
+			windowsOverhead = &cloudprovider.InstanceTypeOverhead{
+				KubeReserved: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("1"),
+					v1.ResourceMemory: resource.MustParse("1Gi"),
+				},
+			}
+			small := fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name:             "small-instance-type",
+				OperatingSystems: sets.New(string(v1.Linux), string(v1.Windows)),
+				Resources: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("2"),
+					v1.ResourceMemory: resource.MustParse("2Gi"),
+				},
+				Offerings: []cloudprovider.Offering{{CapacityType: v1beta1.CapacityTypeOnDemand, Zone: "test-zone-1", Price: 1.0, Available: true}},
+			})
+			small.OSOverhead = map[string]*cloudprovider.InstanceTypeOverhead{string(v1.Windows): windowsOverhead}
+			large := fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name:             "large-instance-type",
+				OperatingSystems: sets.New(string(v1.Linux), string(v1.Windows)),
+				Resources: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("4"),
+					v1.ResourceMemory: resource.MustParse("4Gi"),
+				},
+				Offerings: []cloudprovider.Offering{{CapacityType: v1beta1.CapacityTypeOnDemand, Zone: "test-zone-1", Price: 2.0, Available: true}},
+			})
+			large.OSOverhead = map[string]*cloudprovider.InstanceTypeOverhead{string(v1.Windows): windowsOverhead}
+			linuxOnly := fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name:             "linux-instance-type",
+				OperatingSystems: sets.New(string(v1.Linux)),
+				Resources: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("4"),
+					v1.ResourceMemory: resource.MustParse("4Gi"),
+				},
+				Offerings: []cloudprovider.Offering{{CapacityType: v1beta1.CapacityTypeOnDemand, Zone: "test-zone-1", Price: 0.5, Available: true}},
+			})
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{small, large, linuxOnly}
+		})
+		It("should size windows nodes using the windows overhead", func() {
+			nodePool.Spec.Template.Spec.Requirements = []v1beta1.NodeSelectorRequirementWithMinValues{
+				{
+					NodeSelectorRequirement: v1.NodeSelectorRequirement{
+						Key:      v1.LabelOSStable,
+						Operator: v1.NodeSelectorOpIn,
+						Values:   []string{string(v1.Windows)},
+					},
+				},
+			}
+			ExpectApplied(ctx, env.Client, nodePool)
+			// fits on the small instance type with the linux overhead, but not with the windows overhead
+			pod := test.UnschedulablePod(test.PodOptions{
+				ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("1.5"),
+					v1.ResourceMemory: resource.MustParse("512Mi"),
+				}},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[v1.LabelInstanceTypeStable]).To(Equal("large-instance-type"))
+			Expect(node.Labels[v1.LabelOSStable]).To(Equal(string(v1.Windows)))
+			// the launched node should report the windows allocatable
+			Expect(node.Status.Allocatable.Cpu().String()).To(Equal("3"))
+		})
+		It("should use the default overhead for linux nodes", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{
+				NodeSelector: map[string]string{v1.LabelOSStable: string(v1.Linux)},
+				ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("1.5"),
+					v1.ResourceMemory: resource.MustParse("512Mi"),
+				}},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[v1.LabelInstanceTypeStable]).To(Equal("linux-instance-type"))
+			Expect(supportedInstanceTypes(cloudProvider.CreateCalls[0])).To(HaveLen(3))
+		})
+		It("should only schedule windows pods on windows instance types", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{
+				NodeSelector: map[string]string{v1.LabelOSStable: string(v1.Windows)},
+				ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("0.5"),
+					v1.ResourceMemory: resource.MustParse("512Mi"),
+				}},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[v1.LabelOSStable]).To(Equal(string(v1.Windows)))
+			Expect(node.Labels[v1.LabelInstanceTypeStable]).To(Equal("small-instance-type"))
+			ExpectInstancesWithLabel(supportedInstanceTypes(cloudProvider.CreateCalls[0]), v1.LabelOSStable, string(v1.Windows))
+		})
+		It("should use the most restrictive overhead when the operating system is unconstrained", func() {
+			nodePool.Spec.Template.Spec.Requirements = []v1beta1.NodeSelectorRequirementWithMinValues{
+				{
+					NodeSelectorRequirement: v1.NodeSelectorRequirement{
+						Key:      v1.LabelInstanceTypeStable,
+						Operator: v1.NodeSelectorOpIn,
+						Values:   []string{"small-instance-type", "large-instance-type"},
+					},
+				},
+			}
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{
+				ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("1.5"),
+					v1.ResourceMemory: resource.MustParse("512Mi"),
+				}},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[v1.LabelInstanceTypeStable]).To(Equal("large-instance-type"))
+		})
+	})
 	Context("MinValues", func() {
 		It("should schedule respecting the minValues from instance-type requirements", func() {
 			var instanceTypes []*cloudprovider.InstanceType
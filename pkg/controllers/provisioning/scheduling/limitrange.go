@@ -0,0 +1,80 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+// InjectLimitRangeDefaults applies the defaults that the apiserver gives the containers of a pod at creation to the
+// containers of a pod that wasn't created yet, like the pod template of a DaemonSet. Like pod defaulting, requests
+// default to the container's limits. Then, like the LimitRanger admission plugin, the missing limits and requests
+// default to those of the container LimitRanges of the pod's namespace.
+func InjectLimitRangeDefaults(pod *v1.Pod, limitRanges []v1.LimitRange) {
+	requests, limits := v1.ResourceList{}, v1.ResourceList{}
+	for _, limitRange := range limitRanges {
+		for _, item := range limitRange.Spec.Limits {
+			if item.Type != v1.LimitTypeContainer {
+				continue
+			}
+			for name, quantity := range item.Default {
+				limits[name] = quantity
+				// LimitRanges default their default requests to their default limits
+				if _, ok := item.DefaultRequest[name]; !ok {
+					requests[name] = quantity
+				}
+			}
+			for name, quantity := range item.DefaultRequest {
+				requests[name] = quantity
+			}
+		}
+	}
+	for i := range pod.Spec.InitContainers {
+		applyDefaults(&pod.Spec.InitContainers[i], requests, limits)
+	}
+	for i := range pod.Spec.Containers {
+		applyDefaults(&pod.Spec.Containers[i], requests, limits)
+	}
+}
+
+func applyDefaults(container *v1.Container, requests v1.ResourceList, limits v1.ResourceList) {
+	// the requests that aren't set default to the limits that are set on the container, before any LimitRange applies
+	for name, quantity := range container.Resources.Limits {
+		if _, ok := container.Resources.Requests[name]; !ok {
+			if container.Resources.Requests == nil {
+				container.Resources.Requests = v1.ResourceList{}
+			}
+			container.Resources.Requests[name] = quantity.DeepCopy()
+		}
+	}
+	for name, quantity := range limits {
+		if _, ok := container.Resources.Limits[name]; !ok {
+			if container.Resources.Limits == nil {
+				container.Resources.Limits = v1.ResourceList{}
+			}
+			container.Resources.Limits[name] = quantity.DeepCopy()
+		}
+	}
+	for name, quantity := range requests {
+		if _, ok := container.Resources.Requests[name]; !ok {
+			if container.Resources.Requests == nil {
+				container.Resources.Requests = v1.ResourceList{}
+			}
+			container.Resources.Requests[name] = quantity.DeepCopy()
+		}
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// namespaceCacheTTL is how long the namespaces that a selector resolves to are cached for, which bounds how long it
+// takes for a change to the labels of a namespace to be reflected in the namespaces that a NodePool allows
+const namespaceCacheTTL = time.Minute
+
+func NewNamespaceCache(kubeClient client.Client) *NamespaceCache {
+	return &NamespaceCache{kubeClient: kubeClient, cache: cache.New(namespaceCacheTTL, 10*time.Second)}
+}
+
+// NamespaceCache resolves the allowedNamespaces selectors of the NodePools to the namespaces that they select, caching
+// the result so that the namespaces aren't listed every time a Scheduler is created
+type NamespaceCache struct {
+	kubeClient client.Client
+	cache      *cache.Cache
+}
+
+func (n *NamespaceCache) Resolve(ctx context.Context, labelSelector *metav1.LabelSelector) (sets.Set[string], error) {
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("parsing allowed namespaces, %w", err)
+	}
+	if namespaces, ok := n.cache.Get(selector.String()); ok {
+		return namespaces.(sets.Set[string]), nil
+	}
+	namespaceList := &v1.NamespaceList{}
+	if err = n.kubeClient.List(ctx, namespaceList, &client.ListOptions{LabelSelector: selector}); err != nil {
+		return nil, fmt.Errorf("listing allowed namespaces, %w", err)
+	}
+	namespaces := sets.New[string]()
+	for _, namespace := range namespaceList.Items {
+		namespaces.Insert(namespace.Name)
+	}
+	n.cache.SetDefault(selector.String(), namespaces)
+	return namespaces, nil
+}
+
+// WithNamespaceCache causes the scheduler to resolve the allowedNamespaces selectors of the NodePools through the cache
+func WithNamespaceCache(namespaceCache *NamespaceCache) func(SchedulerOptions) SchedulerOptions {
+	return func(o SchedulerOptions) SchedulerOptions {
+		o.NamespaceCache = namespaceCache
+		return o
+	}
+}
+
+// resolveAllowedNamespaces resolves the allowedNamespaces selector of each NodePool to the set of namespaces whose pods
+// may schedule to its capacity. If the selector can't be resolved, no namespaces are allowed.
+func (s *Scheduler) resolveAllowedNamespaces(ctx context.Context) {
+	for _, nct := range s.nodeClaimTemplates {
+		if nct.Spec.AllowedNamespaces == nil {
+			continue
+		}
+		namespaces, err := s.opts.NamespaceCache.Resolve(ctx, nct.Spec.AllowedNamespaces)
+		if err != nil {
+			logging.FromContext(ctx).With("nodepool", nct.NodePoolName).Errorf("resolving allowed namespaces, %s", err)
+			namespaces = sets.New[string]()
+		}
+		nct.allowedNamespaces = namespaces
+	}
+}
+
+func allowsNamespace(allowedNamespaces sets.Set[string], pod *v1.Pod) error {
+	if allowedNamespaces != nil && !allowedNamespaces.Has(pod.Namespace) {
+		return fmt.Errorf("namespace %q is not allowed", pod.Namespace)
+	}
+	return nil
+}
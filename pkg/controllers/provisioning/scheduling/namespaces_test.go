@@ -0,0 +1,113 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("Allowed Namespaces", func() {
+	var nodePool *v1beta1.NodePool
+	var allowed, disallowed *v1.Namespace
+	var labels map[string]string
+	BeforeEach(func() {
+		// The namespaces that a selector resolves to are cached across scheduling simulations, so each test selects
+		// namespaces with its own labels
+		labels = map[string]string{"workloads": test.RandomName()}
+		allowed = test.Namespace(test.NamespaceOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}})
+		disallowed = test.Namespace()
+		nodePool = test.NodePool(v1beta1.NodePool{
+			Spec: v1beta1.NodePoolSpec{
+				Template: v1beta1.NodeClaimTemplate{
+					Spec: v1beta1.NodeClaimSpec{
+						AllowedNamespaces: &metav1.LabelSelector{MatchLabels: labels},
+					},
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, allowed, disallowed)
+	})
+	It("should schedule pods from allowed namespaces", func() {
+		ExpectApplied(ctx, env.Client, nodePool)
+		pod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Namespace: allowed.Name}})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		ExpectScheduled(ctx, env.Client, pod)
+	})
+	It("should not schedule pods from disallowed namespaces", func() {
+		ExpectApplied(ctx, env.Client, nodePool)
+		pod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Namespace: disallowed.Name}})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		ExpectNotScheduled(ctx, env.Client, pod)
+	})
+	It("should not schedule pods from disallowed namespaces even if they tolerate the nodePool's taints", func() {
+		nodePool.Spec.Template.Spec.Taints = []v1.Taint{{Key: "system", Effect: v1.TaintEffectNoSchedule}}
+		ExpectApplied(ctx, env.Client, nodePool)
+		pod := test.UnschedulablePod(test.PodOptions{
+			ObjectMeta:  metav1.ObjectMeta{Namespace: disallowed.Name},
+			Tolerations: []v1.Toleration{{Operator: v1.TolerationOpExists}},
+		})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		ExpectNotScheduled(ctx, env.Client, pod)
+	})
+	It("should not schedule any pods when no namespaces match the selector", func() {
+		nodePool.Spec.Template.Spec.AllowedNamespaces = &metav1.LabelSelector{MatchLabels: map[string]string{"workloads": test.RandomName()}}
+		ExpectApplied(ctx, env.Client, nodePool)
+		pod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Namespace: allowed.Name}})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		ExpectNotScheduled(ctx, env.Client, pod)
+	})
+	It("should schedule pods from disallowed namespaces to other nodePools", func() {
+		other := test.NodePool()
+		ExpectApplied(ctx, env.Client, nodePool, other)
+		pod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Namespace: disallowed.Name}})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels).To(HaveKeyWithValue(v1beta1.NodePoolLabelKey, other.Name))
+	})
+	It("should not schedule pods from disallowed namespaces to in-flight capacity of the nodePool", func() {
+		ExpectApplied(ctx, env.Client, nodePool)
+		initialPod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Namespace: allowed.Name}})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, initialPod)
+		node := ExpectScheduled(ctx, env.Client, initialPod)
+		ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+
+		pod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Namespace: disallowed.Name}})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		ExpectNotScheduled(ctx, env.Client, pod)
+	})
+	It("should cache the namespaces that the selector resolves to", func() {
+		ExpectApplied(ctx, env.Client, nodePool)
+		pod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Namespace: allowed.Name}})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		ExpectScheduled(ctx, env.Client, pod)
+
+		// The namespace was labeled after the selector was resolved, so it isn't allowed until the cache expires
+		disallowed.Labels = labels
+		ExpectApplied(ctx, env.Client, disallowed)
+		pod = test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Namespace: disallowed.Name}})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		ExpectNotScheduled(ctx, env.Client, pod)
+	})
+})
@@ -17,6 +17,7 @@ limitations under the License.
 package scheduling
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"sync/atomic"
@@ -29,6 +30,7 @@ import (
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
+	podutils "sigs.k8s.io/karpenter/pkg/utils/pod"
 	"sigs.k8s.io/karpenter/pkg/utils/resources"
 )
 
@@ -64,12 +66,22 @@ func NewNodeClaim(nodeClaimTemplate *NodeClaimTemplate, topology *Topology, daem
 	}
 }
 
-func (n *NodeClaim) Add(pod *v1.Pod) error {
+func (n *NodeClaim) Add(ctx context.Context, pod *v1.Pod) error {
+	// Check Namespace
+	if err := n.AllowsNamespace(pod); err != nil {
+		return err
+	}
+
 	// Check Taints
 	if err := scheduling.Taints(n.Spec.Taints).Tolerates(pod); err != nil {
 		return err
 	}
 
+	// Check Dedication
+	if err := checkDedicated(pod, lo.ContainsBy(n.Pods, podutils.IsDedicated), len(n.Pods)); err != nil {
+		return err
+	}
+
 	// exposed host ports on the node
 	hostPorts := scheduling.GetHostPorts(pod)
 	if err := n.hostPortUsage.Conflicts(pod, hostPorts); err != nil {
@@ -77,6 +89,7 @@ func (n *NodeClaim) Add(pod *v1.Pod) error {
 	}
 	nodeClaimRequirements := scheduling.NewRequirements(n.Requirements.Values()...)
 	podRequirements := scheduling.NewPodRequirements(pod)
+	podRequirements.Add(architectureRequirements(pod).Values()...)
 
 	// Check NodeClaim Affinity Requirements
 	if err := nodeClaimRequirements.Compatible(podRequirements, scheduling.AllowUndefinedWellKnownLabels); err != nil {
@@ -90,8 +103,15 @@ func (n *NodeClaim) Add(pod *v1.Pod) error {
 		// preferred node affinity.  Only required node affinities can actually reduce pod domains.
 		strictPodRequirements = scheduling.NewStrictPodRequirements(pod)
 	}
+	requests := resources.Merge(n.Spec.Resources.Requests, n.roundedRequests(pod, nodeClaimRequirements))
+
+	// Topology spreads must only choose from the domains that we are able to launch capacity into
+	nodeDomainRequirements := nodeClaimRequirements
+	if n.topology.HasTopologySpread(pod) {
+		nodeDomainRequirements = offeringRequirements(n.InstanceTypeOptions, nodeClaimRequirements, requests)
+	}
 	// Check Topology Requirements
-	topologyRequirements, err := n.topology.AddRequirements(strictPodRequirements, nodeClaimRequirements, pod, scheduling.AllowUndefinedWellKnownLabels)
+	topologyRequirements, err := n.topology.AddRequirements(strictPodRequirements, nodeDomainRequirements, pod, scheduling.AllowUndefinedWellKnownLabels)
 	if err != nil {
 		return err
 	}
@@ -101,9 +121,20 @@ func (n *NodeClaim) Add(pod *v1.Pod) error {
 	nodeClaimRequirements.Add(topologyRequirements.Values()...)
 
 	// Check instance type combinations
-	requests := resources.Merge(n.Spec.Resources.Requests, resources.RequestsForPods(pod))
-
-	filtered := filterInstanceTypesByRequirements(n.InstanceTypeOptions, nodeClaimRequirements, requests)
+	instanceTypeOptions, err := n.allowedInstanceTypes(n.InstanceTypeOptions, pod)
+	if err != nil {
+		return err
+	}
+	if instanceTypeOptions, err = n.filteredInstanceTypes(ctx, instanceTypeOptions, pod, nodeClaimRequirements); err != nil {
+		return err
+	}
+	filtered := filterInstanceTypesByRequirements(instanceTypeOptions, nodeClaimRequirements, requests)
+	// Narrow the NodeClaim to the NodePool's preferred architecture as long as it still fits its pods
+	if preferred, ok := n.preferArchitecture(nodeClaimRequirements); ok {
+		if preferredFiltered := filterInstanceTypesByRequirements(instanceTypeOptions, preferred, requests); len(preferredFiltered.remaining) > 0 {
+			filtered, nodeClaimRequirements = preferredFiltered, preferred
+		}
+	}
 
 	if len(filtered.remaining) == 0 {
 		// log the total resources being requested (daemonset + the pod)
@@ -118,9 +149,92 @@ func (n *NodeClaim) Add(pod *v1.Pod) error {
 	n.Requirements = nodeClaimRequirements
 	n.topology.Record(pod, nodeClaimRequirements, scheduling.AllowUndefinedWellKnownLabels)
 	n.hostPortUsage.Add(pod, hostPorts)
+	if podutils.IsDedicated(pod) {
+		// taint the NodeClaim so that neither kube-scheduler nor subsequent scheduling simulations place other pods on it
+		n.Spec.Taints = append([]v1.Taint{v1beta1.DedicatedNoScheduleTaint}, n.Spec.Taints...)
+	}
 	return nil
 }
 
+// checkDedicated returns an error if the pod can't share a node with the pods already scheduled to it. Pods with the
+// karpenter.sh/dedicated annotation only schedule to nodes without any other pods aside from DaemonSet pods, and
+// nodes that are dedicated to such a pod don't accept any other pods. Dedicated pods must tolerate the
+// karpenter.sh/dedicated taint, as that's what keeps kube-scheduler from placing other pods on their nodes.
+func checkDedicated(pod *v1.Pod, dedicated bool, pods int) error {
+	if podutils.IsDedicated(pod) && !podutils.ToleratesDedicatedNoScheduleTaint(pod) {
+		return fmt.Errorf("pod requires a dedicated node, but doesn't tolerate taint %s", v1beta1.DedicatedNoScheduleTaint.ToString())
+	}
+	if podutils.IsDedicated(pod) && pods > 0 {
+		return fmt.Errorf("pod requires a dedicated node")
+	}
+	if !podutils.IsDedicated(pod) && dedicated {
+		return fmt.Errorf("node is dedicated to another pod")
+	}
+	return nil
+}
+
+// fitDaemonResources narrows the instance types of a NodeClaim that is launched without any pods to the ones that fit
+// its daemonset overhead
+func (n *NodeClaim) fitDaemonResources() error {
+	requirements := scheduling.NewRequirements(n.Requirements.Values()...)
+	filtered := filterInstanceTypesByRequirements(n.InstanceTypeOptions, requirements, n.Spec.Resources.Requests)
+	if preferred, ok := n.preferArchitecture(requirements); ok {
+		if preferredFiltered := filterInstanceTypesByRequirements(n.InstanceTypeOptions, preferred, n.Spec.Resources.Requests); len(preferredFiltered.remaining) > 0 {
+			filtered, requirements = preferredFiltered, preferred
+		}
+	}
+	if len(filtered.remaining) == 0 {
+		return fmt.Errorf("no instance type satisfied resources %s and requirements %s (%s)", resources.String(n.daemonResources), requirements, filtered.FailureReason())
+	}
+	n.InstanceTypeOptions = filtered.remaining
+	n.Requirements = requirements
+	return nil
+}
+
+// architectureRequirements returns the kubernetes.io/arch requirement from the pod's architecture annotation, if any.
+// Both new NodeClaims and existing nodes honor it, but the kube-scheduler doesn't know about the annotation and may
+// still bind the pod to a node of another architecture, so the pod's own node selector or affinity is needed to
+// guarantee its placement.
+func architectureRequirements(pod *v1.Pod) scheduling.Requirements {
+	architectures := lo.Compact(lo.Map(strings.Split(pod.Annotations[v1beta1.ArchitectureAnnotationKey], ","), func(a string, _ int) string {
+		return strings.TrimSpace(a)
+	}))
+	if len(architectures) == 0 {
+		return scheduling.NewRequirements()
+	}
+	return scheduling.NewRequirements(scheduling.NewRequirement(v1.LabelArchStable, v1.NodeSelectorOpIn, architectures...))
+}
+
+// preferArchitecture returns the NodeClaim requirements narrowed to the preferred architecture of its NodePool, and
+// false if the NodePool doesn't prefer an architecture or the requirements already settle on one
+func (n *NodeClaim) preferArchitecture(requirements scheduling.Requirements) (scheduling.Requirements, bool) {
+	if n.preferredArchitecture == "" {
+		return nil, false
+	}
+	if arch := requirements.Get(v1.LabelArchStable); arch.Len() <= 1 || !arch.Has(n.preferredArchitecture) {
+		return nil, false
+	}
+	preferred := scheduling.NewRequirements(requirements.Values()...)
+	preferred.Add(scheduling.NewRequirement(v1.LabelArchStable, v1.NodeSelectorOpIn, n.preferredArchitecture))
+	return preferred, true
+}
+
+// roundedRequests returns the pod's requests rounded up to the NodePool's request rounding granularity. Rounding only
+// ever makes the simulation more conservative, so the unrounded requests are used if rounding would prevent the pod
+// from fitting on any of the remaining instance types.
+func (n *NodeClaim) roundedRequests(pod *v1.Pod, requirements scheduling.Requirements) v1.ResourceList {
+	podRequests := resources.RequestsForPods(pod)
+	if len(n.requestRounding) == 0 {
+		return podRequests
+	}
+	rounded := resources.RoundUp(podRequests, n.requestRounding)
+	requests := resources.Merge(n.Spec.Resources.Requests, rounded)
+	if lo.ContainsBy(n.InstanceTypeOptions, func(it *cloudprovider.InstanceType) bool { return fits(it, requirements, requests) }) {
+		return rounded
+	}
+	return podRequests
+}
+
 // FinalizeScheduling is called once all scheduling has completed and allows the node to perform any cleanup
 // necessary before its requirements are used for instance launching
 func (n *NodeClaim) FinalizeScheduling() {
@@ -246,7 +360,7 @@ func filterInstanceTypesByRequirements(instanceTypes []*cloudprovider.InstanceTy
 		// the tradeoff to not short circuiting on the filtering is that we can report much better error messages
 		// about why scheduling failed
 		itCompat := compatible(it, requirements)
-		itFits := fits(it, requests)
+		itFits := fits(it, requirements, requests)
 		itHasOffering := hasOffering(it, requirements)
 
 		// track if any single instance type met a single criteria
@@ -274,22 +388,43 @@ func filterInstanceTypesByRequirements(instanceTypes []*cloudprovider.InstanceTy
 	return results
 }
 
+// offeringRequirements tightens the zone and capacity type requirements to the domains that have an available offering
+// for an instance type that is compatible with the requirements and fits the requests. Topology spread constraints
+// need to be evaluated against these domains, otherwise we may select a domain that we can't launch capacity into.
+// If no instance type is compatible, the requirements are returned unchanged so that instance type filtering can
+// report why.
+func offeringRequirements(instanceTypes []*cloudprovider.InstanceType, requirements scheduling.Requirements, requests v1.ResourceList) scheduling.Requirements {
+	zones, capacityTypes := sets.New[string](), sets.New[string]()
+	for _, it := range instanceTypes {
+		if !compatible(it, requirements) || !fits(it, requirements, requests) {
+			continue
+		}
+		for _, offering := range it.Offerings.Available().Compatible(requirements) {
+			zones.Insert(offering.Zone)
+			capacityTypes.Insert(offering.CapacityType)
+		}
+	}
+	if zones.Len() == 0 {
+		return requirements
+	}
+	tightened := scheduling.NewRequirements(requirements.Values()...)
+	tightened.Add(
+		scheduling.NewRequirement(v1.LabelTopologyZone, v1.NodeSelectorOpIn, sets.List(zones)...),
+		scheduling.NewRequirement(v1beta1.CapacityTypeLabelKey, v1.NodeSelectorOpIn, sets.List(capacityTypes)...),
+	)
+	return tightened
+}
+
 func compatible(instanceType *cloudprovider.InstanceType, requirements scheduling.Requirements) bool {
 	return instanceType.Requirements.Intersects(requirements) == nil
 }
 
-func fits(instanceType *cloudprovider.InstanceType, requests v1.ResourceList) bool {
-	return resources.Fits(requests, instanceType.Allocatable())
+func fits(instanceType *cloudprovider.InstanceType, requirements scheduling.Requirements, requests v1.ResourceList) bool {
+	return resources.Fits(requests, instanceType.AllocatableFor(requirements))
 }
 
 func hasOffering(instanceType *cloudprovider.InstanceType, requirements scheduling.Requirements) bool {
-	for _, offering := range instanceType.Offerings.Available() {
-		if (!requirements.Has(v1.LabelTopologyZone) || requirements.Get(v1.LabelTopologyZone).Has(offering.Zone)) &&
-			(!requirements.Has(v1beta1.CapacityTypeLabelKey) || requirements.Get(v1beta1.CapacityTypeLabelKey).Has(offering.CapacityType)) {
-			return true
-		}
-	}
-	return false
+	return len(instanceType.Offerings.Available().Compatible(requirements)) > 0
 }
 
 func IncompatibleReqAcrossInstanceTypes(requirements scheduling.Requirements, instanceTypes cloudprovider.InstanceTypes) (string, int) {
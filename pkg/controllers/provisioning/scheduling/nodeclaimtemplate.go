@@ -22,10 +22,12 @@ import (
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"knative.dev/pkg/ptr"
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 )
 
@@ -42,13 +44,31 @@ type NodeClaimTemplate struct {
 	NodePoolName        string
 	InstanceTypeOptions cloudprovider.InstanceTypes
 	Requirements        scheduling.Requirements
+
+	// allowedNamespaces are the namespaces resolved from the NodePool's allowedNamespaces selector. This is nil if
+	// pods from all namespaces are allowed.
+	allowedNamespaces sets.Set[string]
+	// namespaceInstanceTypes maps namespaces to the instance type names or glob patterns that their pods may cause to
+	// be launched. Pods from namespaces that aren't mapped may cause any instance type to be launched.
+	namespaceInstanceTypes map[string][]string
+	// schedulingFilter is consulted with the instance types that the pods of the NodeClaim may be launched on
+	schedulingFilter SchedulingFilter
+	// requestRounding is the granularity that pod requests are rounded up to when simulating bin-packing
+	requestRounding v1.ResourceList
+	// preferredArchitecture is the architecture that the NodeClaim is narrowed to when its pods can run on several
+	preferredArchitecture string
+	// minNodes is the number of nodes that the NodePool keeps running even when there are no pods pending for them
+	minNodes int
 }
 
 func NewNodeClaimTemplate(nodePool *v1beta1.NodePool) *NodeClaimTemplate {
 	nct := &NodeClaimTemplate{
-		NodeClaimTemplate: nodePool.Spec.Template,
-		NodePoolName:      nodePool.Name,
-		Requirements:      scheduling.NewRequirements(),
+		NodeClaimTemplate:     nodePool.Spec.Template,
+		NodePoolName:          nodePool.Name,
+		Requirements:          scheduling.NewRequirements(),
+		requestRounding:       nodePool.Spec.RequestRounding,
+		preferredArchitecture: nodePool.Spec.PreferredArchitecture,
+		minNodes:              int(nodePool.Spec.MinNodes),
 	}
 	nct.Labels = lo.Assign(nct.Labels, map[string]string{v1beta1.NodePoolLabelKey: nodePool.Name})
 	nct.Requirements.Add(scheduling.NewNodeSelectorRequirementsWithMinValues(nct.Spec.Requirements...).Values()...)
@@ -56,6 +76,27 @@ func NewNodeClaimTemplate(nodePool *v1beta1.NodePool) *NodeClaimTemplate {
 	return nct
 }
 
+// AllowsNamespace returns an error if pods from the pod's namespace aren't allowed to schedule to the NodePool
+func (i *NodeClaimTemplate) AllowsNamespace(pod *v1.Pod) error {
+	return allowsNamespace(i.allowedNamespaces, pod)
+}
+
+// allowedInstanceTypes returns the instance types that pods from the pod's namespace may cause to be launched, or an
+// error if there are none
+func (i *NodeClaimTemplate) allowedInstanceTypes(instanceTypes cloudprovider.InstanceTypes, pod *v1.Pod) (cloudprovider.InstanceTypes, error) {
+	patterns, ok := i.namespaceInstanceTypes[pod.Namespace]
+	if !ok {
+		return instanceTypes, nil
+	}
+	allowed := lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+		return options.MatchesInstanceType(patterns, it.Name)
+	})
+	if len(allowed) == 0 {
+		return nil, fmt.Errorf("no instance type is allowed for namespace %q", pod.Namespace)
+	}
+	return allowed, nil
+}
+
 func (i *NodeClaimTemplate) ToNodeClaim(nodePool *v1beta1.NodePool) *v1beta1.NodeClaim {
 	// Order the instance types by price and only take the first 100 of them to decrease the instance type size in the requirements
 	instanceTypes := lo.Slice(i.InstanceTypeOptions.OrderByPrice(i.Requirements), 0, MaxInstanceTypes)
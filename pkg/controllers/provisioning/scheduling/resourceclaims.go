@@ -0,0 +1,121 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	resourcev1alpha2 "k8s.io/api/resource/v1alpha2"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+)
+
+func NewResourceClaimTopology(kubeClient client.Client) *ResourceClaimTopology {
+	return &ResourceClaimTopology{kubeClient: kubeClient}
+}
+
+// ResourceClaimTopology translates the Dynamic Resource Allocation claims of a pod into node requirements, using the
+// requirements configured for the resource class of each claim
+type ResourceClaimTopology struct {
+	kubeClient client.Client
+}
+
+func (r *ResourceClaimTopology) Inject(ctx context.Context, pod *v1.Pod) error {
+	var requirements []v1.NodeSelectorRequirement
+	for _, claim := range RequiredResourceClaims(pod) {
+		req, err := r.getRequirements(ctx, pod, claim)
+		if err != nil {
+			return err
+		}
+		requirements = append(requirements, req...)
+	}
+	if len(requirements) == 0 {
+		return nil
+	}
+	// Like volume requirements, these are added to every node selector term so that relaxation won't remove them
+	addRequiredNodeAffinity(pod, requirements)
+
+	logging.FromContext(ctx).
+		With("pod", client.ObjectKeyFromObject(pod)).
+		Debugf("adding requirements derived from pod resource claims, %s", requirements)
+	return nil
+}
+
+// ValidateResourceClaims returns an error if the pod has a required resource claim that we can't provision for (e.g.
+// the claim is not found or its resource class has no configured requirements).
+func (r *ResourceClaimTopology) ValidateResourceClaims(ctx context.Context, pod *v1.Pod) error {
+	for _, claim := range RequiredResourceClaims(pod) {
+		if _, err := r.getRequirements(ctx, pod, claim); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ResourceClaimTopology) getRequirements(ctx context.Context, pod *v1.Pod, claim v1.PodResourceClaim) ([]v1.NodeSelectorRequirement, error) {
+	resourceClassName, err := r.getResourceClassName(ctx, pod, claim)
+	if err != nil {
+		return nil, fmt.Errorf("resolving resource class for resource claim %q, %w", claim.Name, err)
+	}
+	requirements, ok := options.FromContext(ctx).ResourceClassRequirements[resourceClassName]
+	if !ok {
+		return nil, fmt.Errorf("no requirements are configured for resource class %q of resource claim %q", resourceClassName, claim.Name)
+	}
+	return requirements, nil
+}
+
+func (r *ResourceClaimTopology) getResourceClassName(ctx context.Context, pod *v1.Pod, claim v1.PodResourceClaim) (string, error) {
+	if name := lo.FromPtr(claim.Source.ResourceClaimName); name != "" {
+		resourceClaim := &resourcev1alpha2.ResourceClaim{}
+		if err := r.kubeClient.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: name}, resourceClaim); err != nil {
+			return "", fmt.Errorf("getting resource claim %q, %w", name, err)
+		}
+		return resourceClaim.Spec.ResourceClassName, nil
+	}
+	// The claim generated from the template copies the template's spec, so we don't need to wait for it to be created
+	if name := lo.FromPtr(claim.Source.ResourceClaimTemplateName); name != "" {
+		resourceClaimTemplate := &resourcev1alpha2.ResourceClaimTemplate{}
+		if err := r.kubeClient.Get(ctx, types.NamespacedName{Namespace: pod.Namespace, Name: name}, resourceClaimTemplate); err != nil {
+			return "", fmt.Errorf("getting resource claim template %q, %w", name, err)
+		}
+		return resourceClaimTemplate.Spec.Spec.ResourceClassName, nil
+	}
+	return "", fmt.Errorf("resource claim doesn't reference a resource claim or a resource claim template")
+}
+
+// RequiredResourceClaims returns the resource claims of the pod that are required by at least one of its containers.
+// Claims that aren't referenced by any container aren't needed to run the pod, so we don't provision for them.
+func RequiredResourceClaims(pod *v1.Pod) []v1.PodResourceClaim {
+	referenced := sets.New[string]()
+	for _, containers := range [][]v1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for _, c := range containers {
+			for _, claim := range c.Resources.Claims {
+				referenced.Insert(claim.Name)
+			}
+		}
+	}
+	return lo.Filter(pod.Spec.ResourceClaims, func(claim v1.PodResourceClaim, _ int) bool {
+		return referenced.Has(claim.Name)
+	})
+}
@@ -0,0 +1,137 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	resourcev1alpha2 "k8s.io/api/resource/v1alpha2"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	"sigs.k8s.io/karpenter/pkg/test"
+	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+)
+
+var _ = Describe("Resource Claims", func() {
+	const instanceFamilyLabelKey = "example.com/instance-family"
+	const gpuResourceClass = "gpu.example.com"
+	var nodePool *v1beta1.NodePool
+
+	// podWithResourceClaim returns a pending pod whose container requires a resource claim from the given source
+	podWithResourceClaim := func(source v1.ClaimSource) *v1.Pod {
+		pod := test.UnschedulablePod()
+		pod.Spec.ResourceClaims = []v1.PodResourceClaim{{Name: "gpu", Source: source}}
+		pod.Spec.Containers[0].Resources.Claims = []v1.ResourceClaim{{Name: "gpu"}}
+		return pod
+	}
+
+	BeforeEach(func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			ResourceClassRequirements: map[string][]v1.NodeSelectorRequirement{
+				gpuResourceClass: {{Key: instanceFamilyLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{"gpu"}}},
+			},
+		}))
+		generalInstanceType := fake.NewInstanceType(fake.InstanceTypeOptions{
+			Name:      "general-instance-type",
+			Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("4"), v1.ResourceMemory: resource.MustParse("16Gi")},
+		})
+		generalInstanceType.Requirements.Add(scheduling.NewRequirement(instanceFamilyLabelKey, v1.NodeSelectorOpIn, "general"))
+		gpuInstanceType := fake.NewInstanceType(fake.InstanceTypeOptions{
+			Name:      "gpu-instance-type",
+			Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("8"), v1.ResourceMemory: resource.MustParse("32Gi")},
+		})
+		gpuInstanceType.Requirements.Add(scheduling.NewRequirement(instanceFamilyLabelKey, v1.NodeSelectorOpIn, "gpu"))
+		cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{generalInstanceType, gpuInstanceType}
+
+		nodePool = test.NodePool(v1beta1.NodePool{
+			Spec: v1beta1.NodePoolSpec{
+				Template: v1beta1.NodeClaimTemplate{
+					Spec: v1beta1.NodeClaimSpec{
+						Requirements: []v1beta1.NodeSelectorRequirementWithMinValues{
+							{
+								NodeSelectorRequirement: v1.NodeSelectorRequirement{
+									Key:      instanceFamilyLabelKey,
+									Operator: v1.NodeSelectorOpExists,
+								},
+							},
+						},
+					},
+				},
+			},
+		})
+	})
+	It("should launch an instance type from the mapped family for a pod with a required resource claim", func() {
+		claim := &resourcev1alpha2.ResourceClaim{
+			ObjectMeta: test.NamespacedObjectMeta(),
+			Spec:       resourcev1alpha2.ResourceClaimSpec{ResourceClassName: gpuResourceClass},
+		}
+		pod := podWithResourceClaim(v1.ClaimSource{ResourceClaimName: lo.ToPtr(claim.Name)})
+		ExpectApplied(ctx, env.Client, nodePool, claim)
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceTypeStable, "gpu-instance-type"))
+		Expect(node.Labels).To(HaveKeyWithValue(instanceFamilyLabelKey, "gpu"))
+	})
+	It("should launch an instance type from the mapped family for a pod with a resource claim template", func() {
+		claimTemplate := &resourcev1alpha2.ResourceClaimTemplate{
+			ObjectMeta: test.NamespacedObjectMeta(),
+			Spec: resourcev1alpha2.ResourceClaimTemplateSpec{
+				Spec: resourcev1alpha2.ResourceClaimSpec{ResourceClassName: gpuResourceClass},
+			},
+		}
+		pod := podWithResourceClaim(v1.ClaimSource{ResourceClaimTemplateName: lo.ToPtr(claimTemplate.Name)})
+		ExpectApplied(ctx, env.Client, nodePool, claimTemplate)
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceTypeStable, "gpu-instance-type"))
+	})
+	It("should not launch for a pod with a required resource claim for an unmapped resource class", func() {
+		claim := &resourcev1alpha2.ResourceClaim{
+			ObjectMeta: test.NamespacedObjectMeta(),
+			Spec:       resourcev1alpha2.ResourceClaimSpec{ResourceClassName: "fpga.example.com"},
+		}
+		pod := podWithResourceClaim(v1.ClaimSource{ResourceClaimName: lo.ToPtr(claim.Name)})
+		ExpectApplied(ctx, env.Client, nodePool, claim)
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		ExpectNotScheduled(ctx, env.Client, pod)
+	})
+	It("should not launch for a pod with a required resource claim that doesn't exist", func() {
+		pod := podWithResourceClaim(v1.ClaimSource{ResourceClaimName: lo.ToPtr("does-not-exist")})
+		ExpectApplied(ctx, env.Client, nodePool)
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		ExpectNotScheduled(ctx, env.Client, pod)
+	})
+	It("should ignore resource claims that aren't required by any container", func() {
+		claim := &resourcev1alpha2.ResourceClaim{
+			ObjectMeta: test.NamespacedObjectMeta(),
+			Spec:       resourcev1alpha2.ResourceClaimSpec{ResourceClassName: gpuResourceClass},
+		}
+		pod := podWithResourceClaim(v1.ClaimSource{ResourceClaimName: lo.ToPtr(claim.Name)})
+		pod.Spec.Containers[0].Resources.Claims = nil
+		ExpectApplied(ctx, env.Client, nodePool, claim)
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceTypeStable, "general-instance-type"))
+	})
+})
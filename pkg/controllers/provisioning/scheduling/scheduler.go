@@ -26,6 +26,7 @@ import (
 	"github.com/samber/lo"
 	"go.uber.org/multierr"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/uuid"
 	"knative.dev/pkg/logging"
@@ -38,26 +39,69 @@ import (
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/metrics"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
+	"sigs.k8s.io/karpenter/pkg/utils/functional"
 	"sigs.k8s.io/karpenter/pkg/utils/pod"
 	"sigs.k8s.io/karpenter/pkg/utils/resources"
 )
 
+// SchedulerOptions are the set of options that can be used to configure the behavior of the scheduler
+type SchedulerOptions struct {
+	PreferExistingNodes              bool
+	PreferSatisfiedPodAffinities     bool
+	PreferSatisfiedPodAntiAffinities bool
+	MaintainMinNodes                 bool
+	SchedulingFilter                 SchedulingFilter
+	NamespaceCache                   *NamespaceCache
+}
+
+// PreferExistingNodes causes the scheduler to attempt to fit pods onto the spare capacity of existing nodes, relaxing
+// the pods' preferences if needed, before deciding to launch new capacity for them.
+func PreferExistingNodes(o SchedulerOptions) SchedulerOptions {
+	o.PreferExistingNodes = true
+	return o
+}
+
+// PreferSatisfiedPodAffinities causes the scheduler to consider the nodes that a pod fits on in descending order of the
+// total weight of the pod's preferred pod affinity terms that they satisfy. Since the weights are taken from the pod
+// before its preferences are relaxed, this breaks the tie between nodes that a relaxed pod fits on in favor of the
+// nodes that keep it co-located with the pods it prefers.
+func PreferSatisfiedPodAffinities(o SchedulerOptions) SchedulerOptions {
+	o.PreferSatisfiedPodAffinities = true
+	return o
+}
+
+// PreferSatisfiedPodAntiAffinities causes the scheduler to penalize the nodes that a pod fits on by the total weight of
+// the pod's preferred pod anti-affinity terms that they violate. Since the weights are taken from the pod before its
+// preferences are relaxed, a relaxed pod is still kept apart from the pods it prefers to be apart from when a node
+// allows it, without failing to schedule when none does.
+func PreferSatisfiedPodAntiAffinities(o SchedulerOptions) SchedulerOptions {
+	o.PreferSatisfiedPodAntiAffinities = true
+	return o
+}
+
+// MaintainMinNodes causes the scheduler to add NodeClaims without any pods to the NodePools that have fewer nodes than
+// their minNodes, once the pods are scheduled. The existing nodes and the NodeClaims that the pods are scheduled to are
+// counted towards minNodes.
+func MaintainMinNodes(o SchedulerOptions) SchedulerOptions {
+	o.MaintainMinNodes = true
+	return o
+}
+
 func NewScheduler(ctx context.Context, kubeClient client.Client, nodePools []*v1beta1.NodePool,
 	cluster *state.Cluster, stateNodes []*state.StateNode, topology *Topology,
 	instanceTypes map[string][]*cloudprovider.InstanceType, daemonSetPods []*v1.Pod,
-	recorder events.Recorder) *Scheduler {
-
-	// if any of the nodePools add a taint with a prefer no schedule effect, we add a toleration for the taint
-	// during preference relaxation
-	toleratePreferNoSchedule := false
-	for _, np := range nodePools {
-		for _, taint := range np.Spec.Template.Spec.Taints {
-			if taint.Effect == v1.TaintEffectPreferNoSchedule {
-				toleratePreferNoSchedule = true
-			}
-		}
-	}
+	recorder events.Recorder, opts ...functional.Option[SchedulerOptions]) *Scheduler {
+
+	// if any of the nodePools or existing nodes have a taint with a prefer no schedule effect, we add a toleration for
+	// the taint during preference relaxation. This treats the taint as a soft penalty: pods avoid the tainted capacity
+	// while an untainted alternative is feasible, but can still land on it as a last resort.
+	toleratePreferNoSchedule := lo.ContainsBy(nodePools, func(np *v1beta1.NodePool) bool {
+		return hasPreferNoScheduleTaint(np.Spec.Template.Spec.Taints)
+	}) || lo.ContainsBy(stateNodes, func(n *state.StateNode) bool {
+		return hasPreferNoScheduleTaint(n.Taints())
+	})
 
 	templates := lo.Map(nodePools, func(np *v1beta1.NodePool, _ int) *NodeClaimTemplate { return NewNodeClaimTemplate(np) })
 	s := &Scheduler{
@@ -68,14 +112,36 @@ func NewScheduler(ctx context.Context, kubeClient client.Client, nodePools []*v1
 		cluster:            cluster,
 		instanceTypes:      instanceTypes,
 		daemonOverhead:     getDaemonOverhead(templates, daemonSetPods),
+		dedicatedOverhead:  getDaemonOverhead(templates, daemonSetPods, v1beta1.DedicatedNoScheduleTaint),
 		recorder:           recorder,
 		preferences:        &Preferences{ToleratePreferNoSchedule: toleratePreferNoSchedule},
 		remainingResources: lo.SliceToMap(nodePools, func(np *v1beta1.NodePool) (string, v1.ResourceList) { return np.Name, v1.ResourceList(np.Spec.Limits) }),
+		opts:               functional.ResolveOptions(opts...),
+	}
+	if s.opts.SchedulingFilter == nil {
+		s.opts.SchedulingFilter = NopSchedulingFilter{}
+	}
+	if s.opts.NamespaceCache == nil {
+		s.opts.NamespaceCache = NewNamespaceCache(kubeClient)
+	}
+	s.resolveAllowedNamespaces(ctx)
+	for _, nct := range s.nodeClaimTemplates {
+		nct.namespaceInstanceTypes = options.FromContext(ctx).NamespaceInstanceTypes
+		nct.schedulingFilter = s.opts.SchedulingFilter
 	}
 	s.calculateExistingNodeClaims(stateNodes, daemonSetPods)
 	return s
 }
 
+func hasPreferNoScheduleTaint(taints []v1.Taint) bool {
+	return lo.ContainsBy(taints, func(t v1.Taint) bool { return t.Effect == v1.TaintEffectPreferNoSchedule })
+}
+
+// InstanceTypes returns the instance types that the scheduler resolved for each NodePool
+func (s *Scheduler) InstanceTypes() map[string][]*cloudprovider.InstanceType {
+	return s.instanceTypes
+}
+
 type Scheduler struct {
 	id                 types.UID // Unique UUID attached to this scheduling loop
 	newNodeClaims      []*NodeClaim
@@ -84,11 +150,19 @@ type Scheduler struct {
 	remainingResources map[string]v1.ResourceList               // (NodePool name) -> remaining resources for that NodePool
 	instanceTypes      map[string][]*cloudprovider.InstanceType // (NodePool name) -> instance types for NodePool
 	daemonOverhead     map[*NodeClaimTemplate]v1.ResourceList
+	dedicatedOverhead  map[*NodeClaimTemplate]v1.ResourceList // daemon overhead of the NodeClaims dedicated to a pod
 	preferences        *Preferences
 	topology           *Topology
 	cluster            *state.Cluster
 	recorder           events.Recorder
 	kubeClient         client.Client
+	opts               SchedulerOptions
+	// preferredPodAffinities are the preferred pod affinity terms of the pods being scheduled before they are relaxed,
+	// which are only tracked when the PreferSatisfiedPodAffinities option is set
+	preferredPodAffinities map[types.UID][]preferredTerm
+	// preferredPodAntiAffinities are the preferred pod anti-affinity terms of the pods being scheduled before they are
+	// relaxed, which are only tracked when the PreferSatisfiedPodAntiAffinities option is set
+	preferredPodAntiAffinities map[types.UID][]preferredTerm
 }
 
 // Results contains the results of the scheduling operation
@@ -212,6 +286,22 @@ func (s *Scheduler) Solve(ctx context.Context, pods []*v1.Pod) Results {
 	// had 5xA pods and 5xB pods were they have a zonal topology spread, but A can only go in one zone and B in another.
 	// We need to schedule them alternating, A, B, A, B, .... and this solution also solves that as well.
 	errors := map[*v1.Pod]error{}
+	if s.opts.PreferSatisfiedPodAffinities {
+		s.preferredPodAffinities = map[types.UID][]preferredTerm{}
+		for _, p := range pods {
+			if p.Spec.Affinity != nil && p.Spec.Affinity.PodAffinity != nil && len(p.Spec.Affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution) > 0 {
+				s.preferredPodAffinities[p.UID] = s.topology.preferredTerms(ctx, p, TopologyTypePodAffinity, p.Spec.Affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution)
+			}
+		}
+	}
+	if s.opts.PreferSatisfiedPodAntiAffinities {
+		s.preferredPodAntiAffinities = map[types.UID][]preferredTerm{}
+		for _, p := range pods {
+			if p.Spec.Affinity != nil && p.Spec.Affinity.PodAntiAffinity != nil && len(p.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution) > 0 {
+				s.preferredPodAntiAffinities[p.UID] = s.topology.preferredTerms(ctx, p, TopologyTypePodAntiAffinity, p.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution)
+			}
+		}
+	}
 	QueueDepth.DeletePartialMatch(prometheus.Labels{controllerLabel: injection.GetControllerName(ctx)}) // Reset the metric for the controller, so we don't keep old ids around
 	q := NewQueue(pods...)
 	for {
@@ -239,6 +329,9 @@ func (s *Scheduler) Solve(ctx context.Context, pods []*v1.Pod) Results {
 		}
 	}
 
+	if s.opts.MaintainMinNodes {
+		s.addMinNodes(ctx)
+	}
 	for _, m := range s.newNodeClaims {
 		m.FinalizeScheduling()
 	}
@@ -257,18 +350,27 @@ func (s *Scheduler) Solve(ctx context.Context, pods []*v1.Pod) Results {
 
 func (s *Scheduler) add(ctx context.Context, pod *v1.Pod) error {
 	// first try to schedule against an in-flight real node
-	for _, node := range s.existingNodes {
-		if err := node.Add(ctx, s.kubeClient, pod); err == nil {
-			return nil
-		}
+	if s.addToExistingNode(ctx, pod) {
+		return nil
+	}
+	// next, try to use the spare capacity on existing nodes by giving up on the pod's preferences before we consider
+	// launching new capacity that would satisfy them
+	if s.opts.PreferExistingNodes && s.addToExistingNodeWithRelaxation(ctx, pod) {
+		return nil
 	}
 
 	// Consider using https://pkg.go.dev/container/heap
 	sort.Slice(s.newNodeClaims, func(a, b int) bool { return len(s.newNodeClaims[a].Pods) < len(s.newNodeClaims[b].Pods) })
+	if s.hasScoredPreferences(pod) {
+		scores := lo.SliceToMap(s.newNodeClaims, func(n *NodeClaim) (*NodeClaim, int32) {
+			return n, s.preferenceScore(pod, n.Requirements)
+		})
+		sort.SliceStable(s.newNodeClaims, func(a, b int) bool { return scores[s.newNodeClaims[a]] > scores[s.newNodeClaims[b]] })
+	}
 
 	// Pick existing node that we are about to create
 	for _, nodeClaim := range s.newNodeClaims {
-		if err := nodeClaim.Add(pod); err == nil {
+		if err := nodeClaim.Add(ctx, pod); err == nil {
 			return nil
 		}
 	}
@@ -288,11 +390,22 @@ func (s *Scheduler) add(ctx context.Context, pod *v1.Pod) error {
 					len(s.instanceTypes[nodeClaimTemplate.NodePoolName])-len(instanceTypes), len(s.instanceTypes[nodeClaimTemplate.NodePoolName]))
 			}
 		}
-		nodeClaim := NewNodeClaim(nodeClaimTemplate, s.topology, s.daemonOverhead[nodeClaimTemplate], instanceTypes)
-		if err := nodeClaim.Add(pod); err != nil {
+		// exclude the instance types that are larger than the nodepool allows a single node to be
+		instanceTypes, oversized := filterByMaxResources(instanceTypes, nodeClaimTemplate.Spec.MaxInstanceResources)
+		if len(instanceTypes) == 0 {
+			errs = multierr.Append(errs, fmt.Errorf("all available instance types exceed the maxInstanceResources of nodepool %q, maxInstanceResources=%s",
+				nodeClaimTemplate.NodePoolName, resources.String(nodeClaimTemplate.Spec.MaxInstanceResources)))
+			continue
+		}
+		daemonOverhead := s.daemonOverheadFor(nodeClaimTemplate, pod)
+		nodeClaim := NewNodeClaim(nodeClaimTemplate, s.topology, daemonOverhead, instanceTypes)
+		if err := nodeClaim.Add(ctx, pod); err != nil {
+			if oversized > 0 {
+				err = fmt.Errorf("%w, %d instance types that exceed maxInstanceResources=%s were excluded", err, oversized, resources.String(nodeClaimTemplate.Spec.MaxInstanceResources))
+			}
 			errs = multierr.Append(errs, fmt.Errorf("incompatible with nodepool %q, daemonset overhead=%s, %w",
 				nodeClaimTemplate.NodePoolName,
-				resources.String(s.daemonOverhead[nodeClaimTemplate]),
+				resources.String(daemonOverhead),
 				err))
 			continue
 		}
@@ -304,6 +417,95 @@ func (s *Scheduler) add(ctx context.Context, pod *v1.Pod) error {
 	return errs
 }
 
+// hasScoredPreferences returns true if the nodes that the pod fits on are ordered by the preferences it had before it
+// was relaxed
+func (s *Scheduler) hasScoredPreferences(pod *v1.Pod) bool {
+	_, affinities := s.preferredPodAffinities[pod.UID]
+	_, antiAffinities := s.preferredPodAntiAffinities[pod.UID]
+	return affinities || antiAffinities
+}
+
+// preferenceScore scores a node with the requirements by the preferred pod affinity terms that it satisfies and the
+// preferred pod anti-affinity terms that it violates, of the pod before it was relaxed
+func (s *Scheduler) preferenceScore(pod *v1.Pod, requirements scheduling.Requirements) int32 {
+	return preferredPodAffinityScore(s.preferredPodAffinities[pod.UID], requirements) +
+		preferredPodAntiAffinityScore(s.preferredPodAntiAffinities[pod.UID], requirements)
+}
+
+// daemonOverheadFor returns the daemon overhead of a new NodeClaim for the pod. NodeClaims that are dedicated to a pod
+// are tainted, so only the DaemonSets that tolerate the taint run on them.
+func (s *Scheduler) daemonOverheadFor(nodeClaimTemplate *NodeClaimTemplate, p *v1.Pod) v1.ResourceList {
+	if pod.IsDedicated(p) {
+		return s.dedicatedOverhead[nodeClaimTemplate]
+	}
+	return s.daemonOverhead[nodeClaimTemplate]
+}
+
+// addMinNodes adds NodeClaims without any pods to the NodePools that have fewer nodes than their minNodes
+func (s *Scheduler) addMinNodes(ctx context.Context) {
+	for _, nodeClaimTemplate := range s.nodeClaimTemplates {
+		count := lo.CountBy(s.existingNodes, func(n *ExistingNode) bool {
+			return n.Labels()[v1beta1.NodePoolLabelKey] == nodeClaimTemplate.NodePoolName
+		}) + lo.CountBy(s.newNodeClaims, func(n *NodeClaim) bool {
+			return n.NodePoolName == nodeClaimTemplate.NodePoolName
+		})
+		for ; count < nodeClaimTemplate.minNodes; count++ {
+			instanceTypes := filterByRemainingResources(s.instanceTypes[nodeClaimTemplate.NodePoolName], s.remainingResources[nodeClaimTemplate.NodePoolName])
+			nodeClaim := NewNodeClaim(nodeClaimTemplate, s.topology, s.daemonOverhead[nodeClaimTemplate], instanceTypes)
+			if err := nodeClaim.fitDaemonResources(); err != nil {
+				logging.FromContext(ctx).With("nodepool", nodeClaimTemplate.NodePoolName).Errorf("maintaining %d min nodes, %s", nodeClaimTemplate.minNodes, err)
+				break
+			}
+			s.newNodeClaims = append(s.newNodeClaims, nodeClaim)
+			s.remainingResources[nodeClaimTemplate.NodePoolName] = subtractMax(s.remainingResources[nodeClaimTemplate.NodePoolName], nodeClaim.InstanceTypeOptions)
+		}
+	}
+}
+
+func (s *Scheduler) addToExistingNode(ctx context.Context, pod *v1.Pod) bool {
+	existingNodes := s.existingNodes
+	if s.hasScoredPreferences(pod) {
+		scores := lo.SliceToMap(existingNodes, func(n *ExistingNode) (*ExistingNode, int32) {
+			return n, s.preferenceScore(pod, n.requirements)
+		})
+		existingNodes = append([]*ExistingNode{}, existingNodes...)
+		sort.SliceStable(existingNodes, func(a, b int) bool { return scores[existingNodes[a]] > scores[existingNodes[b]] })
+	}
+	for _, node := range existingNodes {
+		if err := s.opts.SchedulingFilter.Filter(ctx, pod, FilterNode{Name: node.Name(), Requirements: node.requirements}); err != nil {
+			continue
+		}
+		if err := node.Add(ctx, s.kubeClient, pod); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// addToExistingNodeWithRelaxation relaxes the pod's preferences one at a time, attempting to schedule it against the
+// existing nodes after each relaxation. If the pod still doesn't fit once it can't be relaxed any further, the pod and
+// its topology are restored so that the pod's preferences are still considered when launching new capacity.
+func (s *Scheduler) addToExistingNodeWithRelaxation(ctx context.Context, pod *v1.Pod) bool {
+	original := pod.DeepCopy()
+	relaxed := false
+	for s.preferences.Relax(ctx, pod) {
+		relaxed = true
+		if err := s.topology.Update(ctx, pod); err != nil {
+			logging.FromContext(ctx).Errorf("updating topology, %s", err)
+		}
+		if s.addToExistingNode(ctx, pod) {
+			return true
+		}
+	}
+	if relaxed {
+		*pod = *original
+		if err := s.topology.Update(ctx, pod); err != nil {
+			logging.FromContext(ctx).Errorf("updating topology, %s", err)
+		}
+	}
+	return false
+}
+
 func (s *Scheduler) calculateExistingNodeClaims(stateNodes []*state.StateNode, daemonSetPods []*v1.Pod) {
 	// create our existing nodes
 	for _, node := range stateNodes {
@@ -318,13 +520,19 @@ func (s *Scheduler) calculateExistingNodeClaims(stateNodes []*state.StateNode, d
 			}
 			daemons = append(daemons, p)
 		}
-		s.existingNodes = append(s.existingNodes, NewExistingNode(node, s.topology, resources.RequestsForPods(daemons...)))
+		existingNode := NewExistingNode(node, s.topology, resources.RequestsForPods(daemons...))
+		if nct, ok := lo.Find(s.nodeClaimTemplates, func(nct *NodeClaimTemplate) bool {
+			return nct.NodePoolName == node.Labels()[v1beta1.NodePoolLabelKey]
+		}); ok {
+			existingNode.allowedNamespaces = nct.allowedNamespaces
+		}
+		s.existingNodes = append(s.existingNodes, existingNode)
 
 		// We don't use the status field and instead recompute the remaining resources to ensure we have a consistent view
 		// of the cluster during scheduling.  Depending on how node creation falls out, this will also work for cases where
 		// we don't create NodeClaim resources.
 		if _, ok := s.remainingResources[node.Labels()[v1beta1.NodePoolLabelKey]]; ok {
-			s.remainingResources[node.Labels()[v1beta1.NodePoolLabelKey]] = resources.Subtract(s.remainingResources[node.Labels()[v1beta1.NodePoolLabelKey]], node.Capacity())
+			s.remainingResources[node.Labels()[v1beta1.NodePoolLabelKey]] = resources.Subtract(s.remainingResources[node.Labels()[v1beta1.NodePoolLabelKey]], limitedResources(node.Capacity()))
 		}
 	}
 	// Order the existing nodes for scheduling with initialized nodes first
@@ -341,7 +549,9 @@ func (s *Scheduler) calculateExistingNodeClaims(stateNodes []*state.StateNode, d
 	})
 }
 
-func getDaemonOverhead(nodeClaimTemplates []*NodeClaimTemplate, daemonSetPods []*v1.Pod) map[*NodeClaimTemplate]v1.ResourceList {
+// getDaemonOverhead returns the requests of the DaemonSet pods that run on the NodeClaims of each template, given the
+// taints that are added to the NodeClaims on top of the template's
+func getDaemonOverhead(nodeClaimTemplates []*NodeClaimTemplate, daemonSetPods []*v1.Pod, taints ...v1.Taint) map[*NodeClaimTemplate]v1.ResourceList {
 	overhead := map[*NodeClaimTemplate]v1.ResourceList{}
 
 	for _, nodeClaimTemplate := range nodeClaimTemplates {
@@ -350,6 +560,9 @@ func getDaemonOverhead(nodeClaimTemplates []*NodeClaimTemplate, daemonSetPods []
 			if err := scheduling.Taints(nodeClaimTemplate.Spec.Taints).Tolerates(p); err != nil {
 				continue
 			}
+			if err := scheduling.Taints(taints).Tolerates(p); err != nil {
+				continue
+			}
 			if err := nodeClaimTemplate.Requirements.Compatible(scheduling.NewPodRequirements(p), scheduling.AllowUndefinedWellKnownLabels); err != nil {
 				continue
 			}
@@ -371,7 +584,7 @@ func subtractMax(remaining v1.ResourceList, instanceTypes []*cloudprovider.Insta
 	}
 	var allInstanceResources []v1.ResourceList
 	for _, it := range instanceTypes {
-		allInstanceResources = append(allInstanceResources, it.Capacity)
+		allInstanceResources = append(allInstanceResources, limitedResources(it.Capacity))
 	}
 	result := v1.ResourceList{}
 	itResources := resources.MaxResources(allInstanceResources...)
@@ -385,6 +598,10 @@ func subtractMax(remaining v1.ResourceList, instanceTypes []*cloudprovider.Insta
 
 // filterByRemainingResources is used to filter out instance types that if launched would exceed the nodepool limits
 func filterByRemainingResources(instanceTypes []*cloudprovider.InstanceType, remaining v1.ResourceList) []*cloudprovider.InstanceType {
+	// every instance type counts as a single node, so none of them can be launched once the node limit is reached
+	if nodes, ok := remaining[v1beta1.ResourceNodes]; ok && nodes.Cmp(*resource.NewQuantity(1, resource.DecimalSI)) < 0 {
+		return nil
+	}
 	var filtered []*cloudprovider.InstanceType
 	for _, it := range instanceTypes {
 		itResources := it.Capacity
@@ -401,3 +618,26 @@ func filterByRemainingResources(instanceTypes []*cloudprovider.InstanceType, rem
 	}
 	return filtered
 }
+
+// filterByMaxResources filters out the instance types with a larger capacity of any resource than the maximum that the
+// nodepool allows, and returns the number of instance types that were filtered out
+func filterByMaxResources(instanceTypes []*cloudprovider.InstanceType, maxResources v1.ResourceList) ([]*cloudprovider.InstanceType, int) {
+	if len(maxResources) == 0 {
+		return instanceTypes, 0
+	}
+	filtered := lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+		for resourceName, maxQuantity := range maxResources {
+			if resources.Cmp(it.Capacity[resourceName], maxQuantity) > 0 {
+				return false
+			}
+		}
+		return true
+	})
+	return filtered, len(instanceTypes) - len(filtered)
+}
+
+// limitedResources returns the resources that a node with the given capacity counts against the limits of its
+// nodepool, which include the node itself
+func limitedResources(capacity v1.ResourceList) v1.ResourceList {
+	return resources.Merge(capacity, v1.ResourceList{v1beta1.ResourceNodes: *resource.NewQuantity(1, resource.DecimalSI)})
+}
@@ -0,0 +1,80 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+)
+
+// SchedulingFilter is consulted by the scheduler with every node that it considers placing a pod on, which are the
+// existing nodes and the instance types of the NodeClaims that it plans to launch. This allows rejections that the
+// scheduling simulation doesn't know about, e.g. those of a scheduler extender, to be encoded so that capacity isn't
+// launched for pods that would never be bound to it.
+type SchedulingFilter interface {
+	// Filter returns nil if the pod may be placed on the node, or an error describing why the placement is rejected
+	Filter(context.Context, *v1.Pod, FilterNode) error
+}
+
+// FilterNode describes a node that the scheduler considers placing a pod on
+type FilterNode struct {
+	// Name is the name of an existing node, and is empty for a node that has yet to be launched
+	Name string
+	// Requirements are the labels of an existing node, or the requirements of the NodeClaim that a node yet to be
+	// launched is created from
+	Requirements scheduling.Requirements
+	// InstanceType is the instance type that a node yet to be launched would be launched as, and is nil for existing nodes
+	InstanceType *cloudprovider.InstanceType
+}
+
+// NopSchedulingFilter is the default SchedulingFilter, which allows every placement
+type NopSchedulingFilter struct{}
+
+func (NopSchedulingFilter) Filter(context.Context, *v1.Pod, FilterNode) error {
+	return nil
+}
+
+// WithSchedulingFilter causes the scheduler to consult the SchedulingFilter before placing each pod on a node
+func WithSchedulingFilter(schedulingFilter SchedulingFilter) func(SchedulerOptions) SchedulerOptions {
+	return func(o SchedulerOptions) SchedulerOptions {
+		o.SchedulingFilter = schedulingFilter
+		return o
+	}
+}
+
+// filteredInstanceTypes returns the instance types that the scheduling filter allows the pod to be launched on, or an
+// error if it rejects all of them
+func (i *NodeClaimTemplate) filteredInstanceTypes(ctx context.Context, instanceTypes cloudprovider.InstanceTypes, pod *v1.Pod, requirements scheduling.Requirements) (cloudprovider.InstanceTypes, error) {
+	var rejection error
+	allowed := lo.Filter(instanceTypes, func(it *cloudprovider.InstanceType, _ int) bool {
+		if err := i.schedulingFilter.Filter(ctx, pod, FilterNode{Requirements: requirements, InstanceType: it}); err != nil {
+			rejection = err
+			return false
+		}
+		return true
+	})
+	if len(allowed) == 0 && rejection != nil {
+		return nil, fmt.Errorf("all instance types were rejected by the scheduling filter, %w", rejection)
+	}
+	return allowed, nil
+}
@@ -56,6 +56,7 @@ import (
 	"sigs.k8s.io/karpenter/pkg/operator/scheme"
 	pscheduling "sigs.k8s.io/karpenter/pkg/scheduling"
 	"sigs.k8s.io/karpenter/pkg/test"
+	"sigs.k8s.io/karpenter/pkg/test/property"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -104,6 +105,7 @@ var _ = AfterSuite(func() {
 })
 
 var _ = BeforeEach(func() {
+	ctx = options.ToContext(ctx, test.Options())
 	// reset instance types
 	newCP := fake.CloudProvider{}
 	cloudProvider.InstanceTypes, _ = newCP.GetInstanceTypes(ctx, nil)
@@ -269,6 +271,53 @@ var _ = Context("Scheduling", func() {
 				node := ExpectScheduled(ctx, env.Client, pod)
 				Expect(node.Labels).To(HaveKeyWithValue(fake.IntegerInstanceLabelKey, "2"))
 			})
+			It("should schedule pods with Operator=Gt requirements to only larger instance types", func() {
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod(
+					test.PodOptions{NodeRequirements: []v1.NodeSelectorRequirement{
+						{Key: fake.IntegerInstanceLabelKey, Operator: v1.NodeSelectorOpGt, Values: []string{"4"}},
+					}},
+				)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				node := ExpectScheduled(ctx, env.Client, pod)
+				Expect(node.Labels).To(HaveKeyWithValue(fake.IntegerInstanceLabelKey, "16"))
+			})
+			It("should compare values numerically for pods with Operator=Lt requirements", func() {
+				ExpectApplied(ctx, env.Client, nodePool)
+				// "4" is lexicographically greater than "10" but should still be allowed since it's numerically smaller
+				pod := test.UnschedulablePod(
+					test.PodOptions{NodeRequirements: []v1.NodeSelectorRequirement{
+						{Key: fake.IntegerInstanceLabelKey, Operator: v1.NodeSelectorOpLt, Values: []string{"10"}},
+						{Key: fake.IntegerInstanceLabelKey, Operator: v1.NodeSelectorOpGt, Values: []string{"2"}},
+					}},
+				)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				node := ExpectScheduled(ctx, env.Client, pod)
+				Expect(node.Labels).To(HaveKeyWithValue(fake.IntegerInstanceLabelKey, "4"))
+			})
+			It("should not schedule pods with Operator=Gt requirements that no instance type satisfies", func() {
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod(
+					test.PodOptions{NodeRequirements: []v1.NodeSelectorRequirement{
+						{Key: fake.IntegerInstanceLabelKey, Operator: v1.NodeSelectorOpGt, Values: []string{"16"}},
+					}},
+				)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectNotScheduled(ctx, env.Client, pod)
+			})
+			It("should schedule pods with Operator=Gt requirements against a NodePool with Operator=Lt requirements", func() {
+				nodePool.Spec.Template.Spec.Requirements = []v1beta1.NodeSelectorRequirementWithMinValues{
+					{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: fake.IntegerInstanceLabelKey, Operator: v1.NodeSelectorOpLt, Values: []string{"10"}}}}
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod(
+					test.PodOptions{NodeRequirements: []v1.NodeSelectorRequirement{
+						{Key: fake.IntegerInstanceLabelKey, Operator: v1.NodeSelectorOpGt, Values: []string{"2"}},
+					}},
+				)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				node := ExpectScheduled(ctx, env.Client, pod)
+				Expect(node.Labels).To(HaveKeyWithValue(fake.IntegerInstanceLabelKey, "4"))
+			})
 			It("should not schedule incompatible preferences and requirements with Operator=In", func() {
 				ExpectApplied(ctx, env.Client, nodePool)
 				pod := test.UnschedulablePod(
@@ -1216,6 +1265,219 @@ var _ = Context("Scheduling", func() {
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
 			ExpectNotScheduled(ctx, env.Client, pod)
 		})
+		It("should select the instance type whose offerings have a cloudprovider-only label that the pod selects", func() {
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+				fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: "gen1-instance-type",
+					Offerings: []cloudprovider.Offering{
+						{
+							CapacityType: v1beta1.CapacityTypeOnDemand,
+							Zone:         "test-zone-1",
+							Price:        1.00,
+							Available:    true,
+							Requirements: pscheduling.NewRequirements(pscheduling.NewRequirement(fake.HypervisorLabelKey, v1.NodeSelectorOpIn, "gen1")),
+						},
+					},
+				}),
+				fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: "gen2-instance-type",
+					Offerings: []cloudprovider.Offering{
+						{
+							CapacityType: v1beta1.CapacityTypeOnDemand,
+							Zone:         "test-zone-1",
+							Price:        2.00,
+							Available:    true,
+							Requirements: pscheduling.NewRequirements(pscheduling.NewRequirement(fake.HypervisorLabelKey, v1.NodeSelectorOpIn, "gen2")),
+						},
+					},
+				}),
+			}
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{
+				NodeSelector: map[string]string{fake.HypervisorLabelKey: "gen2"},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceTypeStable, "gen2-instance-type"))
+			Expect(node.Labels).To(HaveKeyWithValue(fake.HypervisorLabelKey, "gen2"))
+		})
+		It("should select the offering that has a cloudprovider-only label that the pod selects", func() {
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+				fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: "default-instance-type",
+					Offerings: []cloudprovider.Offering{
+						{
+							CapacityType: v1beta1.CapacityTypeOnDemand,
+							Zone:         "test-zone-1",
+							Price:        1.00,
+							Available:    true,
+							Requirements: pscheduling.NewRequirements(pscheduling.NewRequirement(fake.HypervisorLabelKey, v1.NodeSelectorOpIn, "gen1")),
+						},
+						{
+							CapacityType: v1beta1.CapacityTypeOnDemand,
+							Zone:         "test-zone-2",
+							Price:        1.00,
+							Available:    true,
+							Requirements: pscheduling.NewRequirements(pscheduling.NewRequirement(fake.HypervisorLabelKey, v1.NodeSelectorOpIn, "gen1", "gen2")),
+						},
+					},
+				}),
+			}
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{
+				NodeSelector: map[string]string{fake.HypervisorLabelKey: "gen2"},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1.LabelTopologyZone, "test-zone-2"))
+			Expect(node.Labels).To(HaveKeyWithValue(fake.HypervisorLabelKey, "gen2"))
+		})
+		It("should not schedule if no offering has the cloudprovider-only label value that the pod selects", func() {
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+				fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: "gen1-instance-type",
+					Offerings: []cloudprovider.Offering{
+						{
+							CapacityType: v1beta1.CapacityTypeOnDemand,
+							Zone:         "test-zone-1",
+							Price:        1.00,
+							Available:    true,
+							Requirements: pscheduling.NewRequirements(pscheduling.NewRequirement(fake.HypervisorLabelKey, v1.NodeSelectorOpIn, "gen1")),
+						},
+					},
+				}),
+			}
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{
+				NodeSelector: map[string]string{fake.HypervisorLabelKey: "gen2"},
+			})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		Context("Cloudprovider-only Version Labels", func() {
+			BeforeEach(func() {
+				cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+					fake.NewInstanceType(fake.InstanceTypeOptions{
+						Name: "old-image-instance-type",
+						Offerings: []cloudprovider.Offering{
+							{
+								CapacityType: v1beta1.CapacityTypeOnDemand,
+								Zone:         "test-zone-1",
+								Price:        1.00,
+								Available:    true,
+								Requirements: pscheduling.NewRequirements(pscheduling.NewRequirement(fake.OSVersionLabelKey, v1.NodeSelectorOpIn, "20230101")),
+							},
+						},
+					}),
+					fake.NewInstanceType(fake.InstanceTypeOptions{
+						Name: "mixed-image-instance-type",
+						Offerings: []cloudprovider.Offering{
+							{
+								CapacityType: v1beta1.CapacityTypeOnDemand,
+								Zone:         "test-zone-1",
+								Price:        2.00,
+								Available:    true,
+								Requirements: pscheduling.NewRequirements(pscheduling.NewRequirement(fake.OSVersionLabelKey, v1.NodeSelectorOpIn, "20230101")),
+							},
+							{
+								CapacityType: v1beta1.CapacityTypeOnDemand,
+								Zone:         "test-zone-2",
+								Price:        2.00,
+								Available:    true,
+								Requirements: pscheduling.NewRequirements(pscheduling.NewRequirement(fake.OSVersionLabelKey, v1.NodeSelectorOpIn, "20240301")),
+							},
+						},
+					}),
+				}
+			})
+			It("should only choose offerings with a newer version than the pod requires", func() {
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod(test.PodOptions{
+					NodeRequirements: []v1.NodeSelectorRequirement{
+						{Key: fake.OSVersionLabelKey, Operator: v1.NodeSelectorOpGt, Values: []string{"20240101"}},
+					},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				node := ExpectScheduled(ctx, env.Client, pod)
+				Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceTypeStable, "mixed-image-instance-type"))
+				Expect(node.Labels).To(HaveKeyWithValue(v1.LabelTopologyZone, "test-zone-2"))
+				Expect(node.Labels).To(HaveKeyWithValue(fake.OSVersionLabelKey, "20240301"))
+			})
+			It("should only choose offerings with an older version than the pod allows", func() {
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod(test.PodOptions{
+					NodeRequirements: []v1.NodeSelectorRequirement{
+						{Key: fake.OSVersionLabelKey, Operator: v1.NodeSelectorOpLt, Values: []string{"20240101"}},
+					},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				node := ExpectScheduled(ctx, env.Client, pod)
+				Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceTypeStable, "old-image-instance-type"))
+				Expect(node.Labels).To(HaveKeyWithValue(fake.OSVersionLabelKey, "20230101"))
+			})
+			It("should not schedule if no offering has a newer version than the pod requires", func() {
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod(test.PodOptions{
+					NodeRequirements: []v1.NodeSelectorRequirement{
+						{Key: fake.OSVersionLabelKey, Operator: v1.NodeSelectorOpGt, Values: []string{"20250101"}},
+					},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectNotScheduled(ctx, env.Client, pod)
+			})
+			It("should only choose offerings with a newer version than the nodepool requires", func() {
+				nodePool.Spec.Template.Spec.Requirements = append(nodePool.Spec.Template.Spec.Requirements, v1beta1.NodeSelectorRequirementWithMinValues{
+					NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: fake.OSVersionLabelKey, Operator: v1.NodeSelectorOpGt, Values: []string{"20240101"}},
+				})
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod()
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				node := ExpectScheduled(ctx, env.Client, pod)
+				Expect(node.Labels).To(HaveKeyWithValue(fake.OSVersionLabelKey, "20240301"))
+			})
+			It("should schedule to existing nodes with a newer version than the pod requires", func() {
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod(test.PodOptions{
+					NodeRequirements: []v1.NodeSelectorRequirement{
+						{Key: fake.OSVersionLabelKey, Operator: v1.NodeSelectorOpGt, Values: []string{"20240101"}},
+					},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				node := ExpectScheduled(ctx, env.Client, pod)
+
+				pod = test.UnschedulablePod(test.PodOptions{
+					NodeRequirements: []v1.NodeSelectorRequirement{
+						{Key: fake.OSVersionLabelKey, Operator: v1.NodeSelectorOpGt, Values: []string{"20231201"}},
+					},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				Expect(ExpectScheduled(ctx, env.Client, pod).Name).To(Equal(node.Name))
+			})
+			It("should not choose offerings with versions that aren't integers", func() {
+				cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+					fake.NewInstanceType(fake.InstanceTypeOptions{
+						Name: "dotted-version-instance-type",
+						Offerings: []cloudprovider.Offering{
+							{
+								CapacityType: v1beta1.CapacityTypeOnDemand,
+								Zone:         "test-zone-1",
+								Price:        1.00,
+								Available:    true,
+								Requirements: pscheduling.NewRequirements(pscheduling.NewRequirement(fake.OSVersionLabelKey, v1.NodeSelectorOpIn, "5.10")),
+							},
+						},
+					}),
+				}
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod(test.PodOptions{
+					NodeRequirements: []v1.NodeSelectorRequirement{
+						{Key: fake.OSVersionLabelKey, Operator: v1.NodeSelectorOpGt, Values: []string{"4"}},
+					},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectNotScheduled(ctx, env.Client, pod)
+			})
+		})
 		It("should launch pods with different archs on different instances", func() {
 			nodePool.Spec.Template.Spec.Requirements = []v1beta1.NodeSelectorRequirementWithMinValues{
 				{
@@ -1492,6 +1754,56 @@ var _ = Context("Scheduling", func() {
 		})
 	})
 
+	Describe("Scheduling Objective", func() {
+		BeforeEach(func() {
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+				fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: "small-instance-type",
+					Resources: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse("2"),
+						v1.ResourceMemory: resource.MustParse("4Gi"),
+						v1.ResourcePods:   resource.MustParse("10"),
+					},
+					Offerings: []cloudprovider.Offering{
+						{CapacityType: v1beta1.CapacityTypeOnDemand, Zone: "test-zone-1", Price: 1.00, Available: true},
+					},
+				}),
+				fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: "large-instance-type",
+					Resources: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse("8"),
+						v1.ResourceMemory: resource.MustParse("16Gi"),
+						v1.ResourcePods:   resource.MustParse("10"),
+					},
+					Offerings: []cloudprovider.Offering{
+						{CapacityType: v1beta1.CapacityTypeOnDemand, Zone: "test-zone-1", Price: 5.00, Available: true},
+					},
+				}),
+			}
+		})
+		DescribeTable("should pack pods onto the cheapest nodes regardless of the scheduling objective",
+			func(objective v1beta1.SchedulingObjective) {
+				nodePool.Spec.SchedulingObjective = objective
+				ExpectApplied(ctx, env.Client, nodePool)
+				opts := test.PodOptions{ResourceRequirements: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1.5")},
+				}}
+				// Five of the pending pods fill a large node and the sixth one spills over onto a second node. Growing
+				// the second node wouldn't save a node, so it's launched with the cheapest instance type that fits.
+				pods := lo.Times(6, func(_ int) *v1.Pod { return test.UnschedulablePod(opts) })
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+				for _, pod := range pods {
+					ExpectScheduled(ctx, env.Client, pod)
+				}
+				Expect(lo.Map(ExpectNodes(ctx, env.Client), func(n *v1.Node, _ int) string {
+					return n.Labels[v1.LabelInstanceTypeStable]
+				})).To(ConsistOf("large-instance-type", "small-instance-type"))
+			},
+			Entry("Cheapest", v1beta1.SchedulingObjectiveCheapest),
+			Entry("no objective", v1beta1.SchedulingObjective("")),
+			Entry("FewestNodes", v1beta1.SchedulingObjectiveFewestNodes),
+		)
+	})
 	Describe("Binpacking", func() {
 		It("should schedule a small pod on the smallest instance", func() {
 			ExpectApplied(ctx, env.Client, nodePool)
@@ -1545,6 +1857,35 @@ var _ = Context("Scheduling", func() {
 			// would
 			Expect(node.Labels[v1.LabelInstanceTypeStable]).To(Equal("default-instance-type"))
 		})
+		It("should take the pod overhead that's populated from the pod's runtime class into consideration", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			runtimeClass := &nodev1.RuntimeClass{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "sandboxed-runtime-class",
+				},
+				Handler: "sandboxed",
+				Overhead: &nodev1.Overhead{
+					PodFixed: v1.ResourceList{
+						v1.ResourceCPU: resource.MustParse("2"),
+					},
+				},
+			}
+			pod := test.UnschedulablePod(test.PodOptions{
+				ResourceRequirements: v1.ResourceRequirements{
+					Requests: map[v1.ResourceName]resource.Quantity{
+						v1.ResourceCPU: resource.MustParse("1"),
+					},
+				},
+				// The overhead is copied from the runtime class when the pod is admitted
+				Overhead: runtimeClass.Overhead.PodFixed,
+			})
+			pod.Spec.RuntimeClassName = &runtimeClass.Name
+			ExpectApplied(ctx, env.Client, runtimeClass)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(ExpectExists(ctx, env.Client, pod).Spec.Overhead).To(HaveKeyWithValue(v1.ResourceCPU, resource.MustParse("2")))
+			Expect(node.Labels[v1.LabelInstanceTypeStable]).To(Equal("default-instance-type"))
+		})
 		It("should schedule multiple small pods on the smallest possible instance type", func() {
 			opts := test.PodOptions{
 				Conditions: []v1.PodCondition{{Type: v1.PodScheduled, Reason: v1.PodReasonUnschedulable, Status: v1.ConditionFalse}},
@@ -1801,8 +2142,369 @@ var _ = Context("Scheduling", func() {
 			possibleInstanceType := sets.NewString(pscheduling.NewNodeSelectorRequirementsWithMinValues(cloudProvider.CreateCalls[0].Spec.Requirements...).Get(v1.LabelInstanceTypeStable).Values()...)
 			Expect(possibleInstanceType).To(Equal(sets.NewString("small", "medium", "large")))
 		})
+		Context("Request Rounding", func() {
+			BeforeEach(func() {
+				cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+					fake.NewInstanceType(fake.InstanceTypeOptions{
+						Name: "instance-type",
+						Resources: v1.ResourceList{
+							v1.ResourceCPU:    resource.MustParse("4"),
+							v1.ResourceMemory: resource.MustParse("4Gi"),
+							v1.ResourcePods:   resource.MustParse("100"),
+						},
+					}),
+				}
+			})
+			oddSizedPods := func(count int) []*v1.Pod {
+				return test.UnschedulablePods(test.PodOptions{ResourceRequirements: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("370m")},
+				}}, count)
+			}
+			It("should pack pods using their actual requests without request rounding", func() {
+				ExpectApplied(ctx, env.Client, nodePool)
+				pods := oddSizedPods(10)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+				nodeNames := sets.New[string]()
+				for _, pod := range pods {
+					nodeNames.Insert(ExpectScheduled(ctx, env.Client, pod).Name)
+				}
+				// 10 * 370m = 3.7 CPU fits within the 3.9 CPU allocatable of a single node
+				Expect(nodeNames).To(HaveLen(1))
+			})
+			It("should pack pods using their rounded requests with request rounding", func() {
+				nodePool.Spec.RequestRounding = v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m")}
+				ExpectApplied(ctx, env.Client, nodePool)
+				pods := oddSizedPods(10)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+				nodeNames := sets.New[string]()
+				for _, pod := range pods {
+					nodeNames.Insert(ExpectScheduled(ctx, env.Client, pod).Name)
+					// the pod itself isn't modified
+					Expect(pod.Spec.Containers[0].Resources.Requests.Cpu().String()).To(Equal("370m"))
+				}
+				// each pod is treated as 500m, so at most 7 pods fit within the 3.9 CPU allocatable of a node
+				Expect(nodeNames).To(HaveLen(2))
+				for _, nodeClaim := range cloudProvider.CreateCalls {
+					cpu := nodeClaim.Spec.Resources.Requests[v1.ResourceCPU]
+					Expect(cpu.MilliValue() % 500).To(BeZero())
+				}
+			})
+			It("should use the actual requests if the rounded requests don't fit on any instance type", func() {
+				nodePool.Spec.RequestRounding = v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod(test.PodOptions{ResourceRequirements: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("3.8")},
+				}})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectScheduled(ctx, env.Client, pod)
+			})
+			It("should never place a pod where its actual requests wouldn't fit", func() {
+				nodePool.Spec.RequestRounding = v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod(test.PodOptions{ResourceRequirements: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")},
+				}})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectNotScheduled(ctx, env.Client, pod)
+			})
+		})
 	})
 
+	Describe("Dedicated Nodes", func() {
+		var dedicatedOpts test.PodOptions
+		BeforeEach(func() {
+			dedicatedOpts = test.PodOptions{
+				ObjectMeta:  metav1.ObjectMeta{Annotations: map[string]string{v1beta1.DedicatedAnnotationKey: "true"}},
+				Tolerations: []v1.Toleration{{Key: v1beta1.DedicatedTaintKey, Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoSchedule}},
+			}
+		})
+		It("should launch a dedicated node for a pod that requests one", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			dedicatedPod := test.UnschedulablePod(dedicatedOpts)
+			pods := test.UnschedulablePods(test.PodOptions{}, 3)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, append(pods, dedicatedPod)...)
+
+			dedicatedNode := ExpectScheduled(ctx, env.Client, dedicatedPod)
+			Expect(dedicatedNode.Spec.Taints).To(ContainElement(v1beta1.DedicatedNoScheduleTaint))
+			nodeNames := sets.New[string]()
+			for _, pod := range pods {
+				node := ExpectScheduled(ctx, env.Client, pod)
+				Expect(node.Name).ToNot(Equal(dedicatedNode.Name))
+				Expect(node.Spec.Taints).ToNot(ContainElement(v1beta1.DedicatedNoScheduleTaint))
+				nodeNames.Insert(node.Name)
+			}
+			// the other pods are still packed together
+			Expect(nodeNames).To(HaveLen(1))
+		})
+		It("should launch a separate node for each dedicated pod", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pods := test.UnschedulablePods(dedicatedOpts, 3)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+
+			nodeNames := sets.New[string]()
+			for _, pod := range pods {
+				nodeNames.Insert(ExpectScheduled(ctx, env.Client, pod).Name)
+			}
+			Expect(nodeNames).To(HaveLen(3))
+		})
+		It("should not schedule pods to a node running a dedicated pod", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			dedicatedPod := test.UnschedulablePod(dedicatedOpts)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, dedicatedPod)
+			node1 := ExpectScheduled(ctx, env.Client, dedicatedPod)
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node1))
+
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node2 := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node2.Name).ToNot(Equal(node1.Name))
+		})
+		It("should not schedule pods to an in-flight node launched for a dedicated pod that hasn't bound yet", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			dedicatedPod := test.UnschedulablePod(dedicatedOpts)
+			ExpectProvisionedNoBinding(ctx, env.Client, cluster, cloudProvider, prov, dedicatedPod)
+			nodeClaims := ExpectNodeClaims(ctx, env.Client)
+			Expect(nodeClaims).To(HaveLen(1))
+			Expect(nodeClaims[0].Spec.Taints).To(ContainElement(v1beta1.DedicatedNoScheduleTaint))
+			var nodes v1.NodeList
+			Expect(env.Client.List(ctx, &nodes)).To(Succeed())
+			Expect(nodes.Items).To(HaveLen(1))
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(&nodes.Items[0]))
+
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Name).ToNot(Equal(nodes.Items[0].Name))
+		})
+		It("should schedule a dedicated pod to the in-flight node that was launched for it", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			dedicatedPod := test.UnschedulablePod(dedicatedOpts)
+			ExpectProvisionedNoBinding(ctx, env.Client, cluster, cloudProvider, prov, dedicatedPod)
+			var nodes v1.NodeList
+			Expect(env.Client.List(ctx, &nodes)).To(Succeed())
+			Expect(nodes.Items).To(HaveLen(1))
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(&nodes.Items[0]))
+
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, dedicatedPod)
+			node := ExpectScheduled(ctx, env.Client, dedicatedPod)
+			Expect(node.Name).To(Equal(nodes.Items[0].Name))
+			// shouldn't create a second node
+			Expect(env.Client.List(ctx, &nodes)).To(Succeed())
+			Expect(nodes.Items).To(HaveLen(1))
+		})
+		It("should not schedule a dedicated pod to a node running other pods", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node1 := ExpectScheduled(ctx, env.Client, pod)
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node1))
+
+			dedicatedPod := test.UnschedulablePod(dedicatedOpts)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, dedicatedPod)
+			node2 := ExpectScheduled(ctx, env.Client, dedicatedPod)
+			Expect(node2.Name).ToNot(Equal(node1.Name))
+		})
+		It("should not schedule a dedicated pod that doesn't tolerate the dedicated taint", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			dedicatedPod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{v1beta1.DedicatedAnnotationKey: "true"}}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, dedicatedPod)
+			ExpectNotScheduled(ctx, env.Client, dedicatedPod)
+		})
+		It("should schedule a dedicated pod to a dedicated node once its dedicated pod has left", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			dedicatedPod := test.UnschedulablePod(dedicatedOpts)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, dedicatedPod)
+			node := ExpectScheduled(ctx, env.Client, dedicatedPod)
+			ExpectDeleted(ctx, env.Client, dedicatedPod)
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+
+			// other pods still can't schedule to the node, as it keeps the dedicated taint
+			pod := test.UnschedulablePod()
+			dedicatedPod2 := test.UnschedulablePod(dedicatedOpts)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod, dedicatedPod2)
+			Expect(ExpectScheduled(ctx, env.Client, dedicatedPod2).Name).To(Equal(node.Name))
+			Expect(ExpectScheduled(ctx, env.Client, pod).Name).ToNot(Equal(node.Name))
+		})
+		It("should schedule a dedicated pod to an existing node that only runs daemonset pods", func() {
+			node := test.Node(test.NodeOptions{
+				Allocatable: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("10"),
+					v1.ResourceMemory: resource.MustParse("10Gi"),
+					v1.ResourcePods:   resource.MustParse("110"),
+				},
+			})
+			daemonSet := test.DaemonSet()
+			daemonSetPod := test.Pod(test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{{
+					APIVersion:         "apps/v1",
+					Kind:               "DaemonSet",
+					Name:               daemonSet.Name,
+					UID:                daemonSet.UID,
+					Controller:         lo.ToPtr(true),
+					BlockOwnerDeletion: lo.ToPtr(true),
+				}}},
+			})
+			ExpectApplied(ctx, env.Client, node, daemonSet, daemonSetPod)
+			ExpectManualBinding(ctx, env.Client, daemonSetPod, node)
+			ExpectMakeNodesInitialized(ctx, env.Client, node)
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+
+			ExpectApplied(ctx, env.Client, nodePool)
+			dedicatedPod := test.UnschedulablePod(dedicatedOpts)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, dedicatedPod)
+			scheduledNode := ExpectScheduled(ctx, env.Client, dedicatedPod)
+			Expect(scheduledNode.Name).To(Equal(node.Name))
+		})
+	})
+	// NodeClaimTemplates carry a requirement on the karpenter.sh/nodepool label of their NodePool, so the pods that are
+	// pinned to NodePools with this label are only compatible with the NodeClaims of those NodePools
+	Describe("NodePool Selection", func() {
+		var lowWeight, highWeight *v1beta1.NodePool
+		BeforeEach(func() {
+			lowWeight = test.NodePool(v1beta1.NodePool{Spec: v1beta1.NodePoolSpec{Weight: ptr.Int32(10)}})
+			highWeight = test.NodePool(v1beta1.NodePool{Spec: v1beta1.NodePoolSpec{Weight: ptr.Int32(100)}})
+		})
+		It("should only provision pods pinned to a nodepool with a node selector from that nodepool", func() {
+			ExpectApplied(ctx, env.Client, nodePool, lowWeight, highWeight)
+			pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{v1beta1.NodePoolLabelKey: lowWeight.Name}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1beta1.NodePoolLabelKey, lowWeight.Name))
+		})
+		It("should only provision pods pinned to several nodepools from those nodepools", func() {
+			ExpectApplied(ctx, env.Client, nodePool, lowWeight, highWeight)
+			pod := test.UnschedulablePod(test.PodOptions{NodeRequirements: []v1.NodeSelectorRequirement{
+				{Key: v1beta1.NodePoolLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{nodePool.Name, lowWeight.Name}},
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			// the highest weight nodepool of the selected ones is preferred
+			Expect(node.Labels).To(HaveKeyWithValue(v1beta1.NodePoolLabelKey, lowWeight.Name))
+		})
+		It("should not provision pods from the nodepools that they exclude", func() {
+			ExpectApplied(ctx, env.Client, lowWeight, highWeight)
+			pod := test.UnschedulablePod(test.PodOptions{NodeRequirements: []v1.NodeSelectorRequirement{
+				{Key: v1beta1.NodePoolLabelKey, Operator: v1.NodeSelectorOpNotIn, Values: []string{highWeight.Name}},
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1beta1.NodePoolLabelKey, lowWeight.Name))
+		})
+		It("should launch separate nodes for pods pinned to different nodepools", func() {
+			ExpectApplied(ctx, env.Client, lowWeight, highWeight)
+			lowWeightPod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{v1beta1.NodePoolLabelKey: lowWeight.Name}})
+			highWeightPod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{v1beta1.NodePoolLabelKey: highWeight.Name}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, lowWeightPod, highWeightPod)
+			Expect(ExpectScheduled(ctx, env.Client, lowWeightPod).Labels).To(HaveKeyWithValue(v1beta1.NodePoolLabelKey, lowWeight.Name))
+			Expect(ExpectScheduled(ctx, env.Client, highWeightPod).Labels).To(HaveKeyWithValue(v1beta1.NodePoolLabelKey, highWeight.Name))
+		})
+		It("should not fall back to other nodepools when the pinned nodepool can't provision the pod", func() {
+			lowWeight.Spec.Limits = v1beta1.Limits(v1.ResourceList{v1.ResourceCPU: resource.MustParse("0")})
+			ExpectApplied(ctx, env.Client, lowWeight, highWeight)
+			pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{v1beta1.NodePoolLabelKey: lowWeight.Name}})
+			ExpectApplied(ctx, env.Client, pod)
+			results, _ := prov.Schedule(ctx)
+			Expect(results.NewNodeClaims).To(BeEmpty())
+			Expect(results.PodErrors).To(HaveLen(1))
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should not provision pods pinned to a nodepool that doesn't exist", func() {
+			ExpectApplied(ctx, env.Client, lowWeight, highWeight)
+			pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{v1beta1.NodePoolLabelKey: "unknown"}})
+			ExpectApplied(ctx, env.Client, pod)
+			results, _ := prov.Schedule(ctx)
+			Expect(results.NewNodeClaims).To(BeEmpty())
+			Expect(results.PodErrors).To(HaveLen(1))
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should schedule pods pinned to a nodepool to its existing nodes", func() {
+			ExpectApplied(ctx, env.Client, lowWeight, highWeight)
+			pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{v1beta1.NodePoolLabelKey: lowWeight.Name}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+
+			pod2 := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{v1beta1.NodePoolLabelKey: lowWeight.Name}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod2)
+			Expect(ExpectScheduled(ctx, env.Client, pod2).Name).To(Equal(node.Name))
+		})
+	})
+	Describe("Max Instance Resources", func() {
+		BeforeEach(func() {
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+				fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name:      "small-instance-type",
+					Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2"), v1.ResourceMemory: resource.MustParse("4Gi")},
+				}),
+				fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name:      "large-instance-type",
+					Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("16"), v1.ResourceMemory: resource.MustParse("64Gi")},
+				}),
+			}
+		})
+		It("should pack pods onto larger instance types without a cap", func() {
+			ExpectApplied(ctx, env.Client, nodePool)
+			pods := test.UnschedulablePods(test.PodOptions{ResourceRequirements: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1.5")},
+			}}, 2)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			node := ExpectScheduled(ctx, env.Client, pods[0])
+			Expect(ExpectScheduled(ctx, env.Client, pods[1]).Name).To(Equal(node.Name))
+			Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceTypeStable, "large-instance-type"))
+		})
+		It("should not launch instance types that exceed the cap", func() {
+			nodePool.Spec.Template.Spec.MaxInstanceResources = v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")}
+			ExpectApplied(ctx, env.Client, nodePool)
+			pods := test.UnschedulablePods(test.PodOptions{ResourceRequirements: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1.5")},
+			}}, 2)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			for _, pod := range pods {
+				Expect(ExpectScheduled(ctx, env.Client, pod).Labels).To(HaveKeyWithValue(v1.LabelInstanceTypeStable, "small-instance-type"))
+			}
+			Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(2))
+		})
+		It("should leave pods that only fit on instance types that exceed the cap pending", func() {
+			nodePool.Spec.Template.Spec.MaxInstanceResources = v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")}
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{ResourceRequirements: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("8")},
+			}})
+			ExpectApplied(ctx, env.Client, pod)
+			results, _ := prov.Schedule(ctx)
+			Expect(results.NewNodeClaims).To(BeEmpty())
+			Expect(results.PodErrors).To(HaveLen(1))
+			for _, err := range results.PodErrors {
+				Expect(err.Error()).To(ContainSubstring("1 instance types that exceed maxInstanceResources"))
+			}
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should leave pods pending when every instance type exceeds the cap", func() {
+			nodePool.Spec.Template.Spec.MaxInstanceResources = v1.ResourceList{v1.ResourceMemory: resource.MustParse("2Gi")}
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectApplied(ctx, env.Client, pod)
+			results, _ := prov.Schedule(ctx)
+			Expect(results.NewNodeClaims).To(BeEmpty())
+			Expect(results.PodErrors).To(HaveLen(1))
+			for _, err := range results.PodErrors {
+				Expect(err.Error()).To(ContainSubstring("all available instance types exceed the maxInstanceResources"))
+			}
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should only apply the cap to the nodepool that sets it", func() {
+			nodePool.Spec.Template.Spec.MaxInstanceResources = v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")}
+			uncapped := test.NodePool()
+			ExpectApplied(ctx, env.Client, nodePool, uncapped)
+			pod := test.UnschedulablePod(test.PodOptions{ResourceRequirements: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("8")},
+			}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1beta1.NodePoolLabelKey, uncapped.Name))
+			Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceTypeStable, "large-instance-type"))
+		})
+	})
 	Describe("In-Flight Nodes", func() {
 		It("should not launch a second node if there is an in-flight node that can support the pod", func() {
 			opts := test.PodOptions{ResourceRequirements: v1.ResourceRequirements{
@@ -2439,6 +3141,46 @@ var _ = Context("Scheduling", func() {
 			scheduledNode := ExpectScheduled(ctx, env.Client, pod)
 			Expect(node.Name).To(Equal(scheduledNode.Name))
 		})
+		It("should not schedule a pod to an existing node with a PreferNoSchedule taint when it can launch untainted capacity", func() {
+			node := test.Node(test.NodeOptions{
+				Allocatable: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("10"),
+					v1.ResourceMemory: resource.MustParse("10Gi"),
+					v1.ResourcePods:   resource.MustParse("110"),
+				},
+				Taints: []v1.Taint{{Key: "foo", Value: "bar", Effect: v1.TaintEffectPreferNoSchedule}},
+			})
+			ExpectApplied(ctx, env.Client, node)
+			ExpectMakeNodesInitialized(ctx, env.Client, node)
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			scheduledNode := ExpectScheduled(ctx, env.Client, pod)
+			Expect(scheduledNode.Name).ToNot(Equal(node.Name))
+		})
+		It("should schedule a pod to an existing node with a PreferNoSchedule taint when no other placement is feasible", func() {
+			node := test.Node(test.NodeOptions{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"foo": "bar"}},
+				Allocatable: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("10"),
+					v1.ResourceMemory: resource.MustParse("10Gi"),
+					v1.ResourcePods:   resource.MustParse("110"),
+				},
+				Taints: []v1.Taint{{Key: "foo", Value: "bar", Effect: v1.TaintEffectPreferNoSchedule}},
+			})
+			ExpectApplied(ctx, env.Client, node)
+			ExpectMakeNodesInitialized(ctx, env.Client, node)
+			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+
+			// The nodePool doesn't define the "foo" label, so only the tainted node can satisfy the pod's node selector
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{"foo": "bar"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			scheduledNode := ExpectScheduled(ctx, env.Client, pod)
+			Expect(scheduledNode.Name).To(Equal(node.Name))
+		})
 		It("should schedule multiple pods to an existing node unowned by Karpenter", func() {
 			node := test.Node(test.NodeOptions{
 				Allocatable: v1.ResourceList{
@@ -2534,6 +3276,63 @@ var _ = Context("Scheduling", func() {
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
 			ExpectScheduled(ctx, env.Client, pod)
 		})
+		Context("PreferExistingNodes", func() {
+			var node *v1.Node
+			var pod *v1.Pod
+			BeforeEach(func() {
+				node = test.Node(test.NodeOptions{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{v1.LabelTopologyZone: "test-zone-1"},
+					},
+					Allocatable: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse("10"),
+						v1.ResourceMemory: resource.MustParse("10Gi"),
+						v1.ResourcePods:   resource.MustParse("110"),
+					},
+				})
+				ExpectApplied(ctx, env.Client, nodePool, node)
+				ExpectMakeNodesInitialized(ctx, env.Client, node)
+				ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(node))
+
+				pod = test.UnschedulablePod(test.PodOptions{
+					NodePreferences: []v1.NodeSelectorRequirement{
+						{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-2"}},
+					},
+				})
+			})
+			It("should launch a new nodeclaim that satisfies the pod's preferences when disabled", func() {
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				scheduledNode := ExpectScheduled(ctx, env.Client, pod)
+				Expect(scheduledNode.Name).ToNot(Equal(node.Name))
+				Expect(scheduledNode.Labels).To(HaveKeyWithValue(v1.LabelTopologyZone, "test-zone-2"))
+				Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+			})
+			It("should schedule a pod to the spare capacity of an existing node instead of launching when enabled", func() {
+				ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{PreferExistingNodes: lo.ToPtr(true)}}))
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				scheduledNode := ExpectScheduled(ctx, env.Client, pod)
+				Expect(scheduledNode.Name).To(Equal(node.Name))
+				Expect(cloudProvider.CreateCalls).To(HaveLen(0))
+				nodeClaims := &v1beta1.NodeClaimList{}
+				Expect(env.Client.List(ctx, nodeClaims)).To(Succeed())
+				Expect(nodeClaims.Items).To(HaveLen(0))
+			})
+			It("should keep the pod's preferences when launching if it doesn't fit on an existing node when enabled", func() {
+				ctx = options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{PreferExistingNodes: lo.ToPtr(true)}}))
+				pod = test.UnschedulablePod(test.PodOptions{
+					ResourceRequirements: v1.ResourceRequirements{
+						Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("11")},
+					},
+					NodePreferences: []v1.NodeSelectorRequirement{
+						{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-2"}},
+					},
+				})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				scheduledNode := ExpectScheduled(ctx, env.Client, pod)
+				Expect(scheduledNode.Name).ToNot(Equal(node.Name))
+				Expect(scheduledNode.Labels).To(HaveKeyWithValue(v1.LabelTopologyZone, "test-zone-2"))
+			})
+		})
 		Context("Daemonsets", func() {
 			It("should not subtract daemonset overhead that is not strictly compatible with an existing node", func() {
 				nodeClaim, node := test.NodeClaimAndNode(v1beta1.NodeClaim{
@@ -3631,6 +4430,34 @@ var _ = Context("Scheduling", func() {
 		})
 	})
 
+	Describe("Properties", func() {
+		It("should only schedule pods to nodes with labels that satisfy the pods' requirements", func() {
+			property.ExpectProperty(10, func(g *property.Generator) error {
+				ExpectCleanedUp(ctx, env.Client)
+				cluster.Reset()
+				cloudProvider.InstanceTypes = g.InstanceTypes(10)
+				pods := g.Pods(5)
+				ExpectApplied(ctx, env.Client, test.NodePool())
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+
+				for _, pod := range pods {
+					pod = ExpectPodExists(ctx, env.Client, pod.Name, pod.Namespace)
+					if pod.Spec.NodeName == "" {
+						continue
+					}
+					node := ExpectNodeExists(ctx, env.Client, pod.Spec.NodeName)
+					matches, err := property.Matches(node.Labels, property.NodeSelectorRequirements(pod)...)
+					if err != nil {
+						return err
+					}
+					if !matches {
+						return fmt.Errorf("pod %s scheduled to node with labels %v that don't satisfy its requirements %v", pod.Name, node.Labels, property.NodeSelectorRequirements(pod))
+					}
+				}
+				return nil
+			})
+		})
+	})
 	Describe("Metrics", func() {
 		It("should surface the queueDepth metric while executing the scheduling loop", func() {
 			nodePool := test.NodePool()
@@ -28,6 +28,7 @@ import (
 	"sigs.k8s.io/karpenter/pkg/utils/functional"
 	"sigs.k8s.io/karpenter/pkg/utils/pretty"
 
+	"github.com/samber/lo"
 	"go.uber.org/multierr"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -379,6 +380,74 @@ func (t *Topology) buildNamespaceList(ctx context.Context, namespace string, nam
 	return selected, nil
 }
 
+// preferredTerm is a preferred pod affinity or anti-affinity term of a pod, along with the topology group that tracks the
+// pods that it selects
+type preferredTerm struct {
+	weight int32
+	group  *TopologyGroup
+}
+
+// preferredTerms resolves the pod's preferred pod affinity or anti-affinity terms to the topology groups that track
+// them, which are tracked from when the pod was first added to the topology. This resolves the namespaces of the terms
+// once, so that the nodes that the pod fits on can be scored without listing namespaces.
+func (t *Topology) preferredTerms(ctx context.Context, p *v1.Pod, topologyType TopologyType, terms []v1.WeightedPodAffinityTerm) []preferredTerm {
+	var preferred []preferredTerm
+	for _, term := range terms {
+		namespaces, err := t.buildNamespaceList(ctx, p.Namespace, term.PodAffinityTerm.Namespaces, term.PodAffinityTerm.NamespaceSelector)
+		if err != nil {
+			continue
+		}
+		tg, ok := t.topologies[NewTopologyGroup(topologyType, term.PodAffinityTerm.TopologyKey, p, namespaces, term.PodAffinityTerm.LabelSelector, math.MaxInt32, nil, nil).Hash()]
+		if !ok {
+			continue
+		}
+		preferred = append(preferred, preferredTerm{weight: term.Weight, group: tg})
+	}
+	return preferred
+}
+
+// preferredPodAffinityScore returns the total weight of the preferred pod affinity terms that are satisfied by the
+// domains that the requirements allow, which is the case when one of these domains already has a pod that the term
+// selects.
+func preferredPodAffinityScore(terms []preferredTerm, requirements scheduling.Requirements) int32 {
+	var score int32
+	for _, term := range terms {
+		if !requirements.Has(term.group.Key) {
+			continue
+		}
+		if lo.ContainsBy(requirements.Get(term.group.Key).Values(), func(domain string) bool { return term.group.domains[domain] > 0 }) {
+			score += term.weight
+		}
+	}
+	return score
+}
+
+// preferredPodAntiAffinityScore returns the negated total weight of the preferred pod anti-affinity terms that are
+// violated by the requirements, which is the case when every domain that the requirements allow already has a pod that
+// the term selects.
+func preferredPodAntiAffinityScore(terms []preferredTerm, requirements scheduling.Requirements) int32 {
+	var score int32
+	for _, term := range terms {
+		if !requirements.Has(term.group.Key) {
+			continue
+		}
+		if lo.EveryBy(requirements.Get(term.group.Key).Values(), func(domain string) bool { return term.group.domains[domain] > 0 }) {
+			score -= term.weight
+		}
+	}
+	return score
+}
+
+// HasTopologySpread returns true if the pod has a topology spread constraint that is tracked by the topology
+func (t *Topology) HasTopologySpread(p *v1.Pod) bool {
+	for _, tc := range t.topologies {
+		if tc.Type == TopologyTypeSpread && tc.IsOwnedBy(p.UID) {
+			return true
+		}
+	}
+	return false
+}
+
 // getMatchingTopologies returns a sorted list of topologies that either control the scheduling of pod p, or for which
 // the topology selects pod p and the scheduling of p affects the count per topology domain
 func (t *Topology) getMatchingTopologies(p *v1.Pod, requirements scheduling.Requirements, compatabilityOptions ...functional.Option[scheduling.CompatibilityOptions]) []*TopologyGroup {
@@ -30,6 +30,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
 	"sigs.k8s.io/karpenter/pkg/test"
 	. "sigs.k8s.io/karpenter/pkg/test/expectations"
@@ -93,12 +94,7 @@ var _ = Describe("Topology", func() {
 
 	Context("Zonal", func() {
 		It("should balance pods across zones (match labels)", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelTopologyZone,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.ZoneSpreadConstraint(labels, 1, v1.DoNotSchedule)}
 			ExpectApplied(ctx, env.Client, nodePool)
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
 				test.UnschedulablePods(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, TopologySpreadConstraints: topology}, 4)...,
@@ -129,12 +125,7 @@ var _ = Describe("Topology", func() {
 		It("should respect NodePool zonal constraints", func() {
 			nodePool.Spec.Template.Spec.Requirements = []v1beta1.NodeSelectorRequirementWithMinValues{
 				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1", "test-zone-2", "test-zone-3"}}}}
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelTopologyZone,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.ZoneSpreadConstraint(labels, 1, v1.DoNotSchedule)}
 			ExpectApplied(ctx, env.Client, nodePool)
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
 				test.UnschedulablePods(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, TopologySpreadConstraints: topology}, 4)...,
@@ -144,12 +135,7 @@ var _ = Describe("Topology", func() {
 		It("should respect NodePool zonal constraints (subset) with requirements", func() {
 			nodePool.Spec.Template.Spec.Requirements = []v1beta1.NodeSelectorRequirementWithMinValues{
 				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1", "test-zone-2"}}}}
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelTopologyZone,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.ZoneSpreadConstraint(labels, 1, v1.DoNotSchedule)}
 			ExpectApplied(ctx, env.Client, nodePool)
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
 				test.UnschedulablePods(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, TopologySpreadConstraints: topology}, 4)...,
@@ -159,12 +145,7 @@ var _ = Describe("Topology", func() {
 		})
 		It("should respect NodePool zonal constraints (subset) with labels", func() {
 			nodePool.Spec.Template.Labels = lo.Assign(nodePool.Spec.Template.Labels, map[string]string{v1.LabelTopologyZone: "test-zone-1"})
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelTopologyZone,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.ZoneSpreadConstraint(labels, 1, v1.DoNotSchedule)}
 			ExpectApplied(ctx, env.Client, nodePool)
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
 				test.UnschedulablePods(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, TopologySpreadConstraints: topology}, 4)...,
@@ -175,12 +156,7 @@ var _ = Describe("Topology", func() {
 		It("should respect NodePool zonal constraints (subset) with requirements and labels", func() {
 			nodePool.Spec.Template.Spec.Requirements = []v1beta1.NodeSelectorRequirementWithMinValues{{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1", "test-zone-2"}}}}
 			nodePool.Spec.Template.Labels = lo.Assign(nodePool.Spec.Template.Labels, map[string]string{v1.LabelTopologyZone: "test-zone-1"})
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelTopologyZone,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.ZoneSpreadConstraint(labels, 1, v1.DoNotSchedule)}
 			ExpectApplied(ctx, env.Client, nodePool)
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
 				test.UnschedulablePods(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, TopologySpreadConstraints: topology}, 4)...,
@@ -203,12 +179,7 @@ var _ = Describe("Topology", func() {
 					},
 				},
 			})
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelTopologyZone,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.ZoneSpreadConstraint(labels, 1, v1.DoNotSchedule)}
 			ExpectApplied(ctx, env.Client, nodePool, nodePool2)
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
 				test.UnschedulablePods(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, TopologySpreadConstraints: topology}, 4)...,
@@ -236,12 +207,7 @@ var _ = Describe("Topology", func() {
 
 			nodePool.Spec.Template.Spec.Requirements = []v1beta1.NodeSelectorRequirementWithMinValues{
 				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1", "test-zone-2"}}}}
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelTopologyZone,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.ZoneSpreadConstraint(labels, 1, v1.DoNotSchedule)}
 			ExpectApplied(ctx, env.Client, nodePool)
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
 				test.UnschedulablePods(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, ResourceRequirements: rr, TopologySpreadConstraints: topology}, 6)...,
@@ -251,12 +217,7 @@ var _ = Describe("Topology", func() {
 			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(1, 2, 2))
 		})
 		It("should schedule to the non-minimum domain if its all that's available", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelTopologyZone,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           5,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.ZoneSpreadConstraint(labels, 5, v1.DoNotSchedule)}
 			rr := v1.ResourceRequirements{
 				Requests: map[v1.ResourceName]resource.Quantity{
 					v1.ResourceCPU: resource.MustParse("1.1"),
@@ -293,12 +254,7 @@ var _ = Describe("Topology", func() {
 			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(1, 1, 6))
 		})
 		It("should only schedule to minimum domains if already violating max skew", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelTopologyZone,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.ZoneSpreadConstraint(labels, 1, v1.DoNotSchedule)}
 			rr := v1.ResourceRequirements{
 				Requests: map[v1.ResourceName]resource.Quantity{
 					v1.ResourceCPU: resource.MustParse("1.1"),
@@ -332,12 +288,7 @@ var _ = Describe("Topology", func() {
 			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(3, 1, 2))
 		})
 		It("should not violate max-skew when unsat = do not schedule", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelTopologyZone,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.ZoneSpreadConstraint(labels, 1, v1.DoNotSchedule)}
 			rr := v1.ResourceRequirements{
 				Requests: map[v1.ResourceName]resource.Quantity{
 					v1.ResourceCPU: resource.MustParse("1.1"),
@@ -365,12 +316,7 @@ var _ = Describe("Topology", func() {
 			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(1, 2, 2))
 		})
 		It("should not violate max-skew when unsat = do not schedule (discover domains)", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelTopologyZone,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.ZoneSpreadConstraint(labels, 1, v1.DoNotSchedule)}
 			rr := v1.ResourceRequirements{
 				Requests: map[v1.ResourceName]resource.Quantity{
 					v1.ResourceCPU: resource.MustParse("1.1"),
@@ -396,17 +342,140 @@ var _ = Describe("Topology", func() {
 			// test-zone-1 has 1 pods in it.
 			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(1, 2, 2))
 		})
+		It("should launch the fourth replica into a new domain when existing domains would violate max skew", func() {
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+				fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: "zonal-instance-type",
+					Offerings: []cloudprovider.Offering{
+						{CapacityType: v1beta1.CapacityTypeOnDemand, Zone: "test-zone-1", Price: 1, Available: true},
+						{CapacityType: v1beta1.CapacityTypeOnDemand, Zone: "test-zone-2", Price: 1, Available: true},
+						{CapacityType: v1beta1.CapacityTypeOnDemand, Zone: "test-zone-3", Price: 1, Available: true},
+						{CapacityType: v1beta1.CapacityTypeOnDemand, Zone: "test-zone-4", Price: 1, Available: true},
+					},
+				}),
+			}
+			topology := []v1.TopologySpreadConstraint{test.ZoneSpreadConstraint(labels, 1, v1.DoNotSchedule)}
+			rr := v1.ResourceRequirements{
+				Requests: map[v1.ResourceName]resource.Quantity{
+					v1.ResourceCPU: resource.MustParse("3"),
+				},
+			}
+			// spread the first three replicas across the first three zones
+			nodePool.Spec.Template.Spec.Requirements = []v1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1", "test-zone-2", "test-zone-3"}}}}
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
+				test.UnschedulablePods(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					ResourceRequirements: rr, TopologySpreadConstraints: topology}, 3)...,
+			)
+			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(1, 1, 1))
+
+			// allow the fourth zone, which is the only domain that keeps the spread satisfied
+			nodePool.Spec.Template.Spec.Requirements = nil
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				ResourceRequirements: rr, TopologySpreadConstraints: topology})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1.LabelTopologyZone, "test-zone-4"))
+			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(1, 1, 1, 1))
+		})
+		It("should leave the fourth replica pending when the only domain that keeps the spread satisfied has no capacity", func() {
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+				fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: "zonal-instance-type",
+					Offerings: []cloudprovider.Offering{
+						{CapacityType: v1beta1.CapacityTypeOnDemand, Zone: "test-zone-1", Price: 1, Available: true},
+						{CapacityType: v1beta1.CapacityTypeOnDemand, Zone: "test-zone-2", Price: 1, Available: true},
+						{CapacityType: v1beta1.CapacityTypeOnDemand, Zone: "test-zone-3", Price: 1, Available: true},
+					},
+				}),
+				// the only instance type offered in the fourth zone is too small for the replicas
+				fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: "small-zonal-instance-type",
+					Resources: map[v1.ResourceName]resource.Quantity{
+						v1.ResourceCPU: resource.MustParse("1"),
+					},
+					Offerings: []cloudprovider.Offering{
+						{CapacityType: v1beta1.CapacityTypeOnDemand, Zone: "test-zone-4", Price: 1, Available: true},
+					},
+				}),
+			}
+			topology := []v1.TopologySpreadConstraint{test.ZoneSpreadConstraint(labels, 1, v1.DoNotSchedule)}
+			rr := v1.ResourceRequirements{
+				Requests: map[v1.ResourceName]resource.Quantity{
+					v1.ResourceCPU: resource.MustParse("3"),
+				},
+			}
+			nodePool.Spec.Template.Spec.Requirements = []v1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1", "test-zone-2", "test-zone-3"}}}}
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
+				test.UnschedulablePods(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					ResourceRequirements: rr, TopologySpreadConstraints: topology}, 3)...,
+			)
+			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(1, 1, 1))
+
+			// the fourth zone is now a known domain with no replicas, so launching into any other zone would violate max skew
+			nodePool.Spec.Template.Spec.Requirements = nil
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				ResourceRequirements: rr, TopologySpreadConstraints: topology})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(1, 1, 1))
+		})
+		It("should only choose from domains with available offerings for the pod", func() {
+			cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+				fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: "zonal-instance-type",
+					Offerings: []cloudprovider.Offering{
+						{CapacityType: v1beta1.CapacityTypeOnDemand, Zone: "test-zone-1", Price: 1, Available: true},
+						{CapacityType: v1beta1.CapacityTypeOnDemand, Zone: "test-zone-3", Price: 1, Available: true},
+					},
+				}),
+				// test-zone-2 is a valid domain, but only offers an instance type that is too small for the replicas
+				fake.NewInstanceType(fake.InstanceTypeOptions{
+					Name: "small-zonal-instance-type",
+					Resources: map[v1.ResourceName]resource.Quantity{
+						v1.ResourceCPU: resource.MustParse("1"),
+					},
+					Offerings: []cloudprovider.Offering{
+						{CapacityType: v1beta1.CapacityTypeOnDemand, Zone: "test-zone-2", Price: 1, Available: true},
+					},
+				}),
+			}
+			topology := []v1.TopologySpreadConstraint{test.ZoneSpreadConstraint(labels, 1, v1.DoNotSchedule)}
+			rr := v1.ResourceRequirements{
+				Requests: map[v1.ResourceName]resource.Quantity{
+					v1.ResourceCPU: resource.MustParse("3"),
+				},
+			}
+			// force this pod onto zone-1
+			nodePool.Spec.Template.Spec.Requirements = []v1beta1.NodeSelectorRequirementWithMinValues{
+				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1"}}}}
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
+				test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					ResourceRequirements: rr, TopologySpreadConstraints: topology}))
+			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(1))
+
+			// test-zone-2 and test-zone-3 are both minimum domains, but only test-zone-3 can run the pod
+			nodePool.Spec.Template.Spec.Requirements = nil
+			ExpectApplied(ctx, env.Client, nodePool)
+			pod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				ResourceRequirements: rr, TopologySpreadConstraints: topology})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels).To(HaveKeyWithValue(v1.LabelTopologyZone, "test-zone-3"))
+			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(1, 1))
+		})
 		It("should only count running/scheduled pods with matching labels scheduled to nodes with a corresponding domain", func() {
 			wrongNamespace := test.RandomName()
 			firstNode := test.Node(test.NodeOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.LabelTopologyZone: "test-zone-1"}}})
 			secondNode := test.Node(test.NodeOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1.LabelTopologyZone: "test-zone-2"}}})
 			thirdNode := test.Node(test.NodeOptions{}) // missing topology domain
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelTopologyZone,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.ZoneSpreadConstraint(labels, 1, v1.DoNotSchedule)}
 			ExpectApplied(ctx, env.Client, nodePool, firstNode, secondNode, thirdNode, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: wrongNamespace}})
 			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(firstNode))
 			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(secondNode))
@@ -442,12 +511,7 @@ var _ = Describe("Topology", func() {
 			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(1))
 		})
 		It("should handle interdependent selectors", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelHostname,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.HostnameSpreadConstraint(labels, 1, v1.DoNotSchedule)}
 			ExpectApplied(ctx, env.Client, nodePool)
 			pods := test.UnschedulablePods(test.PodOptions{TopologySpreadConstraints: topology}, 5)
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
@@ -530,12 +594,7 @@ var _ = Describe("Topology", func() {
 
 	Context("Hostname", func() {
 		It("should balance pods across nodes", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelHostname,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.HostnameSpreadConstraint(labels, 1, v1.DoNotSchedule)}
 			ExpectApplied(ctx, env.Client, nodePool)
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
 				test.UnschedulablePods(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, TopologySpreadConstraints: topology}, 4)...,
@@ -543,12 +602,7 @@ var _ = Describe("Topology", func() {
 			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(1, 1, 1, 1))
 		})
 		It("should balance pods on the same hostname up to maxskew", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelHostname,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           4,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.HostnameSpreadConstraint(labels, 4, v1.DoNotSchedule)}
 			ExpectApplied(ctx, env.Client, nodePool)
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
 				test.UnschedulablePods(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, TopologySpreadConstraints: topology}, 4)...,
@@ -638,12 +692,7 @@ var _ = Describe("Topology", func() {
 
 	Context("CapacityType", func() {
 		It("should balance pods across capacity types", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1beta1.CapacityTypeLabelKey,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.TopologySpreadConstraint(v1beta1.CapacityTypeLabelKey, labels, 1, v1.DoNotSchedule)}
 			ExpectApplied(ctx, env.Client, nodePool)
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
 				test.UnschedulablePods(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, TopologySpreadConstraints: topology}, 4)...,
@@ -653,12 +702,7 @@ var _ = Describe("Topology", func() {
 		It("should respect NodePool capacity type constraints", func() {
 			nodePool.Spec.Template.Spec.Requirements = []v1beta1.NodeSelectorRequirementWithMinValues{
 				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1beta1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{v1beta1.CapacityTypeSpot, v1beta1.CapacityTypeOnDemand}}}}
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1beta1.CapacityTypeLabelKey,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.TopologySpreadConstraint(v1beta1.CapacityTypeLabelKey, labels, 1, v1.DoNotSchedule)}
 			ExpectApplied(ctx, env.Client, nodePool)
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
 				test.UnschedulablePods(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, TopologySpreadConstraints: topology}, 4)...,
@@ -668,12 +712,7 @@ var _ = Describe("Topology", func() {
 		It("should not violate max-skew when unsat = do not schedule (capacity type)", func() {
 			// this test can pass in a flaky manner if we don't restrict our min domain selection to valid choices
 			// per the nodePool spec
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1beta1.CapacityTypeLabelKey,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.TopologySpreadConstraint(v1beta1.CapacityTypeLabelKey, labels, 1, v1.DoNotSchedule)}
 			rr := v1.ResourceRequirements{
 				Requests: map[v1.ResourceName]resource.Quantity{
 					v1.ResourceCPU: resource.MustParse("1.1"),
@@ -701,12 +740,7 @@ var _ = Describe("Topology", func() {
 			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(1, 2))
 		})
 		It("should violate max-skew when unsat = schedule anyway (capacity type)", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1beta1.CapacityTypeLabelKey,
-				WhenUnsatisfiable: v1.ScheduleAnyway,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.TopologySpreadConstraint(v1beta1.CapacityTypeLabelKey, labels, 1, v1.ScheduleAnyway)}
 			rr := v1.ResourceRequirements{
 				Requests: map[v1.ResourceName]resource.Quantity{
 					v1.ResourceCPU: resource.MustParse("1.1"),
@@ -736,12 +770,7 @@ var _ = Describe("Topology", func() {
 			firstNode := test.Node(test.NodeOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1beta1.CapacityTypeLabelKey: v1beta1.CapacityTypeSpot}}})
 			secondNode := test.Node(test.NodeOptions{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{v1beta1.CapacityTypeLabelKey: v1beta1.CapacityTypeOnDemand}}})
 			thirdNode := test.Node(test.NodeOptions{}) // missing topology capacity type
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1beta1.CapacityTypeLabelKey,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.TopologySpreadConstraint(v1beta1.CapacityTypeLabelKey, labels, 1, v1.DoNotSchedule)}
 			ExpectApplied(ctx, env.Client, nodePool, firstNode, secondNode, thirdNode, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: wrongNamespace}})
 			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(firstNode))
 			ExpectReconcileSucceeded(ctx, nodeStateController, client.ObjectKeyFromObject(secondNode))
@@ -777,12 +806,7 @@ var _ = Describe("Topology", func() {
 			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(1))
 		})
 		It("should handle interdependent selectors", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelHostname,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.HostnameSpreadConstraint(labels, 1, v1.DoNotSchedule)}
 			ExpectApplied(ctx, env.Client, nodePool)
 			pods := test.UnschedulablePods(test.PodOptions{TopologySpreadConstraints: topology}, 5)
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
@@ -812,12 +836,7 @@ var _ = Describe("Topology", func() {
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
 			ExpectScheduled(ctx, env.Client, pods[0])
 
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1beta1.CapacityTypeLabelKey,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.TopologySpreadConstraint(v1beta1.CapacityTypeLabelKey, labels, 1, v1.DoNotSchedule)}
 
 			// Try to run 5 pods, with a node selector restricted to test-zone-2, they should all schedule on the same
 			// spot node. This doesn't violate the max-skew of 1 as the node selector requirement here excludes the
@@ -855,12 +874,7 @@ var _ = Describe("Topology", func() {
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
 			ExpectScheduled(ctx, env.Client, pod)
 
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1beta1.CapacityTypeLabelKey,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.TopologySpreadConstraint(v1beta1.CapacityTypeLabelKey, labels, 1, v1.DoNotSchedule)}
 
 			// limit our nodePool to only creating spot nodes
 			nodePool.Spec.Template.Spec.Requirements = []v1beta1.NodeSelectorRequirementWithMinValues{
@@ -899,12 +913,7 @@ var _ = Describe("Topology", func() {
 
 			ExpectScheduled(ctx, env.Client, pod)
 
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelArchStable,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.TopologySpreadConstraint(v1.LabelArchStable, labels, 1, v1.DoNotSchedule)}
 
 			// limit our nodePool to only creating arm64 nodes
 			nodePool.Spec.Template.Spec.Requirements = []v1beta1.NodeSelectorRequirementWithMinValues{
@@ -926,17 +935,10 @@ var _ = Describe("Topology", func() {
 
 	Context("Combined Hostname and Zonal Topology", func() {
 		It("should spread pods while respecting both constraints (hostname and zonal)", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelTopologyZone,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}, {
-				TopologyKey:       v1.LabelHostname,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           3,
-			}}
+			topology := []v1.TopologySpreadConstraint{
+				test.ZoneSpreadConstraint(labels, 1, v1.DoNotSchedule),
+				test.HostnameSpreadConstraint(labels, 3, v1.DoNotSchedule),
+			}
 			ExpectApplied(ctx, env.Client, nodePool)
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
 				test.UnschedulablePods(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, TopologySpreadConstraints: topology}, 2)...,
@@ -1033,26 +1035,14 @@ var _ = Describe("Topology", func() {
 
 			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(4, 4, 4, 4, 4))
 			// due to the spread across nodePools, we've forced a 4:1 spot to on-demand spread
-			ExpectSkew(ctx, env.Client, "default", &v1.TopologySpreadConstraint{
-				TopologyKey:       v1beta1.CapacityTypeLabelKey,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}).To(ConsistOf(4, 16))
+			ExpectSkew(ctx, env.Client, "default", lo.ToPtr(test.TopologySpreadConstraint(v1beta1.CapacityTypeLabelKey, labels, 1, v1.DoNotSchedule))).To(ConsistOf(4, 16))
 		})
 
 		It("should spread pods while respecting both constraints", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelTopologyZone,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}, {
-				TopologyKey:       v1.LabelHostname,
-				WhenUnsatisfiable: v1.ScheduleAnyway,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{
+				test.ZoneSpreadConstraint(labels, 1, v1.DoNotSchedule),
+				test.HostnameSpreadConstraint(labels, 1, v1.ScheduleAnyway),
+			}
 			nodePool.Spec.Template.Spec.Requirements = []v1beta1.NodeSelectorRequirementWithMinValues{
 				{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelTopologyZone, Operator: v1.NodeSelectorOpIn, Values: []string{"test-zone-1", "test-zone-2"}}}}
 
@@ -1085,17 +1075,10 @@ var _ = Describe("Topology", func() {
 		})
 
 		It("should spread pods while respecting both constraints", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1beta1.CapacityTypeLabelKey,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}, {
-				TopologyKey:       v1.LabelHostname,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           3,
-			}}
+			topology := []v1.TopologySpreadConstraint{
+				test.TopologySpreadConstraint(v1beta1.CapacityTypeLabelKey, labels, 1, v1.DoNotSchedule),
+				test.HostnameSpreadConstraint(labels, 3, v1.DoNotSchedule),
+			}
 			ExpectApplied(ctx, env.Client, nodePool)
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
 				test.UnschedulablePods(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, TopologySpreadConstraints: topology}, 2)...,
@@ -1128,17 +1111,10 @@ var _ = Describe("Topology", func() {
 
 	Context("Combined Zonal and Capacity Type Topology", func() {
 		It("should spread pods while respecting both constraints", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1beta1.CapacityTypeLabelKey,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}, {
-				TopologyKey:       v1.LabelTopologyZone,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{
+				test.TopologySpreadConstraint(v1beta1.CapacityTypeLabelKey, labels, 1, v1.DoNotSchedule),
+				test.ZoneSpreadConstraint(labels, 1, v1.DoNotSchedule),
+			}
 			ExpectApplied(ctx, env.Client, nodePool)
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
 				test.UnschedulablePods(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Labels: labels}, TopologySpreadConstraints: topology}, 2)...,
@@ -1170,22 +1146,11 @@ var _ = Describe("Topology", func() {
 		It("should spread pods while respecting all constraints", func() {
 			// ensure we've got an instance type for every zone/capacity-type pair
 			cloudProvider.InstanceTypes = fake.InstanceTypesAssorted()
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1beta1.CapacityTypeLabelKey,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}, {
-				TopologyKey:       v1.LabelTopologyZone,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           2,
-			}, {
-				TopologyKey:       v1.LabelHostname,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           3,
-			}}
+			topology := []v1.TopologySpreadConstraint{
+				test.TopologySpreadConstraint(v1beta1.CapacityTypeLabelKey, labels, 1, v1.DoNotSchedule),
+				test.ZoneSpreadConstraint(labels, 2, v1.DoNotSchedule),
+				test.HostnameSpreadConstraint(labels, 3, v1.DoNotSchedule),
+			}
 
 			// add varying numbers of pods, checking after each scheduling to ensure that our max required max skew
 			// has not been violated for each constraint
@@ -1206,12 +1171,7 @@ var _ = Describe("Topology", func() {
 	// https://kubernetes.io/docs/concepts/workloads/pods/pod-topology-spread-constraints/#interaction-with-node-affinity-and-node-selectors
 	Context("Combined Zonal Topology and Node Affinity", func() {
 		It("should limit spread options by nodeSelector", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelTopologyZone,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.ZoneSpreadConstraint(labels, 1, v1.DoNotSchedule)}
 			ExpectApplied(ctx, env.Client, nodePool)
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
 				append(
@@ -1232,12 +1192,7 @@ var _ = Describe("Topology", func() {
 			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(5, 10))
 		})
 		It("should limit spread options by node requirements", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelTopologyZone,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.ZoneSpreadConstraint(labels, 1, v1.DoNotSchedule)}
 			ExpectApplied(ctx, env.Client, nodePool)
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
 				test.UnschedulablePods(test.PodOptions{
@@ -1254,12 +1209,7 @@ var _ = Describe("Topology", func() {
 			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(5, 5))
 		})
 		It("should limit spread options by required node affinity", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelTopologyZone,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.ZoneSpreadConstraint(labels, 1, v1.DoNotSchedule)}
 
 			ExpectApplied(ctx, env.Client, nodePool)
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
@@ -1298,12 +1248,7 @@ var _ = Describe("Topology", func() {
 			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(4, 4, 4))
 		})
 		It("should not limit spread options by preferred node affinity", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelTopologyZone,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.ZoneSpreadConstraint(labels, 1, v1.DoNotSchedule)}
 
 			ExpectApplied(ctx, env.Client, nodePool)
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
@@ -1323,12 +1268,7 @@ var _ = Describe("Topology", func() {
 	// https://kubernetes.io/docs/concepts/workloads/pods/pod-topology-spread-constraints/#interaction-with-node-affinity-and-node-selectors
 	Context("Combined Capacity Type Topology and Node Affinity", func() {
 		It("should limit spread options by nodeSelector", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1beta1.CapacityTypeLabelKey,
-				WhenUnsatisfiable: v1.ScheduleAnyway,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.TopologySpreadConstraint(v1beta1.CapacityTypeLabelKey, labels, 1, v1.ScheduleAnyway)}
 			ExpectApplied(ctx, env.Client, nodePool)
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov,
 				append(
@@ -1347,12 +1287,7 @@ var _ = Describe("Topology", func() {
 			ExpectSkew(ctx, env.Client, "default", &topology[0]).To(ConsistOf(5, 5))
 		})
 		It("should limit spread options by node affinity (capacity type)", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1beta1.CapacityTypeLabelKey,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.TopologySpreadConstraint(v1beta1.CapacityTypeLabelKey, labels, 1, v1.DoNotSchedule)}
 
 			// need to limit the rules to spot or else it will know that on-demand has 0 pods and won't violate the max-skew
 			ExpectApplied(ctx, env.Client, nodePool)
@@ -1402,12 +1337,7 @@ var _ = Describe("Topology", func() {
 			ExpectScheduled(ctx, env.Client, pod)
 		})
 		It("should respect pod affinity (hostname)", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelHostname,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.HostnameSpreadConstraint(labels, 1, v1.DoNotSchedule)}
 
 			affLabels := map[string]string{"security": "s2"}
 
@@ -1435,14 +1365,31 @@ var _ = Describe("Topology", func() {
 			// should be scheduled on the same node
 			Expect(n1.Name).To(Equal(n2.Name))
 		})
+		It("should co-locate a pod with the pending pod that it requires affinity to on a single new node", func() {
+			affLabels := map[string]string{"security": "s2"}
+			target := test.UnschedulablePod(test.PodOptions{
+				ObjectMeta:           metav1.ObjectMeta{Labels: affLabels},
+				ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}},
+			})
+			// the affine pod requests more resources, so it's considered before its target and is only co-located once
+			// the target has been placed on a new node
+			affPod := test.UnschedulablePod(test.PodOptions{
+				ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")}},
+				PodRequirements: []v1.PodAffinityTerm{{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: affLabels},
+					TopologyKey:   v1.LabelHostname,
+				}},
+			})
+			ExpectApplied(ctx, env.Client, nodePool)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, affPod, target)
+			n1 := ExpectScheduled(ctx, env.Client, affPod)
+			n2 := ExpectScheduled(ctx, env.Client, target)
+			Expect(n1.Name).To(Equal(n2.Name))
+			Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+		})
 		It("should respect pod affinity (arch)", func() {
 			affLabels := map[string]string{"security": "s2"}
-			tsc := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelHostname,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: affLabels},
-				MaxSkew:           1,
-			}}
+			tsc := []v1.TopologySpreadConstraint{test.HostnameSpreadConstraint(affLabels, 1, v1.DoNotSchedule)}
 
 			affPod1 := test.UnschedulablePod(test.PodOptions{
 				TopologySpreadConstraints: tsc,
@@ -1644,12 +1591,7 @@ var _ = Describe("Topology", func() {
 			Expect(len(nodeNames)).To(Equal(1))
 		})
 		It("should allow violation of preferred pod affinity", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelHostname,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.HostnameSpreadConstraint(labels, 1, v1.DoNotSchedule)}
 
 			affPod2 := test.UnschedulablePod(test.PodOptions{PodPreferences: []v1.WeightedPodAffinityTerm{{
 				Weight: 50,
@@ -1691,13 +1633,8 @@ var _ = Describe("Topology", func() {
 
 			var pods []*v1.Pod
 			pods = append(pods, test.UnschedulablePods(test.PodOptions{
-				ObjectMeta: metav1.ObjectMeta{Labels: labels},
-				TopologySpreadConstraints: []v1.TopologySpreadConstraint{{
-					TopologyKey:       v1.LabelTopologyZone,
-					WhenUnsatisfiable: v1.DoNotSchedule,
-					LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-					MaxSkew:           1,
-				}},
+				ObjectMeta:                metav1.ObjectMeta{Labels: labels},
+				TopologySpreadConstraints: []v1.TopologySpreadConstraint{test.ZoneSpreadConstraint(labels, 1, v1.DoNotSchedule)},
 			}, 3)...)
 
 			pods = append(pods, affPods...)
@@ -1794,12 +1731,7 @@ var _ = Describe("Topology", func() {
 		})
 		It("should not violate pod anti-affinity (arch)", func() {
 			affLabels := map[string]string{"security": "s2"}
-			tsc := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelHostname,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: affLabels},
-				MaxSkew:           1,
-			}}
+			tsc := []v1.TopologySpreadConstraint{test.HostnameSpreadConstraint(affLabels, 1, v1.DoNotSchedule)}
 
 			affPod1 := test.UnschedulablePod(test.PodOptions{
 				TopologySpreadConstraints: tsc,
@@ -2254,12 +2186,7 @@ var _ = Describe("Topology", func() {
 			ExpectNotScheduled(ctx, env.Client, pod)
 		})
 		It("should filter pod affinity topologies by namespace, no matching pods", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelHostname,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.HostnameSpreadConstraint(labels, 1, v1.DoNotSchedule)}
 
 			ExpectApplied(ctx, env.Client, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other-ns-no-match"}})
 			affLabels := map[string]string{"security": "s2"}
@@ -2292,12 +2219,7 @@ var _ = Describe("Topology", func() {
 			ExpectNotScheduled(ctx, env.Client, affPod2)
 		})
 		It("should filter pod affinity topologies by namespace, matching pods namespace list", func() {
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelHostname,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.HostnameSpreadConstraint(labels, 1, v1.DoNotSchedule)}
 
 			ExpectApplied(ctx, env.Client, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other-ns-list"}})
 			affLabels := map[string]string{"security": "s2"}
@@ -2334,12 +2256,7 @@ var _ = Describe("Topology", func() {
 			if env.Version.Minor() < 21 {
 				Skip("namespace selector is only supported on K8s >= 1.21.x")
 			}
-			topology := []v1.TopologySpreadConstraint{{
-				TopologyKey:       v1.LabelHostname,
-				WhenUnsatisfiable: v1.DoNotSchedule,
-				LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
-				MaxSkew:           1,
-			}}
+			topology := []v1.TopologySpreadConstraint{test.HostnameSpreadConstraint(labels, 1, v1.DoNotSchedule)}
 
 			ExpectApplied(ctx, env.Client, &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "empty-ns-selector", Labels: map[string]string{"foo": "bar"}}})
 			affLabels := map[string]string{"security": "s2"}
@@ -0,0 +1,30 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioning
+
+import (
+	scheduler "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+)
+
+// WithSchedulingFilter causes the schedulers that the provisioner creates, both for provisioning and for the
+// disruption simulations, to consult the SchedulingFilter before placing each pod on a node
+func WithSchedulingFilter(schedulingFilter scheduler.SchedulingFilter) func(ProvisionerOptions) ProvisionerOptions {
+	return func(o ProvisionerOptions) ProvisionerOptions {
+		o.SchedulingFilter = schedulingFilter
+		return o
+	}
+}
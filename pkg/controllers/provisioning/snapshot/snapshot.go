@@ -0,0 +1,222 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"knative.dev/pkg/logging"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+)
+
+// Path is the path on the metrics server that the cluster state snapshot is served from
+const Path = "/debug/cluster-state"
+
+// MaxSize is the maximum size in bytes of a served snapshot. Once it's reached, the remaining items are left out of
+// the snapshot and it's marked as truncated.
+const MaxSize = 32 << 20
+
+// Snapshots holds the cluster state that the provisioner last scheduled against. Like the metrics registry, it's
+// shared across the operator so that it can be served from the metrics server, which must be configured before the
+// controllers are constructed.
+var Snapshots = New(MaxSize)
+
+// Snapshot is the cluster state that a scheduling decision was made against. It contains everything that's needed
+// to reproduce the decision offline.
+type Snapshot struct {
+	Time      time.Time          `json:"time"`
+	NodePools []v1beta1.NodePool `json:"nodePools"`
+	// InstanceTypes are the instance types that were considered for each NodePool
+	InstanceTypes map[string][]InstanceType `json:"instanceTypes"`
+	Nodes         []Node                    `json:"nodes"`
+	// Pods are the pods that were being scheduled
+	Pods []*v1.Pod `json:"pods"`
+	// Truncated is true if items were left out of the snapshot to bound its size
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Node is a node that's tracked in cluster state along with the pods that are bound to it
+type Node struct {
+	Node              *v1.Node           `json:"node,omitempty"`
+	NodeClaim         *v1beta1.NodeClaim `json:"nodeClaim,omitempty"`
+	Pods              []*v1.Pod          `json:"pods,omitempty"`
+	MarkedForDeletion bool               `json:"markedForDeletion,omitempty"`
+	Nominated         bool               `json:"nominated,omitempty"`
+}
+
+// InstanceType is the serializable form of a cloudprovider.InstanceType
+type InstanceType struct {
+	Name         string                                         `json:"name"`
+	Requirements []v1beta1.NodeSelectorRequirementWithMinValues `json:"requirements"`
+	Offerings    cloudprovider.Offerings                        `json:"offerings"`
+	Capacity     v1.ResourceList                                `json:"capacity"`
+	Overhead     *cloudprovider.InstanceTypeOverhead            `json:"overhead,omitempty"`
+}
+
+// NewNodes converts the state nodes and the pods that are bound to them. Nodes whose pods can't be listed are logged
+// and skipped.
+func NewNodes(ctx context.Context, kubeClient client.Client, nodes state.StateNodes) []Node {
+	var converted []Node
+	for _, n := range nodes {
+		node, err := NewNode(ctx, kubeClient, n)
+		if err != nil {
+			logging.FromContext(ctx).With("node", n.Name()).Errorf("snapshotting cluster state, listing pods, %s", err)
+			continue
+		}
+		converted = append(converted, node)
+	}
+	return converted
+}
+
+// NewNode converts a state node and the pods that are bound to it
+func NewNode(ctx context.Context, kubeClient client.Client, n *state.StateNode) (Node, error) {
+	pods, err := n.Pods(ctx, kubeClient)
+	if err != nil {
+		return Node{}, err
+	}
+	return Node{
+		Node:              n.Node,
+		NodeClaim:         n.NodeClaim,
+		Pods:              pods,
+		MarkedForDeletion: n.MarkedForDeletion(),
+		Nominated:         n.Nominated(),
+	}, nil
+}
+
+// NewInstanceType converts an instance type
+func NewInstanceType(it *cloudprovider.InstanceType) InstanceType {
+	return InstanceType{
+		Name:         it.Name,
+		Requirements: it.Requirements.NodeSelectorRequirements(),
+		Offerings:    it.Offerings,
+		Capacity:     it.Capacity,
+		Overhead:     it.Overhead,
+	}
+}
+
+// Recorder keeps the most recent snapshot. Bounding a snapshot encodes all of it, so it's only bounded once it's read
+// rather than on every scheduling loop.
+type Recorder struct {
+	mu       sync.Mutex
+	snapshot *Snapshot
+	bounded  *Snapshot
+	maxSize  int
+}
+
+func New(maxSize int) *Recorder {
+	return &Recorder{maxSize: maxSize}
+}
+
+// Enabled returns true if the cluster state should be snapshotted
+func Enabled(ctx context.Context) bool {
+	return options.FromContext(ctx).EnableClusterStateSnapshot
+}
+
+// Record replaces the most recent snapshot. The snapshot must not be modified once it's recorded.
+func (r *Recorder) Record(ctx context.Context, snapshot *Snapshot) {
+	if !Enabled(ctx) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.snapshot, r.bounded = lo.ToPtr(*snapshot), nil
+}
+
+// Get returns the most recent snapshot bounded to the maximum size, or nil if nothing has been recorded
+func (r *Recorder) Get() *Snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.snapshot != nil && r.bounded == nil {
+		r.bounded = r.snapshot.bound(r.maxSize)
+	}
+	return r.bounded
+}
+
+// Reset clears the most recent snapshot
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.snapshot, r.bounded = nil, nil
+}
+
+// ServeHTTP serves the most recent snapshot as JSON
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	snapshot := r.Get()
+	if snapshot == nil {
+		http.Error(w, "no cluster state snapshot has been recorded yet", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(snapshot); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// bound returns a copy of the snapshot that encodes to at most maxSize bytes. The NodePools, the pods being scheduled
+// and the nodes are kept in that order of priority over the instance types, which are the bulk of the snapshot.
+func (s *Snapshot) bound(maxSize int) *Snapshot {
+	bounded := &Snapshot{Time: s.Time, InstanceTypes: map[string][]InstanceType{}, Truncated: s.Truncated}
+	remaining := maxSize - size(bounded)
+	nodePools, nodePoolsTruncated := fit(s.NodePools, &remaining)
+	pods, podsTruncated := fit(s.Pods, &remaining)
+	nodes, nodesTruncated := fit(s.Nodes, &remaining)
+	bounded.NodePools, bounded.Pods, bounded.Nodes = nodePools, pods, nodes
+	bounded.Truncated = bounded.Truncated || nodePoolsTruncated || podsTruncated || nodesTruncated
+	for _, nodePool := range lo.Keys(s.InstanceTypes) {
+		// the key, the brackets of the list and the separating comma
+		remaining -= size(nodePool) + 4
+		instanceTypes, truncated := fit(s.InstanceTypes[nodePool], &remaining)
+		if len(instanceTypes) > 0 {
+			bounded.InstanceTypes[nodePool] = instanceTypes
+		}
+		bounded.Truncated = bounded.Truncated || truncated
+	}
+	return bounded
+}
+
+// fit returns the leading items that fit in the remaining number of bytes and whether any items were left out
+func fit[T any](items []T, remaining *int) ([]T, bool) {
+	for i, item := range items {
+		// items are separated by commas
+		if n := size(item) + 1; n <= *remaining {
+			*remaining -= n
+			continue
+		}
+		*remaining = 0
+		return items[:i], true
+	}
+	return items, false
+}
+
+func size(v any) int {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
@@ -0,0 +1,164 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package snapshot_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	. "knative.dev/pkg/logging/testing"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/snapshot"
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/test"
+)
+
+var ctx context.Context
+
+func TestSnapshot(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Provisioning/Snapshot")
+}
+
+var _ = Describe("Snapshot", func() {
+	var r *snapshot.Recorder
+	var s *snapshot.Snapshot
+
+	BeforeEach(func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{EnableClusterStateSnapshot: lo.ToPtr(true)}))
+		r = snapshot.New(snapshot.MaxSize)
+		nodeClaim, node := test.NodeClaimAndNode()
+		s = &snapshot.Snapshot{
+			Time:      time.Now(),
+			NodePools: []v1beta1.NodePool{*test.NodePool()},
+			InstanceTypes: map[string][]snapshot.InstanceType{
+				"default": lo.Map(fake.InstanceTypes(3), func(it *cloudprovider.InstanceType, _ int) snapshot.InstanceType {
+					return snapshot.NewInstanceType(it)
+				}),
+			},
+			Nodes: []snapshot.Node{{Node: node, NodeClaim: nodeClaim, Pods: []*v1.Pod{test.Pod()}}},
+			Pods:  test.Pods(2, test.PodOptions{}),
+		}
+	})
+
+	It("should record the snapshot", func() {
+		r.Record(ctx, s)
+		got := r.Get()
+		Expect(got).ToNot(BeNil())
+		Expect(got.Truncated).To(BeFalse())
+		Expect(got.NodePools).To(HaveLen(1))
+		Expect(got.Pods).To(HaveLen(2))
+		Expect(got.Nodes).To(HaveLen(1))
+		Expect(got.InstanceTypes["default"]).To(HaveLen(3))
+	})
+	It("should replace the previous snapshot", func() {
+		r.Record(ctx, s)
+		Expect(r.Get().Pods).To(HaveLen(2))
+		r.Record(ctx, &snapshot.Snapshot{Time: time.Now(), Pods: test.Pods(5, test.PodOptions{})})
+		Expect(r.Get().Pods).To(HaveLen(5))
+	})
+	It("should not record the snapshot when disabled", func() {
+		ctx = options.ToContext(ctx, test.Options())
+		r.Record(ctx, s)
+		Expect(r.Get()).To(BeNil())
+	})
+	It("should convert instance types with their requirements", func() {
+		it := fake.NewInstanceType(fake.InstanceTypeOptions{
+			Name:      "test-instance-type",
+			Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")},
+		})
+		converted := snapshot.NewInstanceType(it)
+		Expect(converted.Name).To(Equal("test-instance-type"))
+		Expect(converted.Capacity.Cpu().String()).To(Equal("4"))
+		Expect(converted.Offerings).To(Equal(it.Offerings))
+		Expect(converted.Requirements).To(ContainElement(v1beta1.NodeSelectorRequirementWithMinValues{
+			NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpIn, Values: []string{"test-instance-type"}},
+		}))
+	})
+	It("should bound the size of the snapshot", func() {
+		full, err := json.Marshal(s)
+		Expect(err).ToNot(HaveOccurred())
+		podsAndNodePools, err := json.Marshal(&snapshot.Snapshot{Time: s.Time, NodePools: s.NodePools, Pods: s.Pods})
+		Expect(err).ToNot(HaveOccurred())
+		// leave room for the NodePools and pods, but not for the nodes or the instance types
+		r = snapshot.New(len(podsAndNodePools) + 64)
+		r.Record(ctx, s)
+
+		got := r.Get()
+		Expect(len(full)).To(BeNumerically(">", len(podsAndNodePools)+64))
+		Expect(got.Truncated).To(BeTrue())
+		Expect(got.NodePools).To(HaveLen(1))
+		Expect(got.Pods).To(HaveLen(2))
+		Expect(got.Nodes).To(BeEmpty())
+		Expect(got.InstanceTypes).To(BeEmpty())
+		bounded, err := json.Marshal(got)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(len(bounded)).To(BeNumerically("<=", len(podsAndNodePools)+64))
+	})
+	It("should keep the items of the snapshot when it's recorded", func() {
+		r.Record(ctx, s)
+		s.Pods = append(s.Pods, test.Pods(3, test.PodOptions{})...)
+		Expect(r.Get().Pods).To(HaveLen(2))
+	})
+	It("should keep a snapshot that was already truncated marked as truncated", func() {
+		s.Truncated = true
+		r.Record(ctx, s)
+		Expect(r.Get().Truncated).To(BeTrue())
+		Expect(r.Get().Nodes).To(HaveLen(1))
+	})
+	It("should serve the snapshot as JSON", func() {
+		r.Record(ctx, s)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, snapshot.Path, nil))
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Header().Get("Content-Type")).To(Equal("application/json"))
+
+		served := &snapshot.Snapshot{}
+		Expect(json.Unmarshal(w.Body.Bytes(), served)).To(Succeed())
+		Expect(served.NodePools[0].Name).To(Equal(s.NodePools[0].Name))
+		Expect(served.Nodes[0].Node.Name).To(Equal(s.Nodes[0].Node.Name))
+		Expect(served.Nodes[0].Pods).To(HaveLen(1))
+		Expect(served.Pods).To(HaveLen(2))
+		Expect(served.InstanceTypes["default"]).To(HaveLen(3))
+	})
+	It("should respond with not found once the snapshot is reset", func() {
+		r.Record(ctx, s)
+		Expect(r.Get()).ToNot(BeNil())
+		r.Reset()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, snapshot.Path, nil))
+		Expect(w.Code).To(Equal(http.StatusNotFound))
+	})
+	It("should respond with not found before a snapshot is recorded", func() {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, snapshot.Path, nil))
+		Expect(w.Code).To(Equal(http.StatusNotFound))
+	})
+})
@@ -19,12 +19,18 @@ package provisioning_test
 import (
 	"context"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/samber/lo"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -35,20 +41,26 @@ import (
 	. "knative.dev/pkg/logging/testing"
 	"knative.dev/pkg/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"sigs.k8s.io/karpenter/pkg/apis"
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
+	pscheduling "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/snapshot"
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
 	"sigs.k8s.io/karpenter/pkg/controllers/state/informer"
 	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/operator/controller"
 	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	"sigs.k8s.io/karpenter/pkg/operator/tracing"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
 	"sigs.k8s.io/karpenter/pkg/test"
 	. "sigs.k8s.io/karpenter/pkg/test/expectations"
+	nodeutil "sigs.k8s.io/karpenter/pkg/utils/node"
 )
 
 var (
@@ -110,6 +122,64 @@ var _ = Describe("Provisioning", func() {
 		Expect(len(nodes.Items)).To(Equal(1))
 		ExpectScheduled(ctx, env.Client, pod)
 	})
+	It("should not schedule when there are no pending pods", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool(), test.Node(test.NodeOptions{ObjectMeta: metav1.ObjectMeta{Name: "existing-node"}}))
+		ExpectApplied(ctx, env.Client, test.Pod(test.PodOptions{NodeName: "existing-node"}))
+		results, err := prov.Schedule(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results.NewNodeClaims).To(BeEmpty())
+		Expect(cloudProvider.CreateCalls).To(BeEmpty())
+	})
+	It("should provision for pending pods that are bound to a node that doesn't exist", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		pod := test.Pod(test.PodOptions{
+			NodeName:             "deleted-node",
+			ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}},
+			Conditions: []v1.PodCondition{{
+				Type:               v1.PodScheduled,
+				Status:             v1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(time.Now().Add(-nodeutil.OrphanedPodGracePeriod)),
+			}},
+		})
+		bindings := ExpectProvisionedNoBinding(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+		Expect(bindings).To(HaveLen(1))
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+		// the pod keeps its binding, since only its replacement can use the capacity
+		Expect(ExpectExists(ctx, env.Client, pod).Spec.NodeName).To(Equal("deleted-node"))
+	})
+	It("should not provision for pods that were bound to a node that doesn't exist within the grace period", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		ExpectApplied(ctx, env.Client, test.Pod(test.PodOptions{
+			NodeName: "new-node",
+			Conditions: []v1.PodCondition{{
+				Type:               v1.PodScheduled,
+				Status:             v1.ConditionTrue,
+				LastTransitionTime: metav1.Now(),
+			}},
+		}))
+		results, err := prov.Schedule(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results.NewNodeClaims).To(BeEmpty())
+		Expect(cloudProvider.CreateCalls).To(BeEmpty())
+	})
+	It("should not provision for pods that are bound to a node that doesn't exist once they're terminal", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		ExpectApplied(ctx, env.Client, test.Pod(test.PodOptions{NodeName: "deleted-node", Phase: v1.PodFailed}))
+		results, err := prov.Schedule(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results.NewNodeClaims).To(BeEmpty())
+		Expect(cloudProvider.CreateCalls).To(BeEmpty())
+	})
+	It("should schedule pods that become pending after an idle scheduling loop", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov)
+		Expect(cloudProvider.CreateCalls).To(BeEmpty())
+
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		ExpectScheduled(ctx, env.Client, pod)
+	})
 	It("should ignore NodePools that are deleting", func() {
 		nodePool := test.NodePool()
 		ExpectApplied(ctx, env.Client, nodePool)
@@ -121,6 +191,18 @@ var _ = Describe("Provisioning", func() {
 		Expect(len(nodes.Items)).To(Equal(0))
 		ExpectNotScheduled(ctx, env.Client, pod)
 	})
+	It("should ignore NodePools whose launches are paused after repeated launch failures", func() {
+		pausedNodePool := test.NodePool(v1beta1.NodePool{Spec: v1beta1.NodePoolSpec{Weight: lo.ToPtr[int32](100)}})
+		nodePool := test.NodePool()
+		ExpectApplied(ctx, env.Client, pausedNodePool, nodePool)
+		for i := 0; i < state.LaunchFailureThreshold; i++ {
+			cluster.RecordLaunchFailure(pausedNodePool.Name)
+		}
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels[v1beta1.NodePoolLabelKey]).To(Equal(nodePool.Name))
+	})
 	It("should provision nodes for pods with supported node selectors", func() {
 		nodePool := test.NodePool()
 		schedulable := []*v1.Pod{
@@ -430,6 +512,167 @@ var _ = Describe("Provisioning", func() {
 			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
 			ExpectNotScheduled(ctx, env.Client, pod)
 		})
+		It("should not schedule when limits are exceeded (extended resource)", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool(v1beta1.NodePool{
+				Spec: v1beta1.NodePoolSpec{
+					Limits: v1beta1.Limits(v1.ResourceList{fake.ResourceGPUVendorA: resource.MustParse("2")}),
+				},
+				Status: v1beta1.NodePoolStatus{
+					Resources: v1.ResourceList{
+						fake.ResourceGPUVendorA: resource.MustParse("4"),
+					},
+				},
+			}))
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should not schedule to a nodepool after a scheduling round if limits would be exceeded (extended resource)", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool(v1beta1.NodePool{
+				Spec: v1beta1.NodePoolSpec{
+					Limits: v1beta1.Limits(v1.ResourceList{fake.ResourceGPUVendorA: resource.MustParse("2")}),
+				},
+			}))
+			opts := test.PodOptions{ResourceRequirements: v1.ResourceRequirements{
+				Limits: v1.ResourceList{
+					// requires all of the GPUs of an instance type
+					fake.ResourceGPUVendorA: resource.MustParse("2"),
+				},
+			}}
+			pod := test.UnschedulablePod(opts)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Status.Capacity).To(HaveKey(fake.ResourceGPUVendorA))
+
+			// Another instance type with 2 GPUs would add up to 4 GPUs, so this should fail
+			pod = test.UnschedulablePod(opts)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+		})
+		It("should only count extended resources that are limited against the limits", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool(v1beta1.NodePool{
+				Spec: v1beta1.NodePoolSpec{
+					Limits: v1beta1.Limits(v1.ResourceList{fake.ResourceGPUVendorB: resource.MustParse("2")}),
+				},
+			}))
+			pods := []*v1.Pod{
+				test.UnschedulablePod(test.PodOptions{ResourceRequirements: v1.ResourceRequirements{
+					Limits: v1.ResourceList{fake.ResourceGPUVendorA: resource.MustParse("2")},
+				}}),
+				test.UnschedulablePod(test.PodOptions{ResourceRequirements: v1.ResourceRequirements{
+					Limits: v1.ResourceList{fake.ResourceGPUVendorA: resource.MustParse("2")},
+				}}),
+			}
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+			// the vendor-a GPUs aren't limited, so both pods get their own instance
+			Expect(ExpectScheduled(ctx, env.Client, pods[0]).Name).ToNot(Equal(ExpectScheduled(ctx, env.Client, pods[1]).Name))
+		})
+		Context("Node Limits", func() {
+			var nodePool *v1beta1.NodePool
+			var opts test.PodOptions
+			BeforeEach(func() {
+				nodePool = test.NodePool(v1beta1.NodePool{
+					Spec: v1beta1.NodePoolSpec{
+						Limits: v1beta1.Limits(v1.ResourceList{v1beta1.ResourceNodes: resource.MustParse("2")}),
+					},
+				})
+				// prevent these pods from scheduling on the same node
+				opts = test.PodOptions{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{"app": "foo"},
+					},
+					PodAntiRequirements: []v1.PodAffinityTerm{
+						{
+							TopologyKey: v1.LabelHostname,
+							LabelSelector: &metav1.LabelSelector{
+								MatchLabels: map[string]string{"app": "foo"},
+							},
+						},
+					},
+				}
+			})
+			It("should launch nodes below the node limit", func() {
+				ExpectApplied(ctx, env.Client, nodePool)
+				pods := []*v1.Pod{test.UnschedulablePod(opts), test.UnschedulablePod(opts)}
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+				Expect(ExpectScheduled(ctx, env.Client, pods[0]).Name).ToNot(Equal(ExpectScheduled(ctx, env.Client, pods[1]).Name))
+				Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+			})
+			It("should not launch more nodes than the node limit", func() {
+				ExpectApplied(ctx, env.Client, nodePool)
+				pods := []*v1.Pod{test.UnschedulablePod(opts), test.UnschedulablePod(opts), test.UnschedulablePod(opts)}
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+				scheduled := lo.CountBy(pods, func(p *v1.Pod) bool {
+					return ExpectPodExists(ctx, env.Client, p.Name, p.Namespace).Spec.NodeName != ""
+				})
+				Expect(scheduled).To(Equal(2))
+				Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+			})
+			It("should not launch nodes after a scheduling round once the node limit is reached", func() {
+				ExpectApplied(ctx, env.Client, nodePool)
+				pods := []*v1.Pod{test.UnschedulablePod(opts), test.UnschedulablePod(opts)}
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+				ExpectScheduled(ctx, env.Client, pods[0])
+				ExpectScheduled(ctx, env.Client, pods[1])
+
+				pod := test.UnschedulablePod(opts)
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectNotScheduled(ctx, env.Client, pod)
+				Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+			})
+			It("should count in-flight nodeclaims against the node limit", func() {
+				ExpectApplied(ctx, env.Client, nodePool)
+				for i := 0; i < 2; i++ {
+					nodeClaim := test.NodeClaim(v1beta1.NodeClaim{ObjectMeta: metav1.ObjectMeta{
+						Labels: map[string]string{v1beta1.NodePoolLabelKey: nodePool.Name},
+					}})
+					ExpectApplied(ctx, env.Client, nodeClaim)
+					nodeClaim, err := ExpectNodeClaimDeployedNoNode(ctx, env.Client, cloudProvider, nodeClaim)
+					Expect(err).ToNot(HaveOccurred())
+					cluster.UpdateNodeClaim(nodeClaim)
+				}
+				// the pod can't schedule to the in-flight nodeclaims, and no more nodes can be launched
+				pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{"test-key": "test-value"}})
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+				ExpectNotScheduled(ctx, env.Client, pod)
+				Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+			})
+			It("should not count nodes that are marked for deletion against the node limit", func() {
+				ExpectApplied(ctx, env.Client, nodePool)
+				pods := []*v1.Pod{test.UnschedulablePod(opts), test.UnschedulablePod(opts)}
+				ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+				nodeClaims := ExpectNodeClaims(ctx, env.Client)
+				Expect(nodeClaims).To(HaveLen(2))
+
+				// the pod on the node that's being deleted can be rescheduled to a new node
+				cluster.MarkForDeletion(nodeClaims[0].Status.ProviderID)
+				results, err := prov.Schedule(ctx)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results.NewNodeClaims).To(HaveLen(1))
+			})
+			It("should refuse to create a nodeclaim once the node limit is reached", func() {
+				nodePool.Spec.Limits = v1beta1.Limits(v1.ResourceList{v1beta1.ResourceNodes: resource.MustParse("1")})
+				ExpectApplied(ctx, env.Client, nodePool)
+				pod := test.UnschedulablePod()
+				ExpectApplied(ctx, env.Client, pod)
+				results, err := prov.Schedule(ctx)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results.NewNodeClaims).To(HaveLen(1))
+
+				// another nodeclaim is launched for the nodepool before the scheduled one is created
+				nodeClaim := test.NodeClaim(v1beta1.NodeClaim{ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{v1beta1.NodePoolLabelKey: nodePool.Name},
+				}})
+				ExpectApplied(ctx, env.Client, nodeClaim)
+				nodeClaim, err = ExpectNodeClaimDeployedNoNode(ctx, env.Client, cloudProvider, nodeClaim)
+				Expect(err).ToNot(HaveOccurred())
+				cluster.UpdateNodeClaim(nodeClaim)
+
+				_, err = prov.Create(ctx, results.NewNodeClaims[0])
+				Expect(err).To(MatchError(ContainSubstring("nodes resource usage of 1 has reached limit of 1")))
+				Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+			})
+		})
 	})
 	Context("Daemonsets and Node Overhead", func() {
 		It("should account for overhead", func() {
@@ -477,6 +720,58 @@ var _ = Describe("Provisioning", func() {
 			Expect(*allocatable.Cpu()).To(Equal(resource.MustParse("4")))
 			Expect(*allocatable.Memory()).To(Equal(resource.MustParse("4Gi")))
 		})
+		It("should only account for the overhead of daemonsets that tolerate the dedicated taint for dedicated pods", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool(), test.DaemonSet(
+				test.DaemonSetOptions{PodOptions: test.PodOptions{
+					ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi")}},
+				}},
+			))
+			pod := test.UnschedulablePod(
+				test.PodOptions{
+					ObjectMeta:           metav1.ObjectMeta{Annotations: map[string]string{v1beta1.DedicatedAnnotationKey: "true"}},
+					Tolerations:          []v1.Toleration{{Key: v1beta1.DedicatedTaintKey, Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoSchedule}},
+					ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi")}},
+				},
+			)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+
+			// the daemonset doesn't run on the dedicated node, so the pod fits on the smaller instance type
+			allocatable := instanceTypeMap[node.Labels[v1.LabelInstanceTypeStable]].Capacity
+			Expect(*allocatable.Cpu()).To(Equal(resource.MustParse("2")))
+			Expect(*allocatable.Memory()).To(Equal(resource.MustParse("2Gi")))
+		})
+		It("should account for the overhead of daemonsets that tolerate the dedicated taint for dedicated pods", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool(), test.DaemonSet(
+				test.DaemonSetOptions{PodOptions: test.PodOptions{
+					Tolerations:          []v1.Toleration{{Key: v1beta1.DedicatedTaintKey, Operator: v1.TolerationOpExists}},
+					ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi")}},
+				}},
+			))
+			pod := test.UnschedulablePod(
+				test.PodOptions{
+					ObjectMeta:           metav1.ObjectMeta{Annotations: map[string]string{v1beta1.DedicatedAnnotationKey: "true"}},
+					Tolerations:          []v1.Toleration{{Key: v1beta1.DedicatedTaintKey, Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoSchedule}},
+					ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi")}},
+				},
+			)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+
+			allocatable := instanceTypeMap[node.Labels[v1.LabelInstanceTypeStable]].Capacity
+			Expect(*allocatable.Cpu()).To(Equal(resource.MustParse("4")))
+			Expect(*allocatable.Memory()).To(Equal(resource.MustParse("4Gi")))
+		})
+		It("should not provision for a dedicated pod that doesn't tolerate the dedicated taint", func() {
+			ExpectApplied(ctx, env.Client, test.NodePool())
+			pod := test.UnschedulablePod(test.PodOptions{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{v1beta1.DedicatedAnnotationKey: "true"}},
+			})
+			Expect(prov.Validate(ctx, pod)).To(MatchError(ContainSubstring("doesn't tolerate the karpenter.sh/dedicated taint")))
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+			Expect(cloudProvider.CreateCalls).To(BeEmpty())
+		})
 		It("should not schedule if overhead is too large", func() {
 			ExpectApplied(ctx, env.Client, test.NodePool(), test.DaemonSet(
 				test.DaemonSetOptions{PodOptions: test.PodOptions{
@@ -1893,6 +2188,42 @@ var _ = Describe("Provisioning", func() {
 			node := ExpectScheduled(ctx, env.Client, pod)
 			Expect(node.Labels[v1beta1.NodePoolLabelKey]).ToNot(Equal(nodePool.Name))
 		})
+		It("should not match a higher weight NodePool with PreferNoSchedule taint when a lower weight NodePool matches", func() {
+			nodePool := test.NodePool(v1beta1.NodePool{
+				Spec: v1beta1.NodePoolSpec{
+					Weight: ptr.Int32(100),
+					Template: v1beta1.NodeClaimTemplate{
+						Spec: v1beta1.NodeClaimSpec{
+							Taints: []v1.Taint{{Key: "foo", Value: "bar", Effect: v1.TaintEffectPreferNoSchedule}},
+						},
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, test.NodePool())
+			pod := test.UnschedulablePod()
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[v1beta1.NodePoolLabelKey]).ToNot(Equal(nodePool.Name))
+		})
+		It("should match NodePool with PreferNoSchedule taint when no other NodePool matches", func() {
+			nodePool := test.NodePool(v1beta1.NodePool{
+				Spec: v1beta1.NodePoolSpec{
+					Template: v1beta1.NodeClaimTemplate{
+						ObjectMeta: v1beta1.ObjectMeta{
+							Labels: map[string]string{"foo": "bar"},
+						},
+						Spec: v1beta1.NodeClaimSpec{
+							Taints: []v1.Taint{{Key: "foo", Value: "bar", Effect: v1.TaintEffectPreferNoSchedule}},
+						},
+					},
+				},
+			})
+			ExpectApplied(ctx, env.Client, nodePool, test.NodePool())
+			pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{"foo": "bar"}})
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+			node := ExpectScheduled(ctx, env.Client, pod)
+			Expect(node.Labels[v1beta1.NodePoolLabelKey]).To(Equal(nodePool.Name))
+		})
 		Context("Weighted NodePools", func() {
 			It("should schedule to the nodepool with the highest priority always", func() {
 				nodePools := []client.Object{
@@ -1927,47 +2258,1093 @@ var _ = Describe("Provisioning", func() {
 	})
 })
 
-func ExpectNodeClaimRequirements(nodeClaim *v1beta1.NodeClaim, requirements ...v1.NodeSelectorRequirement) {
-	GinkgoHelper()
-	for _, requirement := range requirements {
-		req, ok := lo.Find(nodeClaim.Spec.Requirements, func(r v1beta1.NodeSelectorRequirementWithMinValues) bool {
-			return r.Key == requirement.Key && r.Operator == requirement.Operator
-		})
-		Expect(ok).To(BeTrue())
+// dynamicLimitProvider resolves the limits of every NodePool from a quota that can be changed mid-run
+type dynamicLimitProvider struct {
+	mu     sync.Mutex
+	limits v1beta1.Limits
+}
 
-		have := sets.New(req.Values...)
-		expected := sets.New(requirement.Values...)
-		Expect(have.Len()).To(Equal(expected.Len()))
-		Expect(have.Intersection(expected).Len()).To(Equal(expected.Len()))
-	}
+func (d *dynamicLimitProvider) Limits(context.Context, *v1beta1.NodePool) (v1beta1.Limits, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.limits, nil
 }
 
-func ExpectNodeClaimRequests(nodeClaim *v1beta1.NodeClaim, resources v1.ResourceList) {
-	GinkgoHelper()
-	for name, value := range resources {
-		v := nodeClaim.Spec.Resources.Requests[name]
-		Expect(v.AsApproximateFloat64()).To(BeNumerically("~", value.AsApproximateFloat64(), 10))
+func (d *dynamicLimitProvider) SetLimits(limits v1beta1.Limits) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.limits = limits
+}
+
+var _ = Describe("Limit Provider", func() {
+	var limitProvider *dynamicLimitProvider
+	var limitedProv *provisioning.Provisioner
+	BeforeEach(func() {
+		limitProvider = &dynamicLimitProvider{}
+		limitedProv = provisioning.NewProvisioner(env.Client, events.NewRecorder(&record.FakeRecorder{}), cloudProvider, cluster, provisioning.WithLimitProvider(limitProvider))
+	})
+	It("should enforce the limits resolved by the provider instead of the nodepool limits", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool(v1beta1.NodePool{
+			Spec: v1beta1.NodePoolSpec{
+				Limits: v1beta1.Limits(v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")}),
+			},
+		}))
+		limitProvider.SetLimits(v1beta1.Limits(v1.ResourceList{v1.ResourceCPU: resource.MustParse("100")}))
+		pod := test.UnschedulablePod(test.PodOptions{ResourceRequirements: v1.ResourceRequirements{
+			Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2.1")},
+		}})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, limitedProv, pod)
+		// the nodepool's own limit would refuse this pod, but the provider allows it
+		ExpectScheduled(ctx, env.Client, pod)
+	})
+	It("should refuse subsequent launches when the provider shrinks the limits", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		limitProvider.SetLimits(v1beta1.Limits(v1.ResourceList{v1.ResourceCPU: resource.MustParse("100")}))
+		pod := test.UnschedulablePod(test.PodOptions{ResourceRequirements: v1.ResourceRequirements{
+			Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1.75")},
+		}})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, limitedProv, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+
+		// the quota is lowered to the capacity that has already been launched
+		limitProvider.SetLimits(v1beta1.Limits(v1.ResourceList{v1.ResourceCPU: node.Status.Capacity[v1.ResourceCPU]}))
+		pod = test.UnschedulablePod(test.PodOptions{ResourceRequirements: v1.ResourceRequirements{
+			Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1.75")},
+		}})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, limitedProv, pod)
+		ExpectNotScheduled(ctx, env.Client, pod)
+	})
+	It("should refuse to launch when the nodepool's usage exceeds the limits resolved by the provider", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool(v1beta1.NodePool{
+			Status: v1beta1.NodePoolStatus{
+				Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("50")},
+			},
+		}))
+		limitProvider.SetLimits(v1beta1.Limits(v1.ResourceList{v1.ResourceCPU: resource.MustParse("20")}))
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, limitedProv, pod)
+		ExpectNotScheduled(ctx, env.Client, pod)
+	})
+	It("should launch again once the provider raises the limits", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		limitProvider.SetLimits(v1beta1.Limits(v1.ResourceList{v1.ResourceCPU: resource.MustParse("0")}))
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, limitedProv, pod)
+		ExpectNotScheduled(ctx, env.Client, pod)
+
+		limitProvider.SetLimits(v1beta1.Limits(v1.ResourceList{v1.ResourceCPU: resource.MustParse("100")}))
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, limitedProv, pod)
+		ExpectScheduled(ctx, env.Client, pod)
+	})
+})
+
+// zonalLaunchPolicy vetoes the launch of nodeclaims that may launch the instance type in the zone
+type zonalLaunchPolicy struct {
+	instanceType string
+	zone         string
+	admitted     []*v1beta1.NodeClaim
+}
+
+func (z *zonalLaunchPolicy) Admit(_ context.Context, nodeClaim *v1beta1.NodeClaim) error {
+	reqs := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaim.Spec.Requirements...)
+	if reqs.Get(v1.LabelInstanceTypeStable).Has(z.instanceType) && reqs.Get(v1.LabelTopologyZone).Has(z.zone) {
+		return fmt.Errorf("instance type %s is not allowed in zone %s", z.instanceType, z.zone)
 	}
+	z.admitted = append(z.admitted, nodeClaim)
+	return nil
 }
 
-func AddInstanceResources(instanceTypes []*cloudprovider.InstanceType, resources v1.ResourceList) []*cloudprovider.InstanceType {
-	opts := fake.InstanceTypeOptions{
-		Name:             "example",
-		Architecture:     "arch",
-		Resources:        resources,
-		OperatingSystems: sets.New(string(v1.Linux)),
+var _ = Describe("Launch Policy", func() {
+	var launchPolicy *zonalLaunchPolicy
+	var recorder *test.EventRecorder
+	var policyProv *provisioning.Provisioner
+	BeforeEach(func() {
+		launchPolicy = &zonalLaunchPolicy{instanceType: "default-instance-type", zone: "test-zone-1"}
+		recorder = test.NewEventRecorder()
+		policyProv = provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster, provisioning.WithLaunchPolicy(launchPolicy))
+	})
+	It("should leave the pod pending with an event when the policy vetoes the launch", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{
+			v1.LabelInstanceTypeStable: "default-instance-type",
+			v1.LabelTopologyZone:       "test-zone-1",
+		}})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, policyProv, pod)
+		ExpectNotScheduled(ctx, env.Client, pod)
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(0))
+
+		evts := recorder.EventsFor(pod, "LaunchVetoed")
+		Expect(evts).To(HaveLen(1))
+		Expect(evts[0].Type).To(Equal(v1.EventTypeWarning))
+		Expect(evts[0].Message).To(ContainSubstring("instance type default-instance-type is not allowed in zone test-zone-1"))
+	})
+	It("should launch the nodeclaims that the policy admits", func() {
+		nodePool := test.NodePool()
+		ExpectApplied(ctx, env.Client, nodePool)
+		pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{
+			v1.LabelInstanceTypeStable: "default-instance-type",
+			v1.LabelTopologyZone:       "test-zone-2",
+		}})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, policyProv, pod)
+		ExpectScheduled(ctx, env.Client, pod)
+		Expect(recorder.EventsFor(pod, "LaunchVetoed")).To(HaveLen(0))
+
+		// the policy is consulted with the planned nodeclaim
+		Expect(launchPolicy.admitted).To(HaveLen(1))
+		Expect(launchPolicy.admitted[0].Labels).To(HaveKeyWithValue(v1beta1.NodePoolLabelKey, nodePool.Name))
+	})
+	It("should launch once the policy stops vetoing the launch", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{
+			v1.LabelInstanceTypeStable: "default-instance-type",
+			v1.LabelTopologyZone:       "test-zone-1",
+		}})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, policyProv, pod)
+		ExpectNotScheduled(ctx, env.Client, pod)
+
+		launchPolicy.zone = "test-zone-2"
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, policyProv, pod)
+		ExpectScheduled(ctx, env.Client, pod)
+	})
+})
+
+// extenderFilter rejects the placements that a scheduler extender would reject
+type extenderFilter struct {
+	instanceTypes sets.Set[string]
+	nodes         sets.Set[string]
+}
+
+func (e *extenderFilter) Filter(_ context.Context, _ *v1.Pod, node pscheduling.FilterNode) error {
+	if node.InstanceType != nil && e.instanceTypes.Has(node.InstanceType.Name) {
+		return fmt.Errorf("extender rejects instance type %s", node.InstanceType.Name)
 	}
-	price := fake.PriceFromResources(opts.Resources)
-	opts.Offerings = []cloudprovider.Offering{
-		{
-			CapacityType: v1beta1.CapacityTypeSpot,
-			Zone:         "test-zone-1",
-			Price:        price,
-			Available:    true,
-		},
+	if e.nodes.Has(node.Name) {
+		return fmt.Errorf("extender rejects node %s", node.Name)
 	}
+	return nil
+}
 
-	instanceTypes = append(instanceTypes, fake.NewInstanceType(opts))
+var _ = Describe("Scheduling Filter", func() {
+	var filter *extenderFilter
+	var filterProv *provisioning.Provisioner
+	BeforeEach(func() {
+		cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+			fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name:      "small-instance-type",
+				Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2"), v1.ResourceMemory: resource.MustParse("4Gi")},
+			}),
+			fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name:      "large-instance-type",
+				Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("16"), v1.ResourceMemory: resource.MustParse("64Gi")},
+			}),
+		}
+		filter = &extenderFilter{instanceTypes: sets.New[string](), nodes: sets.New[string]()}
+		filterProv = provisioning.NewProvisioner(env.Client, events.NewRecorder(&record.FakeRecorder{}), cloudProvider, cluster, provisioning.WithSchedulingFilter(filter))
+	})
+	It("should launch the cheapest instance type when the filter allows it", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, filterProv, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceTypeStable, "small-instance-type"))
+	})
+	It("should launch a different instance type when the filter rejects an otherwise feasible one", func() {
+		filter.instanceTypes.Insert("small-instance-type")
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, filterProv, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceTypeStable, "large-instance-type"))
 
-	return instanceTypes
-}
+		nodeClaims := ExpectNodeClaims(ctx, env.Client)
+		Expect(nodeClaims).To(HaveLen(1))
+		requirements := scheduling.NewNodeSelectorRequirementsWithMinValues(nodeClaims[0].Spec.Requirements...)
+		Expect(requirements.Get(v1.LabelInstanceTypeStable).Has("small-instance-type")).To(BeFalse())
+	})
+	It("should leave the pod pending when the filter rejects every instance type", func() {
+		filter.instanceTypes.Insert("small-instance-type", "large-instance-type")
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		pod := test.UnschedulablePod()
+		ExpectApplied(ctx, env.Client, pod)
+		results, err := filterProv.Schedule(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results.NewNodeClaims).To(BeEmpty())
+		for _, err := range results.PodErrors {
+			Expect(err.Error()).To(ContainSubstring("rejected by the scheduling filter"))
+		}
+		ExpectNotScheduled(ctx, env.Client, pod)
+	})
+	It("should not schedule pods to existing nodes that the filter rejects", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, filterProv, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+
+		// the existing node has room for the pod, but the filter rejects it
+		filter.nodes.Insert(node.Name)
+		pod2 := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, filterProv, pod2)
+		Expect(ExpectScheduled(ctx, env.Client, pod2).Name).ToNot(Equal(node.Name))
+	})
+	It("should schedule pods to existing nodes that the filter allows", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, filterProv, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+
+		pod2 := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, filterProv, pod2)
+		Expect(ExpectScheduled(ctx, env.Client, pod2).Name).To(Equal(node.Name))
+	})
+})
+
+// taggingDecorator adds the organization's tags to nodeclaims, and attempts to clobber the fields that Karpenter sets
+type taggingDecorator struct {
+	err error
+}
+
+func (t *taggingDecorator) Decorate(_ context.Context, nodeClaim *v1beta1.NodeClaim) error {
+	if t.err != nil {
+		return t.err
+	}
+	nodeClaim.GenerateName = "acme-"
+	nodeClaim.Labels = lo.Assign(nodeClaim.Labels, map[string]string{
+		"acme.com/team":          "platform",
+		v1beta1.NodePoolLabelKey: "clobbered",
+		v1.LabelHostname:         "clobbered",
+	})
+	nodeClaim.Annotations = lo.Assign(nodeClaim.Annotations, map[string]string{
+		"acme.com/cost-center":            "1234",
+		v1beta1.NodePoolHashAnnotationKey: "clobbered",
+		v1beta1.DoNotDisruptAnnotationKey: "true",
+	})
+	nodeClaim.Finalizers = []string{"acme.com/audit"}
+	nodeClaim.Spec.Taints = append(nodeClaim.Spec.Taints, v1.Taint{Key: "acme.com/clobbered", Effect: v1.TaintEffectNoSchedule})
+	return nil
+}
+
+var _ = Describe("LimitRange Defaults", func() {
+	BeforeEach(func() {
+		cloudProvider.InstanceTypes = []*cloudprovider.InstanceType{
+			fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name:      "small-instance-type",
+				Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2"), v1.ResourceMemory: resource.MustParse("4Gi")},
+			}),
+			fake.NewInstanceType(fake.InstanceTypeOptions{
+				Name:      "large-instance-type",
+				Resources: v1.ResourceList{v1.ResourceCPU: resource.MustParse("16"), v1.ResourceMemory: resource.MustParse("64Gi")},
+			}),
+		}
+	})
+	limitRange := func(namespace string) *v1.LimitRange {
+		return &v1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{Name: test.RandomName(), Namespace: namespace},
+			Spec: v1.LimitRangeSpec{
+				Limits: []v1.LimitRangeItem{{
+					Type:           v1.LimitTypeContainer,
+					DefaultRequest: v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")},
+				}},
+			},
+		}
+	}
+	// the DaemonSet has no pods yet, so its overhead comes from its pod template, which isn't defaulted at admission
+	daemonSet := func(requirements v1.ResourceRequirements) *appsv1.DaemonSet {
+		return test.DaemonSet(test.DaemonSetOptions{PodOptions: test.PodOptions{ResourceRequirements: requirements}})
+	}
+	It("should launch a larger instance type for the default requests of a DaemonSet's LimitRange", func() {
+		ds := daemonSet(v1.ResourceRequirements{})
+		ExpectApplied(ctx, env.Client, test.NodePool(), ds, limitRange(ds.Namespace))
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceTypeStable, "large-instance-type"))
+	})
+	It("should apply the default requests to init containers", func() {
+		ds := daemonSet(v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m")}})
+		ds.Spec.Template.Spec.InitContainers = []v1.Container{{Name: "init", Image: ds.Spec.Template.Spec.Containers[0].Image}}
+		ExpectApplied(ctx, env.Client, test.NodePool(), ds, limitRange(ds.Namespace))
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceTypeStable, "large-instance-type"))
+	})
+	It("should not override the requests that the DaemonSet specifies", func() {
+		ds := daemonSet(v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m")}})
+		ExpectApplied(ctx, env.Client, test.NodePool(), ds, limitRange(ds.Namespace))
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceTypeStable, "small-instance-type"))
+	})
+	It("should default the requests to the limits that the DaemonSet specifies rather than to the LimitRange", func() {
+		ds := daemonSet(v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m")}})
+		ExpectApplied(ctx, env.Client, test.NodePool(), ds, limitRange(ds.Namespace))
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceTypeStable, "small-instance-type"))
+	})
+	It("should ignore the LimitRanges of other namespaces", func() {
+		namespace := test.Namespace()
+		ExpectApplied(ctx, env.Client, test.NodePool(), namespace, daemonSet(v1.ResourceRequirements{}), limitRange(namespace.Name))
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceTypeStable, "small-instance-type"))
+	})
+	It("should not apply the LimitRange to pods, which were already defaulted at admission", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		// the pod is created before the LimitRange, so it isn't defaulted at admission
+		pod := test.UnschedulablePod()
+		ExpectApplied(ctx, env.Client, pod, limitRange(pod.Namespace))
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceTypeStable, "small-instance-type"))
+	})
+})
+
+var _ = Describe("NodeClaim Decorator", func() {
+	var decorator *taggingDecorator
+	var decoratedProv *provisioning.Provisioner
+	BeforeEach(func() {
+		decorator = &taggingDecorator{}
+		decoratedProv = provisioning.NewProvisioner(env.Client, test.NewEventRecorder(), cloudProvider, cluster, provisioning.WithNodeClaimDecorator(decorator))
+	})
+	It("should create nodeclaims with the decorator's labels, annotations and finalizers", func() {
+		nodePool := test.NodePool()
+		ExpectApplied(ctx, env.Client, nodePool)
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, decoratedProv, pod)
+		ExpectScheduled(ctx, env.Client, pod)
+
+		nodeClaims := ExpectNodeClaims(ctx, env.Client)
+		Expect(nodeClaims).To(HaveLen(1))
+		Expect(nodeClaims[0].Name).To(HavePrefix("acme-"))
+		Expect(nodeClaims[0].Labels).To(HaveKeyWithValue("acme.com/team", "platform"))
+		Expect(nodeClaims[0].Annotations).To(HaveKeyWithValue("acme.com/cost-center", "1234"))
+		Expect(nodeClaims[0].Finalizers).To(ContainElement("acme.com/audit"))
+	})
+	It("should not let the decorator clobber the fields that Karpenter sets", func() {
+		nodePool := test.NodePool()
+		ExpectApplied(ctx, env.Client, nodePool)
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, decoratedProv, pod)
+		ExpectScheduled(ctx, env.Client, pod)
+
+		nodeClaims := ExpectNodeClaims(ctx, env.Client)
+		Expect(nodeClaims).To(HaveLen(1))
+		Expect(nodeClaims[0].Labels).To(HaveKeyWithValue(v1beta1.NodePoolLabelKey, nodePool.Name))
+		Expect(nodeClaims[0].Labels).ToNot(HaveKey(v1.LabelHostname))
+		Expect(nodeClaims[0].Annotations).To(HaveKeyWithValue(v1beta1.NodePoolHashAnnotationKey, nodePool.Hash()))
+		Expect(nodeClaims[0].Annotations).ToNot(HaveKey(v1beta1.DoNotDisruptAnnotationKey))
+		Expect(nodeClaims[0].Spec.Taints).ToNot(ContainElement(HaveField("Key", "acme.com/clobbered")))
+	})
+	It("should not launch when the decorator fails", func() {
+		decorator.err = fmt.Errorf("tagging service unavailable")
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, decoratedProv, pod)
+		ExpectNotScheduled(ctx, env.Client, pod)
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(0))
+	})
+})
+
+var _ = Describe("Min Nodes", func() {
+	It("should launch nodes up to minNodes without any pending pods", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool(v1beta1.NodePool{Spec: v1beta1.NodePoolSpec{MinNodes: 2}}))
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov)
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+		Expect(ExpectNodes(ctx, env.Client)).To(HaveLen(2))
+	})
+	It("should not launch more nodes once minNodes is reached", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool(v1beta1.NodePool{Spec: v1beta1.NodePoolSpec{MinNodes: 2}}))
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov)
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+
+		results, err := prov.Schedule(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results.NewNodeClaims).To(BeEmpty())
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+	})
+	It("should count the nodes launched for pods towards minNodes", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool(v1beta1.NodePool{Spec: v1beta1.NodePoolSpec{MinNodes: 2}}))
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		ExpectScheduled(ctx, env.Client, pod)
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+	})
+	It("should launch nodes for pods beyond minNodes", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool(v1beta1.NodePool{Spec: v1beta1.NodePoolSpec{MinNodes: 1}}))
+		pods := []*v1.Pod{
+			test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{v1.LabelTopologyZone: "test-zone-1"}}),
+			test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{v1.LabelTopologyZone: "test-zone-2"}}),
+		}
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+		for _, pod := range pods {
+			ExpectScheduled(ctx, env.Client, pod)
+		}
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(2))
+	})
+	It("should replace nodes that are marked for deletion", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool(v1beta1.NodePool{Spec: v1beta1.NodePoolSpec{MinNodes: 2}}))
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov)
+		nodeClaims := ExpectNodeClaims(ctx, env.Client)
+		Expect(nodeClaims).To(HaveLen(2))
+
+		cluster.MarkForDeletion(nodeClaims[0].Status.ProviderID)
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov)
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(3))
+	})
+	It("should not launch nodes for minNodes beyond the nodepool's limits", func() {
+		cloudProvider.InstanceTypes = fake.InstanceTypes(2)
+		ExpectApplied(ctx, env.Client, test.NodePool(v1beta1.NodePool{Spec: v1beta1.NodePoolSpec{
+			MinNodes: 3,
+			Limits:   v1beta1.Limits(v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")}),
+		}}))
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov)
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(1))
+	})
+	It("should not launch nodes for minNodes in dry-run", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			DryRun:            lo.ToPtr(true),
+			BatchMaxDuration:  lo.ToPtr(10 * time.Millisecond),
+			BatchIdleDuration: lo.ToPtr(10 * time.Millisecond),
+		}))
+		ExpectApplied(ctx, env.Client, test.NodePool(v1beta1.NodePool{Spec: v1beta1.NodePoolSpec{MinNodes: 2}}))
+		prov.Trigger()
+		ExpectReconcileSucceeded(ctx, prov, client.ObjectKey{})
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(0))
+		Expect(cloudProvider.CreateCalls).To(HaveLen(0))
+	})
+})
+
+var _ = Describe("Batch Config", func() {
+	var batchConfigController controller.Controller
+	var cm *v1.ConfigMap
+	BeforeEach(func() {
+		batchConfigController = provisioning.NewBatchConfigController(env.Client, prov)
+		cm = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      provisioning.BatchConfigMapName,
+				Namespace: "default",
+			},
+		}
+	})
+	AfterEach(func() {
+		ExpectDeleted(ctx, env.Client, cm)
+		ExpectReconcileSucceeded(ctx, batchConfigController, client.ObjectKeyFromObject(cm))
+	})
+	// expectBatchDuration triggers a batching window and returns how long the provisioner waited for it to close
+	expectBatchDuration := func() time.Duration {
+		GinkgoHelper()
+		start := time.Now()
+		prov.Trigger()
+		ExpectReconcileSucceeded(ctx, prov, client.ObjectKey{})
+		return time.Since(start)
+	}
+
+	It("should reject invalid batch durations", func() {
+		batcher := provisioning.NewBatcher()
+		Expect(batcher.SetDurations(-time.Second, 0)).ToNot(Succeed())
+		Expect(batcher.SetDurations(time.Second, -time.Second)).ToNot(Succeed())
+		Expect(batcher.SetDurations(time.Second, 10*time.Second)).ToNot(Succeed())
+		Expect(batcher.SetDurations(10*time.Second, time.Second)).To(Succeed())
+	})
+	It("should use the batch durations from the ConfigMap", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{BatchMaxDuration: lo.ToPtr(5 * time.Second), BatchIdleDuration: lo.ToPtr(5 * time.Second)}))
+		cm.Data = map[string]string{
+			provisioning.BatchMaxDurationKey:  "10ms",
+			provisioning.BatchIdleDurationKey: "10ms",
+		}
+		ExpectApplied(ctx, env.Client, cm)
+		ExpectReconcileSucceeded(ctx, batchConfigController, client.ObjectKeyFromObject(cm))
+		Expect(expectBatchDuration()).To(BeNumerically("<", time.Second))
+	})
+	It("should keep the previous batch durations when the ConfigMap is invalid", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{BatchMaxDuration: lo.ToPtr(5 * time.Second), BatchIdleDuration: lo.ToPtr(5 * time.Second)}))
+		cm.Data = map[string]string{
+			provisioning.BatchMaxDurationKey:  "10ms",
+			provisioning.BatchIdleDurationKey: "10ms",
+		}
+		ExpectApplied(ctx, env.Client, cm)
+		ExpectReconcileSucceeded(ctx, batchConfigController, client.ObjectKeyFromObject(cm))
+
+		// max duration must be at least as long as the idle duration
+		cm.Data[provisioning.BatchIdleDurationKey] = "1m"
+		ExpectApplied(ctx, env.Client, cm)
+		ExpectReconcileSucceeded(ctx, batchConfigController, client.ObjectKeyFromObject(cm))
+		Expect(expectBatchDuration()).To(BeNumerically("<", time.Second))
+
+		cm.Data[provisioning.BatchIdleDurationKey] = "not-a-duration"
+		ExpectApplied(ctx, env.Client, cm)
+		ExpectReconcileSucceeded(ctx, batchConfigController, client.ObjectKeyFromObject(cm))
+		Expect(expectBatchDuration()).To(BeNumerically("<", time.Second))
+	})
+	It("should fall back to the configured options when the ConfigMap is deleted", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{BatchMaxDuration: lo.ToPtr(2 * time.Second), BatchIdleDuration: lo.ToPtr(2 * time.Second)}))
+		cm.Data = map[string]string{
+			provisioning.BatchMaxDurationKey:  "10ms",
+			provisioning.BatchIdleDurationKey: "10ms",
+		}
+		ExpectApplied(ctx, env.Client, cm)
+		ExpectReconcileSucceeded(ctx, batchConfigController, client.ObjectKeyFromObject(cm))
+		Expect(expectBatchDuration()).To(BeNumerically("<", time.Second))
+
+		ExpectDeleted(ctx, env.Client, cm)
+		ExpectReconcileSucceeded(ctx, batchConfigController, client.ObjectKeyFromObject(cm))
+		Expect(expectBatchDuration()).To(BeNumerically(">=", 2*time.Second))
+	})
+})
+
+func ExpectNodeClaimRequirements(nodeClaim *v1beta1.NodeClaim, requirements ...v1.NodeSelectorRequirement) {
+	GinkgoHelper()
+	for _, requirement := range requirements {
+		req, ok := lo.Find(nodeClaim.Spec.Requirements, func(r v1beta1.NodeSelectorRequirementWithMinValues) bool {
+			return r.Key == requirement.Key && r.Operator == requirement.Operator
+		})
+		Expect(ok).To(BeTrue())
+
+		have := sets.New(req.Values...)
+		expected := sets.New(requirement.Values...)
+		Expect(have.Len()).To(Equal(expected.Len()))
+		Expect(have.Intersection(expected).Len()).To(Equal(expected.Len()))
+	}
+}
+
+func ExpectNodeClaimRequests(nodeClaim *v1beta1.NodeClaim, resources v1.ResourceList) {
+	GinkgoHelper()
+	for name, value := range resources {
+		v := nodeClaim.Spec.Resources.Requests[name]
+		Expect(v.AsApproximateFloat64()).To(BeNumerically("~", value.AsApproximateFloat64(), 10))
+	}
+}
+
+func AddInstanceResources(instanceTypes []*cloudprovider.InstanceType, resources v1.ResourceList) []*cloudprovider.InstanceType {
+	opts := fake.InstanceTypeOptions{
+		Name:             "example",
+		Architecture:     "arch",
+		Resources:        resources,
+		OperatingSystems: sets.New(string(v1.Linux)),
+	}
+	price := fake.PriceFromResources(opts.Resources)
+	opts.Offerings = []cloudprovider.Offering{
+		{
+			CapacityType: v1beta1.CapacityTypeSpot,
+			Zone:         "test-zone-1",
+			Price:        price,
+			Available:    true,
+		},
+	}
+
+	instanceTypes = append(instanceTypes, fake.NewInstanceType(opts))
+
+	return instanceTypes
+}
+
+var _ = Describe("Simulate With NodePools", func() {
+	var pod *v1.Pod
+	BeforeEach(func() {
+		pod = test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{"team": "a"}})
+		ExpectApplied(ctx, env.Client, pod)
+	})
+	It("should not schedule the pods without a hypothetical NodePool that they can schedule to", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		results, err := prov.SimulateWithNodePools(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results.NewNodeClaims).To(BeEmpty())
+		Expect(lo.Keys(results.PodErrors)).To(ConsistOf(HaveField("Name", pod.Name)))
+	})
+	It("should schedule the pods to a hypothetical NodePool without launching anything", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		hypothetical := test.NodePool(v1beta1.NodePool{
+			Spec: v1beta1.NodePoolSpec{
+				Template: v1beta1.NodeClaimTemplate{
+					ObjectMeta: v1beta1.ObjectMeta{Labels: map[string]string{"team": "a"}},
+				},
+			},
+		})
+		results, err := prov.SimulateWithNodePools(ctx, hypothetical)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results.PodErrors).To(BeEmpty())
+		Expect(results.NewNodeClaims).To(HaveLen(1))
+		Expect(results.NewNodeClaims[0].NodePoolName).To(Equal(hypothetical.Name))
+		Expect(results.NewNodeClaims[0].Pods).To(ConsistOf(HaveField("Name", pod.Name)))
+
+		// The hypothetical NodePool isn't applied and the pod stays pending
+		ExpectNotFound(ctx, env.Client, hypothetical)
+		Expect(cloudProvider.CreateCalls).To(BeEmpty())
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(BeEmpty())
+		ExpectNotScheduled(ctx, env.Client, pod)
+	})
+	It("should replace the NodePool of the same name with the hypothetical NodePool", func() {
+		nodePool := test.NodePool(v1beta1.NodePool{
+			Spec: v1beta1.NodePoolSpec{
+				Template: v1beta1.NodeClaimTemplate{
+					ObjectMeta: v1beta1.ObjectMeta{Labels: map[string]string{"team": "b"}},
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool)
+		hypothetical := nodePool.DeepCopy()
+		hypothetical.Spec.Template.Labels = map[string]string{"team": "a"}
+		results, err := prov.SimulateWithNodePools(ctx, hypothetical)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results.PodErrors).To(BeEmpty())
+		Expect(results.NewNodeClaims).To(HaveLen(1))
+		Expect(results.NewNodeClaims[0].NodePoolName).To(Equal(nodePool.Name))
+
+		// The NodePool in the cluster is unchanged
+		Expect(ExpectExists(ctx, env.Client, nodePool).Spec.Template.Labels).To(HaveKeyWithValue("team", "b"))
+	})
+	It("should prefer the hypothetical NodePool over the existing ones by weight", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool(v1beta1.NodePool{
+			Spec: v1beta1.NodePoolSpec{
+				Template: v1beta1.NodeClaimTemplate{
+					ObjectMeta: v1beta1.ObjectMeta{Labels: map[string]string{"team": "a"}},
+				},
+			},
+		}))
+		hypothetical := test.NodePool(v1beta1.NodePool{
+			Spec: v1beta1.NodePoolSpec{
+				Weight: lo.ToPtr[int32](100),
+				Template: v1beta1.NodeClaimTemplate{
+					ObjectMeta: v1beta1.ObjectMeta{Labels: map[string]string{"team": "a"}},
+				},
+			},
+		})
+		results, err := prov.SimulateWithNodePools(ctx, hypothetical)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results.NewNodeClaims).To(HaveLen(1))
+		Expect(results.NewNodeClaims[0].NodePoolName).To(Equal(hypothetical.Name))
+	})
+})
+
+var _ = Describe("Scheduler Names", func() {
+	It("should provision for pods of every scheduler when no scheduler names are set", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		pod := test.UnschedulablePod()
+		pod.Spec.SchedulerName = "custom-scheduler"
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		ExpectScheduled(ctx, env.Client, pod)
+	})
+	It("should not provision for pods of a scheduler that isn't managed", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{SchedulerNames: []string{v1.DefaultSchedulerName}}))
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		pod := test.UnschedulablePod()
+		pod.Spec.SchedulerName = "custom-scheduler"
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		ExpectNotScheduled(ctx, env.Client, pod)
+		Expect(cloudProvider.CreateCalls).To(BeEmpty())
+	})
+	It("should only provision for the pods of managed schedulers", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{SchedulerNames: []string{v1.DefaultSchedulerName, "custom-scheduler"}}))
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		defaultPod := test.UnschedulablePod()
+		customPod := test.UnschedulablePod()
+		customPod.Spec.SchedulerName = "custom-scheduler"
+		otherPod := test.UnschedulablePod()
+		otherPod.Spec.SchedulerName = "other-scheduler"
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, defaultPod, customPod, otherPod)
+		ExpectScheduled(ctx, env.Client, defaultPod)
+		ExpectScheduled(ctx, env.Client, customPod)
+		ExpectNotScheduled(ctx, env.Client, otherPod)
+	})
+})
+
+var _ = Describe("Excluded Instance Types", func() {
+	It("should not launch excluded instance types that a nodepool allows", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ExcludedInstanceTypes: []string{"small-instance-type", "default-instance-type"}}))
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels[v1.LabelInstanceTypeStable]).ToNot(BeElementOf("small-instance-type", "default-instance-type"))
+		Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+		instanceTypeRequirement, ok := lo.Find(cloudProvider.CreateCalls[0].Spec.Requirements, func(r v1beta1.NodeSelectorRequirementWithMinValues) bool {
+			return r.Key == v1.LabelInstanceTypeStable
+		})
+		Expect(ok).To(BeTrue())
+		Expect(instanceTypeRequirement.Values).ToNot(ContainElement(BeElementOf("small-instance-type", "default-instance-type")))
+	})
+	It("should exclude instance types matching a glob pattern", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ExcludedInstanceTypes: []string{"gpu-vendor-*"}}))
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		pod := test.UnschedulablePod(test.PodOptions{ResourceRequirements: v1.ResourceRequirements{
+			Limits: v1.ResourceList{fake.ResourceGPUVendorA: resource.MustParse("1")},
+		}})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		// the only instance type with vendor-a GPUs is excluded
+		ExpectNotScheduled(ctx, env.Client, pod)
+	})
+	It("should not schedule to a nodepool that only allows excluded instance types", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{ExcludedInstanceTypes: []string{"default-instance-type"}}))
+		ExpectApplied(ctx, env.Client, test.NodePool(v1beta1.NodePool{
+			Spec: v1beta1.NodePoolSpec{
+				Template: v1beta1.NodeClaimTemplate{
+					Spec: v1beta1.NodeClaimSpec{
+						Requirements: []v1beta1.NodeSelectorRequirementWithMinValues{
+							{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelInstanceTypeStable, Operator: v1.NodeSelectorOpIn, Values: []string{"default-instance-type"}}},
+						},
+					},
+				},
+			},
+		}))
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		ExpectNotScheduled(ctx, env.Client, pod)
+		Expect(cloudProvider.CreateCalls).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Deprecated Instance Types", func() {
+	It("should not launch deprecated instance types", func() {
+		cloudProvider.InstanceTypes = fake.InstanceTypes(3)
+		for _, it := range cloudProvider.InstanceTypes[:2] {
+			it.Deprecated = true
+		}
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels[v1.LabelInstanceTypeStable]).To(Equal(cloudProvider.InstanceTypes[2].Name))
+	})
+	It("should not schedule if all instance types are deprecated", func() {
+		cloudProvider.InstanceTypes = fake.InstanceTypes(3)
+		for _, it := range cloudProvider.InstanceTypes {
+			it.Deprecated = true
+		}
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		ExpectNotScheduled(ctx, env.Client, pod)
+		Expect(cloudProvider.CreateCalls).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Namespace Instance Types", func() {
+	var recorder *test.EventRecorder
+	var namespaceProv *provisioning.Provisioner
+	var restricted, unrestricted *v1.Namespace
+	BeforeEach(func() {
+		recorder = test.NewEventRecorder()
+		namespaceProv = provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster)
+		restricted = test.Namespace()
+		unrestricted = test.Namespace()
+		ExpectApplied(ctx, env.Client, restricted, unrestricted, test.NodePool())
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{NamespaceInstanceTypes: map[string][]string{
+			restricted.Name: {"small-*", "arm-instance-type"},
+		}}))
+	})
+	It("should only launch allowed instance types for pods from a restricted namespace", func() {
+		pod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Namespace: restricted.Name}})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, namespaceProv, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels[v1.LabelInstanceTypeStable]).To(BeElementOf("small-instance-type", "arm-instance-type"))
+		Expect(cloudProvider.CreateCalls).To(HaveLen(1))
+		instanceTypeRequirement, ok := lo.Find(cloudProvider.CreateCalls[0].Spec.Requirements, func(r v1beta1.NodeSelectorRequirementWithMinValues) bool {
+			return r.Key == v1.LabelInstanceTypeStable
+		})
+		Expect(ok).To(BeTrue())
+		Expect(instanceTypeRequirement.Values).To(ConsistOf("small-instance-type", "arm-instance-type"))
+	})
+	It("should launch any instance type for pods from an unrestricted namespace", func() {
+		pod := test.UnschedulablePod(test.PodOptions{
+			ObjectMeta:   metav1.ObjectMeta{Namespace: unrestricted.Name},
+			NodeSelector: map[string]string{v1.LabelInstanceTypeStable: "default-instance-type"},
+		})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, namespaceProv, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+		Expect(node.Labels).To(HaveKeyWithValue(v1.LabelInstanceTypeStable, "default-instance-type"))
+	})
+	DescribeTable("should leave pods from a restricted namespace pending with an event when no allowed instance type fits",
+		func(podOptions test.PodOptions, message string) {
+			podOptions.ObjectMeta = metav1.ObjectMeta{Namespace: restricted.Name}
+			pod := test.UnschedulablePod(podOptions)
+			ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, namespaceProv, pod)
+			ExpectNotScheduled(ctx, env.Client, pod)
+			Expect(cloudProvider.CreateCalls).To(BeEmpty())
+
+			evts := recorder.EventsFor(pod, "FailedScheduling")
+			Expect(evts).To(HaveLen(1))
+			Expect(evts[0].Type).To(Equal(v1.EventTypeWarning))
+			Expect(evts[0].Message).To(ContainSubstring(message))
+		},
+		Entry("disallowed instance type", test.PodOptions{
+			NodeSelector: map[string]string{v1.LabelInstanceTypeStable: "default-instance-type"},
+		}, "no instance type satisfied resources"),
+		Entry("requests that only fit disallowed instance types", test.PodOptions{
+			ResourceRequirements: v1.ResourceRequirements{Limits: v1.ResourceList{fake.ResourceGPUVendorA: resource.MustParse("1")}},
+		}, "no instance type satisfied resources"),
+	)
+	It("should leave pods from a restricted namespace pending when the nodepool allows none of its instance types", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{NamespaceInstanceTypes: map[string][]string{
+			restricted.Name: {"m5.*"},
+		}}))
+		pod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Namespace: restricted.Name}})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, namespaceProv, pod)
+		ExpectNotScheduled(ctx, env.Client, pod)
+
+		evts := recorder.EventsFor(pod, "FailedScheduling")
+		Expect(evts).To(HaveLen(1))
+		Expect(evts[0].Message).To(ContainSubstring(fmt.Sprintf("no instance type is allowed for namespace %q", restricted.Name)))
+	})
+	It("should only launch instance types allowed for every namespace of the pods packed onto a nodeclaim", func() {
+		restrictedPod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Namespace: restricted.Name}})
+		unrestrictedPod := test.UnschedulablePod(test.PodOptions{ObjectMeta: metav1.ObjectMeta{Namespace: unrestricted.Name}})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, namespaceProv, unrestrictedPod, restrictedPod)
+		ExpectScheduled(ctx, env.Client, unrestrictedPod)
+		node := ExpectScheduled(ctx, env.Client, restrictedPod)
+		Expect(node.Labels[v1.LabelInstanceTypeStable]).To(BeElementOf("small-instance-type", "arm-instance-type"))
+	})
+})
+
+var _ = Describe("Dry Run", func() {
+	BeforeEach(func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			DryRun:            lo.ToPtr(true),
+			BatchMaxDuration:  lo.ToPtr(10 * time.Millisecond),
+			BatchIdleDuration: lo.ToPtr(10 * time.Millisecond),
+		}))
+	})
+	It("should report the nodeclaims it would launch without creating them", func() {
+		nodePool := test.NodePool()
+		pod := test.UnschedulablePod()
+		ExpectApplied(ctx, env.Client, nodePool, pod)
+		prov.Trigger()
+		ExpectReconcileSucceeded(ctx, prov, client.ObjectKey{})
+
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(0))
+		Expect(cloudProvider.CreateCalls).To(HaveLen(0))
+		ExpectNotScheduled(ctx, env.Client, pod)
+		m, found := FindMetricWithLabelValues("karpenter_provisioner_would_launch_total", map[string]string{
+			"nodepool": nodePool.Name,
+		})
+		Expect(found).To(BeTrue())
+		Expect(m.GetCounter().GetValue()).To(BeNumerically(">=", 1))
+	})
+})
+
+var _ = Describe("Instance Tags", func() {
+	It("should tag the instance with the values of the nodepool's labels", func() {
+		nodePool := test.NodePool(v1beta1.NodePool{
+			Spec: v1beta1.NodePoolSpec{
+				Template: v1beta1.NodeClaimTemplate{
+					ObjectMeta: v1beta1.ObjectMeta{Labels: map[string]string{"team": "payments"}},
+					Spec: v1beta1.NodeClaimSpec{
+						TagLabels: map[string]string{"team": "Team", v1beta1.NodePoolLabelKey: "karpenter-nodepool"},
+					},
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool)
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+
+		nodeClaims := ExpectNodeClaims(ctx, env.Client)
+		Expect(nodeClaims).To(HaveLen(1))
+		Expect(nodeClaims[0].Spec.TagLabels).To(Equal(nodePool.Spec.Template.Spec.TagLabels))
+		Expect(cloudProvider.CreatedTags[node.Spec.ProviderID]).To(Equal(map[string]string{
+			"Team":               "payments",
+			"karpenter-nodepool": nodePool.Name,
+		}))
+	})
+	It("should tag the instance with the values of labels that pods require", func() {
+		nodePool := test.NodePool(v1beta1.NodePool{
+			Spec: v1beta1.NodePoolSpec{
+				Template: v1beta1.NodeClaimTemplate{
+					Spec: v1beta1.NodeClaimSpec{
+						Requirements: []v1beta1.NodeSelectorRequirementWithMinValues{
+							{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: "app-tier", Operator: v1.NodeSelectorOpExists}},
+						},
+						TagLabels: map[string]string{"app-tier": "AppTier", v1.LabelTopologyZone: "Zone"},
+					},
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool)
+		pod := test.UnschedulablePod(test.PodOptions{NodeSelector: map[string]string{"app-tier": "web"}})
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+
+		Expect(cloudProvider.CreatedTags[node.Spec.ProviderID]).To(Equal(map[string]string{
+			"AppTier": "web",
+			"Zone":    node.Labels[v1.LabelTopologyZone],
+		}))
+	})
+	It("should not tag the instance with labels whose values aren't known", func() {
+		nodePool := test.NodePool(v1beta1.NodePool{
+			Spec: v1beta1.NodePoolSpec{
+				Template: v1beta1.NodeClaimTemplate{
+					Spec: v1beta1.NodeClaimSpec{
+						Requirements: []v1beta1.NodeSelectorRequirementWithMinValues{
+							{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: "app-tier", Operator: v1.NodeSelectorOpIn, Values: []string{"web", "batch"}}},
+						},
+						TagLabels: map[string]string{"app-tier": "AppTier", "missing": "Missing"},
+					},
+				},
+			},
+		})
+		ExpectApplied(ctx, env.Client, nodePool)
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+
+		Expect(cloudProvider.CreatedTags[node.Spec.ProviderID]).To(BeEmpty())
+	})
+	It("should not tag the instance if the nodepool doesn't map any labels", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		node := ExpectScheduled(ctx, env.Client, pod)
+
+		Expect(cloudProvider.CreatedTags[node.Spec.ProviderID]).To(BeEmpty())
+	})
+})
+
+var _ = Describe("Launch Events", func() {
+	var recorder *test.EventRecorder
+	var eventProv *provisioning.Provisioner
+	BeforeEach(func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			BatchMaxDuration:  lo.ToPtr(10 * time.Millisecond),
+			BatchIdleDuration: lo.ToPtr(10 * time.Millisecond),
+		}))
+		recorder = test.NewEventRecorder()
+		eventProv = provisioning.NewProvisioner(env.Client, recorder, cloudProvider, cluster)
+	})
+	It("should record the pods that triggered a launch to emit an event on them once it's launched", func() {
+		nodePool := test.NodePool()
+		pods := test.UnschedulablePods(test.PodOptions{}, 3)
+		ExpectApplied(ctx, env.Client, nodePool)
+		for _, pod := range pods {
+			ExpectApplied(ctx, env.Client, pod)
+		}
+		eventProv.Trigger()
+		ExpectReconcileSucceeded(ctx, eventProv, client.ObjectKey{})
+
+		nodeClaims := ExpectNodeClaims(ctx, env.Client)
+		Expect(nodeClaims).To(HaveLen(1))
+		// the instance type isn't resolved until the NodeClaim is launched
+		for _, pod := range pods {
+			Expect(recorder.EventsFor(pod, "TriggeredLaunch")).To(HaveLen(0))
+		}
+		Expect(lo.Map(cluster.TakeLaunchPods(nodeClaims[0].Name), func(p *v1.Pod, _ int) string { return p.Name })).To(ConsistOf(
+			lo.Map(pods, func(p *v1.Pod, _ int) string { return p.Name })))
+	})
+	It("should not record the pods in dry-run", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			DryRun:            lo.ToPtr(true),
+			BatchMaxDuration:  lo.ToPtr(10 * time.Millisecond),
+			BatchIdleDuration: lo.ToPtr(10 * time.Millisecond),
+		}))
+		pod := test.UnschedulablePod()
+		ExpectApplied(ctx, env.Client, test.NodePool(), pod)
+		eventProv.Trigger()
+		ExpectReconcileSucceeded(ctx, eventProv, client.ObjectKey{})
+
+		Expect(ExpectNodeClaims(ctx, env.Client)).To(HaveLen(0))
+		Expect(recorder.EventsFor(pod, "WouldLaunch")).To(HaveLen(1))
+	})
+})
+
+var _ = Describe("NodePool Selection Metric", func() {
+	It("should count a launch for the highest weight nodepool once per nodeclaim", func() {
+		lowWeight := test.NodePool(v1beta1.NodePool{Spec: v1beta1.NodePoolSpec{Weight: lo.ToPtr[int32](10)}})
+		highWeight := test.NodePool(v1beta1.NodePool{Spec: v1beta1.NodePoolSpec{Weight: lo.ToPtr[int32](100)}})
+		ExpectApplied(ctx, env.Client, lowWeight, highWeight)
+		pods := []*v1.Pod{test.UnschedulablePod(), test.UnschedulablePod(), test.UnschedulablePod()}
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pods...)
+		nodeClaims := ExpectNodeClaims(ctx, env.Client)
+		Expect(nodeClaims).To(HaveLen(1))
+
+		m, found := FindMetricWithLabelValues("karpenter_provisioner_nodepool_selected_total", map[string]string{
+			"nodepool": highWeight.Name,
+		})
+		Expect(found).To(BeTrue())
+		Expect(m.GetCounter().GetValue()).To(BeNumerically("==", 1))
+		_, found = FindMetricWithLabelValues("karpenter_provisioner_nodepool_selected_total", map[string]string{
+			"nodepool": lowWeight.Name,
+		})
+		Expect(found).To(BeFalse())
+	})
+})
+
+var _ = Describe("Cluster State Snapshot", func() {
+	AfterEach(func() {
+		snapshot.Snapshots.Reset()
+	})
+	It("should record the cluster state that pods were scheduled against", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{EnableClusterStateSnapshot: lo.ToPtr(true)}))
+		nodePool := test.NodePool()
+		ExpectApplied(ctx, env.Client, nodePool)
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		ExpectScheduled(ctx, env.Client, pod)
+
+		s := snapshot.Snapshots.Get()
+		Expect(s).ToNot(BeNil())
+		Expect(s.Truncated).To(BeFalse())
+		Expect(lo.Map(s.NodePools, func(np v1beta1.NodePool, _ int) string { return np.Name })).To(ConsistOf(nodePool.Name))
+		Expect(s.InstanceTypes[nodePool.Name]).To(HaveLen(len(lo.Must(cloudProvider.GetInstanceTypes(ctx, nodePool)))))
+		Expect(lo.Map(s.Pods, func(p *v1.Pod, _ int) string { return p.Name })).To(ConsistOf(pod.Name))
+	})
+	It("should record the instance types that the scheduler considered", func() {
+		ctx = options.ToContext(ctx, test.Options(test.OptionsFields{
+			EnableClusterStateSnapshot: lo.ToPtr(true),
+			ExcludedInstanceTypes:      []string{"default-instance-type"},
+		}))
+		nodePool := test.NodePool()
+		ExpectApplied(ctx, env.Client, nodePool)
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		ExpectScheduled(ctx, env.Client, pod)
+
+		s := snapshot.Snapshots.Get()
+		Expect(s).ToNot(BeNil())
+		Expect(lo.Map(s.InstanceTypes[nodePool.Name], func(it snapshot.InstanceType, _ int) string { return it.Name })).ToNot(ContainElement("default-instance-type"))
+		Expect(s.InstanceTypes[nodePool.Name]).To(HaveLen(len(lo.Must(cloudProvider.GetInstanceTypes(ctx, nodePool))) - 1))
+	})
+	It("should not record the cluster state when disabled", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		ExpectScheduled(ctx, env.Client, pod)
+		Expect(snapshot.Snapshots.Get()).To(BeNil())
+	})
+})
+
+var _ = Describe("Tracing", func() {
+	var spanRecorder *tracetest.SpanRecorder
+	BeforeEach(func() {
+		spanRecorder = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	})
+	AfterEach(func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+	})
+	It("should create spans for scheduling and launching", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		pod := test.UnschedulablePod()
+		ExpectProvisioned(ctx, env.Client, cluster, cloudProvider, prov, pod)
+		ExpectScheduled(ctx, env.Client, pod)
+
+		spans := lo.SliceToMap(spanRecorder.Ended(), func(s sdktrace.ReadOnlySpan) (string, sdktrace.ReadOnlySpan) { return s.Name(), s })
+		Expect(spans).To(HaveKey("provisioning.Schedule"))
+		Expect(spans).To(HaveKey("provisioning.Solve"))
+		Expect(spans).To(HaveKey("provisioning.Launch"))
+		Expect(spans["provisioning.Schedule"].Attributes()).To(ContainElements(tracing.PodsKey.Int(1), tracing.NodeClaimsKey.Int(1)))
+		Expect(spans["provisioning.Solve"].Parent().SpanID()).To(Equal(spans["provisioning.Schedule"].SpanContext().SpanID()))
+	})
+	It("should not create spans for launching when there is nothing to schedule", func() {
+		ExpectApplied(ctx, env.Client, test.NodePool())
+		_, err := prov.Schedule(ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lo.Map(spanRecorder.Ended(), func(s sdktrace.ReadOnlySpan, _ int) string { return s.Name() })).To(ConsistOf("provisioning.Schedule"))
+	})
+	It("should not create spans when the batch isn't triggered", func() {
+		_, err := prov.Reconcile(ctx, reconcile.Request{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(spanRecorder.Ended()).To(BeEmpty())
+	})
+})
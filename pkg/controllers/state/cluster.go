@@ -65,6 +65,10 @@ type Cluster struct {
 	// optimize and not try to disrupt if nothing about the cluster has changed.
 	clusterState     time.Time
 	antiAffinityPods sync.Map // pod namespaced name -> *v1.Pod of pods that have required anti affinities
+	launchPods       sync.Map // node claim name -> []*v1.Pod of pods that triggered its launch
+
+	launchBreakerMu sync.Mutex                // Separate mutex as launch outcomes are recorded outside of the state informers
+	launchBreakers  map[string]*launchBreaker // node pool name -> launch circuit breaker
 }
 
 func NewCluster(clk clock.Clock, client client.Client, cp cloudprovider.CloudProvider) *Cluster {
@@ -77,6 +81,7 @@ func NewCluster(clk clock.Clock, client client.Client, cp cloudprovider.CloudPro
 		daemonSetPods:             sync.Map{},
 		nodeNameToProviderID:      map[string]string{},
 		nodeClaimNameToProviderID: map[string]string{},
+		launchBreakers:            map[string]*launchBreaker{},
 	}
 }
 
@@ -152,7 +157,9 @@ func (c *Cluster) ForPodsWithAntiAffinity(fn func(p *v1.Pod, n *v1.Node) bool) {
 }
 
 // ForEachNode calls the supplied function once per node object that is being tracked. It is not safe to store the
-// state.StateNode object, it should be only accessed from within the function provided to this method.
+// state.StateNode object, it should be only accessed from within the function provided to this method. The cluster
+// state is locked while the function runs, so it must not call back into the cluster state to make updates. Use
+// ForEachNodeSnapshot when that's needed.
 func (c *Cluster) ForEachNode(f func(n *StateNode) bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -164,6 +171,18 @@ func (c *Cluster) ForEachNode(f func(n *StateNode) bool) {
 	}
 }
 
+// ForEachNodeSnapshot calls the supplied function once per node in a point-in-time snapshot of the nodes that are
+// being tracked. Updates made to the cluster state while iterating aren't observed, and the cluster state isn't locked
+// while the function runs, so the state.StateNode objects are safe to store and the function may update the cluster state.
+// NOTE: This DeepCopies all state nodes up front, so prefer ForEachNode on hot paths
+func (c *Cluster) ForEachNodeSnapshot(f func(n *StateNode) bool) {
+	for _, node := range c.Nodes() {
+		if !f(node) {
+			return
+		}
+	}
+}
+
 // Nodes creates a DeepCopy of all state nodes.
 // NOTE: This is very inefficient so this should only be used when DeepCopying is absolutely necessary
 func (c *Cluster) Nodes() StateNodes {
@@ -247,9 +266,27 @@ func (c *Cluster) DeleteNodeClaim(name string) {
 	defer c.mu.Unlock()
 
 	c.cleanupNodeClaim(name)
+	c.launchPods.Delete(name)
 	clusterStateNodesCount.Set(float64(len(c.nodes)))
 }
 
+// RecordLaunchPods records the pods that triggered the creation of a NodeClaim, so that they can be told which
+// instance type was launched for them once the NodeClaim is launched
+func (c *Cluster) RecordLaunchPods(nodeClaimName string, pods []*v1.Pod) {
+	if len(pods) > 0 {
+		c.launchPods.Store(nodeClaimName, pods)
+	}
+}
+
+// TakeLaunchPods returns and forgets the pods that triggered the creation of a NodeClaim
+func (c *Cluster) TakeLaunchPods(nodeClaimName string) []*v1.Pod {
+	pods, ok := c.launchPods.LoadAndDelete(nodeClaimName)
+	if !ok {
+		return nil
+	}
+	return pods.([]*v1.Pod)
+}
+
 func (c *Cluster) UpdateNode(ctx context.Context, node *v1.Node) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -268,16 +305,36 @@ func (c *Cluster) UpdateNode(ctx context.Context, node *v1.Node) error {
 	if managed && node.Labels[v1.LabelInstanceTypeStable] == "" && !initialized {
 		return nil
 	}
-	n, err := c.newStateFromNode(ctx, node, c.nodes[node.Spec.ProviderID])
+	providerID := c.providerIDForNode(node)
+	n, err := c.newStateFromNode(ctx, node, providerID, c.nodes[providerID])
 	if err != nil {
 		return err
 	}
-	c.nodes[node.Spec.ProviderID] = n
-	c.nodeNameToProviderID[node.Name] = node.Spec.ProviderID
+	c.nodes[providerID] = n
+	c.nodeNameToProviderID[node.Name] = providerID
 	clusterStateNodesCount.Set(float64(len(c.nodes)))
 	return nil
 }
 
+// providerIDForNode returns the providerID that the node is tracked by. This is the node's providerID, unless no
+// NodeClaim has it and the CloudProvider implements MatchNode, in which case the node is tracked with the registered
+// NodeClaim that the CloudProvider matches it to.
+func (c *Cluster) providerIDForNode(node *v1.Node) string {
+	if n, ok := c.nodes[node.Spec.ProviderID]; ok && n.NodeClaim != nil {
+		return node.Spec.ProviderID
+	}
+	matcher, ok := cloudprovider.As[cloudprovider.NodeMatcher](c.cloudProvider)
+	if !ok {
+		return node.Spec.ProviderID
+	}
+	for providerID, n := range c.nodes {
+		if n.NodeClaim != nil && n.NodeClaim.Status.NodeName == node.Name && matcher.MatchNode(n.NodeClaim, node) {
+			return providerID
+		}
+	}
+	return node.Spec.ProviderID
+}
+
 func (c *Cluster) DeleteNode(name string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -348,7 +405,12 @@ func (c *Cluster) Reset() {
 	c.nodeClaimNameToProviderID = map[string]string{}
 	c.bindings = map[types.NamespacedName]string{}
 	c.antiAffinityPods = sync.Map{}
+	c.launchPods = sync.Map{}
 	c.daemonSetPods = sync.Map{}
+
+	c.launchBreakerMu.Lock()
+	defer c.launchBreakerMu.Unlock()
+	c.launchBreakers = map[string]*launchBreaker{}
 }
 
 func (c *Cluster) GetDaemonSetPod(daemonset *appsv1.DaemonSet) *v1.Pod {
@@ -400,6 +462,7 @@ func (c *Cluster) newStateFromNodeClaim(nodeClaim *v1beta1.NodeClaim, oldNode *S
 		daemonSetLimits:   oldNode.daemonSetLimits,
 		podRequests:       oldNode.podRequests,
 		podLimits:         oldNode.podLimits,
+		dedicatedPods:     oldNode.dedicatedPods,
 		hostPortUsage:     oldNode.hostPortUsage,
 		volumeUsage:       oldNode.volumeUsage,
 		markedForDeletion: oldNode.markedForDeletion,
@@ -430,7 +493,7 @@ func (c *Cluster) cleanupNodeClaim(name string) {
 	delete(c.nodeClaimNameToProviderID, name)
 }
 
-func (c *Cluster) newStateFromNode(ctx context.Context, node *v1.Node, oldNode *StateNode) (*StateNode, error) {
+func (c *Cluster) newStateFromNode(ctx context.Context, node *v1.Node, providerID string, oldNode *StateNode) (*StateNode, error) {
 	if oldNode == nil {
 		oldNode = NewNode()
 	}
@@ -441,6 +504,7 @@ func (c *Cluster) newStateFromNode(ctx context.Context, node *v1.Node, oldNode *
 		daemonSetLimits:   map[types.NamespacedName]v1.ResourceList{},
 		podRequests:       map[types.NamespacedName]v1.ResourceList{},
 		podLimits:         map[types.NamespacedName]v1.ResourceList{},
+		dedicatedPods:     map[types.NamespacedName]struct{}{},
 		hostPortUsage:     scheduling.NewHostPortUsage(),
 		volumeUsage:       scheduling.NewVolumeUsage(),
 		markedForDeletion: oldNode.markedForDeletion,
@@ -455,7 +519,7 @@ func (c *Cluster) newStateFromNode(ctx context.Context, node *v1.Node, oldNode *
 	// Cleanup the old node with its old providerID if its providerID changes
 	// This can happen since nodes don't get created with providerIDs. Rather, CCM picks up the
 	// created node and injects the providerID into the spec.providerID
-	if id, ok := c.nodeNameToProviderID[node.Name]; ok && id != node.Spec.ProviderID {
+	if id, ok := c.nodeNameToProviderID[node.Name]; ok && id != providerID {
 		c.cleanupNode(node.Name)
 	}
 	c.triggerConsolidationOnChange(oldNode, n)
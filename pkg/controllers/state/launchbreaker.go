@@ -0,0 +1,140 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"time"
+
+	"github.com/samber/lo"
+)
+
+const (
+	// LaunchFailureThreshold is the number of consecutive launch failures for a NodePool that opens its circuit breaker
+	LaunchFailureThreshold = 5
+	// LaunchFailureWindow is how recent the previous failure must be for a launch failure to count as consecutive
+	LaunchFailureWindow = 10 * time.Minute
+	// LaunchBackoff is how long launches are paused for once the circuit breaker first opens
+	LaunchBackoff = 5 * time.Minute
+	// MaxLaunchBackoff caps the backoff, which doubles every time a trial launch after the backoff fails
+	MaxLaunchBackoff = time.Hour
+	// LaunchTrialTimeout is how long the other launches wait for the outcome of a trial launch, after which another
+	// trial is let through in case the outcome of the trial was never recorded
+	LaunchTrialTimeout = 5 * time.Minute
+)
+
+// launchBreaker tracks the launch outcomes of a single NodePool. Once open, it pauses launches until openUntil, after
+// which a single trial launch is let through and the other launches are paused until trialUntil. A successful launch
+// closes the breaker while a failed trial re-opens it with a longer backoff.
+type launchBreaker struct {
+	failures    int
+	lastFailure time.Time
+	backoff     time.Duration
+	openUntil   time.Time
+	trialUntil  time.Time
+}
+
+func (b *launchBreaker) pausedFor(now time.Time) time.Duration {
+	return lo.Max([]time.Duration{b.openUntil.Sub(now), b.trialUntil.Sub(now), 0})
+}
+
+// RecordLaunchFailure records a failed launch for the NodePool, opening its circuit breaker once the failures
+// exceed the LaunchFailureThreshold within the LaunchFailureWindow.
+func (c *Cluster) RecordLaunchFailure(nodePoolName string) {
+	c.launchBreakerMu.Lock()
+	defer c.launchBreakerMu.Unlock()
+
+	now := c.clock.Now()
+	b, ok := c.launchBreakers[nodePoolName]
+	if !ok {
+		b = &launchBreaker{}
+		c.launchBreakers[nodePoolName] = b
+	}
+	if b.backoff == 0 && now.Sub(b.lastFailure) > LaunchFailureWindow {
+		b.failures = 0
+	}
+	b.failures++
+	b.lastFailure = now
+	// The breaker has already opened and is tracking its backoff
+	if b.backoff > 0 {
+		// Launches that were in-flight when the breaker opened are counted, but don't extend the backoff
+		if now.Before(b.openUntil) {
+			return
+		}
+		// A trial launch after the backoff failed, so re-open the breaker for longer
+		b.backoff = lo.Min([]time.Duration{b.backoff * 2, MaxLaunchBackoff})
+		b.openUntil = now.Add(b.backoff)
+		b.trialUntil = time.Time{}
+		return
+	}
+	if b.failures >= LaunchFailureThreshold {
+		b.backoff = LaunchBackoff
+		b.openUntil = now.Add(b.backoff)
+	}
+}
+
+// RecordLaunchSuccess records a successful launch for the NodePool, closing its circuit breaker.
+func (c *Cluster) RecordLaunchSuccess(nodePoolName string) {
+	c.launchBreakerMu.Lock()
+	defer c.launchBreakerMu.Unlock()
+
+	delete(c.launchBreakers, nodePoolName)
+}
+
+// LaunchesPausedFor returns how much longer launches for the NodePool are paused by its circuit breaker, either
+// because its backoff hasn't elapsed or because a trial launch is in-flight. A zero duration means that launches are
+// allowed.
+func (c *Cluster) LaunchesPausedFor(nodePoolName string) time.Duration {
+	c.launchBreakerMu.Lock()
+	defer c.launchBreakerMu.Unlock()
+
+	b, ok := c.launchBreakers[nodePoolName]
+	if !ok {
+		return 0
+	}
+	return b.pausedFor(c.clock.Now())
+}
+
+// AcquireLaunch is called before launching a NodeClaim for the NodePool and returns how much longer the launch has to
+// wait for. Once the backoff of an open circuit breaker has elapsed, the first launch to acquire it is the trial
+// launch, and the other launches wait for its outcome.
+func (c *Cluster) AcquireLaunch(nodePoolName string) time.Duration {
+	c.launchBreakerMu.Lock()
+	defer c.launchBreakerMu.Unlock()
+
+	b, ok := c.launchBreakers[nodePoolName]
+	if !ok {
+		return 0
+	}
+	now := c.clock.Now()
+	if d := b.pausedFor(now); d > 0 || b.backoff == 0 {
+		return d
+	}
+	b.trialUntil = now.Add(LaunchTrialTimeout)
+	return 0
+}
+
+// LaunchFailures returns the number of consecutive launch failures of the NodePool, including the failures of the
+// launches that were in-flight while its circuit breaker was open.
+func (c *Cluster) LaunchFailures(nodePoolName string) int {
+	c.launchBreakerMu.Lock()
+	defer c.launchBreakerMu.Unlock()
+
+	if b, ok := c.launchBreakers[nodePoolName]; ok {
+		return b.failures
+	}
+	return 0
+}
@@ -57,6 +57,17 @@ func (n StateNodes) Deleting() StateNodes {
 	})
 }
 
+// CountByNodePool returns the number of StateNodes that are owned by each NodePool
+func (n StateNodes) CountByNodePool() map[string]int {
+	counts := map[string]int{}
+	for _, node := range n {
+		if nodePoolName, ok := node.Labels()[v1beta1.NodePoolLabelKey]; ok {
+			counts[nodePoolName]++
+		}
+	}
+	return counts
+}
+
 // Pods gets the pods assigned to all StateNodes based on the kubernetes api-server bindings
 func (n StateNodes) Pods(ctx context.Context, c client.Client) ([]*v1.Pod, error) {
 	var pods []*v1.Pod
@@ -98,6 +109,8 @@ type StateNode struct {
 
 	podRequests map[types.NamespacedName]v1.ResourceList
 	podLimits   map[types.NamespacedName]v1.ResourceList
+	// dedicatedPods are the pods bound to the node that requested a node of their own
+	dedicatedPods map[types.NamespacedName]struct{}
 
 	hostPortUsage *scheduling.HostPortUsage
 	volumeUsage   *scheduling.VolumeUsage
@@ -114,6 +127,7 @@ func NewNode() *StateNode {
 		daemonSetLimits:   map[types.NamespacedName]v1.ResourceList{},
 		podRequests:       map[types.NamespacedName]v1.ResourceList{},
 		podLimits:         map[types.NamespacedName]v1.ResourceList{},
+		dedicatedPods:     map[types.NamespacedName]struct{}{},
 		hostPortUsage:     scheduling.NewHostPortUsage(),
 		volumeUsage:       scheduling.NewVolumeUsage(),
 	}
@@ -136,7 +150,8 @@ func (in *StateNode) Name() string {
 // If the Node and NodeClaim have a providerID, this should map to a real providerID
 // If the Node does not have a providerID, this will map to the node name
 func (in *StateNode) ProviderID() string {
-	if in.Node == nil {
+	// Nodes that the CloudProvider matches to a NodeClaim are tracked by the NodeClaim's providerID
+	if in.NodeClaim != nil {
 		return in.NodeClaim.Status.ProviderID
 	}
 	return in.Node.Spec.ProviderID
@@ -312,6 +327,18 @@ func (in *StateNode) PodLimits() v1.ResourceList {
 	return resources.Merge(lo.Values(in.podLimits)...)
 }
 
+// NonDaemonSetPodCount returns the number of pods bound to the node that aren't owned by a DaemonSet
+func (in *StateNode) NonDaemonSetPodCount() int {
+	return len(in.podRequests) - len(in.daemonSetRequests)
+}
+
+// Dedicated returns true if the node is running a pod with the karpenter.sh/dedicated annotation, in which case no
+// other pods aside from DaemonSet pods should be scheduled to it. Nodes that were launched for such a pod keep the
+// karpenter.sh/dedicated taint after it leaves, so they can be reused by other dedicated pods until they're consolidated.
+func (in *StateNode) Dedicated() bool {
+	return len(in.dedicatedPods) > 0
+}
+
 func (in *StateNode) MarkedForDeletion() bool {
 	// The Node is marked for deletion if:
 	//  1. The Node has MarkedForDeletion set
@@ -348,6 +375,9 @@ func (in *StateNode) updateForPod(ctx context.Context, kubeClient client.Client,
 		in.daemonSetRequests[podKey] = resources.RequestsForPods(pod)
 		in.daemonSetLimits[podKey] = resources.LimitsForPods(pod)
 	}
+	if podutils.IsDedicated(pod) {
+		in.dedicatedPods[podKey] = struct{}{}
+	}
 	in.hostPortUsage.Add(pod, hostPorts)
 	in.volumeUsage.Add(pod, volumes)
 	return nil
@@ -360,6 +390,7 @@ func (in *StateNode) cleanupForPod(podKey types.NamespacedName) {
 	delete(in.podLimits, podKey)
 	delete(in.daemonSetRequests, podKey)
 	delete(in.daemonSetLimits, podKey)
+	delete(in.dedicatedPods, podKey)
 }
 
 func nominationWindow(ctx context.Context) time.Duration {
@@ -372,9 +403,23 @@ func nominationWindow(ctx context.Context) time.Duration {
 
 // RequireNoScheduleTaint will add/remove the karpenter.sh/disruption:NoSchedule taint from the candidates.
 // This is used to enforce no taints at the beginning of disruption, and
-// to add/remove taints while executing a disruption action.
-// nolint:gocyclo
+// to add/remove taints while executing a disruption action. Removing the taint also removes the disruption
+// reason and decision time annotations.
 func RequireNoScheduleTaint(ctx context.Context, kubeClient client.Client, addTaint bool, nodes ...*StateNode) error {
+	return requireNoScheduleTaint(ctx, kubeClient, addTaint, nil, nodes...)
+}
+
+// RequireDisruptionTaint adds the karpenter.sh/disruption:NoSchedule taint to the candidates along with annotations
+// recording the reason and time of the disruption decision. The taint and annotations are applied in the same patch.
+func RequireDisruptionTaint(ctx context.Context, kubeClient client.Client, reason string, decisionTime time.Time, nodes ...*StateNode) error {
+	return requireNoScheduleTaint(ctx, kubeClient, true, map[string]string{
+		v1beta1.DisruptionReasonAnnotationKey:       reason,
+		v1beta1.DisruptionDecisionTimeAnnotationKey: decisionTime.UTC().Format(time.RFC3339),
+	}, nodes...)
+}
+
+// nolint:gocyclo
+func requireNoScheduleTaint(ctx context.Context, kubeClient client.Client, addTaint bool, annotations map[string]string, nodes ...*StateNode) error {
 	var multiErr error
 	for _, n := range nodes {
 		// If the StateNode is Karpenter owned and only has a nodeclaim, or is not owned by
@@ -402,6 +447,8 @@ func RequireNoScheduleTaint(ctx context.Context, kubeClient client.Client, addTa
 			node.Spec.Taints = lo.Reject(node.Spec.Taints, func(taint v1.Taint, _ int) bool {
 				return taint.Key == v1beta1.DisruptionTaintKey
 			})
+			delete(node.Annotations, v1beta1.DisruptionReasonAnnotationKey)
+			delete(node.Annotations, v1beta1.DisruptionDecisionTimeAnnotationKey)
 			// otherwise, add it.
 		} else if addTaint && !hasTaint {
 			// If the taint key is present (but with a different value or effect), remove it.
@@ -410,6 +457,9 @@ func RequireNoScheduleTaint(ctx context.Context, kubeClient client.Client, addTa
 			})
 			node.Spec.Taints = append(node.Spec.Taints, v1beta1.DisruptionNoScheduleTaint)
 		}
+		if addTaint && len(annotations) > 0 {
+			node.Annotations = lo.Assign(node.Annotations, annotations)
+		}
 		if !equality.Semantic.DeepEqual(stored, node) {
 			if err := kubeClient.Patch(ctx, node, client.StrategicMergeFrom(stored)); err != nil {
 				multiErr = multierr.Append(multiErr, fmt.Errorf("patching node %s, %w", node.Name, err))
@@ -21,9 +21,11 @@ import (
 	"fmt"
 	"math/rand"
 	"net"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/samber/lo"
 	storagev1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	cloudproviderapi "k8s.io/cloud-provider/api"
@@ -1164,6 +1166,30 @@ var _ = Describe("Cluster State Sync", func() {
 		ExpectMetricGaugeValue("karpenter_cluster_state_synced", 1.0, nil)
 		ExpectMetricGaugeValue("karpenter_cluster_state_node_count", 1000.0, nil)
 	})
+	It("should track a node with the registered nodeclaim that the cloudprovider matches it to", func() {
+		cloudProvider.NodeMatcher = func(nc *v1beta1.NodeClaim, n *v1.Node) bool {
+			return n.Spec.ProviderID == "custom:///"+nc.Status.ProviderID
+		}
+		node := test.Node(test.NodeOptions{ProviderID: "custom:///" + test.RandomProviderID()})
+		nodeClaim := test.NodeClaim(v1beta1.NodeClaim{
+			Status: v1beta1.NodeClaimStatus{
+				ProviderID: strings.TrimPrefix(node.Spec.ProviderID, "custom:///"),
+				NodeName:   node.Name,
+			},
+		})
+		ExpectApplied(ctx, env.Client, node, nodeClaim)
+		ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+		ExpectReconcileSucceeded(ctx, nodeClaimController, client.ObjectKeyFromObject(nodeClaim))
+		ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+
+		Expect(cluster.Synced(ctx)).To(BeTrue())
+		ExpectMetricGaugeValue("karpenter_cluster_state_node_count", 1.0, nil)
+		stateNodes := cluster.Nodes()
+		Expect(stateNodes).To(HaveLen(1))
+		Expect(stateNodes[0].Node.Name).To(Equal(node.Name))
+		Expect(stateNodes[0].NodeClaim.Name).To(Equal(nodeClaim.Name))
+		Expect(stateNodes[0].ProviderID()).To(Equal(nodeClaim.Status.ProviderID))
+	})
 	It("should consider the cluster state synced when all nodeclaims are tracked", func() {
 		// Deploy 1000 nodeClaims and sync them all with the cluster
 		for i := 0; i < 1000; i++ {
@@ -1464,6 +1490,97 @@ var _ = Describe("Consolidated State", func() {
 	})
 })
 
+var _ = Describe("Launch Circuit Breaker", func() {
+	It("should pause launches once the failure threshold is reached", func() {
+		for i := 0; i < state.LaunchFailureThreshold-1; i++ {
+			cluster.RecordLaunchFailure(nodePool.Name)
+		}
+		Expect(cluster.LaunchesPausedFor(nodePool.Name)).To(BeZero())
+		cluster.RecordLaunchFailure(nodePool.Name)
+		Expect(cluster.LaunchesPausedFor(nodePool.Name)).To(Equal(state.LaunchBackoff))
+	})
+	It("should not count failures that are spread further apart than the failure window", func() {
+		for i := 0; i < state.LaunchFailureThreshold; i++ {
+			cluster.RecordLaunchFailure(nodePool.Name)
+			fakeClock.Step(state.LaunchFailureWindow + time.Second)
+		}
+		Expect(cluster.LaunchesPausedFor(nodePool.Name)).To(BeZero())
+	})
+	It("should reset the failure count after a successful launch", func() {
+		for i := 0; i < state.LaunchFailureThreshold-1; i++ {
+			cluster.RecordLaunchFailure(nodePool.Name)
+		}
+		cluster.RecordLaunchSuccess(nodePool.Name)
+		cluster.RecordLaunchFailure(nodePool.Name)
+		Expect(cluster.LaunchesPausedFor(nodePool.Name)).To(BeZero())
+	})
+	It("should count the failures of launches that were in-flight when the breaker opened without extending the backoff", func() {
+		for i := 0; i < state.LaunchFailureThreshold; i++ {
+			cluster.RecordLaunchFailure(nodePool.Name)
+		}
+		fakeClock.Step(time.Minute)
+		cluster.RecordLaunchFailure(nodePool.Name)
+		Expect(cluster.LaunchesPausedFor(nodePool.Name)).To(Equal(state.LaunchBackoff - time.Minute))
+		Expect(cluster.LaunchFailures(nodePool.Name)).To(Equal(state.LaunchFailureThreshold + 1))
+	})
+	It("should only let a single trial launch through once the backoff has elapsed", func() {
+		for i := 0; i < state.LaunchFailureThreshold; i++ {
+			cluster.RecordLaunchFailure(nodePool.Name)
+		}
+		Expect(cluster.AcquireLaunch(nodePool.Name)).To(Equal(state.LaunchBackoff))
+		fakeClock.Step(state.LaunchBackoff)
+		Expect(cluster.AcquireLaunch(nodePool.Name)).To(BeZero())
+		Expect(cluster.AcquireLaunch(nodePool.Name)).To(Equal(state.LaunchTrialTimeout))
+		Expect(cluster.LaunchesPausedFor(nodePool.Name)).To(Equal(state.LaunchTrialTimeout))
+	})
+	It("should let another trial launch through if the outcome of the trial isn't recorded", func() {
+		for i := 0; i < state.LaunchFailureThreshold; i++ {
+			cluster.RecordLaunchFailure(nodePool.Name)
+		}
+		fakeClock.Step(state.LaunchBackoff)
+		Expect(cluster.AcquireLaunch(nodePool.Name)).To(BeZero())
+		fakeClock.Step(state.LaunchTrialTimeout)
+		Expect(cluster.AcquireLaunch(nodePool.Name)).To(BeZero())
+	})
+	It("should allow all launches once the breaker is closed", func() {
+		Expect(cluster.AcquireLaunch(nodePool.Name)).To(BeZero())
+		Expect(cluster.AcquireLaunch(nodePool.Name)).To(BeZero())
+		for i := 0; i < state.LaunchFailureThreshold-1; i++ {
+			cluster.RecordLaunchFailure(nodePool.Name)
+		}
+		Expect(cluster.AcquireLaunch(nodePool.Name)).To(BeZero())
+		Expect(cluster.AcquireLaunch(nodePool.Name)).To(BeZero())
+	})
+	It("should double the backoff when the trial launch after the backoff fails", func() {
+		for i := 0; i < state.LaunchFailureThreshold; i++ {
+			cluster.RecordLaunchFailure(nodePool.Name)
+		}
+		fakeClock.Step(state.LaunchBackoff)
+		Expect(cluster.AcquireLaunch(nodePool.Name)).To(BeZero())
+		cluster.RecordLaunchFailure(nodePool.Name)
+		Expect(cluster.LaunchesPausedFor(nodePool.Name)).To(Equal(state.LaunchBackoff * 2))
+	})
+	It("should cap the backoff", func() {
+		for i := 0; i < state.LaunchFailureThreshold; i++ {
+			cluster.RecordLaunchFailure(nodePool.Name)
+		}
+		for i := 0; i < 10; i++ {
+			fakeClock.Step(state.MaxLaunchBackoff)
+			cluster.RecordLaunchFailure(nodePool.Name)
+		}
+		Expect(cluster.LaunchesPausedFor(nodePool.Name)).To(Equal(state.MaxLaunchBackoff))
+	})
+	It("should close the breaker after a successful trial launch", func() {
+		for i := 0; i < state.LaunchFailureThreshold; i++ {
+			cluster.RecordLaunchFailure(nodePool.Name)
+		}
+		fakeClock.Step(state.LaunchBackoff)
+		cluster.RecordLaunchSuccess(nodePool.Name)
+		cluster.RecordLaunchFailure(nodePool.Name)
+		Expect(cluster.LaunchesPausedFor(nodePool.Name)).To(BeZero())
+	})
+})
+
 var _ = Describe("Data Races", func() {
 	It("should ensure that calling Synced() is valid while making updates to Nodes", func() {
 		cancelCtx, cancel := context.WithCancel(ctx)
@@ -1519,6 +1636,93 @@ var _ = Describe("Data Races", func() {
 	})
 })
 
+var _ = Describe("Node Snapshots", func() {
+	var nodes []*v1.Node
+	BeforeEach(func() {
+		nodes = nil
+		for i := 0; i < 3; i++ {
+			node := test.Node(test.NodeOptions{
+				ProviderID: test.RandomProviderID(),
+			})
+			ExpectApplied(ctx, env.Client, node)
+			ExpectReconcileSucceeded(ctx, nodeController, client.ObjectKeyFromObject(node))
+			nodes = append(nodes, node)
+		}
+	})
+	It("should visit every node that was tracked when iteration began", func() {
+		var visited []string
+		cluster.ForEachNodeSnapshot(func(n *state.StateNode) bool {
+			visited = append(visited, n.Node.Name)
+			return true
+		})
+		Expect(visited).To(ConsistOf(lo.Map(nodes, func(n *v1.Node, _ int) string { return n.Name })))
+	})
+	It("should stop iterating when the function returns false", func() {
+		visits := 0
+		cluster.ForEachNodeSnapshot(func(n *state.StateNode) bool {
+			visits++
+			return false
+		})
+		Expect(visits).To(Equal(1))
+	})
+	It("should allow updating the cluster state while iterating", func() {
+		var visited []string
+		cluster.ForEachNodeSnapshot(func(n *state.StateNode) bool {
+			visited = append(visited, n.Node.Name)
+			// Deleting every node would deadlock if the cluster state was locked while iterating
+			for _, node := range nodes {
+				cluster.DeleteNode(node.Name)
+			}
+			return true
+		})
+		// The deletions aren't observed by the snapshot that's being iterated
+		Expect(visited).To(HaveLen(len(nodes)))
+		Expect(cluster.Nodes()).To(BeEmpty())
+	})
+	It("should not reflect changes to the snapshot in the cluster state", func() {
+		cluster.ForEachNodeSnapshot(func(n *state.StateNode) bool {
+			n.Node.Labels = map[string]string{"snapshot": "modified"}
+			return true
+		})
+		for _, n := range cluster.Nodes() {
+			Expect(n.Node.Labels).ToNot(HaveKey("snapshot"))
+		}
+	})
+	It("should iterate a consistent snapshot while the cluster state is concurrently updated", func() {
+		cancelCtx, cancel := context.WithCancel(ctx)
+		DeferCleanup(func() {
+			cancel()
+		})
+		providerID := nodes[0].Spec.ProviderID
+
+		// Keep marking the same node for deletion and unmarking it for the entirety of this test
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for cancelCtx.Err() == nil {
+				cluster.MarkForDeletion(providerID)
+				cluster.UnmarkForDeletion(providerID)
+			}
+		}()
+
+		for i := 0; i < 100; i++ {
+			var visited []*state.StateNode
+			cluster.ForEachNodeSnapshot(func(n *state.StateNode) bool {
+				visited = append(visited, n)
+				return true
+			})
+			Expect(visited).To(HaveLen(len(nodes)))
+			// Reading the snapshot after iterating is safe because the nodes in it are never updated
+			for _, n := range visited {
+				_ = n.MarkedForDeletion()
+				Expect(n.Node.Name).ToNot(BeEmpty())
+			}
+		}
+		cancel()
+		<-done
+	})
+})
+
 var _ = Describe("Taints", func() {
 	var nodeClaim *v1beta1.NodeClaim
 	var node *v1.Node
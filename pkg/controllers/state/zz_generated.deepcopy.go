@@ -113,6 +113,13 @@ func (in *StateNode) DeepCopyInto(out *StateNode) {
 			(*out)[key] = outVal
 		}
 	}
+	if in.dedicatedPods != nil {
+		in, out := &in.dedicatedPods, &out.dedicatedPods
+		*out = make(map[types.NamespacedName]struct{}, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.hostPortUsage != nil {
 		in, out := &in.hostPortUsage, &out.hostPortUsage
 		*out = new(scheduling.HostPortUsage)
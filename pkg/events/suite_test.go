@@ -31,6 +31,7 @@ import (
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	terminatorevents "sigs.k8s.io/karpenter/pkg/controllers/node/termination/terminator/events"
+	lifecycleevents "sigs.k8s.io/karpenter/pkg/controllers/nodeclaim/lifecycle"
 	schedulingevents "sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
 	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/test"
@@ -79,6 +80,7 @@ var _ = BeforeEach(func() {
 	internalRecorder = NewInternalRecorder()
 	eventRecorder = events.NewRecorder(internalRecorder)
 	schedulingevents.PodNominationRateLimiter = flowcontrol.NewTokenBucketRateLimiter(5, 10)
+	lifecycleevents.PodLaunchRateLimiter = flowcontrol.NewTokenBucketRateLimiter(5, 10)
 
 })
 
@@ -87,6 +89,10 @@ var _ = Describe("Event Creation", func() {
 		eventRecorder.Publish(schedulingevents.NominatePodEvent(PodWithUID(), NodeWithUID(), NodeClaimWithUID()))
 		Expect(internalRecorder.Calls(schedulingevents.NominatePodEvent(PodWithUID(), NodeWithUID(), NodeClaimWithUID()).Reason)).To(Equal(1))
 	})
+	It("should create a TriggeredLaunch event", func() {
+		eventRecorder.Publish(lifecycleevents.TriggeredLaunchPodEvent(PodWithUID(), NodeClaimWithUID()))
+		Expect(internalRecorder.Calls(lifecycleevents.TriggeredLaunchPodEvent(PodWithUID(), NodeClaimWithUID()).Reason)).To(Equal(1))
+	})
 	It("should create a EvictPod event", func() {
 		eventRecorder.Publish(terminatorevents.EvictPod(PodWithUID()))
 		Expect(internalRecorder.Calls(terminatorevents.EvictPod(PodWithUID()).Reason)).To(Equal(1))
@@ -149,6 +155,20 @@ var _ = Describe("Rate Limiting", func() {
 		}
 		Expect(internalRecorder.Calls(schedulingevents.NominatePodEvent(PodWithUID(), NodeWithUID(), NodeClaimWithUID()).Reason)).To(Equal(15))
 	})
+	It("should rate limit the TriggeredLaunch events of a large launch", func() {
+		nodeClaim := NodeClaimWithUID()
+		for i := 0; i < 100; i++ {
+			eventRecorder.Publish(lifecycleevents.TriggeredLaunchPodEvent(PodWithUID(), nodeClaim))
+		}
+		Expect(internalRecorder.Calls(lifecycleevents.TriggeredLaunchPodEvent(PodWithUID(), nodeClaim).Reason)).To(Equal(10))
+	})
+	It("should rate limit TriggeredLaunch events separately from Nominated events", func() {
+		for i := 0; i < 100; i++ {
+			eventRecorder.Publish(schedulingevents.NominatePodEvent(PodWithUID(), NodeWithUID(), NodeClaimWithUID()))
+		}
+		eventRecorder.Publish(lifecycleevents.TriggeredLaunchPodEvent(PodWithUID(), NodeClaimWithUID()))
+		Expect(internalRecorder.Calls(lifecycleevents.TriggeredLaunchPodEvent(PodWithUID(), NodeClaimWithUID()).Reason)).To(Equal(1))
+	})
 })
 
 func PodWithUID() *v1.Pod {
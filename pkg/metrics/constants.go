@@ -37,6 +37,8 @@ const (
 	ExpirationReason    = "expiration"
 	EmptinessReason     = "emptiness"
 	DriftReason         = "drift"
+	MaintenanceReason   = "maintenance"
+	UnhealthyReason     = "unhealthy"
 )
 
 // DurationBuckets returns a []float64 of default threshold values for duration histograms.
@@ -24,6 +24,7 @@ import (
 const (
 	NodeSubsystem      = "nodes"
 	nodeClaimSubsystem = "nodeclaims"
+	nodePoolSubsystem  = "nodepool"
 )
 
 var (
@@ -132,10 +133,35 @@ var (
 			NodePoolLabel,
 		},
 	)
+	NodePoolNodesCreatedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: nodePoolSubsystem,
+			Name:      "nodes_created_total",
+			Help:      "Number of nodes that registered with the cluster in total. Labeled by the owning nodepool and capacity type.",
+		},
+		[]string{
+			NodePoolLabel,
+			CapacityTypeLabel,
+		},
+	)
+	NodePoolNodesDeletedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: nodePoolSubsystem,
+			Name:      "nodes_deleted_total",
+			Help:      "Number of nodes that were deleted from the cluster in total. Labeled by the owning nodepool, capacity type and the disruption reason, if the node was disrupted.",
+		},
+		[]string{
+			NodePoolLabel,
+			CapacityTypeLabel,
+			ReasonLabel,
+		},
+	)
 )
 
 func init() {
 	crmetrics.Registry.MustRegister(NodeClaimsCreatedCounter, NodeClaimsTerminatedCounter, NodeClaimsLaunchedCounter,
 		NodeClaimsRegisteredCounter, NodeClaimsInitializedCounter, NodeClaimsDisruptedCounter, NodeClaimsDriftedCounter,
-		NodesCreatedCounter, NodesTerminatedCounter)
+		NodesCreatedCounter, NodesTerminatedCounter, NodePoolNodesCreatedCounter, NodePoolNodesDeletedCounter)
 }
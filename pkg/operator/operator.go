@@ -20,13 +20,13 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"net/http/pprof"
 	"runtime"
 	"runtime/debug"
 	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
 	coordinationv1 "k8s.io/api/coordination/v1"
 	"knative.dev/pkg/changeset"
 	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
@@ -54,12 +54,17 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/controllers/disruption/candidates"
+	"sigs.k8s.io/karpenter/pkg/controllers/node/termination/history"
+	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/snapshot"
 	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/operator/controller"
 	"sigs.k8s.io/karpenter/pkg/operator/injection"
 	"sigs.k8s.io/karpenter/pkg/operator/logging"
 	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/profiling"
 	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	"sigs.k8s.io/karpenter/pkg/operator/tracing"
 	"sigs.k8s.io/karpenter/pkg/webhooks"
 )
 
@@ -135,6 +140,20 @@ func NewOperator() (context.Context, *Operator) {
 
 	knativelogging.FromContext(ctx).With("version", Version).Debugf("discovered karpenter version")
 
+	// Tracing
+	tracerProvider, err := tracing.NewTracerProvider(ctx)
+	lo.Must0(err, "failed to setup tracing")
+	if tracerProvider != nil {
+		otel.SetTracerProvider(tracerProvider)
+		// Flush the spans that are still batched once the operator stops
+		go func() {
+			<-ctx.Done()
+			if err := tracerProvider.Shutdown(context.Background()); err != nil {
+				knativelogging.FromContext(ctx).Errorf("shutting down tracer provider, %s", err)
+			}
+		}()
+	}
+
 	// Manager
 	mgrOpts := controllerruntime.Options{
 		Logger:                        logging.IgnoreDebugEvents(zapr.NewLogger(logger.Desugar())),
@@ -159,37 +178,54 @@ func NewOperator() (context.Context, *Operator) {
 				&coordinationv1.Lease{}: {
 					Field: fields.SelectorFromSet(fields.Set{"metadata.namespace": "kube-node-lease"}),
 				},
+				&v1.ConfigMap{}: {
+					Namespaces: map[string]cache.Config{system.Namespace(): {}},
+				},
 			},
 		},
 	}
-	if options.FromContext(ctx).EnableProfiling {
-		// TODO @joinnis: Investigate the mgrOpts.PprofBindAddress that would allow native support for pprof
-		// On initial look, it seems like this native pprof doesn't support some of the routes that we have here
-		// like "/debug/pprof/heap" or "/debug/pprof/block"
+	if handlers := profiling.MetricsHandlers(ctx); len(handlers) > 0 {
+		logger.Warnf("serving profiling endpoints from the metrics port is deprecated and will be removed in the next minor release, enable profiling on the profiling bind address %s instead", options.FromContext(ctx).ProfilingBindAddress)
+		mgrOpts.Metrics.ExtraHandlers = lo.Assign(mgrOpts.Metrics.ExtraHandlers, handlers)
+	}
+	if options.FromContext(ctx).TerminationHistorySize > 0 {
 		mgrOpts.Metrics.ExtraHandlers = lo.Assign(mgrOpts.Metrics.ExtraHandlers, map[string]http.Handler{
-			"/debug/pprof/":             http.HandlerFunc(pprof.Index),
-			"/debug/pprof/cmdline":      http.HandlerFunc(pprof.Cmdline),
-			"/debug/pprof/profile":      http.HandlerFunc(pprof.Profile),
-			"/debug/pprof/symbol":       http.HandlerFunc(pprof.Symbol),
-			"/debug/pprof/trace":        http.HandlerFunc(pprof.Trace),
-			"/debug/pprof/allocs":       pprof.Handler("allocs"),
-			"/debug/pprof/heap":         pprof.Handler("heap"),
-			"/debug/pprof/block":        pprof.Handler("block"),
-			"/debug/pprof/goroutine":    pprof.Handler("goroutine"),
-			"/debug/pprof/threadcreate": pprof.Handler("threadcreate"),
+			history.Path: history.Terminations,
+		})
+	}
+	if options.FromContext(ctx).EnableClusterStateSnapshot {
+		mgrOpts.Metrics.ExtraHandlers = lo.Assign(mgrOpts.Metrics.ExtraHandlers, map[string]http.Handler{
+			snapshot.Path: snapshot.Snapshots,
+		})
+	}
+	if options.FromContext(ctx).ManualDisruption {
+		mgrOpts.Metrics.ExtraHandlers = lo.Assign(mgrOpts.Metrics.ExtraHandlers, map[string]http.Handler{
+			candidates.Path: candidates.Pending,
 		})
 	}
 	mgr, err := controllerruntime.NewManager(config, mgrOpts)
 	mgr = lo.Must(mgr, err, "failed to setup manager")
+	if server := profiling.NewServer(ctx); server != nil {
+		lo.Must0(mgr.Add(server), "failed to setup profiling server")
+	}
 	lo.Must0(mgr.GetFieldIndexer().IndexField(ctx, &v1.Pod{}, "spec.nodeName", func(o client.Object) []string {
 		return []string{o.(*v1.Pod).Spec.NodeName}
 	}), "failed to setup pod indexer")
+	lo.Must0(mgr.GetFieldIndexer().IndexField(ctx, &v1.Pod{}, "status.phase", func(o client.Object) []string {
+		return []string{string(o.(*v1.Pod).Status.Phase)}
+	}), "failed to setup pod phase indexer")
 	lo.Must0(mgr.GetFieldIndexer().IndexField(ctx, &v1.Node{}, "spec.providerID", func(o client.Object) []string {
 		return []string{o.(*v1.Node).Spec.ProviderID}
 	}), "failed to setup node provider id indexer")
+	lo.Must0(mgr.GetFieldIndexer().IndexField(ctx, &v1.Node{}, "metadata.labels.registered", func(o client.Object) []string {
+		return []string{o.(*v1.Node).Labels[v1beta1.NodeRegisteredLabelKey]}
+	}), "failed to setup node registered indexer")
 	lo.Must0(mgr.GetFieldIndexer().IndexField(ctx, &v1beta1.NodeClaim{}, "status.providerID", func(o client.Object) []string {
 		return []string{o.(*v1beta1.NodeClaim).Status.ProviderID}
 	}), "failed to setup nodeclaim provider id indexer")
+	lo.Must0(mgr.GetFieldIndexer().IndexField(ctx, &v1beta1.NodeClaim{}, "status.nodeName", func(o client.Object) []string {
+		return []string{o.(*v1beta1.NodeClaim).Status.NodeName}
+	}), "failed to setup nodeclaim node name indexer")
 	lo.Must0(mgr.GetFieldIndexer().IndexField(ctx, &v1beta1.NodeClaim{}, "spec.nodeClassRef.apiVersion", func(o client.Object) []string {
 		return []string{o.(*v1beta1.NodeClaim).Spec.NodeClassRef.APIVersion}
 	}), "failed to setup nodeclaim nodeclassref apiversion indexer")
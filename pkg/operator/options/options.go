@@ -18,50 +18,101 @@ package options
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
+	"net"
 	"os"
+	"path"
+	"strings"
 	"time"
 
+	"github.com/robfig/cron/v3"
 	"github.com/samber/lo"
+	"go.uber.org/multierr"
+	v1 "k8s.io/api/core/v1"
 	cliflag "k8s.io/component-base/cli/flag"
 
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/utils/env"
 )
 
 var (
-	validLogLevels = []string{"", "debug", "info", "error"}
+	validLogLevels        = []string{"", "debug", "info", "error"}
+	validTracingExporters = []string{"", "otlp", "stdout"}
 
 	Injectables = []Injectable{&Options{}}
 )
 
+// MaxTerminationHistorySize bounds the number of terminated node records that can be retained in memory
+const MaxTerminationHistorySize = 1000
+
 type optionsKey struct{}
 
 type FeatureGates struct {
 	inputStr string
 
-	Drift                   bool
-	SpotToSpotConsolidation bool
+	Drift                     bool
+	SpotToSpotConsolidation   bool
+	PreferExistingNodes       bool
+	PodRebalancing            bool
+	KeepPreferredAntiAffinity bool
 }
 
 // Options contains all CLI flags / env vars for karpenter-core. It adheres to the options.Injectable interface.
 type Options struct {
-	ServiceName          string
-	DisableWebhook       bool
-	WebhookPort          int
-	MetricsPort          int
-	WebhookMetricsPort   int
-	HealthProbePort      int
-	KubeClientQPS        int
-	KubeClientBurst      int
-	EnableProfiling      bool
-	EnableLeaderElection bool
-	MemoryLimit          int64
-	LogLevel             string
-	BatchMaxDuration     time.Duration
-	BatchIdleDuration    time.Duration
-	FeatureGates         FeatureGates
+	ServiceName                        string
+	DisableWebhook                     bool
+	WebhookPort                        int
+	MetricsPort                        int
+	WebhookMetricsPort                 int
+	HealthProbePort                    int
+	KubeClientQPS                      int
+	KubeClientBurst                    int
+	CloudProviderQPS                   int
+	CloudProviderBurst                 int
+	EnableProfiling                    bool
+	ProfilingBindAddress               string
+	EnableProfilingOnMetricsPort       bool
+	EnableLeaderElection               bool
+	MemoryLimit                        int64
+	LogLevel                           string
+	BatchMaxDuration                   time.Duration
+	BatchIdleDuration                  time.Duration
+	ConsolidationSchedule              string
+	ConsolidationScheduleDuration      time.Duration
+	ConsolidationPodReadyTimeout       time.Duration
+	ConsolidationBatchSize             int
+	MaxConcurrentNodeDrains            int
+	DisruptionTolerationTimeout        time.Duration
+	MaintenanceLeadTime                time.Duration
+	RegistrationBackoffBaseDelay       time.Duration
+	RegistrationBackoffMaxDelay        time.Duration
+	DryRun                             bool
+	TerminationHistorySize             int
+	EnableClusterStateSnapshot         bool
+	EnableProvisionerNameLabel         bool
+	TracingExporter                    string
+	OrphanNodeClaimsOnNodePoolDeletion bool
+	ManualDisruption                   bool
+	// ResourceClassRequirements maps DRA resource class names to the requirements of the nodes that can satisfy
+	// resource claims for them
+	ResourceClassRequirements map[string][]v1.NodeSelectorRequirement
+	resourceClassRequirements string
+	// ExcludedInstanceTypes are the names or glob patterns of the instance types that are never launched, regardless of
+	// what the NodePools allow
+	ExcludedInstanceTypes []string
+	excludedInstanceTypes string
+	// NamespaceInstanceTypes maps namespaces to the names or glob patterns of the instance types that their pods may
+	// cause to be launched. Pods from namespaces that aren't mapped may cause any instance type to be launched.
+	NamespaceInstanceTypes map[string][]string
+	namespaceInstanceTypes string
+	// SchedulerNames are the names of the schedulers whose pods are provisioned for. Pods of every scheduler are
+	// provisioned for when empty.
+	SchedulerNames []string
+	schedulerNames string
+	FeatureGates   FeatureGates
 }
 
 type FlagSet struct {
@@ -90,13 +141,37 @@ func (o *Options) AddFlags(fs *FlagSet) {
 	fs.IntVar(&o.HealthProbePort, "health-probe-port", env.WithDefaultInt("HEALTH_PROBE_PORT", 8081), "The port the health probe endpoint binds to for reporting controller health")
 	fs.IntVar(&o.KubeClientQPS, "kube-client-qps", env.WithDefaultInt("KUBE_CLIENT_QPS", 200), "The smoothed rate of qps to kube-apiserver")
 	fs.IntVar(&o.KubeClientBurst, "kube-client-burst", env.WithDefaultInt("KUBE_CLIENT_BURST", 300), "The maximum allowed burst of queries to the kube-apiserver")
-	fs.BoolVarWithEnv(&o.EnableProfiling, "enable-profiling", "ENABLE_PROFILING", false, "Enable the profiling on the metric endpoint")
+	fs.IntVar(&o.CloudProviderQPS, "cloud-provider-qps", env.WithDefaultInt("CLOUD_PROVIDER_QPS", 0), "The smoothed rate of qps to the cloud provider API. Calls to the cloud provider aren't rate limited when set to 0.")
+	fs.IntVar(&o.CloudProviderBurst, "cloud-provider-burst", env.WithDefaultInt("CLOUD_PROVIDER_BURST", 0), "The maximum allowed burst of queries to the cloud provider API. Must be at least 1 when the cloud provider qps is set.")
+	fs.BoolVarWithEnv(&o.EnableProfiling, "enable-profiling", "ENABLE_PROFILING", false, "Serve the net/http/pprof profiling endpoints from /debug/pprof/ on the profiling bind address. The endpoints are unauthenticated and expose the command line of the controller, so they should only be reachable by trusted clients.")
+	fs.StringVar(&o.ProfilingBindAddress, "profiling-bind-address", env.WithDefaultString("PROFILING_BIND_ADDRESS", "localhost:6060"), "The address the profiling endpoints bind to when profiling is enabled. Binds to localhost by default so that the endpoints are only reachable from within the pod, e.g. through kubectl port-forward.")
+	fs.BoolVarWithEnv(&o.EnableProfilingOnMetricsPort, "enable-profiling-on-metrics-port", "ENABLE_PROFILING_ON_METRICS_PORT", false, "Also serve the net/http/pprof profiling endpoints from /debug/pprof/ on the metrics port, which listens on all interfaces, as they were served before the profiling bind address was introduced. Deprecated: this flag will be removed in the next minor release, use enable-profiling and the profiling bind address instead.")
 	fs.BoolVarWithEnv(&o.EnableLeaderElection, "leader-elect", "LEADER_ELECT", true, "Start leader election client and gain leadership before executing the main loop. Enable this when running replicated components for high availability.")
 	fs.Int64Var(&o.MemoryLimit, "memory-limit", env.WithDefaultInt64("MEMORY_LIMIT", -1), "Memory limit on the container running the controller. The GC soft memory limit is set to 90% of this value.")
 	fs.StringVar(&o.LogLevel, "log-level", env.WithDefaultString("LOG_LEVEL", "info"), "Log verbosity level. Can be one of 'debug', 'info', or 'error'")
 	fs.DurationVar(&o.BatchMaxDuration, "batch-max-duration", env.WithDefaultDuration("BATCH_MAX_DURATION", 10*time.Second), "The maximum length of a batch window. The longer this is, the more pods we can consider for provisioning at one time which usually results in fewer but larger nodes.")
 	fs.DurationVar(&o.BatchIdleDuration, "batch-idle-duration", env.WithDefaultDuration("BATCH_IDLE_DURATION", time.Second), "The maximum amount of time with no new pending pods that if exceeded ends the current batching window. If pods arrive faster than this time, the batching window will be extended up to the maxDuration. If they arrive slower, the pods will be batched separately.")
-	fs.StringVar(&o.FeatureGates.inputStr, "feature-gates", env.WithDefaultString("FEATURE_GATES", "Drift=true,SpotToSpotConsolidation=false"), "Optional features can be enabled / disabled using feature gates. Current options are: Drift,SpotToSpotConsolidation")
+	fs.StringVar(&o.ConsolidationSchedule, "consolidation-schedule", env.WithDefaultString("CONSOLIDATION_SCHEDULE", ""), "A cron schedule in UTC at which a window where consolidation is allowed begins. Consolidation is blocked outside of these windows, while drift and expiration are unaffected. If unset, consolidation is always allowed.")
+	fs.DurationVar(&o.ConsolidationScheduleDuration, "consolidation-schedule-duration", env.WithDefaultDuration("CONSOLIDATION_SCHEDULE_DURATION", 0), "The length of each window where consolidation is allowed, starting at each hit of the consolidation schedule. Required when the consolidation schedule is set.")
+	fs.DurationVar(&o.ConsolidationPodReadyTimeout, "consolidation-pod-ready-timeout", env.WithDefaultDuration("CONSOLIDATION_POD_READY_TIMEOUT", 0), "The maximum amount of time to wait, after a pod is evicted from a consolidated node, for its replacement to become Ready elsewhere before another pod with the same owner is evicted from the node. Pods of consolidated nodes are evicted all at once when set to 0.")
+	fs.IntVar(&o.ConsolidationBatchSize, "consolidation-batch-size", env.WithDefaultInt("CONSOLIDATION_BATCH_SIZE", 1), "The maximum number of single-node consolidation commands that are computed and executed in a single disruption loop, respecting disruption budgets. Commands are only batched together if they don't conflict: they don't share candidates and none of them moves pods onto a node that another one disrupts or moves pods onto.")
+	fs.IntVar(&o.MaxConcurrentNodeDrains, "max-concurrent-node-drains", env.WithDefaultInt("MAX_CONCURRENT_NODE_DRAINS", 0), "The maximum number of terminating nodes that are drained at once. Terminating nodes are tainted right away but wait for a slot before their pods are evicted. Nodes are drained without bound when set to 0.")
+	fs.DurationVar(&o.DisruptionTolerationTimeout, "disruption-toleration-timeout", env.WithDefaultDuration("DISRUPTION_TOLERATION_TIMEOUT", 15*time.Minute), "The maximum amount of time that draining a node waits for the pods that tolerate the karpenter.sh/disruption taint with a tolerationSeconds, counted from when the node started terminating. The pods are evicted once it elapses, even if their tolerationSeconds haven't.")
+	fs.DurationVar(&o.MaintenanceLeadTime, "maintenance-lead-time", env.WithDefaultDuration("MAINTENANCE_LEAD_TIME", 24*time.Hour), "How long before a maintenance event that the cloud provider scheduled for an instance begins that its node is replaced. Maintenance events that begin further in the future don't disrupt the node yet.")
+	fs.DurationVar(&o.RegistrationBackoffBaseDelay, "registration-backoff-base-delay", env.WithDefaultDuration("REGISTRATION_BACKOFF_BASE_DELAY", time.Second), "The delay before checking the instance of a NodeClaim whose node hasn't registered yet with the cloud provider again after a transient error. The delay doubles with each consecutive transient error up to the registration backoff max delay.")
+	fs.DurationVar(&o.RegistrationBackoffMaxDelay, "registration-backoff-max-delay", env.WithDefaultDuration("REGISTRATION_BACKOFF_MAX_DELAY", time.Minute), "The maximum delay before checking the instance of a NodeClaim whose node hasn't registered yet with the cloud provider again after a transient error.")
+	fs.BoolVarWithEnv(&o.DryRun, "dry-run", "DRY_RUN", false, "Compute and report the NodeClaims that provisioning would launch for pending pods without creating them. Disruption is unaffected.")
+	fs.IntVar(&o.TerminationHistorySize, "termination-history-size", env.WithDefaultInt("TERMINATION_HISTORY_SIZE", 0), "The number of recently terminated nodes to keep a record of (disruption reason, lifetime, and pods at termination) for debugging. The records are served as JSON from /debug/terminations on the metrics endpoint. Must be at most 1000. Recording is disabled when set to 0.")
+	fs.BoolVarWithEnv(&o.EnableClusterStateSnapshot, "enable-cluster-state-snapshot", "ENABLE_CLUSTER_STATE_SNAPSHOT", false, "Keep a snapshot of the cluster state (nodes, pods, NodePools and instance types) that provisioning last scheduled against for debugging. The snapshot is served as JSON from /debug/cluster-state on the metrics endpoint and is truncated once it reaches 32MiB.")
+	fs.BoolVarWithEnv(&o.EnableProvisionerNameLabel, "enable-provisioner-name-label", "ENABLE_PROVISIONER_NAME_LABEL", false, "Label nodes with the legacy karpenter.sh/provisioner-name label, set to the name of their NodePool, so that tooling that relies on the label keeps working while migrating from Provisioners to NodePools. The label is removed from nodes again when this is disabled.")
+	fs.StringVar(&o.TracingExporter, "tracing-exporter", env.WithDefaultString("TRACING_EXPORTER", ""), "The exporter that OpenTelemetry traces of the provisioning and disruption loops are sent to. Can be one of 'otlp' or 'stdout'. The otlp exporter is configured through the standard OTEL_EXPORTER_OTLP_* environment variables. Tracing is disabled if unset.")
+	fs.BoolVarWithEnv(&o.OrphanNodeClaimsOnNodePoolDeletion, "orphan-nodeclaims-on-nodepool-deletion", "ORPHAN_NODECLAIMS_ON_NODEPOOL_DELETION", false, "Orphan the NodeClaims of a NodePool when it is deleted, leaving their nodes running, instead of gracefully terminating them. NodeClaims are terminated, respecting node drain and PodDisruptionBudgets, before a deleted NodePool is removed if unset.")
+	fs.BoolVarWithEnv(&o.ManualDisruption, "manual-disruption", "MANUAL_DISRUPTION", false, "Only disrupt the nodes that are approved by annotating them with karpenter.sh/disruption-approved=true, which is removed once they are disrupted, so that disruption can be driven by external orchestration. The decisions that are waiting for approval are served as JSON from /debug/disruption-candidates on the metrics endpoint.")
+	fs.StringVar(&o.resourceClassRequirements, "resource-class-requirements", env.WithDefaultString("RESOURCE_CLASS_REQUIREMENTS", ""), "A JSON object mapping Dynamic Resource Allocation resource class names to the node selector requirements of the nodes that can satisfy resource claims for them, e.g. {\"gpu.example.com\":[{\"key\":\"example.com/instance-family\",\"operator\":\"In\",\"values\":[\"gpu\"]}]}. Pods with required resource claims for an unmapped resource class are not provisioned for.")
+	fs.StringVar(&o.excludedInstanceTypes, "excluded-instance-types", env.WithDefaultString("EXCLUDED_INSTANCE_TYPES", ""), "A comma separated list of instance type names or glob patterns, e.g. m5.*,c5.large, that are excluded from the instance types of every NodePool. Excluded instance types are never launched, even when a NodePool's requirements allow them.")
+	fs.StringVar(&o.namespaceInstanceTypes, "namespace-instance-types", env.WithDefaultString("NAMESPACE_INSTANCE_TYPES", ""), "A JSON object mapping namespaces to the instance type names or glob patterns that their pods may cause to be launched, e.g. {\"team-a\":[\"m5.*\",\"c5.large\"]}. Pods from a mapped namespace that can't be scheduled to an allowed instance type stay pending. Pods from namespaces that aren't mapped may cause any instance type to be launched.")
+	fs.StringVar(&o.schedulerNames, "scheduler-names", env.WithDefaultString("SCHEDULER_NAMES", ""), "A comma separated list of scheduler names, e.g. default-scheduler, whose pending pods are provisioned for. Pods with a different spec.schedulerName are left to their scheduler and don't drive provisioning. Pods of every scheduler are provisioned for if unset.")
+	fs.StringVar(&o.FeatureGates.inputStr, "feature-gates", env.WithDefaultString("FEATURE_GATES", "Drift=true,SpotToSpotConsolidation=false"), "Optional features can be enabled / disabled using feature gates. Current options are: Drift,SpotToSpotConsolidation,PreferExistingNodes,PodRebalancing,KeepPreferredAntiAffinity")
 }
 
 func (o *Options) Parse(fs *FlagSet, args ...string) error {
@@ -110,6 +185,57 @@ func (o *Options) Parse(fs *FlagSet, args ...string) error {
 	if !lo.Contains(validLogLevels, o.LogLevel) {
 		return fmt.Errorf("validating cli flags / env vars, invalid log level %q", o.LogLevel)
 	}
+	if !lo.Contains(validTracingExporters, o.TracingExporter) {
+		return fmt.Errorf("validating cli flags / env vars, invalid tracing exporter %q", o.TracingExporter)
+	}
+	if o.EnableProfiling {
+		if _, _, err := net.SplitHostPort(o.ProfilingBindAddress); err != nil {
+			return fmt.Errorf("validating cli flags / env vars, invalid profiling bind address %q, %w", o.ProfilingBindAddress, err)
+		}
+	}
+	if err := o.validateCloudProviderRateLimit(); err != nil {
+		return fmt.Errorf("validating cli flags / env vars, %w", err)
+	}
+	if err := o.validateConsolidationSchedule(); err != nil {
+		return fmt.Errorf("validating cli flags / env vars, %w", err)
+	}
+	if o.ConsolidationPodReadyTimeout < 0 {
+		return fmt.Errorf("validating cli flags / env vars, consolidation pod ready timeout %s must be non-negative", o.ConsolidationPodReadyTimeout)
+	}
+	if o.ConsolidationBatchSize < 1 {
+		return fmt.Errorf("validating cli flags / env vars, consolidation batch size %d must be at least 1", o.ConsolidationBatchSize)
+	}
+	if o.MaxConcurrentNodeDrains < 0 {
+		return fmt.Errorf("validating cli flags / env vars, max concurrent node drains %d must be non-negative", o.MaxConcurrentNodeDrains)
+	}
+	if o.DisruptionTolerationTimeout < 0 {
+		return fmt.Errorf("validating cli flags / env vars, disruption toleration timeout %s must be non-negative", o.DisruptionTolerationTimeout)
+	}
+	if o.MaintenanceLeadTime < 0 {
+		return fmt.Errorf("validating cli flags / env vars, maintenance lead time %s must be non-negative", o.MaintenanceLeadTime)
+	}
+	if o.RegistrationBackoffBaseDelay <= 0 || o.RegistrationBackoffMaxDelay < o.RegistrationBackoffBaseDelay {
+		return fmt.Errorf("validating cli flags / env vars, registration backoff base delay %s must be positive and at most the max delay %s", o.RegistrationBackoffBaseDelay, o.RegistrationBackoffMaxDelay)
+	}
+	if o.TerminationHistorySize < 0 || o.TerminationHistorySize > MaxTerminationHistorySize {
+		return fmt.Errorf("validating cli flags / env vars, termination history size %d must be between 0 and %d", o.TerminationHistorySize, MaxTerminationHistorySize)
+	}
+	resourceClassRequirements, err := ParseResourceClassRequirements(o.resourceClassRequirements)
+	if err != nil {
+		return fmt.Errorf("parsing resource class requirements, %w", err)
+	}
+	o.ResourceClassRequirements = resourceClassRequirements
+	excludedInstanceTypes, err := ParseExcludedInstanceTypes(o.excludedInstanceTypes)
+	if err != nil {
+		return fmt.Errorf("parsing excluded instance types, %w", err)
+	}
+	o.ExcludedInstanceTypes = excludedInstanceTypes
+	namespaceInstanceTypes, err := ParseNamespaceInstanceTypes(o.namespaceInstanceTypes)
+	if err != nil {
+		return fmt.Errorf("parsing namespace instance types, %w", err)
+	}
+	o.NamespaceInstanceTypes = namespaceInstanceTypes
+	o.SchedulerNames = ParseSchedulerNames(o.schedulerNames)
 	gates, err := ParseFeatureGates(o.FeatureGates.inputStr)
 	if err != nil {
 		return fmt.Errorf("parsing feature gates, %w", err)
@@ -122,14 +248,154 @@ func (o *Options) ToContext(ctx context.Context) context.Context {
 	return ToContext(ctx, o)
 }
 
+// validateCloudProviderRateLimit ensures that the cloud provider qps and burst are non-negative and that a cloud
+// provider with a qps is allowed at least one query at a time
+func (o *Options) validateCloudProviderRateLimit() error {
+	if o.CloudProviderQPS < 0 {
+		return fmt.Errorf("cloud provider qps %d must be non-negative", o.CloudProviderQPS)
+	}
+	if o.CloudProviderBurst < 0 {
+		return fmt.Errorf("cloud provider burst %d must be non-negative", o.CloudProviderBurst)
+	}
+	if o.CloudProviderQPS > 0 && o.CloudProviderBurst < 1 {
+		return fmt.Errorf("cloud provider burst %d must be at least 1 when the cloud provider qps is set", o.CloudProviderBurst)
+	}
+	return nil
+}
+
+func (o *Options) validateConsolidationSchedule() error {
+	if o.ConsolidationSchedule == "" {
+		if o.ConsolidationScheduleDuration != 0 {
+			return fmt.Errorf("consolidation schedule duration requires a consolidation schedule")
+		}
+		return nil
+	}
+	// The schedule is evaluated in UTC, matching NodePool disruption budget schedules
+	if _, err := cron.ParseStandard(fmt.Sprintf("TZ=UTC %s", o.ConsolidationSchedule)); err != nil {
+		return fmt.Errorf("invalid consolidation schedule %q, %w", o.ConsolidationSchedule, err)
+	}
+	if o.ConsolidationScheduleDuration <= 0 {
+		return fmt.Errorf("consolidation schedule duration %s must be positive", o.ConsolidationScheduleDuration)
+	}
+	return nil
+}
+
+// ParseResourceClassRequirements parses a JSON object mapping resource class names to node selector requirements
+func ParseResourceClassRequirements(str string) (map[string][]v1.NodeSelectorRequirement, error) {
+	if str == "" {
+		return nil, nil
+	}
+	resourceClassRequirements := map[string][]v1.NodeSelectorRequirement{}
+	if err := json.Unmarshal([]byte(str), &resourceClassRequirements); err != nil {
+		return nil, err
+	}
+	var errs error
+	for resourceClass, requirements := range resourceClassRequirements {
+		if len(requirements) == 0 {
+			errs = multierr.Append(errs, fmt.Errorf("resource class %q must define at least one requirement", resourceClass))
+		}
+		for _, requirement := range requirements {
+			if err := v1beta1.ValidateRequirement(v1beta1.NodeSelectorRequirementWithMinValues{NodeSelectorRequirement: requirement}); err != nil {
+				errs = multierr.Append(errs, fmt.Errorf("resource class %q, %w", resourceClass, err))
+			}
+		}
+	}
+	if errs != nil {
+		return nil, errs
+	}
+	return resourceClassRequirements, nil
+}
+
+// IsExcludedInstanceType returns true if the instance type name matches any of the excluded instance type patterns
+func (o *Options) IsExcludedInstanceType(name string) bool {
+	return MatchesInstanceType(o.ExcludedInstanceTypes, name)
+}
+
+// MatchesInstanceType returns true if the instance type name matches any of the instance type patterns
+func MatchesInstanceType(patterns []string, name string) bool {
+	return lo.ContainsBy(patterns, func(pattern string) bool {
+		// Patterns are validated when they're parsed
+		matched, _ := path.Match(pattern, name)
+		return matched
+	})
+}
+
+// ParseExcludedInstanceTypes parses a comma separated list of instance type names or glob patterns
+func ParseExcludedInstanceTypes(str string) ([]string, error) {
+	if str == "" {
+		return nil, nil
+	}
+	patterns := lo.Compact(lo.Map(strings.Split(str, ","), func(p string, _ int) string { return strings.TrimSpace(p) }))
+	if err := validateInstanceTypePatterns(patterns); err != nil {
+		return nil, err
+	}
+	return patterns, nil
+}
+
+// ParseNamespaceInstanceTypes parses a JSON object mapping namespaces to instance type names or glob patterns
+func ParseNamespaceInstanceTypes(str string) (map[string][]string, error) {
+	if str == "" {
+		return nil, nil
+	}
+	namespaceInstanceTypes := map[string][]string{}
+	if err := json.Unmarshal([]byte(str), &namespaceInstanceTypes); err != nil {
+		return nil, err
+	}
+	var errs error
+	for namespace, patterns := range namespaceInstanceTypes {
+		if len(patterns) == 0 {
+			errs = multierr.Append(errs, fmt.Errorf("namespace %q must allow at least one instance type", namespace))
+		}
+		if err := validateInstanceTypePatterns(patterns); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("namespace %q, %w", namespace, err))
+		}
+	}
+	if errs != nil {
+		return nil, errs
+	}
+	return namespaceInstanceTypes, nil
+}
+
+func validateInstanceTypePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q, %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// IsManagedScheduler returns true if pods with the scheduler name are provisioned for
+func (o *Options) IsManagedScheduler(name string) bool {
+	if len(o.SchedulerNames) == 0 {
+		return true
+	}
+	// Pods that don't set a scheduler name are scheduled by the default scheduler
+	return lo.Contains(o.SchedulerNames, lo.Ternary(name == "", v1.DefaultSchedulerName, name))
+}
+
+// ParseSchedulerNames parses a comma separated list of scheduler names
+func ParseSchedulerNames(str string) []string {
+	if str == "" {
+		return nil
+	}
+	return lo.Compact(lo.Map(strings.Split(str, ","), func(n string, _ int) string { return strings.TrimSpace(n) }))
+}
+
 func ParseFeatureGates(gateStr string) (FeatureGates, error) {
+	return FeatureGates{}.Override(gateStr)
+}
+
+// Override returns the feature gates with the gates that are set in gateStr, e.g. "SpotToSpotConsolidation=true",
+// overridden. The feature gates are returned unchanged if gateStr can't be parsed.
+func (g FeatureGates) Override(gateStr string) (FeatureGates, error) {
 	gateMap := map[string]bool{}
-	gates := FeatureGates{}
+	gates := g
 
 	// Parses feature gates with the upstream mechanism. This is meant to be used with flag directly but this enables
 	// simple merging with environment vars.
 	if err := cliflag.NewMapStringBool(&gateMap).Set(gateStr); err != nil {
-		return gates, err
+		return g, err
 	}
 	if val, ok := gateMap["Drift"]; ok {
 		gates.Drift = val
@@ -137,10 +403,32 @@ func ParseFeatureGates(gateStr string) (FeatureGates, error) {
 	if val, ok := gateMap["SpotToSpotConsolidation"]; ok {
 		gates.SpotToSpotConsolidation = val
 	}
+	if val, ok := gateMap["PreferExistingNodes"]; ok {
+		gates.PreferExistingNodes = val
+	}
+	if val, ok := gateMap["PodRebalancing"]; ok {
+		gates.PodRebalancing = val
+	}
+	if val, ok := gateMap["KeepPreferredAntiAffinity"]; ok {
+		gates.KeepPreferredAntiAffinity = val
+	}
 
 	return gates, nil
 }
 
+// NodePoolFeatureGates returns the feature gates for the NodePool, so that features can be canaried on a subset of
+// NodePools. The gates in the NodePool's karpenter.sh/feature-gates annotation override the global feature gates. The
+// global feature gates are returned along with an error if the annotation can't be parsed. Only the features that are
+// evaluated per NodePool (Drift, SpotToSpotConsolidation and PodRebalancing) honor the annotation.
+func NodePoolFeatureGates(ctx context.Context, nodePool *v1beta1.NodePool) (FeatureGates, error) {
+	gates := FromContext(ctx).FeatureGates
+	annotation, ok := nodePool.Annotations[v1beta1.FeatureGatesAnnotationKey]
+	if !ok {
+		return gates, nil
+	}
+	return gates.Override(annotation)
+}
+
 func ToContext(ctx context.Context, opts *Options) context.Context {
 	return context.WithValue(ctx, optionsKey{}, opts)
 }
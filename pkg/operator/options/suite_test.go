@@ -26,8 +26,11 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	. "knative.dev/pkg/logging/testing"
 
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
 	"sigs.k8s.io/karpenter/pkg/operator/options"
 	"sigs.k8s.io/karpenter/pkg/test"
 )
@@ -52,12 +55,36 @@ var _ = Describe("Options", func() {
 		"HEALTH_PROBE_PORT",
 		"KUBE_CLIENT_QPS",
 		"KUBE_CLIENT_BURST",
+		"CLOUD_PROVIDER_QPS",
+		"CLOUD_PROVIDER_BURST",
 		"ENABLE_PROFILING",
+		"PROFILING_BIND_ADDRESS",
+		"ENABLE_PROFILING_ON_METRICS_PORT",
 		"LEADER_ELECT",
 		"MEMORY_LIMIT",
 		"LOG_LEVEL",
 		"BATCH_MAX_DURATION",
 		"BATCH_IDLE_DURATION",
+		"CONSOLIDATION_SCHEDULE",
+		"CONSOLIDATION_SCHEDULE_DURATION",
+		"CONSOLIDATION_POD_READY_TIMEOUT",
+		"CONSOLIDATION_BATCH_SIZE",
+		"MAX_CONCURRENT_NODE_DRAINS",
+		"DISRUPTION_TOLERATION_TIMEOUT",
+		"MAINTENANCE_LEAD_TIME",
+		"REGISTRATION_BACKOFF_BASE_DELAY",
+		"REGISTRATION_BACKOFF_MAX_DELAY",
+		"DRY_RUN",
+		"TERMINATION_HISTORY_SIZE",
+		"ENABLE_CLUSTER_STATE_SNAPSHOT",
+		"ENABLE_PROVISIONER_NAME_LABEL",
+		"TRACING_EXPORTER",
+		"ORPHAN_NODECLAIMS_ON_NODEPOOL_DELETION",
+		"MANUAL_DISRUPTION",
+		"RESOURCE_CLASS_REQUIREMENTS",
+		"EXCLUDED_INSTANCE_TYPES",
+		"NAMESPACE_INSTANCE_TYPES",
+		"SCHEDULER_NAMES",
 		"FEATURE_GATES",
 	}
 
@@ -88,6 +115,40 @@ var _ = Describe("Options", func() {
 			Entry("with whitespace", "Drift\t= false", false),
 			Entry("multiple values", "Hello=true,Drift=false,World=true", false),
 		)
+		It("should parse the PreferExistingNodes feature gate", func() {
+			gates, err := options.ParseFeatureGates("Drift=true,PreferExistingNodes=true")
+			Expect(err).To(BeNil())
+			Expect(gates.PreferExistingNodes).To(BeTrue())
+		})
+		It("should parse the PodRebalancing feature gate", func() {
+			gates, err := options.ParseFeatureGates("Drift=true,PodRebalancing=true")
+			Expect(err).To(BeNil())
+			Expect(gates.PodRebalancing).To(BeTrue())
+		})
+		It("should parse the KeepPreferredAntiAffinity feature gate", func() {
+			gates, err := options.ParseFeatureGates("Drift=true,KeepPreferredAntiAffinity=true")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gates.KeepPreferredAntiAffinity).To(BeTrue())
+		})
+		It("should only override the feature gates that are set", func() {
+			gates, err := options.FeatureGates{Drift: true, SpotToSpotConsolidation: true}.Override("SpotToSpotConsolidation=false,PodRebalancing=true")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gates.Drift).To(BeTrue())
+			Expect(gates.SpotToSpotConsolidation).To(BeFalse())
+			Expect(gates.PodRebalancing).To(BeTrue())
+		})
+		DescribeTable(
+			"should resolve the feature gates of a NodePool",
+			func(annotations map[string]string, spotToSpot bool, valid bool) {
+				ctx := options.ToContext(ctx, test.Options(test.OptionsFields{FeatureGates: test.FeatureGates{SpotToSpotConsolidation: lo.ToPtr(true)}}))
+				gates, err := options.NodePoolFeatureGates(ctx, test.NodePool(v1beta1.NodePool{ObjectMeta: metav1.ObjectMeta{Annotations: annotations}}))
+				Expect(err == nil).To(Equal(valid))
+				Expect(gates.SpotToSpotConsolidation).To(Equal(spotToSpot))
+			},
+			Entry("without the annotation", nil, true, true),
+			Entry("overridden by the annotation", map[string]string{v1beta1.FeatureGatesAnnotationKey: "SpotToSpotConsolidation=false"}, false, true),
+			Entry("with a malformed annotation", map[string]string{v1beta1.FeatureGatesAnnotationKey: "SpotToSpotConsolidation"}, true, false),
+		)
 	})
 
 	Context("Parse", func() {
@@ -95,20 +156,37 @@ var _ = Describe("Options", func() {
 			err := opts.Parse(fs)
 			Expect(err).To(BeNil())
 			expectOptionsMatch(opts, test.Options(test.OptionsFields{
-				ServiceName:          lo.ToPtr(""),
-				DisableWebhook:       lo.ToPtr(true),
-				WebhookPort:          lo.ToPtr(8443),
-				MetricsPort:          lo.ToPtr(8000),
-				WebhookMetricsPort:   lo.ToPtr(8001),
-				HealthProbePort:      lo.ToPtr(8081),
-				KubeClientQPS:        lo.ToPtr(200),
-				KubeClientBurst:      lo.ToPtr(300),
-				EnableProfiling:      lo.ToPtr(false),
-				EnableLeaderElection: lo.ToPtr(true),
-				MemoryLimit:          lo.ToPtr[int64](-1),
-				LogLevel:             lo.ToPtr("info"),
-				BatchMaxDuration:     lo.ToPtr(10 * time.Second),
-				BatchIdleDuration:    lo.ToPtr(time.Second),
+				ServiceName:                        lo.ToPtr(""),
+				DisableWebhook:                     lo.ToPtr(true),
+				WebhookPort:                        lo.ToPtr(8443),
+				MetricsPort:                        lo.ToPtr(8000),
+				WebhookMetricsPort:                 lo.ToPtr(8001),
+				HealthProbePort:                    lo.ToPtr(8081),
+				KubeClientQPS:                      lo.ToPtr(200),
+				KubeClientBurst:                    lo.ToPtr(300),
+				CloudProviderQPS:                   lo.ToPtr(0),
+				CloudProviderBurst:                 lo.ToPtr(0),
+				EnableProfiling:                    lo.ToPtr(false),
+				ProfilingBindAddress:               lo.ToPtr("localhost:6060"),
+				EnableProfilingOnMetricsPort:       lo.ToPtr(false),
+				EnableLeaderElection:               lo.ToPtr(true),
+				MemoryLimit:                        lo.ToPtr[int64](-1),
+				LogLevel:                           lo.ToPtr("info"),
+				BatchMaxDuration:                   lo.ToPtr(10 * time.Second),
+				BatchIdleDuration:                  lo.ToPtr(time.Second),
+				ConsolidationPodReadyTimeout:       lo.ToPtr(time.Duration(0)),
+				ConsolidationBatchSize:             lo.ToPtr(1),
+				MaxConcurrentNodeDrains:            lo.ToPtr(0),
+				DisruptionTolerationTimeout:        lo.ToPtr(15 * time.Minute),
+				MaintenanceLeadTime:                lo.ToPtr(24 * time.Hour),
+				RegistrationBackoffBaseDelay:       lo.ToPtr(time.Second),
+				RegistrationBackoffMaxDelay:        lo.ToPtr(time.Minute),
+				DryRun:                             lo.ToPtr(false),
+				TerminationHistorySize:             lo.ToPtr(0),
+				EnableClusterStateSnapshot:         lo.ToPtr(false),
+				EnableProvisionerNameLabel:         lo.ToPtr(false),
+				OrphanNodeClaimsOnNodePoolDeletion: lo.ToPtr(false),
+				ManualDisruption:                   lo.ToPtr(false),
 				FeatureGates: test.FeatureGates{
 					Drift: lo.ToPtr(true),
 				},
@@ -126,30 +204,66 @@ var _ = Describe("Options", func() {
 				"--health-probe-port", "0",
 				"--kube-client-qps", "0",
 				"--kube-client-burst", "0",
+				"--cloud-provider-qps", "10",
+				"--cloud-provider-burst", "20",
 				"--enable-profiling",
+				"--profiling-bind-address", ":6061",
+				"--enable-profiling-on-metrics-port",
 				"--leader-elect=false",
 				"--memory-limit", "0",
 				"--log-level", "debug",
 				"--batch-max-duration", "5s",
 				"--batch-idle-duration", "5s",
+				"--consolidation-pod-ready-timeout", "5m",
+				"--consolidation-batch-size", "5",
+				"--max-concurrent-node-drains", "10",
+				"--disruption-toleration-timeout", "5m",
+				"--maintenance-lead-time", "1h",
+				"--registration-backoff-base-delay", "5s",
+				"--registration-backoff-max-delay", "5m",
+				"--dry-run",
+				"--termination-history-size", "10",
+				"--enable-cluster-state-snapshot",
+				"--enable-provisioner-name-label",
+				"--tracing-exporter", "stdout",
+				"--orphan-nodeclaims-on-nodepool-deletion",
+				"--manual-disruption",
 				"--feature-gates", "Drift=true",
 			)
 			Expect(err).To(BeNil())
 			expectOptionsMatch(opts, test.Options(test.OptionsFields{
-				ServiceName:          lo.ToPtr("cli"),
-				DisableWebhook:       lo.ToPtr(true),
-				WebhookPort:          lo.ToPtr(0),
-				MetricsPort:          lo.ToPtr(0),
-				WebhookMetricsPort:   lo.ToPtr(0),
-				HealthProbePort:      lo.ToPtr(0),
-				KubeClientQPS:        lo.ToPtr(0),
-				KubeClientBurst:      lo.ToPtr(0),
-				EnableProfiling:      lo.ToPtr(true),
-				EnableLeaderElection: lo.ToPtr(false),
-				MemoryLimit:          lo.ToPtr[int64](0),
-				LogLevel:             lo.ToPtr("debug"),
-				BatchMaxDuration:     lo.ToPtr(5 * time.Second),
-				BatchIdleDuration:    lo.ToPtr(5 * time.Second),
+				ServiceName:                        lo.ToPtr("cli"),
+				DisableWebhook:                     lo.ToPtr(true),
+				WebhookPort:                        lo.ToPtr(0),
+				MetricsPort:                        lo.ToPtr(0),
+				WebhookMetricsPort:                 lo.ToPtr(0),
+				HealthProbePort:                    lo.ToPtr(0),
+				KubeClientQPS:                      lo.ToPtr(0),
+				KubeClientBurst:                    lo.ToPtr(0),
+				CloudProviderQPS:                   lo.ToPtr(10),
+				CloudProviderBurst:                 lo.ToPtr(20),
+				EnableProfiling:                    lo.ToPtr(true),
+				ProfilingBindAddress:               lo.ToPtr(":6061"),
+				EnableProfilingOnMetricsPort:       lo.ToPtr(true),
+				EnableLeaderElection:               lo.ToPtr(false),
+				MemoryLimit:                        lo.ToPtr[int64](0),
+				LogLevel:                           lo.ToPtr("debug"),
+				BatchMaxDuration:                   lo.ToPtr(5 * time.Second),
+				BatchIdleDuration:                  lo.ToPtr(5 * time.Second),
+				ConsolidationPodReadyTimeout:       lo.ToPtr(5 * time.Minute),
+				ConsolidationBatchSize:             lo.ToPtr(5),
+				MaxConcurrentNodeDrains:            lo.ToPtr(10),
+				DisruptionTolerationTimeout:        lo.ToPtr(5 * time.Minute),
+				MaintenanceLeadTime:                lo.ToPtr(time.Hour),
+				RegistrationBackoffBaseDelay:       lo.ToPtr(5 * time.Second),
+				RegistrationBackoffMaxDelay:        lo.ToPtr(5 * time.Minute),
+				DryRun:                             lo.ToPtr(true),
+				TerminationHistorySize:             lo.ToPtr(10),
+				EnableClusterStateSnapshot:         lo.ToPtr(true),
+				EnableProvisionerNameLabel:         lo.ToPtr(true),
+				TracingExporter:                    lo.ToPtr("stdout"),
+				OrphanNodeClaimsOnNodePoolDeletion: lo.ToPtr(true),
+				ManualDisruption:                   lo.ToPtr(true),
 				FeatureGates: test.FeatureGates{
 					Drift: lo.ToPtr(true),
 				},
@@ -165,12 +279,30 @@ var _ = Describe("Options", func() {
 			os.Setenv("HEALTH_PROBE_PORT", "0")
 			os.Setenv("KUBE_CLIENT_QPS", "0")
 			os.Setenv("KUBE_CLIENT_BURST", "0")
+			os.Setenv("CLOUD_PROVIDER_QPS", "10")
+			os.Setenv("CLOUD_PROVIDER_BURST", "20")
 			os.Setenv("ENABLE_PROFILING", "true")
+			os.Setenv("PROFILING_BIND_ADDRESS", "0.0.0.0:6061")
+			os.Setenv("ENABLE_PROFILING_ON_METRICS_PORT", "true")
 			os.Setenv("LEADER_ELECT", "false")
 			os.Setenv("MEMORY_LIMIT", "0")
 			os.Setenv("LOG_LEVEL", "debug")
 			os.Setenv("BATCH_MAX_DURATION", "5s")
 			os.Setenv("BATCH_IDLE_DURATION", "5s")
+			os.Setenv("CONSOLIDATION_POD_READY_TIMEOUT", "5m")
+			os.Setenv("CONSOLIDATION_BATCH_SIZE", "5")
+			os.Setenv("MAX_CONCURRENT_NODE_DRAINS", "10")
+			os.Setenv("DISRUPTION_TOLERATION_TIMEOUT", "5m")
+			os.Setenv("MAINTENANCE_LEAD_TIME", "1h")
+			os.Setenv("REGISTRATION_BACKOFF_BASE_DELAY", "5s")
+			os.Setenv("REGISTRATION_BACKOFF_MAX_DELAY", "5m")
+			os.Setenv("DRY_RUN", "true")
+			os.Setenv("TERMINATION_HISTORY_SIZE", "10")
+			os.Setenv("ENABLE_CLUSTER_STATE_SNAPSHOT", "true")
+			os.Setenv("ENABLE_PROVISIONER_NAME_LABEL", "true")
+			os.Setenv("TRACING_EXPORTER", "otlp")
+			os.Setenv("ORPHAN_NODECLAIMS_ON_NODEPOOL_DELETION", "true")
+			os.Setenv("MANUAL_DISRUPTION", "true")
 			os.Setenv("FEATURE_GATES", "Drift=true")
 			fs = &options.FlagSet{
 				FlagSet: flag.NewFlagSet("karpenter", flag.ContinueOnError),
@@ -179,20 +311,38 @@ var _ = Describe("Options", func() {
 			err := opts.Parse(fs)
 			Expect(err).To(BeNil())
 			expectOptionsMatch(opts, test.Options(test.OptionsFields{
-				ServiceName:          lo.ToPtr("env"),
-				DisableWebhook:       lo.ToPtr(true),
-				WebhookPort:          lo.ToPtr(0),
-				MetricsPort:          lo.ToPtr(0),
-				WebhookMetricsPort:   lo.ToPtr(0),
-				HealthProbePort:      lo.ToPtr(0),
-				KubeClientQPS:        lo.ToPtr(0),
-				KubeClientBurst:      lo.ToPtr(0),
-				EnableProfiling:      lo.ToPtr(true),
-				EnableLeaderElection: lo.ToPtr(false),
-				MemoryLimit:          lo.ToPtr[int64](0),
-				LogLevel:             lo.ToPtr("debug"),
-				BatchMaxDuration:     lo.ToPtr(5 * time.Second),
-				BatchIdleDuration:    lo.ToPtr(5 * time.Second),
+				ServiceName:                        lo.ToPtr("env"),
+				DisableWebhook:                     lo.ToPtr(true),
+				WebhookPort:                        lo.ToPtr(0),
+				MetricsPort:                        lo.ToPtr(0),
+				WebhookMetricsPort:                 lo.ToPtr(0),
+				HealthProbePort:                    lo.ToPtr(0),
+				KubeClientQPS:                      lo.ToPtr(0),
+				KubeClientBurst:                    lo.ToPtr(0),
+				CloudProviderQPS:                   lo.ToPtr(10),
+				CloudProviderBurst:                 lo.ToPtr(20),
+				EnableProfiling:                    lo.ToPtr(true),
+				ProfilingBindAddress:               lo.ToPtr("0.0.0.0:6061"),
+				EnableProfilingOnMetricsPort:       lo.ToPtr(true),
+				EnableLeaderElection:               lo.ToPtr(false),
+				MemoryLimit:                        lo.ToPtr[int64](0),
+				LogLevel:                           lo.ToPtr("debug"),
+				BatchMaxDuration:                   lo.ToPtr(5 * time.Second),
+				BatchIdleDuration:                  lo.ToPtr(5 * time.Second),
+				ConsolidationPodReadyTimeout:       lo.ToPtr(5 * time.Minute),
+				ConsolidationBatchSize:             lo.ToPtr(5),
+				MaxConcurrentNodeDrains:            lo.ToPtr(10),
+				DisruptionTolerationTimeout:        lo.ToPtr(5 * time.Minute),
+				MaintenanceLeadTime:                lo.ToPtr(time.Hour),
+				RegistrationBackoffBaseDelay:       lo.ToPtr(5 * time.Second),
+				RegistrationBackoffMaxDelay:        lo.ToPtr(5 * time.Minute),
+				DryRun:                             lo.ToPtr(true),
+				TerminationHistorySize:             lo.ToPtr(10),
+				EnableClusterStateSnapshot:         lo.ToPtr(true),
+				EnableProvisionerNameLabel:         lo.ToPtr(true),
+				TracingExporter:                    lo.ToPtr("otlp"),
+				OrphanNodeClaimsOnNodePoolDeletion: lo.ToPtr(true),
+				ManualDisruption:                   lo.ToPtr(true),
 				FeatureGates: test.FeatureGates{
 					Drift: lo.ToPtr(true),
 				},
@@ -206,12 +356,30 @@ var _ = Describe("Options", func() {
 			os.Setenv("HEALTH_PROBE_PORT", "0")
 			os.Setenv("KUBE_CLIENT_QPS", "0")
 			os.Setenv("KUBE_CLIENT_BURST", "0")
+			os.Setenv("CLOUD_PROVIDER_QPS", "10")
+			os.Setenv("CLOUD_PROVIDER_BURST", "20")
 			os.Setenv("ENABLE_PROFILING", "true")
+			os.Setenv("PROFILING_BIND_ADDRESS", "0.0.0.0:6061")
+			os.Setenv("ENABLE_PROFILING_ON_METRICS_PORT", "true")
 			os.Setenv("LEADER_ELECT", "false")
 			os.Setenv("MEMORY_LIMIT", "0")
 			os.Setenv("LOG_LEVEL", "debug")
 			os.Setenv("BATCH_MAX_DURATION", "5s")
 			os.Setenv("BATCH_IDLE_DURATION", "5s")
+			os.Setenv("CONSOLIDATION_POD_READY_TIMEOUT", "5m")
+			os.Setenv("CONSOLIDATION_BATCH_SIZE", "5")
+			os.Setenv("MAX_CONCURRENT_NODE_DRAINS", "10")
+			os.Setenv("DISRUPTION_TOLERATION_TIMEOUT", "5m")
+			os.Setenv("MAINTENANCE_LEAD_TIME", "1h")
+			os.Setenv("REGISTRATION_BACKOFF_BASE_DELAY", "5s")
+			os.Setenv("REGISTRATION_BACKOFF_MAX_DELAY", "5m")
+			os.Setenv("DRY_RUN", "true")
+			os.Setenv("TERMINATION_HISTORY_SIZE", "10")
+			os.Setenv("ENABLE_CLUSTER_STATE_SNAPSHOT", "true")
+			os.Setenv("ENABLE_PROVISIONER_NAME_LABEL", "true")
+			os.Setenv("TRACING_EXPORTER", "otlp")
+			os.Setenv("ORPHAN_NODECLAIMS_ON_NODEPOOL_DELETION", "true")
+			os.Setenv("MANUAL_DISRUPTION", "true")
 			os.Setenv("FEATURE_GATES", "Drift=true")
 			fs = &options.FlagSet{
 				FlagSet: flag.NewFlagSet("karpenter", flag.ContinueOnError),
@@ -224,20 +392,38 @@ var _ = Describe("Options", func() {
 			)
 			Expect(err).To(BeNil())
 			expectOptionsMatch(opts, test.Options(test.OptionsFields{
-				ServiceName:          lo.ToPtr("cli"),
-				DisableWebhook:       lo.ToPtr(true),
-				WebhookPort:          lo.ToPtr(0),
-				MetricsPort:          lo.ToPtr(0),
-				WebhookMetricsPort:   lo.ToPtr(0),
-				HealthProbePort:      lo.ToPtr(0),
-				KubeClientQPS:        lo.ToPtr(0),
-				KubeClientBurst:      lo.ToPtr(0),
-				EnableProfiling:      lo.ToPtr(true),
-				EnableLeaderElection: lo.ToPtr(false),
-				MemoryLimit:          lo.ToPtr[int64](0),
-				LogLevel:             lo.ToPtr("debug"),
-				BatchMaxDuration:     lo.ToPtr(5 * time.Second),
-				BatchIdleDuration:    lo.ToPtr(5 * time.Second),
+				ServiceName:                        lo.ToPtr("cli"),
+				DisableWebhook:                     lo.ToPtr(true),
+				WebhookPort:                        lo.ToPtr(0),
+				MetricsPort:                        lo.ToPtr(0),
+				WebhookMetricsPort:                 lo.ToPtr(0),
+				HealthProbePort:                    lo.ToPtr(0),
+				KubeClientQPS:                      lo.ToPtr(0),
+				KubeClientBurst:                    lo.ToPtr(0),
+				CloudProviderQPS:                   lo.ToPtr(10),
+				CloudProviderBurst:                 lo.ToPtr(20),
+				EnableProfiling:                    lo.ToPtr(true),
+				ProfilingBindAddress:               lo.ToPtr("0.0.0.0:6061"),
+				EnableProfilingOnMetricsPort:       lo.ToPtr(true),
+				EnableLeaderElection:               lo.ToPtr(false),
+				MemoryLimit:                        lo.ToPtr[int64](0),
+				LogLevel:                           lo.ToPtr("debug"),
+				BatchMaxDuration:                   lo.ToPtr(5 * time.Second),
+				BatchIdleDuration:                  lo.ToPtr(5 * time.Second),
+				ConsolidationPodReadyTimeout:       lo.ToPtr(5 * time.Minute),
+				ConsolidationBatchSize:             lo.ToPtr(5),
+				MaxConcurrentNodeDrains:            lo.ToPtr(10),
+				DisruptionTolerationTimeout:        lo.ToPtr(5 * time.Minute),
+				MaintenanceLeadTime:                lo.ToPtr(time.Hour),
+				RegistrationBackoffBaseDelay:       lo.ToPtr(5 * time.Second),
+				RegistrationBackoffMaxDelay:        lo.ToPtr(5 * time.Minute),
+				DryRun:                             lo.ToPtr(true),
+				TerminationHistorySize:             lo.ToPtr(10),
+				EnableClusterStateSnapshot:         lo.ToPtr(true),
+				EnableProvisionerNameLabel:         lo.ToPtr(true),
+				TracingExporter:                    lo.ToPtr("otlp"),
+				OrphanNodeClaimsOnNodePoolDeletion: lo.ToPtr(true),
+				ManualDisruption:                   lo.ToPtr(true),
 				FeatureGates: test.FeatureGates{
 					Drift: lo.ToPtr(true),
 				},
@@ -274,6 +460,199 @@ var _ = Describe("Options", func() {
 			err := opts.Parse(fs, "--log-level", "hello")
 			Expect(err).ToNot(BeNil())
 		})
+		It("should error with an invalid profiling bind address when profiling is enabled", func() {
+			err := opts.Parse(fs, "--enable-profiling", "--profiling-bind-address", "localhost")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should ignore the profiling bind address when profiling is disabled", func() {
+			err := opts.Parse(fs, "--profiling-bind-address", "localhost")
+			Expect(err).To(BeNil())
+		})
+		It("should error with an invalid tracing exporter", func() {
+			err := opts.Parse(fs, "--tracing-exporter", "jaeger")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should accept batch durations that the batching window ConfigMap would reject", func() {
+			err := opts.Parse(fs, "--batch-max-duration", "1s", "--batch-idle-duration", "10s")
+			Expect(err).To(BeNil())
+			Expect(opts.BatchMaxDuration).To(Equal(time.Second))
+			Expect(opts.BatchIdleDuration).To(Equal(10 * time.Second))
+		})
+		It("should parse a valid consolidation schedule", func() {
+			err := opts.Parse(fs, "--consolidation-schedule", "0 0 * * *", "--consolidation-schedule-duration", "1h")
+			Expect(err).To(BeNil())
+			Expect(opts.ConsolidationSchedule).To(Equal("0 0 * * *"))
+			Expect(opts.ConsolidationScheduleDuration).To(Equal(time.Hour))
+		})
+		DescribeTable(
+			"should error with an invalid consolidation schedule",
+			func(args ...string) {
+				err := opts.Parse(fs, args...)
+				Expect(err).ToNot(BeNil())
+			},
+			Entry("invalid cron", "--consolidation-schedule", "not-a-cron", "--consolidation-schedule-duration", "1h"),
+			Entry("missing duration", "--consolidation-schedule", "0 0 * * *"),
+			Entry("negative duration", "--consolidation-schedule", "0 0 * * *", "--consolidation-schedule-duration", "-1h"),
+			Entry("duration without a schedule", "--consolidation-schedule-duration", "1h"),
+		)
+		DescribeTable(
+			"should error with invalid cloud provider rate limits",
+			func(args ...string) {
+				err := opts.Parse(fs, args...)
+				Expect(err).ToNot(BeNil())
+			},
+			Entry("negative cloud provider qps", "--cloud-provider-qps", "-1"),
+			Entry("negative cloud provider burst", "--cloud-provider-burst", "-1"),
+			Entry("cloud provider qps without burst", "--cloud-provider-qps", "10"),
+		)
+		It("should not validate the kube client rate limits", func() {
+			err := opts.Parse(fs, "--kube-client-qps", "100", "--kube-client-burst", "0")
+			Expect(err).To(BeNil())
+			Expect(opts.KubeClientQPS).To(Equal(100))
+			Expect(opts.KubeClientBurst).To(Equal(0))
+		})
+		It("should configure the kube client and cloud provider rate limits separately", func() {
+			err := opts.Parse(fs, "--kube-client-qps", "50", "--kube-client-burst", "100", "--cloud-provider-qps", "5", "--cloud-provider-burst", "10")
+			Expect(err).To(BeNil())
+			Expect(opts.KubeClientQPS).To(Equal(50))
+			Expect(opts.KubeClientBurst).To(Equal(100))
+			Expect(opts.CloudProviderQPS).To(Equal(5))
+			Expect(opts.CloudProviderBurst).To(Equal(10))
+		})
+		It("should error with a consolidation batch size less than 1", func() {
+			err := opts.Parse(fs, "--consolidation-batch-size", "0")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should error with a negative max concurrent node drains", func() {
+			err := opts.Parse(fs, "--max-concurrent-node-drains", "-1")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should error with a negative disruption toleration timeout", func() {
+			err := opts.Parse(fs, "--disruption-toleration-timeout", "-1s")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should error with a negative maintenance lead time", func() {
+			err := opts.Parse(fs, "--maintenance-lead-time", "-1s")
+			Expect(err).ToNot(BeNil())
+		})
+		DescribeTable(
+			"should error with invalid registration backoff delays",
+			func(args ...string) {
+				err := opts.Parse(fs, args...)
+				Expect(err).ToNot(BeNil())
+			},
+			Entry("negative base delay", "--registration-backoff-base-delay", "-1s"),
+			Entry("zero base delay", "--registration-backoff-base-delay", "0s"),
+			Entry("base delay greater than the max delay", "--registration-backoff-base-delay", "2m", "--registration-backoff-max-delay", "1m"),
+		)
+		It("should error with a negative consolidation pod ready timeout", func() {
+			err := opts.Parse(fs, "--consolidation-pod-ready-timeout", "-1m")
+			Expect(err).ToNot(BeNil())
+		})
+		DescribeTable(
+			"should error with an invalid termination history size",
+			func(size string) {
+				err := opts.Parse(fs, "--termination-history-size", size)
+				Expect(err).ToNot(BeNil())
+			},
+			Entry("negative size", "-1"),
+			Entry("size above the maximum", "1001"),
+		)
+		It("should parse valid resource class requirements", func() {
+			err := opts.Parse(fs, "--resource-class-requirements", `{"gpu.example.com":[{"key":"example.com/instance-family","operator":"In","values":["gpu"]}]}`)
+			Expect(err).To(BeNil())
+			Expect(opts.ResourceClassRequirements).To(HaveKeyWithValue("gpu.example.com", []v1.NodeSelectorRequirement{
+				{Key: "example.com/instance-family", Operator: v1.NodeSelectorOpIn, Values: []string{"gpu"}},
+			}))
+		})
+		DescribeTable(
+			"should error with invalid resource class requirements",
+			func(str string) {
+				err := opts.Parse(fs, "--resource-class-requirements", str)
+				Expect(err).ToNot(BeNil())
+			},
+			Entry("invalid json", "not-json"),
+			Entry("no requirements", `{"gpu.example.com":[]}`),
+			Entry("unsupported operator", `{"gpu.example.com":[{"key":"example.com/instance-family","operator":"Unknown","values":["gpu"]}]}`),
+			Entry("invalid label key", `{"gpu.example.com":[{"key":"invalid key!","operator":"In","values":["gpu"]}]}`),
+		)
+		It("should parse excluded instance types", func() {
+			err := opts.Parse(fs, "--excluded-instance-types", "m5.*, c5.large,,")
+			Expect(err).To(BeNil())
+			Expect(opts.ExcludedInstanceTypes).To(Equal([]string{"m5.*", "c5.large"}))
+			Expect(opts.IsExcludedInstanceType("m5.xlarge")).To(BeTrue())
+			Expect(opts.IsExcludedInstanceType("c5.large")).To(BeTrue())
+			Expect(opts.IsExcludedInstanceType("c5.xlarge")).To(BeFalse())
+		})
+		It("should parse excluded instance types from the environment", func() {
+			os.Setenv("EXCLUDED_INSTANCE_TYPES", "m5.*")
+			fs = &options.FlagSet{
+				FlagSet: flag.NewFlagSet("karpenter", flag.ContinueOnError),
+			}
+			opts.AddFlags(fs)
+			err := opts.Parse(fs)
+			Expect(err).To(BeNil())
+			Expect(opts.ExcludedInstanceTypes).To(Equal([]string{"m5.*"}))
+		})
+		It("should error with an invalid excluded instance type pattern", func() {
+			err := opts.Parse(fs, "--excluded-instance-types", "m5.[")
+			Expect(err).ToNot(BeNil())
+		})
+		It("should parse namespace instance types", func() {
+			err := opts.Parse(fs, "--namespace-instance-types", `{"team-a":["m5.*","c5.large"]}`)
+			Expect(err).To(BeNil())
+			Expect(opts.NamespaceInstanceTypes).To(HaveKeyWithValue("team-a", []string{"m5.*", "c5.large"}))
+		})
+		It("should parse namespace instance types from the environment", func() {
+			os.Setenv("NAMESPACE_INSTANCE_TYPES", `{"team-a":["m5.*"]}`)
+			fs = &options.FlagSet{
+				FlagSet: flag.NewFlagSet("karpenter", flag.ContinueOnError),
+			}
+			opts.AddFlags(fs)
+			err := opts.Parse(fs)
+			Expect(err).To(BeNil())
+			Expect(opts.NamespaceInstanceTypes).To(HaveKeyWithValue("team-a", []string{"m5.*"}))
+		})
+		DescribeTable(
+			"should error with invalid namespace instance types",
+			func(str string) {
+				err := opts.Parse(fs, "--namespace-instance-types", str)
+				Expect(err).ToNot(BeNil())
+			},
+			Entry("invalid json", "not-json"),
+			Entry("no instance types", `{"team-a":[]}`),
+			Entry("invalid pattern", `{"team-a":["m5.["]}`),
+		)
+		It("should manage pods of every scheduler when no scheduler names are set", func() {
+			err := opts.Parse(fs)
+			Expect(err).To(BeNil())
+			Expect(opts.SchedulerNames).To(BeEmpty())
+			Expect(opts.IsManagedScheduler(v1.DefaultSchedulerName)).To(BeTrue())
+			Expect(opts.IsManagedScheduler("custom-scheduler")).To(BeTrue())
+		})
+		It("should parse scheduler names", func() {
+			err := opts.Parse(fs, "--scheduler-names", "default-scheduler, custom-scheduler,,")
+			Expect(err).To(BeNil())
+			Expect(opts.SchedulerNames).To(Equal([]string{"default-scheduler", "custom-scheduler"}))
+			Expect(opts.IsManagedScheduler("custom-scheduler")).To(BeTrue())
+			Expect(opts.IsManagedScheduler("other-scheduler")).To(BeFalse())
+		})
+		It("should treat an unset scheduler name as the default scheduler", func() {
+			err := opts.Parse(fs, "--scheduler-names", "default-scheduler")
+			Expect(err).To(BeNil())
+			Expect(opts.IsManagedScheduler("")).To(BeTrue())
+		})
+		It("should parse scheduler names from the environment", func() {
+			os.Setenv("SCHEDULER_NAMES", "custom-scheduler")
+			fs = &options.FlagSet{
+				FlagSet: flag.NewFlagSet("karpenter", flag.ContinueOnError),
+			}
+			opts.AddFlags(fs)
+			err := opts.Parse(fs)
+			Expect(err).To(BeNil())
+			Expect(opts.SchedulerNames).To(Equal([]string{"custom-scheduler"}))
+			Expect(opts.IsManagedScheduler(v1.DefaultSchedulerName)).To(BeFalse())
+		})
 	})
 })
 
@@ -292,11 +671,34 @@ func expectOptionsMatch(optsA, optsB *options.Options) {
 	Expect(optsA.HealthProbePort).To(Equal(optsB.HealthProbePort))
 	Expect(optsA.KubeClientQPS).To(Equal(optsB.KubeClientQPS))
 	Expect(optsA.KubeClientBurst).To(Equal(optsB.KubeClientBurst))
+	Expect(optsA.CloudProviderQPS).To(Equal(optsB.CloudProviderQPS))
+	Expect(optsA.CloudProviderBurst).To(Equal(optsB.CloudProviderBurst))
 	Expect(optsA.EnableProfiling).To(Equal(optsB.EnableProfiling))
+	Expect(optsA.ProfilingBindAddress).To(Equal(optsB.ProfilingBindAddress))
+	Expect(optsA.EnableProfilingOnMetricsPort).To(Equal(optsB.EnableProfilingOnMetricsPort))
 	Expect(optsA.EnableLeaderElection).To(Equal(optsB.EnableLeaderElection))
 	Expect(optsA.MemoryLimit).To(Equal(optsB.MemoryLimit))
 	Expect(optsA.LogLevel).To(Equal(optsB.LogLevel))
 	Expect(optsA.BatchMaxDuration).To(Equal(optsB.BatchMaxDuration))
 	Expect(optsA.BatchIdleDuration).To(Equal(optsB.BatchIdleDuration))
+	Expect(optsA.ConsolidationSchedule).To(Equal(optsB.ConsolidationSchedule))
+	Expect(optsA.ConsolidationScheduleDuration).To(Equal(optsB.ConsolidationScheduleDuration))
+	Expect(optsA.ConsolidationPodReadyTimeout).To(Equal(optsB.ConsolidationPodReadyTimeout))
+	Expect(optsA.ConsolidationBatchSize).To(Equal(optsB.ConsolidationBatchSize))
+	Expect(optsA.MaxConcurrentNodeDrains).To(Equal(optsB.MaxConcurrentNodeDrains))
+	Expect(optsA.DisruptionTolerationTimeout).To(Equal(optsB.DisruptionTolerationTimeout))
+	Expect(optsA.MaintenanceLeadTime).To(Equal(optsB.MaintenanceLeadTime))
+	Expect(optsA.RegistrationBackoffBaseDelay).To(Equal(optsB.RegistrationBackoffBaseDelay))
+	Expect(optsA.RegistrationBackoffMaxDelay).To(Equal(optsB.RegistrationBackoffMaxDelay))
+	Expect(optsA.DryRun).To(Equal(optsB.DryRun))
+	Expect(optsA.TerminationHistorySize).To(Equal(optsB.TerminationHistorySize))
+	Expect(optsA.EnableClusterStateSnapshot).To(Equal(optsB.EnableClusterStateSnapshot))
+	Expect(optsA.EnableProvisionerNameLabel).To(Equal(optsB.EnableProvisionerNameLabel))
+	Expect(optsA.TracingExporter).To(Equal(optsB.TracingExporter))
+	Expect(optsA.OrphanNodeClaimsOnNodePoolDeletion).To(Equal(optsB.OrphanNodeClaimsOnNodePoolDeletion))
+	Expect(optsA.ManualDisruption).To(Equal(optsB.ManualDisruption))
+	Expect(optsA.ExcludedInstanceTypes).To(Equal(optsB.ExcludedInstanceTypes))
+	Expect(optsA.NamespaceInstanceTypes).To(Equal(optsB.NamespaceInstanceTypes))
+	Expect(optsA.SchedulerNames).To(Equal(optsB.SchedulerNames))
 	Expect(optsA.FeatureGates.Drift).To(Equal(optsB.FeatureGates.Drift))
 }
@@ -0,0 +1,101 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package profiling
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"knative.dev/pkg/logging"
+
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+)
+
+// Path is the path prefix that the pprof endpoints are served from
+const Path = "/debug/pprof/"
+
+// Handler returns the handler that serves the net/http/pprof endpoints. The endpoints expose the command line of the
+// process and allow anyone that can reach them to run CPU profiles and traces, so they must only be served on an
+// address that untrusted clients can't reach.
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	// The index also serves the named profiles, e.g. /debug/pprof/heap and /debug/pprof/goroutine
+	mux.HandleFunc(Path, pprof.Index)
+	mux.HandleFunc(Path+"cmdline", pprof.Cmdline)
+	mux.HandleFunc(Path+"profile", pprof.Profile)
+	mux.HandleFunc(Path+"symbol", pprof.Symbol)
+	mux.HandleFunc(Path+"trace", pprof.Trace)
+	return mux
+}
+
+// MetricsHandlers returns the pprof endpoints to serve from the metrics port, where they were served before the
+// profiling bind address was introduced. The metrics port listens on all interfaces, so they're only served from it
+// when explicitly enabled.
+//
+// Deprecated: The endpoints are served from the metrics port for one more release so that existing tooling can move
+// to the profiling bind address, and will then only be served from the profiling bind address.
+func MetricsHandlers(ctx context.Context) map[string]http.Handler {
+	if !options.FromContext(ctx).EnableProfilingOnMetricsPort {
+		return nil
+	}
+	return map[string]http.Handler{Path: Handler()}
+}
+
+// Server serves the pprof endpoints on the profiling bind address. It's run by the manager on every replica, not only
+// on the leader.
+type Server struct {
+	addr string
+}
+
+// NewServer returns the server for the pprof endpoints, or nil if profiling is disabled
+func NewServer(ctx context.Context) *Server {
+	if !options.FromContext(ctx).EnableProfiling {
+		return nil
+	}
+	return &Server{addr: options.FromContext(ctx).ProfilingBindAddress}
+}
+
+// Start serves the pprof endpoints until the context is cancelled
+func (s *Server) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("listening on %s, %w", s.addr, err)
+	}
+	server := &http.Server{Handler: Handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logging.FromContext(ctx).Errorf("shutting down profiling server, %s", err)
+		}
+	}()
+	logging.FromContext(ctx).With("address", listener.Addr().String()).Infof("serving profiling endpoints")
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serving profiling endpoints, %w", err)
+	}
+	return nil
+}
+
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
@@ -0,0 +1,148 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package profiling_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	. "knative.dev/pkg/logging/testing"
+
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/profiling"
+	"sigs.k8s.io/karpenter/pkg/test"
+)
+
+var ctx context.Context
+
+func TestProfiling(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Profiling")
+}
+
+var _ = Describe("Profiling", func() {
+	Context("Handler", func() {
+		var server *httptest.Server
+		BeforeEach(func() {
+			server = httptest.NewServer(profiling.Handler())
+		})
+		AfterEach(func() {
+			server.Close()
+		})
+		DescribeTable("should serve the pprof endpoints",
+			func(path string) {
+				resp, err := http.Get(server.URL + path)
+				Expect(err).ToNot(HaveOccurred())
+				defer resp.Body.Close()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			},
+			Entry("index", "/debug/pprof/"),
+			Entry("cmdline", "/debug/pprof/cmdline"),
+			Entry("symbol", "/debug/pprof/symbol"),
+			Entry("heap", "/debug/pprof/heap"),
+			Entry("goroutine", "/debug/pprof/goroutine"),
+			Entry("allocs", "/debug/pprof/allocs"),
+		)
+		It("should not serve anything else", func() {
+			resp, err := http.Get(server.URL + "/metrics")
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+		})
+	})
+	Context("Metrics Handlers", func() {
+		It("should not serve the pprof endpoints from the metrics port by default", func() {
+			Expect(profiling.MetricsHandlers(options.ToContext(ctx, test.Options()))).To(BeEmpty())
+		})
+		It("should not serve the pprof endpoints from the metrics port if only profiling is enabled", func() {
+			Expect(profiling.MetricsHandlers(options.ToContext(ctx, test.Options(test.OptionsFields{EnableProfiling: lo.ToPtr(true)})))).To(BeEmpty())
+		})
+		It("should serve the pprof endpoints from the metrics port if serving them from the metrics port is enabled", func() {
+			handlers := profiling.MetricsHandlers(options.ToContext(ctx, test.Options(test.OptionsFields{EnableProfilingOnMetricsPort: lo.ToPtr(true)})))
+			Expect(handlers).To(HaveKey(profiling.Path))
+
+			mux := http.NewServeMux()
+			for path, handler := range handlers {
+				mux.Handle(path, handler)
+			}
+			server := httptest.NewServer(mux)
+			defer server.Close()
+			resp, err := http.Get(server.URL + "/debug/pprof/heap")
+			Expect(err).ToNot(HaveOccurred())
+			defer resp.Body.Close()
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		})
+	})
+	Context("Server", func() {
+		It("should not create a server if profiling is disabled", func() {
+			Expect(profiling.NewServer(options.ToContext(ctx, test.Options()))).To(BeNil())
+		})
+		It("should serve the pprof endpoints on the profiling bind address if profiling is enabled", func() {
+			addr := freeAddress()
+			server := profiling.NewServer(options.ToContext(ctx, test.Options(test.OptionsFields{
+				EnableProfiling:      lo.ToPtr(true),
+				ProfilingBindAddress: lo.ToPtr(addr),
+			})))
+			Expect(server).ToNot(BeNil())
+			Expect(server.NeedLeaderElection()).To(BeFalse())
+
+			serverCtx, cancel := context.WithCancel(ctx)
+			done := make(chan error)
+			go func() { done <- server.Start(serverCtx) }()
+
+			Eventually(func(g Gomega) {
+				resp, err := http.Get(fmt.Sprintf("http://%s/debug/pprof/heap", addr))
+				g.Expect(err).ToNot(HaveOccurred())
+				defer resp.Body.Close()
+				g.Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			}).Should(Succeed())
+
+			// the server stops serving once the context is cancelled
+			cancel()
+			Eventually(done).Should(Receive(BeNil()))
+			_, err := http.Get(fmt.Sprintf("http://%s/debug/pprof/heap", addr))
+			Expect(err).To(HaveOccurred())
+		})
+		It("should fail to start on an address that's in use", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+			defer listener.Close()
+			server := profiling.NewServer(options.ToContext(ctx, test.Options(test.OptionsFields{
+				EnableProfiling:      lo.ToPtr(true),
+				ProfilingBindAddress: lo.ToPtr(listener.Addr().String()),
+			})))
+			Expect(server.Start(ctx)).ToNot(Succeed())
+		})
+	})
+})
+
+// freeAddress returns a localhost address with a port that isn't in use
+func freeAddress() string {
+	GinkgoHelper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+	defer listener.Close()
+	return listener.Addr().String()
+}
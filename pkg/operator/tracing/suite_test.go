@@ -0,0 +1,93 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	. "knative.dev/pkg/logging/testing"
+
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+	"sigs.k8s.io/karpenter/pkg/operator/tracing"
+	"sigs.k8s.io/karpenter/pkg/test"
+)
+
+var ctx context.Context
+
+func TestTracing(t *testing.T) {
+	ctx = TestContextWithLogger(t)
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Tracing")
+}
+
+var _ = Describe("Tracing", func() {
+	Context("NewTracerProvider", func() {
+		It("should not create a tracer provider if tracing is disabled", func() {
+			tracerProvider, err := tracing.NewTracerProvider(options.ToContext(ctx, test.Options()))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tracerProvider).To(BeNil())
+		})
+		DescribeTable("should create a tracer provider for the tracing exporter",
+			func(exporter string) {
+				tracerProvider, err := tracing.NewTracerProvider(options.ToContext(ctx, test.Options(test.OptionsFields{TracingExporter: lo.ToPtr(exporter)})))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(tracerProvider).ToNot(BeNil())
+				Expect(tracerProvider.Shutdown(ctx)).To(Succeed())
+			},
+			Entry("otlp", tracing.ExporterOTLP),
+			Entry("stdout", tracing.ExporterStdout),
+		)
+		It("should fail for an unsupported tracing exporter", func() {
+			_, err := tracing.NewTracerProvider(options.ToContext(ctx, test.Options(test.OptionsFields{TracingExporter: lo.ToPtr("jaeger")})))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Context("End", func() {
+		var spanRecorder *tracetest.SpanRecorder
+		BeforeEach(func() {
+			spanRecorder = tracetest.NewSpanRecorder()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+		})
+		AfterEach(func() {
+			otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		})
+		It("should end the span", func() {
+			_, span := tracing.Tracer().Start(ctx, "test")
+			tracing.End(span, nil)
+			Expect(spanRecorder.Ended()).To(HaveLen(1))
+			Expect(spanRecorder.Ended()[0].Name()).To(Equal("test"))
+			Expect(spanRecorder.Ended()[0].Status().Code).To(Equal(codes.Unset))
+		})
+		It("should record the error on the span", func() {
+			_, span := tracing.Tracer().Start(ctx, "test")
+			tracing.End(span, errors.New("failed"))
+			Expect(spanRecorder.Ended()).To(HaveLen(1))
+			Expect(spanRecorder.Ended()[0].Status().Code).To(Equal(codes.Error))
+			Expect(spanRecorder.Ended()[0].Status().Description).To(Equal("failed"))
+		})
+	})
+})
@@ -0,0 +1,94 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"sigs.k8s.io/karpenter/pkg/operator/options"
+)
+
+const (
+	TracerName  = "sigs.k8s.io/karpenter"
+	ServiceName = "karpenter"
+
+	// ExporterOTLP exports spans over OTLP/HTTP. The exporter is configured through the standard OTEL_EXPORTER_OTLP_*
+	// environment variables, e.g. OTEL_EXPORTER_OTLP_ENDPOINT.
+	ExporterOTLP = "otlp"
+	// ExporterStdout writes spans to stdout, which is useful for local debugging
+	ExporterStdout = "stdout"
+)
+
+// Span attributes that are set by the provisioning and disruption loops
+const (
+	PodsKey       = attribute.Key("karpenter.pods")
+	NodesKey      = attribute.Key("karpenter.nodes")
+	NodeClaimsKey = attribute.Key("karpenter.nodeclaims")
+	NodePoolKey   = attribute.Key("karpenter.nodepool")
+	CandidatesKey = attribute.Key("karpenter.candidates")
+	MethodKey     = attribute.Key("karpenter.disruption.method")
+	DecisionKey   = attribute.Key("karpenter.disruption.decision")
+)
+
+// Tracer returns the tracer that Karpenter's control loops create spans with. Spans are dropped unless a tracer
+// provider was registered, which only happens when a tracing exporter is configured.
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// NewTracerProvider returns a tracer provider that batches spans to the configured tracing exporter, or nil if
+// tracing is disabled
+func NewTracerProvider(ctx context.Context) (*sdktrace.TracerProvider, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch options.FromContext(ctx).TracingExporter {
+	case "":
+		return nil, nil
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New()
+	default:
+		return nil, fmt.Errorf("unsupported tracing exporter %q", options.FromContext(ctx).TracingExporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating %s exporter, %w", options.FromContext(ctx).TracingExporter, err)
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", ServiceName))),
+	), nil
+}
+
+// End records the error on the span, if any, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
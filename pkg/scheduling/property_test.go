@@ -0,0 +1,76 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scheduling_test
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	"sigs.k8s.io/karpenter/pkg/test/property"
+)
+
+var _ = Describe("Properties", func() {
+	It("should allow the same label values as Kubernetes node selectors", func() {
+		property.ExpectProperty(1000, func(g *property.Generator) error {
+			key := g.Key()
+			requirement := g.NodeSelectorRequirement(key)
+			value := g.Value(key)
+			matches, err := property.Matches(map[string]string{key: value}, requirement)
+			if err != nil {
+				return err
+			}
+			if has := scheduling.NewRequirement(requirement.Key, requirement.Operator, requirement.Values...).Has(value); has != matches {
+				return fmt.Errorf("requirement %s allows %q is %t, expected %t", requirement, value, has, matches)
+			}
+			return nil
+		})
+	})
+	It("should only allow label values in the intersection of requirements that both requirements allow", func() {
+		property.ExpectProperty(1000, func(g *property.Generator) error {
+			key := g.Key()
+			lhs, rhs := g.NodeSelectorRequirement(key), g.NodeSelectorRequirement(key)
+			value := g.Value(key)
+			matches, err := property.Matches(map[string]string{key: value}, lhs, rhs)
+			if err != nil {
+				return err
+			}
+			intersection := scheduling.NewRequirement(lhs.Key, lhs.Operator, lhs.Values...).Intersection(scheduling.NewRequirement(rhs.Key, rhs.Operator, rhs.Values...))
+			if has := intersection.Has(value); has != matches {
+				return fmt.Errorf("intersection of %s and %s allows %q is %t, expected %t", lhs, rhs, value, has, matches)
+			}
+			return nil
+		})
+	})
+	It("should consider requirements compatible with labels that satisfy them", func() {
+		property.ExpectProperty(1000, func(g *property.Generator) error {
+			requirements := g.NodeSelectorRequirements()
+			labels := g.Labels()
+			matches, err := property.Matches(labels, requirements...)
+			if err != nil {
+				return err
+			}
+			if matches {
+				if err := scheduling.NewLabelRequirements(labels).Compatible(scheduling.NewNodeSelectorRequirements(requirements...)); err != nil {
+					return fmt.Errorf("labels %v satisfy requirements %v but aren't compatible, %w", labels, requirements, err)
+				}
+			}
+			return nil
+		})
+	})
+})
@@ -826,6 +826,12 @@ var _ = Describe("Requirement", func() {
 			Entry(nil, greaterThan9, "9", BeFalse()),
 			Entry(nil, lessThan1, "9", BeFalse()),
 			Entry(nil, lessThan9, "9", BeFalse()),
+
+			// Gt and Lt compare values numerically rather than lexicographically
+			Entry(nil, greaterThan1, "10", BeTrue()),
+			Entry(nil, greaterThan9, "10", BeTrue()),
+			Entry(nil, lessThan1, "10", BeFalse()),
+			Entry(nil, lessThan9, "10", BeFalse()),
 		)
 	})
 	Context("Operator", func() {
@@ -176,12 +176,45 @@ func (r Requirements) Compatible(requirements Requirements, options ...functiona
 		if operator := requirements.Get(key).Operator(); r.Has(key) || operator == v1.NodeSelectorOpNotIn || operator == v1.NodeSelectorOpDoesNotExist {
 			continue
 		}
-		errs = multierr.Append(errs, fmt.Errorf("label %q does not have known values%s", key, labelHint(r, key, opts.AllowUndefined)))
+		errs = multierr.Append(errs, undefinedKeyError{
+			key:      key,
+			incoming: requirements.Get(key),
+			hint:     labelHint(r, key, opts.AllowUndefined),
+		})
 	}
 	// Well Known Labels must intersect, but if not defined, are allowed.
 	return multierr.Append(errs, r.Intersects(requirements))
 }
 
+// Incompatibility describes a key of the incoming requirements that can't be met by the existing requirements
+type Incompatibility struct {
+	// Key is the label key that the requirements conflict on
+	Key string
+	// Existing is the requirement that the incoming requirement was checked against, nil if the key isn't defined
+	Existing *Requirement
+	// Incoming is the requirement that can't be met
+	Incoming *Requirement
+	// Reason is a human-readable explanation of the conflict
+	Reason string
+}
+
+// Incompatibilities returns the keys of the incoming requirements that can't be met by the existing requirements,
+// ordered by key. It follows the same semantics as Requirements.Compatible, so an empty result means that the
+// requirements are compatible.
+func Incompatibilities(existing, incoming Requirements, options ...functional.Option[CompatibilityOptions]) []Incompatibility {
+	incompatibilities := lo.FilterMap(multierr.Errors(existing.Compatible(incoming, options...)), func(err error, _ int) (Incompatibility, bool) {
+		switch e := err.(type) {
+		case badKeyError:
+			return Incompatibility{Key: e.key, Existing: e.existing, Incoming: e.incoming, Reason: e.Error()}, true
+		case undefinedKeyError:
+			return Incompatibility{Key: e.key, Incoming: e.incoming, Reason: e.Error()}, true
+		}
+		return Incompatibility{}, false
+	})
+	sort.Slice(incompatibilities, func(i, j int) bool { return incompatibilities[i].Key < incompatibilities[j].Key })
+	return incompatibilities
+}
+
 // editDistance is an implementation of edit distance from Algorithms/DPV
 func editDistance(s, t string) int {
 	min := func(a, b, c int) int {
@@ -258,6 +291,17 @@ func (b badKeyError) Error() string {
 	return fmt.Sprintf("key %s, %s not in %s", b.key, b.incoming, b.existing)
 }
 
+// undefinedKeyError is returned when a custom label is required but the existing requirements don't define it
+type undefinedKeyError struct {
+	key      string
+	incoming *Requirement
+	hint     string
+}
+
+func (u undefinedKeyError) Error() string {
+	return fmt.Sprintf("label %q does not have known values%s", u.key, u.hint)
+}
+
 // intersectKeys is much faster and allocates less han getting the two key sets separately and intersecting them
 func (r Requirements) intersectKeys(rhs Requirements) sets.Set[string] {
 	smallest := r
@@ -541,6 +541,84 @@ var _ = Describe("Requirements", func() {
 			Expect(lessThan9.Compatible(lessThan9)).To(Succeed())
 		})
 	})
+	Context("Incompatibilities", func() {
+		It("should return no incompatibilities for compatible requirements", func() {
+			existing := NewRequirements(NewRequirement("key", v1.NodeSelectorOpIn, "A", "B"))
+			incoming := NewRequirements(NewRequirement("key", v1.NodeSelectorOpIn, "B"))
+			Expect(Incompatibilities(existing, incoming)).To(BeEmpty())
+		})
+		It("should report In conflicting with NotIn", func() {
+			existing := NewRequirements(NewRequirement("key", v1.NodeSelectorOpIn, "A"))
+			incoming := NewRequirements(NewRequirement("key", v1.NodeSelectorOpNotIn, "A"))
+			incompatibilities := Incompatibilities(existing, incoming)
+			Expect(incompatibilities).To(HaveLen(1))
+			Expect(incompatibilities[0].Key).To(Equal("key"))
+			Expect(incompatibilities[0].Existing.Operator()).To(Equal(v1.NodeSelectorOpIn))
+			Expect(incompatibilities[0].Existing.Values()).To(ConsistOf("A"))
+			Expect(incompatibilities[0].Incoming.Operator()).To(Equal(v1.NodeSelectorOpNotIn))
+			Expect(incompatibilities[0].Incoming.Values()).To(ConsistOf("A"))
+			Expect(incompatibilities[0].Reason).To(Equal("key key, key NotIn [A] not in key In [A]"))
+		})
+		It("should report In conflicting with In on disjoint values", func() {
+			existing := NewRequirements(NewRequirement("key", v1.NodeSelectorOpIn, "A"))
+			incoming := NewRequirements(NewRequirement("key", v1.NodeSelectorOpIn, "B", "C"))
+			incompatibilities := Incompatibilities(existing, incoming)
+			Expect(incompatibilities).To(HaveLen(1))
+			Expect(incompatibilities[0].Key).To(Equal("key"))
+			Expect(incompatibilities[0].Existing.Values()).To(ConsistOf("A"))
+			Expect(incompatibilities[0].Incoming.Values()).To(ConsistOf("B", "C"))
+		})
+		It("should report Exists conflicting with DoesNotExist", func() {
+			existing := NewRequirements(NewRequirement("key", v1.NodeSelectorOpExists))
+			incoming := NewRequirements(NewRequirement("key", v1.NodeSelectorOpDoesNotExist))
+			incompatibilities := Incompatibilities(existing, incoming)
+			Expect(incompatibilities).To(HaveLen(1))
+			Expect(incompatibilities[0].Key).To(Equal("key"))
+			Expect(incompatibilities[0].Existing.Operator()).To(Equal(v1.NodeSelectorOpExists))
+			Expect(incompatibilities[0].Incoming.Operator()).To(Equal(v1.NodeSelectorOpDoesNotExist))
+		})
+		It("should report DoesNotExist conflicting with Exists", func() {
+			existing := NewRequirements(NewRequirement("key", v1.NodeSelectorOpDoesNotExist))
+			incoming := NewRequirements(NewRequirement("key", v1.NodeSelectorOpExists))
+			incompatibilities := Incompatibilities(existing, incoming)
+			Expect(incompatibilities).To(HaveLen(1))
+			Expect(incompatibilities[0].Key).To(Equal("key"))
+			Expect(incompatibilities[0].Existing.Operator()).To(Equal(v1.NodeSelectorOpDoesNotExist))
+			Expect(incompatibilities[0].Incoming.Operator()).To(Equal(v1.NodeSelectorOpExists))
+		})
+		It("should not report NotIn against DoesNotExist", func() {
+			existing := NewRequirements(NewRequirement("key", v1.NodeSelectorOpDoesNotExist))
+			incoming := NewRequirements(NewRequirement("key", v1.NodeSelectorOpNotIn, "A"))
+			Expect(Incompatibilities(existing, incoming)).To(BeEmpty())
+		})
+		It("should report custom labels that aren't defined by the existing requirements", func() {
+			incompatibilities := Incompatibilities(NewRequirements(), NewRequirements(NewRequirement("key", v1.NodeSelectorOpIn, "A")))
+			Expect(incompatibilities).To(HaveLen(1))
+			Expect(incompatibilities[0].Key).To(Equal("key"))
+			Expect(incompatibilities[0].Existing).To(BeNil())
+			Expect(incompatibilities[0].Incoming.Values()).To(ConsistOf("A"))
+			Expect(incompatibilities[0].Reason).To(Equal(`label "key" does not have known values`))
+		})
+		It("should allow undefined well known labels with the compatibility options", func() {
+			incoming := NewRequirements(NewRequirement(v1.LabelTopologyZone, v1.NodeSelectorOpIn, "test-zone-1"))
+			Expect(Incompatibilities(NewRequirements(), incoming)).To(HaveLen(1))
+			Expect(Incompatibilities(NewRequirements(), incoming, AllowUndefinedWellKnownLabels)).To(BeEmpty())
+		})
+		It("should report every conflicting key ordered by key", func() {
+			existing := NewRequirements(
+				NewRequirement("b", v1.NodeSelectorOpIn, "A"),
+				NewRequirement("c", v1.NodeSelectorOpIn, "A"),
+				NewRequirement("d", v1.NodeSelectorOpExists),
+			)
+			incoming := NewRequirements(
+				NewRequirement("a", v1.NodeSelectorOpExists),
+				NewRequirement("b", v1.NodeSelectorOpNotIn, "A"),
+				NewRequirement("c", v1.NodeSelectorOpIn, "A"),
+				NewRequirement("d", v1.NodeSelectorOpDoesNotExist),
+			)
+			Expect(lo.Map(Incompatibilities(existing, incoming), func(i Incompatibility, _ int) string { return i.Key })).To(Equal([]string{"a", "b", "d"}))
+		})
+	})
 	Context("Error Messages", func() {
 		DescribeTable("should detect well known label truncations", func(badLabel, expectedError string) {
 			unconstrained := NewRequirements()
@@ -0,0 +1,82 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/avast/retry-go"
+	"go.uber.org/multierr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultBatchConcurrency is the number of objects that CreateInBatches creates at once if it isn't given a concurrency
+const DefaultBatchConcurrency = 50
+
+// batchRetryOptions retry the creates that fail with a transient error, backing off so that a throttling apiserver
+// isn't overwhelmed by the retries
+var batchRetryOptions = []retry.Option{
+	retry.Attempts(5),
+	retry.Delay(time.Millisecond * 50),
+	retry.DelayType(retry.BackOffDelay),
+	retry.LastErrorOnly(true),
+	retry.RetryIf(isTransientError),
+}
+
+// CreateInBatches creates the objects against the client, e.g. the Environment's client, with at most concurrency
+// creates in flight at once. This is much faster than creating the objects one-by-one when a test needs thousands of
+// them. Creates that fail with a transient error are retried, and objects that already exist are read back instead of
+// being created. The objects are updated in place with the apiserver's response and returned in the order they were
+// passed in, alongside the errors of the objects that couldn't be created.
+func CreateInBatches[T client.Object](ctx context.Context, c client.Client, concurrency int, objects ...T) ([]T, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	errs := make([]error, len(objects))
+	workqueue.ParallelizeUntil(ctx, concurrency, len(objects), func(i int) {
+		errs[i] = createOrGet(ctx, c, objects[i])
+	})
+	if err := ctx.Err(); err != nil {
+		return objects, fmt.Errorf("creating objects, %w", err)
+	}
+	return objects, multierr.Combine(errs...)
+}
+
+func createOrGet(ctx context.Context, c client.Client, object client.Object) error {
+	err := retry.Do(func() error {
+		if err := c.Create(ctx, object); err != nil {
+			if errors.IsAlreadyExists(err) {
+				return c.Get(ctx, client.ObjectKeyFromObject(object), object)
+			}
+			return err
+		}
+		return nil
+	}, append(batchRetryOptions, retry.Context(ctx))...)
+	if err != nil {
+		return fmt.Errorf("creating %s, %w", client.ObjectKeyFromObject(object), err)
+	}
+	return nil
+}
+
+func isTransientError(err error) bool {
+	return errors.IsConflict(err) || errors.IsTooManyRequests(err) || errors.IsServerTimeout(err) ||
+		errors.IsTimeout(err) || errors.IsServiceUnavailable(err) || errors.IsInternalError(err)
+}
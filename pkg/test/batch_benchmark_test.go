@@ -0,0 +1,74 @@
+//go:build test_performance
+
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"sigs.k8s.io/karpenter/pkg/operator/scheme"
+	"sigs.k8s.io/karpenter/pkg/test"
+)
+
+var env *test.Environment
+
+// To run the benchmarks use:
+// `go test -tags=test_performance -run=XXX -bench=Create`
+//
+// BenchmarkCreateSerially creates the pods one at a time, like ExpectApplied does, while BenchmarkCreateInBatches
+// creates them with the default concurrency, so comparing the two shows the speedup for large integration tests.
+
+func BenchmarkCreateSerially100(b *testing.B) {
+	benchmarkCreate(b, 100, 1)
+}
+func BenchmarkCreateSerially1000(b *testing.B) {
+	benchmarkCreate(b, 1000, 1)
+}
+func BenchmarkCreateInBatches100(b *testing.B) {
+	benchmarkCreate(b, 100, test.DefaultBatchConcurrency)
+}
+func BenchmarkCreateInBatches1000(b *testing.B) {
+	benchmarkCreate(b, 1000, test.DefaultBatchConcurrency)
+}
+
+func TestMain(m *testing.M) {
+	env = test.NewEnvironment(scheme.Scheme)
+	code := m.Run()
+	lo.Must0(env.Stop())
+	os.Exit(code)
+}
+
+func benchmarkCreate(b *testing.B, podCount int, concurrency int) {
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pods := test.Pods(podCount, test.PodOptions{})
+		if _, err := test.CreateInBatches(ctx, env.Client, concurrency, pods...); err != nil {
+			b.Fatalf("creating pods, %s", err)
+		}
+		b.StopTimer()
+		lo.Must0(env.Client.DeleteAllOf(ctx, &v1.Pod{}, client.InNamespace(pods[0].Namespace)))
+		b.StartTimer()
+	}
+}
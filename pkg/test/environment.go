@@ -100,6 +100,13 @@ func NewEnvironment(scheme *runtime.Scheme, options ...functional.Option[Environ
 		// Ref: https://github.com/aws/karpenter-core/pull/330
 		environment.ControlPlane.GetAPIServer().Configure().Set("feature-gates", "MinDomainsInPodTopologySpread=true")
 	}
+	if version.Minor() >= 27 {
+		// DynamicResourceAllocation lets pods request devices through resource claims. If the feature-gate is turned off,
+		// the api-server clears out the resource claims of pods so we never see them. The resource.k8s.io/v1alpha2 API
+		// that serves resource claims is disabled by default and must be turned on alongside it.
+		environment.ControlPlane.GetAPIServer().Configure().Set("feature-gates", "MinDomainsInPodTopologySpread=true,DynamicResourceAllocation=true")
+		environment.ControlPlane.GetAPIServer().Configure().Set("runtime-config", "resource.k8s.io/v1alpha2=true")
+	}
 
 	_ = lo.Must(environment.Start())
 
@@ -114,6 +121,15 @@ func NewEnvironment(scheme *runtime.Scheme, options ...functional.Option[Environ
 			pod := o.(*corev1.Pod)
 			return []string{pod.Spec.NodeName}
 		}))
+		lo.Must0(cache.IndexField(ctx, &corev1.Pod{}, "status.phase", func(o client.Object) []string {
+			return []string{string(o.(*corev1.Pod).Status.Phase)}
+		}))
+		lo.Must0(cache.IndexField(ctx, &corev1.Node{}, "metadata.labels.registered", func(o client.Object) []string {
+			return []string{o.(*corev1.Node).Labels[v1beta1.NodeRegisteredLabelKey]}
+		}))
+		lo.Must0(cache.IndexField(ctx, &v1beta1.NodeClaim{}, "status.nodeName", func(o client.Object) []string {
+			return []string{o.(*v1beta1.NodeClaim).Status.NodeName}
+		}))
 		c = &CacheSyncingClient{
 			Client: lo.Must(client.New(environment.Config, client.Options{Scheme: scheme, Cache: &client.CacheOptions{Reader: cache}})),
 		}
@@ -17,9 +17,11 @@ limitations under the License.
 package test
 
 import (
+	"reflect"
 	"sync"
 
 	"github.com/samber/lo"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"sigs.k8s.io/karpenter/pkg/events"
 )
@@ -96,3 +98,14 @@ func (e *EventRecorder) DetectedEvent(msg string) bool {
 	})
 	return foundEvent
 }
+
+// EventsFor returns the captured events with the reason that involve the object, matching on its type and name
+func (e *EventRecorder) EventsFor(obj client.Object, reason string) []events.Event {
+	return lo.Filter(e.Events(), func(evt events.Event, _ int) bool {
+		involved, ok := evt.InvolvedObject.(client.Object)
+		if !ok || evt.Reason != reason || reflect.TypeOf(involved) != reflect.TypeOf(obj) {
+			return false
+		}
+		return client.ObjectKeyFromObject(involved) == client.ObjectKeyFromObject(obj)
+	})
+}
@@ -54,6 +54,7 @@ import (
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning"
 	"sigs.k8s.io/karpenter/pkg/controllers/provisioning/scheduling"
 	"sigs.k8s.io/karpenter/pkg/controllers/state"
+	"sigs.k8s.io/karpenter/pkg/events"
 	"sigs.k8s.io/karpenter/pkg/metrics"
 	"sigs.k8s.io/karpenter/pkg/operator/controller"
 	"sigs.k8s.io/karpenter/pkg/operator/scheme"
@@ -188,7 +189,7 @@ func ExpectCleanedUp(ctx context.Context, c client.Client) {
 	wg := sync.WaitGroup{}
 	namespaces := &v1.NamespaceList{}
 	Expect(c.List(ctx, namespaces)).To(Succeed())
-	ExpectFinalizersRemovedFromList(ctx, c, &v1.NodeList{}, &v1beta1.NodeClaimList{}, &v1.PersistentVolumeClaimList{})
+	ExpectFinalizersRemovedFromList(ctx, c, &v1.NodeList{}, &v1beta1.NodeClaimList{}, &v1beta1.NodePoolList{}, &v1.PersistentVolumeClaimList{})
 	for _, object := range []client.Object{
 		&v1.Pod{},
 		&v1.Node{},
@@ -453,6 +454,20 @@ func ExpectStatusConditionExists(obj apis.ConditionsAccessor, t apis.ConditionTy
 	return cond
 }
 
+// ExpectEvent expects that the recorder captured an event with the reason for the object and returns the most recent one
+func ExpectEvent(recorder *test.EventRecorder, obj client.Object, reason string) events.Event {
+	GinkgoHelper()
+	evts := recorder.EventsFor(obj, reason)
+	Expect(evts).ToNot(BeEmpty(), fmt.Sprintf("expected a %q event for %s", reason, client.ObjectKeyFromObject(obj)))
+	return evts[len(evts)-1]
+}
+
+// ExpectNoEvent expects that the recorder didn't capture any event with the reason for the object
+func ExpectNoEvent(recorder *test.EventRecorder, obj client.Object, reason string) {
+	GinkgoHelper()
+	Expect(recorder.EventsFor(obj, reason)).To(BeEmpty(), fmt.Sprintf("expected no %q event for %s", reason, client.ObjectKeyFromObject(obj)))
+}
+
 func ExpectOwnerReferenceExists(obj, owner client.Object) metav1.OwnerReference {
 	or, found := lo.Find(obj.GetOwnerReferences(), func(o metav1.OwnerReference) bool {
 		return o.UID == owner.GetUID()
@@ -465,27 +480,7 @@ func ExpectOwnerReferenceExists(obj, owner client.Object) metav1.OwnerReference
 // If no metric is found, the *prometheus.Metric will be nil
 func FindMetricWithLabelValues(name string, labelValues map[string]string) (*prometheus.Metric, bool) {
 	GinkgoHelper()
-	metrics, err := crmetrics.Registry.Gather()
-	Expect(err).To(BeNil())
-
-	mf, found := lo.Find(metrics, func(mf *prometheus.MetricFamily) bool {
-		return mf.GetName() == name
-	})
-	if !found {
-		return nil, false
-	}
-	for _, m := range mf.Metric {
-		temp := lo.Assign(labelValues)
-		for _, labelPair := range m.Label {
-			if v, ok := temp[labelPair.GetName()]; ok && v == labelPair.GetValue() {
-				delete(temp, labelPair.GetName())
-			}
-		}
-		if len(temp) == 0 {
-			return m, true
-		}
-	}
-	return nil, false
+	return test.FindMetricWithLabelValues(crmetrics.Registry, name, labelValues)
 }
 
 func ExpectMetricGaugeValue(metricName string, expectedValue float64, labels map[string]string) {
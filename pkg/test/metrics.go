@@ -0,0 +1,101 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	. "github.com/onsi/ginkgo/v2" //nolint:revive,stylecheck
+	. "github.com/onsi/gomega"    //nolint:revive,stylecheck
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/samber/lo"
+)
+
+// MetricsRegistry is an isolated metrics registry that can be injected into controllers in place of the global
+// controller-runtime registry. Metrics registered against it don't leak between tests, so tests that assert on
+// emitted metrics can run in parallel.
+type MetricsRegistry struct {
+	*prometheus.Registry
+}
+
+func NewMetricsRegistry() *MetricsRegistry {
+	return &MetricsRegistry{Registry: prometheus.NewRegistry()}
+}
+
+// FindMetricWithLabelValues attempts to find a metric with a name with a set of label values
+// If no metric is found, the *dto.Metric will be nil
+func (r *MetricsRegistry) FindMetricWithLabelValues(name string, labelValues map[string]string) (*dto.Metric, bool) {
+	GinkgoHelper()
+	return FindMetricWithLabelValues(r, name, labelValues)
+}
+
+func (r *MetricsRegistry) ExpectMetricGaugeValue(name string, labels map[string]string, expectedValue float64) {
+	GinkgoHelper()
+	metric := r.expectMetric(name, labels)
+	Expect(metric.GetGauge().GetValue()).To(Equal(expectedValue), "Metric "+name+" should have the expected value")
+}
+
+func (r *MetricsRegistry) ExpectMetricCounterValue(name string, labels map[string]string, expectedValue float64) {
+	GinkgoHelper()
+	metric := r.expectMetric(name, labels)
+	Expect(metric.GetCounter().GetValue()).To(Equal(expectedValue), "Metric "+name+" should have the expected value")
+}
+
+func (r *MetricsRegistry) ExpectMetricHistogramCount(name string, labels map[string]string, expectedCount uint64) {
+	GinkgoHelper()
+	metric := r.expectMetric(name, labels)
+	Expect(metric.GetHistogram().GetSampleCount()).To(Equal(expectedCount), "Metric "+name+" should have the expected sample count")
+}
+
+func (r *MetricsRegistry) ExpectMetricSummaryCount(name string, labels map[string]string, expectedCount uint64) {
+	GinkgoHelper()
+	metric := r.expectMetric(name, labels)
+	Expect(metric.GetSummary().GetSampleCount()).To(Equal(expectedCount), "Metric "+name+" should have the expected sample count")
+}
+
+func (r *MetricsRegistry) expectMetric(name string, labels map[string]string) *dto.Metric {
+	GinkgoHelper()
+	metric, ok := r.FindMetricWithLabelValues(name, labels)
+	Expect(ok).To(BeTrue(), "Metric "+name+" should be available")
+	return metric
+}
+
+// FindMetricWithLabelValues attempts to find a metric gathered from the gatherer with a name with a set of label values
+// If no metric is found, the *dto.Metric will be nil
+func FindMetricWithLabelValues(gatherer prometheus.Gatherer, name string, labelValues map[string]string) (*dto.Metric, bool) {
+	GinkgoHelper()
+	metrics, err := gatherer.Gather()
+	Expect(err).To(BeNil())
+
+	mf, found := lo.Find(metrics, func(mf *dto.MetricFamily) bool {
+		return mf.GetName() == name
+	})
+	if !found {
+		return nil, false
+	}
+	for _, m := range mf.Metric {
+		temp := lo.Assign(labelValues)
+		for _, labelPair := range m.Label {
+			if v, ok := temp[labelPair.GetName()]; ok && v == labelPair.GetValue() {
+				delete(temp, labelPair.GetName())
+			}
+		}
+		if len(temp) == 0 {
+			return m, true
+		}
+	}
+	return nil, false
+}
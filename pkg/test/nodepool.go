@@ -18,6 +18,7 @@ package test
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/imdario/mergo"
 	"github.com/samber/lo"
@@ -26,6 +27,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/utils/functional"
 )
 
 // NodePool creates a test NodePool with defaults that can be overridden by overrides.
@@ -60,6 +62,107 @@ func NodePool(overrides ...v1beta1.NodePool) *v1beta1.NodePool {
 	return np
 }
 
+// DefaultNodePool creates a test NodePool that passes validation, with requirements on the architecture, operating
+// system and capacity type and with the disruption settings that NodePools are defaulted to. Options are applied in
+// order, with a last write wins semantic.
+func DefaultNodePool(options ...functional.Option[v1beta1.NodePool]) *v1beta1.NodePool {
+	np := NodePool(v1beta1.NodePool{
+		Spec: v1beta1.NodePoolSpec{
+			Template: v1beta1.NodeClaimTemplate{
+				Spec: v1beta1.NodeClaimSpec{
+					Requirements: []v1beta1.NodeSelectorRequirementWithMinValues{
+						{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelArchStable, Operator: v1.NodeSelectorOpIn, Values: []string{v1beta1.ArchitectureAmd64}}},
+						{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1.LabelOSStable, Operator: v1.NodeSelectorOpIn, Values: []string{string(v1.Linux)}}},
+						{NodeSelectorRequirement: v1.NodeSelectorRequirement{Key: v1beta1.CapacityTypeLabelKey, Operator: v1.NodeSelectorOpIn, Values: []string{v1beta1.CapacityTypeOnDemand}}},
+					},
+				},
+			},
+			Disruption: v1beta1.Disruption{
+				ConsolidationPolicy: v1beta1.ConsolidationPolicyWhenUnderutilized,
+				ExpireAfter:         v1beta1.NillableDuration{Duration: lo.ToPtr(720 * time.Hour)},
+				Budgets:             []v1beta1.Budget{{Nodes: "10%"}},
+			},
+		},
+	})
+	for _, opt := range options {
+		*np = opt(*np)
+	}
+	return np
+}
+
+// WithNodePoolRequirements replaces the requirements of the NodePool with the same keys as the passed in requirements
+func WithNodePoolRequirements(reqs ...v1beta1.NodeSelectorRequirementWithMinValues) functional.Option[v1beta1.NodePool] {
+	return func(np v1beta1.NodePool) v1beta1.NodePool {
+		return *ReplaceRequirements(&np, reqs...)
+	}
+}
+
+// WithNodePoolLabels adds the labels to the NodeClaim template of the NodePool
+func WithNodePoolLabels(labels map[string]string) functional.Option[v1beta1.NodePool] {
+	return func(np v1beta1.NodePool) v1beta1.NodePool {
+		np.Spec.Template.Labels = lo.Assign(np.Spec.Template.Labels, labels)
+		return np
+	}
+}
+
+// WithNodePoolTaints sets the taints of the NodeClaim template of the NodePool
+func WithNodePoolTaints(taints ...v1.Taint) functional.Option[v1beta1.NodePool] {
+	return func(np v1beta1.NodePool) v1beta1.NodePool {
+		np.Spec.Template.Spec.Taints = taints
+		return np
+	}
+}
+
+// WithNodePoolStartupTaints sets the startup taints of the NodeClaim template of the NodePool
+func WithNodePoolStartupTaints(taints ...v1.Taint) functional.Option[v1beta1.NodePool] {
+	return func(np v1beta1.NodePool) v1beta1.NodePool {
+		np.Spec.Template.Spec.StartupTaints = taints
+		return np
+	}
+}
+
+// WithNodePoolConsolidationPolicy sets the consolidation policy of the NodePool. ConsolidateAfter is only set for the
+// WhenEmpty policy, which requires it.
+func WithNodePoolConsolidationPolicy(policy v1beta1.ConsolidationPolicy, consolidateAfter time.Duration) functional.Option[v1beta1.NodePool] {
+	return func(np v1beta1.NodePool) v1beta1.NodePool {
+		np.Spec.Disruption.ConsolidationPolicy = policy
+		np.Spec.Disruption.ConsolidateAfter = lo.Ternary(policy == v1beta1.ConsolidationPolicyWhenEmpty, &v1beta1.NillableDuration{Duration: lo.ToPtr(consolidateAfter)}, nil)
+		return np
+	}
+}
+
+// WithNodePoolExpireAfter sets the expireAfter of the NodePool. Nodes never expire when it's nil.
+func WithNodePoolExpireAfter(expireAfter *time.Duration) functional.Option[v1beta1.NodePool] {
+	return func(np v1beta1.NodePool) v1beta1.NodePool {
+		np.Spec.Disruption.ExpireAfter = v1beta1.NillableDuration{Duration: expireAfter}
+		return np
+	}
+}
+
+// WithNodePoolBudgets sets the disruption budgets of the NodePool
+func WithNodePoolBudgets(budgets ...v1beta1.Budget) functional.Option[v1beta1.NodePool] {
+	return func(np v1beta1.NodePool) v1beta1.NodePool {
+		np.Spec.Disruption.Budgets = budgets
+		return np
+	}
+}
+
+// WithNodePoolLimits sets the limits of the NodePool
+func WithNodePoolLimits(limits v1.ResourceList) functional.Option[v1beta1.NodePool] {
+	return func(np v1beta1.NodePool) v1beta1.NodePool {
+		np.Spec.Limits = v1beta1.Limits(limits)
+		return np
+	}
+}
+
+// WithNodePoolWeight sets the weight of the NodePool
+func WithNodePoolWeight(weight int32) functional.Option[v1beta1.NodePool] {
+	return func(np v1beta1.NodePool) v1beta1.NodePool {
+		np.Spec.Weight = lo.ToPtr(weight)
+		return np
+	}
+}
+
 // NodePools creates homogeneous groups of NodePools
 // based on the passed in options, evenly divided by the total NodePools requested
 func NodePools(total int, options ...v1beta1.NodePool) []*v1beta1.NodePool {
@@ -22,32 +22,60 @@ import (
 
 	"github.com/imdario/mergo"
 	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
 
 	"sigs.k8s.io/karpenter/pkg/operator/options"
 )
 
 type OptionsFields struct {
 	// Vendor Neutral
-	ServiceName          *string
-	DisableWebhook       *bool
-	WebhookPort          *int
-	MetricsPort          *int
-	WebhookMetricsPort   *int
-	HealthProbePort      *int
-	KubeClientQPS        *int
-	KubeClientBurst      *int
-	EnableProfiling      *bool
-	EnableLeaderElection *bool
-	MemoryLimit          *int64
-	LogLevel             *string
-	BatchMaxDuration     *time.Duration
-	BatchIdleDuration    *time.Duration
-	FeatureGates         FeatureGates
+	ServiceName                        *string
+	DisableWebhook                     *bool
+	WebhookPort                        *int
+	MetricsPort                        *int
+	WebhookMetricsPort                 *int
+	HealthProbePort                    *int
+	KubeClientQPS                      *int
+	KubeClientBurst                    *int
+	CloudProviderQPS                   *int
+	CloudProviderBurst                 *int
+	EnableProfiling                    *bool
+	ProfilingBindAddress               *string
+	EnableProfilingOnMetricsPort       *bool
+	EnableLeaderElection               *bool
+	MemoryLimit                        *int64
+	LogLevel                           *string
+	BatchMaxDuration                   *time.Duration
+	BatchIdleDuration                  *time.Duration
+	ConsolidationSchedule              *string
+	ConsolidationScheduleDuration      *time.Duration
+	ConsolidationPodReadyTimeout       *time.Duration
+	ConsolidationBatchSize             *int
+	MaxConcurrentNodeDrains            *int
+	DisruptionTolerationTimeout        *time.Duration
+	MaintenanceLeadTime                *time.Duration
+	RegistrationBackoffBaseDelay       *time.Duration
+	RegistrationBackoffMaxDelay        *time.Duration
+	DryRun                             *bool
+	TerminationHistorySize             *int
+	EnableClusterStateSnapshot         *bool
+	EnableProvisionerNameLabel         *bool
+	TracingExporter                    *string
+	OrphanNodeClaimsOnNodePoolDeletion *bool
+	ManualDisruption                   *bool
+	ResourceClassRequirements          map[string][]v1.NodeSelectorRequirement
+	ExcludedInstanceTypes              []string
+	NamespaceInstanceTypes             map[string][]string
+	SchedulerNames                     []string
+	FeatureGates                       FeatureGates
 }
 
 type FeatureGates struct {
-	Drift                   *bool
-	SpotToSpotConsolidation *bool
+	Drift                     *bool
+	SpotToSpotConsolidation   *bool
+	PreferExistingNodes       *bool
+	PodRebalancing            *bool
+	KeepPreferredAntiAffinity *bool
 }
 
 func Options(overrides ...OptionsFields) *options.Options {
@@ -59,23 +87,50 @@ func Options(overrides ...OptionsFields) *options.Options {
 	}
 
 	return &options.Options{
-		ServiceName:          lo.FromPtrOr(opts.ServiceName, ""),
-		DisableWebhook:       lo.FromPtrOr(opts.DisableWebhook, false),
-		WebhookPort:          lo.FromPtrOr(opts.WebhookPort, 8443),
-		MetricsPort:          lo.FromPtrOr(opts.MetricsPort, 8000),
-		WebhookMetricsPort:   lo.FromPtrOr(opts.WebhookMetricsPort, 8001),
-		HealthProbePort:      lo.FromPtrOr(opts.HealthProbePort, 8081),
-		KubeClientQPS:        lo.FromPtrOr(opts.KubeClientQPS, 200),
-		KubeClientBurst:      lo.FromPtrOr(opts.KubeClientBurst, 300),
-		EnableProfiling:      lo.FromPtrOr(opts.EnableProfiling, false),
-		EnableLeaderElection: lo.FromPtrOr(opts.EnableLeaderElection, true),
-		MemoryLimit:          lo.FromPtrOr(opts.MemoryLimit, -1),
-		LogLevel:             lo.FromPtrOr(opts.LogLevel, ""),
-		BatchMaxDuration:     lo.FromPtrOr(opts.BatchMaxDuration, 10*time.Second),
-		BatchIdleDuration:    lo.FromPtrOr(opts.BatchIdleDuration, time.Second),
+		ServiceName:                        lo.FromPtrOr(opts.ServiceName, ""),
+		DisableWebhook:                     lo.FromPtrOr(opts.DisableWebhook, false),
+		WebhookPort:                        lo.FromPtrOr(opts.WebhookPort, 8443),
+		MetricsPort:                        lo.FromPtrOr(opts.MetricsPort, 8000),
+		WebhookMetricsPort:                 lo.FromPtrOr(opts.WebhookMetricsPort, 8001),
+		HealthProbePort:                    lo.FromPtrOr(opts.HealthProbePort, 8081),
+		KubeClientQPS:                      lo.FromPtrOr(opts.KubeClientQPS, 200),
+		KubeClientBurst:                    lo.FromPtrOr(opts.KubeClientBurst, 300),
+		CloudProviderQPS:                   lo.FromPtrOr(opts.CloudProviderQPS, 0),
+		CloudProviderBurst:                 lo.FromPtrOr(opts.CloudProviderBurst, 0),
+		EnableProfiling:                    lo.FromPtrOr(opts.EnableProfiling, false),
+		ProfilingBindAddress:               lo.FromPtrOr(opts.ProfilingBindAddress, "localhost:6060"),
+		EnableProfilingOnMetricsPort:       lo.FromPtrOr(opts.EnableProfilingOnMetricsPort, false),
+		EnableLeaderElection:               lo.FromPtrOr(opts.EnableLeaderElection, true),
+		MemoryLimit:                        lo.FromPtrOr(opts.MemoryLimit, -1),
+		LogLevel:                           lo.FromPtrOr(opts.LogLevel, ""),
+		BatchMaxDuration:                   lo.FromPtrOr(opts.BatchMaxDuration, 10*time.Second),
+		BatchIdleDuration:                  lo.FromPtrOr(opts.BatchIdleDuration, time.Second),
+		ConsolidationSchedule:              lo.FromPtrOr(opts.ConsolidationSchedule, ""),
+		ConsolidationScheduleDuration:      lo.FromPtrOr(opts.ConsolidationScheduleDuration, 0),
+		ConsolidationPodReadyTimeout:       lo.FromPtrOr(opts.ConsolidationPodReadyTimeout, 0),
+		ConsolidationBatchSize:             lo.FromPtrOr(opts.ConsolidationBatchSize, 1),
+		MaxConcurrentNodeDrains:            lo.FromPtrOr(opts.MaxConcurrentNodeDrains, 0),
+		DisruptionTolerationTimeout:        lo.FromPtrOr(opts.DisruptionTolerationTimeout, 15*time.Minute),
+		MaintenanceLeadTime:                lo.FromPtrOr(opts.MaintenanceLeadTime, 24*time.Hour),
+		RegistrationBackoffBaseDelay:       lo.FromPtrOr(opts.RegistrationBackoffBaseDelay, time.Second),
+		RegistrationBackoffMaxDelay:        lo.FromPtrOr(opts.RegistrationBackoffMaxDelay, time.Minute),
+		DryRun:                             lo.FromPtrOr(opts.DryRun, false),
+		TerminationHistorySize:             lo.FromPtrOr(opts.TerminationHistorySize, 0),
+		EnableClusterStateSnapshot:         lo.FromPtrOr(opts.EnableClusterStateSnapshot, false),
+		EnableProvisionerNameLabel:         lo.FromPtrOr(opts.EnableProvisionerNameLabel, false),
+		TracingExporter:                    lo.FromPtrOr(opts.TracingExporter, ""),
+		OrphanNodeClaimsOnNodePoolDeletion: lo.FromPtrOr(opts.OrphanNodeClaimsOnNodePoolDeletion, false),
+		ManualDisruption:                   lo.FromPtrOr(opts.ManualDisruption, false),
+		ResourceClassRequirements:          opts.ResourceClassRequirements,
+		ExcludedInstanceTypes:              opts.ExcludedInstanceTypes,
+		NamespaceInstanceTypes:             opts.NamespaceInstanceTypes,
+		SchedulerNames:                     opts.SchedulerNames,
 		FeatureGates: options.FeatureGates{
-			Drift:                   lo.FromPtrOr(opts.FeatureGates.Drift, false),
-			SpotToSpotConsolidation: lo.FromPtrOr(opts.FeatureGates.SpotToSpotConsolidation, false),
+			Drift:                     lo.FromPtrOr(opts.FeatureGates.Drift, false),
+			SpotToSpotConsolidation:   lo.FromPtrOr(opts.FeatureGates.SpotToSpotConsolidation, false),
+			PreferExistingNodes:       lo.FromPtrOr(opts.FeatureGates.PreferExistingNodes, false),
+			PodRebalancing:            lo.FromPtrOr(opts.FeatureGates.PodRebalancing, false),
+			KeepPreferredAntiAffinity: lo.FromPtrOr(opts.FeatureGates.KeepPreferredAntiAffinity, false),
 		},
 	}
 }
@@ -221,6 +221,27 @@ func UnschedulablePods(options PodOptions, num int) []*v1.Pod {
 	return pods
 }
 
+// TopologySpreadConstraint creates a topology spread constraint that spreads the pods matching the labels across the
+// domains of the topology key.
+func TopologySpreadConstraint(topologyKey string, labels map[string]string, maxSkew int32, whenUnsatisfiable v1.UnsatisfiableConstraintAction) v1.TopologySpreadConstraint {
+	return v1.TopologySpreadConstraint{
+		TopologyKey:       topologyKey,
+		WhenUnsatisfiable: whenUnsatisfiable,
+		LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
+		MaxSkew:           maxSkew,
+	}
+}
+
+// ZoneSpreadConstraint creates a topology spread constraint that spreads the pods matching the labels across zones.
+func ZoneSpreadConstraint(labels map[string]string, maxSkew int32, whenUnsatisfiable v1.UnsatisfiableConstraintAction) v1.TopologySpreadConstraint {
+	return TopologySpreadConstraint(v1.LabelTopologyZone, labels, maxSkew, whenUnsatisfiable)
+}
+
+// HostnameSpreadConstraint creates a topology spread constraint that spreads the pods matching the labels across nodes.
+func HostnameSpreadConstraint(labels map[string]string, maxSkew int32, whenUnsatisfiable v1.UnsatisfiableConstraintAction) v1.TopologySpreadConstraint {
+	return TopologySpreadConstraint(v1.LabelHostname, labels, maxSkew, whenUnsatisfiable)
+}
+
 // PodDisruptionBudget creates a PodDisruptionBudget.  To function properly, it should have its status applied
 func PodDisruptionBudget(overrides ...PDBOptions) *policyv1.PodDisruptionBudget {
 	options := PDBOptions{}
@@ -0,0 +1,189 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package property
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+
+	"github.com/samber/lo"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider/fake"
+	"sigs.k8s.io/karpenter/pkg/scheduling"
+	"sigs.k8s.io/karpenter/pkg/test"
+)
+
+// DefaultDomains are the label keys that generated requirements select on and the values that they select from. The
+// values match the ones of the generated instance types, so that generated pods are likely to be schedulable.
+var DefaultDomains = map[string][]string{
+	v1.LabelTopologyZone:           {"test-zone-1", "test-zone-2", "test-zone-3"},
+	v1.LabelArchStable:             {v1beta1.ArchitectureAmd64, v1beta1.ArchitectureArm64},
+	v1beta1.CapacityTypeLabelKey:   {v1beta1.CapacityTypeSpot, v1beta1.CapacityTypeOnDemand},
+	fake.IntegerInstanceLabelKey:   {"1", "2", "4", "8", "16", "32"},
+	"test.karpenter.sh/custom-key": {"a", "b", "c"},
+}
+
+// Generator creates random requirements, pods and instance types for property tests. It's deterministic for a given
+// seed, so that a violation can be reproduced from the seed alone.
+type Generator struct {
+	*rand.Rand
+	// Domains are the label keys that generated requirements select on and the values that they select from
+	Domains map[string][]string
+}
+
+func NewGenerator(seed int64) *Generator {
+	return &Generator{
+		Rand:    rand.New(rand.NewSource(seed)), //nolint:gosec
+		Domains: DefaultDomains,
+	}
+}
+
+// Keys returns the sorted label keys of the generator's domains
+func (g *Generator) Keys() []string {
+	keys := lo.Keys(g.Domains)
+	sort.Strings(keys)
+	return keys
+}
+
+// Key returns a random label key from the generator's domains
+func (g *Generator) Key() string {
+	keys := g.Keys()
+	return keys[g.Intn(len(keys))]
+}
+
+// Value returns a random value from the domain of the key
+func (g *Generator) Value(key string) string {
+	return g.Domains[key][g.Intn(len(g.Domains[key]))]
+}
+
+// Values returns a random, non-empty subset of the domain of the key
+func (g *Generator) Values(key string) []string {
+	return g.shuffle(g.Domains[key])[:1+g.Intn(len(g.Domains[key]))]
+}
+
+// NodeSelectorRequirement returns a random node selector requirement on the key. Gt and Lt are only used if all the
+// values in the domain of the key are integers.
+func (g *Generator) NodeSelectorRequirement(key string) v1.NodeSelectorRequirement {
+	operators := []v1.NodeSelectorOperator{v1.NodeSelectorOpIn, v1.NodeSelectorOpNotIn, v1.NodeSelectorOpExists, v1.NodeSelectorOpDoesNotExist}
+	if g.integers(key) {
+		operators = append(operators, v1.NodeSelectorOpGt, v1.NodeSelectorOpLt)
+	}
+	requirement := v1.NodeSelectorRequirement{Key: key, Operator: operators[g.Intn(len(operators))]}
+	switch requirement.Operator {
+	case v1.NodeSelectorOpIn, v1.NodeSelectorOpNotIn:
+		requirement.Values = g.Values(key)
+	case v1.NodeSelectorOpGt, v1.NodeSelectorOpLt:
+		requirement.Values = []string{g.Value(key)}
+	}
+	return requirement
+}
+
+// NodeSelectorRequirements returns up to three random node selector requirements on random keys. The same key may be
+// selected on more than once.
+func (g *Generator) NodeSelectorRequirements() []v1.NodeSelectorRequirement {
+	return lo.Times(g.Intn(4), func(_ int) v1.NodeSelectorRequirement {
+		return g.NodeSelectorRequirement(g.Key())
+	})
+}
+
+// Requirements returns random scheduling requirements
+func (g *Generator) Requirements() scheduling.Requirements {
+	return scheduling.NewNodeSelectorRequirements(g.NodeSelectorRequirements()...)
+}
+
+// Labels returns a random value for a random subset of the keys of the generator's domains
+func (g *Generator) Labels() map[string]string {
+	labels := map[string]string{}
+	for _, key := range g.Keys() {
+		if g.Intn(2) == 0 {
+			labels[key] = g.Value(key)
+		}
+	}
+	return labels
+}
+
+// Pod returns an unschedulable pod with random resource requests that requires random node selector requirements
+// through its node affinity
+func (g *Generator) Pod() *v1.Pod {
+	return test.UnschedulablePod(test.PodOptions{
+		NodeRequirements: g.NodeSelectorRequirements(),
+		ResourceRequirements: v1.ResourceRequirements{Requests: v1.ResourceList{
+			v1.ResourceCPU:    *resource.NewMilliQuantity(int64(100*(1+g.Intn(20))), resource.DecimalSI),
+			v1.ResourceMemory: *resource.NewQuantity(int64(128*(1+g.Intn(32)))*1024*1024, resource.BinarySI),
+		}},
+	})
+}
+
+// Pods returns count random pods
+func (g *Generator) Pods(count int) []*v1.Pod {
+	return lo.Times(count, func(_ int) *v1.Pod { return g.Pod() })
+}
+
+// InstanceType returns an instance type with the given name, a random architecture and size, and a random, non-empty
+// set of offerings
+func (g *Generator) InstanceType(name string) *cloudprovider.InstanceType {
+	cpu := g.Value(fake.IntegerInstanceLabelKey)
+	cpus, _ := strconv.Atoi(cpu)
+	resources := v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse(cpu),
+		v1.ResourceMemory: resource.MustParse(fmt.Sprintf("%dGi", cpus*4)),
+		v1.ResourcePods:   resource.MustParse("110"),
+	}
+	var offerings cloudprovider.Offerings
+	for _, zone := range g.Values(v1.LabelTopologyZone) {
+		for _, capacityType := range g.Values(v1beta1.CapacityTypeLabelKey) {
+			offerings = append(offerings, cloudprovider.Offering{
+				CapacityType: capacityType,
+				Zone:         zone,
+				Price:        fake.PriceFromResources(resources) * (0.5 + g.Float64()),
+				Available:    true,
+			})
+		}
+	}
+	return fake.NewInstanceType(fake.InstanceTypeOptions{
+		Name:         name,
+		Architecture: g.Value(v1.LabelArchStable),
+		Resources:    resources,
+		Offerings:    offerings,
+	})
+}
+
+// InstanceTypes returns count random instance types with unique names
+func (g *Generator) InstanceTypes(count int) []*cloudprovider.InstanceType {
+	return lo.Times(count, func(i int) *cloudprovider.InstanceType {
+		return g.InstanceType(fmt.Sprintf("random-instance-type-%d", i))
+	})
+}
+
+func (g *Generator) integers(key string) bool {
+	return lo.EveryBy(g.Domains[key], func(value string) bool {
+		_, err := strconv.Atoi(value)
+		return err == nil
+	})
+}
+
+func (g *Generator) shuffle(values []string) []string {
+	shuffled := append([]string{}, values...)
+	g.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	return shuffled
+}
@@ -0,0 +1,92 @@
+/*
+Copyright The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+//nolint:revive
+package property
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2" //nolint:revive,stylecheck
+	. "github.com/onsi/gomega"    //nolint:revive,stylecheck
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// Property is an invariant that must hold for any of the inputs that a Generator creates. It returns an error that
+// describes the violation, if any.
+type Property func(g *Generator) error
+
+// Check runs the property against generators seeded from seed through seed+iterations-1 and returns an error naming
+// the seed of the first violation, so that it can be reproduced with NewGenerator(seed).
+func Check(seed int64, iterations int, property Property) error {
+	for i := 0; i < iterations; i++ {
+		if err := property(NewGenerator(seed + int64(i))); err != nil {
+			return fmt.Errorf("property violated with seed %d, %w", seed+int64(i), err)
+		}
+	}
+	return nil
+}
+
+// ExpectProperty checks the property against the given number of generators, seeded from the Ginkgo random seed so
+// that a failing run can be reproduced with --seed
+func ExpectProperty(iterations int, property Property) {
+	GinkgoHelper()
+	Expect(Check(GinkgoRandomSeed(), iterations, property)).To(Succeed())
+}
+
+var operators = map[v1.NodeSelectorOperator]selection.Operator{
+	v1.NodeSelectorOpIn:           selection.In,
+	v1.NodeSelectorOpNotIn:        selection.NotIn,
+	v1.NodeSelectorOpExists:       selection.Exists,
+	v1.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+	v1.NodeSelectorOpGt:           selection.GreaterThan,
+	v1.NodeSelectorOpLt:           selection.LessThan,
+}
+
+// Matches returns true if the labels satisfy all the node selector requirements. The requirements are evaluated with
+// the Kubernetes label selector implementation rather than Karpenter's, so that it serves as an independent oracle.
+func Matches(lbls map[string]string, requirements ...v1.NodeSelectorRequirement) (bool, error) {
+	selector := labels.NewSelector()
+	for _, requirement := range requirements {
+		operator, ok := operators[requirement.Operator]
+		if !ok {
+			return false, fmt.Errorf("unsupported operator %q", requirement.Operator)
+		}
+		r, err := labels.NewRequirement(requirement.Key, operator, requirement.Values)
+		if err != nil {
+			return false, fmt.Errorf("converting requirement %s, %w", requirement, err)
+		}
+		selector = selector.Add(*r)
+	}
+	return selector.Matches(labels.Set(lbls)), nil
+}
+
+// NodeSelectorRequirements returns the node selector and the first required node affinity term of the pod as node
+// selector requirements, which are the requirements that Generator.Pod sets
+func NodeSelectorRequirements(pod *v1.Pod) []v1.NodeSelectorRequirement {
+	var requirements []v1.NodeSelectorRequirement
+	for key, value := range pod.Spec.NodeSelector {
+		requirements = append(requirements, v1.NodeSelectorRequirement{Key: key, Operator: v1.NodeSelectorOpIn, Values: []string{value}})
+	}
+	if affinity := pod.Spec.Affinity; affinity != nil && affinity.NodeAffinity != nil && affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		if terms := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms; len(terms) > 0 {
+			requirements = append(requirements, terms[0].MatchExpressions...)
+		}
+	}
+	return requirements
+}
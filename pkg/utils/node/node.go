@@ -19,9 +19,12 @@ package node
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/samber/lo"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"sigs.k8s.io/karpenter/pkg/utils/pod"
@@ -53,15 +56,72 @@ func GetReschedulablePods(ctx context.Context, kubeClient client.Client, nodes .
 	}), nil
 }
 
-// GetProvisionablePods grabs all the pods from the passed nodes that satisfy the IsProvisionable criteria
+// OrphanedPodGracePeriod is how long a pending pod must have been bound to a node that doesn't exist before it's
+// considered orphaned. This keeps pods that were just bound to a new node from looking orphaned while the node hasn't
+// reached the informer cache yet.
+const OrphanedPodGracePeriod = 30 * time.Second
+
+// GetProvisionablePods grabs all the pods that satisfy the IsProvisionable criteria, along with the pods that are
+// orphaned on a node that doesn't exist
 func GetProvisionablePods(ctx context.Context, kubeClient client.Client) ([]*v1.Pod, error) {
 	var podList v1.PodList
 	if err := kubeClient.List(ctx, &podList, client.MatchingFields{"spec.nodeName": ""}); err != nil {
 		return nil, fmt.Errorf("listing pods, %w", err)
 	}
-	return lo.FilterMap(podList.Items, func(p v1.Pod, _ int) (*v1.Pod, bool) {
+	pods := lo.FilterMap(podList.Items, func(p v1.Pod, _ int) (*v1.Pod, bool) {
 		return &p, pod.IsProvisionable(&p)
-	}), nil
+	})
+	orphaned, err := getOrphanedPods(ctx, kubeClient)
+	if err != nil {
+		return nil, err
+	}
+	return append(pods, orphaned...), nil
+}
+
+// getOrphanedPods grabs the pending pods that are orphaned on a node that doesn't exist. Only the pending pods are
+// listed, since the pods that are running or have completed are never orphaned.
+func getOrphanedPods(ctx context.Context, kubeClient client.Client) ([]*v1.Pod, error) {
+	var podList v1.PodList
+	if err := kubeClient.List(ctx, &podList, client.MatchingFields{"status.phase": string(v1.PodPending)}); err != nil {
+		return nil, fmt.Errorf("listing pods, %w", err)
+	}
+	var pods []*v1.Pod
+	for i := range podList.Items {
+		orphaned, _, err := IsOrphaned(ctx, kubeClient, &podList.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		if orphaned {
+			pods = append(pods, &podList.Items[i])
+		}
+	}
+	return pods, nil
+}
+
+// IsOrphaned returns true if the pod has been pending on a node that doesn't exist for longer than
+// OrphanedPodGracePeriod, which happens when a pod is created with a stale spec.nodeName. Pod bindings are immutable,
+// so these pods can never start. Provisioning for them launches the capacity that their replacement uses once they're
+// garbage collected, and only a replacement pod can use it. If the pod's node doesn't exist, but it was bound to it
+// too recently, the time after which it's considered orphaned is returned so that it can be checked again.
+func IsOrphaned(ctx context.Context, kubeClient client.Client, p *v1.Pod) (bool, time.Duration, error) {
+	if !pod.IsOrphaned(p) {
+		return false, 0, nil
+	}
+	if err := kubeClient.Get(ctx, types.NamespacedName{Name: p.Spec.NodeName}, &v1.Node{}); err != nil {
+		if !errors.IsNotFound(err) {
+			return false, 0, fmt.Errorf("getting node, %w", err)
+		}
+		// The pod is bound when it's scheduled, or when it's created if it was created with a spec.nodeName
+		bound := p.CreationTimestamp.Time
+		if cond, ok := lo.Find(p.Status.Conditions, func(c v1.PodCondition) bool { return c.Type == v1.PodScheduled }); ok {
+			bound = cond.LastTransitionTime.Time
+		}
+		if remaining := OrphanedPodGracePeriod - time.Since(bound); remaining > 0 {
+			return false, remaining, nil
+		}
+		return true, 0, nil
+	}
+	return false, 0, nil
 }
 
 func GetCondition(n *v1.Node, match v1.NodeConditionType) v1.NodeCondition {
@@ -31,6 +31,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"sigs.k8s.io/karpenter/pkg/apis/v1beta1"
+	"sigs.k8s.io/karpenter/pkg/cloudprovider"
 	"sigs.k8s.io/karpenter/pkg/scheduling"
 )
 
@@ -159,11 +160,12 @@ func IgnoreDuplicateNodeError(err error) error {
 }
 
 // NodeForNodeClaim is a helper function that takes a v1beta1.NodeClaim and attempts to find the matching v1.Node by its providerID
+// or, if the CloudProvider implements MatchNode, by the CloudProvider's match
 // This function will return errors if:
-//  1. No v1.Nodes match the v1beta1.NodeClaim providerID
-//  2. Multiple v1.Nodes match the v1beta1.NodeClaim providerID
-func NodeForNodeClaim(ctx context.Context, c client.Client, nodeClaim *v1beta1.NodeClaim) (*v1.Node, error) {
-	nodes, err := AllNodesForNodeClaim(ctx, c, nodeClaim)
+//  1. No v1.Nodes match the v1beta1.NodeClaim
+//  2. Multiple v1.Nodes match the v1beta1.NodeClaim
+func NodeForNodeClaim(ctx context.Context, c client.Client, cloudProvider cloudprovider.CloudProvider, nodeClaim *v1beta1.NodeClaim) (*v1.Node, error) {
+	nodes, err := AllNodesForNodeClaim(ctx, c, cloudProvider, nodeClaim)
 	if err != nil {
 		return nil, err
 	}
@@ -178,7 +180,10 @@ func NodeForNodeClaim(ctx context.Context, c client.Client, nodeClaim *v1beta1.N
 
 // AllNodesForNodeClaim is a helper function that takes a v1beta1.NodeClaim and finds ALL matching v1.Nodes by their providerID
 // If the providerID is not resolved for a NodeClaim, then no Nodes will map to it
-func AllNodesForNodeClaim(ctx context.Context, c client.Client, nodeClaim *v1beta1.NodeClaim) ([]*v1.Node, error) {
+// If no Node has a matching providerID and the CloudProvider implements MatchNode, the CloudProvider is given the chance to
+// match the NodeClaim to the Node that it's registered to or, before it's registered, to the Nodes that aren't registered
+// to another NodeClaim
+func AllNodesForNodeClaim(ctx context.Context, c client.Client, cloudProvider cloudprovider.CloudProvider, nodeClaim *v1beta1.NodeClaim) ([]*v1.Node, error) {
 	// NodeClaims that have no resolved providerID have no nodes mapped to them
 	if nodeClaim.Status.ProviderID == "" {
 		return nil, nil
@@ -187,7 +192,32 @@ func AllNodesForNodeClaim(ctx context.Context, c client.Client, nodeClaim *v1bet
 	if err := c.List(ctx, &nodeList, client.MatchingFields{"spec.providerID": nodeClaim.Status.ProviderID}); err != nil {
 		return nil, fmt.Errorf("listing nodes, %w", err)
 	}
-	return lo.ToSlicePtr(nodeList.Items), nil
+	if len(nodeList.Items) > 0 {
+		return lo.ToSlicePtr(nodeList.Items), nil
+	}
+	matcher, ok := cloudprovider.As[cloudprovider.NodeMatcher](cloudProvider)
+	if !ok {
+		return nil, nil
+	}
+	if nodeClaim.Status.NodeName != "" {
+		node := &v1.Node{}
+		if err := c.Get(ctx, types.NamespacedName{Name: nodeClaim.Status.NodeName}, node); err != nil {
+			if err = client.IgnoreNotFound(err); err != nil {
+				return nil, fmt.Errorf("getting node, %w", err)
+			}
+			return nil, nil
+		}
+		if !matcher.MatchNode(nodeClaim, node) {
+			return nil, nil
+		}
+		return []*v1.Node{node}, nil
+	}
+	if err := c.List(ctx, &nodeList, client.MatchingFields{"metadata.labels.registered": ""}); err != nil {
+		return nil, fmt.Errorf("listing nodes, %w", err)
+	}
+	return lo.Filter(lo.ToSlicePtr(nodeList.Items), func(n *v1.Node, _ int) bool {
+		return matcher.MatchNode(nodeClaim, n)
+	}), nil
 }
 
 // NewFromNode converts a node into a pseudo-NodeClaim using known values from the node
@@ -222,6 +252,23 @@ func NewFromNode(node *v1.Node) *v1beta1.NodeClaim {
 	return nc
 }
 
+// RequirementsFromNode reconstructs the scheduling requirements that a node satisfies from its well-known labels
+// (e.g. instance type, zone, architecture, operating system, and capacity type). Aliased labels are normalized to their
+// well-known equivalents and well-known labels that aren't set on the node are omitted from the requirements.
+func RequirementsFromNode(node *v1.Node) scheduling.Requirements {
+	requirements := scheduling.NewRequirements()
+	for key, value := range node.Labels {
+		if normalized, ok := v1beta1.NormalizedLabels[key]; ok {
+			key = normalized
+		}
+		if !v1beta1.WellKnownLabels.Has(key) {
+			continue
+		}
+		requirements.Add(scheduling.NewRequirement(key, v1.NodeSelectorOpIn, value))
+	}
+	return requirements
+}
+
 func UpdateNodeOwnerReferences(nodeClaim *v1beta1.NodeClaim, node *v1.Node) *v1.Node {
 	node.OwnerReferences = append(node.OwnerReferences, metav1.OwnerReference{
 		APIVersion:         v1beta1.SchemeGroupVersion.String(),
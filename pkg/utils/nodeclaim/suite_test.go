@@ -152,4 +152,52 @@ var _ = Describe("NodeClaimUtils", func() {
 			BlockOwnerDeletion: lo.ToPtr(true),
 		}))
 	})
+	Context("RequirementsFromNode", func() {
+		It("should reconstruct requirements from the well-known labels of a node", func() {
+			node.Labels[v1.LabelArchStable] = v1beta1.ArchitectureAmd64
+			requirements := nodeclaimutil.RequirementsFromNode(node)
+			Expect(requirements.Get(v1.LabelInstanceTypeStable).Values()).To(ConsistOf("test-instance-type"))
+			Expect(requirements.Get(v1.LabelTopologyZone).Values()).To(ConsistOf("test-zone-1"))
+			Expect(requirements.Get(v1.LabelTopologyRegion).Values()).To(ConsistOf("test-region"))
+			Expect(requirements.Get(v1.LabelArchStable).Values()).To(ConsistOf(v1beta1.ArchitectureAmd64))
+			Expect(requirements.Get(v1.LabelOSStable).Values()).To(ConsistOf("linux"))
+			Expect(requirements.Get(v1beta1.CapacityTypeLabelKey).Values()).To(ConsistOf(v1beta1.CapacityTypeOnDemand))
+			Expect(requirements.Get(v1beta1.NodePoolLabelKey).Values()).To(ConsistOf("default"))
+		})
+		It("should not include labels that aren't well-known", func() {
+			requirements := nodeclaimutil.RequirementsFromNode(node)
+			Expect(requirements.Has("test-label-key")).To(BeFalse())
+			Expect(requirements.Has("test-label-key2")).To(BeFalse())
+			Expect(requirements.Has(v1beta1.NodeRegisteredLabelKey)).To(BeFalse())
+			Expect(requirements.Has(v1beta1.NodeInitializedLabelKey)).To(BeFalse())
+		})
+		It("should omit well-known labels that are missing from the node", func() {
+			delete(node.Labels, v1.LabelTopologyZone)
+			delete(node.Labels, v1beta1.CapacityTypeLabelKey)
+			requirements := nodeclaimutil.RequirementsFromNode(node)
+			Expect(requirements.Has(v1.LabelTopologyZone)).To(BeFalse())
+			Expect(requirements.Has(v1beta1.CapacityTypeLabelKey)).To(BeFalse())
+			Expect(requirements.Has(v1.LabelArchStable)).To(BeFalse())
+			Expect(requirements.Get(v1.LabelInstanceTypeStable).Values()).To(ConsistOf("test-instance-type"))
+		})
+		It("should return empty requirements for a node without labels", func() {
+			Expect(nodeclaimutil.RequirementsFromNode(test.Node())).To(BeEmpty())
+		})
+		It("should normalize aliased labels to their well-known equivalents", func() {
+			node = test.Node(test.NodeOptions{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						v1.LabelFailureDomainBetaZone: "test-zone-2",
+						v1.LabelInstanceType:          "test-instance-type",
+						"beta.kubernetes.io/arch":     v1beta1.ArchitectureArm64,
+					},
+				},
+			})
+			requirements := nodeclaimutil.RequirementsFromNode(node)
+			Expect(requirements.Get(v1.LabelTopologyZone).Values()).To(ConsistOf("test-zone-2"))
+			Expect(requirements.Get(v1.LabelInstanceTypeStable).Values()).To(ConsistOf("test-instance-type"))
+			Expect(requirements.Get(v1.LabelArchStable).Values()).To(ConsistOf(v1beta1.ArchitectureArm64))
+			Expect(requirements.Has(v1.LabelFailureDomainBetaZone)).To(BeFalse())
+		})
+	})
 })
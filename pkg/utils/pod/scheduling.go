@@ -17,8 +17,12 @@ limitations under the License.
 package pod
 
 import (
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/samber/lo"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/utils/clock"
@@ -46,7 +50,7 @@ func IsReschedulable(pod *v1.Pod) bool {
 	// differently for higher availability by considering terminating pods for scheduling
 	return (IsActive(pod) || (IsOwnedByStatefulSet(pod) && IsTerminating(pod))) &&
 		!IsOwnedByDaemonSet(pod) &&
-		!IsOwnedByNode(pod)
+		!IsMirror(pod)
 }
 
 // IsEvictable checks if a pod is evictable by Karpenter by ensuring that the pod:
@@ -56,7 +60,7 @@ func IsReschedulable(pod *v1.Pod) bool {
 func IsEvictable(pod *v1.Pod) bool {
 	return IsActive(pod) &&
 		!ToleratesDisruptionNoScheduleTaint(pod) &&
-		!IsOwnedByNode(pod)
+		!IsMirror(pod)
 }
 
 // IsWaitingEviction checks if this is a pod that we are waiting to be removed from the node by ensuring that the pod:
@@ -71,7 +75,7 @@ func IsWaitingEviction(pod *v1.Pod, clk clock.Clock) bool {
 		// Mirror pods cannot be deleted through the API server since they are created and managed by kubelet
 		// This means they are effectively read-only and can't be controlled by API server calls
 		// https://kubernetes.io/docs/reference/generated/kubectl/kubectl-commands#drain
-		!IsOwnedByNode(pod)
+		!IsMirror(pod)
 }
 
 // IsProvisionable checks if a pod needs to be scheduled to new capacity by Karpenter by ensuring that the pod:
@@ -85,7 +89,21 @@ func IsProvisionable(pod *v1.Pod) bool {
 		!IsScheduled(pod) &&
 		!IsPreempting(pod) &&
 		!IsOwnedByDaemonSet(pod) &&
-		!IsOwnedByNode(pod)
+		!IsMirror(pod)
+}
+
+// IsOrphaned checks if a pod that is bound to a node could be waiting on capacity if that node doesn't exist. It checks
+// whether the following is true for the pod:
+// - Is bound to a node
+// - Is still pending and isn't actively terminating
+// - Isn't owned by a DaemonSet
+// - Isn't a mirror pod (https://kubernetes.io/docs/tasks/configure-pod-container/static-pod/)
+func IsOrphaned(pod *v1.Pod) bool {
+	return IsScheduled(pod) &&
+		pod.Status.Phase == v1.PodPending &&
+		!IsTerminating(pod) &&
+		!IsOwnedByDaemonSet(pod) &&
+		!IsMirror(pod)
 }
 
 // IsDisruptable checks if a pod can be disrupted based on validating the `karpenter.sh/do-not-disrupt` annotation on the pod.
@@ -135,18 +153,58 @@ func IsStuckTerminating(pod *v1.Pod, clk clock.Clock) bool {
 	return IsTerminating(pod) && clk.Since(pod.DeletionTimestamp.Time) > time.Minute
 }
 
+// IsPastTerminationGracePeriod checks if the pod is terminating and its termination grace period has elapsed
+func IsPastTerminationGracePeriod(pod *v1.Pod, clk clock.Clock) bool {
+	return IsTerminating(pod) && !clk.Now().Before(pod.DeletionTimestamp.Time)
+}
+
+// IsNearActiveDeadline checks if an active pod will be failed by the kubelet for exceeding its
+// spec.activeDeadlineSeconds within the threshold
+func IsNearActiveDeadline(pod *v1.Pod, clk clock.Clock, threshold time.Duration) bool {
+	if !IsActive(pod) || pod.Spec.ActiveDeadlineSeconds == nil || pod.Status.StartTime == nil {
+		return false
+	}
+	deadline := pod.Status.StartTime.Add(time.Duration(*pod.Spec.ActiveDeadlineSeconds) * time.Second)
+	return deadline.Sub(clk.Now()) <= threshold
+}
+
 func IsOwnedByStatefulSet(pod *v1.Pod) bool {
 	return IsOwnedBy(pod, []schema.GroupVersionKind{
 		{Group: "apps", Version: "v1", Kind: "StatefulSet"},
 	})
 }
 
+// StatefulSetOrdinal returns the ordinal of a pod that's owned by a StatefulSet. The ordinal is read from the pod index
+// label, falling back to the suffix of the pod's name for pods that were created before the label was introduced.
+func StatefulSetOrdinal(pod *v1.Pod) (int, bool) {
+	if !IsOwnedByStatefulSet(pod) {
+		return 0, false
+	}
+	value, ok := pod.Labels[appsv1.PodIndexLabel]
+	if !ok {
+		value = pod.Name[strings.LastIndex(pod.Name, "-")+1:]
+	}
+	ordinal, err := strconv.Atoi(value)
+	if err != nil || ordinal < 0 {
+		return 0, false
+	}
+	return ordinal, true
+}
+
 func IsOwnedByDaemonSet(pod *v1.Pod) bool {
 	return IsOwnedBy(pod, []schema.GroupVersionKind{
 		{Group: "apps", Version: "v1", Kind: "DaemonSet"},
 	})
 }
 
+// IsMirror returns true if the pod is the mirror pod of a static pod (https://kubernetes.io/docs/tasks/configure-pod-container/static-pod/).
+// Mirror pods are usually owned by their node, but not every kubelet sets the owner reference, so the mirror pod
+// annotation that the kubelet always sets is checked as well.
+func IsMirror(pod *v1.Pod) bool {
+	_, ok := pod.Annotations[v1.MirrorPodAnnotationKey]
+	return ok || IsOwnedByNode(pod)
+}
+
 // IsOwnedByNode returns true if the pod is a static pod owned by a specific node
 func IsOwnedByNode(pod *v1.Pod) bool {
 	return IsOwnedBy(pod, []schema.GroupVersionKind{
@@ -174,6 +232,16 @@ func HasDoNotDisrupt(pod *v1.Pod) bool {
 		pod.Annotations[v1beta1.DoNotDisruptAnnotationKey] == "true"
 }
 
+// IsDedicated returns true if the pod requested a node of its own with the karpenter.sh/dedicated annotation
+func IsDedicated(pod *v1.Pod) bool {
+	return pod.Annotations[v1beta1.DedicatedAnnotationKey] == "true"
+}
+
+// ToleratesDedicatedNoScheduleTaint returns true if the pod tolerates karpenter.sh/dedicated:NoSchedule=true taint
+func ToleratesDedicatedNoScheduleTaint(pod *v1.Pod) bool {
+	return scheduling.Taints([]v1.Taint{v1beta1.DedicatedNoScheduleTaint}).Tolerates(pod) == nil
+}
+
 // ToleratesDisruptionNoScheduleTaint returns true if the pod tolerates karpenter.sh/disruption:NoSchedule=Disrupting taint
 func ToleratesDisruptionNoScheduleTaint(pod *v1.Pod) bool {
 	return scheduling.Taints([]v1.Taint{v1beta1.DisruptionNoScheduleTaint}).Tolerates(pod) == nil
@@ -192,3 +260,19 @@ func HasPodAntiAffinity(pod *v1.Pod) bool {
 		(len(pod.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution) != 0 ||
 			len(pod.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution) != 0)
 }
+
+// DisruptionTolerationSeconds returns the shortest tolerationSeconds of the pod's tolerations for the
+// karpenter.sh/disruption=disrupting taint, and false if none of them are bounded by tolerationSeconds. Karpenter
+// applies the taint as NoSchedule while the apiserver only allows tolerationSeconds on NoExecute tolerations, so the
+// tolerations are matched on the key and value of the taint regardless of their effect.
+func DisruptionTolerationSeconds(pod *v1.Pod) (int64, bool) {
+	taint := v1beta1.DisruptionNoScheduleTaint
+	seconds := lo.FilterMap(pod.Spec.Tolerations, func(t v1.Toleration, _ int) (int64, bool) {
+		t.Effect = ""
+		return lo.FromPtr(t.TolerationSeconds), t.TolerationSeconds != nil && t.ToleratesTaint(&taint)
+	})
+	if len(seconds) == 0 {
+		return 0, false
+	}
+	return lo.Min(seconds), true
+}
@@ -124,6 +124,8 @@ func podRequests(pod *v1.Pod) v1.ResourceList {
 	// The container's needed requests are the max of all of the container requests combined with native sidecar container requests OR the requests required for a large init containers with native sidecar container requests to run
 	requests = MaxResources(requests, maxInitContainerReqs)
 
+	// The pod overhead is populated at admission from the overhead of the pod's RuntimeClass, so it already accounts
+	// for sandboxed runtimes without needing to resolve the RuntimeClass here
 	if pod.Spec.Overhead != nil {
 		MergeInto(requests, pod.Spec.Overhead)
 	}
@@ -184,6 +186,52 @@ func MaxResources(resources ...v1.ResourceList) v1.ResourceList {
 	return resourceList
 }
 
+// MinResources returns the minimum quantities for a given list of resources. A resource that is missing from any of
+// the lists is treated as zero and omitted from the result.
+func MinResources(resources ...v1.ResourceList) v1.ResourceList {
+	resourceList := v1.ResourceList{}
+	if len(resources) == 0 {
+		return resourceList
+	}
+	for resourceName, quantity := range resources[0] {
+		min := quantity.DeepCopy()
+		found := true
+		for _, resource := range resources[1:] {
+			value, ok := resource[resourceName]
+			if !ok {
+				found = false
+				break
+			}
+			if value.Cmp(min) < 0 {
+				min = value.DeepCopy()
+			}
+		}
+		if found {
+			resourceList[resourceName] = min
+		}
+	}
+	return resourceList
+}
+
+// RoundUp rounds each quantity up to the nearest multiple of the granularity for that resource. Resources without a
+// positive granularity are returned unchanged.
+func RoundUp(resources v1.ResourceList, granularity v1.ResourceList) v1.ResourceList {
+	resourceList := v1.ResourceList{}
+	for resourceName, quantity := range resources {
+		step, ok := granularity[resourceName]
+		if !ok || step.Sign() <= 0 {
+			resourceList[resourceName] = quantity.DeepCopy()
+			continue
+		}
+		value, stepValue := quantity.MilliValue(), step.MilliValue()
+		if remainder := value % stepValue; remainder != 0 {
+			value += stepValue - remainder
+		}
+		resourceList[resourceName] = *resource.NewMilliQuantity(value, quantity.Format)
+	}
+	return resourceList
+}
+
 // MergeResourceLimitsIntoRequests merges resource limits into requests if no request exists for the given resource
 func MergeResourceLimitsIntoRequests(container v1.Container) v1.ResourceList {
 	resources := container.Resources.DeepCopy()
@@ -100,6 +100,20 @@ var _ = Describe("Resources", func() {
 				v1.ResourceMemory: resource.MustParse("5Gi"),
 			})
 		})
+		It("should include the overhead of the pod's runtime class in the resource requests", func() {
+			pod := test.Pod(test.PodOptions{
+				Overhead: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")},
+				ResourceRequirements: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("1Gi")},
+				},
+			})
+			pod.Spec.RuntimeClassName = lo.ToPtr("sandboxed-runtime-class")
+			ExpectResources(resources.RequestsForPods(pod), v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("3"),
+				v1.ResourceMemory: resource.MustParse("1Gi"),
+				v1.ResourcePods:   resource.MustParse("1"),
+			})
+		})
 		It("should calculate resource requests when there is an initContainer after a sidecarContainer that exceeds container resource requests", func() {
 			pod := test.Pod(test.PodOptions{
 				ResourceRequirements: v1.ResourceRequirements{
@@ -567,6 +581,51 @@ var _ = Describe("Resources", func() {
 			})
 		})
 	})
+	Context("Resource Minimum", func() {
+		It("should take the minimum of each resource", func() {
+			ExpectResources(resources.MinResources(
+				v1.ResourceList{v1.ResourceCPU: resource.MustParse("2"), v1.ResourceMemory: resource.MustParse("1Gi")},
+				v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("2Gi")},
+			), v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("1"),
+				v1.ResourceMemory: resource.MustParse("1Gi"),
+			})
+		})
+		It("should omit resources that are missing from any list", func() {
+			min := resources.MinResources(
+				v1.ResourceList{v1.ResourceCPU: resource.MustParse("2"), v1.ResourcePods: resource.MustParse("10")},
+				v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+			)
+			Expect(min).To(HaveLen(1))
+			ExpectResources(min, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+		})
+	})
+	Context("Resource Rounding", func() {
+		It("should round quantities up to the granularity", func() {
+			ExpectResources(resources.RoundUp(
+				v1.ResourceList{v1.ResourceCPU: resource.MustParse("37m"), v1.ResourceMemory: resource.MustParse("100Mi")},
+				v1.ResourceList{v1.ResourceCPU: resource.MustParse("50m"), v1.ResourceMemory: resource.MustParse("64Mi")},
+			), v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("50m"),
+				v1.ResourceMemory: resource.MustParse("128Mi"),
+			})
+		})
+		It("should not change quantities that are already a multiple of the granularity", func() {
+			ExpectResources(resources.RoundUp(
+				v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+				v1.ResourceList{v1.ResourceCPU: resource.MustParse("250m")},
+			), v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")})
+		})
+		It("should not change resources without a granularity", func() {
+			ExpectResources(resources.RoundUp(
+				v1.ResourceList{v1.ResourceCPU: resource.MustParse("37m"), v1.ResourcePods: resource.MustParse("1")},
+				v1.ResourceList{v1.ResourceMemory: resource.MustParse("64Mi")},
+			), v1.ResourceList{
+				v1.ResourceCPU:  resource.MustParse("37m"),
+				v1.ResourcePods: resource.MustParse("1"),
+			})
+		})
+	})
 	Context("Resource Merging", func() {
 		It("should merge resource limits into requests if no request exists for the given container", func() {
 			container := v1.Container{